@@ -47,7 +47,7 @@ func (s *ApiKeyService) generatePublicID() (string, error) {
 }
 
 func (s *ApiKeyService) HashKey(ctx context.Context, key string) string {
-	h := hmac.New(sha256.New, []byte(environment_variables.EnvironmentVariables.APIKEY_SECRET))
+	h := hmac.New(sha256.New, []byte(environment_variables.Current().APIKEY_SECRET))
 	h.Write([]byte(key))
 
 	return hex.EncodeToString(h.Sum(nil))
@@ -67,7 +67,7 @@ func (s *AuthService) InitOrganization(ctx context.Context) error {
 	// set DEFAULT_ORGANIZATION
 	organization.UpdateDefaultOrganization(orgEntity)
 
-	emails := environment_variables.EnvironmentVariables.ORGANIZATION_ADMIN_EMAILS
+	emails := environment_variables.Current().ORGANIZATION_ADMIN_EMAILS
 	if len(emails) == 0 {
 		return fmt.Errorf("no ORGANIZATION_ADMIN_EMAILS configured")
 	}
@@ -94,7 +94,7 @@ func (s *AuthService) InitOrganization(ctx context.Context) error {
 			}
 		}
 
-		if password := environment_variables.EnvironmentVariables.LOCAL_ADMIN_PASSWORD; password != "" {
+		if password := environment_variables.Current().LOCAL_ADMIN_PASSWORD; password != "" {
 			if err := s.SetUserPassword(ctx, admin, password); err != nil {
 				return err
 			}
@@ -217,15 +217,7 @@ func (s *AuthService) JWTAuthMiddleware() gin.HandlerFunc {
 // Retrieve the user's public ID from the header.
 func (s *AuthService) AppUserAuthMiddleware() gin.HandlerFunc {
 	return func(reqCtx *gin.Context) {
-		userId, ok := s.getUserPublicIDFromJWT(reqCtx)
-		if ok {
-			SetUserIDToContext(reqCtx, userId)
-			reqCtx.Next()
-			return
-		}
-		userId, ok = s.getUserIDFromApikey(reqCtx)
-		if ok {
-			SetUserIDToContext(reqCtx, userId)
+		if s.AuthenticateAppUser(reqCtx) {
 			reqCtx.Next()
 			return
 		}
@@ -236,6 +228,22 @@ func (s *AuthService) AppUserAuthMiddleware() gin.HandlerFunc {
 	}
 }
 
+// AuthenticateAppUser resolves the user of the JWT or API key of the request without aborting it,
+// for routes where only some features require authentication
+func (s *AuthService) AuthenticateAppUser(reqCtx *gin.Context) bool {
+	userId, ok := s.getUserPublicIDFromJWT(reqCtx)
+	if ok {
+		SetUserIDToContext(reqCtx, userId)
+		return true
+	}
+	userId, ok = s.getUserIDFromApikey(reqCtx)
+	if ok {
+		SetUserIDToContext(reqCtx, userId)
+		return true
+	}
+	return false
+}
+
 func (s *AuthService) AdminUserAuthMiddleware() gin.HandlerFunc {
 	return func(reqCtx *gin.Context) {
 		userId, ok := s.getUserPublicIDFromJWT(reqCtx)
@@ -359,7 +367,7 @@ func (s *AuthService) getUserPublicIDFromJWT(reqCtx *gin.Context) (string, bool)
 		return "", false
 	}
 	token, err := jwt.ParseWithClaims(tokenString, &UserClaim{}, func(token *jwt.Token) (interface{}, error) {
-		return environment_variables.EnvironmentVariables.JWT_SECRET, nil
+		return environment_variables.Current().JWT_SECRET, nil
 	})
 	if err != nil || !token.Valid {
 		return "", false
@@ -392,6 +400,7 @@ func (s *AuthService) getUserIDFromApikey(reqCtx *gin.Context) (string, bool) {
 	if apikeyEntity == nil || apikeyEntity.ApikeyType == string(apikey.ApikeyTypeAdmin) {
 		return "", false
 	}
+	SetAppApiKeyToContext(reqCtx, apikeyEntity)
 	return apikeyEntity.OwnerPublicID, true
 }
 
@@ -447,8 +456,9 @@ func SetUserIDToContext(reqCtx *gin.Context, v string) {
 type ApikeyContextKey string
 
 const (
-	ApikeyContextKeyEntity   ApikeyContextKey = "ApikeyContextKeyEntity"
-	ApikeyContextKeyPublicID ApikeyContextKey = "apikey_public_id"
+	ApikeyContextKeyEntity    ApikeyContextKey = "ApikeyContextKeyEntity"
+	ApikeyContextKeyPublicID  ApikeyContextKey = "apikey_public_id"
+	ApikeyContextKeyAppEntity ApikeyContextKey = "ApikeyContextKeyAppEntity"
 )
 
 func (s *AuthService) GetAdminApiKeyFromQuery() gin.HandlerFunc {
@@ -512,6 +522,36 @@ func SetAdminKeyToContext(reqCtx *gin.Context, apiKey *apikey.ApiKey) {
 	reqCtx.Set(string(ApikeyContextKeyEntity), apiKey)
 }
 
+// GetAppApiKeyFromContext returns the non-admin API key the request authenticated with
+func GetAppApiKeyFromContext(reqCtx *gin.Context) (*apikey.ApiKey, bool) {
+	apiKey, ok := reqCtx.Get(string(ApikeyContextKeyAppEntity))
+	if !ok {
+		return nil, false
+	}
+	v, ok := apiKey.(*apikey.ApiKey)
+	if !ok {
+		return nil, false
+	}
+	return v, true
+}
+
+func SetAppApiKeyToContext(reqCtx *gin.Context, apiKey *apikey.ApiKey) {
+	reqCtx.Set(string(ApikeyContextKeyAppEntity), apiKey)
+	// the services reached through the request context act for the organization of the key
+	if apiKey.OrganizationID != nil {
+		reqCtx.Request = reqCtx.Request.WithContext(organization.WithOrganizationID(reqCtx.Request.Context(), *apiKey.OrganizationID))
+	}
+}
+
+// GetRequestProjectID returns the project of the API key the request authenticated with, nil for user tokens
+func GetRequestProjectID(reqCtx *gin.Context) *uint {
+	apiKey, ok := GetAppApiKeyFromContext(reqCtx)
+	if !ok {
+		return nil
+	}
+	return apiKey.ProjectID
+}
+
 type OrganizationContextKey string
 
 const (
@@ -22,7 +22,7 @@ type UserClaim struct {
 
 func CreateJwtSignedString(u UserClaim) (string, error) {
 	token := jwt.NewWithClaims(jwt.SigningMethodHS512, u)
-	return token.SignedString(environment_variables.EnvironmentVariables.JWT_SECRET)
+	return token.SignedString(environment_variables.Current().JWT_SECRET)
 }
 
 func GetUserClaimFromRequestContext(reqCtx *gin.Context) (*UserClaim, error) {
@@ -44,7 +44,7 @@ func GetUserClaimFromRefreshToken(reqCtx *gin.Context) (*UserClaim, bool) {
 	}
 
 	token, err := jwt.ParseWithClaims(refreshTokenString, &UserClaim{}, func(token *jwt.Token) (interface{}, error) {
-		return environment_variables.EnvironmentVariables.JWT_SECRET, nil
+		return environment_variables.Current().JWT_SECRET, nil
 	})
 	if err != nil {
 		return nil, false
@@ -0,0 +1,54 @@
+package completioncache
+
+import (
+	"context"
+	"time"
+)
+
+// @Enum(exact, semantic)
+type Mode string
+
+const (
+	// ModeExact serves a cached completion only for a request identical to the cached one
+	ModeExact Mode = "exact"
+	// ModeSemantic also serves the completion of a prompt whose embedding is close enough to the request
+	ModeSemantic Mode = "semantic"
+)
+
+const (
+	DefaultTTL                 = time.Hour
+	MaxTTL                     = 30 * 24 * time.Hour
+	DefaultSimilarityThreshold = 0.95
+	MinSimilarityThreshold     = 0.5
+)
+
+// Settings control the completion cache of a project, projects without settings are not cached
+type Settings struct {
+	ID        uint
+	ProjectID uint
+	Enabled   bool
+	Mode      Mode
+	// TTLSeconds is how long a completion stays cached
+	TTLSeconds int
+	// SimilarityThreshold is the cosine similarity a semantic match needs
+	SimilarityThreshold float64
+	// EmbeddingModel embeds the prompts of the semantic mode, empty uses the vector store model
+	EmbeddingModel string
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// TTL returns the expiration of the cached completions
+func (s *Settings) TTL() time.Duration {
+	if s.TTLSeconds <= 0 {
+		return DefaultTTL
+	}
+	return time.Duration(s.TTLSeconds) * time.Second
+}
+
+// SettingsRepository stores one settings row per project
+type SettingsRepository interface {
+	FindByProjectID(ctx context.Context, projectID uint) (*Settings, error)
+	// Upsert creates or replaces the settings of the project
+	Upsert(ctx context.Context, settings *Settings) error
+}
@@ -0,0 +1,72 @@
+package completioncache
+
+import (
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// replayChunkRunes is the size of the content deltas of a replayed completion
+const replayChunkRunes = 32
+
+// StreamChunks splits a cached completion into the chunks a streamed completion sends: the role, the
+// reasoning and content deltas, the tool calls, the finish reason and, when asked for, the usage
+func StreamChunks(response *openai.ChatCompletionResponse, includeUsage bool) []openai.ChatCompletionStreamResponse {
+	chunk := func(choices []openai.ChatCompletionStreamChoice) openai.ChatCompletionStreamResponse {
+		return openai.ChatCompletionStreamResponse{
+			ID:                response.ID,
+			Object:            "chat.completion.chunk",
+			Created:           response.Created,
+			Model:             response.Model,
+			SystemFingerprint: response.SystemFingerprint,
+			Choices:           choices,
+		}
+	}
+
+	var chunks []openai.ChatCompletionStreamResponse
+	for _, choice := range response.Choices {
+		message := choice.Message
+		delta := func(d openai.ChatCompletionStreamChoiceDelta) {
+			chunks = append(chunks, chunk([]openai.ChatCompletionStreamChoice{{Index: choice.Index, Delta: d}}))
+		}
+
+		delta(openai.ChatCompletionStreamChoiceDelta{Role: openai.ChatMessageRoleAssistant})
+		for _, piece := range splitRunes(message.ReasoningContent, replayChunkRunes) {
+			delta(openai.ChatCompletionStreamChoiceDelta{ReasoningContent: piece})
+		}
+		for _, piece := range splitRunes(message.Content, replayChunkRunes) {
+			delta(openai.ChatCompletionStreamChoiceDelta{Content: piece})
+		}
+		if message.FunctionCall != nil {
+			delta(openai.ChatCompletionStreamChoiceDelta{FunctionCall: message.FunctionCall})
+		}
+		if len(message.ToolCalls) > 0 {
+			toolCalls := make([]openai.ToolCall, len(message.ToolCalls))
+			for i, call := range message.ToolCalls {
+				index := i
+				call.Index = &index
+				toolCalls[i] = call
+			}
+			delta(openai.ChatCompletionStreamChoiceDelta{ToolCalls: toolCalls})
+		}
+		chunks = append(chunks, chunk([]openai.ChatCompletionStreamChoice{{
+			Index:        choice.Index,
+			FinishReason: choice.FinishReason,
+		}}))
+	}
+
+	if includeUsage {
+		usage := response.Usage
+		last := chunk([]openai.ChatCompletionStreamChoice{})
+		last.Usage = &usage
+		chunks = append(chunks, last)
+	}
+	return chunks
+}
+
+func splitRunes(text string, size int) []string {
+	runes := []rune(text)
+	pieces := make([]string, 0, len(runes)/size+1)
+	for start := 0; start < len(runes); start += size {
+		pieces = append(pieces, string(runes[start:min(start+size, len(runes))]))
+	}
+	return pieces
+}
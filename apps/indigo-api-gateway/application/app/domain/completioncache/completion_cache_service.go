@@ -0,0 +1,343 @@
+package completioncache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	openai "github.com/sashabaranov/go-openai"
+	"menlo.ai/indigo-api-gateway/app/domain/common"
+	domainmodel "menlo.ai/indigo-api-gateway/app/domain/model"
+	"menlo.ai/indigo-api-gateway/app/domain/organization"
+	"menlo.ai/indigo-api-gateway/app/domain/vectorstore"
+	"menlo.ai/indigo-api-gateway/app/infrastructure/cache"
+	"menlo.ai/indigo-api-gateway/app/infrastructure/inference"
+	"menlo.ai/indigo-api-gateway/app/utils/logger"
+)
+
+const (
+	// maxSemanticEntries caps the prompts compared for one set of request parameters
+	maxSemanticEntries = 256
+	// maxPromptRunes is the tail of the prompt that is embedded
+	maxPromptRunes = 8000
+)
+
+type CompletionCacheService struct {
+	repo              SettingsRepository
+	cache             *cache.RedisCacheService
+	providerRegistry  *domainmodel.ProviderRegistryService
+	inferenceProvider *inference.InferenceProvider
+}
+
+func NewCompletionCacheService(
+	repo SettingsRepository,
+	cacheService *cache.RedisCacheService,
+	providerRegistry *domainmodel.ProviderRegistryService,
+	inferenceProvider *inference.InferenceProvider,
+) *CompletionCacheService {
+	return &CompletionCacheService{
+		repo:              repo,
+		cache:             cacheService,
+		providerRegistry:  providerRegistry,
+		inferenceProvider: inferenceProvider,
+	}
+}
+
+func defaultSettings(projectID uint) *Settings {
+	return &Settings{
+		ProjectID:           projectID,
+		Enabled:             false,
+		Mode:                ModeExact,
+		TTLSeconds:          int(DefaultTTL / time.Second),
+		SimilarityThreshold: DefaultSimilarityThreshold,
+	}
+}
+
+// GetSettings returns the cache settings of a project, disabled when the project has none
+func (s *CompletionCacheService) GetSettings(ctx context.Context, projectID uint) (*Settings, *common.Error) {
+	settings, err := s.repo.FindByProjectID(ctx, projectID)
+	if err != nil {
+		return nil, common.NewError(err, "5e2b8d4f-1a7c-4c9e-b3d6-8f1a5c2e9b47")
+	}
+	if settings == nil {
+		return defaultSettings(projectID), nil
+	}
+	return settings, nil
+}
+
+type UpdateSettingsInput struct {
+	Enabled             *bool
+	Mode                *string
+	TTLSeconds          *int
+	SimilarityThreshold *float64
+	EmbeddingModel      *string
+}
+
+// UpdateSettings applies the set fields of the input on the current settings of the project
+func (s *CompletionCacheService) UpdateSettings(ctx context.Context, projectID uint, input UpdateSettingsInput) (*Settings, *common.Error) {
+	settings, err := s.GetSettings(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	if input.Enabled != nil {
+		settings.Enabled = *input.Enabled
+	}
+	if input.Mode != nil {
+		mode := Mode(strings.TrimSpace(*input.Mode))
+		if mode != ModeExact && mode != ModeSemantic {
+			return nil, common.NewErrorWithMessage(fmt.Sprintf("mode must be %s or %s", ModeExact, ModeSemantic), "8c4f1e7a-2d9b-4a6e-9c3f-5b1d8e4a7c26")
+		}
+		settings.Mode = mode
+	}
+	if input.TTLSeconds != nil {
+		if *input.TTLSeconds < 1 || time.Duration(*input.TTLSeconds)*time.Second > MaxTTL {
+			return nil, common.NewErrorWithMessage(fmt.Sprintf("ttl_seconds must be between 1 and %d", int(MaxTTL/time.Second)), "8c4f1e7a-2d9b-4a6e-9c3f-5b1d8e4a7c27")
+		}
+		settings.TTLSeconds = *input.TTLSeconds
+	}
+	if input.SimilarityThreshold != nil {
+		if *input.SimilarityThreshold < MinSimilarityThreshold || *input.SimilarityThreshold > 1 {
+			return nil, common.NewErrorWithMessage(fmt.Sprintf("similarity_threshold must be between %.1f and 1", MinSimilarityThreshold), "8c4f1e7a-2d9b-4a6e-9c3f-5b1d8e4a7c28")
+		}
+		settings.SimilarityThreshold = *input.SimilarityThreshold
+	}
+	if input.EmbeddingModel != nil {
+		settings.EmbeddingModel = strings.TrimSpace(*input.EmbeddingModel)
+	}
+	if repoErr := s.repo.Upsert(ctx, settings); repoErr != nil {
+		return nil, common.NewError(repoErr, "3a9d6f2c-7e1b-4d8a-a5c4-9e2f7b3d1a68")
+	}
+	return settings, nil
+}
+
+// Request is a deterministic chat completion request the cache can serve
+type Request struct {
+	openai.ChatCompletionRequest
+	// Temperature is read from the request body, go-openai drops an explicit 0
+	Temperature *float64
+}
+
+// NewRequest returns the cacheable form of a request, or false when its output is not deterministic:
+// a request needs an explicit temperature of 0 or a seed, and a single choice
+func NewRequest(body []byte, request openai.ChatCompletionRequest) (*Request, bool) {
+	if request.N > 1 {
+		return nil, false
+	}
+	var fields struct {
+		Temperature *float64 `json:"temperature"`
+	}
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return nil, false
+	}
+	if request.Seed == nil && (fields.Temperature == nil || *fields.Temperature != 0) {
+		return nil, false
+	}
+	return &Request{ChatCompletionRequest: request, Temperature: fields.Temperature}, true
+}
+
+// hash is the canonical SHA-256 of the request; streaming and bookkeeping fields are left out so a
+// completion cached from a non-streaming request serves a streaming one
+func (r *Request) hash(withMessages bool) (string, error) {
+	request := r.ChatCompletionRequest
+	request.Stream = false
+	request.StreamOptions = nil
+	request.User = ""
+	request.Store = false
+	request.Metadata = nil
+	if !withMessages {
+		request.Messages = nil
+	}
+	encoded, err := json.Marshal(struct {
+		Request     openai.ChatCompletionRequest `json:"request"`
+		Temperature *float64                     `json:"temperature"`
+	}{request, r.Temperature})
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(encoded)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Lookup is the outcome of a cache lookup, it carries what Store needs to cache the completion of a miss
+type Lookup struct {
+	settings  *Settings
+	key       string
+	bucket    string
+	embedding []float32
+	// Response is the cached completion, nil on a miss
+	Response *openai.ChatCompletionResponse
+	// Similarity is 1 for an exact hit and the cosine similarity of the prompts for a semantic one
+	Similarity float64
+}
+
+func (l *Lookup) Hit() bool {
+	return l != nil && l.Response != nil
+}
+
+type semanticEntry struct {
+	Key       string    `json:"key"`
+	Embedding []float32 `json:"embedding"`
+}
+
+// Lookup finds the cached completion of a request, first by its exact hash and then, in the semantic mode,
+// by the prompt closest to the request among those sent with the same parameters
+func (s *CompletionCacheService) Lookup(ctx context.Context, settings *Settings, request *Request) (*Lookup, error) {
+	hash, err := request.hash(true)
+	if err != nil {
+		return nil, err
+	}
+	lookup := &Lookup{
+		settings: settings,
+		key:      fmt.Sprintf(cache.CompletionCacheKey, settings.ProjectID, hash),
+	}
+	if response := s.get(ctx, lookup.key); response != nil {
+		lookup.Response = response
+		lookup.Similarity = 1
+		return lookup, nil
+	}
+	if settings.Mode != ModeSemantic {
+		return lookup, nil
+	}
+
+	bucketHash, err := request.hash(false)
+	if err != nil {
+		return lookup, err
+	}
+	lookup.bucket = fmt.Sprintf(cache.CompletionCacheSemanticKey, settings.ProjectID, bucketHash)
+	embedding, err := s.embed(ctx, settings, promptText(request.Messages))
+	if err != nil {
+		// The exact mode still works without embeddings
+		return lookup, fmt.Errorf("failed to embed the prompt: %w", err)
+	}
+	lookup.embedding = embedding
+
+	values, err := s.cache.ListRange(ctx, lookup.bucket)
+	if err != nil {
+		return lookup, err
+	}
+	var best semanticEntry
+	bestSimilarity := -1.0
+	for _, value := range values {
+		var entry semanticEntry
+		if err := json.Unmarshal([]byte(value), &entry); err != nil {
+			continue
+		}
+		if similarity := cosineSimilarity(embedding, entry.Embedding); similarity > bestSimilarity {
+			best, bestSimilarity = entry, similarity
+		}
+	}
+	if bestSimilarity >= settings.SimilarityThreshold {
+		if response := s.get(ctx, best.Key); response != nil {
+			lookup.Response = response
+			lookup.Similarity = bestSimilarity
+		}
+	}
+	return lookup, nil
+}
+
+// Store caches the completion of a missed lookup for the TTL of the project
+func (s *CompletionCacheService) Store(ctx context.Context, lookup *Lookup, response *openai.ChatCompletionResponse) error {
+	if lookup == nil || lookup.Hit() || response == nil || len(response.Choices) == 0 {
+		return nil
+	}
+	encoded, err := json.Marshal(response)
+	if err != nil {
+		return err
+	}
+	ttl := lookup.settings.TTL()
+	if err := s.cache.Set(ctx, lookup.key, string(encoded), ttl); err != nil {
+		return err
+	}
+	if lookup.bucket == "" || lookup.embedding == nil {
+		return nil
+	}
+	entry, err := json.Marshal(semanticEntry{Key: lookup.key, Embedding: lookup.embedding})
+	if err != nil {
+		return err
+	}
+	return s.cache.ListPushCapped(ctx, lookup.bucket, string(entry), maxSemanticEntries, ttl)
+}
+
+func (s *CompletionCacheService) get(ctx context.Context, key string) *openai.ChatCompletionResponse {
+	cached, err := s.cache.Get(ctx, key)
+	if err != nil {
+		return nil
+	}
+	var response openai.ChatCompletionResponse
+	if err := json.Unmarshal([]byte(cached), &response); err != nil {
+		logger.GetLogger().Errorf("invalid cached completion %s: %v", key, err)
+		return nil
+	}
+	return &response
+}
+
+// embed computes the embedding of the prompt through the provider serving the embedding model
+func (s *CompletionCacheService) embed(ctx context.Context, settings *Settings, text string) ([]float32, error) {
+	model := settings.EmbeddingModel
+	if model == "" {
+		model = vectorstore.EmbeddingModel()
+	}
+	var organizationID uint
+	if organization.DEFAULT_ORGANIZATION != nil {
+		organizationID = organization.DEFAULT_ORGANIZATION.ID
+	}
+	provider, err := s.providerRegistry.GetProviderForModel(ctx, model, organizationID, nil)
+	if err != nil {
+		return nil, err
+	}
+	client, err := s.inferenceProvider.GetEmbeddingClient(provider)
+	if err != nil {
+		return nil, err
+	}
+	vectors, err := client.CreateEmbeddings(ctx, model, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	if len(vectors) == 0 || len(vectors[0]) == 0 {
+		return nil, fmt.Errorf("empty embedding")
+	}
+	return vectors[0], nil
+}
+
+// promptText flattens the messages into the text that is embedded, keeping its tail when it is too long
+func promptText(messages []openai.ChatCompletionMessage) string {
+	var builder strings.Builder
+	for _, message := range messages {
+		builder.WriteString(message.Role)
+		builder.WriteString(": ")
+		builder.WriteString(message.Content)
+		for _, part := range message.MultiContent {
+			if part.Type == openai.ChatMessagePartTypeText {
+				builder.WriteString(part.Text)
+				builder.WriteString(" ")
+			}
+		}
+		builder.WriteString("\n")
+	}
+	text := []rune(builder.String())
+	if len(text) > maxPromptRunes {
+		text = text[len(text)-maxPromptRunes:]
+	}
+	return string(text)
+}
+
+func cosineSimilarity(a, b []float32) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return -1
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return -1
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
@@ -0,0 +1,117 @@
+package completioncache
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+func TestNewRequestRequiresDeterminism(t *testing.T) {
+	seed := 7
+	messages := []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "hi"}}
+	cases := []struct {
+		name      string
+		body      string
+		request   openai.ChatCompletionRequest
+		cacheable bool
+	}{
+		{"temperature 0", `{"temperature":0}`, openai.ChatCompletionRequest{Messages: messages}, true},
+		{"seed", `{"seed":7}`, openai.ChatCompletionRequest{Messages: messages, Seed: &seed}, true},
+		{"default temperature", `{}`, openai.ChatCompletionRequest{Messages: messages}, false},
+		{"temperature 0.7", `{"temperature":0.7}`, openai.ChatCompletionRequest{Messages: messages, Temperature: 0.7}, false},
+		{"several choices", `{"temperature":0,"n":2}`, openai.ChatCompletionRequest{Messages: messages, N: 2}, false},
+	}
+	for _, c := range cases {
+		if _, ok := NewRequest([]byte(c.body), c.request); ok != c.cacheable {
+			t.Errorf("%s: cacheable = %v, want %v", c.name, ok, c.cacheable)
+		}
+	}
+}
+
+func TestHashIgnoresStreaming(t *testing.T) {
+	messages := []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "hi"}}
+	plain, _ := NewRequest([]byte(`{"temperature":0}`), openai.ChatCompletionRequest{Model: "m", Messages: messages})
+	streamed, _ := NewRequest([]byte(`{"temperature":0}`), openai.ChatCompletionRequest{
+		Model:         "m",
+		Messages:      messages,
+		Stream:        true,
+		StreamOptions: &openai.StreamOptions{IncludeUsage: true},
+		User:          "someone",
+	})
+	other, _ := NewRequest([]byte(`{"temperature":0}`), openai.ChatCompletionRequest{Model: "m", Messages: messages, MaxTokens: 10})
+
+	plainHash, _ := plain.hash(true)
+	streamedHash, _ := streamed.hash(true)
+	otherHash, _ := other.hash(true)
+	if plainHash != streamedHash {
+		t.Error("streaming changed the hash")
+	}
+	if plainHash == otherHash {
+		t.Error("max_tokens did not change the hash")
+	}
+
+	seed := 1
+	seeded, _ := NewRequest([]byte(`{"seed":1}`), openai.ChatCompletionRequest{Model: "m", Messages: messages, Seed: &seed})
+	seededZero, _ := NewRequest([]byte(`{"seed":1,"temperature":0}`), openai.ChatCompletionRequest{Model: "m", Messages: messages, Seed: &seed})
+	seededHash, _ := seeded.hash(true)
+	seededZeroHash, _ := seededZero.hash(true)
+	if seededHash == seededZeroHash {
+		t.Error("an explicit temperature of 0 did not change the hash")
+	}
+}
+
+func TestStreamChunks(t *testing.T) {
+	content := strings.Repeat("é", replayChunkRunes+5)
+	response := &openai.ChatCompletionResponse{
+		ID:    "chatcmpl-1",
+		Model: "m",
+		Choices: []openai.ChatCompletionChoice{{
+			Message: openai.ChatCompletionMessage{
+				Role:      openai.ChatMessageRoleAssistant,
+				Content:   content,
+				ToolCalls: []openai.ToolCall{{ID: "call_1", Type: openai.ToolTypeFunction, Function: openai.FunctionCall{Name: "f", Arguments: "{}"}}},
+			},
+			FinishReason: openai.FinishReasonToolCalls,
+		}},
+		Usage: openai.Usage{TotalTokens: 12},
+	}
+
+	chunks := StreamChunks(response, true)
+	// role, two content pieces, tool calls, finish and usage
+	if len(chunks) != 6 {
+		t.Fatalf("got %d chunks, want 6", len(chunks))
+	}
+	var replayed strings.Builder
+	for _, chunk := range chunks[1:3] {
+		replayed.WriteString(chunk.Choices[0].Delta.Content)
+	}
+	if replayed.String() != content {
+		t.Errorf("replayed content = %q", replayed.String())
+	}
+	if call := chunks[3].Choices[0].Delta.ToolCalls[0]; call.Index == nil || *call.Index != 0 || call.ID != "call_1" {
+		t.Errorf("unexpected tool call chunk: %+v", call)
+	}
+	if chunks[4].Choices[0].FinishReason != openai.FinishReasonToolCalls {
+		t.Errorf("finish reason = %q", chunks[4].Choices[0].FinishReason)
+	}
+	if last := chunks[5]; len(last.Choices) != 0 || last.Usage == nil || last.Usage.TotalTokens != 12 {
+		t.Errorf("unexpected usage chunk: %+v", last)
+	}
+	if len(StreamChunks(response, false)) != 5 {
+		t.Error("the usage chunk was sent without include_usage")
+	}
+}
+
+func TestCosineSimilarity(t *testing.T) {
+	if got := cosineSimilarity([]float32{1, 0}, []float32{2, 0}); math.Abs(got-1) > 1e-9 {
+		t.Errorf("parallel vectors = %v", got)
+	}
+	if got := cosineSimilarity([]float32{1, 0}, []float32{0, 1}); math.Abs(got) > 1e-9 {
+		t.Errorf("orthogonal vectors = %v", got)
+	}
+	if got := cosineSimilarity([]float32{1}, []float32{1, 0}); got != -1 {
+		t.Errorf("mismatched dimensions = %v", got)
+	}
+}
@@ -0,0 +1,391 @@
+package contextwindow
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	openai "github.com/sashabaranov/go-openai"
+	"menlo.ai/indigo-api-gateway/app/domain/common"
+	"menlo.ai/indigo-api-gateway/app/domain/conversation"
+	domainmodel "menlo.ai/indigo-api-gateway/app/domain/model"
+	"menlo.ai/indigo-api-gateway/app/domain/query"
+	"menlo.ai/indigo-api-gateway/app/domain/workspace"
+	"menlo.ai/indigo-api-gateway/app/infrastructure/inference"
+	"menlo.ai/indigo-api-gateway/app/utils/logger"
+	"menlo.ai/indigo-api-gateway/app/utils/tokenizer"
+	"menlo.ai/indigo-api-gateway/config/environment_variables"
+)
+
+const (
+	// DefaultCompletionReserve caps the room left for the reply when the request does not set max_tokens
+	DefaultCompletionReserve = 4096
+	// DefaultSummaryContextLength is assumed for summary models without known limits
+	DefaultSummaryContextLength = 8192
+	// SummaryMaxTokens bounds the length of a generated summary
+	SummaryMaxTokens = 1024
+	// summaryLookback limits how many stored summaries are considered for reuse
+	summaryLookback = 20
+	summaryTimeout  = 60 * time.Second
+
+	summaryMessagePrefix = "Summary of the earlier conversation:\n"
+	summaryInstruction   = "You maintain a running summary of a conversation so it can continue within a limited context window. " +
+		"Merge the previous summary with the new messages into a single concise summary. Preserve facts, decisions, user preferences, " +
+		"names, numbers, code identifiers and open questions needed to continue. Respond with the summary only."
+)
+
+// ModelLimits describes what is known about a model's context window
+type ModelLimits struct {
+	ContextLength       int
+	MaxCompletionTokens int
+	Tokenizer           string
+}
+
+// FitInput describes a prompt that has to be fitted into a model's context window
+type FitInput struct {
+	OrganizationID uint
+	ProjectIDs     []uint
+	Provider       *domainmodel.Provider
+	Model          string
+	Messages       []openai.ChatCompletionMessage
+	// MaxTokens is the completion budget requested by the client, zero when unset
+	MaxTokens int
+	// Settings is the request-level override; workspace settings of the conversation apply underneath
+	Settings     *conversation.ContextSettings
+	Conversation *conversation.Conversation
+}
+
+// FitResult is the prompt that will be sent upstream
+type FitResult struct {
+	Messages        []openai.ChatCompletionMessage
+	Counter         tokenizer.Counter
+	Strategy        conversation.ContextStrategy
+	PromptTokens    int
+	ContextLength   int
+	DroppedMessages int
+	Summary         *conversation.Item
+}
+
+type ContextWindowService struct {
+	providerModelService *domainmodel.ProviderModelService
+	modelCatalogService  *domainmodel.ModelCatalogService
+	providerRegistry     *domainmodel.ProviderRegistryService
+	inferenceProvider    *inference.InferenceProvider
+	conversationService  *conversation.ConversationService
+	workspaceService     *workspace.WorkspaceService
+}
+
+func NewContextWindowService(
+	providerModelService *domainmodel.ProviderModelService,
+	modelCatalogService *domainmodel.ModelCatalogService,
+	providerRegistry *domainmodel.ProviderRegistryService,
+	inferenceProvider *inference.InferenceProvider,
+	conversationService *conversation.ConversationService,
+	workspaceService *workspace.WorkspaceService,
+) *ContextWindowService {
+	return &ContextWindowService{
+		providerModelService: providerModelService,
+		modelCatalogService:  modelCatalogService,
+		providerRegistry:     providerRegistry,
+		inferenceProvider:    inferenceProvider,
+		conversationService:  conversationService,
+		workspaceService:     workspaceService,
+	}
+}
+
+// ResolveModelLimits looks up the token limits and tokenizer of a provider model.
+// Unknown models yield zero limits, which disables context fitting.
+func (s *ContextWindowService) ResolveModelLimits(ctx context.Context, provider *domainmodel.Provider, modelKey string) ModelLimits {
+	limits := ModelLimits{}
+	if provider == nil {
+		return limits
+	}
+	providerModels, err := s.providerModelService.FindActiveByProviderIDsAndKey(ctx, []uint{provider.ID}, modelKey)
+	if err != nil || len(providerModels) == 0 {
+		return limits
+	}
+	providerModel := providerModels[0]
+	if providerModel.TokenLimits != nil {
+		limits.ContextLength = providerModel.TokenLimits.ContextLength
+		limits.MaxCompletionTokens = providerModel.TokenLimits.MaxCompletionTokens
+	}
+	if providerModel.ModelCatalogID != nil {
+		catalog, catalogErr := s.modelCatalogService.FindByID(ctx, *providerModel.ModelCatalogID)
+		if catalogErr == nil && catalog != nil {
+			limits.Tokenizer = catalog.Architecture.Tokenizer
+		}
+	}
+	return limits
+}
+
+// CounterFor returns the token counter matching the model's catalog tokenizer
+func (s *ContextWindowService) CounterFor(ctx context.Context, provider *domainmodel.Provider, modelKey string) tokenizer.Counter {
+	limits := s.ResolveModelLimits(ctx, provider, modelKey)
+	return tokenizer.ForModel(limits.Tokenizer, modelKey)
+}
+
+// FitMessages applies the effective context strategy so the prompt fits the model's context window
+func (s *ContextWindowService) FitMessages(ctx context.Context, input FitInput) (*FitResult, *common.Error) {
+	if err := input.Settings.Validate(); err != nil {
+		return nil, common.NewError(err, "0b4f6a2e-2f0e-4d1b-9a8c-5e7d3c1b9f20")
+	}
+	settings := conversation.ResolveContextSettings(input.Settings, s.workspaceContextSettings(ctx, input.Conversation))
+
+	limits := s.ResolveModelLimits(ctx, input.Provider, input.Model)
+	counter := tokenizer.ForModel(limits.Tokenizer, input.Model)
+	result := &FitResult{
+		Messages:      input.Messages,
+		Counter:       counter,
+		Strategy:      settings.Strategy,
+		ContextLength: limits.ContextLength,
+	}
+	result.PromptTokens = counter.CountMessages(input.Messages)
+
+	budget := promptBudget(limits, input.MaxTokens)
+	if settings.Strategy == conversation.ContextStrategyNone || budget <= 0 {
+		return result, nil
+	}
+
+	system, dialogue := splitSystemPrefix(input.Messages)
+	switch settings.Strategy {
+	case conversation.ContextStrategyKeepLastN:
+		messages, dropped := keepLastN(system, dialogue, settings.KeepLastN)
+		result.Messages = messages
+		result.DroppedMessages = dropped
+	case conversation.ContextStrategySummarize:
+		if result.PromptTokens > budget {
+			if err := s.summarizeOlderTurns(ctx, input, settings, counter, system, dialogue, budget, result); err != nil {
+				// Falling back to truncation keeps the request usable when the summary model is unavailable
+				logger.GetLogger().Errorf("context summarization failed, truncating instead: %s - %s", err.GetCode(), err.Error())
+				result.Messages = input.Messages
+			}
+		}
+	}
+
+	// Every strategy ends with a hard truncation so the prompt never exceeds the window
+	if counter.CountMessages(result.Messages) > budget {
+		fittedSystem, fittedDialogue := splitSystemPrefix(result.Messages)
+		messages, dropped := truncateOldest(counter, fittedSystem, fittedDialogue, budget)
+		result.Messages = messages
+		result.DroppedMessages += dropped
+	}
+	result.PromptTokens = counter.CountMessages(result.Messages)
+	return result, nil
+}
+
+// promptBudget returns the tokens available for the prompt after reserving room for the completion
+func promptBudget(limits ModelLimits, maxTokens int) int {
+	if limits.ContextLength <= 0 {
+		return 0
+	}
+	reserve := maxTokens
+	if reserve <= 0 {
+		reserve = limits.ContextLength / 4
+		if limits.MaxCompletionTokens > 0 && limits.MaxCompletionTokens < reserve {
+			reserve = limits.MaxCompletionTokens
+		}
+		if reserve > DefaultCompletionReserve {
+			reserve = DefaultCompletionReserve
+		}
+	}
+	return limits.ContextLength - reserve
+}
+
+func (s *ContextWindowService) workspaceContextSettings(ctx context.Context, conv *conversation.Conversation) *conversation.ContextSettings {
+	if conv == nil || conv.WorkspacePublicID == nil || *conv.WorkspacePublicID == "" {
+		return nil
+	}
+	ws, err := s.workspaceService.GetWorkspaceByPublicIDAndUserID(ctx, *conv.WorkspacePublicID, conv.UserID)
+	if err != nil || ws == nil {
+		return nil
+	}
+	return ws.ContextSettings
+}
+
+// summarizeOlderTurns replaces the dialogue that does not fit with a summary message.
+// Summaries are stored as context_summary items and extended incrementally on later turns.
+func (s *ContextWindowService) summarizeOlderTurns(
+	ctx context.Context,
+	input FitInput,
+	settings conversation.ResolvedContextSettings,
+	counter tokenizer.Counter,
+	system, dialogue []openai.ChatCompletionMessage,
+	budget int,
+	result *FitResult,
+) *common.Error {
+	remaining := budget - sumTokens(counter, system) - tokenizer.TokensPerReply - SummaryMaxTokens
+	start := recentWithinBudget(counter, dialogue, remaining)
+	if start == 0 {
+		return nil
+	}
+	covered := dialogue[:start]
+	coveredHash := hashMessages(covered)
+
+	previous, previousCount := s.findReusableSummary(ctx, input.Conversation, covered)
+	summaryText := previous
+	if previousCount < len(covered) {
+		provider, model := s.resolveSummaryModel(ctx, input, settings.SummaryModel)
+		text, err := s.summarize(ctx, provider, model, previous, covered[previousCount:])
+		if err != nil {
+			return err
+		}
+		summaryText = text
+
+		if input.Conversation != nil {
+			item, err := s.storeSummary(ctx, input.Conversation, summaryText, conversation.ContextSummary{
+				CoveredMessages: len(covered),
+				CoveredHash:     coveredHash,
+				Model:           model,
+			})
+			if err != nil {
+				return err
+			}
+			result.Summary = item
+		}
+	}
+
+	summaryMessage := openai.ChatCompletionMessage{
+		Role:    openai.ChatMessageRoleSystem,
+		Content: summaryMessagePrefix + summaryText,
+	}
+	result.Messages = joinMessages(system, []openai.ChatCompletionMessage{summaryMessage}, dialogue[start:])
+	result.DroppedMessages = len(covered)
+	return nil
+}
+
+// findReusableSummary returns the stored summary covering the longest matching prefix of covered
+func (s *ContextWindowService) findReusableSummary(ctx context.Context, conv *conversation.Conversation, covered []openai.ChatCompletionMessage) (string, int) {
+	if conv == nil {
+		return "", 0
+	}
+	summaryType := conversation.ItemTypeContextSummary
+	limit := summaryLookback
+	items, err := s.conversationService.FindItemsByFilter(ctx, conversation.ItemFilter{
+		ConversationID: &conv.ID,
+		Type:           &summaryType,
+	}, &query.Pagination{Limit: &limit, Order: "desc"})
+	if err != nil {
+		return "", 0
+	}
+
+	bestText, bestCount := "", 0
+	for _, item := range items {
+		for _, content := range item.Content {
+			if content.ContextSummary == nil || content.Text == nil {
+				continue
+			}
+			count := content.ContextSummary.CoveredMessages
+			if count <= bestCount || count > len(covered) {
+				continue
+			}
+			if hashMessages(covered[:count]) == content.ContextSummary.CoveredHash {
+				bestText, bestCount = content.Text.Value, count
+			}
+		}
+	}
+	return bestText, bestCount
+}
+
+// resolveSummaryModel picks the configured summary model, falling back to the request's own model
+func (s *ContextWindowService) resolveSummaryModel(ctx context.Context, input FitInput, configured string) (*domainmodel.Provider, string) {
+	if configured == "" {
+		configured = strings.TrimSpace(environment_variables.Current().CONTEXT_SUMMARY_MODEL)
+	}
+	if configured == "" || configured == input.Model {
+		return input.Provider, input.Model
+	}
+	provider, err := s.providerRegistry.GetProviderForModel(ctx, configured, input.OrganizationID, input.ProjectIDs)
+	if err != nil || provider == nil {
+		logger.GetLogger().Warnf("summary model '%s' is not available, using '%s'", configured, input.Model)
+		return input.Provider, input.Model
+	}
+	return provider, configured
+}
+
+// summarize folds messages into the previous summary, in chunks that fit the summary model
+func (s *ContextWindowService) summarize(ctx context.Context, provider *domainmodel.Provider, model string, previous string, messages []openai.ChatCompletionMessage) (string, *common.Error) {
+	chatClient, err := s.inferenceProvider.GetChatCompletionClient(provider)
+	if err != nil {
+		return "", common.NewError(err, "4f1c7d0a-93b2-4e55-8d6f-1a2b3c4d5e6f")
+	}
+
+	limits := s.ResolveModelLimits(ctx, provider, model)
+	counter := tokenizer.ForModel(limits.Tokenizer, model)
+	contextLength := limits.ContextLength
+	if contextLength <= 0 {
+		contextLength = DefaultSummaryContextLength
+	}
+
+	summary := previous
+	for len(messages) > 0 {
+		chunkBudget := contextLength - SummaryMaxTokens - counter.CountText(summaryInstruction) - counter.CountText(summary) - 64
+		transcript, consumed := buildTranscript(counter, messages, chunkBudget)
+		messages = messages[consumed:]
+
+		prompt := transcript
+		if summary != "" {
+			prompt = fmt.Sprintf("Previous summary:\n%s\n\nNew messages:\n%s", summary, transcript)
+		}
+
+		callCtx, cancel := context.WithTimeout(ctx, summaryTimeout)
+		response, callErr := chatClient.CreateChatCompletion(callCtx, "", openai.ChatCompletionRequest{
+			Model:     model,
+			MaxTokens: SummaryMaxTokens,
+			Messages: []openai.ChatCompletionMessage{
+				{Role: openai.ChatMessageRoleSystem, Content: summaryInstruction},
+				{Role: openai.ChatMessageRoleUser, Content: prompt},
+			},
+		})
+		cancel()
+		if callErr != nil {
+			return "", common.NewError(callErr, "8a9d2c41-6e0b-4f3a-b7c5-2d1e0f9a8b7c")
+		}
+		if len(response.Choices) == 0 || strings.TrimSpace(response.Choices[0].Message.Content) == "" {
+			return "", common.NewErrorWithMessage("summary model returned no content", "c3e5a7b9-1d2f-4a6c-8e0b-9f7d5c3a1e2b")
+		}
+		summary = strings.TrimSpace(response.Choices[0].Message.Content)
+	}
+	return summary, nil
+}
+
+// buildTranscript renders as many messages as fit into budget; a single oversized message is clipped
+func buildTranscript(counter tokenizer.Counter, messages []openai.ChatCompletionMessage, budget int) (string, int) {
+	var builder strings.Builder
+	used, consumed := 0, 0
+	for _, message := range messages {
+		line := fmt.Sprintf("%s: %s\n", message.Role, transcriptText(message))
+		size := counter.CountText(line)
+		if consumed > 0 && used+size > budget {
+			break
+		}
+		if consumed == 0 && size > budget && budget > 0 {
+			runes := []rune(line)
+			line = string(runes[:len(runes)*budget/size]) + "\n"
+			size = budget
+		}
+		builder.WriteString(line)
+		used += size
+		consumed++
+	}
+	return builder.String(), consumed
+}
+
+func transcriptText(message openai.ChatCompletionMessage) string {
+	text := messageText(message)
+	for _, toolCall := range message.ToolCalls {
+		text += fmt.Sprintf("\n[tool call %s(%s)]", toolCall.Function.Name, toolCall.Function.Arguments)
+	}
+	return text
+}
+
+func (s *ContextWindowService) storeSummary(ctx context.Context, conv *conversation.Conversation, text string, coverage conversation.ContextSummary) (*conversation.Item, *common.Error) {
+	role := conversation.ItemRoleSystem
+	return s.conversationService.AddItem(ctx, conv, conv.UserID, conversation.ItemTypeContextSummary, &role, []conversation.Content{
+		{
+			Type:           "text",
+			Text:           &conversation.Text{Value: text},
+			ContextSummary: &coverage,
+		},
+	})
+}
@@ -0,0 +1,123 @@
+package contextwindow
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+
+	openai "github.com/sashabaranov/go-openai"
+	"menlo.ai/indigo-api-gateway/app/utils/tokenizer"
+)
+
+// splitSystemPrefix separates the leading system/developer messages, which are always kept, from the dialogue
+func splitSystemPrefix(messages []openai.ChatCompletionMessage) ([]openai.ChatCompletionMessage, []openai.ChatCompletionMessage) {
+	idx := 0
+	for idx < len(messages) && isSystemRole(messages[idx].Role) {
+		idx++
+	}
+	return messages[:idx], messages[idx:]
+}
+
+func isSystemRole(role string) bool {
+	return role == openai.ChatMessageRoleSystem || role == openai.ChatMessageRoleDeveloper
+}
+
+// sumTokens returns the prompt size of the messages, excluding the reply priming overhead
+func sumTokens(counter tokenizer.Counter, messages []openai.ChatCompletionMessage) int {
+	total := 0
+	for _, message := range messages {
+		total += tokenizer.CountMessage(counter, message)
+	}
+	return total
+}
+
+// recentWithinBudget returns the start index of the longest suffix of dialogue fitting within budget.
+// The last message is always kept, even when it alone exceeds the budget.
+func recentWithinBudget(counter tokenizer.Counter, dialogue []openai.ChatCompletionMessage, budget int) int {
+	if len(dialogue) == 0 {
+		return 0
+	}
+	start := len(dialogue) - 1
+	used := tokenizer.CountMessage(counter, dialogue[start])
+	for start > 0 {
+		size := tokenizer.CountMessage(counter, dialogue[start-1])
+		if used+size > budget {
+			break
+		}
+		used += size
+		start--
+	}
+	return alignToTurn(dialogue, start)
+}
+
+// alignToTurn moves a cut forward so the kept history never starts with a tool result
+// whose originating assistant tool call was dropped.
+func alignToTurn(dialogue []openai.ChatCompletionMessage, start int) int {
+	for start < len(dialogue)-1 && dialogue[start].Role == openai.ChatMessageRoleTool {
+		start++
+	}
+	return start
+}
+
+// truncateOldest keeps the system prefix and as many of the newest messages as fit in budget
+func truncateOldest(counter tokenizer.Counter, system, dialogue []openai.ChatCompletionMessage, budget int) ([]openai.ChatCompletionMessage, int) {
+	remaining := budget - sumTokens(counter, system) - tokenizer.TokensPerReply
+	start := recentWithinBudget(counter, dialogue, remaining)
+	return joinMessages(system, dialogue[start:]), start
+}
+
+// keepLastN keeps the system prefix and at most the last n dialogue messages
+func keepLastN(system, dialogue []openai.ChatCompletionMessage, n int) ([]openai.ChatCompletionMessage, int) {
+	start := 0
+	if len(dialogue) > n {
+		start = alignToTurn(dialogue, len(dialogue)-n)
+	}
+	return joinMessages(system, dialogue[start:]), start
+}
+
+func joinMessages(parts ...[]openai.ChatCompletionMessage) []openai.ChatCompletionMessage {
+	size := 0
+	for _, part := range parts {
+		size += len(part)
+	}
+	result := make([]openai.ChatCompletionMessage, 0, size)
+	for _, part := range parts {
+		result = append(result, part...)
+	}
+	return result
+}
+
+// hashMessages fingerprints a message prefix so stored summaries are only reused for identical history
+func hashMessages(messages []openai.ChatCompletionMessage) string {
+	hasher := sha256.New()
+	for _, message := range messages {
+		hasher.Write([]byte(message.Role))
+		hasher.Write([]byte{0})
+		hasher.Write([]byte(messageText(message)))
+		hasher.Write([]byte{0})
+		for _, toolCall := range message.ToolCalls {
+			hasher.Write([]byte(toolCall.ID))
+			hasher.Write([]byte(toolCall.Function.Name))
+			hasher.Write([]byte(toolCall.Function.Arguments))
+		}
+		hasher.Write([]byte(message.ToolCallID))
+		hasher.Write([]byte{1})
+	}
+	return hex.EncodeToString(hasher.Sum(nil))
+}
+
+// messageText flattens plain and multi-part content into a single string
+func messageText(message openai.ChatCompletionMessage) string {
+	if len(message.MultiContent) == 0 {
+		return message.Content
+	}
+	text := message.Content
+	for _, part := range message.MultiContent {
+		if part.Type == openai.ChatMessagePartTypeText {
+			if text != "" {
+				text += "\n"
+			}
+			text += part.Text
+		}
+	}
+	return text
+}
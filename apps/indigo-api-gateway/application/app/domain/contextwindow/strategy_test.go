@@ -0,0 +1,156 @@
+package contextwindow
+
+import (
+	"strings"
+	"testing"
+
+	openai "github.com/sashabaranov/go-openai"
+	"menlo.ai/indigo-api-gateway/app/utils/tokenizer"
+)
+
+// With the heuristic counter every message below counts 6 tokens (framing 3, role 1 or 2, content 2),
+// except the system message which counts 7 and the assistant ones which count 8.
+func message(role, content string) openai.ChatCompletionMessage {
+	return openai.ChatCompletionMessage{Role: role, Content: content}
+}
+
+func contents(messages []openai.ChatCompletionMessage) []string {
+	result := make([]string, 0, len(messages))
+	for _, m := range messages {
+		result = append(result, m.Content)
+	}
+	return result
+}
+
+func TestTruncateOldestAtBudgetEdge(t *testing.T) {
+	counter := tokenizer.HeuristicCounter{}
+	system := []openai.ChatCompletionMessage{message("system", "pinned01")}
+	dialogue := []openai.ChatCompletionMessage{
+		message("user", "first001"),
+		message("user", "second01"),
+		message("user", "third001"),
+		message("user", "fourth01"),
+	}
+	systemTokens := sumTokens(counter, system)
+	if systemTokens != 7 {
+		t.Fatalf("expected the system prefix to count 7, got %d", systemTokens)
+	}
+
+	// exactly two dialogue messages fit
+	budget := systemTokens + tokenizer.TokensPerReply + 12
+	kept, dropped := truncateOldest(counter, system, dialogue, budget)
+	if got := strings.Join(contents(kept), ","); got != "pinned01,third001,fourth01" || dropped != 2 {
+		t.Fatalf("unexpected messages at the edge: %s, dropped %d", got, dropped)
+	}
+	if counter.CountMessages(kept) > budget {
+		t.Fatalf("the prompt exceeds the budget: %d > %d", counter.CountMessages(kept), budget)
+	}
+
+	// one token less drops another message, the system prefix is always kept
+	kept, dropped = truncateOldest(counter, system, dialogue, budget-1)
+	if got := strings.Join(contents(kept), ","); got != "pinned01,fourth01" || dropped != 3 {
+		t.Fatalf("unexpected messages below the edge: %s, dropped %d", got, dropped)
+	}
+
+	// the last message is kept even when it alone does not fit
+	kept, _ = truncateOldest(counter, system, dialogue, 0)
+	if got := strings.Join(contents(kept), ","); got != "pinned01,fourth01" {
+		t.Fatalf("expected the system prefix and the last message, got %s", got)
+	}
+}
+
+func TestTruncationNeverStartsWithToolResult(t *testing.T) {
+	counter := tokenizer.HeuristicCounter{}
+	dialogue := []openai.ChatCompletionMessage{
+		message("user", "question"),
+		{Role: "assistant", ToolCalls: []openai.ToolCall{{ID: "c1", Function: openai.FunctionCall{Name: "f", Arguments: "{}"}}}},
+		{Role: "tool", Content: "result01", ToolCallID: "c1"},
+		{Role: "tool", Content: "result02", ToolCallID: "c2"},
+		message("user", "followup"),
+	}
+	// room for the two tool results and the follow up, but not for the assistant tool call
+	budget := tokenizer.TokensPerReply + sumTokens(counter, dialogue[2:])
+	kept, dropped := truncateOldest(counter, nil, dialogue, budget)
+	if got := strings.Join(contents(kept), ","); got != "followup" || dropped != 4 {
+		t.Fatalf("expected the orphaned tool results to be dropped, got %q, dropped %d", got, dropped)
+	}
+
+	kept, dropped = keepLastN(nil, dialogue, 3)
+	if got := strings.Join(contents(kept), ","); got != "followup" || dropped != 4 {
+		t.Fatalf("expected keep_last_n to skip the tool results, got %q, dropped %d", got, dropped)
+	}
+}
+
+func TestKeepLastN(t *testing.T) {
+	system := []openai.ChatCompletionMessage{message("system", "pinned01"), message("developer", "pinned02")}
+	dialogue := []openai.ChatCompletionMessage{
+		message("user", "first001"),
+		message("assistant", "answer01"),
+		message("system", "inline01"),
+		message("user", "second01"),
+	}
+	messages := joinMessages(system, dialogue)
+	prefix, rest := splitSystemPrefix(messages)
+	if len(prefix) != 2 || len(rest) != 4 {
+		t.Fatalf("expected only the leading system and developer messages to be pinned, got %d and %d", len(prefix), len(rest))
+	}
+
+	kept, dropped := keepLastN(prefix, rest, 2)
+	if got := strings.Join(contents(kept), ","); got != "pinned01,pinned02,inline01,second01" || dropped != 2 {
+		t.Fatalf("unexpected messages: %s, dropped %d", got, dropped)
+	}
+	kept, dropped = keepLastN(prefix, rest, 10)
+	if len(kept) != len(messages) || dropped != 0 {
+		t.Fatalf("expected every message to be kept, got %d, dropped %d", len(kept), dropped)
+	}
+}
+
+func TestPromptBudget(t *testing.T) {
+	cases := []struct {
+		limits    ModelLimits
+		maxTokens int
+		expected  int
+	}{
+		{limits: ModelLimits{}, maxTokens: 100, expected: 0},
+		{limits: ModelLimits{ContextLength: 8000}, maxTokens: 1000, expected: 7000},
+		{limits: ModelLimits{ContextLength: 8000}, expected: 6000},
+		{limits: ModelLimits{ContextLength: 8000, MaxCompletionTokens: 500}, expected: 7500},
+		{limits: ModelLimits{ContextLength: 128000}, expected: 128000 - DefaultCompletionReserve},
+	}
+	for _, c := range cases {
+		if got := promptBudget(c.limits, c.maxTokens); got != c.expected {
+			t.Fatalf("promptBudget(%+v, %d) = %d, expected %d", c.limits, c.maxTokens, got, c.expected)
+		}
+	}
+}
+
+func TestBuildTranscriptClipsOversizedMessage(t *testing.T) {
+	counter := tokenizer.HeuristicCounter{}
+	messages := []openai.ChatCompletionMessage{
+		message("user", strings.Repeat("a", 400)),
+		message("user", "short"),
+	}
+	transcript, consumed := buildTranscript(counter, messages, 20)
+	if consumed != 1 {
+		t.Fatalf("expected only the clipped first message, got %d", consumed)
+	}
+	if size := counter.CountText(transcript); size > 21 {
+		t.Fatalf("expected the clipped message to fit the budget, got %d tokens", size)
+	}
+
+	transcript, consumed = buildTranscript(counter, messages[1:], 20)
+	if consumed != 1 || transcript != "user: short\n" {
+		t.Fatalf("unexpected transcript %q", transcript)
+	}
+}
+
+func TestHashMessagesDistinguishesToolCalls(t *testing.T) {
+	a := []openai.ChatCompletionMessage{{Role: "assistant", ToolCalls: []openai.ToolCall{{ID: "1", Function: openai.FunctionCall{Name: "f", Arguments: "{}"}}}}}
+	b := []openai.ChatCompletionMessage{{Role: "assistant", ToolCalls: []openai.ToolCall{{ID: "1", Function: openai.FunctionCall{Name: "f", Arguments: `{"x":1}`}}}}}
+	if hashMessages(a) == hashMessages(b) {
+		t.Fatal("expected different tool call arguments to change the hash")
+	}
+	if hashMessages(a) != hashMessages(a) {
+		t.Fatal("expected the hash to be stable")
+	}
+}
@@ -0,0 +1,99 @@
+package conversation
+
+import (
+	"fmt"
+	"strings"
+)
+
+// @Enum(none, truncate_oldest, keep_last_n, summarize)
+type ContextStrategy string
+
+const (
+	ContextStrategyNone           ContextStrategy = "none"
+	ContextStrategyTruncateOldest ContextStrategy = "truncate_oldest"
+	ContextStrategyKeepLastN      ContextStrategy = "keep_last_n"
+	ContextStrategySummarize      ContextStrategy = "summarize"
+
+	DefaultContextStrategy  = ContextStrategyTruncateOldest
+	DefaultContextKeepLastN = 20
+)
+
+// ItemTypeContextSummary marks an item holding a model-generated summary of older turns.
+// Summary items are bookkeeping for context management and are never replayed as dialogue.
+const ItemTypeContextSummary ItemType = "context_summary"
+
+func ValidateContextStrategy(input string) bool {
+	switch ContextStrategy(input) {
+	case ContextStrategyNone, ContextStrategyTruncateOldest, ContextStrategyKeepLastN, ContextStrategySummarize:
+		return true
+	default:
+		return false
+	}
+}
+
+// ContextSettings controls how history is fitted into the model context window.
+// Every field is optional so request-level settings can override workspace defaults field by field.
+type ContextSettings struct {
+	Strategy     *ContextStrategy `json:"strategy,omitempty"`
+	KeepLastN    *int             `json:"keep_last_n,omitempty"`
+	SummaryModel *string          `json:"summary_model,omitempty"`
+}
+
+// Validate checks the settings supplied by a client
+func (s *ContextSettings) Validate() error {
+	if s == nil {
+		return nil
+	}
+	if s.Strategy != nil && !ValidateContextStrategy(string(*s.Strategy)) {
+		return fmt.Errorf("unsupported context strategy: %s", *s.Strategy)
+	}
+	if s.KeepLastN != nil && *s.KeepLastN < 1 {
+		return fmt.Errorf("keep_last_n must be at least 1")
+	}
+	if s.SummaryModel != nil && strings.TrimSpace(*s.SummaryModel) == "" {
+		s.SummaryModel = nil
+	}
+	return nil
+}
+
+// ResolvedContextSettings is the effective configuration after layering request over workspace settings
+type ResolvedContextSettings struct {
+	Strategy     ContextStrategy
+	KeepLastN    int
+	SummaryModel string
+}
+
+// ResolveContextSettings layers the given settings in order of precedence, first non-nil field wins
+func ResolveContextSettings(layers ...*ContextSettings) ResolvedContextSettings {
+	resolved := ResolvedContextSettings{
+		Strategy:  DefaultContextStrategy,
+		KeepLastN: DefaultContextKeepLastN,
+	}
+	strategySet, keepSet, modelSet := false, false, false
+	for _, layer := range layers {
+		if layer == nil {
+			continue
+		}
+		if !strategySet && layer.Strategy != nil {
+			resolved.Strategy = *layer.Strategy
+			strategySet = true
+		}
+		if !keepSet && layer.KeepLastN != nil {
+			resolved.KeepLastN = *layer.KeepLastN
+			keepSet = true
+		}
+		if !modelSet && layer.SummaryModel != nil {
+			resolved.SummaryModel = strings.TrimSpace(*layer.SummaryModel)
+			modelSet = true
+		}
+	}
+	return resolved
+}
+
+// ContextSummary records which prefix of the history a summary item covers.
+// CoveredHash fingerprints the covered messages so a summary is only reused for the same history.
+type ContextSummary struct {
+	CoveredMessages int    `json:"covered_messages"`
+	CoveredHash     string `json:"covered_hash"`
+	Model           string `json:"model,omitempty"`
+}
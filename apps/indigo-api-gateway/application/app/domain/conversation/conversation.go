@@ -15,6 +15,26 @@ const (
 	ConversationStatusDeleted  ConversationStatus = "deleted"
 )
 
+// ConversationAccess is the level of access a user has on a conversation
+type ConversationAccess string
+
+const (
+	ConversationAccessOwner  ConversationAccess = "owner"
+	ConversationAccessEditor ConversationAccess = "editor"
+	ConversationAccessViewer ConversationAccess = "viewer"
+)
+
+func (a ConversationAccess) CanEdit() bool {
+	return a == ConversationAccessOwner || a == ConversationAccessEditor
+}
+
+func (a ConversationAccess) IsOwner() bool {
+	return a == ConversationAccessOwner
+}
+
+// DefaultConversationTitle is used until a title can be derived from the conversation
+const DefaultConversationTitle = "New Conversation"
+
 // @Enum(message, function_call, function_call_output)
 type ItemType string
 
@@ -99,17 +119,20 @@ type Item struct {
 	CompletedAt       *time.Time         `json:"completed_at,omitempty"`
 	ResponseID        *uint              `json:"-"`
 	CreatedAt         time.Time          `json:"created_at"`
+	DeletedAt         *time.Time         `json:"deleted_at,omitempty"` // Set while the item is in the trash
 }
 
 type Content struct {
-	Type             string        `json:"type"`
-	FinishReason     *string       `json:"finish_reason,omitempty"`     // Finish reason
-	Text             *Text         `json:"text,omitempty"`              // Generic text content
-	InputText        *string       `json:"input_text,omitempty"`        // User input text (simple)
-	OutputText       *OutputText   `json:"output_text,omitempty"`       // AI output text (with annotations)
-	ReasoningContent *string       `json:"reasoning_content,omitempty"` // AI reasoning content
-	Image            *ImageContent `json:"image,omitempty"`             // Image content
-	File             *FileContent  `json:"file,omitempty"`              // File content
+	Type             string          `json:"type"`
+	FinishReason     *string         `json:"finish_reason,omitempty"`     // Finish reason
+	Text             *Text           `json:"text,omitempty"`              // Generic text content
+	InputText        *string         `json:"input_text,omitempty"`        // User input text (simple)
+	OutputText       *OutputText     `json:"output_text,omitempty"`       // AI output text (with annotations)
+	ReasoningContent *string         `json:"reasoning_content,omitempty"` // AI reasoning content
+	Image            *ImageContent   `json:"image,omitempty"`             // Image content
+	File             *FileContent    `json:"file,omitempty"`              // File content
+	ContextSummary   *ContextSummary `json:"context_summary,omitempty"`   // Coverage of a context summary item
+	ToolCall         *ToolCall       `json:"tool_call,omitempty"`         // Tool invocation or its output
 }
 
 // Generic text content (backward compatibility)
@@ -124,6 +147,14 @@ type OutputText struct {
 	LogProbs    []LogProb    `json:"logprobs,omitempty"` // Token probabilities
 }
 
+// ToolCall describes a tool invocation made by the model or the output returned for it
+type ToolCall struct {
+	CallID    string `json:"call_id"`
+	Name      string `json:"name,omitempty"`
+	Arguments string `json:"arguments,omitempty"`
+	Output    string `json:"output,omitempty"`
+}
+
 // Image content for multimodal support
 type ImageContent struct {
 	URL    string `json:"url,omitempty"`
@@ -177,21 +208,33 @@ type Conversation struct {
 	Items             []Item             `json:"items,omitempty"`
 	Metadata          map[string]string  `json:"metadata,omitempty"`
 	IsPrivate         bool               `json:"is_private"`
-	CreatedAt         time.Time          `json:"created_at"` // Unix timestamp for OpenAI compatibility
-	UpdatedAt         time.Time          `json:"updated_at"` // Unix timestamp for OpenAI compatibility
+	CreatedAt         time.Time          `json:"created_at"`           // Unix timestamp for OpenAI compatibility
+	UpdatedAt         time.Time          `json:"updated_at"`           // Unix timestamp for OpenAI compatibility
+	DeletedAt         *time.Time         `json:"deleted_at,omitempty"` // Set while the conversation is in the trash
 }
 
 type ConversationFilter struct {
-	PublicID          *string
-	UserID            *uint
+	PublicID *string
+	UserID   *uint
+	// VisibleToUserID matches the conversations of the user and the conversations of others that are not private
+	VisibleToUserID   *uint
 	WorkspacePublicID *string
+	// Status ConversationStatusDeleted selects the trashed conversations only
+	Status *ConversationStatus
+	// IncludeDeleted also matches trashed conversations
+	IncludeDeleted bool
 }
 
 type ItemFilter struct {
 	PublicID       *string
 	ConversationID *uint
 	Role           *ItemRole
+	Type           *ItemType
 	ResponseID     *uint
+	// OnlyDeleted selects the trashed items only
+	OnlyDeleted bool
+	// IncludeDeleted also matches trashed items
+	IncludeDeleted bool
 }
 
 type ConversationRepository interface {
@@ -201,20 +244,35 @@ type ConversationRepository interface {
 	FindByID(ctx context.Context, id uint) (*Conversation, error)
 	FindByPublicID(ctx context.Context, publicID string) (*Conversation, error)
 	Update(ctx context.Context, conversation *Conversation) error
+	// ReplaceTitle sets the title only while the stored one is missing or one of placeholders, it reports whether it was set
+	ReplaceTitle(ctx context.Context, id uint, title string, placeholders []string) (bool, error)
+	// Delete moves the conversation to the trash
 	Delete(ctx context.Context, id uint) error
+	Restore(ctx context.Context, id uint) error
+	// DeletePermanently removes the conversation together with its items and responses
+	DeletePermanently(ctx context.Context, id uint) error
 	DeleteByWorkspacePublicID(ctx context.Context, workspacePublicID string) error
 	AddItem(ctx context.Context, conversationID uint, item *Item) error
 	SearchItems(ctx context.Context, conversationID uint, query string) ([]*Item, error)
 	BulkAddItems(ctx context.Context, conversationID uint, items []*Item) error
 }
 
+// WorkspaceAccessProvider grants access to conversations of other members of a shared workspace
+type WorkspaceAccessProvider interface {
+	// SharedConversationAccess returns the access of the user on conversations of the workspace, ok is false when it does not share them with the user
+	SharedConversationAccess(ctx context.Context, workspacePublicID string, userID uint) (access ConversationAccess, ok bool, err error)
+}
+
 type ItemRepository interface {
 	Create(ctx context.Context, item *Item) error
 	FindByID(ctx context.Context, id uint) (*Item, error)
 	FindByPublicID(ctx context.Context, publicID string) (*Item, error) // Find by OpenAI-compatible string ID
 	FindByConversationID(ctx context.Context, conversationID uint) ([]*Item, error)
 	Search(ctx context.Context, conversationID uint, query string) ([]*Item, error)
+	// Delete moves the item to the trash
 	Delete(ctx context.Context, id uint) error
+	Restore(ctx context.Context, id uint) error
+	DeletePermanently(ctx context.Context, id uint) error
 	BulkCreate(ctx context.Context, items []*Item) error
 	CountByConversation(ctx context.Context, conversationID uint) (int64, error)
 	ExistsByIDAndConversation(ctx context.Context, itemID uint, conversationID uint) (bool, error)
@@ -19,6 +19,7 @@ type ConversationContextKey string
 const (
 	ConversationContextKeyPublicID ConversationContextKey = "conv_public_id"
 	ConversationContextEntity      ConversationContextKey = "ConversationContextEntity"
+	ConversationContextAccess      ConversationContextKey = "ConversationContextAccess"
 )
 
 type ConversationItemContextKey string
@@ -32,15 +33,17 @@ type ConversationService struct {
 	conversationRepo ConversationRepository
 	itemRepo         ItemRepository
 	validator        *ConversationValidator
+	workspaceAccess  WorkspaceAccessProvider
 }
 
-func NewService(conversationRepo ConversationRepository, itemRepo ItemRepository) *ConversationService {
+func NewService(conversationRepo ConversationRepository, itemRepo ItemRepository, workspaceAccess WorkspaceAccessProvider) *ConversationService {
 	// Initialize with default validation config
 	validator := NewConversationValidator(DefaultValidationConfig())
 	return &ConversationService{
 		conversationRepo: conversationRepo,
 		itemRepo:         itemRepo,
 		validator:        validator,
+		workspaceAccess:  workspaceAccess,
 	}
 }
 
@@ -137,6 +140,18 @@ func (s *ConversationService) UpdateConversation(ctx context.Context, entity *Co
 	return entity, nil
 }
 
+// ReplacePlaceholderTitle stores a generated title unless the conversation was renamed since placeholder was assigned
+func (s *ConversationService) ReplacePlaceholderTitle(ctx context.Context, conv *Conversation, placeholder string, title string) (bool, *common.Error) {
+	replaced, err := s.conversationRepo.ReplaceTitle(ctx, conv.ID, title, []string{"", placeholder, DefaultConversationTitle})
+	if err != nil {
+		return false, common.NewError(err, "3f8a1c6e-2d9b-4e7a-b5c4-8e1d6a3f9b27")
+	}
+	if replaced {
+		conv.Title = &title
+	}
+	return replaced, nil
+}
+
 func (s *ConversationService) UpdateConversationWorkspace(ctx context.Context, conv *Conversation, workspacePublicID *string) (*Conversation, *common.Error) {
 	conv.WorkspacePublicID = workspacePublicID
 	if err := s.conversationRepo.Update(ctx, conv); err != nil {
@@ -145,17 +160,44 @@ func (s *ConversationService) UpdateConversationWorkspace(ctx context.Context, c
 	return conv, nil
 }
 
+// DeleteConversation moves the conversation to the trash, it can be restored until it is deleted permanently
 func (s *ConversationService) DeleteConversation(ctx context.Context, conv *Conversation) (bool, *common.Error) {
 	if err := s.conversationRepo.Delete(ctx, conv.ID); err != nil {
 		return false, common.NewError(err, "m3n4o5p6-q7r8-9012-mnop-345678901234")
 	}
+	now := time.Now()
+	conv.Status = ConversationStatusDeleted
+	conv.DeletedAt = &now
 	return true, nil
 }
 
+// RestoreConversation moves a trashed conversation out of the trash with the status it had, active or archived
+func (s *ConversationService) RestoreConversation(ctx context.Context, conv *Conversation) (*Conversation, *common.Error) {
+	if conv.DeletedAt == nil {
+		return nil, common.NewErrorWithMessage("Conversation is not in the trash", "7d1e3f5a-9b2c-4d6e-8f0a-1c3e5a7b9d2f")
+	}
+	if err := s.conversationRepo.Restore(ctx, conv.ID); err != nil {
+		return nil, common.NewError(err, "2a4c6e8f-0b1d-4f3a-9c5e-7a9b1d3f5e7c")
+	}
+	restored, err := s.conversationRepo.FindByID(ctx, conv.ID)
+	if err != nil {
+		return nil, common.NewError(err, "6c2e8a4f-1b7d-4f3e-9a5c-2d8f6b1e4a73")
+	}
+	return restored, nil
+}
+
+// DeleteConversationPermanently removes the conversation with its items and responses, this cannot be undone
+func (s *ConversationService) DeleteConversationPermanently(ctx context.Context, conv *Conversation) *common.Error {
+	if err := s.conversationRepo.DeletePermanently(ctx, conv.ID); err != nil {
+		return common.NewError(err, "5e7a9c1b-3d5f-4a7c-8e9b-0d2f4a6c8e1b")
+	}
+	return nil
+}
+
 func (s *ConversationService) AddItem(ctx context.Context, conversation *Conversation, userID uint, itemType ItemType, role *ItemRole, content []Content) (*Item, *common.Error) {
 	// Check access permissions
-	if conversation.IsPrivate && conversation.UserID != userID {
-		return nil, common.NewErrorWithMessage("Private conversation access denied", "n4o5p6q7-r8s9-0123-nopq-456789012345")
+	if err := s.checkWriteAccess(ctx, conversation, userID); err != nil {
+		return nil, err
 	}
 
 	if err := s.validator.ValidateItemContent(content); err != nil {
@@ -190,8 +232,8 @@ func (s *ConversationService) AddItem(ctx context.Context, conversation *Convers
 // AddItemWithID adds an item to a conversation with a custom public ID
 func (s *ConversationService) AddItemWithID(ctx context.Context, conversation *Conversation, userID uint, itemType ItemType, role *ItemRole, content []Content, customPublicID string) (*Item, *common.Error) {
 	// Check access permissions
-	if conversation.IsPrivate && conversation.UserID != userID {
-		return nil, common.NewErrorWithMessage("Private conversation access denied", "n4o5p6q7-r8s9-0123-nopq-456789012345")
+	if err := s.checkWriteAccess(ctx, conversation, userID); err != nil {
+		return nil, err
 	}
 
 	if err := s.validator.ValidateItemContent(content); err != nil {
@@ -218,11 +260,13 @@ func (s *ConversationService) AddItemWithID(ctx context.Context, conversation *C
 	return item, nil
 }
 
-// DeleteItemWithConversation deletes an item by its ID and updates the conversation accordingly.
+// DeleteItemWithConversation moves an item to the trash and updates the conversation accordingly.
 func (s *ConversationService) DeleteItemWithConversation(ctx context.Context, conversation *Conversation, item *Item) (*Item, *common.Error) {
 	if err := s.itemRepo.Delete(ctx, item.ID); err != nil {
 		return nil, common.NewError(err, "e1f2g3h4-i5j6-7890-efgh-123456789012")
 	}
+	now := time.Now()
+	item.DeletedAt = &now
 
 	if err := s.updateConversationTimestamp(ctx, conversation, "f2g3h4i5-j6k7-8901-fghi-234567890123"); err != nil {
 		return nil, err
@@ -231,6 +275,31 @@ func (s *ConversationService) DeleteItemWithConversation(ctx context.Context, co
 	return item, nil
 }
 
+// RestoreItemWithConversation moves a trashed item back into the conversation.
+func (s *ConversationService) RestoreItemWithConversation(ctx context.Context, conversation *Conversation, item *Item) (*Item, *common.Error) {
+	if item.DeletedAt == nil {
+		return nil, common.NewErrorWithMessage("Item is not in the trash", "9c1e3a5b-7d9f-4b1c-a3e5-7b9d1f3a5c7e")
+	}
+	if err := s.itemRepo.Restore(ctx, item.ID); err != nil {
+		return nil, common.NewError(err, "4b6d8f0a-2c4e-4a6b-8d0f-2a4c6e8b0d2f")
+	}
+	item.DeletedAt = nil
+
+	if err := s.updateConversationTimestamp(ctx, conversation, "8e0a2c4d-6f8b-4c0e-a2d4-6f8a0c2e4b6d"); err != nil {
+		return nil, err
+	}
+
+	return item, nil
+}
+
+// DeleteItemPermanently removes an item from the conversation, this cannot be undone.
+func (s *ConversationService) DeleteItemPermanently(ctx context.Context, conversation *Conversation, item *Item) *common.Error {
+	if err := s.itemRepo.DeletePermanently(ctx, item.ID); err != nil {
+		return common.NewError(err, "1f3b5d7e-9a1c-4e3f-b5d7-9e1a3c5f7b9d")
+	}
+	return s.updateConversationTimestamp(ctx, conversation, "6a8c0e2f-4b6d-4a8c-9e0f-2b4d6f8a0c2e")
+}
+
 // generateConversationPublicID generates a conversation ID with business rules
 // Business rule: conversations use "conv" prefix with 42 character length for OpenAI compatibility
 func (s *ConversationService) generateConversationPublicID() (string, error) {
@@ -283,6 +352,9 @@ func (s *ConversationService) CountItemsByFilter(ctx context.Context, filter Ite
 // AddMultipleItems adds multiple items to a conversation in a single transaction
 func (s *ConversationService) AddMultipleItems(ctx context.Context, conversation *Conversation, userID uint, items []*Item) ([]*Item, *common.Error) {
 	// Check access permissions
+	if err := s.checkWriteAccess(ctx, conversation, userID); err != nil {
+		return nil, err
+	}
 	now := time.Now()
 	createdItems := make([]*Item, len(items))
 
@@ -318,6 +390,15 @@ func (s *ConversationService) AddMultipleItems(ctx context.Context, conversation
 }
 
 func (s *ConversationService) GetConversationMiddleWare() gin.HandlerFunc {
+	return s.conversationMiddleWare(false)
+}
+
+// GetConversationWithTrashMiddleWare also resolves conversations that are in the trash
+func (s *ConversationService) GetConversationWithTrashMiddleWare() gin.HandlerFunc {
+	return s.conversationMiddleWare(true)
+}
+
+func (s *ConversationService) conversationMiddleWare(includeDeleted bool) gin.HandlerFunc {
 	return func(reqCtx *gin.Context) {
 		ctx := reqCtx.Request.Context()
 		publicID := reqCtx.Param(string(ConversationContextKeyPublicID))
@@ -336,10 +417,7 @@ func (s *ConversationService) GetConversationMiddleWare() gin.HandlerFunc {
 			})
 			return
 		}
-		entities, err := s.FindConversationsByFilter(ctx, ConversationFilter{
-			PublicID: &publicID,
-			UserID:   &user.ID,
-		}, nil)
+		conv, access, err := s.resolveConversationAccess(ctx, publicID, user.ID, includeDeleted)
 
 		if err != nil {
 			reqCtx.AbortWithStatusJSON(http.StatusUnauthorized, responses.ErrorResponse{
@@ -349,7 +427,7 @@ func (s *ConversationService) GetConversationMiddleWare() gin.HandlerFunc {
 			return
 		}
 
-		if len(entities) == 0 {
+		if conv == nil {
 			reqCtx.AbortWithStatusJSON(http.StatusNotFound, responses.ErrorResponse{
 				Code:  "e91636c2-fced-4a89-bf08-55309005365f",
 				Error: "conversation not found",
@@ -357,11 +435,108 @@ func (s *ConversationService) GetConversationMiddleWare() gin.HandlerFunc {
 			return
 		}
 
-		SetConversationFromContext(reqCtx, entities[0])
+		if reqCtx.Request.Method != http.MethodGet && !access.CanEdit() {
+			reqCtx.AbortWithStatusJSON(http.StatusForbidden, responses.ErrorResponse{
+				Code:  "3b5d7f9a-1c3e-4b5d-9f7a-1c3e5b7d9f1b",
+				Error: "read-only access to this conversation",
+			})
+			return
+		}
+
+		SetConversationFromContext(reqCtx, conv)
+		reqCtx.Set(string(ConversationContextAccess), access)
 		reqCtx.Next()
 	}
 }
 
+// resolveConversationAccess finds a conversation owned by the user or shared with them through a workspace
+func (s *ConversationService) resolveConversationAccess(ctx context.Context, publicID string, userID uint, includeDeleted bool) (*Conversation, ConversationAccess, *common.Error) {
+	owned, err := s.FindConversationsByFilter(ctx, ConversationFilter{
+		PublicID:       &publicID,
+		UserID:         &userID,
+		IncludeDeleted: includeDeleted,
+	}, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(owned) > 0 {
+		return owned[0], ConversationAccessOwner, nil
+	}
+	if s.workspaceAccess == nil {
+		return nil, "", nil
+	}
+
+	entities, err := s.FindConversationsByFilter(ctx, ConversationFilter{
+		PublicID:       &publicID,
+		IncludeDeleted: includeDeleted,
+	}, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(entities) == 0 {
+		return nil, "", nil
+	}
+	access, ok, accessErr := s.sharedAccess(ctx, entities[0], userID)
+	if accessErr != nil {
+		return nil, "", accessErr
+	}
+	if !ok {
+		return nil, "", nil
+	}
+	return entities[0], access, nil
+}
+
+// sharedAccess resolves the access of a user who does not own the conversation, private conversations are never shared
+func (s *ConversationService) sharedAccess(ctx context.Context, conv *Conversation, userID uint) (ConversationAccess, bool, *common.Error) {
+	if s.workspaceAccess == nil || conv.IsPrivate || conv.WorkspacePublicID == nil {
+		return "", false, nil
+	}
+	access, ok, err := s.workspaceAccess.SharedConversationAccess(ctx, *conv.WorkspacePublicID, userID)
+	if err != nil {
+		return "", false, common.NewError(err, "7f9b1d3e-5a7c-4f9b-8d3e-5a7c9f1b3d5e")
+	}
+	return access, ok, nil
+}
+
+// checkWriteAccess allows the owner and the users the workspace shares the conversation with as editors to add items
+func (s *ConversationService) checkWriteAccess(ctx context.Context, conv *Conversation, userID uint) *common.Error {
+	if conv.UserID == userID {
+		return nil
+	}
+	access, ok, err := s.sharedAccess(ctx, conv, userID)
+	if err != nil {
+		return err
+	}
+	if !ok || !access.CanEdit() {
+		return common.NewErrorWithMessage("Conversation write access denied", "n4o5p6q7-r8s9-0123-nopq-456789012345")
+	}
+	return nil
+}
+
+// RequireConversationOwner restricts a route to the owner of the conversation, it runs after the conversation middleware
+func RequireConversationOwner() gin.HandlerFunc {
+	return func(reqCtx *gin.Context) {
+		if !GetConversationAccessFromContext(reqCtx).IsOwner() {
+			reqCtx.AbortWithStatusJSON(http.StatusForbidden, responses.ErrorResponse{
+				Code:  "9b1d3f5a-7c9e-4b1d-8f5a-7c9e1b3d5f7b",
+				Error: "only the owner of the conversation can do this",
+			})
+			return
+		}
+		reqCtx.Next()
+	}
+}
+
+// GetConversationAccessFromContext returns the access resolved by the conversation middleware
+func GetConversationAccessFromContext(reqCtx *gin.Context) ConversationAccess {
+	access, ok := reqCtx.Get(string(ConversationContextAccess))
+	if !ok {
+		return ""
+	}
+	v, _ := access.(ConversationAccess)
+	return v
+}
+
 func SetConversationFromContext(reqCtx *gin.Context, conv *Conversation) {
 	reqCtx.Set(string(ConversationContextEntity), conv)
 }
@@ -379,6 +554,15 @@ func GetConversationFromContext(reqCtx *gin.Context) (*Conversation, bool) {
 }
 
 func (s *ConversationService) GetConversationItemMiddleWare() gin.HandlerFunc {
+	return s.conversationItemMiddleWare(false)
+}
+
+// GetConversationItemWithTrashMiddleWare also resolves items that are in the trash
+func (s *ConversationService) GetConversationItemWithTrashMiddleWare() gin.HandlerFunc {
+	return s.conversationItemMiddleWare(true)
+}
+
+func (s *ConversationService) conversationItemMiddleWare(includeDeleted bool) gin.HandlerFunc {
 	return func(reqCtx *gin.Context) {
 		ctx := reqCtx.Request.Context()
 		conv, ok := GetConversationFromContext(reqCtx)
@@ -400,6 +584,7 @@ func (s *ConversationService) GetConversationItemMiddleWare() gin.HandlerFunc {
 		entities, err := s.FindItemsByFilter(ctx, ItemFilter{
 			PublicID:       &publicID,
 			ConversationID: &conv.ID,
+			IncludeDeleted: includeDeleted,
 		}, nil)
 
 		if err != nil {
@@ -0,0 +1,186 @@
+package conversationtitle
+
+import (
+	"context"
+	"strings"
+	"time"
+	"unicode"
+
+	openai "github.com/sashabaranov/go-openai"
+
+	"menlo.ai/indigo-api-gateway/app/domain/common"
+	"menlo.ai/indigo-api-gateway/app/domain/conversation"
+	domainmodel "menlo.ai/indigo-api-gateway/app/domain/model"
+	"menlo.ai/indigo-api-gateway/app/domain/settings"
+	"menlo.ai/indigo-api-gateway/app/infrastructure/inference"
+	"menlo.ai/indigo-api-gateway/app/utils/logger"
+)
+
+const (
+	// MaxGeneratedTitleLength bounds the stored title in runes
+	MaxGeneratedTitleLength = 80
+	// TitleMaxTokens bounds the completion requested from the title model
+	TitleMaxTokens = 32
+
+	titleTimeout = 30 * time.Second
+	// titleInputLength clips each message sent to the title model, in runes
+	titleInputLength = 2000
+
+	// titleQuotes are the quotes and markdown trimmed around a generated title
+	titleQuotes = " \t\"'`*#“”‘’"
+
+	titleInstruction = "Write a short, descriptive title for the conversation below in the language of the user. " +
+		"Use at most six words. Respond with the title only, without quotes or trailing punctuation."
+)
+
+type TitleService struct {
+	settingsService     *settings.Service
+	providerRegistry    *domainmodel.ProviderRegistryService
+	inferenceProvider   *inference.InferenceProvider
+	conversationService *conversation.ConversationService
+}
+
+func NewTitleService(
+	settingsService *settings.Service,
+	providerRegistry *domainmodel.ProviderRegistryService,
+	inferenceProvider *inference.InferenceProvider,
+	conversationService *conversation.ConversationService,
+) *TitleService {
+	return &TitleService{
+		settingsService:     settingsService,
+		providerRegistry:    providerRegistry,
+		inferenceProvider:   inferenceProvider,
+		conversationService: conversationService,
+	}
+}
+
+// TitleInput describes the first exchange of a conversation that still carries a placeholder title
+type TitleInput struct {
+	OrganizationID uint
+	ProjectIDs     []uint
+	Conversation   *conversation.Conversation
+	// PlaceholderTitle is the title assigned before generation; any other stored title counts as a user rename
+	PlaceholderTitle string
+	UserMessage      string
+	AssistantMessage string
+	// Provider and Model serve the request and are used when the organization has no title model configured
+	Provider *domainmodel.Provider
+	Model    string
+}
+
+// GenerateAsync generates and stores a title in the background.
+// The returned channel receives the new title once stored and is closed when generation finishes or is skipped.
+func (s *TitleService) GenerateAsync(ctx context.Context, input TitleInput) <-chan string {
+	result := make(chan string, 1)
+	if input.Conversation == nil || strings.TrimSpace(input.UserMessage) == "" {
+		close(result)
+		return result
+	}
+
+	// The request context ends with the response, so generation runs detached from it
+	backgroundCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), titleTimeout)
+	go func() {
+		defer cancel()
+		defer close(result)
+		title, err := s.Generate(backgroundCtx, input)
+		if err != nil {
+			logger.GetLogger().Errorf("conversation title generation failed for %s: %s - %s", input.Conversation.PublicID, err.GetCode(), err.Error())
+			return
+		}
+		if title != "" {
+			result <- title
+		}
+	}()
+	return result
+}
+
+// Generate asks the configured title model for a title and stores it unless the user renamed the conversation.
+// It returns an empty title when generation is disabled or the title was left untouched.
+func (s *TitleService) Generate(ctx context.Context, input TitleInput) (string, *common.Error) {
+	config, err := s.settingsService.GetConversationTitleSettings(ctx, input.OrganizationID)
+	if err != nil {
+		return "", common.NewError(err, "5d8e2f1a-7c4b-4e9d-a3f6-0b1c2d3e4f5a")
+	}
+	if !config.Enabled {
+		return "", nil
+	}
+
+	provider, model := s.resolveTitleModel(ctx, input, strings.TrimSpace(config.Model))
+	if provider == nil {
+		return "", common.NewErrorWithMessage("no provider available for title generation", "9e1f3a5c-2b4d-4c6e-8f0a-7b9d1c3e5a7f")
+	}
+	chatClient, clientErr := s.inferenceProvider.GetChatCompletionClient(provider)
+	if clientErr != nil {
+		return "", common.NewError(clientErr, "2c4e6a8b-0d1f-4e3a-9b5c-7d9f1a3c5e7b")
+	}
+
+	prompt := "User: " + clip(input.UserMessage, titleInputLength)
+	if strings.TrimSpace(input.AssistantMessage) != "" {
+		prompt += "\n\nAssistant: " + clip(input.AssistantMessage, titleInputLength)
+	}
+	response, callErr := chatClient.CreateChatCompletion(ctx, "", openai.ChatCompletionRequest{
+		Model:     model,
+		MaxTokens: TitleMaxTokens,
+		Messages: []openai.ChatCompletionMessage{
+			{Role: openai.ChatMessageRoleSystem, Content: titleInstruction},
+			{Role: openai.ChatMessageRoleUser, Content: prompt},
+		},
+	})
+	if callErr != nil {
+		return "", common.NewError(callErr, "7a9c1e3f-5b7d-4f9a-b1c3-e5f7a9c1e3b5")
+	}
+	if len(response.Choices) == 0 {
+		return "", common.NewErrorWithMessage("title model returned no choices", "4b6d8f0a-2c4e-4a6c-8e0a-2c4e6a8c0e2a")
+	}
+	title := sanitizeTitle(response.Choices[0].Message.Content)
+	if title == "" {
+		return "", common.NewErrorWithMessage("title model returned an empty title", "1f3a5c7e-9b1d-4d3f-a5c7-e9b1d3f5a7c9")
+	}
+
+	return s.storeTitle(ctx, input, title)
+}
+
+// storeTitle replaces the placeholder title; a rename made while generating is kept
+func (s *TitleService) storeTitle(ctx context.Context, input TitleInput, title string) (string, *common.Error) {
+	replaced, err := s.conversationService.ReplacePlaceholderTitle(ctx, input.Conversation, strings.TrimSpace(input.PlaceholderTitle), title)
+	if err != nil || !replaced {
+		return "", err
+	}
+	return title, nil
+}
+
+// resolveTitleModel picks the organization's title model, falling back to the request's own model
+func (s *TitleService) resolveTitleModel(ctx context.Context, input TitleInput, configured string) (*domainmodel.Provider, string) {
+	if configured == "" || configured == input.Model {
+		return input.Provider, input.Model
+	}
+	provider, err := s.providerRegistry.GetProviderForModel(ctx, configured, input.OrganizationID, input.ProjectIDs)
+	if err != nil || provider == nil {
+		logger.GetLogger().Warnf("title model '%s' is not available, using '%s'", configured, input.Model)
+		return input.Provider, input.Model
+	}
+	return provider, configured
+}
+
+// sanitizeTitle keeps the first line of the model output without quotes, markdown or trailing punctuation
+func sanitizeTitle(raw string) string {
+	title := strings.TrimSpace(raw)
+	if line, _, found := strings.Cut(title, "\n"); found {
+		title = strings.TrimSpace(line)
+	}
+	title = strings.TrimPrefix(title, "Title:")
+	title = strings.Trim(title, titleQuotes)
+	// quotes and punctuation can alternate at the end, as in “Title”; or "Title."
+	title = strings.TrimRightFunc(title, func(r rune) bool {
+		return r == '.' || r == ':' || r == ';' || r == ',' || unicode.IsSpace(r) || strings.ContainsRune(titleQuotes, r)
+	})
+	return clip(title, MaxGeneratedTitleLength)
+}
+
+func clip(value string, limit int) string {
+	runes := []rune(strings.TrimSpace(value))
+	if len(runes) <= limit {
+		return string(runes)
+	}
+	return strings.TrimSpace(string(runes[:limit]))
+}
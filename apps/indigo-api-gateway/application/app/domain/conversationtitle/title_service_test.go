@@ -0,0 +1,34 @@
+package conversationtitle
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSanitizeTitle(t *testing.T) {
+	cases := map[string]string{
+		"Planning a Trip to Japan":           "Planning a Trip to Japan",
+		"\"Planning a Trip to Japan.\"":      "Planning a Trip to Japan",
+		"Title: **Go Generics Overview**":    "Go Generics Overview",
+		"# Weekly Report:\nSecond line here": "Weekly Report",
+		"“Résumé Tips”;":                     "Résumé Tips",
+		"  `Code Review`  ":                  "Code Review",
+		"":                                   "",
+	}
+	for raw, expected := range cases {
+		if got := sanitizeTitle(raw); got != expected {
+			t.Errorf("sanitizeTitle(%q) = %q, expected %q", raw, got, expected)
+		}
+	}
+}
+
+func TestSanitizeTitleClipsRunes(t *testing.T) {
+	title := sanitizeTitle(strings.Repeat("é", MaxGeneratedTitleLength+20))
+	if !utf8.ValidString(title) {
+		t.Fatal("expected a valid utf-8 title")
+	}
+	if count := utf8.RuneCountInString(title); count != MaxGeneratedTitleLength {
+		t.Fatalf("expected %d runes, got %d", MaxGeneratedTitleLength, count)
+	}
+}
@@ -0,0 +1,79 @@
+package cron
+
+import (
+	"context"
+	"time"
+
+	"menlo.ai/indigo-api-gateway/app/domain/common"
+	"menlo.ai/indigo-api-gateway/app/domain/query"
+)
+
+// Triggers of a job run
+const (
+	TriggerSchedule = "schedule"
+	TriggerManual   = "manual"
+)
+
+// Statuses of a job run; runs skipped because another replica holds the lock are not recorded
+const (
+	RunStatusRunning   = "running"
+	RunStatusSucceeded = "succeeded"
+	RunStatusFailed    = "failed"
+)
+
+// Job is a named task run on its schedule by a single replica at a time
+type Job struct {
+	Name        string
+	Description string
+	// Schedule is the default crontab schedule, the cron_jobs setting can replace it
+	Schedule string
+	// Timeout bounds a run, the lock of the job is held as long so it never expires while the job runs
+	Timeout time.Duration
+	// Run does the work and returns a short summary of it, kept in the run history
+	Run func(ctx context.Context) (string, *common.Error)
+}
+
+// JobState is a job with the schedule in effect and its last run
+type JobState struct {
+	Job      *Job
+	Schedule string
+	Enabled  bool
+	// Overridden is set when the schedule or the enabled state come from the settings
+	Overridden bool
+	LastRun    *JobRun
+}
+
+// JobRun is one execution of a job by the replica that acquired its lock
+type JobRun struct {
+	ID          uint
+	PublicID    string
+	Job         string
+	Trigger     string
+	Status      string
+	TriggeredBy *string
+	Hostname    string
+	Summary     *string
+	Error       *string
+	StartedAt   time.Time
+	FinishedAt  *time.Time
+	DurationMs  int64
+}
+
+type JobRunFilter struct {
+	PublicID *string
+	Job      *string
+	Status   *string
+}
+
+type JobRunRepository interface {
+	Create(ctx context.Context, run *JobRun) error
+	Update(ctx context.Context, run *JobRun) error
+	FindByFilter(ctx context.Context, filter JobRunFilter, pagination *query.Pagination) ([]*JobRun, error)
+	Count(ctx context.Context, filter JobRunFilter) (int64, error)
+	// FindLatest returns the last run of each job by job name
+	FindLatest(ctx context.Context) (map[string]*JobRun, error)
+	// FailStale marks the runs of the job still running since before startedBefore as failed
+	FailStale(ctx context.Context, job string, startedBefore time.Time, message string) (int64, error)
+	// DeleteBefore removes up to limit runs started before cutoff and returns how many were removed
+	DeleteBefore(ctx context.Context, cutoff time.Time, limit int) (int64, error)
+}
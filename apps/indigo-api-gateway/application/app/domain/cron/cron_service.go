@@ -2,21 +2,566 @@ package cron
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/go-redsync/redsync/v4"
 	"github.com/mileusna/crontab"
+	"menlo.ai/indigo-api-gateway/app/domain/common"
+	"menlo.ai/indigo-api-gateway/app/domain/mcp/mcpactivity"
+	"menlo.ai/indigo-api-gateway/app/domain/organization"
+	"menlo.ai/indigo-api-gateway/app/domain/query"
+	"menlo.ai/indigo-api-gateway/app/domain/retention"
+	"menlo.ai/indigo-api-gateway/app/domain/settings"
+	"menlo.ai/indigo-api-gateway/app/infrastructure/cache"
+	"menlo.ai/indigo-api-gateway/app/utils/idgen"
+	"menlo.ai/indigo-api-gateway/app/utils/logger"
+	"menlo.ai/indigo-api-gateway/app/utils/metrics"
 	"menlo.ai/indigo-api-gateway/config/environment_variables"
 )
 
+const (
+	retentionJob        = "conversation_retention"
+	mcpActivityPruneJob = "mcp_activity_prune"
+	runHistoryPruneJob  = "cron_run_history_prune"
+
+	// reconcileSchedule reloads the job settings, a change made on another replica applies within a minute
+	reconcileSchedule = "* * * * *"
+	lockPrefix        = "cron:lock:"
+	// slotLockPrefix names the claims of the scheduled runs, one per job and minute, kept until they expire
+	slotLockPrefix = "cron:slot:"
+	// errCodeJobRunning is returned when another replica holds the lock of the job
+	errCodeJobRunning = "4c1e8a7d-2b9f-4d6e-a3c5-8f7b1d4e9a26"
+
+	defaultRunRetentionDays = 30
+	// maxMessageLength bounds the summary and the error kept for a run
+	maxMessageLength = 1024
+	recordTimeout    = 5 * time.Second
+	// staleRunGrace is added to the timeout of a job before a run still marked running is considered interrupted
+	staleRunGrace          = 5 * time.Minute
+	historyPruneBatchSize  = 1000
+	maxHistoryPruneBatches = 100
+)
+
+// mutexFactory creates the distributed locks of the jobs
+type mutexFactory interface {
+	NewMutex(name string, options ...redsync.Option) *redsync.Mutex
+}
+
+// CronService runs the named jobs on their schedule. Every replica schedules them, the replica that claims the
+// slot of a job runs it and records the run, the others skip it.
 type CronService struct {
+	repo            JobRunRepository
+	settingsService *settings.Service
+	cache           mutexFactory
+	jobs            []*Job
+	hostname        string
+
+	mu      sync.Mutex
+	ctx     context.Context
+	ctab    *crontab.Crontab
+	applied map[string]string
 }
 
-func NewCronService() *CronService {
-	return &CronService{}
+func NewCronService(
+	repo JobRunRepository,
+	settingsService *settings.Service,
+	retentionService *retention.RetentionService,
+	mcpActivityService *mcpactivity.ActivityService,
+	cacheService *cache.RedisCacheService,
+) *CronService {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+	cs := &CronService{
+		repo:            repo,
+		settingsService: settingsService,
+		cache:           cacheService,
+		hostname:        hostname,
+	}
+	cs.jobs = []*Job{
+		{
+			Name:        retentionJob,
+			Description: "Archives and purges conversations according to the retention policies of the organizations",
+			Schedule:    "17 * * * *",
+			Timeout:     30 * time.Minute,
+			Run: func(ctx context.Context) (string, *common.Error) {
+				return runRetention(ctx, retentionService)
+			},
+		},
+		{
+			Name:        mcpActivityPruneJob,
+			Description: "Removes the MCP activity older than MCP_ACTIVITY_RETENTION_DAYS",
+			Schedule:    "47 * * * *",
+			Timeout:     10 * time.Minute,
+			Run: func(ctx context.Context) (string, *common.Error) {
+				deleted, err := mcpActivityService.Prune(ctx)
+				if err != nil {
+					return "", err
+				}
+				return fmt.Sprintf("pruned %d MCP activity entries", deleted), nil
+			},
+		},
+		{
+			Name:        runHistoryPruneJob,
+			Description: "Removes the cron runs older than CRON_RUN_RETENTION_DAYS and fails the interrupted ones",
+			Schedule:    "37 * * * *",
+			Timeout:     10 * time.Minute,
+			Run:         cs.pruneRunHistory,
+		},
+	}
+	return cs
 }
 
+// Start schedules the jobs on ctab; ctx is the context of the runs, cancelled on shutdown
 func (cs *CronService) Start(ctx context.Context, ctab *crontab.Crontab) {
+	cs.mu.Lock()
+	cs.ctx = ctx
+	cs.ctab = ctab
+	cs.mu.Unlock()
+	cs.reconcile(ctx)
+}
+
+// Jobs returns the registered jobs
+func (cs *CronService) Jobs() []*Job {
+	return cs.jobs
+}
+
+func (cs *CronService) findJob(name string) *Job {
+	for _, job := range cs.jobs {
+		if job.Name == name {
+			return job
+		}
+	}
+	return nil
+}
+
+// background returns the context of the runs, cancelled on shutdown
+func (cs *CronService) background() context.Context {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	if cs.ctx == nil {
+		return context.Background()
+	}
+	return cs.ctx
+}
+
+// ListJobs returns the jobs with the schedule in effect and their last run
+func (cs *CronService) ListJobs(ctx context.Context) ([]*JobState, *common.Error) {
+	overrides, err := cs.overrides(ctx)
+	if err != nil {
+		return nil, err
+	}
+	latest, latestErr := cs.repo.FindLatest(ctx)
+	if latestErr != nil {
+		return nil, common.NewError(latestErr, "8e3b6d1a-5f2c-4a9e-b7d4-1c6f9a3e8b52")
+	}
+	states := cs.resolve(overrides)
+	for _, state := range states {
+		state.LastRun = latest[state.Job.Name]
+	}
+	return states, nil
+}
+
+// GetJob returns the job with the name, or nil
+func (cs *CronService) GetJob(ctx context.Context, name string) (*JobState, *common.Error) {
+	states, err := cs.ListJobs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, state := range states {
+		if state.Job.Name == name {
+			return state, nil
+		}
+	}
+	return nil, nil
+}
+
+type UpdateJobInput struct {
+	// Schedule replaces the schedule of the job when set, an empty value restores its default
+	Schedule   *string
+	Enabled    *bool
+	ActorID    *uint
+	ActorEmail *string
+}
+
+// UpdateJob stores the schedule or the enabled state of a job in the settings and applies them on this replica
+// right away, the other replicas pick them up within a minute
+func (cs *CronService) UpdateJob(ctx context.Context, name string, input UpdateJobInput) (*JobState, *common.Error) {
+	job := cs.findJob(name)
+	if job == nil {
+		return nil, common.NewErrorWithMessage("cron job not found", "2f8d5a1c-7e4b-4c9a-8b3f-6d1e9c4a7b15")
+	}
+	organizationID, err := defaultOrganizationID()
+	if err != nil {
+		return nil, err
+	}
+	overrides, err := cs.overrides(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	override := overrides[name]
+	if input.Schedule != nil {
+		schedule := strings.Join(strings.Fields(*input.Schedule), " ")
+		override.Schedule = nil
+		if schedule != "" && schedule != job.Schedule {
+			if validateErr := ValidateSchedule(schedule); validateErr != nil {
+				return nil, common.NewError(validateErr, "9a4e7c2b-1d6f-4b8e-a5c3-7e2b9d4f1a68")
+			}
+			override.Schedule = &schedule
+		}
+	}
+	if input.Enabled != nil {
+		override.Enabled = nil
+		if !*input.Enabled {
+			override.Enabled = input.Enabled
+		}
+	}
+	if _, updateErr := cs.settingsService.UpdateCronJobOverride(ctx, organizationID, settings.UpdateCronJobOverrideInput{
+		Job:        name,
+		Override:   override,
+		ActorID:    input.ActorID,
+		ActorEmail: input.ActorEmail,
+	}); updateErr != nil {
+		return nil, common.NewError(updateErr, "6b2d9f4e-8a1c-4e7b-9d5a-3f8c1e6b2d47")
+	}
+
+	cs.reconcile(cs.background())
+	return cs.GetJob(ctx, name)
+}
+
+// TriggerJob runs a job now, whether it is enabled or not. The run is started in the background once the
+// lock of the job is acquired and returned as it started.
+func (cs *CronService) TriggerJob(ctx context.Context, name string, triggeredBy *string) (*JobRun, *common.Error) {
+	job := cs.findJob(name)
+	if job == nil {
+		return nil, common.NewErrorWithMessage("cron job not found", "5d9a2e6f-3c8b-4f1d-a7e4-2b6f9d3c8a51")
+	}
+	run, mutex, err := cs.begin(ctx, job, TriggerManual, triggeredBy)
+	if err != nil {
+		return nil, err
+	}
+	started := *run
+	go cs.finish(cs.background(), job, run, mutex)
+	return &started, nil
+}
+
+func (cs *CronService) ListRuns(ctx context.Context, filter JobRunFilter, pagination *query.Pagination) ([]*JobRun, int64, *common.Error) {
+	runs, err := cs.repo.FindByFilter(ctx, filter, pagination)
+	if err != nil {
+		return nil, 0, common.NewError(err, "1e7c4b9a-6d2f-4a8e-b3c5-9f1a6e4d2c73")
+	}
+	total, err := cs.repo.Count(ctx, filter)
+	if err != nil {
+		return nil, 0, common.NewError(err, "7b3f8e1d-4c6a-4d9b-a2e5-6c9d3b7f1e84")
+	}
+	return runs, total, nil
+}
+
+// FindRun returns the run with the public id within the filter, or nil
+func (cs *CronService) FindRun(ctx context.Context, filter JobRunFilter, publicID string) (*JobRun, *common.Error) {
+	filter.PublicID = &publicID
+	runs, err := cs.repo.FindByFilter(ctx, filter, nil)
+	if err != nil {
+		return nil, common.NewError(err, "3a6d1f8c-9e4b-4c2a-8f7d-5b1e3c9a6d28")
+	}
+	if len(runs) == 0 {
+		return nil, nil
+	}
+	return runs[0], nil
+}
+
+// ValidateSchedule checks a crontab schedule, five fields in the local time of the server
+func ValidateSchedule(schedule string) error {
+	validator := scheduleValidator()
+	validatorMu.Lock()
+	defer validatorMu.Unlock()
+	defer validator.Clear()
+	return validator.AddJob(schedule, func() {})
+}
+
+var (
+	// scheduleValidator parses the schedules with the parser of the scheduler, its ticker is stopped so it
+	// never runs anything
+	scheduleValidator = sync.OnceValue(func() *crontab.Crontab {
+		ctab := crontab.New()
+		ctab.Shutdown()
+		return ctab
+	})
+	validatorMu sync.Mutex
+)
 
-	ctab.AddJob("* * * * *", func() {
-		environment_variables.EnvironmentVariables.LoadFromEnv()
+func defaultOrganizationID() (uint, *common.Error) {
+	if organization.DEFAULT_ORGANIZATION == nil {
+		return 0, common.NewErrorWithMessage("default organization is not initialized", "8c5f2a9e-3b7d-4e1c-9a6f-4d8b2e7c1a39")
+	}
+	return organization.DEFAULT_ORGANIZATION.ID, nil
+}
+
+// overrides returns the cron_jobs setting of the default organization, where the jobs of the gateway are configured
+func (cs *CronService) overrides(ctx context.Context) (map[string]settings.CronJobOverride, *common.Error) {
+	if organization.DEFAULT_ORGANIZATION == nil {
+		return map[string]settings.CronJobOverride{}, nil
+	}
+	overrides, err := cs.settingsService.GetCronJobOverrides(ctx, organization.DEFAULT_ORGANIZATION.ID)
+	if err != nil {
+		return nil, common.NewError(err, "4f9b6e2d-1a8c-4d7e-b5a3-8e2c6f9b4d17")
+	}
+	return overrides, nil
+}
+
+func (cs *CronService) resolve(overrides map[string]settings.CronJobOverride) []*JobState {
+	states := make([]*JobState, 0, len(cs.jobs))
+	for _, job := range cs.jobs {
+		state := &JobState{Job: job, Schedule: job.Schedule, Enabled: true}
+		if override, ok := overrides[job.Name]; ok {
+			if override.Schedule != nil {
+				state.Schedule = *override.Schedule
+				state.Overridden = true
+			}
+			if override.Enabled != nil {
+				state.Enabled = *override.Enabled
+				state.Overridden = true
+			}
+		}
+		states = append(states, state)
+	}
+	return states
+}
+
+// reconcile registers the jobs on the crontab with the schedules in effect, when they changed
+func (cs *CronService) reconcile(ctx context.Context) {
+	overrides, err := cs.overrides(ctx)
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	if cs.ctab == nil {
+		return
+	}
+	if err != nil {
+		logger.GetLogger().Errorf("failed to load the cron job settings: %s - %s", err.GetCode(), err.Error())
+		if cs.applied != nil {
+			return
+		}
+	}
+
+	schedules := make(map[string]string, len(cs.jobs))
+	for _, state := range cs.resolve(overrides) {
+		if state.Enabled {
+			schedules[state.Job.Name] = state.Schedule
+		}
+	}
+	if cs.applied != nil && maps.Equal(schedules, cs.applied) {
+		return
+	}
+
+	cs.ctab.Clear()
+	cs.ctab.MustAddJob(reconcileSchedule, func() {
+		cs.reconcile(cs.background())
 	})
+	for _, job := range cs.jobs {
+		schedule, ok := schedules[job.Name]
+		if !ok {
+			continue
+		}
+		run := func() {
+			cs.runScheduled(cs.background(), job, time.Now())
+		}
+		if err := cs.ctab.AddJob(schedule, run); err != nil {
+			logger.GetLogger().Errorf("invalid schedule %q for cron job %s, using %q: %v", schedule, job.Name, job.Schedule, err)
+			schedules[job.Name] = job.Schedule
+			cs.ctab.MustAddJob(job.Schedule, run)
+		}
+	}
+	if cs.applied != nil {
+		logger.GetLogger().Infof("cron job schedules updated: %v", schedules)
+	}
+	cs.applied = schedules
+}
+
+// runScheduled runs the job for the minute of tick. The slot is claimed until it expires rather than released
+// with the lock of the job, so a replica whose tick comes after the run finished does not run it again.
+func (cs *CronService) runScheduled(ctx context.Context, job *Job, tick time.Time) {
+	slot := cs.cache.NewMutex(fmt.Sprintf("%s%s:%d", slotLockPrefix, lockName(job), tick.Truncate(time.Minute).Unix()),
+		redsync.WithExpiry(job.Timeout), redsync.WithTries(1))
+	err := tryLock(ctx, job, slot)
+	var run *JobRun
+	var mutex *redsync.Mutex
+	if err == nil {
+		run, mutex, err = cs.begin(ctx, job, TriggerSchedule, nil)
+	}
+	if err != nil {
+		if err.GetCode() == errCodeJobRunning {
+			logger.GetLogger().Debugf("skipping cron job %s, lock not acquired", job.Name)
+			metrics.ObserveCronJob(job.Name, metrics.OutcomeSkipped, 0)
+			return
+		}
+		logger.GetLogger().Errorf("failed to start cron job %s: %s - %s", job.Name, err.GetCode(), err.Error())
+		metrics.ObserveCronJob(job.Name, metrics.OutcomeFailure, 0)
+		return
+	}
+	cs.finish(ctx, job, run, mutex)
+}
+
+func lockName(job *Job) string {
+	return strings.ReplaceAll(job.Name, "_", "-")
+}
+
+// tryLock acquires the mutex once, a mutex held elsewhere is reported with errCodeJobRunning
+func tryLock(ctx context.Context, job *Job, mutex *redsync.Mutex) *common.Error {
+	if err := mutex.TryLockContext(ctx); err != nil {
+		var taken *redsync.ErrTaken
+		if errors.Is(err, redsync.ErrFailed) || errors.As(err, &taken) {
+			return common.NewErrorWithMessage(fmt.Sprintf("cron job %s is already running", job.Name), errCodeJobRunning)
+		}
+		return common.NewError(err, "6e1a9d4c-2f7b-4b8e-9c3d-7a5f2e8b1c64")
+	}
+	return nil
+}
+
+// begin acquires the lock of the job and records the start of its run; the lock keeps the scheduled and the
+// manual runs of a job from overlapping and is released when the run finishes
+func (cs *CronService) begin(ctx context.Context, job *Job, trigger string, triggeredBy *string) (*JobRun, *redsync.Mutex, *common.Error) {
+	mutex := cs.cache.NewMutex(lockPrefix+lockName(job), redsync.WithExpiry(job.Timeout), redsync.WithTries(1))
+	if err := tryLock(ctx, job, mutex); err != nil {
+		return nil, nil, err
+	}
+
+	run := &JobRun{
+		Job:         job.Name,
+		Trigger:     trigger,
+		Status:      RunStatusRunning,
+		TriggeredBy: triggeredBy,
+		Hostname:    cs.hostname,
+		StartedAt:   time.Now(),
+	}
+	publicID, err := idgen.GenerateSecureID("cronrun", 24)
+	if err == nil {
+		run.PublicID = publicID
+		err = cs.repo.Create(ctx, run)
+	}
+	if err != nil {
+		cs.unlock(job, mutex)
+		return nil, nil, common.NewError(err, "9f4c7b2e-5a1d-4e6c-b8a3-2d7e4f9c1b56")
+	}
+	return run, mutex, nil
+}
+
+// finish runs the job, records the outcome of the run and releases the lock
+func (cs *CronService) finish(ctx context.Context, job *Job, run *JobRun, mutex *redsync.Mutex) {
+	defer cs.unlock(job, mutex)
+
+	runCtx, cancel := context.WithTimeout(ctx, job.Timeout)
+	defer cancel()
+	summary, runErr := invoke(runCtx, job)
+
+	finished := time.Now()
+	run.FinishedAt = &finished
+	run.DurationMs = finished.Sub(run.StartedAt).Milliseconds()
+	if summary != "" {
+		run.Summary = truncate(summary)
+	}
+	outcome := metrics.OutcomeSuccess
+	run.Status = RunStatusSucceeded
+	if runErr != nil {
+		outcome = metrics.OutcomeFailure
+		run.Status = RunStatusFailed
+		run.Error = truncate(fmt.Sprintf("%s: %s", runErr.GetCode(), runErr.Error()))
+		logger.GetLogger().Errorf("cron job %s failed: %s - %s", job.Name, runErr.GetCode(), runErr.Error())
+	} else {
+		logger.GetLogger().Infof("cron job %s finished in %s: %s", job.Name, finished.Sub(run.StartedAt), summary)
+	}
+	metrics.ObserveCronJob(job.Name, outcome, finished.Sub(run.StartedAt))
+
+	// The run is recorded even when it was cancelled by the shutdown
+	recordCtx, cancelRecord := context.WithTimeout(context.WithoutCancel(ctx), recordTimeout)
+	defer cancelRecord()
+	if err := cs.repo.Update(recordCtx, run); err != nil {
+		logger.GetLogger().Errorf("failed to record the run of cron job %s: %v", job.Name, err)
+	}
+}
+
+func (cs *CronService) unlock(job *Job, mutex *redsync.Mutex) {
+	if _, err := mutex.UnlockContext(context.Background()); err != nil {
+		logger.GetLogger().Warnf("failed to release the lock of cron job %s: %v", job.Name, err)
+	}
+}
+
+// invoke runs the job, a panic fails the run
+func invoke(ctx context.Context, job *Job) (summary string, err *common.Error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = common.NewErrorWithMessage(fmt.Sprintf("panic: %v", r), "3d8b1f6a-7c4e-4a2d-9b5f-1e6c8a3d7f92")
+		}
+	}()
+	return job.Run(ctx)
+}
+
+func truncate(message string) *string {
+	if len(message) > maxMessageLength {
+		message = message[:maxMessageLength]
+	}
+	return &message
+}
+
+// RunRetentionDays returns how long the run history is kept
+func RunRetentionDays() int {
+	if days := environment_variables.Current().CRON_RUN_RETENTION_DAYS; days > 0 {
+		return days
+	}
+	return defaultRunRetentionDays
+}
+
+// pruneRunHistory fails the runs whose replica stopped before recording their outcome and removes the runs
+// older than the retention period
+func (cs *CronService) pruneRunHistory(ctx context.Context) (string, *common.Error) {
+	var interrupted int64
+	for _, job := range cs.jobs {
+		failed, err := cs.repo.FailStale(ctx, job.Name, time.Now().Add(-job.Timeout-staleRunGrace), "the run was interrupted before it finished")
+		if err != nil {
+			return "", common.NewError(err, "2b7e5c9f-8d3a-4f1b-a6e2-4c9f7b1d5e38")
+		}
+		interrupted += failed
+	}
+
+	cutoff := time.Now().AddDate(0, 0, -RunRetentionDays())
+	var deleted int64
+	for i := 0; i < maxHistoryPruneBatches; i++ {
+		count, err := cs.repo.DeleteBefore(ctx, cutoff, historyPruneBatchSize)
+		if err != nil {
+			return "", common.NewError(err, "7f2a4d8e-6b1c-4e9a-8d3f-5a2e7c1b9f46")
+		}
+		deleted += count
+		if count < historyPruneBatchSize {
+			break
+		}
+	}
+	return fmt.Sprintf("removed %d runs, failed %d interrupted runs", deleted, interrupted), nil
+}
+
+func runRetention(ctx context.Context, retentionService *retention.RetentionService) (string, *common.Error) {
+	reports, err := retentionService.Run(ctx)
+	if err != nil {
+		return "", err
+	}
+	var total retention.Report
+	for _, report := range reports {
+		if report.ArchivedConversations == 0 && report.PurgedConversations == 0 && report.PurgedItems == 0 && report.PurgedResponses == 0 {
+			continue
+		}
+		logger.GetLogger().Infof("retention run for organization %d: archived %d conversations, purged %d conversations, %d items and %d responses",
+			report.OrganizationID, report.ArchivedConversations, report.PurgedConversations, report.PurgedItems, report.PurgedResponses)
+		total.ArchivedConversations += report.ArchivedConversations
+		total.PurgedConversations += report.PurgedConversations
+		total.PurgedItems += report.PurgedItems
+		total.PurgedResponses += report.PurgedResponses
+	}
+	return fmt.Sprintf("archived %d conversations, purged %d conversations, %d items and %d responses in %d organizations",
+		total.ArchivedConversations, total.PurgedConversations, total.PurgedItems, total.PurgedResponses, len(reports)), nil
 }
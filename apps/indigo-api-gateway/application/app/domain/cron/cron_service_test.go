@@ -0,0 +1,239 @@
+package cron
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-redsync/redsync/v4"
+	"github.com/go-redsync/redsync/v4/redis"
+	"menlo.ai/indigo-api-gateway/app/domain/common"
+	"menlo.ai/indigo-api-gateway/app/domain/query"
+	"menlo.ai/indigo-api-gateway/app/domain/settings"
+)
+
+func TestResolveAppliesOverrides(t *testing.T) {
+	cs := NewCronService(nil, nil, nil, nil, nil)
+	schedule := "*/5 * * * *"
+	disabled := false
+	states := cs.resolve(map[string]settings.CronJobOverride{
+		retentionJob:        {Schedule: &schedule},
+		mcpActivityPruneJob: {Enabled: &disabled},
+	})
+	if len(states) != len(cs.Jobs()) {
+		t.Fatalf("expected a state per job, got %d", len(states))
+	}
+	for _, state := range states {
+		switch state.Job.Name {
+		case retentionJob:
+			if state.Schedule != schedule || !state.Enabled || !state.Overridden {
+				t.Fatalf("unexpected retention state %+v", state)
+			}
+		case mcpActivityPruneJob:
+			if state.Schedule != state.Job.Schedule || state.Enabled || !state.Overridden {
+				t.Fatalf("unexpected MCP activity prune state %+v", state)
+			}
+		default:
+			if state.Schedule != state.Job.Schedule || !state.Enabled || state.Overridden {
+				t.Fatalf("unexpected default state %+v", state)
+			}
+		}
+		if err := ValidateSchedule(state.Job.Schedule); err != nil {
+			t.Fatalf("invalid default schedule of %s: %v", state.Job.Name, err)
+		}
+	}
+}
+
+func TestValidateSchedule(t *testing.T) {
+	for _, schedule := range []string{"* * * * *", "0 3 * * 1-5", "*/15 0,12 1 * *"} {
+		if err := ValidateSchedule(schedule); err != nil {
+			t.Fatalf("expected %q to be valid: %v", schedule, err)
+		}
+	}
+	for _, schedule := range []string{"", "* * * *", "61 * * * *", "hourly"} {
+		if err := ValidateSchedule(schedule); err == nil {
+			t.Fatalf("expected %q to be rejected", schedule)
+		}
+	}
+}
+
+func TestInvokeRecoversPanics(t *testing.T) {
+	_, err := invoke(context.Background(), &Job{
+		Name: "panicking",
+		Run: func(ctx context.Context) (string, *common.Error) {
+			panic("boom")
+		},
+	})
+	if err == nil || err.GetMessage() != "panic: boom" {
+		t.Fatalf("expected the panic to fail the run, got %v", err)
+	}
+}
+
+// memoryRedis is the shared Redis of the replicas, it implements what the mutexes of redsync use
+type memoryRedis struct {
+	mu     sync.Mutex
+	values map[string]string
+	expiry map[string]time.Time
+}
+
+func newMemoryRedis() *memoryRedis {
+	return &memoryRedis{values: map[string]string{}, expiry: map[string]time.Time{}}
+}
+
+// memoryRedisPool hands out the shared Redis as the connection
+type memoryRedisPool struct {
+	redis *memoryRedis
+}
+
+func (p memoryRedisPool) Get(ctx context.Context) (redis.Conn, error) {
+	return p.redis, nil
+}
+
+func (r *memoryRedis) get(name string) (string, bool) {
+	if expiry, ok := r.expiry[name]; ok && time.Now().After(expiry) {
+		delete(r.values, name)
+		delete(r.expiry, name)
+	}
+	value, ok := r.values[name]
+	return value, ok
+}
+
+func (r *memoryRedis) Get(name string) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	value, _ := r.get(name)
+	return value, nil
+}
+
+func (r *memoryRedis) Set(name string, value string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.values[name] = value
+	delete(r.expiry, name)
+	return true, nil
+}
+
+func (r *memoryRedis) SetNX(name string, value string, expiry time.Duration) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.get(name); ok {
+		return false, nil
+	}
+	r.values[name] = value
+	r.expiry[name] = time.Now().Add(expiry)
+	return true, nil
+}
+
+// Eval runs the delete script, the only one used without extending the locks
+func (r *memoryRedis) Eval(script *redis.Script, keysAndArgs ...interface{}) (interface{}, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	name, value := keysAndArgs[0].(string), keysAndArgs[1].(string)
+	current, ok := r.get(name)
+	switch {
+	case !ok:
+		return int64(-1), nil
+	case current != value:
+		return int64(0), nil
+	}
+	delete(r.values, name)
+	delete(r.expiry, name)
+	return int64(1), nil
+}
+
+func (r *memoryRedis) PTTL(name string) (time.Duration, error) {
+	return 0, errors.New("not implemented")
+}
+
+func (r *memoryRedis) Close() error {
+	return nil
+}
+
+type memoryRunRepo struct {
+	mu   sync.Mutex
+	runs []*JobRun
+}
+
+func (r *memoryRunRepo) Create(ctx context.Context, run *JobRun) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.runs = append(r.runs, run)
+	return nil
+}
+
+func (r *memoryRunRepo) Update(ctx context.Context, run *JobRun) error {
+	return nil
+}
+
+func (r *memoryRunRepo) FindByFilter(ctx context.Context, filter JobRunFilter, pagination *query.Pagination) ([]*JobRun, error) {
+	return nil, nil
+}
+
+func (r *memoryRunRepo) Count(ctx context.Context, filter JobRunFilter) (int64, error) {
+	return 0, nil
+}
+
+func (r *memoryRunRepo) FindLatest(ctx context.Context) (map[string]*JobRun, error) {
+	return nil, nil
+}
+
+func (r *memoryRunRepo) FailStale(ctx context.Context, job string, startedBefore time.Time, message string) (int64, error) {
+	return 0, nil
+}
+
+func (r *memoryRunRepo) DeleteBefore(ctx context.Context, cutoff time.Time, limit int) (int64, error) {
+	return 0, nil
+}
+
+func TestScheduledRunOncePerSlot(t *testing.T) {
+	ctx := context.Background()
+	locks := redsync.New(memoryRedisPool{redis: newMemoryRedis()})
+	var runs atomic.Int32
+	job := &Job{
+		Name:    "test_job",
+		Timeout: time.Minute,
+		Run: func(ctx context.Context) (string, *common.Error) {
+			runs.Add(1)
+			return "done", nil
+		},
+	}
+	replicas := make([]*CronService, 2)
+	repos := make([]*memoryRunRepo, 2)
+	for i := range replicas {
+		repos[i] = &memoryRunRepo{}
+		replicas[i] = &CronService{repo: repos[i], cache: locks, jobs: []*Job{job}}
+	}
+
+	slot := time.Date(2025, 10, 1, 3, 17, 0, 0, time.UTC)
+	replicas[0].runScheduled(ctx, job, slot)
+	// the tick of the other replica comes after the run finished, within the same minute
+	replicas[1].runScheduled(ctx, job, slot.Add(2*time.Second))
+	if runs.Load() != 1 || len(repos[0].runs) != 1 || len(repos[1].runs) != 0 {
+		t.Fatalf("expected a single run of the slot, got %d", runs.Load())
+	}
+
+	// the lock of the job is released, the next slot and the manual runs are not blocked
+	replicas[1].runScheduled(ctx, job, slot.Add(time.Hour))
+	if runs.Load() != 2 || len(repos[1].runs) != 1 {
+		t.Fatalf("expected the next slot to run, got %d runs", runs.Load())
+	}
+	run, mutex, err := replicas[0].begin(ctx, job, TriggerManual, nil)
+	if err != nil {
+		t.Fatalf("expected the manual run to start: %v", err)
+	}
+	// a scheduled run does not overlap a manual one
+	replicas[1].runScheduled(ctx, job, slot.Add(2*time.Hour))
+	if runs.Load() != 2 {
+		t.Fatalf("expected the slot to be skipped while the job runs, got %d runs", runs.Load())
+	}
+	replicas[0].finish(ctx, job, run, mutex)
+	if runs.Load() != 3 {
+		t.Fatalf("expected the manual run, got %d runs", runs.Load())
+	}
+	if _, _, err := replicas[1].begin(ctx, job, TriggerManual, nil); err != nil {
+		t.Fatalf("expected the lock to be released after the manual run: %v", err)
+	}
+}
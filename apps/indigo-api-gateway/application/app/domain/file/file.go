@@ -0,0 +1,82 @@
+package file
+
+import (
+	"context"
+	"io"
+	"strings"
+	"time"
+
+	"menlo.ai/indigo-api-gateway/app/domain/query"
+)
+
+type File struct {
+	ID       uint
+	PublicID string
+	UserID   uint
+	// ProjectID is set when the file was uploaded with a project API key
+	ProjectID *uint
+	Filename  string
+	Purpose   FilePurpose
+	Bytes     int64
+	MimeType  string
+	// StorageKey locates the content in the blob storage
+	StorageKey string
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+// IsImage reports whether the file can be passed to the model as an image
+func (f *File) IsImage() bool {
+	return strings.HasPrefix(f.MimeType, "image/")
+}
+
+// IsTextDocument reports whether the file content can be read as text
+func (f *File) IsTextDocument() bool {
+	return documentMimeTypes[f.MimeType]
+}
+
+// @Enum(assistants, vision, user_data)
+type FilePurpose string
+
+const (
+	FilePurposeAssistants FilePurpose = "assistants"
+	FilePurposeVision     FilePurpose = "vision"
+	FilePurposeUserData   FilePurpose = "user_data"
+)
+
+func ValidateFilePurpose(input string) bool {
+	switch FilePurpose(input) {
+	case FilePurposeAssistants, FilePurposeVision, FilePurposeUserData:
+		return true
+	default:
+		return false
+	}
+}
+
+// Owner scopes file access to a user and, for requests made with a project API key, to that project
+type Owner struct {
+	UserID    uint
+	ProjectID *uint
+}
+
+type FileFilter struct {
+	ID        *uint
+	PublicID  *string
+	UserID    *uint
+	ProjectID *uint
+	Purpose   *FilePurpose
+}
+
+type FileRepository interface {
+	Create(ctx context.Context, f *File) error
+	FindByFilter(ctx context.Context, filter FileFilter, pagination *query.Pagination) ([]*File, error)
+	Count(ctx context.Context, filter FileFilter) (int64, error)
+	DeleteByID(ctx context.Context, id uint) error
+}
+
+// BlobStorage stores file contents, drivers live in the infrastructure layer
+type BlobStorage interface {
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
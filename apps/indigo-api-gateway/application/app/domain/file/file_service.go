@@ -0,0 +1,351 @@
+package file
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	openai "github.com/sashabaranov/go-openai"
+
+	"menlo.ai/indigo-api-gateway/app/domain/auth"
+	"menlo.ai/indigo-api-gateway/app/domain/common"
+	"menlo.ai/indigo-api-gateway/app/domain/query"
+	"menlo.ai/indigo-api-gateway/app/interfaces/http/responses"
+	"menlo.ai/indigo-api-gateway/app/utils/idgen"
+	"menlo.ai/indigo-api-gateway/app/utils/webfetch"
+	"menlo.ai/indigo-api-gateway/config/environment_variables"
+)
+
+type FileContextKey string
+
+const (
+	FileContextKeyPublicID FileContextKey = "file_id"
+	FileContextEntity      FileContextKey = "FileContextEntity"
+)
+
+const (
+	// defaultMaxUploadBytes applies when FILE_MAX_UPLOAD_BYTES is not set
+	defaultMaxUploadBytes = 25 * 1024 * 1024
+	// maxImageInputBytes caps images inlined into model requests as data URLs
+	maxImageInputBytes = 20 * 1024 * 1024
+	// maxDocumentInputChars caps the document text inlined into model requests
+	maxDocumentInputChars = 100000
+)
+
+var imageMimeTypes = map[string]bool{
+	"image/png":  true,
+	"image/jpeg": true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+// documentMimeTypes maps the accepted document types to whether their content can be inlined as text
+var documentMimeTypes = map[string]bool{
+	"text/plain":       true,
+	"text/markdown":    true,
+	"text/csv":         true,
+	"text/html":        true,
+	"application/json": true,
+	pdfMimeType:        false,
+}
+
+const pdfMimeType = "application/pdf"
+
+type FileService struct {
+	repo    FileRepository
+	storage BlobStorage
+}
+
+func NewFileService(repo FileRepository, storage BlobStorage) *FileService {
+	return &FileService{
+		repo:    repo,
+		storage: storage,
+	}
+}
+
+// MaxUploadBytes returns the configured upload limit
+func MaxUploadBytes() int64 {
+	if limit := environment_variables.Current().FILE_MAX_UPLOAD_BYTES; limit > 0 {
+		return int64(limit)
+	}
+	return defaultMaxUploadBytes
+}
+
+// UploadInput describes an uploaded file before it is stored
+type UploadInput struct {
+	Filename string
+	Purpose  FilePurpose
+	// MimeType is the type declared by the client, it is sniffed from the content when missing
+	MimeType string
+	Size     int64
+	Body     io.Reader
+}
+
+func (s *FileService) Upload(ctx context.Context, owner Owner, input UploadInput) (*File, *common.Error) {
+	if !ValidateFilePurpose(string(input.Purpose)) {
+		return nil, common.NewErrorWithMessage("purpose must be one of: assistants, vision, user_data", "8d2f6a1c-3b7e-4c9d-a5f2-6e1b8d3c7a40")
+	}
+	filename := filepath.Base(strings.TrimSpace(input.Filename))
+	if filename == "" || filename == "." || filename == string(filepath.Separator) {
+		return nil, common.NewErrorWithMessage("filename is required", "2c7e9a4f-1d6b-4e3a-8f5c-9b2d7e4a1c63")
+	}
+	if input.Size <= 0 {
+		return nil, common.NewErrorWithMessage("file is empty", "5a9c3e7f-2b4d-4f1a-9c6e-3d8b5f2a7e14")
+	}
+	if input.Size > MaxUploadBytes() {
+		return nil, common.NewErrorWithMessage(fmt.Sprintf("file exceeds the %d bytes limit", MaxUploadBytes()), "9e4b7d2a-6c1f-4a8e-b3d5-7f2c9a6e4b81")
+	}
+
+	body := bufio.NewReader(io.LimitReader(input.Body, input.Size))
+	mimeType := normalizeMimeType(input.MimeType, filename)
+	if mimeType == "" {
+		head, _ := body.Peek(512)
+		mimeType = normalizeMimeType(http.DetectContentType(head), filename)
+	}
+	if !allowedMimeType(input.Purpose, mimeType) {
+		return nil, common.NewErrorWithMessage(fmt.Sprintf("file type %s is not supported for purpose %s", mimeType, input.Purpose), "3f8a2c6e-9d1b-4e7a-a4c2-8b5e1f9d3a76")
+	}
+
+	publicID, err := idgen.GenerateSecureID("file", 24)
+	if err != nil {
+		return nil, common.NewError(err, "6b1e4d8a-2f7c-4a3e-9d5b-1c8f6a2e4d97")
+	}
+	storageKey := fmt.Sprintf("%d/%s", owner.UserID, publicID)
+	if err := s.storage.Put(ctx, storageKey, body, input.Size, mimeType); err != nil {
+		return nil, common.NewError(err, "7c2f5a9e-3b8d-4e1a-b6c4-2d9e7f3a5b18")
+	}
+
+	f := &File{
+		PublicID:   publicID,
+		UserID:     owner.UserID,
+		ProjectID:  owner.ProjectID,
+		Filename:   filename,
+		Purpose:    input.Purpose,
+		Bytes:      input.Size,
+		MimeType:   mimeType,
+		StorageKey: storageKey,
+	}
+	if err := s.repo.Create(ctx, f); err != nil {
+		// do not leave orphaned blobs behind when the record cannot be stored
+		_ = s.storage.Delete(ctx, storageKey)
+		return nil, common.NewError(err, "4d9a6c2f-8e3b-4a7d-a1f5-6c3e9b2d8a45")
+	}
+	return f, nil
+}
+
+// ownerFilter restricts a filter to the files the owner can access
+func ownerFilter(owner Owner, filter FileFilter) FileFilter {
+	filter.UserID = &owner.UserID
+	if owner.ProjectID != nil {
+		filter.ProjectID = owner.ProjectID
+	}
+	return filter
+}
+
+func (s *FileService) GetFile(ctx context.Context, owner Owner, publicID string) (*File, *common.Error) {
+	if publicID == "" {
+		return nil, common.NewErrorWithMessage("file id is required", "1a6d3f9c-7e2b-4c8a-9f4d-2b7e5c1a8d36")
+	}
+	files, err := s.repo.FindByFilter(ctx, ownerFilter(owner, FileFilter{PublicID: &publicID}), nil)
+	if err != nil {
+		return nil, common.NewError(err, "8f3c1a7e-4d9b-4e2a-b5c8-3a6f9d1e7c24")
+	}
+	if len(files) == 0 {
+		return nil, common.NewErrorWithMessage("file not found", "2e7b4d1a-9c6f-4a3e-8d2b-5f1c7a9e3b62")
+	}
+	return files[0], nil
+}
+
+// GetFileByID loads a file without an owner check, for background work on files already authorised
+func (s *FileService) GetFileByID(ctx context.Context, id uint) (*File, *common.Error) {
+	files, err := s.repo.FindByFilter(ctx, FileFilter{ID: &id}, nil)
+	if err != nil {
+		return nil, common.NewError(err, "5c2e8a1f-7d4b-4f9e-a3c6-1b8d5e2f9a47")
+	}
+	if len(files) == 0 {
+		return nil, common.NewErrorWithMessage("file not found", "9a4f1c7e-2b6d-4e8a-b5f3-7c1e4a9d2b68")
+	}
+	return files[0], nil
+}
+
+func (s *FileService) ListFiles(ctx context.Context, owner Owner, filter FileFilter, pagination *query.Pagination) ([]*File, int64, *common.Error) {
+	filter = ownerFilter(owner, filter)
+	files, err := s.repo.FindByFilter(ctx, filter, pagination)
+	if err != nil {
+		return nil, 0, common.NewError(err, "5c8e2a6f-1b4d-4f9a-a3e7-9d2c6b8f1a53")
+	}
+	total, err := s.repo.Count(ctx, filter)
+	if err != nil {
+		return nil, 0, common.NewError(err, "9a4f7c2e-6d1b-4e8a-b2f5-4c9e1a7d3b86")
+	}
+	return files, total, nil
+}
+
+func (s *FileService) DeleteFile(ctx context.Context, f *File) *common.Error {
+	if err := s.repo.DeleteByID(ctx, f.ID); err != nil {
+		return common.NewError(err, "3b7e1c9a-5f2d-4a6e-9c4b-8e2a6d1f7c39")
+	}
+	if err := s.storage.Delete(ctx, f.StorageKey); err != nil {
+		return common.NewError(err, "6e2a9d4c-8b1f-4c7e-a5d3-1f8c4b6e2a97")
+	}
+	return nil
+}
+
+// OpenContent returns a reader over the stored content, callers must close it
+func (s *FileService) OpenContent(ctx context.Context, f *File) (io.ReadCloser, *common.Error) {
+	reader, err := s.storage.Get(ctx, f.StorageKey)
+	if err != nil {
+		return nil, common.NewError(err, "4f1c8a3e-7b2d-4e9a-8c6f-2d5b9e1a4c73")
+	}
+	return reader, nil
+}
+
+// ResolveMessagePart loads a file the owner can access as a chat message part: images become data URLs, text documents are inlined
+func (s *FileService) ResolveMessagePart(ctx context.Context, owner Owner, publicID string, detail string) (*openai.ChatMessagePart, *common.Error) {
+	f, err := s.GetFile(ctx, owner, publicID)
+	if err != nil {
+		return nil, err
+	}
+
+	if f.IsImage() && f.Bytes > maxImageInputBytes {
+		return nil, common.NewErrorWithMessage("image is too large to be used as model input", "7a3e9c1f-2d6b-4f8a-b4e2-9c1d5a7f3e28")
+	}
+	if !f.IsImage() && !f.IsTextDocument() {
+		return nil, common.NewErrorWithMessage(fmt.Sprintf("file type %s cannot be used as model input", f.MimeType), "1d5b8e2a-9f3c-4a7e-8b1d-6e4a2c9f5b37")
+	}
+
+	reader, err := s.OpenContent(ctx, f)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	if f.IsImage() {
+		data, readErr := io.ReadAll(reader)
+		if readErr != nil {
+			return nil, common.NewError(readErr, "8c2f6a4e-1b7d-4e3a-9f5c-3a8e1d6b4f92")
+		}
+		return &openai.ChatMessagePart{
+			Type: openai.ChatMessagePartTypeImageURL,
+			ImageURL: &openai.ChatMessageImageURL{
+				URL:    fmt.Sprintf("data:%s;base64,%s", f.MimeType, base64.StdEncoding.EncodeToString(data)),
+				Detail: openai.ImageURLDetail(detail),
+			},
+		}, nil
+	}
+
+	data, readErr := io.ReadAll(io.LimitReader(reader, maxDocumentInputChars))
+	if readErr != nil {
+		return nil, common.NewError(readErr, "2a7d4f1c-6e9b-4c3a-a8d2-5f1e9c3a7b64")
+	}
+	return &openai.ChatMessagePart{
+		Type: openai.ChatMessagePartTypeText,
+		Text: fmt.Sprintf("File: %s\n\n%s", f.Filename, string(data)),
+	}, nil
+}
+
+// ReadText returns the whole content of a text document, or the text extracted from a PDF
+func (s *FileService) ReadText(ctx context.Context, f *File) (string, *common.Error) {
+	if !f.IsTextDocument() && f.MimeType != pdfMimeType {
+		return "", common.NewErrorWithMessage(fmt.Sprintf("text cannot be extracted from file type %s", f.MimeType), "6c1e8b3f-4a7d-4d2e-9b5a-8f3c1e6d4a29")
+	}
+	reader, err := s.OpenContent(ctx, f)
+	if err != nil {
+		return "", err
+	}
+	defer reader.Close()
+
+	data, readErr := io.ReadAll(reader)
+	if readErr != nil {
+		return "", common.NewError(readErr, "3e9a5c1d-7b4f-4e8a-a2c6-9d1f5b3e7a84")
+	}
+	if f.MimeType == pdfMimeType {
+		text, extractErr := webfetch.ExtractPDFText(data)
+		if extractErr != nil {
+			return "", common.NewError(extractErr, "7e3b9d2a-5c1f-4a8e-b6d4-2f9a7c3e1b58")
+		}
+		return text, nil
+	}
+	return string(data), nil
+}
+
+// normalizeMimeType drops parameters from a content type and falls back to the file extension for generic types
+func normalizeMimeType(contentType string, filename string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil || mediaType == "application/octet-stream" || mediaType == "" {
+		mediaType = ""
+		if byExt := mime.TypeByExtension(filepath.Ext(filename)); byExt != "" {
+			mediaType, _, _ = mime.ParseMediaType(byExt)
+		}
+	}
+	if mediaType == "text/x-markdown" {
+		mediaType = "text/markdown"
+	}
+	return strings.ToLower(mediaType)
+}
+
+func allowedMimeType(purpose FilePurpose, mimeType string) bool {
+	if imageMimeTypes[mimeType] {
+		return true
+	}
+	if purpose == FilePurposeVision {
+		return false
+	}
+	_, ok := documentMimeTypes[mimeType]
+	return ok
+}
+
+func (s *FileService) GetFileMiddleware() gin.HandlerFunc {
+	return func(reqCtx *gin.Context) {
+		ctx := reqCtx.Request.Context()
+		owner, ok := GetRequestOwner(reqCtx)
+		if !ok {
+			reqCtx.AbortWithStatusJSON(http.StatusUnauthorized, responses.ErrorResponse{
+				Code: "5e9a2c7f-3d1b-4f6e-a8c4-7b2e9d5a1f38",
+			})
+			return
+		}
+		f, err := s.GetFile(ctx, owner, reqCtx.Param(string(FileContextKeyPublicID)))
+		if err != nil {
+			reqCtx.AbortWithStatusJSON(http.StatusNotFound, responses.ErrorResponse{
+				Code:  err.GetCode(),
+				Error: err.Error(),
+			})
+			return
+		}
+		SetFileToContext(reqCtx, f)
+		reqCtx.Next()
+	}
+}
+
+// GetRequestOwner returns the user of the request and the project of its API key
+func GetRequestOwner(reqCtx *gin.Context) (Owner, bool) {
+	user, ok := auth.GetUserFromContext(reqCtx)
+	if !ok {
+		return Owner{}, false
+	}
+	return Owner{
+		UserID:    user.ID,
+		ProjectID: auth.GetRequestProjectID(reqCtx),
+	}, true
+}
+
+func SetFileToContext(reqCtx *gin.Context, f *File) {
+	reqCtx.Set(string(FileContextEntity), f)
+}
+
+func GetFileFromContext(reqCtx *gin.Context) (*File, bool) {
+	v, ok := reqCtx.Get(string(FileContextEntity))
+	if !ok {
+		return nil, false
+	}
+	f, ok := v.(*File)
+	return f, ok
+}
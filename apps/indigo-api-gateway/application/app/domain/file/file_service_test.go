@@ -0,0 +1,158 @@
+package file_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	openai "github.com/sashabaranov/go-openai"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+	"menlo.ai/indigo-api-gateway/app/domain/file"
+	"menlo.ai/indigo-api-gateway/app/infrastructure/database/dbschema"
+	"menlo.ai/indigo-api-gateway/app/infrastructure/database/repository/filerepo"
+	"menlo.ai/indigo-api-gateway/app/infrastructure/database/repository/transaction"
+	"menlo.ai/indigo-api-gateway/app/infrastructure/storage"
+	"menlo.ai/indigo-api-gateway/app/utils/ptr"
+	"menlo.ai/indigo-api-gateway/config/environment_variables"
+)
+
+// pngHeader is enough for http.DetectContentType to recognise a PNG image
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+func newTestFileService(t *testing.T) *file.FileService {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{
+		NamingStrategy:                           schema.NamingStrategy{SingularTable: true},
+		DisableForeignKeyConstraintWhenMigrating: true,
+	})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	if err := db.AutoMigrate(&dbschema.File{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return file.NewFileService(filerepo.NewFileRepository(transaction.NewDatabase(db)), storage.NewLocalStorage(t.TempDir()))
+}
+
+func upload(ctx context.Context, service *file.FileService, owner file.Owner, filename string, purpose file.FilePurpose, mimeType string, content []byte) (*file.File, error) {
+	f, err := service.Upload(ctx, owner, file.UploadInput{
+		Filename: filename,
+		Purpose:  purpose,
+		MimeType: mimeType,
+		Size:     int64(len(content)),
+		Body:     bytes.NewReader(content),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func TestUploadValidatesTheFile(t *testing.T) {
+	environment_variables.Override("file_service_test", func(env *environment_variables.EnvironmentVariable) {
+		env.FILE_MAX_UPLOAD_BYTES = 64
+	})
+	defer environment_variables.Override("file_service_test", func(env *environment_variables.EnvironmentVariable) {})
+	ctx := context.Background()
+	service := newTestFileService(t)
+	owner := file.Owner{UserID: 1}
+
+	cases := []struct {
+		name     string
+		filename string
+		purpose  file.FilePurpose
+		mimeType string
+		content  []byte
+	}{
+		{"unknown purpose", "notes.txt", "fine-tune", "text/plain", []byte("hello")},
+		{"missing filename", " ", file.FilePurposeAssistants, "text/plain", []byte("hello")},
+		{"empty file", "notes.txt", file.FilePurposeAssistants, "text/plain", nil},
+		{"over the limit", "notes.txt", file.FilePurposeAssistants, "text/plain", bytes.Repeat([]byte("a"), 65)},
+		{"document for vision", "notes.txt", file.FilePurposeVision, "text/plain", []byte("hello")},
+		{"unsupported type", "tool.exe", file.FilePurposeUserData, "application/x-msdownload", []byte("MZ")},
+	}
+	for _, tc := range cases {
+		if _, err := upload(ctx, service, owner, tc.filename, tc.purpose, tc.mimeType, tc.content); err == nil {
+			t.Errorf("%s: expected the upload to be refused", tc.name)
+		}
+	}
+
+	// the type falls back to the extension, then to the content
+	notes, err := upload(ctx, service, owner, "dir/notes.md", file.FilePurposeAssistants, "application/octet-stream", []byte("# notes"))
+	if err != nil || notes.MimeType != "text/markdown" || notes.Filename != "notes.md" {
+		t.Fatalf("expected a markdown document, got %+v, %v", notes, err)
+	}
+	image, err := upload(ctx, service, owner, "screenshot", file.FilePurposeVision, "", pngHeader)
+	if err != nil || image.MimeType != "image/png" || image.Bytes != int64(len(pngHeader)) {
+		t.Fatalf("expected a sniffed png, got %+v, %v", image, err)
+	}
+}
+
+func TestFilesAreScopedToTheirOwner(t *testing.T) {
+	ctx := context.Background()
+	service := newTestFileService(t)
+	personal, err := upload(ctx, service, file.Owner{UserID: 1}, "personal.txt", file.FilePurposeUserData, "text/plain", []byte("personal"))
+	if err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	project, err := upload(ctx, service, file.Owner{UserID: 1, ProjectID: ptr.ToUint(5)}, "project.txt", file.FilePurposeUserData, "text/plain", []byte("project"))
+	if err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+
+	cases := []struct {
+		name    string
+		owner   file.Owner
+		file    *file.File
+		allowed bool
+	}{
+		{"owner", file.Owner{UserID: 1}, personal, true},
+		{"owner without project key", file.Owner{UserID: 1}, project, true},
+		{"owner with the project key", file.Owner{UserID: 1, ProjectID: ptr.ToUint(5)}, project, true},
+		{"owner with another project key", file.Owner{UserID: 1, ProjectID: ptr.ToUint(6)}, project, false},
+		{"personal file with a project key", file.Owner{UserID: 1, ProjectID: ptr.ToUint(5)}, personal, false},
+		{"another user", file.Owner{UserID: 2}, personal, false},
+	}
+	for _, tc := range cases {
+		_, getErr := service.GetFile(ctx, tc.owner, tc.file.PublicID)
+		if tc.allowed != (getErr == nil) {
+			t.Errorf("%s: expected allowed=%v, got %v", tc.name, tc.allowed, getErr)
+		}
+	}
+
+	if _, total, listErr := service.ListFiles(ctx, file.Owner{UserID: 2}, file.FileFilter{}, nil); listErr != nil || total != 0 {
+		t.Fatalf("expected another user to list nothing, got %d, %v", total, listErr)
+	}
+	if _, total, listErr := service.ListFiles(ctx, file.Owner{UserID: 1, ProjectID: ptr.ToUint(5)}, file.FileFilter{}, nil); listErr != nil || total != 1 {
+		t.Fatalf("expected the project key to list the project file, got %d, %v", total, listErr)
+	}
+}
+
+func TestResolveMessagePart(t *testing.T) {
+	ctx := context.Background()
+	service := newTestFileService(t)
+	owner := file.Owner{UserID: 1}
+	image, _ := upload(ctx, service, owner, "chart.png", file.FilePurposeVision, "image/png", pngHeader)
+	notes, _ := upload(ctx, service, owner, "notes.md", file.FilePurposeAssistants, "text/markdown", []byte("# Plan\nship it"))
+	pdf, _ := upload(ctx, service, owner, "report.pdf", file.FilePurposeAssistants, "application/pdf", []byte("%PDF-1.4"))
+
+	part, err := service.ResolveMessagePart(ctx, owner, image.PublicID, "low")
+	if err != nil || part.Type != openai.ChatMessagePartTypeImageURL || part.ImageURL.Detail != openai.ImageURLDetailLow ||
+		part.ImageURL.URL != "data:image/png;base64,"+base64.StdEncoding.EncodeToString(pngHeader) {
+		t.Fatalf("expected the image as a data URL, got %+v, %v", part, err)
+	}
+	part, err = service.ResolveMessagePart(ctx, owner, notes.PublicID, "")
+	if err != nil || part.Type != openai.ChatMessagePartTypeText || part.Text != "File: notes.md\n\n# Plan\nship it" {
+		t.Fatalf("expected the document inlined, got %+v, %v", part, err)
+	}
+	if _, err := service.ResolveMessagePart(ctx, owner, pdf.PublicID, ""); err == nil || !strings.Contains(err.Error(), "cannot be used as model input") {
+		t.Fatalf("expected a PDF to be refused as model input, got %v", err)
+	}
+	if _, err := service.ResolveMessagePart(ctx, file.Owner{UserID: 2}, notes.PublicID, ""); err == nil {
+		t.Fatalf("expected the file of another user to be refused")
+	}
+}
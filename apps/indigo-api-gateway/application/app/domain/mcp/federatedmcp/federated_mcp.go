@@ -0,0 +1,112 @@
+package federatedmcp
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"menlo.ai/indigo-api-gateway/app/domain/query"
+)
+
+// @Enum(streamable_http, sse)
+type Transport string
+
+const (
+	TransportStreamableHTTP Transport = "streamable_http"
+	TransportSSE            Transport = "sse"
+)
+
+func IsSupportedTransport(transport string) bool {
+	return transport == string(TransportStreamableHTTP) || transport == string(TransportSSE)
+}
+
+// NameSeparator joins a server name and the name of one of its tools or prompts in the gateway namespace
+const NameSeparator = "__"
+
+// Server is an external MCP server whose tools, prompts and resources the gateway exposes
+type Server struct {
+	ID             uint
+	PublicID       string
+	OrganizationID uint
+	// ProjectID limits the server to one project, nil servers are shared by the whole organization
+	ProjectID *uint
+	// Name prefixes the tools and prompts of the server, it is unique per organization
+	Name      string
+	URL       string
+	Transport Transport
+	// EncryptedHeaders holds the JSON object of the auth headers sent to the server
+	EncryptedHeaders string
+	// HeaderNames lists the configured headers so they can be shown without their values
+	HeaderNames []string
+	Active      bool
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// ToolAllowlist restricts the tools, prompts and resources exposed to a project, projects without one see all of them
+type ToolAllowlist struct {
+	ID        uint
+	ProjectID uint
+	// Tools holds exact namespaced tool or prompt names, "<server>__<uri>" for a resource or resource template,
+	// "<server>__*" for everything a server offers or "*"
+	Tools     []string
+	UpdatedAt time.Time
+}
+
+// Allows reports whether a namespaced tool is exposed, a nil allowlist allows everything
+func (a *ToolAllowlist) Allows(tool string) bool {
+	if a == nil {
+		return true
+	}
+	for _, pattern := range a.Tools {
+		if pattern == "*" || pattern == tool {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok && strings.HasPrefix(tool, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// NamespacedName returns the name a backend tool or prompt is exposed under
+func NamespacedName(serverName string, name string) string {
+	return serverName + NameSeparator + name
+}
+
+// SplitNamespacedName returns the server name and the backend name of a namespaced tool or prompt
+func SplitNamespacedName(name string) (string, string, bool) {
+	serverName, backendName, ok := strings.Cut(name, NameSeparator)
+	if !ok || serverName == "" || backendName == "" {
+		return "", "", false
+	}
+	return serverName, backendName, true
+}
+
+type ServerFilter struct {
+	ID             *uint
+	PublicID       *string
+	OrganizationID *uint
+	Name           *string
+	Active         *bool
+	ProjectID      *uint
+	// WithoutProject only matches organization wide servers
+	WithoutProject *bool
+	// VisibleToProjectID matches the servers of the project together with the organization wide ones
+	VisibleToProjectID *uint
+}
+
+type ServerRepository interface {
+	Create(ctx context.Context, server *Server) error
+	Update(ctx context.Context, server *Server) error
+	FindByFilter(ctx context.Context, filter ServerFilter, pagination *query.Pagination) ([]*Server, error)
+	Count(ctx context.Context, filter ServerFilter) (int64, error)
+	DeleteByID(ctx context.Context, id uint) error
+}
+
+type ToolAllowlistRepository interface {
+	FindByProjectID(ctx context.Context, projectID uint) (*ToolAllowlist, error)
+	// Upsert creates or replaces the allowlist of the project
+	Upsert(ctx context.Context, allowlist *ToolAllowlist) error
+	DeleteByProjectID(ctx context.Context, projectID uint) error
+}
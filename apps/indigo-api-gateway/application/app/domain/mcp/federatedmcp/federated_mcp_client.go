@@ -0,0 +1,210 @@
+package federatedmcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/client/transport"
+	"github.com/mark3labs/mcp-go/mcp"
+	"menlo.ai/indigo-api-gateway/app/utils/logger"
+)
+
+const (
+	clientName    = "indigo-api-gateway"
+	clientVersion = "1.0.0"
+	// connectTimeout bounds the initialize handshake with a backend
+	connectTimeout = 10 * time.Second
+	requestTimeout = 60 * time.Second
+)
+
+// Capabilities are the tools, prompts and resources a backend server offers, with their backend names
+type Capabilities struct {
+	Tools             []mcp.Tool
+	Prompts           []mcp.Prompt
+	Resources         []mcp.Resource
+	ResourceTemplates []mcp.ResourceTemplate
+}
+
+// pooledClient is an initialized session with a backend, it is replaced when the server configuration changes
+type pooledClient struct {
+	client       *client.Client
+	updatedAt    time.Time
+	capabilities mcp.ServerCapabilities
+}
+
+func (s *FederatedMCPService) newClient(server *Server, headers map[string]string) (*client.Client, error) {
+	if server.Transport == TransportSSE {
+		// the event stream stays open, so the SSE client has no overall timeout
+		return client.NewSSEMCPClient(server.URL,
+			client.WithHeaders(headers),
+			client.WithHTTPClient(&http.Client{Transport: s.transport}),
+		)
+	}
+	return client.NewStreamableHttpClient(server.URL,
+		transport.WithHTTPBasicClient(&http.Client{Transport: s.transport}),
+		transport.WithHTTPHeaders(headers),
+		transport.WithHTTPTimeout(requestTimeout),
+	)
+}
+
+func (s *FederatedMCPService) connect(ctx context.Context, server *Server) (*pooledClient, error) {
+	s.clientsMu.Lock()
+	defer s.clientsMu.Unlock()
+
+	if pooled, ok := s.clients[server.ID]; ok {
+		if pooled.updatedAt.Equal(server.UpdatedAt) {
+			return pooled, nil
+		}
+		_ = pooled.client.Close()
+		delete(s.clients, server.ID)
+	}
+
+	headers, err := decryptHeaders(ctx, server)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt the headers of MCP server %s: %w", server.Name, err)
+	}
+	c, err := s.newClient(server, headers)
+	if err != nil {
+		return nil, err
+	}
+	// the SSE transport keeps its event stream open for the lifetime of the context it is started with
+	if err := c.Start(context.Background()); err != nil {
+		return nil, err
+	}
+	initCtx, cancel := context.WithTimeout(ctx, connectTimeout)
+	defer cancel()
+	result, err := c.Initialize(initCtx, mcp.InitializeRequest{
+		Params: mcp.InitializeParams{
+			ProtocolVersion: mcp.LATEST_PROTOCOL_VERSION,
+			ClientInfo: mcp.Implementation{
+				Name:    clientName,
+				Version: clientVersion,
+			},
+		},
+	})
+	if err != nil {
+		_ = c.Close()
+		return nil, fmt.Errorf("failed to initialize MCP server %s: %w", server.Name, err)
+	}
+
+	pooled := &pooledClient{
+		client:       c,
+		updatedAt:    server.UpdatedAt,
+		capabilities: result.Capabilities,
+	}
+	s.clients[server.ID] = pooled
+	return pooled, nil
+}
+
+func (s *FederatedMCPService) closeClient(serverID uint) {
+	s.clientsMu.Lock()
+	defer s.clientsMu.Unlock()
+	if pooled, ok := s.clients[serverID]; ok {
+		_ = pooled.client.Close()
+		delete(s.clients, serverID)
+	}
+}
+
+// withClient runs call with a session to the server and reconnects once when the session was lost
+func (s *FederatedMCPService) withClient(ctx context.Context, server *Server, call func(pooled *pooledClient) error) error {
+	pooled, err := s.connect(ctx, server)
+	if err != nil {
+		return err
+	}
+	err = call(pooled)
+	var transportErr *transport.Error
+	if err == nil || ctx.Err() != nil || !errors.As(err, &transportErr) {
+		return err
+	}
+
+	logger.GetLogger().Warnf("MCP server %s failed, reconnecting: %v", server.Name, err)
+	s.closeClient(server.ID)
+	pooled, err = s.connect(ctx, server)
+	if err != nil {
+		return err
+	}
+	return call(pooled)
+}
+
+// Discover lists what the server offers, capabilities the server does not declare are left empty
+func (s *FederatedMCPService) Discover(ctx context.Context, server *Server) (*Capabilities, error) {
+	result := &Capabilities{}
+	err := s.withClient(ctx, server, func(pooled *pooledClient) error {
+		*result = Capabilities{}
+		if pooled.capabilities.Tools != nil {
+			tools, err := pooled.client.ListTools(ctx, mcp.ListToolsRequest{})
+			if err != nil {
+				return err
+			}
+			result.Tools = tools.Tools
+		}
+		if pooled.capabilities.Prompts != nil {
+			prompts, err := pooled.client.ListPrompts(ctx, mcp.ListPromptsRequest{})
+			if err != nil {
+				return err
+			}
+			result.Prompts = prompts.Prompts
+		}
+		if pooled.capabilities.Resources != nil {
+			resources, err := pooled.client.ListResources(ctx, mcp.ListResourcesRequest{})
+			if err != nil {
+				return err
+			}
+			result.Resources = resources.Resources
+			templates, err := pooled.client.ListResourceTemplates(ctx, mcp.ListResourceTemplatesRequest{})
+			if err != nil {
+				return err
+			}
+			result.ResourceTemplates = templates.ResourceTemplates
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// CallTool calls a tool of the server by its backend name
+func (s *FederatedMCPService) CallTool(ctx context.Context, server *Server, name string, arguments any) (*mcp.CallToolResult, error) {
+	var result *mcp.CallToolResult
+	err := s.withClient(ctx, server, func(pooled *pooledClient) error {
+		var err error
+		request := mcp.CallToolRequest{}
+		request.Params.Name = name
+		request.Params.Arguments = arguments
+		result, err = pooled.client.CallTool(ctx, request)
+		return err
+	})
+	return result, err
+}
+
+// GetPrompt renders a prompt of the server by its backend name
+func (s *FederatedMCPService) GetPrompt(ctx context.Context, server *Server, name string, arguments map[string]string) (*mcp.GetPromptResult, error) {
+	var result *mcp.GetPromptResult
+	err := s.withClient(ctx, server, func(pooled *pooledClient) error {
+		var err error
+		request := mcp.GetPromptRequest{}
+		request.Params.Name = name
+		request.Params.Arguments = arguments
+		result, err = pooled.client.GetPrompt(ctx, request)
+		return err
+	})
+	return result, err
+}
+
+func (s *FederatedMCPService) ReadResource(ctx context.Context, server *Server, uri string) (*mcp.ReadResourceResult, error) {
+	var result *mcp.ReadResourceResult
+	err := s.withClient(ctx, server, func(pooled *pooledClient) error {
+		var err error
+		request := mcp.ReadResourceRequest{}
+		request.Params.URI = uri
+		result, err = pooled.client.ReadResource(ctx, request)
+		return err
+	})
+	return result, err
+}
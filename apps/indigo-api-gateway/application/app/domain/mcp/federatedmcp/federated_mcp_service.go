@@ -0,0 +1,378 @@
+package federatedmcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+
+	"menlo.ai/indigo-api-gateway/app/domain/common"
+	"menlo.ai/indigo-api-gateway/app/domain/organization"
+	"menlo.ai/indigo-api-gateway/app/domain/query"
+	"menlo.ai/indigo-api-gateway/app/utils/crypto"
+	"menlo.ai/indigo-api-gateway/app/utils/idgen"
+	"menlo.ai/indigo-api-gateway/app/utils/ptr"
+	"menlo.ai/indigo-api-gateway/app/utils/webfetch"
+)
+
+const maxServersPerOrganization = 50
+
+var serverNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,31}$`)
+
+type FederatedMCPService struct {
+	serverRepo    ServerRepository
+	allowlistRepo ToolAllowlistRepository
+
+	// transport dials the backends, the guard runs on the resolved address so a name pointing at the
+	// internal network cannot be registered
+	transport http.RoundTripper
+	// allowPrivateNetworks disables the SSRF guard, only for tests
+	allowPrivateNetworks bool
+
+	clientsMu sync.Mutex
+	clients   map[uint]*pooledClient
+	// generation is bumped whenever a server or an allowlist changes so the exposed MCP servers are rebuilt
+	generation atomic.Uint64
+}
+
+func NewFederatedMCPService(serverRepo ServerRepository, allowlistRepo ToolAllowlistRepository) *FederatedMCPService {
+	return &FederatedMCPService{
+		serverRepo:    serverRepo,
+		allowlistRepo: allowlistRepo,
+		transport:     newTransport(webfetch.GuardDial),
+		clients:       make(map[uint]*pooledClient),
+	}
+}
+
+// AllowPrivateNetworks disables the SSRF guard, only for tests and trusted deployments
+func (s *FederatedMCPService) AllowPrivateNetworks() {
+	s.allowPrivateNetworks = true
+	s.transport = newTransport(nil)
+}
+
+func newTransport(control func(network, address string, c syscall.RawConn) error) *http.Transport {
+	dialer := &net.Dialer{Timeout: connectTimeout, Control: control}
+	return &http.Transport{
+		// proxies from the environment would hide the destination from the guard
+		Proxy:               nil,
+		DialContext:         dialer.DialContext,
+		ForceAttemptHTTP2:   true,
+		TLSHandshakeTimeout: connectTimeout,
+	}
+}
+
+// Generation changes every time the configuration of the federated servers changes on this instance
+func (s *FederatedMCPService) Generation() uint64 {
+	return s.generation.Load()
+}
+
+type RegisterServerInput struct {
+	OrganizationID uint
+	ProjectID      *uint
+	Name           string
+	URL            string
+	Transport      string
+	Headers        map[string]string
+	Active         bool
+}
+
+type UpdateServerInput struct {
+	Name      *string
+	URL       *string
+	Transport *string
+	// Headers replaces every configured header when set
+	Headers *map[string]string
+	Active  *bool
+}
+
+func (s *FederatedMCPService) RegisterServer(ctx context.Context, input RegisterServerInput) (*Server, *common.Error) {
+	organizationID := organization.DEFAULT_ORGANIZATION.ID
+	if input.OrganizationID != 0 {
+		organizationID = input.OrganizationID
+	}
+
+	name := strings.TrimSpace(input.Name)
+	if err := s.validateName(ctx, organizationID, name, nil); err != nil {
+		return nil, err
+	}
+	serverURL, err := s.validateServerURL(input.URL)
+	if err != nil {
+		return nil, err
+	}
+	transport := strings.TrimSpace(input.Transport)
+	if transport == "" {
+		transport = string(TransportStreamableHTTP)
+	}
+	if !IsSupportedTransport(transport) {
+		return nil, common.NewErrorWithMessage("transport must be streamable_http or sse", "b4e1c7a9-2d6f-4a3b-8e5c-1f9a7d3b6e42")
+	}
+
+	count, countErr := s.serverRepo.Count(ctx, ServerFilter{OrganizationID: &organizationID})
+	if countErr != nil {
+		return nil, common.NewError(countErr, "6d2a9f4c-8b1e-4c7a-a3d5-9e6f2b8c4a17")
+	}
+	if count >= maxServersPerOrganization {
+		return nil, common.NewErrorWithMessage(fmt.Sprintf("an organization can register at most %d MCP servers", maxServersPerOrganization), "3f8c2e6a-1d4b-4e9f-b7a2-5c1e8d3f9a64")
+	}
+
+	encryptedHeaders, headerNames, headerErr := encryptHeaders(ctx, input.Headers)
+	if headerErr != nil {
+		return nil, headerErr
+	}
+
+	publicID, idErr := idgen.GenerateSecureID("mcps", 24)
+	if idErr != nil {
+		return nil, common.NewError(idErr, "9a5e3c1f-7b2d-4f8a-b6e4-2d8c5a1f7e93")
+	}
+
+	server := &Server{
+		PublicID:         publicID,
+		OrganizationID:   organizationID,
+		ProjectID:        input.ProjectID,
+		Name:             name,
+		URL:              serverURL,
+		Transport:        Transport(transport),
+		EncryptedHeaders: encryptedHeaders,
+		HeaderNames:      headerNames,
+		Active:           input.Active,
+	}
+	if err := s.serverRepo.Create(ctx, server); err != nil {
+		return nil, common.NewError(err, "2c7f4a9e-5d1b-4e3c-8a6f-7b9e1d4c2a85")
+	}
+	s.generation.Add(1)
+	return server, nil
+}
+
+func (s *FederatedMCPService) UpdateServer(ctx context.Context, server *Server, input UpdateServerInput) (*Server, *common.Error) {
+	if input.Name != nil {
+		name := strings.TrimSpace(*input.Name)
+		if name != server.Name {
+			if err := s.validateName(ctx, server.OrganizationID, name, &server.ID); err != nil {
+				return nil, err
+			}
+			server.Name = name
+		}
+	}
+	if input.URL != nil {
+		serverURL, err := s.validateServerURL(*input.URL)
+		if err != nil {
+			return nil, err
+		}
+		server.URL = serverURL
+	}
+	if input.Transport != nil {
+		transport := strings.TrimSpace(*input.Transport)
+		if !IsSupportedTransport(transport) {
+			return nil, common.NewErrorWithMessage("transport must be streamable_http or sse", "e7b3d9a1-4c6f-4b2e-9d8a-3f5c7e1b9d26")
+		}
+		server.Transport = Transport(transport)
+	}
+	if input.Headers != nil {
+		encryptedHeaders, headerNames, err := encryptHeaders(ctx, *input.Headers)
+		if err != nil {
+			return nil, err
+		}
+		server.EncryptedHeaders = encryptedHeaders
+		server.HeaderNames = headerNames
+	}
+	if input.Active != nil {
+		server.Active = *input.Active
+	}
+
+	if err := s.serverRepo.Update(ctx, server); err != nil {
+		return nil, common.NewError(err, "5b9d1f3a-8e2c-4a7d-b4f6-1c3e9a5d7b28")
+	}
+	s.closeClient(server.ID)
+	s.generation.Add(1)
+	return server, nil
+}
+
+func (s *FederatedMCPService) DeleteServer(ctx context.Context, server *Server) *common.Error {
+	if err := s.serverRepo.DeleteByID(ctx, server.ID); err != nil {
+		return common.NewError(err, "8e4a2c6f-1b9d-4f3e-a7c5-6d2b8e4f1a39")
+	}
+	s.closeClient(server.ID)
+	s.generation.Add(1)
+	return nil
+}
+
+// FindServer returns a server of the organization, or nil when there is none with that public id
+func (s *FederatedMCPService) FindServer(ctx context.Context, organizationID uint, publicID string) (*Server, *common.Error) {
+	servers, err := s.serverRepo.FindByFilter(ctx, ServerFilter{
+		PublicID:       &publicID,
+		OrganizationID: &organizationID,
+	}, nil)
+	if err != nil {
+		return nil, common.NewError(err, "1d7f3b9e-6a2c-4e8d-b5f1-9c4a2e7d3b16")
+	}
+	if len(servers) == 0 {
+		return nil, nil
+	}
+	return servers[0], nil
+}
+
+func (s *FederatedMCPService) ListServers(ctx context.Context, filter ServerFilter, pagination *query.Pagination) ([]*Server, int64, *common.Error) {
+	servers, err := s.serverRepo.FindByFilter(ctx, filter, pagination)
+	if err != nil {
+		return nil, 0, common.NewError(err, "4a8c6e2f-9d1b-4f7a-8c3e-5b7d1f9a2c64")
+	}
+	total, err := s.serverRepo.Count(ctx, filter)
+	if err != nil {
+		return nil, 0, common.NewError(err, "7f1b5d9a-3e6c-4a2f-9b8d-2e4a6c8f1d37")
+	}
+	return servers, total, nil
+}
+
+// ListExposedServers returns the active servers of an organization whose tools are exposed to a project,
+// or to the keys of the organization without project when projectID is nil
+func (s *FederatedMCPService) ListExposedServers(ctx context.Context, organizationID uint, projectID *uint) ([]*Server, *common.Error) {
+	filter := ServerFilter{
+		OrganizationID: &organizationID,
+		Active:         ptr.ToBool(true),
+	}
+	if projectID != nil {
+		filter.VisibleToProjectID = projectID
+	} else {
+		filter.WithoutProject = ptr.ToBool(true)
+	}
+	servers, err := s.serverRepo.FindByFilter(ctx, filter, nil)
+	if err != nil {
+		return nil, common.NewError(err, "c3e9a5d1-7f2b-4c8e-a6d4-9b1f3e7c5a28")
+	}
+	return servers, nil
+}
+
+// GetToolAllowlist returns the allowlist of a project, nil when every tool is exposed
+func (s *FederatedMCPService) GetToolAllowlist(ctx context.Context, projectID uint) (*ToolAllowlist, *common.Error) {
+	allowlist, err := s.allowlistRepo.FindByProjectID(ctx, projectID)
+	if err != nil {
+		return nil, common.NewError(err, "6e2c8a4f-1d7b-4b9e-8f3a-5c9e1b7d3f42")
+	}
+	return allowlist, nil
+}
+
+// SetToolAllowlist replaces the allowlist of a project, nil tools removes it so every tool is exposed again
+func (s *FederatedMCPService) SetToolAllowlist(ctx context.Context, projectID uint, tools []string) (*ToolAllowlist, *common.Error) {
+	if tools == nil {
+		if err := s.allowlistRepo.DeleteByProjectID(ctx, projectID); err != nil {
+			return nil, common.NewError(err, "9b3f7d1a-5e8c-4a2d-b6f9-3d1a7c5e9b84")
+		}
+		s.generation.Add(1)
+		return nil, nil
+	}
+
+	normalized := make([]string, 0, len(tools))
+	seen := make(map[string]bool, len(tools))
+	for _, tool := range tools {
+		tool = strings.TrimSpace(tool)
+		if tool == "" {
+			return nil, common.NewErrorWithMessage("allowed_tools must not contain empty names", "2f6a9c3e-8d1b-4e7f-a5c2-7e3b9d1f6a58")
+		}
+		if strings.Contains(strings.TrimSuffix(tool, "*"), "*") {
+			return nil, common.NewErrorWithMessage(fmt.Sprintf("invalid tool pattern %q, only a trailing * is supported", tool), "5d1e8b4a-2c7f-4f9d-8b3e-1a6c4f8d2e97")
+		}
+		if !seen[tool] {
+			seen[tool] = true
+			normalized = append(normalized, tool)
+		}
+	}
+	sort.Strings(normalized)
+
+	allowlist := &ToolAllowlist{ProjectID: projectID, Tools: normalized}
+	if err := s.allowlistRepo.Upsert(ctx, allowlist); err != nil {
+		return nil, common.NewError(err, "8c4a1e7f-6b3d-4a9c-9e2f-4d8b1c6a3e71")
+	}
+	s.generation.Add(1)
+	return allowlist, nil
+}
+
+func (s *FederatedMCPService) validateName(ctx context.Context, organizationID uint, name string, excludeID *uint) *common.Error {
+	if !serverNamePattern.MatchString(name) || strings.Contains(name, NameSeparator) {
+		return common.NewErrorWithMessage("name must be 1-32 lowercase letters, digits, '-' or '_', start with a letter or digit and not contain '__'", "a7d3f9b1-4e2c-4c8a-b5e7-8f1d3a9c6b25")
+	}
+	servers, err := s.serverRepo.FindByFilter(ctx, ServerFilter{
+		OrganizationID: &organizationID,
+		Name:           &name,
+	}, nil)
+	if err != nil {
+		return common.NewError(err, "3b8e2a6d-9f1c-4d7b-a4e8-6c2f9b1d5a73")
+	}
+	for _, existing := range servers {
+		if excludeID == nil || existing.ID != *excludeID {
+			return common.NewErrorWithMessage(fmt.Sprintf("an MCP server named %q already exists", name), "f2c6a8e4-1b5d-4f9a-8d3c-7a1e5b9f2d46")
+		}
+	}
+	return nil
+}
+
+// validateServerURL only accepts absolute http and https URLs that do not name a loopback, private or
+// link-local host; names resolving to such addresses are refused when the gateway connects
+func (s *FederatedMCPService) validateServerURL(raw string) (string, *common.Error) {
+	trimmed := strings.TrimSpace(raw)
+	parsed, err := url.Parse(trimmed)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return "", common.NewErrorWithMessage("url must be an absolute http or https URL", "d9a5c1e7-3f8b-4b2d-9c6a-1e7f3b9d5c82")
+	}
+	if !s.allowPrivateNetworks && webfetch.IsBlockedHost(parsed.Hostname()) {
+		return "", common.NewErrorWithMessage("url must not point to a loopback, private or link-local address", "d9a5c1e7-3f8b-4b2d-9c6a-1e7f3b9d5c83")
+	}
+	return trimmed, nil
+}
+
+func encryptHeaders(ctx context.Context, headers map[string]string) (string, []string, *common.Error) {
+	if len(headers) == 0 {
+		return "", []string{}, nil
+	}
+	names := make([]string, 0, len(headers))
+	cleaned := make(map[string]string, len(headers))
+	for name, value := range headers {
+		name = http.CanonicalHeaderKey(strings.TrimSpace(name))
+		if name == "" || strings.ContainsAny(name, " \r\n:") || strings.ContainsAny(value, "\r\n") {
+			return "", nil, common.NewErrorWithMessage(fmt.Sprintf("invalid header %q", name), "4e7b1d9f-6a3c-4e8b-a2d5-9f4c7a1e3b68")
+		}
+		cleaned[name] = value
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	keyring, err := crypto.DefaultKeyring()
+	if err != nil {
+		return "", nil, common.NewError(err, "7c2e9a5f-1d8b-4f3a-b6c9-2a5e8d1f7c34")
+	}
+	plain, err := json.Marshal(cleaned)
+	if err != nil {
+		return "", nil, common.NewError(err, "1f5d3b7e-9a2c-4c6f-8e1b-5d9a3f7c2e16")
+	}
+	cipher, err := keyring.Encrypt(ctx, string(plain))
+	if err != nil {
+		return "", nil, common.NewError(err, "6a9c4e2f-7b1d-4d8a-9f3e-2c6a8e4b1d59")
+	}
+	return cipher, names, nil
+}
+
+func decryptHeaders(ctx context.Context, server *Server) (map[string]string, error) {
+	headers := map[string]string{}
+	if server.EncryptedHeaders == "" {
+		return headers, nil
+	}
+	keyring, err := crypto.DefaultKeyring()
+	if err != nil {
+		return nil, err
+	}
+	plain, err := keyring.Decrypt(ctx, server.EncryptedHeaders)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(plain), &headers); err != nil {
+		return nil, err
+	}
+	return headers, nil
+}
@@ -0,0 +1,48 @@
+package federatedmcp_test
+
+import (
+	"testing"
+
+	"menlo.ai/indigo-api-gateway/app/domain/mcp/federatedmcp"
+)
+
+func TestToolAllowlistAllows(t *testing.T) {
+	var unrestricted *federatedmcp.ToolAllowlist
+	if !unrestricted.Allows("github__create_issue") {
+		t.Fatal("a nil allowlist must allow every tool")
+	}
+
+	allowlist := &federatedmcp.ToolAllowlist{Tools: []string{"google_search", "github__*", "jira__get_issue"}}
+	cases := map[string]bool{
+		"google_search":        true,
+		"scrape":               false,
+		"github__create_issue": true,
+		"jira__get_issue":      true,
+		"jira__delete_issue":   false,
+		"githubx__list":        false,
+	}
+	for tool, want := range cases {
+		if got := allowlist.Allows(tool); got != want {
+			t.Errorf("Allows(%q) = %v, want %v", tool, got, want)
+		}
+	}
+
+	if (&federatedmcp.ToolAllowlist{Tools: []string{}}).Allows("google_search") {
+		t.Error("an empty allowlist must not allow any tool")
+	}
+	if !(&federatedmcp.ToolAllowlist{Tools: []string{"*"}}).Allows("jira__get_issue") {
+		t.Error("* must allow every tool")
+	}
+}
+
+func TestSplitNamespacedName(t *testing.T) {
+	server, name, ok := federatedmcp.SplitNamespacedName(federatedmcp.NamespacedName("github", "create__issue"))
+	if !ok || server != "github" || name != "create__issue" {
+		t.Fatalf("SplitNamespacedName() = %q, %q, %v", server, name, ok)
+	}
+	for _, invalid := range []string{"google_search", "__tool", "github__"} {
+		if _, _, ok := federatedmcp.SplitNamespacedName(invalid); ok {
+			t.Errorf("SplitNamespacedName(%q) should fail", invalid)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package mcpactivity
+
+import (
+	"context"
+	"time"
+
+	"menlo.ai/indigo-api-gateway/app/domain/query"
+)
+
+// Activity is one JSON-RPC request handled by the MCP endpoint
+type Activity struct {
+	ID             uint
+	PublicID       string
+	OrganizationID uint
+	ProjectID      *uint
+	UserPublicID   *string
+	APIKeyPublicID *string
+	Method         string
+	// Tool is the called tool for tools/call requests
+	Tool *string
+	// ArgumentBytes is the size of the JSON encoded params of the request
+	ArgumentBytes int
+	LatencyMs     int64
+	Success       bool
+	// Error holds the JSON-RPC error message, or the text of a tool result flagged as error
+	Error     *string
+	CreatedAt time.Time
+}
+
+type ActivityFilter struct {
+	PublicID       *string
+	OrganizationID *uint
+	ProjectID      *uint
+	UserPublicID   *string
+	APIKeyPublicID *string
+	Method         *string
+	Tool           *string
+	Success        *bool
+	CreatedAfter   *time.Time
+	CreatedBefore  *time.Time
+}
+
+// ToolStats aggregates the tools/call activity of one tool
+type ToolStats struct {
+	Tool         string
+	Calls        int64
+	Errors       int64
+	AvgLatencyMs float64
+	P95LatencyMs float64
+	LastCalledAt time.Time
+}
+
+type ActivityRepository interface {
+	Create(ctx context.Context, activity *Activity) error
+	FindByFilter(ctx context.Context, filter ActivityFilter, pagination *query.Pagination) ([]*Activity, error)
+	Count(ctx context.Context, filter ActivityFilter) (int64, error)
+	// ToolStats aggregates the tools/call activity matching the filter per tool, most called first
+	ToolStats(ctx context.Context, filter ActivityFilter) ([]*ToolStats, error)
+	// DeleteBefore removes up to limit entries created before cutoff and returns how many were removed
+	DeleteBefore(ctx context.Context, cutoff time.Time, limit int) (int64, error)
+}
@@ -0,0 +1,109 @@
+package mcpactivity
+
+import (
+	"context"
+	"time"
+
+	"menlo.ai/indigo-api-gateway/app/domain/common"
+	"menlo.ai/indigo-api-gateway/app/domain/query"
+	"menlo.ai/indigo-api-gateway/app/utils/idgen"
+	"menlo.ai/indigo-api-gateway/app/utils/logger"
+	"menlo.ai/indigo-api-gateway/config/environment_variables"
+)
+
+const (
+	defaultRetentionDays = 30
+	// maxErrorLength bounds the error message kept for a failed request
+	maxErrorLength = 1024
+	recordTimeout  = 5 * time.Second
+	pruneBatchSize = 1000
+	// maxPruneBatches caps the work of one prune, the remainder is picked up by the next one
+	maxPruneBatches = 100
+)
+
+type ActivityService struct {
+	repo ActivityRepository
+}
+
+func NewActivityService(repo ActivityRepository) *ActivityService {
+	return &ActivityService{repo: repo}
+}
+
+// RetentionDays returns how long activity is kept
+func RetentionDays() int {
+	if days := environment_variables.Current().MCP_ACTIVITY_RETENTION_DAYS; days > 0 {
+		return days
+	}
+	return defaultRetentionDays
+}
+
+// Record stores an activity in the background so logging never delays or fails the MCP response
+func (s *ActivityService) Record(ctx context.Context, activity *Activity) {
+	if activity.Error != nil && len(*activity.Error) > maxErrorLength {
+		truncated := (*activity.Error)[:maxErrorLength]
+		activity.Error = &truncated
+	}
+	go func() {
+		recordCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), recordTimeout)
+		defer cancel()
+		publicID, err := idgen.GenerateSecureID("mcpact", 24)
+		if err != nil {
+			logger.GetLogger().Errorf("failed to generate MCP activity id: %v", err)
+			return
+		}
+		activity.PublicID = publicID
+		if err := s.repo.Create(recordCtx, activity); err != nil {
+			logger.GetLogger().Errorf("failed to record MCP activity %s: %v", activity.Method, err)
+		}
+	}()
+}
+
+func (s *ActivityService) ListActivity(ctx context.Context, filter ActivityFilter, pagination *query.Pagination) ([]*Activity, int64, *common.Error) {
+	activities, err := s.repo.FindByFilter(ctx, filter, pagination)
+	if err != nil {
+		return nil, 0, common.NewError(err, "3d8f1b6a-9c2e-4a7d-b5f3-1e6a9c4d8b27")
+	}
+	total, err := s.repo.Count(ctx, filter)
+	if err != nil {
+		return nil, 0, common.NewError(err, "7a2c5e9f-4b1d-4e8a-9f6c-3b8e1d5a7c42")
+	}
+	return activities, total, nil
+}
+
+// FindActivity returns the activity with the public id within the filter, or nil
+func (s *ActivityService) FindActivity(ctx context.Context, filter ActivityFilter, publicID string) (*Activity, *common.Error) {
+	filter.PublicID = &publicID
+	activities, err := s.repo.FindByFilter(ctx, filter, nil)
+	if err != nil {
+		return nil, common.NewError(err, "5e9b3d7f-2a6c-4f1e-8d4b-9c2f6a1e5d83")
+	}
+	if len(activities) == 0 {
+		return nil, nil
+	}
+	return activities[0], nil
+}
+
+func (s *ActivityService) ToolStats(ctx context.Context, filter ActivityFilter) ([]*ToolStats, *common.Error) {
+	stats, err := s.repo.ToolStats(ctx, filter)
+	if err != nil {
+		return nil, common.NewError(err, "1b7e4a9d-6f3c-4d2a-a8e5-7c1f4b9e3d66")
+	}
+	return stats, nil
+}
+
+// Prune removes the activity older than the retention period
+func (s *ActivityService) Prune(ctx context.Context) (int64, *common.Error) {
+	cutoff := time.Now().AddDate(0, 0, -RetentionDays())
+	var total int64
+	for i := 0; i < maxPruneBatches; i++ {
+		deleted, err := s.repo.DeleteBefore(ctx, cutoff, pruneBatchSize)
+		if err != nil {
+			return total, common.NewError(err, "8f4c2a6e-1d9b-4b7f-a3e8-5d2c9f6b1a74")
+		}
+		total += deleted
+		if deleted < pruneBatchSize {
+			break
+		}
+	}
+	return total, nil
+}
@@ -0,0 +1,340 @@
+package serpermcp
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"menlo.ai/indigo-api-gateway/app/domain/settings"
+	"menlo.ai/indigo-api-gateway/app/utils/httpclients/serper"
+	"menlo.ai/indigo-api-gateway/app/utils/httpclients/websearch"
+)
+
+const (
+	defaultSearchResults = 10
+	// maxSearchResults is the page size limit of Brave and Tavily
+	maxSearchResults = 20
+	// maxBraveOffset is the last page Brave serves
+	maxBraveOffset = 9
+)
+
+// SearchBackend runs web searches and normalizes the results to the Serper response shape,
+// so the search tools work the same whatever the backend
+type SearchBackend interface {
+	Name() string
+	Search(ctx context.Context, query SearchRequest) (*SearchResponse, error)
+}
+
+// NewSearchBackend creates the backend selected in the web search settings of an organization
+func NewSearchBackend(config *settings.WebSearchSettings) (SearchBackend, error) {
+	switch config.Backend {
+	case "", settings.WebSearchBackendSerper:
+		return &serperBackend{client: serper.NewSerperClientWithConfig(config.BaseURL, config.APIKey)}, nil
+	case settings.WebSearchBackendSearXNG:
+		if config.BaseURL == "" {
+			return nil, fmt.Errorf("searxng web search requires a base URL")
+		}
+		return &searxngBackend{client: websearch.NewSearXNGClient(config.BaseURL)}, nil
+	case settings.WebSearchBackendBrave:
+		if config.APIKey == "" {
+			return nil, fmt.Errorf("brave web search requires an API key")
+		}
+		return &braveBackend{client: websearch.NewBraveClient(config.BaseURL, config.APIKey)}, nil
+	case settings.WebSearchBackendTavily:
+		if config.APIKey == "" {
+			return nil, fmt.Errorf("tavily web search requires an API key")
+		}
+		return &tavilyBackend{client: websearch.NewTavilyClient(config.BaseURL, config.APIKey)}, nil
+	default:
+		return nil, fmt.Errorf("unknown web search backend %q", config.Backend)
+	}
+}
+
+type serperBackend struct {
+	client *serper.SerperClient
+}
+
+func (b *serperBackend) Name() string {
+	return settings.WebSearchBackendSerper
+}
+
+func (b *serperBackend) Search(ctx context.Context, query SearchRequest) (*SearchResponse, error) {
+	request := serper.SearchRequest{
+		Q:           query.Q,
+		GL:          query.GL,
+		HL:          query.HL,
+		Location:    query.Location,
+		Num:         query.Num,
+		Page:        query.Page,
+		Autocorrect: query.Autocorrect,
+	}
+	if query.TBS != nil && *query.TBS != TBSAny {
+		tbs := serper.TBSTimeRange(*query.TBS)
+		request.TBS = &tbs
+	}
+	resp, err := b.client.Search(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SearchResponse{
+		SearchParameters: resp.SearchParameters,
+		Organic:          resp.Organic,
+		KnowledgeGraph:   resp.KnowledgeGraph,
+		Images:           resp.Images,
+		News:             resp.News,
+		AnswerBox:        resp.AnswerBox,
+	}, nil
+}
+
+type searxngBackend struct {
+	client *websearch.SearXNGClient
+}
+
+func (b *searxngBackend) Name() string {
+	return settings.WebSearchBackendSearXNG
+}
+
+func (b *searxngBackend) Search(ctx context.Context, query SearchRequest) (*SearchResponse, error) {
+	resp, err := b.client.Search(ctx, websearch.SearXNGSearchRequest{
+		Q:         query.Q,
+		Language:  stringValue(query.HL),
+		PageNo:    searchPage(query),
+		TimeRange: timeRange(query.TBS),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// SearXNG has no page size, the page is cut to the requested count
+	results := resp.Results
+	if num := resultCount(query, 0); len(results) > num {
+		results = results[:num]
+	}
+	organic := make([]map[string]interface{}, 0, len(results))
+	for i, result := range results {
+		entry := organicResult(i+1, result.Title, result.URL, result.Content)
+		if result.PublishedDate != "" {
+			entry["date"] = result.PublishedDate
+		}
+		if result.Engine != "" {
+			entry["source"] = result.Engine
+		}
+		organic = append(organic, entry)
+	}
+
+	response := &SearchResponse{
+		SearchParameters: searchParameters(query, b.Name()),
+		Organic:          organic,
+	}
+	for _, answer := range resp.Answers {
+		if text := answerText(answer); text != "" {
+			response.AnswerBox = map[string]interface{}{"answer": text}
+			break
+		}
+	}
+	return response, nil
+}
+
+// answerText reads a SearXNG answer, older versions return strings and newer ones objects
+func answerText(answer interface{}) string {
+	switch value := answer.(type) {
+	case string:
+		return value
+	case map[string]interface{}:
+		if text, ok := value["answer"].(string); ok {
+			return text
+		}
+	}
+	return ""
+}
+
+type braveBackend struct {
+	client *websearch.BraveClient
+}
+
+func (b *braveBackend) Name() string {
+	return settings.WebSearchBackendBrave
+}
+
+func (b *braveBackend) Search(ctx context.Context, query SearchRequest) (*SearchResponse, error) {
+	offset := searchPage(query) - 1
+	if offset > maxBraveOffset {
+		offset = maxBraveOffset
+	}
+	resp, err := b.client.Search(ctx, websearch.BraveSearchRequest{
+		Q:          query.Q,
+		Country:    strings.ToUpper(stringValue(query.GL)),
+		SearchLang: stringValue(query.HL),
+		Count:      resultCount(query, maxSearchResults),
+		Offset:     offset,
+		Freshness:  braveFreshness(query.TBS),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	organic := make([]map[string]interface{}, 0, len(resp.Web.Results))
+	for i, result := range resp.Web.Results {
+		organic = append(organic, braveEntry(i+1, result))
+	}
+	response := &SearchResponse{
+		SearchParameters: searchParameters(query, b.Name()),
+		Organic:          organic,
+	}
+	for i, result := range resp.News.Results {
+		response.News = append(response.News, braveEntry(i+1, result))
+	}
+	return response, nil
+}
+
+// braveMarkup strips the highlighting Brave puts in its descriptions
+var braveMarkup = strings.NewReplacer("<strong>", "", "</strong>", "")
+
+func braveEntry(position int, result websearch.BraveResult) map[string]interface{} {
+	entry := organicResult(position, result.Title, result.URL, braveMarkup.Replace(result.Description))
+	if result.Age != "" {
+		entry["date"] = result.Age
+	}
+	if result.Profile.Name != "" {
+		entry["source"] = result.Profile.Name
+	}
+	return entry
+}
+
+func braveFreshness(tbs *TBSTimeRange) string {
+	switch timeRange(tbs) {
+	case "day":
+		return "pd"
+	case "week":
+		return "pw"
+	case "month":
+		return "pm"
+	case "year":
+		return "py"
+	}
+	return ""
+}
+
+type tavilyBackend struct {
+	client *websearch.TavilyClient
+}
+
+func (b *tavilyBackend) Name() string {
+	return settings.WebSearchBackendTavily
+}
+
+func (b *tavilyBackend) Search(ctx context.Context, query SearchRequest) (*SearchResponse, error) {
+	// Tavily has no pagination, later pages are cut from a larger result set within its limit
+	num := resultCount(query, maxSearchResults)
+	skip := (searchPage(query) - 1) * num
+	if skip >= maxSearchResults {
+		return &SearchResponse{
+			SearchParameters: searchParameters(query, b.Name()),
+			Organic:          []map[string]interface{}{},
+		}, nil
+	}
+	resp, err := b.client.Search(ctx, websearch.TavilySearchRequest{
+		Query:      query.Q,
+		MaxResults: min(skip+num, maxSearchResults),
+		TimeRange:  timeRange(query.TBS),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	results := resp.Results
+	if skip < len(results) {
+		results = results[skip:]
+	} else {
+		results = nil
+	}
+	organic := make([]map[string]interface{}, 0, len(results))
+	for i, result := range results {
+		entry := organicResult(skip+i+1, result.Title, result.URL, result.Content)
+		if result.PublishedDate != "" {
+			entry["date"] = result.PublishedDate
+		}
+		organic = append(organic, entry)
+	}
+	response := &SearchResponse{
+		SearchParameters: searchParameters(query, b.Name()),
+		Organic:          organic,
+	}
+	if resp.Answer != "" {
+		response.AnswerBox = map[string]interface{}{"answer": resp.Answer}
+	}
+	return response, nil
+}
+
+func organicResult(position int, title, link, snippet string) map[string]interface{} {
+	return map[string]interface{}{
+		"position": position,
+		"title":    title,
+		"link":     link,
+		"snippet":  snippet,
+	}
+}
+
+func searchParameters(query SearchRequest, engine string) map[string]interface{} {
+	parameters := map[string]interface{}{
+		"q":      query.Q,
+		"engine": engine,
+		"num":    resultCount(query, 0),
+		"page":   searchPage(query),
+	}
+	if query.GL != nil {
+		parameters["gl"] = *query.GL
+	}
+	if query.HL != nil {
+		parameters["hl"] = *query.HL
+	}
+	if timeRange(query.TBS) != "" {
+		parameters["tbs"] = string(*query.TBS)
+	}
+	return parameters
+}
+
+// resultCount returns the requested number of results, capped at limit when limit is positive
+func resultCount(query SearchRequest, limit int) int {
+	num := defaultSearchResults
+	if query.Num != nil && *query.Num > 0 {
+		num = *query.Num
+	}
+	if limit > 0 && num > limit {
+		num = limit
+	}
+	return num
+}
+
+func searchPage(query SearchRequest) int {
+	if query.Page != nil && *query.Page > 1 {
+		return *query.Page
+	}
+	return 1
+}
+
+// timeRange maps a Serper time filter to the day, week, month or year ranges of the other backends
+func timeRange(tbs *TBSTimeRange) string {
+	if tbs == nil {
+		return ""
+	}
+	switch *tbs {
+	case TBSPastHour, TBSPastDay:
+		return "day"
+	case TBSPastWeek:
+		return "week"
+	case TBSPastMonth:
+		return "month"
+	case TBSPastYear:
+		return "year"
+	}
+	return ""
+}
+
+func stringValue(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return strings.TrimSpace(*value)
+}
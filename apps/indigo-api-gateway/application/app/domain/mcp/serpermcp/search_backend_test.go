@@ -0,0 +1,104 @@
+package serpermcp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"menlo.ai/indigo-api-gateway/app/domain/settings"
+	"menlo.ai/indigo-api-gateway/app/utils/httpclients/websearch"
+	"menlo.ai/indigo-api-gateway/app/utils/ptr"
+)
+
+func TestSearchBackendsNormalizeResults(t *testing.T) {
+	websearch.Init()
+	cases := []struct {
+		backend string
+		path    string
+		body    string
+		check   func(r *http.Request) string
+	}{
+		{
+			backend: settings.WebSearchBackendSearXNG,
+			path:    "/search",
+			body:    `{"results":[{"title":"Go","url":"https://go.dev","content":"The Go language","engine":"duckduckgo"},{"title":"Extra","url":"https://example.com","content":"cut"}],"answers":[{"answer":"Go 1.25"}]}`,
+			check: func(r *http.Request) string {
+				if r.URL.Query().Get("format") != "json" || r.URL.Query().Get("time_range") != "week" {
+					return r.URL.RawQuery
+				}
+				return ""
+			},
+		},
+		{
+			backend: settings.WebSearchBackendBrave,
+			path:    "/res/v1/web/search",
+			body:    `{"web":{"results":[{"title":"Go","url":"https://go.dev","description":"The <strong>Go</strong> language","profile":{"name":"go.dev"}}]}}`,
+			check: func(r *http.Request) string {
+				if r.Header.Get("X-Subscription-Token") != "key" || r.URL.Query().Get("freshness") != "pw" || r.URL.Query().Get("count") != "1" {
+					return r.URL.RawQuery
+				}
+				return ""
+			},
+		},
+		{
+			backend: settings.WebSearchBackendTavily,
+			path:    "/search",
+			body:    `{"answer":"Go 1.25","results":[{"title":"Go","url":"https://go.dev","content":"The Go language"}]}`,
+			check: func(r *http.Request) string {
+				if r.Method != http.MethodPost || r.Header.Get("Authorization") != "Bearer key" {
+					return r.Method + " " + r.Header.Get("Authorization")
+				}
+				return ""
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != tc.path {
+				t.Errorf("%s: unexpected path %s", tc.backend, r.URL.Path)
+			}
+			if problem := tc.check(r); problem != "" {
+				t.Errorf("%s: unexpected request %s", tc.backend, problem)
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(tc.body))
+		}))
+
+		backend, err := NewSearchBackend(&settings.WebSearchSettings{Backend: tc.backend, BaseURL: server.URL, APIKey: "key"})
+		if err != nil {
+			t.Fatalf("%s: %v", tc.backend, err)
+		}
+		tbs := TBSPastWeek
+		resp, err := backend.Search(context.Background(), SearchRequest{Q: "golang", Num: ptr.ToInt(1), TBS: &tbs})
+		server.Close()
+		if err != nil {
+			t.Fatalf("%s: %v", tc.backend, err)
+		}
+
+		if len(resp.Organic) != 1 {
+			t.Fatalf("%s: got %d organic results, want 1", tc.backend, len(resp.Organic))
+		}
+		result := resp.Organic[0]
+		if result["title"] != "Go" || result["link"] != "https://go.dev" || result["snippet"] != "The Go language" || result["position"] != 1 {
+			t.Errorf("%s: organic result = %v", tc.backend, result)
+		}
+		if resp.SearchParameters["engine"] != tc.backend || resp.SearchParameters["q"] != "golang" {
+			t.Errorf("%s: search parameters = %v", tc.backend, resp.SearchParameters)
+		}
+	}
+}
+
+func TestNewSearchBackendRequiresCredentials(t *testing.T) {
+	for _, config := range []settings.WebSearchSettings{
+		{Backend: settings.WebSearchBackendSearXNG},
+		{Backend: settings.WebSearchBackendBrave},
+		{Backend: settings.WebSearchBackendTavily},
+		{Backend: "bing"},
+	} {
+		if _, err := NewSearchBackend(&config); err == nil {
+			t.Errorf("NewSearchBackend(%+v) should fail", config)
+		}
+	}
+}
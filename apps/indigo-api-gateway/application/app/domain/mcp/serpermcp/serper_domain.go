@@ -38,5 +38,6 @@ type FetchWebpageRequest struct {
 
 type FetchWebpageResponse struct {
 	Text     string                 `json:"text"`
+	Markdown string                 `json:"markdown,omitempty"`
 	Metadata map[string]interface{} `json:"metadata"`
 }
@@ -3,58 +3,53 @@ package serpermcp
 import (
 	"context"
 
+	"menlo.ai/indigo-api-gateway/app/domain/organization"
+	"menlo.ai/indigo-api-gateway/app/domain/settings"
+	"menlo.ai/indigo-api-gateway/app/infrastructure/cache"
 	"menlo.ai/indigo-api-gateway/app/utils/httpclients/serper"
 )
 
 type SerperService struct {
-	SerperClient *serper.SerperClient
+	SerperClient    *serper.SerperClient
+	WebpageFetcher  WebpageFetcher
+	settingsService *settings.Service
 }
 
-func NewSerperService() *SerperService {
+func NewSerperService(settingsService *settings.Service, cacheService *cache.RedisCacheService) *SerperService {
+	serperClient := serper.NewSerperClient()
 	return &SerperService{
-		SerperClient: serper.NewSerperClient(),
+		SerperClient:    serperClient,
+		WebpageFetcher:  NewWebpageFetcher(cacheService, serperClient),
+		settingsService: settingsService,
 	}
 }
 
+// Search runs the query on the web search backend of the organization of the request, see
+// organization.WithOrganizationID, or of the default organization when the request has none
 func (s *SerperService) Search(ctx context.Context, query SearchRequest) (*SearchResponse, error) {
-	var tbs *serper.TBSTimeRange
-	request := serper.SearchRequest{
-		Q:           query.Q,
-		GL:          query.GL,
-		HL:          query.HL,
-		Location:    query.Location,
-		Num:         query.Num,
-		Page:        query.Page,
-		Autocorrect: query.Autocorrect,
-		TBS:         tbs,
+	if organizationID, ok := organization.OrganizationIDFromContext(ctx); ok {
+		return s.SearchForOrganization(ctx, organizationID, query)
 	}
-	resp, err := s.SerperClient.Search(ctx, request)
-	if err != nil {
-		return nil, err
+	if organization.DEFAULT_ORGANIZATION == nil {
+		return (&serperBackend{client: s.SerperClient}).Search(ctx, query)
 	}
-
-	return &SearchResponse{
-		SearchParameters: resp.SearchParameters,
-		Organic:          resp.Organic,
-		KnowledgeGraph:   resp.KnowledgeGraph,
-		Images:           resp.Images,
-		News:             resp.News,
-		AnswerBox:        resp.AnswerBox,
-	}, nil
+	return s.SearchForOrganization(ctx, organization.DEFAULT_ORGANIZATION.ID, query)
 }
 
-func (s *SerperService) FetchWebpage(ctx context.Context, query FetchWebpageRequest) (*FetchWebpageResponse, error) {
-	request := serper.FetchWebpageRequest{
-		Url:             query.Url,
-		IncludeMarkdown: query.IncludeMarkdown,
+// SearchForOrganization runs the query on the web search backend selected in the settings of the organization
+func (s *SerperService) SearchForOrganization(ctx context.Context, organizationID uint, query SearchRequest) (*SearchResponse, error) {
+	config, err := s.settingsService.GetWebSearchSettings(ctx, organizationID)
+	if err != nil {
+		return nil, err
 	}
-	resp, err := s.SerperClient.FetchWebpage(ctx, request)
+	backend, err := NewSearchBackend(config)
 	if err != nil {
 		return nil, err
 	}
+	return backend.Search(ctx, query)
+}
 
-	return &FetchWebpageResponse{
-		Text:     resp.Text,
-		Metadata: resp.Metadata,
-	}, nil
+// FetchWebpage reads a webpage with the fetcher selected by WEBPAGE_FETCHER
+func (s *SerperService) FetchWebpage(ctx context.Context, query FetchWebpageRequest) (*FetchWebpageResponse, error) {
+	return s.WebpageFetcher.FetchWebpage(ctx, query)
 }
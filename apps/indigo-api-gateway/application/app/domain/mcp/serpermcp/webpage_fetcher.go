@@ -0,0 +1,147 @@
+package serpermcp
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"menlo.ai/indigo-api-gateway/app/infrastructure/cache"
+	"menlo.ai/indigo-api-gateway/app/utils/httpclients/serper"
+	"menlo.ai/indigo-api-gateway/app/utils/logger"
+	"menlo.ai/indigo-api-gateway/app/utils/webfetch"
+	"menlo.ai/indigo-api-gateway/config/environment_variables"
+)
+
+const (
+	WebpageFetcherNative = "native"
+	WebpageFetcherSerper = "serper"
+
+	defaultWebpageCacheTTL = time.Hour
+)
+
+// WebpageFetcher reads a webpage for the scrape and fetch_webpage tools
+type WebpageFetcher interface {
+	Name() string
+	FetchWebpage(ctx context.Context, query FetchWebpageRequest) (*FetchWebpageResponse, error)
+}
+
+// NewWebpageFetcher returns the fetcher selected by WEBPAGE_FETCHER, the in-gateway fetcher by default
+func NewWebpageFetcher(cacheService *cache.RedisCacheService, serperClient *serper.SerperClient) WebpageFetcher {
+	if strings.EqualFold(environment_variables.Current().WEBPAGE_FETCHER, WebpageFetcherSerper) {
+		return &serperWebpageFetcher{client: serperClient}
+	}
+	ttl := defaultWebpageCacheTTL
+	if minutes := environment_variables.Current().WEBPAGE_CACHE_TTL_MINUTES; minutes > 0 {
+		ttl = time.Duration(minutes) * time.Minute
+	}
+	return &nativeWebpageFetcher{
+		fetcher: webfetch.NewFetcher(webfetch.Config{}),
+		cache:   cacheService,
+		ttl:     ttl,
+	}
+}
+
+// nativeWebpageFetcher fetches pages from the gateway and caches them in Redis by URL
+type nativeWebpageFetcher struct {
+	fetcher *webfetch.Fetcher
+	cache   *cache.RedisCacheService
+	ttl     time.Duration
+}
+
+func (f *nativeWebpageFetcher) Name() string {
+	return WebpageFetcherNative
+}
+
+func (f *nativeWebpageFetcher) FetchWebpage(ctx context.Context, query FetchWebpageRequest) (*FetchWebpageResponse, error) {
+	key := webpageCacheKey(query.Url)
+	if f.cache != nil {
+		if cached, err := f.cache.Get(ctx, key); err == nil {
+			var page FetchWebpageResponse
+			if err := json.Unmarshal([]byte(cached), &page); err == nil {
+				return withMarkdown(&page, query.IncludeMarkdown), nil
+			}
+		}
+	}
+
+	page, err := f.fetcher.Fetch(ctx, query.Url)
+	if err != nil {
+		return nil, err
+	}
+	metadata := map[string]interface{}{
+		"url":         page.URL,
+		"contentType": page.ContentType,
+	}
+	if page.Title != "" {
+		metadata["title"] = page.Title
+	}
+	if page.Description != "" {
+		metadata["description"] = page.Description
+	}
+	if page.Truncated {
+		metadata["truncated"] = true
+	}
+	response := &FetchWebpageResponse{
+		Text:     page.Text,
+		Markdown: page.Markdown,
+		Metadata: metadata,
+	}
+
+	if f.cache != nil {
+		if data, err := json.Marshal(response); err == nil {
+			if err := f.cache.Set(ctx, key, string(data), f.ttl); err != nil {
+				logger.GetLogger().Errorf("failed to cache webpage %s: %v", page.URL, err)
+			}
+		}
+	}
+	return withMarkdown(response, query.IncludeMarkdown), nil
+}
+
+// withMarkdown drops the Markdown unless it was asked for, the cache always keeps both
+func withMarkdown(page *FetchWebpageResponse, includeMarkdown *bool) *FetchWebpageResponse {
+	if includeMarkdown == nil || !*includeMarkdown {
+		page.Markdown = ""
+	}
+	return page
+}
+
+// webpageCacheKey keys a page by its URL without the fragment, which never reaches the server
+func webpageCacheKey(rawURL string) string {
+	normalized := strings.TrimSpace(rawURL)
+	if parsed, err := url.Parse(normalized); err == nil {
+		parsed.Fragment = ""
+		parsed.RawFragment = ""
+		parsed.Host = strings.ToLower(parsed.Host)
+		normalized = parsed.String()
+	}
+	sum := sha256.Sum256([]byte(normalized))
+	return fmt.Sprintf(cache.WebpageKey, hex.EncodeToString(sum[:]))
+}
+
+// serperWebpageFetcher uses the paid scrape endpoint of Serper
+type serperWebpageFetcher struct {
+	client *serper.SerperClient
+}
+
+func (f *serperWebpageFetcher) Name() string {
+	return WebpageFetcherSerper
+}
+
+func (f *serperWebpageFetcher) FetchWebpage(ctx context.Context, query FetchWebpageRequest) (*FetchWebpageResponse, error) {
+	resp, err := f.client.FetchWebpage(ctx, serper.FetchWebpageRequest{
+		Url:             query.Url,
+		IncludeMarkdown: query.IncludeMarkdown,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &FetchWebpageResponse{
+		Text:     resp.Text,
+		Markdown: resp.Markdown,
+		Metadata: resp.Metadata,
+	}, nil
+}
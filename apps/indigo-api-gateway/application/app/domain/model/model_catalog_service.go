@@ -50,6 +50,18 @@ func (s *ModelCatalogService) UpsertCatalog(ctx context.Context, kind ProviderKi
 	return catalog, nil
 }
 
+// FindByID returns the catalog entry with the given ID, or nil when it does not exist.
+func (s *ModelCatalogService) FindByID(ctx context.Context, id uint) (*ModelCatalog, *common.Error) {
+	catalogs, err := s.modelCatalogRepo.FindByFilter(ctx, ModelCatalogFilter{IDs: &[]uint{id}}, nil)
+	if err != nil {
+		return nil, common.NewError(err, "6b0f3f7e-5d0c-4c43-a0a8-2a9e7c1d4b52")
+	}
+	if len(catalogs) == 0 {
+		return nil, nil
+	}
+	return catalogs[0], nil
+}
+
 func catalogPublicID(model chatclient.Model) string {
 	if slug := slugify(model.CanonicalSlug); slug != "" {
 		return slug
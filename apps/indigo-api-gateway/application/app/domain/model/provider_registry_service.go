@@ -17,7 +17,6 @@ import (
 	chatclient "menlo.ai/indigo-api-gateway/app/utils/httpclients/chat"
 	"menlo.ai/indigo-api-gateway/app/utils/idgen"
 	"menlo.ai/indigo-api-gateway/app/utils/ptr"
-	environment_variables "menlo.ai/indigo-api-gateway/config/environment_variables"
 )
 
 type ProviderRegistryService struct {
@@ -124,11 +123,11 @@ func (s *ProviderRegistryService) RegisterProvider(ctx context.Context, input Re
 	apiKeyHint := apiKeyHint(plainAPIKey)
 	var encryptedAPIKey string
 	if plainAPIKey != "" {
-		secret := strings.TrimSpace(environment_variables.EnvironmentVariables.MODEL_PROVIDER_SECRET)
-		if secret == "" {
-			return nil, common.NewErrorWithMessage("model provider secret is not configured", "2f2a5cf4-5f2d-49ca-9e60-dfb09efc3a9e")
+		keyring, err := crypto.DefaultKeyring()
+		if err != nil {
+			return nil, common.NewError(err, "2f2a5cf4-5f2d-49ca-9e60-dfb09efc3a9e")
 		}
-		cipher, err := crypto.EncryptString(secret, plainAPIKey)
+		cipher, err := keyring.Encrypt(ctx, plainAPIKey)
 		if err != nil {
 			return nil, common.NewError(err, "5d0d8f02-bf6f-4e1f-9f04-2a4dd21f4c81")
 		}
@@ -285,11 +284,11 @@ func (s *ProviderRegistryService) UpdateProvider(ctx context.Context, provider *
 			provider.EncryptedAPIKey = ""
 			provider.APIKeyHint = nil
 		} else {
-			secret := strings.TrimSpace(environment_variables.EnvironmentVariables.MODEL_PROVIDER_SECRET)
-			if secret == "" {
-				return nil, common.NewErrorWithMessage("model provider secret is not configured", "ae950cb5-2f5a-4415-bc15-eec48c92610a")
+			keyring, err := crypto.DefaultKeyring()
+			if err != nil {
+				return nil, common.NewError(err, "ae950cb5-2f5a-4415-bc15-eec48c92610a")
 			}
-			cipher, err := crypto.EncryptString(secret, key)
+			cipher, err := keyring.Encrypt(ctx, key)
 			if err != nil {
 				return nil, common.NewError(err, "b5bd5d1c-7811-4dd3-9f3c-43f0cb14e1f4")
 			}
@@ -6,6 +6,7 @@ import (
 	"sync"
 
 	"menlo.ai/indigo-api-gateway/app/domain/query"
+	"menlo.ai/indigo-api-gateway/app/utils/contextkeys"
 	"menlo.ai/indigo-api-gateway/app/utils/idgen"
 	"menlo.ai/indigo-api-gateway/app/utils/ptr"
 )
@@ -33,6 +34,17 @@ func UpdateDefaultOrganization(o *Organization) {
 	})
 }
 
+// WithOrganizationID returns a context carrying the organization the request acts for
+func WithOrganizationID(ctx context.Context, organizationID uint) context.Context {
+	return context.WithValue(ctx, contextkeys.OrganizationID{}, organizationID)
+}
+
+// OrganizationIDFromContext returns the organization set by WithOrganizationID
+func OrganizationIDFromContext(ctx context.Context) (uint, bool) {
+	organizationID, ok := ctx.Value(contextkeys.OrganizationID{}).(uint)
+	return organizationID, ok
+}
+
 func (s *OrganizationService) createPublicID() (string, error) {
 	return idgen.GenerateSecureID("org", 16)
 }
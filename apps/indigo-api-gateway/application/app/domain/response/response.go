@@ -16,6 +16,7 @@ type Response struct {
 	PublicID           string
 	UserID             uint
 	ConversationID     *uint
+	ProjectID          *uint   // Project of the API key the response was created with
 	PreviousResponseID *string // Public ID of the previous response
 	Model              string
 	Status             ResponseStatus
@@ -42,6 +43,7 @@ type Response struct {
 	User               *string
 	Usage              *string // JSON string of usage statistics
 	Error              *string // JSON string of error details
+	IncompleteDetails  *string // JSON string of the reason the response is incomplete
 	CompletedAt        *time.Time
 	CancelledAt        *time.Time
 	FailedAt           *time.Time
@@ -55,12 +57,20 @@ type ResponseStatus string
 
 const (
 	ResponseStatusPending   ResponseStatus = "pending"
+	ResponseStatusQueued    ResponseStatus = "queued"
 	ResponseStatusRunning   ResponseStatus = "running"
 	ResponseStatusCompleted ResponseStatus = "completed"
 	ResponseStatusCancelled ResponseStatus = "cancelled"
 	ResponseStatusFailed    ResponseStatus = "failed"
+	// ResponseStatusIncomplete marks a finished response whose output does not satisfy the request, see IncompleteDetails
+	ResponseStatusIncomplete ResponseStatus = "incomplete"
 )
 
+// IsTerminal reports whether the response can no longer change status
+func (s ResponseStatus) IsTerminal() bool {
+	return s == ResponseStatusCompleted || s == ResponseStatusCancelled || s == ResponseStatusFailed || s == ResponseStatusIncomplete
+}
+
 // ResponseFilter represents filters for querying responses
 type ResponseFilter struct {
 	PublicID       *string
@@ -83,6 +93,8 @@ type ResponseRepository interface {
 	Count(ctx context.Context, filter ResponseFilter) (int64, error)
 	FindByUserID(ctx context.Context, userID uint, pagination *query.Pagination) ([]*Response, error)
 	FindByConversationID(ctx context.Context, conversationID uint, pagination *query.Pagination) ([]*Response, error)
+	// FailOrphaned marks the responses left unfinished by a stopped replica as failed and returns how many were
+	FailOrphaned(ctx context.Context, updatedBefore time.Time, errorJSON string) (int64, error)
 }
 
 // ResponseParams represents parameters for creating a response
@@ -194,10 +206,11 @@ func NewResponse(userID uint, conversationID *uint, model, input string, systemP
 
 // ResponseUpdates represents multiple updates to be applied to a response
 type ResponseUpdates struct {
-	Status *string `json:"status,omitempty"`
-	Output any     `json:"output,omitempty"`
-	Usage  any     `json:"usage,omitempty"`
-	Error  any     `json:"error,omitempty"`
+	Status            *string `json:"status,omitempty"`
+	Output            any     `json:"output,omitempty"`
+	Usage             any     `json:"usage,omitempty"`
+	Error             any     `json:"error,omitempty"`
+	IncompleteDetails any     `json:"incomplete_details,omitempty"`
 }
 
 // ApplyResponseUpdates applies multiple updates to a response object (no DB access)
@@ -228,6 +241,16 @@ func ApplyResponseUpdates(response *Response, updates *ResponseUpdates) *common.
 		}
 	}
 
+	// Update incomplete details if provided
+	if updates.IncompleteDetails != nil {
+		incompleteJSON, err := json.Marshal(updates.IncompleteDetails)
+		if err != nil {
+			return common.NewError(err, "e2b8d4f6-1a3c-4e5b-9d7f-2c4a6e8b0d13")
+		}
+		incompleteStr := string(incompleteJSON)
+		response.IncompleteDetails = &incompleteStr
+	}
+
 	return nil
 }
 
@@ -239,7 +262,7 @@ func UpdateResponseStatusOnObject(response *Response, status ResponseStatus) {
 	// Set completion timestamps based on status
 	now := time.Now()
 	switch status {
-	case ResponseStatusCompleted:
+	case ResponseStatusCompleted, ResponseStatusIncomplete:
 		response.CompletedAt = &now
 	case ResponseStatusCancelled:
 		response.CancelledAt = &now
@@ -0,0 +1,43 @@
+package response
+
+import (
+	"context"
+	"sync"
+)
+
+// CancellationRegistry keeps the cancel functions of the upstream calls running in this process
+type CancellationRegistry struct {
+	mu      sync.Mutex
+	cancels map[string]context.CancelFunc
+}
+
+func NewCancellationRegistry() *CancellationRegistry {
+	return &CancellationRegistry{
+		cancels: make(map[string]context.CancelFunc),
+	}
+}
+
+// Track derives a cancellable context for the response, release must be called once the call is done
+func (r *CancellationRegistry) Track(parent context.Context, responsePublicID string) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(parent)
+	r.mu.Lock()
+	r.cancels[responsePublicID] = cancel
+	r.mu.Unlock()
+	return ctx, func() {
+		r.mu.Lock()
+		delete(r.cancels, responsePublicID)
+		r.mu.Unlock()
+		cancel()
+	}
+}
+
+// Cancel interrupts the call of the response, it reports whether the call was running in this process
+func (r *CancellationRegistry) Cancel(responsePublicID string) bool {
+	r.mu.Lock()
+	cancel, ok := r.cancels[responsePublicID]
+	r.mu.Unlock()
+	if ok {
+		cancel()
+	}
+	return ok
+}
@@ -0,0 +1,60 @@
+package response
+
+import (
+	"context"
+	"time"
+
+	openai "github.com/sashabaranov/go-openai"
+	requesttypes "menlo.ai/indigo-api-gateway/app/interfaces/http/requests"
+)
+
+// ResponseJobStatus represents the state of a background response job
+type ResponseJobStatus string
+
+const (
+	ResponseJobStatusQueued    ResponseJobStatus = "queued"
+	ResponseJobStatusRunning   ResponseJobStatus = "running"
+	ResponseJobStatusCompleted ResponseJobStatus = "completed"
+	ResponseJobStatusFailed    ResponseJobStatus = "failed"
+	ResponseJobStatusCancelled ResponseJobStatus = "cancelled"
+)
+
+// ResponseJob is a durable unit of work processing a background response.
+// A running job is leased to one worker until LockedUntil, a job whose lease
+// expired is picked up again so work survives restarts of the worker.
+type ResponseJob struct {
+	ID              uint
+	ResponseID      uint
+	OrganizationID  uint   // organization the model provider is resolved for, as on the request
+	ProjectID       *uint  // project of the API key the response was created with
+	Payload         string // JSON encoded ResponseJobPayload
+	Status          ResponseJobStatus
+	Attempts        int
+	LockedBy        *string
+	LockedUntil     *time.Time
+	CancelRequested bool
+	LastError       *string
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
+
+// ResponseJobPayload holds everything needed to run the upstream call outside of the HTTP request
+type ResponseJobPayload struct {
+	Request               requesttypes.CreateResponseRequest `json:"request"`
+	ChatCompletionRequest openai.ChatCompletionRequest       `json:"chat_completion_request"`
+}
+
+// ResponseJobRepository defines the durable queue used by the background workers
+type ResponseJobRepository interface {
+	Enqueue(ctx context.Context, job *ResponseJob) error
+	// Claim leases the oldest runnable job to the worker, it returns nil when the queue is empty
+	Claim(ctx context.Context, workerID string, lease time.Duration) (*ResponseJob, error)
+	// Heartbeat extends the lease, ok is false when the worker no longer holds the job
+	Heartbeat(ctx context.Context, jobID uint, workerID string, lease time.Duration) (cancelRequested bool, ok bool, err error)
+	// IsCancelRequested reads the cancellation flag of the job without touching its lease
+	IsCancelRequested(ctx context.Context, jobID uint) (bool, error)
+	// Finish releases the lease and records the final status of the job
+	Finish(ctx context.Context, jobID uint, workerID string, status ResponseJobStatus, lastError *string) error
+	// RequestCancel flags the job of the response for cancellation, queued jobs are cancelled right away
+	RequestCancel(ctx context.Context, responseID uint) error
+}
@@ -2,6 +2,7 @@ package response
 
 import (
 	"context"
+	"encoding/json"
 	"net/http"
 	"time"
 
@@ -10,6 +11,7 @@ import (
 	"menlo.ai/indigo-api-gateway/app/domain/common"
 	"menlo.ai/indigo-api-gateway/app/domain/conversation"
 	domainmodel "menlo.ai/indigo-api-gateway/app/domain/model"
+	"menlo.ai/indigo-api-gateway/app/domain/structuredoutput"
 	requesttypes "menlo.ai/indigo-api-gateway/app/interfaces/http/requests"
 	responsetypes "menlo.ai/indigo-api-gateway/app/interfaces/http/responses"
 	"menlo.ai/indigo-api-gateway/app/utils/logger"
@@ -36,7 +38,7 @@ func NewNonStreamModelService(responseModelService *ResponseModelService) *NonSt
 // CreateNonStreamResponse handles the business logic for creating a non-streaming response
 func (h *NonStreamModelService) CreateNonStreamResponseHandler(reqCtx *gin.Context, request *requesttypes.CreateResponseRequest, provider *domainmodel.Provider, key string, conv *conversation.Conversation, responseEntity *Response, chatCompletionRequest *openai.ChatCompletionRequest) {
 
-	result, err := h.CreateNonStreamResponse(reqCtx, request, provider, key, conv, responseEntity, chatCompletionRequest)
+	result, err := h.CreateNonStreamResponse(reqCtx.Request.Context(), request, provider, key, conv, responseEntity, chatCompletionRequest)
 	if err != nil {
 		reqCtx.AbortWithStatusJSON(
 			http.StatusBadRequest,
@@ -50,11 +52,13 @@ func (h *NonStreamModelService) CreateNonStreamResponseHandler(reqCtx *gin.Conte
 	reqCtx.JSON(http.StatusOK, result)
 }
 
-// doCreateNonStreamResponse performs the business logic for creating a non-streaming response
-func (h *NonStreamModelService) CreateNonStreamResponse(reqCtx *gin.Context, request *requesttypes.CreateResponseRequest, provider *domainmodel.Provider, key string, conv *conversation.Conversation, responseEntity *Response, chatCompletionRequest *openai.ChatCompletionRequest) (responsetypes.Response, *common.Error) {
+// CreateNonStreamResponse performs the upstream call for a non-streaming response, it also runs the background jobs
+func (h *NonStreamModelService) CreateNonStreamResponse(ctx context.Context, request *requesttypes.CreateResponseRequest, provider *domainmodel.Provider, key string, conv *conversation.Conversation, responseEntity *Response, chatCompletionRequest *openai.ChatCompletionRequest) (responsetypes.Response, *common.Error) {
 	// Process with chat completion client for non-streaming with timeout
-	ctx, cancel := context.WithTimeout(reqCtx.Request.Context(), DefaultTimeout)
+	ctx, cancel := context.WithTimeout(ctx, DefaultTimeout)
 	defer cancel()
+	ctx, release := h.cancellations.Track(ctx, responseEntity.PublicID)
+	defer release()
 
 	// Create chat client from provider
 	chatClient, clientErr := h.ResponseModelService.inferenceProvider.GetChatCompletionClient(provider)
@@ -62,9 +66,44 @@ func (h *NonStreamModelService) CreateNonStreamResponse(reqCtx *gin.Context, req
 		return responsetypes.Response{}, common.NewError(clientErr, "bc82d69c-685b-4556-9d1f-2a4a80ae8ca3")
 	}
 
-	chatResponse, err := chatClient.CreateChatCompletion(ctx, key, *chatCompletionRequest)
-	if err != nil {
-		return responsetypes.Response{}, common.NewError(err, "bc82d69c-685b-4556-9d1f-2a4a80ae8ca4")
+	// Run built-in tools server-side until the model answers or the step budget is spent
+	var executions []*ToolExecution
+	var chatResponse *openai.ChatCompletionResponse
+	scope := NewToolScope(responseEntity.UserID, request.Tools)
+	for step := 0; ; step++ {
+		var err error
+		chatResponse, err = chatClient.CreateChatCompletion(ctx, key, *chatCompletionRequest)
+		if err != nil {
+			return responsetypes.Response{}, common.NewError(err, "bc82d69c-685b-4556-9d1f-2a4a80ae8ca4")
+		}
+		if ctx.Err() != nil {
+			// the response was cancelled while the upstream call was finishing
+			return responsetypes.Response{}, common.NewError(ctx.Err(), "5d7f9b1c-3e5a-4d7f-9b1c-3e5a7d9f1b3c")
+		}
+
+		if len(chatResponse.Choices) == 0 || step >= MaxToolSteps {
+			break
+		}
+		message := chatResponse.Choices[0].Message
+		if !h.toolExecutor.CanExecute(message.ToolCalls) {
+			break
+		}
+
+		roundExecutions := make([]*ToolExecution, 0, len(message.ToolCalls))
+		for _, call := range message.ToolCalls {
+			roundExecutions = append(roundExecutions, h.toolExecutor.Execute(ctx, call, scope))
+		}
+		executions = append(executions, roundExecutions...)
+		h.recordToolRound(ctx, conv, &responseEntity.ID, chatCompletionRequest, message.Content, message.ToolCalls, roundExecutions)
+	}
+
+	// Validate a json_object or json_schema answer, feeding the errors back to the model for bounded repairs
+	complete := func(ctx context.Context, request openai.ChatCompletionRequest) (*openai.ChatCompletionResponse, error) {
+		return chatClient.CreateChatCompletion(ctx, key, request)
+	}
+	chatResponse, structuredResult, enforceErr := structuredoutput.Enforce(ctx, structuredOutputFormat(chatCompletionRequest), *chatCompletionRequest, chatResponse, complete)
+	if enforceErr != nil {
+		return responsetypes.Response{}, common.NewError(enforceErr, "7c1e5a9d-3b7f-4d2a-8e6c-5f9b1d3a7e24")
 	}
 
 	// Process reasoning content
@@ -76,7 +115,7 @@ func (h *NonStreamModelService) CreateNonStreamResponse(reqCtx *gin.Context, req
 			Role:    openai.ChatMessageRoleAssistant,
 			Content: processedResponse.Choices[0].Message.Content,
 		}
-		success, err := h.responseService.AppendMessagesToConversation(reqCtx, conv, []openai.ChatCompletionMessage{assistantMessage}, &responseEntity.ID)
+		success, err := h.responseService.AppendMessagesToConversation(ctx, conv, []openai.ChatCompletionMessage{assistantMessage}, &responseEntity.ID)
 		if !success {
 			// Log error but don't fail the response
 			logger.GetLogger().Errorf("Failed to append assistant response to conversation: %s - %s", err.GetCode(), err.Error())
@@ -84,7 +123,7 @@ func (h *NonStreamModelService) CreateNonStreamResponse(reqCtx *gin.Context, req
 	}
 
 	// Convert chat completion response to response format
-	responseData := h.convertFromChatCompletionResponse(processedResponse, request, conv, responseEntity)
+	responseData := h.convertFromChatCompletionResponse(processedResponse, request, conv, responseEntity, executions)
 
 	// Update response with all fields at once (optimized to prevent N+1 queries)
 	updates := &ResponseUpdates{
@@ -92,7 +131,14 @@ func (h *NonStreamModelService) CreateNonStreamResponse(reqCtx *gin.Context, req
 		Output: responseData.Output,
 		Usage:  responseData.Usage,
 	}
-	success, updateErr := h.responseService.UpdateResponseFields(reqCtx, responseEntity.ID, updates)
+	if !structuredResult.Valid() {
+		details := incompleteDetails(structuredResult.Errors)
+		responseData.Status = responsetypes.ResponseStatusIncomplete
+		responseData.IncompleteDetails = details
+		updates.Status = ptr.ToString(string(ResponseStatusIncomplete))
+		updates.IncompleteDetails = details
+	}
+	success, updateErr := h.responseService.UpdateResponseFields(ctx, responseEntity.ID, updates)
 	if !success {
 		// Log error but don't fail the request since response is already generated
 		logger.GetLogger().Errorf("Failed to update response fields: %s - %s\n", updateErr.GetCode(), updateErr.Error())
@@ -102,7 +148,7 @@ func (h *NonStreamModelService) CreateNonStreamResponse(reqCtx *gin.Context, req
 }
 
 // convertFromChatCompletionResponse converts a ChatCompletionResponse to a Response
-func (h *NonStreamModelService) convertFromChatCompletionResponse(chatResp *openai.ChatCompletionResponse, req *requesttypes.CreateResponseRequest, conv *conversation.Conversation, responseEntity *Response) responsetypes.Response {
+func (h *NonStreamModelService) convertFromChatCompletionResponse(chatResp *openai.ChatCompletionResponse, req *requesttypes.CreateResponseRequest, conv *conversation.Conversation, responseEntity *Response, executions []*ToolExecution) responsetypes.Response {
 
 	// Extract the content and reasoning from the first choice
 	var outputText string
@@ -144,13 +190,34 @@ func (h *NonStreamModelService) convertFromChatCompletionResponse(chatResp *open
 		})
 	}
 
+	// Add the tools that ran server-side, in call order
+	for _, execution := range executions {
+		output = append(output, toolExecutionOutput(execution))
+	}
+
+	// Surface client-side function calls the model asked for
+	if len(chatResp.Choices) > 0 && len(chatResp.Choices[0].Message.ToolCalls) > 0 {
+		calls := make([]responsetypes.FunctionCallResult, 0, len(chatResp.Choices[0].Message.ToolCalls))
+		for _, toolCall := range chatResp.Choices[0].Message.ToolCalls {
+			call := responsetypes.FunctionCallResult{
+				Name: toolCall.Function.Name,
+			}
+			_ = json.Unmarshal([]byte(toolCall.Function.Arguments), &call.Arguments)
+			calls = append(calls, call)
+		}
+		output = append(output, responsetypes.ResponseOutput{
+			Type:          responsetypes.OutputTypeFunctionCalls,
+			FunctionCalls: &responsetypes.FunctionCallsOutput{Calls: calls},
+		})
+	}
+
 	// Add text content if present
 	if outputText != "" {
 		output = append(output, responsetypes.ResponseOutput{
 			Type: responsetypes.OutputTypeText,
 			Text: &responsetypes.TextOutput{
 				Value:       outputText,
-				Annotations: []responsetypes.Annotation{},
+				Annotations: fileCitations(outputText, executions),
 			},
 		})
 	}
@@ -3,6 +3,7 @@ package response
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
@@ -13,10 +14,13 @@ import (
 	"menlo.ai/indigo-api-gateway/app/domain/apikey"
 	"menlo.ai/indigo-api-gateway/app/domain/auth"
 	"menlo.ai/indigo-api-gateway/app/domain/common"
+	"menlo.ai/indigo-api-gateway/app/domain/contextwindow"
 	"menlo.ai/indigo-api-gateway/app/domain/conversation"
+	"menlo.ai/indigo-api-gateway/app/domain/file"
 	domainmodel "menlo.ai/indigo-api-gateway/app/domain/model"
 	"menlo.ai/indigo-api-gateway/app/domain/organization"
 	"menlo.ai/indigo-api-gateway/app/domain/user"
+	"menlo.ai/indigo-api-gateway/app/domain/vectorstore"
 	"menlo.ai/indigo-api-gateway/app/infrastructure/inference"
 	requesttypes "menlo.ai/indigo-api-gateway/app/interfaces/http/requests"
 	responsetypes "menlo.ai/indigo-api-gateway/app/interfaces/http/responses"
@@ -31,7 +35,9 @@ type ResponseCreationResult struct {
 	ChatCompletionRequest *openai.ChatCompletionRequest
 	APIKey                string
 	IsStreaming           bool
-	Provider              *domainmodel.Provider
+	// IsBackground is set when the response was queued for a background worker
+	IsBackground bool
+	Provider     *domainmodel.Provider
 }
 
 // ResponseModelService handles the business logic for response API endpoints
@@ -45,6 +51,13 @@ type ResponseModelService struct {
 	nonStreamModelService *NonStreamModelService
 	inferenceProvider     *inference.InferenceProvider
 	providerRegistry      *domainmodel.ProviderRegistryService
+	contextWindowService  *contextwindow.ContextWindowService
+	jobRepo               ResponseJobRepository
+	cancellations         *CancellationRegistry
+	toolExecutor          *ToolExecutor
+	fileService           *file.FileService
+	vectorStoreService    *vectorstore.VectorStoreService
+	streamBuffer          *StreamBuffer
 }
 
 // NewResponseModelService creates a new ResponseModelService instance
@@ -56,15 +69,29 @@ func NewResponseModelService(
 	responseService *ResponseService,
 	inferenceProvider *inference.InferenceProvider,
 	providerRegistry *domainmodel.ProviderRegistryService,
+	contextWindowService *contextwindow.ContextWindowService,
+	jobRepo ResponseJobRepository,
+	cancellations *CancellationRegistry,
+	toolExecutor *ToolExecutor,
+	fileService *file.FileService,
+	vectorStoreService *vectorstore.VectorStoreService,
+	streamBuffer *StreamBuffer,
 ) *ResponseModelService {
 	responseModelService := &ResponseModelService{
-		UserService:         userService,
-		authService:         authService,
-		apikeyService:       apikeyService,
-		conversationService: conversationService,
-		responseService:     responseService,
-		inferenceProvider:   inferenceProvider,
-		providerRegistry:    providerRegistry,
+		UserService:          userService,
+		authService:          authService,
+		apikeyService:        apikeyService,
+		conversationService:  conversationService,
+		responseService:      responseService,
+		inferenceProvider:    inferenceProvider,
+		providerRegistry:     providerRegistry,
+		contextWindowService: contextWindowService,
+		jobRepo:              jobRepo,
+		cancellations:        cancellations,
+		toolExecutor:         toolExecutor,
+		fileService:          fileService,
+		vectorStoreService:   vectorStoreService,
+		streamBuffer:         streamBuffer,
 	}
 
 	// Initialize specialized handlers
@@ -76,7 +103,7 @@ func NewResponseModelService(
 
 // CreateResponse handles the business logic for creating a response
 // Returns domain objects and business logic results, no HTTP concerns
-func (h *ResponseModelService) CreateResponse(ctx context.Context, userID uint, request *requesttypes.CreateResponseRequest) (*ResponseCreationResult, *common.Error) {
+func (h *ResponseModelService) CreateResponse(ctx context.Context, userID uint, projectID *uint, request *requesttypes.CreateResponseRequest) (*ResponseCreationResult, *common.Error) {
 	// Validate the request
 	success, err := ValidateCreateResponseRequest(request)
 	if !success {
@@ -92,8 +119,22 @@ func (h *ResponseModelService) CreateResponse(ctx context.Context, userID uint,
 		return nil, common.NewErrorWithMessage("Input validation error", "i9j0k1l2-m3n4-5678-ijkl-901234567890")
 	}
 
+	// Replace uploaded file references with their content
+	owner := file.Owner{UserID: userID, ProjectID: projectID}
+	if err := h.resolveFileInput(ctx, owner, request, chatCompletionRequest); err != nil {
+		return nil, err
+	}
+
+	// Reject file_search tools pointing at vector stores the caller cannot access
+	if scope := NewToolScope(userID, request.Tools); len(scope.VectorStoreIDs) > 0 {
+		if _, err := h.vectorStoreService.FindVectorStores(ctx, owner, scope.VectorStoreIDs); err != nil {
+			return nil, err
+		}
+	}
+
 	// Get provider based on the requested model
-	provider, providerErr := h.providerRegistry.GetProviderForModel(ctx, request.Model, organization.DEFAULT_ORGANIZATION.ID, nil)
+	organizationID := organization.DEFAULT_ORGANIZATION.ID
+	provider, providerErr := h.providerRegistry.GetProviderForModel(ctx, request.Model, organizationID, providerProjectIDs(projectID))
 	if providerErr != nil {
 		logger.GetLogger().Warnf("Failed to find provider for model '%s': %v, using default provider", request.Model, providerErr)
 	}
@@ -133,6 +174,9 @@ func (h *ResponseModelService) CreateResponse(ctx context.Context, userID uint,
 		return nil, err
 	}
 
+	// Only the new input is appended to the conversation, history is already stored
+	inputMessages := chatCompletionRequest.Messages
+
 	// If previous_response_id is provided, prepend conversation history to input messages
 	if request.PreviousResponseID != nil && *request.PreviousResponseID != "" {
 		conversationMessages, err := h.responseService.ConvertConversationItemsToMessages(ctx, conversation)
@@ -143,6 +187,25 @@ func (h *ResponseModelService) CreateResponse(ctx context.Context, userID uint,
 		chatCompletionRequest.Messages = append(conversationMessages, chatCompletionRequest.Messages...)
 	}
 
+	// Fit the prompt into the model context window
+	maxTokens := 0
+	if request.MaxTokens != nil {
+		maxTokens = *request.MaxTokens
+	}
+	fitResult, err := h.contextWindowService.FitMessages(ctx, contextwindow.FitInput{
+		OrganizationID: organizationID,
+		Provider:       provider,
+		Model:          request.Model,
+		Messages:       chatCompletionRequest.Messages,
+		MaxTokens:      maxTokens,
+		Settings:       convertContextManagement(request.ContextManagement),
+		Conversation:   conversation,
+	})
+	if err != nil {
+		return nil, err
+	}
+	chatCompletionRequest.Messages = fitResult.Messages
+
 	// Create response parameters
 	responseParams := &ResponseParams{
 		MaxTokens:         request.MaxTokens,
@@ -177,6 +240,11 @@ func (h *ResponseModelService) CreateResponse(ctx context.Context, userID uint,
 
 	// Build Response object from parameters
 	response := NewResponse(userID, conversationID, request.Model, string(inputJSON), request.SystemPrompt, responseParams)
+	response.ProjectID = projectID
+	isBackground := request.Background != nil && *request.Background
+	if isBackground {
+		response.Status = ResponseStatusQueued
+	}
 
 	responseEntity, err := h.responseService.CreateResponse(ctx, response)
 	if err != nil {
@@ -185,12 +253,18 @@ func (h *ResponseModelService) CreateResponse(ctx context.Context, userID uint,
 
 	// Append input messages to conversation (only if conversation exists)
 	if conversation != nil {
-		success, err := h.responseService.AppendMessagesToConversation(ctx, conversation, chatCompletionRequest.Messages, &responseEntity.ID)
+		success, err := h.responseService.AppendMessagesToConversation(ctx, conversation, inputMessages, &responseEntity.ID)
 		if !success {
 			return nil, err
 		}
 	}
 
+	if isBackground {
+		if err := h.enqueueBackgroundResponse(ctx, responseEntity, organizationID, request, chatCompletionRequest); err != nil {
+			return nil, err
+		}
+	}
+
 	// Return the result for the interface layer to handle
 	isStreaming := request.Stream != nil && *request.Stream
 	return &ResponseCreationResult{
@@ -199,10 +273,61 @@ func (h *ResponseModelService) CreateResponse(ctx context.Context, userID uint,
 		ChatCompletionRequest: chatCompletionRequest,
 		APIKey:                key,
 		IsStreaming:           isStreaming,
+		IsBackground:          isBackground,
 		Provider:              provider,
 	}, nil
 }
 
+// enqueueBackgroundResponse stores the upstream request in the durable queue processed by ResponseWorker
+func (h *ResponseModelService) enqueueBackgroundResponse(ctx context.Context, responseEntity *Response, organizationID uint, request *requesttypes.CreateResponseRequest, chatCompletionRequest *openai.ChatCompletionRequest) *common.Error {
+	payload, jsonErr := json.Marshal(ResponseJobPayload{
+		Request:               *request,
+		ChatCompletionRequest: *chatCompletionRequest,
+	})
+	if jsonErr != nil {
+		return common.NewError(jsonErr, "6a8c0e2f-4b6d-4a8c-8e2f-4b6d8a0c2e4f")
+	}
+	if err := h.jobRepo.Enqueue(ctx, &ResponseJob{
+		ResponseID:     responseEntity.ID,
+		OrganizationID: organizationID,
+		ProjectID:      responseEntity.ProjectID,
+		Payload:        string(payload),
+		Status:         ResponseJobStatusQueued,
+	}); err != nil {
+		h.responseService.UpdateResponseError(ctx, responseEntity.ID, responsetypes.ResponseError{
+			Code:    "0c2e4a6b-8d0f-4c2e-a6b8-d0f2c4e6a8b0",
+			Message: "failed to queue the response",
+		})
+		h.responseService.PublishResponseEvent(ctx, responseEntity.ID)
+		return common.NewError(err, "0c2e4a6b-8d0f-4c2e-a6b8-d0f2c4e6a8b0")
+	}
+	return nil
+}
+
+// providerProjectIDs returns the projects whose providers serve a request authenticated for projectID
+func providerProjectIDs(projectID *uint) []uint {
+	if projectID == nil {
+		return nil
+	}
+	return []uint{*projectID}
+}
+
+// convertContextManagement maps the request context settings onto the conversation domain
+func convertContextManagement(input *requesttypes.ContextManagement) *conversation.ContextSettings {
+	if input == nil {
+		return nil
+	}
+	settings := &conversation.ContextSettings{
+		KeepLastN:    input.KeepLastN,
+		SummaryModel: input.SummaryModel,
+	}
+	if input.Strategy != nil {
+		strategy := conversation.ContextStrategy(*input.Strategy)
+		settings.Strategy = &strategy
+	}
+	return settings
+}
+
 // handleConversation handles conversation creation or loading based on the request
 
 // GetResponse handles the business logic for getting a response
@@ -214,6 +339,12 @@ func (h *ResponseModelService) GetResponseHandler(reqCtx *gin.Context) {
 		return
 	}
 
+	// stream=true replays the events of a streamed response and follows it until it ends
+	if reqCtx.Query("stream") == "true" {
+		h.streamModelService.ResumeStreamResponse(reqCtx, responseEntity)
+		return
+	}
+
 	result, err := h.GetResponse(responseEntity)
 	if err != nil {
 		h.sendErrorResponse(reqCtx, http.StatusBadRequest, err.GetCode(), err.Error())
@@ -278,7 +409,7 @@ func (h *ResponseModelService) CancelResponseHandler(reqCtx *gin.Context) {
 		return
 	}
 
-	result, err := h.CancelResponse(responseEntity)
+	result, err := h.CancelResponse(reqCtx.Request.Context(), responseEntity)
 	if err != nil {
 		h.sendErrorResponse(reqCtx, http.StatusBadRequest, err.GetCode(), err.Error())
 		return
@@ -287,20 +418,30 @@ func (h *ResponseModelService) CancelResponseHandler(reqCtx *gin.Context) {
 	h.sendSuccessResponse(reqCtx, result)
 }
 
-// doCancelResponse performs the business logic for cancelling a response
-func (h *ResponseModelService) CancelResponse(responseEntity *Response) (responsetypes.Response, *common.Error) {
-	// TODO: Implement actual cancellation logic
-	// For now, return the response with cancelled status
-	mockResponse := responsetypes.Response{
-		ID:          responseEntity.PublicID,
-		Object:      "response",
-		Created:     responseEntity.CreatedAt.Unix(),
-		Model:       responseEntity.Model,
-		Status:      responsetypes.ResponseStatusCancelled,
-		CancelledAt: ptr.ToInt64(time.Now().Unix()),
+// CancelResponse interrupts the upstream call of a response and marks it cancelled.
+// Calls running in this process are interrupted right away, background jobs running
+// on another replica notice the cancellation on their next heartbeat.
+func (h *ResponseModelService) CancelResponse(ctx context.Context, responseEntity *Response) (responsetypes.Response, *common.Error) {
+	if responseEntity.Status == ResponseStatusCancelled {
+		return h.responseService.ConvertDomainResponseToAPIResponse(responseEntity), nil
+	}
+	if responseEntity.Status.IsTerminal() {
+		return responsetypes.Response{}, common.NewErrorWithMessage("response has already finished", "e2a4c6d8-0f2b-4e4a-8c6d-0f2b4e6a8c0d")
 	}
 
-	return mockResponse, nil
+	h.cancellations.Cancel(responseEntity.PublicID)
+	if responseEntity.Background != nil && *responseEntity.Background {
+		if err := h.jobRepo.RequestCancel(ctx, responseEntity.ID); err != nil {
+			return responsetypes.Response{}, common.NewError(err, "a4c6e8f0-2b4d-4a6c-8e0f-2b4d6a8c0e2f")
+		}
+	}
+	if success, err := h.responseService.UpdateResponseStatus(ctx, responseEntity.ID, ResponseStatusCancelled); !success {
+		return responsetypes.Response{}, err
+	}
+
+	UpdateResponseStatusOnObject(responseEntity, ResponseStatusCancelled)
+	h.responseService.PublishResponseEvent(ctx, responseEntity.ID)
+	return h.responseService.ConvertDomainResponseToAPIResponse(responseEntity), nil
 }
 
 // ListInputItems handles the business logic for listing input items
@@ -419,3 +560,71 @@ func (h *ResponseModelService) sendErrorResponse(reqCtx *gin.Context, statusCode
 func (h *ResponseModelService) sendSuccessResponse(reqCtx *gin.Context, data any) {
 	reqCtx.JSON(http.StatusOK, data.(responsetypes.Response))
 }
+
+// resolveFileInput replaces a structured image or file input referencing an uploaded file with the file content
+func (h *ResponseModelService) resolveFileInput(ctx context.Context, owner file.Owner, request *requesttypes.CreateResponseRequest, chatRequest *openai.ChatCompletionRequest) *common.Error {
+	inputMap, ok := request.Input.(map[string]any)
+	if !ok || len(chatRequest.Messages) == 0 {
+		return nil
+	}
+	structuredInput := convertToCreateResponseInput(inputMap)
+	if structuredInput == nil {
+		return nil
+	}
+
+	var fileID, detail string
+	switch {
+	case structuredInput.Image != nil && structuredInput.Image.FileID != nil:
+		fileID = *structuredInput.Image.FileID
+		if structuredInput.Image.Detail != nil {
+			detail = *structuredInput.Image.Detail
+		}
+	case structuredInput.File != nil:
+		fileID = structuredInput.File.FileID
+	case structuredInput.FileSearch != nil:
+		return h.resolveFileSearchInput(ctx, owner, structuredInput.FileSearch, chatRequest)
+	default:
+		return nil
+	}
+
+	part, err := h.fileService.ResolveMessagePart(ctx, owner, fileID, detail)
+	if err != nil {
+		return err
+	}
+	// The structured input is always converted to the last message
+	chatRequest.Messages[len(chatRequest.Messages)-1] = openai.ChatCompletionMessage{
+		Role:         openai.ChatMessageRoleUser,
+		MultiContent: []openai.ChatMessagePart{*part},
+	}
+	return nil
+}
+
+// resolveFileSearchInput replaces a structured file_search input with the query and the passages retrieved from the files
+func (h *ResponseModelService) resolveFileSearchInput(ctx context.Context, owner file.Owner, input *requesttypes.FileSearchInput, chatRequest *openai.ChatCompletionRequest) *common.Error {
+	limit := vectorstore.DefaultSearchResults
+	if input.MaxResults != nil {
+		limit = *input.MaxResults
+	}
+	results, err := h.vectorStoreService.SearchFiles(ctx, owner, input.Query, input.FileIDs, limit)
+	if err != nil {
+		return err
+	}
+
+	var content strings.Builder
+	if len(results) == 0 {
+		content.WriteString("No matching content was found in the files.\n\n")
+	} else {
+		content.WriteString("Answer using the following passages from the files. Cite the passages you use with their number in square brackets, for example [1].\n\n")
+		for i, result := range results {
+			fmt.Fprintf(&content, "[%d] %s\n%s\n\n", i+1, result.Filename, result.Text)
+		}
+	}
+	content.WriteString(input.Query)
+
+	// The structured input is always converted to the last message
+	chatRequest.Messages[len(chatRequest.Messages)-1] = openai.ChatCompletionMessage{
+		Role:    openai.ChatMessageRoleUser,
+		Content: content.String(),
+	}
+	return nil
+}
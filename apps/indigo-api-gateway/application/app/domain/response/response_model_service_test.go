@@ -0,0 +1,115 @@
+package response
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	openai "github.com/sashabaranov/go-openai"
+	"menlo.ai/indigo-api-gateway/app/domain/file"
+	"menlo.ai/indigo-api-gateway/app/domain/query"
+	"menlo.ai/indigo-api-gateway/app/infrastructure/storage"
+	requesttypes "menlo.ai/indigo-api-gateway/app/interfaces/http/requests"
+)
+
+// memoryFileRepository keeps files in memory, the database repositories cannot be imported from this package
+type memoryFileRepository struct {
+	files []*file.File
+}
+
+func (r *memoryFileRepository) Create(ctx context.Context, f *file.File) error {
+	f.ID = uint(len(r.files) + 1)
+	r.files = append(r.files, f)
+	return nil
+}
+
+func (r *memoryFileRepository) FindByFilter(ctx context.Context, filter file.FileFilter, pagination *query.Pagination) ([]*file.File, error) {
+	var result []*file.File
+	for _, f := range r.files {
+		if filter.PublicID != nil && f.PublicID != *filter.PublicID {
+			continue
+		}
+		if filter.UserID != nil && f.UserID != *filter.UserID {
+			continue
+		}
+		result = append(result, f)
+	}
+	return result, nil
+}
+
+func (r *memoryFileRepository) Count(ctx context.Context, filter file.FileFilter) (int64, error) {
+	files, _ := r.FindByFilter(ctx, filter, nil)
+	return int64(len(files)), nil
+}
+
+func (r *memoryFileRepository) DeleteByID(ctx context.Context, id uint) error {
+	return nil
+}
+
+func TestResolveFileInput(t *testing.T) {
+	ctx := context.Background()
+	fileService := file.NewFileService(&memoryFileRepository{}, storage.NewLocalStorage(t.TempDir()))
+	service := &ResponseModelService{fileService: fileService}
+	owner := file.Owner{UserID: 1}
+	upload := func(filename string, purpose file.FilePurpose, mimeType string, content []byte) string {
+		uploaded, err := fileService.Upload(ctx, owner, file.UploadInput{
+			Filename: filename,
+			Purpose:  purpose,
+			MimeType: mimeType,
+			Size:     int64(len(content)),
+			Body:     bytes.NewReader(content),
+		})
+		if err != nil {
+			t.Fatalf("upload %s: %v", filename, err)
+		}
+		return uploaded.PublicID
+	}
+	imageID := upload("chart.png", file.FilePurposeVision, "image/png", []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"))
+	notesID := upload("notes.txt", file.FilePurposeAssistants, "text/plain", []byte("ship it"))
+
+	resolve := func(owner file.Owner, input map[string]any) (*openai.ChatCompletionRequest, error) {
+		chatRequest := &openai.ChatCompletionRequest{Messages: []openai.ChatCompletionMessage{
+			{Role: openai.ChatMessageRoleSystem, Content: "be brief"},
+			{Role: openai.ChatMessageRoleUser, Content: "placeholder"},
+		}}
+		if err := service.resolveFileInput(ctx, owner, &requesttypes.CreateResponseRequest{Input: input}, chatRequest); err != nil {
+			return nil, err
+		}
+		return chatRequest, nil
+	}
+
+	chatRequest, err := resolve(owner, map[string]any{"type": "image", "image": map[string]any{"file_id": imageID, "detail": "low"}})
+	if err != nil {
+		t.Fatalf("resolve image: %v", err)
+	}
+	last := chatRequest.Messages[len(chatRequest.Messages)-1]
+	if last.Role != openai.ChatMessageRoleUser || last.Content != "" || len(last.MultiContent) != 1 ||
+		last.MultiContent[0].Type != openai.ChatMessagePartTypeImageURL || last.MultiContent[0].ImageURL.Detail != openai.ImageURLDetailLow {
+		t.Fatalf("expected the image as the last user message, got %+v", last)
+	}
+	if chatRequest.Messages[0].Content != "be brief" {
+		t.Fatalf("expected the earlier messages to be kept, got %+v", chatRequest.Messages[0])
+	}
+
+	chatRequest, err = resolve(owner, map[string]any{"type": "file", "file": map[string]any{"file_id": notesID}})
+	if err != nil {
+		t.Fatalf("resolve file: %v", err)
+	}
+	last = chatRequest.Messages[len(chatRequest.Messages)-1]
+	if len(last.MultiContent) != 1 || last.MultiContent[0].Type != openai.ChatMessagePartTypeText || last.MultiContent[0].Text != "File: notes.txt\n\nship it" {
+		t.Fatalf("expected the document as the last user message, got %+v", last)
+	}
+
+	if _, err := resolve(file.Owner{UserID: 2}, map[string]any{"type": "file", "file": map[string]any{"file_id": notesID}}); err == nil {
+		t.Fatalf("expected the file of another user to be refused")
+	}
+	if _, err := resolve(owner, map[string]any{"type": "file", "file": map[string]any{"file_id": "file_missing"}}); err == nil {
+		t.Fatalf("expected an unknown file to be refused")
+	}
+
+	// inputs without a file reference are left to the chat conversion
+	chatRequest, err = resolve(owner, map[string]any{"type": "text", "text": "hello"})
+	if err != nil || chatRequest.Messages[1].Content != "placeholder" {
+		t.Fatalf("expected a text input to be left alone, got %+v, %v", chatRequest, err)
+	}
+}
@@ -5,7 +5,9 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -15,6 +17,7 @@ import (
 	"menlo.ai/indigo-api-gateway/app/domain/common"
 	"menlo.ai/indigo-api-gateway/app/domain/conversation"
 	domainmodel "menlo.ai/indigo-api-gateway/app/domain/model"
+	"menlo.ai/indigo-api-gateway/app/domain/organization"
 	requesttypes "menlo.ai/indigo-api-gateway/app/interfaces/http/requests"
 	responsetypes "menlo.ai/indigo-api-gateway/app/interfaces/http/responses"
 	"menlo.ai/indigo-api-gateway/app/utils/idgen"
@@ -113,12 +116,21 @@ func (h *StreamModelService) CreateStreamResponse(reqCtx *gin.Context, request *
 		return
 	}
 
-	// Add timeout context
-	ctx, cancel := context.WithTimeout(reqCtx.Request.Context(), RequestTimeout)
+	// The generation is detached from the client connection: a client that drops the stream
+	// resumes it with GET /v1/responses/{id}?stream=true while the events keep being buffered
+	clientCtx := reqCtx.Request.Context()
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(clientCtx), RequestTimeout)
 	defer cancel()
+	ctx, release := h.cancellations.Track(ctx, responseEntity.PublicID)
+	defer release()
 
 	// Use ctx for long-running operations
 	reqCtx.Request = reqCtx.Request.WithContext(ctx)
+	stream := &sseStream{
+		reqCtx:    reqCtx,
+		clientCtx: clientCtx,
+		recorder:  h.streamBuffer.NewRecorder(responseEntity.PublicID),
+	}
 
 	// Set up streaming headers (matching completion API format)
 	reqCtx.Header("Content-Type", "text/event-stream")
@@ -166,7 +178,7 @@ func (h *StreamModelService) CreateStreamResponse(reqCtx *gin.Context, request *
 	}
 
 	// Emit response.created event
-	h.emitStreamEvent(reqCtx, "response.created", responsetypes.ResponseCreatedEvent{
+	h.emitStreamEvent(stream, "response.created", responsetypes.ResponseCreatedEvent{
 		BaseStreamingEvent: responsetypes.BaseStreamingEvent{
 			Type:           "response.created",
 			SequenceNumber: 0,
@@ -178,11 +190,13 @@ func (h *StreamModelService) CreateStreamResponse(reqCtx *gin.Context, request *
 	// No need to add them again here to avoid duplication
 
 	// Process with chat completion client for streaming
-	streamErr := h.processStreamingResponse(reqCtx, provider, *chatCompletionRequest, responseID, conv)
+	scope := NewToolScope(responseEntity.UserID, request.Tools)
+	var outcome streamOutcome
+	streamErr := h.processStreamingResponse(reqCtx, stream, provider, *chatCompletionRequest, responseID, conv, scope, &outcome)
 	if streamErr != nil {
 		// Check if context was cancelled (timeout)
 		if reqCtx.Request.Context().Err() == context.DeadlineExceeded {
-			h.emitStreamEvent(reqCtx, "response.error", responsetypes.ResponseErrorEvent{
+			h.emitStreamEvent(stream, "response.error", responsetypes.ResponseErrorEvent{
 				Event:      "response.error",
 				Created:    time.Now().Unix(),
 				ResponseID: responseID,
@@ -191,7 +205,7 @@ func (h *StreamModelService) CreateStreamResponse(reqCtx *gin.Context, request *
 				},
 			})
 		} else if reqCtx.Request.Context().Err() == context.Canceled {
-			h.emitStreamEvent(reqCtx, "response.error", responsetypes.ResponseErrorEvent{
+			h.emitStreamEvent(stream, "response.error", responsetypes.ResponseErrorEvent{
 				Event:      "response.error",
 				Created:    time.Now().Unix(),
 				ResponseID: responseID,
@@ -200,7 +214,7 @@ func (h *StreamModelService) CreateStreamResponse(reqCtx *gin.Context, request *
 				},
 			})
 		} else {
-			h.emitStreamEvent(reqCtx, "response.error", responsetypes.ResponseErrorEvent{
+			h.emitStreamEvent(stream, "response.error", responsetypes.ResponseErrorEvent{
 				Event:      "response.error",
 				Created:    time.Now().Unix(),
 				ResponseID: responseID,
@@ -209,22 +223,112 @@ func (h *StreamModelService) CreateStreamResponse(reqCtx *gin.Context, request *
 				},
 			})
 		}
+		stream.finish("")
 		return
 	}
 
 	// Emit response.completed event
 	response.Status = responsetypes.ResponseStatusCompleted
-	h.emitStreamEvent(reqCtx, "response.completed", responsetypes.ResponseCompletedEvent{
+	if outcome.incompleteDetails != nil {
+		response.Status = responsetypes.ResponseStatusIncomplete
+		response.IncompleteDetails = outcome.incompleteDetails
+	}
+	h.emitStreamEvent(stream, "response.completed", responsetypes.ResponseCompletedEvent{
 		BaseStreamingEvent: responsetypes.BaseStreamingEvent{
 			Type:           "response.completed",
-			SequenceNumber: 9999, // High number to indicate completion
+			SequenceNumber: outcome.sequenceNumber,
 		},
 		Response: response,
 	})
+
+	// Send [DONE] to close the stream
+	stream.finish(fmt.Sprintf(SSEDataFormat, DoneMarker))
+}
+
+// sseStream writes the events of a response to its client and buffers them for resumption
+type sseStream struct {
+	reqCtx *gin.Context
+	// clientCtx ends when the client disconnects, the generation goes on
+	clientCtx context.Context
+	recorder  *StreamRecorder
+	detached  bool
+}
+
+// write buffers an SSE event and forwards it to the client while it is connected
+func (s *sseStream) write(event string) {
+	s.send(s.recorder.Record(s.reqCtx.Request.Context(), event))
+}
+
+// finish buffers the last data of the stream and forwards it to the client
+func (s *sseStream) finish(event string) {
+	s.send(s.recorder.Finish(s.reqCtx.Request.Context(), event))
+}
+
+func (s *sseStream) send(event string) {
+	if s.detached || event == "" {
+		return
+	}
+	if s.clientCtx.Err() != nil {
+		s.detached = true
+		return
+	}
+	if _, err := s.reqCtx.Writer.Write([]byte(event)); err != nil {
+		logger.GetLogger().Warnf("client of a streamed response disconnected, the events stay buffered: %v", err)
+		s.detached = true
+		return
+	}
+	s.reqCtx.Writer.Flush()
+}
+
+// ResumeStreamResponse replays the buffered events of a streamed response numbered after the
+// starting_after query parameter or the Last-Event-ID header, then continues with the live events
+func (h *StreamModelService) ResumeStreamResponse(reqCtx *gin.Context, responseEntity *Response) {
+	if responseEntity.Stream == nil || !*responseEntity.Stream {
+		h.sendErrorResponse(reqCtx, http.StatusBadRequest, "6b2e9f4a-8d1c-4a7e-b3f5-9c4a2e6d8b17", "only responses created with stream: true can be streamed")
+		return
+	}
+	startingAfter := -1
+	cursor := reqCtx.Query("starting_after")
+	if cursor == "" {
+		cursor = reqCtx.GetHeader("Last-Event-ID")
+	}
+	if cursor != "" {
+		parsed, err := strconv.Atoi(cursor)
+		if err != nil || parsed < 0 {
+			h.sendErrorResponse(reqCtx, http.StatusBadRequest, "6b2e9f4a-8d1c-4a7e-b3f5-9c4a2e6d8b18", "starting_after must be a sequence number")
+			return
+		}
+		startingAfter = parsed
+	}
+
+	ctx := reqCtx.Request.Context()
+	started := false
+	write := func(event string) error {
+		if !started {
+			started = true
+			reqCtx.Header("Content-Type", "text/event-stream")
+			reqCtx.Header("Cache-Control", "no-cache")
+			reqCtx.Header("Connection", "keep-alive")
+			reqCtx.Header("Access-Control-Allow-Origin", "*")
+			reqCtx.Header("Access-Control-Allow-Headers", "Cache-Control")
+		}
+		if _, err := reqCtx.Writer.Write([]byte(event)); err != nil {
+			return err
+		}
+		reqCtx.Writer.Flush()
+		return nil
+	}
+	isFinished := func() bool {
+		current, err := h.responseService.GetResponseByPublicID(ctx, responseEntity.PublicID)
+		return err != nil || current == nil || current.Status.IsTerminal()
+	}
+	if err := h.streamBuffer.Replay(ctx, responseEntity.PublicID, startingAfter, write, isFinished); err != nil && !started {
+		h.sendErrorResponse(reqCtx, http.StatusNotFound, err.GetCode(), err.GetMessage())
+	}
 }
 
 // emitStreamEvent emits a streaming event (matching completion API SSE format)
-func (h *StreamModelService) emitStreamEvent(reqCtx *gin.Context, eventType string, data any) {
+func (h *StreamModelService) emitStreamEvent(stream *sseStream, eventType string, data any) {
 	// Marshal the data directly without wrapping
 	eventJSON, err := json.Marshal(data)
 	if err != nil {
@@ -233,12 +337,19 @@ func (h *StreamModelService) emitStreamEvent(reqCtx *gin.Context, eventType stri
 	}
 
 	// Use proper SSE format
-	reqCtx.Writer.Write([]byte(fmt.Sprintf(SSEEventFormat, eventType, string(eventJSON))))
-	reqCtx.Writer.Flush()
+	stream.write(fmt.Sprintf(SSEEventFormat, eventType, string(eventJSON)))
+}
+
+// streamOutcome carries what the streaming goroutine learned about the final answer
+type streamOutcome struct {
+	// incompleteDetails is set when the answer does not match the requested JSON format
+	incompleteDetails *responsetypes.IncompleteDetails
+	// sequenceNumber is the number of the next event
+	sequenceNumber int
 }
 
 // processStreamingResponse processes the streaming response using two channels
-func (h *StreamModelService) processStreamingResponse(reqCtx *gin.Context, provider *domainmodel.Provider, request openai.ChatCompletionRequest, responseID string, conv *conversation.Conversation) error {
+func (h *StreamModelService) processStreamingResponse(reqCtx *gin.Context, stream *sseStream, provider *domainmodel.Provider, request openai.ChatCompletionRequest, responseID string, conv *conversation.Conversation, scope *ToolScope, outcome *streamOutcome) error {
 	// Create buffered channels for data and errors
 	dataChan := make(chan string, ChannelBufferSize)
 	errChan := make(chan error, ErrorBufferSize)
@@ -247,7 +358,7 @@ func (h *StreamModelService) processStreamingResponse(reqCtx *gin.Context, provi
 	wg.Add(1)
 
 	// Start streaming in a goroutine
-	go h.streamResponseToChannel(reqCtx, provider, request, dataChan, errChan, responseID, conv, &wg)
+	go h.streamResponseToChannel(reqCtx, provider, request, dataChan, errChan, responseID, conv, scope, outcome, &wg)
 
 	// Wait for streaming to complete and close channels
 	go func() {
@@ -263,16 +374,8 @@ func (h *StreamModelService) processStreamingResponse(reqCtx *gin.Context, provi
 			if !ok {
 				return nil
 			}
-			_, err := reqCtx.Writer.Write([]byte(line))
-			if err != nil {
-				reqCtx.AbortWithStatusJSON(
-					http.StatusBadRequest,
-					responsetypes.ErrorResponse{
-						Code: "bc82d69c-685b-4556-9d1f-2a4a80ae8ca4",
-					})
-				return err
-			}
-			reqCtx.Writer.Flush()
+			// events keep being buffered after the client disconnected
+			stream.write(line)
 		case err := <-errChan:
 			if err != nil {
 				reqCtx.AbortWithStatusJSON(
@@ -290,8 +393,9 @@ func (h *StreamModelService) processStreamingResponse(reqCtx *gin.Context, provi
 type OpenAIStreamData struct {
 	Choices []struct {
 		Delta struct {
-			Content          string `json:"content"`
-			ReasoningContent string `json:"reasoning_content"`
+			Content          string            `json:"content"`
+			ReasoningContent string            `json:"reasoning_content"`
+			ToolCalls        []openai.ToolCall `json:"tool_calls"`
 		} `json:"delta"`
 	} `json:"choices"`
 }
@@ -371,8 +475,152 @@ func (h *StreamModelService) parseOpenAIStreamReasoningData(jsonStr string) stri
 	return data.Choices[0].Delta.ReasoningContent
 }
 
+// extractToolCallsFromOpenAIStream extracts tool call fragments from OpenAI streaming data
+func (h *StreamModelService) extractToolCallsFromOpenAIStream(jsonStr string) []openai.ToolCall {
+	var data OpenAIStreamData
+	if err := json.Unmarshal([]byte(jsonStr), &data); err != nil {
+		return nil
+	}
+
+	// Check if choices array is empty to prevent panic
+	if len(data.Choices) == 0 {
+		return nil
+	}
+
+	return data.Choices[0].Delta.ToolCalls
+}
+
+// toolCallAccumulator assembles streamed tool call fragments into complete calls
+type toolCallAccumulator struct {
+	calls []openai.ToolCall
+}
+
+// add merges fragments into the calls they belong to, using the fragment index when the upstream sends one
+func (a *toolCallAccumulator) add(fragments []openai.ToolCall) {
+	for _, fragment := range fragments {
+		index := len(a.calls) - 1
+		if fragment.Index != nil {
+			index = *fragment.Index
+		} else if fragment.ID != "" || index < 0 {
+			index = len(a.calls)
+		}
+		for len(a.calls) <= index {
+			a.calls = append(a.calls, openai.ToolCall{Type: openai.ToolTypeFunction})
+		}
+
+		call := &a.calls[index]
+		if fragment.ID != "" {
+			call.ID = fragment.ID
+		}
+		if fragment.Function.Name != "" {
+			call.Function.Name = fragment.Function.Name
+		}
+		call.Function.Arguments += fragment.Function.Arguments
+	}
+}
+
+// toolContext carries the organization of the request over to the tools, gin does not read the values of the request context
+func toolContext(reqCtx *gin.Context) context.Context {
+	if organizationID, ok := organization.OrganizationIDFromContext(reqCtx.Request.Context()); ok {
+		return organization.WithOrganizationID(reqCtx, organizationID)
+	}
+	return reqCtx
+}
+
+// closeStreamReader closes an upstream stream, logging failures
+func (h *StreamModelService) closeStreamReader(reader io.Closer) {
+	if closeErr := reader.Close(); closeErr != nil {
+		logger.GetLogger().Warnf("failed to close streaming reader: %v", closeErr)
+	}
+}
+
+// lookupResponseID resolves the internal ID of a response for linking conversation items
+func (h *StreamModelService) lookupResponseID(ctx context.Context, responseID string) *uint {
+	responseEntity, err := h.responseService.GetResponseByPublicID(ctx, responseID)
+	if err != nil || responseEntity == nil {
+		return nil
+	}
+	return &responseEntity.ID
+}
+
+// executeStreamToolCall runs a built-in tool and emits its web_search_call, fetch_webpage_call or file_search_call
+// item and progress events
+func (h *StreamModelService) executeStreamToolCall(ctx context.Context, dataChan chan<- string, call openai.ToolCall, scope *ToolScope, outputIndex int, sequenceNumber *int) *ToolExecution {
+	itemType, itemPrefix, workingStage := "web_search_call", "ws", "searching"
+	switch call.Function.Name {
+	case BuiltinToolFileSearch:
+		itemType, itemPrefix = "file_search_call", "fs"
+	case BuiltinToolFetchWebpage:
+		itemType, itemPrefix, workingStage = "fetch_webpage_call", "fw", "fetching"
+	}
+	itemID, _ := idgen.GenerateSecureID(itemPrefix, 42)
+
+	emit := func(eventType string, data any) {
+		h.marshalAndSendEvent(dataChan, eventType, data)
+		*sequenceNumber++
+	}
+	progress := func(stage string) {
+		eventType := fmt.Sprintf("response.%s.%s", itemType, stage)
+		emit(eventType, responsetypes.ResponseWebSearchCallEvent{
+			BaseStreamingEvent: responsetypes.BaseStreamingEvent{
+				Type:           eventType,
+				SequenceNumber: *sequenceNumber,
+			},
+			OutputIndex: outputIndex,
+			ItemID:      itemID,
+		})
+	}
+
+	emit("response.output_item.added", responsetypes.ResponseOutputItemAddedEvent{
+		BaseStreamingEvent: responsetypes.BaseStreamingEvent{
+			Type:           "response.output_item.added",
+			SequenceNumber: *sequenceNumber,
+		},
+		OutputIndex: outputIndex,
+		Item: responsetypes.ResponseOutputItem{
+			ID:     itemID,
+			Type:   itemType,
+			Status: string(conversation.ItemStatusInProgress),
+		},
+	})
+	progress("in_progress")
+	progress(workingStage)
+
+	execution := h.toolExecutor.Execute(ctx, call, scope)
+
+	progress("completed")
+
+	item := responsetypes.ResponseOutputItem{
+		ID:     itemID,
+		Type:   itemType,
+		Status: string(conversation.ItemStatusCompleted),
+	}
+	switch execution.Name {
+	case BuiltinToolFileSearch:
+		item.Queries = []string{execution.Query}
+		item.Results = fileSearchResults(execution)
+	case BuiltinToolFetchWebpage:
+		item.Action = &responsetypes.WebSearchAction{Type: "open_page", URL: execution.URL}
+	default:
+		item.Action = &responsetypes.WebSearchAction{Type: "search", Query: execution.Query}
+	}
+	if execution.Err != nil {
+		item.Status = string(conversation.ItemStatusFailed)
+	}
+	emit("response.output_item.done", responsetypes.ResponseOutputItemDoneEvent{
+		BaseStreamingEvent: responsetypes.BaseStreamingEvent{
+			Type:           "response.output_item.done",
+			SequenceNumber: *sequenceNumber,
+		},
+		OutputIndex: outputIndex,
+		Item:        item,
+	})
+
+	return execution
+}
+
 // streamResponseToChannel handles the streaming response and sends data/errors to channels
-func (h *StreamModelService) streamResponseToChannel(reqCtx *gin.Context, provider *domainmodel.Provider, request openai.ChatCompletionRequest, dataChan chan<- string, errChan chan<- error, responseID string, conv *conversation.Conversation, wg *sync.WaitGroup) {
+func (h *StreamModelService) streamResponseToChannel(reqCtx *gin.Context, provider *domainmodel.Provider, request openai.ChatCompletionRequest, dataChan chan<- string, errChan chan<- error, responseID string, conv *conversation.Conversation, scope *ToolScope, outcome *streamOutcome, wg *sync.WaitGroup) {
 	defer wg.Done()
 
 	startTime := time.Now()
@@ -442,44 +690,55 @@ func (h *StreamModelService) streamResponseToChannel(reqCtx *gin.Context, provid
 		return
 	}
 
-	reader, err := chatClient.CreateChatCompletionStream(reqCtx.Request.Context(), "", request)
-	if err != nil {
-		errChan <- err
-		return
-	}
-	defer func() {
-		if closeErr := reader.Close(); closeErr != nil {
-			logger.GetLogger().Warnf("failed to close streaming reader: %v", closeErr)
-		}
-	}()
-
 	// Buffer for accumulating content chunks
 	var contentBuffer strings.Builder
 	var fullResponse strings.Builder
+	// passResponse holds the content of the current upstream pass, the last one is the final answer
+	var passResponse strings.Builder
 
 	// Buffer for accumulating reasoning content chunks
 	var reasoningBuffer strings.Builder
 	var fullReasoningResponse strings.Builder
 	var reasoningItemID string
-	var reasoningSequenceNumber int
 	var hasReasoningContent bool
 	var reasoningComplete bool
 
-	// Process the stream line by line
-	scanner := bufio.NewScanner(reader)
-	for scanner.Scan() {
-		// Check if context was cancelled
-		if h.checkContextCancellation(reqCtx, errChan) {
+	// Output index 0 is the message item, tool call items follow it
+	toolOutputIndex := 1
+	var executions []*ToolExecution
+	// finalCalls are the tool calls of the last pass, left for the client to run
+	var finalCalls []openai.ToolCall
+
+	// Each pass streams one upstream completion; built-in tool calls trigger another pass with their results
+	for step := 0; ; step++ {
+		reader, err := chatClient.CreateChatCompletionStream(reqCtx.Request.Context(), "", request)
+		if err != nil {
+			errChan <- err
 			return
 		}
+		passResponse.Reset()
+		var toolCalls toolCallAccumulator
+
+		// Process the stream line by line
+		scanner := bufio.NewScanner(reader)
+		for scanner.Scan() {
+			// Check if context was cancelled
+			if h.checkContextCancellation(reqCtx, errChan) {
+				h.closeStreamReader(reader)
+				return
+			}
 
-		line := scanner.Text()
-		if strings.HasPrefix(line, DataPrefix) {
+			line := scanner.Text()
+			if !strings.HasPrefix(line, DataPrefix) {
+				continue
+			}
 			data := strings.TrimPrefix(line, DataPrefix)
 			if data == DoneMarker {
 				break
 			}
 
+			toolCalls.add(h.extractToolCallsFromOpenAIStream(data))
+
 			// Extract content from OpenAI streaming format
 			content := h.extractContentFromOpenAIStream(data)
 
@@ -487,6 +746,7 @@ func (h *StreamModelService) streamResponseToChannel(reqCtx *gin.Context, provid
 			if content != "" {
 				contentBuffer.WriteString(content)
 				fullResponse.WriteString(content)
+				passResponse.WriteString(content)
 
 				// Only send content if reasoning is complete or there's no reasoning content
 				if reasoningComplete || !hasReasoningContent {
@@ -511,14 +771,13 @@ func (h *StreamModelService) streamResponseToChannel(reqCtx *gin.Context, provid
 				// Initialize reasoning item if not already done
 				if !hasReasoningContent {
 					reasoningItemID = fmt.Sprintf("rs_%d", time.Now().UnixNano())
-					reasoningSequenceNumber = sequenceNumber
 					hasReasoningContent = true
 
 					// Emit response.output_item.added event for reasoning
 					reasoningItemAddedEvent := responsetypes.ResponseOutputItemAddedEvent{
 						BaseStreamingEvent: responsetypes.BaseStreamingEvent{
 							Type:           "response.output_item.added",
-							SequenceNumber: reasoningSequenceNumber,
+							SequenceNumber: sequenceNumber,
 						},
 						OutputIndex: 0,
 						Item: responsetypes.ResponseOutputItem{
@@ -531,13 +790,13 @@ func (h *StreamModelService) streamResponseToChannel(reqCtx *gin.Context, provid
 					}
 					eventJSON, _ := json.Marshal(reasoningItemAddedEvent)
 					dataChan <- fmt.Sprintf("event: response.output_item.added\ndata: %s\n\n", string(eventJSON))
-					reasoningSequenceNumber++
+					sequenceNumber++
 
 					// Emit response.reasoning_summary_part.added event
 					reasoningSummaryPartAddedEvent := responsetypes.ResponseReasoningSummaryPartAddedEvent{
 						BaseStreamingEvent: responsetypes.BaseStreamingEvent{
 							Type:           "response.reasoning_summary_part.added",
-							SequenceNumber: reasoningSequenceNumber,
+							SequenceNumber: sequenceNumber,
 						},
 						ItemID:       reasoningItemID,
 						OutputIndex:  0,
@@ -552,7 +811,7 @@ func (h *StreamModelService) streamResponseToChannel(reqCtx *gin.Context, provid
 					}
 					eventJSON, _ = json.Marshal(reasoningSummaryPartAddedEvent)
 					dataChan <- fmt.Sprintf("event: response.reasoning_summary_part.added\ndata: %s\n\n", string(eventJSON))
-					reasoningSequenceNumber++
+					sequenceNumber++
 				}
 
 				reasoningBuffer.WriteString(reasoningContent)
@@ -567,7 +826,7 @@ func (h *StreamModelService) streamResponseToChannel(reqCtx *gin.Context, provid
 					reasoningSummaryTextDeltaEvent := responsetypes.ResponseReasoningSummaryTextDeltaEvent{
 						BaseStreamingEvent: responsetypes.BaseStreamingEvent{
 							Type:           "response.reasoning_summary_text.delta",
-							SequenceNumber: reasoningSequenceNumber,
+							SequenceNumber: sequenceNumber,
 						},
 						ItemID:       reasoningItemID,
 						OutputIndex:  0,
@@ -577,13 +836,28 @@ func (h *StreamModelService) streamResponseToChannel(reqCtx *gin.Context, provid
 					}
 					eventJSON, _ := json.Marshal(reasoningSummaryTextDeltaEvent)
 					dataChan <- fmt.Sprintf("event: response.reasoning_summary_text.delta\ndata: %s\n\n", string(eventJSON))
-					reasoningSequenceNumber++
+					sequenceNumber++
 					// Clear the reasoning buffer
 					reasoningBuffer.Reset()
 				}
 			}
 
 		}
+		h.closeStreamReader(reader)
+
+		calls := toolCalls.calls
+		if step >= MaxToolSteps || !h.toolExecutor.CanExecute(calls) {
+			finalCalls = calls
+			break
+		}
+
+		roundExecutions := make([]*ToolExecution, 0, len(calls))
+		for _, call := range calls {
+			roundExecutions = append(roundExecutions, h.executeStreamToolCall(toolContext(reqCtx), dataChan, call, scope, toolOutputIndex, &sequenceNumber))
+			toolOutputIndex++
+		}
+		executions = append(executions, roundExecutions...)
+		h.recordToolRound(reqCtx, conv, h.lookupResponseID(reqCtx, responseID), &request, passResponse.String(), calls, roundExecutions)
 	}
 
 	// Send any remaining buffered reasoning content
@@ -591,7 +865,7 @@ func (h *StreamModelService) streamResponseToChannel(reqCtx *gin.Context, provid
 		reasoningSummaryTextDeltaEvent := responsetypes.ResponseReasoningSummaryTextDeltaEvent{
 			BaseStreamingEvent: responsetypes.BaseStreamingEvent{
 				Type:           "response.reasoning_summary_text.delta",
-				SequenceNumber: reasoningSequenceNumber,
+				SequenceNumber: sequenceNumber,
 			},
 			ItemID:       reasoningItemID,
 			OutputIndex:  0,
@@ -601,7 +875,7 @@ func (h *StreamModelService) streamResponseToChannel(reqCtx *gin.Context, provid
 		}
 		eventJSON, _ := json.Marshal(reasoningSummaryTextDeltaEvent)
 		dataChan <- fmt.Sprintf("event: response.reasoning_summary_text.delta\ndata: %s\n\n", string(eventJSON))
-		reasoningSequenceNumber++
+		sequenceNumber++
 	}
 
 	// Handle reasoning completion events
@@ -610,7 +884,7 @@ func (h *StreamModelService) streamResponseToChannel(reqCtx *gin.Context, provid
 		reasoningSummaryTextDoneEvent := responsetypes.ResponseReasoningSummaryTextDoneEvent{
 			BaseStreamingEvent: responsetypes.BaseStreamingEvent{
 				Type:           "response.reasoning_summary_text.done",
-				SequenceNumber: reasoningSequenceNumber,
+				SequenceNumber: sequenceNumber,
 			},
 			ItemID:       reasoningItemID,
 			OutputIndex:  0,
@@ -619,13 +893,13 @@ func (h *StreamModelService) streamResponseToChannel(reqCtx *gin.Context, provid
 		}
 		eventJSON, _ := json.Marshal(reasoningSummaryTextDoneEvent)
 		dataChan <- fmt.Sprintf("event: response.reasoning_summary_text.done\ndata: %s\n\n", string(eventJSON))
-		reasoningSequenceNumber++
+		sequenceNumber++
 
 		// Emit reasoning summary part done event
 		reasoningSummaryPartDoneEvent := responsetypes.ResponseReasoningSummaryPartDoneEvent{
 			BaseStreamingEvent: responsetypes.BaseStreamingEvent{
 				Type:           "response.reasoning_summary_part.done",
-				SequenceNumber: reasoningSequenceNumber,
+				SequenceNumber: sequenceNumber,
 			},
 			ItemID:       reasoningItemID,
 			OutputIndex:  0,
@@ -640,7 +914,7 @@ func (h *StreamModelService) streamResponseToChannel(reqCtx *gin.Context, provid
 		}
 		eventJSON, _ = json.Marshal(reasoningSummaryPartDoneEvent)
 		dataChan <- fmt.Sprintf("event: response.reasoning_summary_part.done\ndata: %s\n\n", string(eventJSON))
-		reasoningSequenceNumber++
+		sequenceNumber++
 
 		// Mark reasoning as complete
 		reasoningComplete = true
@@ -654,11 +928,11 @@ func (h *StreamModelService) streamResponseToChannel(reqCtx *gin.Context, provid
 		contentBuffer.Reset()
 	}
 
-	// Append assistant's complete response to conversation
-	if fullResponse.Len() > 0 && conv != nil {
+	// Append assistant's final answer to conversation, earlier passes were stored with their tool calls
+	if passResponse.Len() > 0 && conv != nil {
 		assistantMessage := openai.ChatCompletionMessage{
 			Role:    openai.ChatMessageRoleAssistant,
-			Content: fullResponse.String(),
+			Content: passResponse.String(),
 		}
 		// Get response entity to get the internal ID
 		responseEntity, err := h.responseService.GetResponseByPublicID(reqCtx, responseID)
@@ -673,6 +947,7 @@ func (h *StreamModelService) streamResponseToChannel(reqCtx *gin.Context, provid
 
 	// Emit text done event
 	if fullResponse.Len() > 0 {
+		annotations := fileCitations(fullResponse.String(), executions)
 		doneEvent := responsetypes.ResponseOutputTextDoneEvent{
 			BaseStreamingEvent: responsetypes.BaseStreamingEvent{
 				Type:           "response.output_text.done",
@@ -699,7 +974,7 @@ func (h *StreamModelService) streamResponseToChannel(reqCtx *gin.Context, provid
 			ContentIndex: 0,
 			Part: responsetypes.ResponseContentPart{
 				Type:        "output_text",
-				Annotations: []responsetypes.Annotation{},
+				Annotations: annotations,
 				Logprobs:    []responsetypes.Logprob{},
 				Text:        fullResponse.String(),
 			},
@@ -722,7 +997,7 @@ func (h *StreamModelService) streamResponseToChannel(reqCtx *gin.Context, provid
 				Content: []responsetypes.ResponseContentPart{
 					{
 						Type:        "output_text",
-						Annotations: []responsetypes.Annotation{},
+						Annotations: annotations,
 						Logprobs:    []responsetypes.Logprob{},
 						Text:        fullResponse.String(),
 					},
@@ -735,8 +1010,14 @@ func (h *StreamModelService) streamResponseToChannel(reqCtx *gin.Context, provid
 		sequenceNumber++
 	}
 
-	// Send [DONE] to close the stream
-	dataChan <- fmt.Sprintf(SSEDataFormat, DoneMarker)
+	// Validate a json_object or json_schema answer; it has already been streamed so it cannot be repaired
+	if format := structuredOutputFormat(&request); format != nil && (len(finalCalls) == 0 || passResponse.Len() > 0) {
+		if _, errs := format.Check(passResponse.String()); len(errs) > 0 {
+			outcome.incompleteDetails = incompleteDetails(errs)
+		}
+	}
+
+	outcome.sequenceNumber = sequenceNumber
 
 	// Update response status to completed and save output
 	// Get response entity by public ID to update status
@@ -755,6 +1036,10 @@ func (h *StreamModelService) streamResponseToChannel(reqCtx *gin.Context, provid
 			Status: ptr.ToString(string(ResponseStatusCompleted)),
 			Output: outputData,
 		}
+		if outcome.incompleteDetails != nil {
+			updates.Status = ptr.ToString(string(ResponseStatusIncomplete))
+			updates.IncompleteDetails = outcome.incompleteDetails
+		}
 		success, updateErr := h.responseService.UpdateResponseFields(reqCtx, responseEntity.ID, updates)
 		if !success {
 			// Log error but don't fail the request since streaming is already complete
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	openai "github.com/sashabaranov/go-openai"
@@ -12,6 +13,7 @@ import (
 	"menlo.ai/indigo-api-gateway/app/domain/common"
 	"menlo.ai/indigo-api-gateway/app/domain/conversation"
 	"menlo.ai/indigo-api-gateway/app/domain/query"
+	"menlo.ai/indigo-api-gateway/app/domain/webhook"
 	requesttypes "menlo.ai/indigo-api-gateway/app/interfaces/http/requests"
 	responsetypes "menlo.ai/indigo-api-gateway/app/interfaces/http/responses"
 	"menlo.ai/indigo-api-gateway/app/utils/idgen"
@@ -23,6 +25,7 @@ type ResponseService struct {
 	responseRepo        ResponseRepository
 	itemRepo            conversation.ItemRepository
 	conversationService *conversation.ConversationService
+	webhookService      *webhook.WebhookService
 }
 
 // ResponseContextKey represents context keys for responses
@@ -37,11 +40,12 @@ const (
 )
 
 // NewResponseService creates a new response service
-func NewResponseService(responseRepo ResponseRepository, itemRepo conversation.ItemRepository, conversationService *conversation.ConversationService) *ResponseService {
+func NewResponseService(responseRepo ResponseRepository, itemRepo conversation.ItemRepository, conversationService *conversation.ConversationService, webhookService *webhook.WebhookService) *ResponseService {
 	return &ResponseService{
 		responseRepo:        responseRepo,
 		itemRepo:            itemRepo,
 		conversationService: conversationService,
+		webhookService:      webhookService,
 	}
 }
 
@@ -200,6 +204,27 @@ func (s *ResponseService) UpdateResponseError(ctx context.Context, responseID ui
 	return true, nil
 }
 
+// orphanedResponseAge is how long a response stays unfinished before the startup sweep fails it,
+// well beyond RequestTimeout so the responses served by other replicas are left alone
+const orphanedResponseAge = 2 * RequestTimeout
+
+// FailOrphanedResponses fails the responses a stopped replica left pending or running; background
+// responses with a queued or running job are left to the workers
+func (s *ResponseService) FailOrphanedResponses(ctx context.Context) (int64, *common.Error) {
+	errorJSON, err := json.Marshal(responsetypes.ResponseError{
+		Code:    "9c4e2a7b-5d1f-4b8e-a3c6-7f0d2e9b4a18",
+		Message: "the response was interrupted by a server restart",
+	})
+	if err != nil {
+		return 0, common.NewError(err, "3e7a1c9d-6b2f-4d8a-9e5c-1a4f7b3d6c20")
+	}
+	failed, err := s.responseRepo.FailOrphaned(ctx, time.Now().Add(-orphanedResponseAge), string(errorJSON))
+	if err != nil {
+		return 0, common.NewError(err, "b5d2f8a1-4c7e-4a9b-8d3f-6e1c9a2b7d54")
+	}
+	return failed, nil
+}
+
 // UpdateResponseFields updates multiple fields on a response object and saves it once (optimized for N+1 prevention)
 func (s *ResponseService) UpdateResponseFields(ctx context.Context, responseID uint, updates *ResponseUpdates) (bool, *common.Error) {
 	response, err := s.responseRepo.FindByID(ctx, responseID)
@@ -223,6 +248,18 @@ func (s *ResponseService) UpdateResponseFields(ctx context.Context, responseID u
 	return true, nil
 }
 
+// GetResponseByID gets a response by its internal ID
+func (s *ResponseService) GetResponseByID(ctx context.Context, responseID uint) (*Response, *common.Error) {
+	response, err := s.responseRepo.FindByID(ctx, responseID)
+	if err != nil {
+		return nil, common.NewError(err, "2b4d6f8a-0c2e-4b4d-8f8a-0c2e4b6d8f0a")
+	}
+	if response == nil {
+		return nil, common.NewErrorWithMessage("Response not found", "d0e1f2g3-h4i5-6789-defg-012345678901")
+	}
+	return response, nil
+}
+
 // GetResponseByPublicID gets a response by public ID
 func (s *ResponseService) GetResponseByPublicID(ctx context.Context, publicID string) (*Response, *common.Error) {
 	response, err := s.responseRepo.FindByPublicID(ctx, publicID)
@@ -481,6 +518,14 @@ func (s *ResponseService) ConvertDomainResponseToAPIResponse(responseEntity *Res
 		}
 	}
 
+	// Parse incomplete details if exists
+	if responseEntity.IncompleteDetails != nil {
+		var incompleteDetails responsetypes.IncompleteDetails
+		if err := json.Unmarshal([]byte(*responseEntity.IncompleteDetails), &incompleteDetails); err == nil {
+			apiResponse.IncompleteDetails = &incompleteDetails
+		}
+	}
+
 	return apiResponse
 }
 
@@ -567,6 +612,16 @@ func (s *ResponseService) AppendMessagesToConversation(ctx context.Context, conv
 	// Convert OpenAI messages to conversation items
 	items := make([]*conversation.Item, 0, len(messages))
 	for _, msg := range messages {
+		// Tool calls and their outputs are stored as separate function items
+		toolItems, toolErr := s.convertToolMessageToItems(msg, conv.ID, responseID)
+		if toolErr != nil {
+			return false, toolErr
+		}
+		items = append(items, toolItems...)
+		if msg.Role == openai.ChatMessageRoleTool || (len(msg.ToolCalls) > 0 && msg.Content == "") {
+			continue
+		}
+
 		// Generate public ID for the item
 		publicID, err := idgen.GenerateSecureID("msg", 42)
 		if err != nil {
@@ -622,6 +677,46 @@ func (s *ResponseService) AppendMessagesToConversation(ctx context.Context, conv
 	return true, nil
 }
 
+// convertToolMessageToItems converts the tool calls of an assistant message, or a tool output message, to conversation items
+func (s *ResponseService) convertToolMessageToItems(msg openai.ChatCompletionMessage, conversationID uint, responseID *uint) ([]*conversation.Item, *common.Error) {
+	var items []*conversation.Item
+	if msg.Role == openai.ChatMessageRoleTool {
+		publicID, err := idgen.GenerateSecureID("fco", 42)
+		if err != nil {
+			return nil, common.NewErrorWithMessage("Failed to generate item ID", "0c6e2b8a-4f1d-4a3e-9b7c-5d2e8f1a6c34")
+		}
+		items = append(items, conversation.NewItem(publicID, conversation.ItemTypeFunctionCall, conversation.ItemRoleTool, []conversation.Content{
+			{
+				Type: "function_call_output",
+				ToolCall: &conversation.ToolCall{
+					CallID: msg.ToolCallID,
+					Name:   msg.Name,
+					Output: msg.Content,
+				},
+			},
+		}, conversationID, responseID))
+		return items, nil
+	}
+
+	for _, call := range msg.ToolCalls {
+		publicID, err := idgen.GenerateSecureID("fc", 42)
+		if err != nil {
+			return nil, common.NewErrorWithMessage("Failed to generate item ID", "7a3d9e1f-2b6c-4d8e-a5f0-1c9b4e7d3a62")
+		}
+		items = append(items, conversation.NewItem(publicID, conversation.ItemTypeFunction, conversation.ItemRoleAssistant, []conversation.Content{
+			{
+				Type: "function_call",
+				ToolCall: &conversation.ToolCall{
+					CallID:    call.ID,
+					Name:      call.Function.Name,
+					Arguments: call.Function.Arguments,
+				},
+			},
+		}, conversationID, responseID))
+	}
+	return items, nil
+}
+
 // ConvertToChatCompletionRequest converts a response request to OpenAI chat completion request
 func (s *ResponseService) ConvertToChatCompletionRequest(req *requesttypes.CreateResponseRequest) *openai.ChatCompletionRequest {
 	chatReq := &openai.ChatCompletionRequest{
@@ -675,6 +770,13 @@ func (s *ResponseService) ConvertToChatCompletionRequest(req *requesttypes.Creat
 	if req.User != nil {
 		chatReq.User = *req.User
 	}
+	if len(req.Tools) > 0 {
+		chatReq.Tools = ConvertTools(req.Tools)
+		chatReq.ToolChoice = ConvertToolChoice(req.ToolChoice)
+	}
+	if req.ResponseFormat != nil {
+		chatReq.ResponseFormat = ConvertResponseFormat(req.ResponseFormat)
+	}
 
 	return chatReq
 }
@@ -695,6 +797,17 @@ func (s *ResponseService) ConvertConversationItemsToMessages(ctx context.Context
 			continue
 		}
 
+		// Context summaries are applied by the context window service, never replayed as dialogue
+		if item.Type == conversation.ItemTypeContextSummary {
+			continue
+		}
+
+		// Tool calls are replayed as assistant tool_calls followed by their outputs
+		if item.Type == conversation.ItemTypeFunction || item.Type == conversation.ItemTypeFunctionCall {
+			messages = appendToolItemMessage(messages, &item)
+			continue
+		}
+
 		// Convert conversation role to OpenAI role
 		var openaiRole string
 		switch *item.Role {
@@ -727,3 +840,41 @@ func (s *ResponseService) ConvertConversationItemsToMessages(ctx context.Context
 
 	return messages, nil
 }
+
+// appendToolItemMessage replays a function call item onto the message history. Calls attach to the preceding
+// assistant turn so that the tool outputs which follow them remain valid for the upstream model.
+func appendToolItemMessage(messages []openai.ChatCompletionMessage, item *conversation.Item) []openai.ChatCompletionMessage {
+	if len(item.Content) == 0 {
+		return messages
+	}
+	toolCall := item.Content[0].ToolCall
+	if toolCall == nil {
+		return messages
+	}
+
+	if item.Type == conversation.ItemTypeFunctionCall {
+		return append(messages, openai.ChatCompletionMessage{
+			Role:       openai.ChatMessageRoleTool,
+			Content:    toolCall.Output,
+			Name:       toolCall.Name,
+			ToolCallID: toolCall.CallID,
+		})
+	}
+
+	call := openai.ToolCall{
+		ID:   toolCall.CallID,
+		Type: openai.ToolTypeFunction,
+		Function: openai.FunctionCall{
+			Name:      toolCall.Name,
+			Arguments: toolCall.Arguments,
+		},
+	}
+	if last := len(messages) - 1; last >= 0 && messages[last].Role == openai.ChatMessageRoleAssistant {
+		messages[last].ToolCalls = append(messages[last].ToolCalls, call)
+		return messages
+	}
+	return append(messages, openai.ChatCompletionMessage{
+		Role:      openai.ChatMessageRoleAssistant,
+		ToolCalls: []openai.ToolCall{call},
+	})
+}
@@ -0,0 +1,160 @@
+package response
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"menlo.ai/indigo-api-gateway/app/domain/common"
+	"menlo.ai/indigo-api-gateway/app/infrastructure/cache"
+	"menlo.ai/indigo-api-gateway/app/utils/logger"
+)
+
+const (
+	// StreamBufferTTL is how long the events of a streamed response can be replayed after the last one was recorded
+	StreamBufferTTL = 15 * time.Minute
+	// streamFollowBlock bounds each wait for live events, the response status is checked in between
+	streamFollowBlock = 5 * time.Second
+
+	streamFieldSequence = "seq"
+	streamFieldEvent    = "event"
+	streamFieldEnd      = "end"
+)
+
+// streamStore is the part of the Redis cache the buffer uses
+type streamStore interface {
+	StreamAppend(ctx context.Context, key string, values map[string]string, expiration time.Duration) error
+	StreamRange(ctx context.Context, key string) ([]cache.StreamEntry, error)
+	StreamRead(ctx context.Context, key string, afterID string, block time.Duration) ([]cache.StreamEntry, error)
+}
+
+// StreamBuffer keeps the SSE events of streamed responses in Redis so a client can resume a dropped stream
+type StreamBuffer struct {
+	cache streamStore
+}
+
+func NewStreamBuffer(cache *cache.RedisCacheService) *StreamBuffer {
+	return &StreamBuffer{cache: cache}
+}
+
+func streamBufferKey(responseID string) string {
+	return fmt.Sprintf(cache.ResponseStreamKey, responseID)
+}
+
+// StreamRecorder identifies the events of one streamed response by their sequence number and appends them to the buffer
+type StreamRecorder struct {
+	buffer     *StreamBuffer
+	responseID string
+	next       int
+}
+
+func (b *StreamBuffer) NewRecorder(responseID string) *StreamRecorder {
+	return &StreamRecorder{buffer: b, responseID: responseID}
+}
+
+// Record buffers an SSE event and returns it with its sequence_number as SSE id; events without one,
+// like response.error, take the number following the previous event
+func (r *StreamRecorder) Record(ctx context.Context, event string) string {
+	sequence, ok := eventSequenceNumber(event)
+	if !ok {
+		sequence = r.next
+	}
+	r.next = sequence + 1
+	r.append(ctx, map[string]string{
+		streamFieldSequence: strconv.Itoa(sequence),
+		streamFieldEvent:    event,
+	})
+	return fmt.Sprintf("id: %d\n%s", sequence, event)
+}
+
+// Finish buffers the closing data of the stream, it may be empty; replays stop after it
+func (r *StreamRecorder) Finish(ctx context.Context, event string) string {
+	r.append(ctx, map[string]string{
+		streamFieldEvent: event,
+		streamFieldEnd:   "1",
+	})
+	return event
+}
+
+// eventSequenceNumber reads the sequence_number of the data of an SSE event
+func eventSequenceNumber(event string) (int, bool) {
+	for _, line := range strings.Split(event, "\n") {
+		data, ok := strings.CutPrefix(line, "data: ")
+		if !ok {
+			continue
+		}
+		var payload struct {
+			SequenceNumber *int `json:"sequence_number"`
+		}
+		if err := json.Unmarshal([]byte(data), &payload); err != nil || payload.SequenceNumber == nil {
+			return 0, false
+		}
+		return *payload.SequenceNumber, true
+	}
+	return 0, false
+}
+
+func (r *StreamRecorder) append(ctx context.Context, values map[string]string) {
+	// the stream goes on without resume support when Redis is unavailable
+	if err := r.buffer.cache.StreamAppend(ctx, streamBufferKey(r.responseID), values, StreamBufferTTL); err != nil {
+		logger.GetLogger().Warnf("failed to buffer stream event of response %s: %v", r.responseID, err)
+	}
+}
+
+// Replay writes the buffered events numbered after startingAfter, then follows the live events until the stream ends.
+// isFinished is consulted when no event arrived for a while, to stop following a stream whose producer is gone.
+// A running response that has not buffered its first event yet is waited for.
+func (b *StreamBuffer) Replay(ctx context.Context, responseID string, startingAfter int, write func(event string) error, isFinished func() bool) *common.Error {
+	key := streamBufferKey(responseID)
+	entries, err := b.cache.StreamRange(ctx, key)
+	if err != nil {
+		return common.NewError(err, "4e8b2d6f-1a9c-4f3e-b7d5-2c6a8e4f1b39")
+	}
+	if len(entries) == 0 && isFinished() {
+		return common.NewErrorWithMessage("the events of this response are no longer available", "4e8b2d6f-1a9c-4f3e-b7d5-2c6a8e4f1b40")
+	}
+
+	// "0" reads the stream from its first entry
+	lastID := "0"
+	for {
+		for _, entry := range entries {
+			if sequence, err := strconv.Atoi(entry.Values[streamFieldSequence]); err == nil && sequence <= startingAfter {
+				continue
+			}
+			if event := entry.Values[streamFieldEvent]; event != "" {
+				if sequence, ok := entry.Values[streamFieldSequence]; ok {
+					event = fmt.Sprintf("id: %s\n%s", sequence, event)
+				}
+				if err := write(event); err != nil {
+					// the client is gone
+					return nil
+				}
+			}
+			if entry.Values[streamFieldEnd] != "" {
+				return nil
+			}
+		}
+
+		if len(entries) > 0 {
+			lastID = entries[len(entries)-1].ID
+		}
+		for {
+			entries, err = b.cache.StreamRead(ctx, key, lastID, streamFollowBlock)
+			if ctx.Err() != nil {
+				return nil
+			}
+			if err != nil {
+				return common.NewError(err, "9a3f7c1e-5d2b-4e8a-a6c4-1f9d3b7e5a28")
+			}
+			if len(entries) > 0 {
+				break
+			}
+			if isFinished() {
+				return nil
+			}
+		}
+	}
+}
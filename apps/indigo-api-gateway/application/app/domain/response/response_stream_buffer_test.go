@@ -0,0 +1,147 @@
+package response
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"menlo.ai/indigo-api-gateway/app/infrastructure/cache"
+)
+
+// memoryStreamStore keeps the streams in memory, StreamRead waits for appends like XREAD BLOCK
+type memoryStreamStore struct {
+	mu      sync.Mutex
+	streams map[string][]cache.StreamEntry
+	// appended is closed and replaced on every append
+	appended chan struct{}
+}
+
+func newMemoryStreamStore() *memoryStreamStore {
+	return &memoryStreamStore{streams: map[string][]cache.StreamEntry{}, appended: make(chan struct{})}
+}
+
+func (s *memoryStreamStore) StreamAppend(ctx context.Context, key string, values map[string]string, expiration time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := strconv.Itoa(len(s.streams[key]) + 1)
+	s.streams[key] = append(s.streams[key], cache.StreamEntry{ID: id, Values: values})
+	close(s.appended)
+	s.appended = make(chan struct{})
+	return nil
+}
+
+func (s *memoryStreamStore) StreamRange(ctx context.Context, key string) ([]cache.StreamEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]cache.StreamEntry(nil), s.streams[key]...), nil
+}
+
+func (s *memoryStreamStore) StreamRead(ctx context.Context, key string, afterID string, block time.Duration) ([]cache.StreamEntry, error) {
+	after, _ := strconv.Atoi(afterID)
+	timeout := time.After(block)
+	for {
+		s.mu.Lock()
+		entries := s.streams[key]
+		appended := s.appended
+		s.mu.Unlock()
+		if len(entries) > after {
+			return append([]cache.StreamEntry(nil), entries[after:]...), nil
+		}
+		select {
+		case <-appended:
+		case <-timeout:
+			return nil, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func sequencedEvent(eventType string, sequence int) string {
+	return fmt.Sprintf(SSEEventFormat, eventType, fmt.Sprintf(`{"type":%q,"sequence_number":%d}`, eventType, sequence))
+}
+
+func TestStreamRecorderUsesSequenceNumbers(t *testing.T) {
+	ctx := context.Background()
+	buffer := &StreamBuffer{cache: newMemoryStreamStore()}
+	recorder := buffer.NewRecorder("resp_1")
+
+	if event := recorder.Record(ctx, sequencedEvent("response.created", 0)); !strings.HasPrefix(event, "id: 0\n") {
+		t.Fatalf("unexpected event %q", event)
+	}
+	if event := recorder.Record(ctx, sequencedEvent("response.output_text.delta", 4)); !strings.HasPrefix(event, "id: 4\n") {
+		t.Fatalf("expected the sequence_number as id, got %q", event)
+	}
+	// an event without sequence_number follows the previous one
+	if event := recorder.Record(ctx, "event: response.error\ndata: {\"code\":\"x\"}\n\n"); !strings.HasPrefix(event, "id: 5\n") {
+		t.Fatalf("expected the next number, got %q", event)
+	}
+	if event := recorder.Finish(ctx, "data: [DONE]\n\n"); event != "data: [DONE]\n\n" {
+		t.Fatalf("expected the closing data without id, got %q", event)
+	}
+
+	var replayed []string
+	err := buffer.Replay(ctx, "resp_1", 0, func(event string) error {
+		replayed = append(replayed, event)
+		return nil
+	}, func() bool { return true })
+	if err != nil {
+		t.Fatalf("replay: %v", err)
+	}
+	if len(replayed) != 3 || !strings.HasPrefix(replayed[0], "id: 4\n") || !strings.HasPrefix(replayed[1], "id: 5\n") || replayed[2] != "data: [DONE]\n\n" {
+		t.Fatalf("expected the events after sequence 0, got %q", replayed)
+	}
+}
+
+func TestStreamReplayFollowsLiveEvents(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	buffer := &StreamBuffer{cache: newMemoryStreamStore()}
+	recorder := buffer.NewRecorder("resp_1")
+
+	// the stream has started but not buffered its first event yet
+	replayed := make(chan string, 10)
+	done := make(chan error, 1)
+	go func() {
+		err := buffer.Replay(ctx, "resp_1", -1, func(event string) error {
+			replayed <- event
+			return nil
+		}, func() bool { return false })
+		if err != nil {
+			done <- err
+			return
+		}
+		done <- nil
+	}()
+
+	recorder.Record(ctx, sequencedEvent("response.created", 0))
+	if event := <-replayed; !strings.HasPrefix(event, "id: 0\n") {
+		t.Fatalf("expected the first event, got %q", event)
+	}
+	recorder.Record(ctx, sequencedEvent("response.in_progress", 1))
+	recorder.Finish(ctx, "data: [DONE]\n\n")
+	if err := <-done; err != nil {
+		t.Fatalf("replay: %v", err)
+	}
+	if event := <-replayed; !strings.HasPrefix(event, "id: 1\n") {
+		t.Fatalf("expected the live event, got %q", event)
+	}
+	if event := <-replayed; event != "data: [DONE]\n\n" {
+		t.Fatalf("expected the end of the stream, got %q", event)
+	}
+}
+
+func TestStreamReplayOfExpiredStream(t *testing.T) {
+	buffer := &StreamBuffer{cache: newMemoryStreamStore()}
+	err := buffer.Replay(context.Background(), "resp_1", -1, func(event string) error {
+		t.Fatalf("unexpected event %q", event)
+		return nil
+	}, func() bool { return true })
+	if err == nil || err.GetCode() != "4e8b2d6f-1a9c-4f3e-b7d5-2c6a8e4f1b40" {
+		t.Fatalf("expected the events of a finished response to be reported missing, got %v", err)
+	}
+}
@@ -0,0 +1,51 @@
+package response
+
+import (
+	"encoding/json"
+
+	openai "github.com/sashabaranov/go-openai"
+	"menlo.ai/indigo-api-gateway/app/domain/structuredoutput"
+	requesttypes "menlo.ai/indigo-api-gateway/app/interfaces/http/requests"
+	responsetypes "menlo.ai/indigo-api-gateway/app/interfaces/http/responses"
+	"menlo.ai/indigo-api-gateway/app/utils/jsonschema"
+)
+
+// ConvertResponseFormat converts a response format to the chat completion response_format
+func ConvertResponseFormat(format *requesttypes.ResponseFormat) *openai.ChatCompletionResponseFormat {
+	converted := &openai.ChatCompletionResponseFormat{
+		Type: openai.ChatCompletionResponseFormatType(format.Type),
+	}
+	if format.JSONSchema != nil {
+		schema, _ := json.Marshal(format.JSONSchema.Schema)
+		converted.JSONSchema = &openai.ChatCompletionResponseFormatJSONSchema{
+			Name:   format.JSONSchema.Name,
+			Schema: json.RawMessage(schema),
+		}
+		if format.JSONSchema.Description != nil {
+			converted.JSONSchema.Description = *format.JSONSchema.Description
+		}
+		if format.JSONSchema.Strict != nil {
+			converted.JSONSchema.Strict = *format.JSONSchema.Strict
+		}
+	}
+	return converted
+}
+
+// structuredOutputFormat returns the JSON format the final answer must match, nil for plain text
+func structuredOutputFormat(request *openai.ChatCompletionRequest) *structuredoutput.Format {
+	// the format was validated with the request
+	format, _ := structuredoutput.FormatFromChatRequest(request)
+	return format
+}
+
+// incompleteDetails reports the validation errors of an output that never matched the requested format
+func incompleteDetails(errs []jsonschema.ValidationError) *responsetypes.IncompleteDetails {
+	details := &responsetypes.IncompleteDetails{
+		Reason: structuredoutput.IncompleteReason,
+		Errors: make([]string, 0, len(errs)),
+	}
+	for _, e := range errs {
+		details.Errors = append(details.Errors, e.String())
+	}
+	return details
+}
@@ -0,0 +1,503 @@
+package response
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	openai "github.com/sashabaranov/go-openai"
+	"menlo.ai/indigo-api-gateway/app/domain/conversation"
+	"menlo.ai/indigo-api-gateway/app/domain/file"
+	"menlo.ai/indigo-api-gateway/app/domain/mcp/serpermcp"
+	"menlo.ai/indigo-api-gateway/app/domain/vectorstore"
+	requesttypes "menlo.ai/indigo-api-gateway/app/interfaces/http/requests"
+	responsetypes "menlo.ai/indigo-api-gateway/app/interfaces/http/responses"
+	"menlo.ai/indigo-api-gateway/app/utils/logger"
+	"menlo.ai/indigo-api-gateway/app/utils/ptr"
+)
+
+// Built-in tools are executed by the gateway instead of being handed back to the client
+const (
+	BuiltinToolWebSearch    = "web_search"
+	BuiltinToolFetchWebpage = "fetch_webpage"
+	BuiltinToolFileSearch   = "file_search"
+)
+
+const (
+	// MaxToolSteps bounds how many tool rounds a single response may run before answering
+	MaxToolSteps = 5
+	// defaultSearchResults is the number of organic results requested when the model does not ask for a count
+	defaultSearchResults = 5
+	// maxFetchedPageChars caps the page text fed back to the model
+	maxFetchedPageChars = 20000
+)
+
+// IsBuiltinTool reports whether the tool name is executed server-side
+func IsBuiltinTool(name string) bool {
+	return name == BuiltinToolWebSearch || name == BuiltinToolFetchWebpage || name == BuiltinToolFileSearch
+}
+
+// ToolScope carries the per-response settings of the built-in tools
+type ToolScope struct {
+	// UserID owns the vector stores searched by file_search
+	UserID         uint
+	VectorStoreIDs []string
+	MaxNumResults  int
+	// citations numbers file_search results across the tool rounds of a response
+	citations int
+}
+
+// NewToolScope collects the file_search settings of the request tools
+func NewToolScope(userID uint, tools []requesttypes.Tool) *ToolScope {
+	scope := &ToolScope{
+		UserID:        userID,
+		MaxNumResults: vectorstore.DefaultSearchResults,
+	}
+	for _, tool := range tools {
+		if tool.Type != BuiltinToolFileSearch {
+			continue
+		}
+		scope.VectorStoreIDs = append(scope.VectorStoreIDs, tool.VectorStoreIDs...)
+		if tool.MaxNumResults != nil && *tool.MaxNumResults > 0 {
+			scope.MaxNumResults = *tool.MaxNumResults
+		}
+	}
+	return scope
+}
+
+// ToolExecution records one server-side tool call and its outcome
+type ToolExecution struct {
+	CallID    string
+	Name      string
+	Arguments string
+	// Output is the text fed back to the model
+	Output string
+	// Query and Results are set for web_search calls
+	Query   string
+	Results []responsetypes.WebSearchResult
+	// URL is set for fetch_webpage calls
+	URL string
+	// FileResults are set for file_search calls, result i is cited as [FirstCitation+i]
+	FileResults   []*vectorstore.SearchResult
+	FirstCitation int
+	Err           error
+}
+
+// ToolExecutor runs the built-in tools on behalf of the model
+type ToolExecutor struct {
+	serperService      *serpermcp.SerperService
+	vectorStoreService *vectorstore.VectorStoreService
+}
+
+// NewToolExecutor creates a new ToolExecutor instance
+func NewToolExecutor(serperService *serpermcp.SerperService, vectorStoreService *vectorstore.VectorStoreService) *ToolExecutor {
+	return &ToolExecutor{
+		serperService:      serperService,
+		vectorStoreService: vectorStoreService,
+	}
+}
+
+// CanExecute reports whether every call targets a built-in tool
+func (e *ToolExecutor) CanExecute(calls []openai.ToolCall) bool {
+	if len(calls) == 0 {
+		return false
+	}
+	for _, call := range calls {
+		if !IsBuiltinTool(call.Function.Name) {
+			return false
+		}
+	}
+	return true
+}
+
+// Execute runs a single tool call. Failures are reported to the model through the output instead of aborting the response.
+func (e *ToolExecutor) Execute(ctx context.Context, call openai.ToolCall, scope *ToolScope) *ToolExecution {
+	execution := &ToolExecution{
+		CallID:    call.ID,
+		Name:      call.Function.Name,
+		Arguments: call.Function.Arguments,
+	}
+
+	switch call.Function.Name {
+	case BuiltinToolWebSearch:
+		execution.Err = e.webSearch(ctx, execution)
+	case BuiltinToolFetchWebpage:
+		execution.Err = e.fetchWebpage(ctx, execution)
+	case BuiltinToolFileSearch:
+		execution.Err = e.fileSearch(ctx, execution, scope)
+	default:
+		execution.Err = fmt.Errorf("unknown tool %q", call.Function.Name)
+	}
+
+	if execution.Err != nil {
+		logger.GetLogger().Warnf("tool %s failed: %v", execution.Name, execution.Err)
+		execution.Output = fmt.Sprintf("error: %s", execution.Err.Error())
+	}
+	return execution
+}
+
+func (e *ToolExecutor) webSearch(ctx context.Context, execution *ToolExecution) error {
+	var args struct {
+		Query string `json:"query"`
+		Num   *int   `json:"num"`
+	}
+	if err := json.Unmarshal([]byte(execution.Arguments), &args); err != nil {
+		return fmt.Errorf("invalid arguments: %w", err)
+	}
+	execution.Query = strings.TrimSpace(args.Query)
+	if execution.Query == "" {
+		return fmt.Errorf("query is required")
+	}
+	num := defaultSearchResults
+	if args.Num != nil && *args.Num > 0 {
+		num = *args.Num
+	}
+
+	searchResp, err := e.serperService.Search(ctx, serpermcp.SearchRequest{
+		Q:   execution.Query,
+		Num: &num,
+	})
+	if err != nil {
+		return err
+	}
+
+	results := make([]responsetypes.WebSearchResult, 0, len(searchResp.Organic))
+	for _, organic := range searchResp.Organic {
+		result := responsetypes.WebSearchResult{
+			Title:   stringField(organic, "title"),
+			URL:     stringField(organic, "link"),
+			Snippet: stringField(organic, "snippet"),
+		}
+		if source := stringField(organic, "source"); source != "" {
+			result.Source = ptr.ToString(source)
+		}
+		results = append(results, result)
+	}
+	execution.Results = results
+
+	output, err := json.Marshal(results)
+	if err != nil {
+		return err
+	}
+	execution.Output = string(output)
+	return nil
+}
+
+func (e *ToolExecutor) fetchWebpage(ctx context.Context, execution *ToolExecution) error {
+	var args struct {
+		URL string `json:"url"`
+	}
+	if err := json.Unmarshal([]byte(execution.Arguments), &args); err != nil {
+		return fmt.Errorf("invalid arguments: %w", err)
+	}
+	execution.URL = strings.TrimSpace(args.URL)
+	if execution.URL == "" {
+		return fmt.Errorf("url is required")
+	}
+
+	page, err := e.serperService.FetchWebpage(ctx, serpermcp.FetchWebpageRequest{
+		Url:             execution.URL,
+		IncludeMarkdown: ptr.ToBool(true),
+	})
+	if err != nil {
+		return err
+	}
+
+	text := page.Markdown
+	if text == "" {
+		text = page.Text
+	}
+	execution.Output = truncateUTF8(text, maxFetchedPageChars)
+	return nil
+}
+
+// truncateUTF8 cuts text to at most limit bytes without splitting a multi-byte character
+func truncateUTF8(text string, limit int) string {
+	if len(text) <= limit {
+		return text
+	}
+	cut := limit
+	for cut > 0 && !utf8.RuneStart(text[cut]) {
+		cut--
+	}
+	return text[:cut]
+}
+
+func (e *ToolExecutor) fileSearch(ctx context.Context, execution *ToolExecution, scope *ToolScope) error {
+	var args struct {
+		Query string `json:"query"`
+	}
+	if err := json.Unmarshal([]byte(execution.Arguments), &args); err != nil {
+		return fmt.Errorf("invalid arguments: %w", err)
+	}
+	execution.Query = strings.TrimSpace(args.Query)
+	if execution.Query == "" {
+		return fmt.Errorf("query is required")
+	}
+	if scope == nil || len(scope.VectorStoreIDs) == 0 {
+		return fmt.Errorf("no vector stores configured for file_search")
+	}
+
+	stores, err := e.vectorStoreService.FindVectorStores(ctx, file.Owner{UserID: scope.UserID}, scope.VectorStoreIDs)
+	if err != nil {
+		return err
+	}
+	results, err := e.vectorStoreService.Search(ctx, stores, execution.Query, nil, scope.MaxNumResults)
+	if err != nil {
+		return err
+	}
+	execution.FileResults = results
+	execution.FirstCitation = scope.citations + 1
+	scope.citations += len(results)
+
+	if len(results) == 0 {
+		execution.Output = "No matching content was found in the files."
+		return nil
+	}
+	var output strings.Builder
+	output.WriteString("Cite the sources you use with their number in square brackets, for example [1].\n\n")
+	for i, result := range results {
+		fmt.Fprintf(&output, "[%d] %s\n%s\n\n", execution.FirstCitation+i, result.Filename, result.Text)
+	}
+	execution.Output = output.String()
+	return nil
+}
+
+// stringField reads a string value from a loosely typed search result
+func stringField(values map[string]interface{}, key string) string {
+	if value, ok := values[key].(string); ok {
+		return value
+	}
+	return ""
+}
+
+// ConvertTools converts request tools to chat completion tools. Built-in tools are exposed to the model as functions.
+func ConvertTools(tools []requesttypes.Tool) []openai.Tool {
+	if len(tools) == 0 {
+		return nil
+	}
+
+	converted := make([]openai.Tool, 0, len(tools))
+	for _, tool := range tools {
+		switch tool.Type {
+		case BuiltinToolWebSearch:
+			converted = append(converted, openai.Tool{
+				Type: openai.ToolTypeFunction,
+				Function: &openai.FunctionDefinition{
+					Name:        BuiltinToolWebSearch,
+					Description: "Search the web and return the top results with title, url and snippet.",
+					Parameters: map[string]any{
+						"type": "object",
+						"properties": map[string]any{
+							"query": map[string]any{"type": "string", "description": "The search query"},
+							"num":   map[string]any{"type": "integer", "description": "Number of results to return"},
+						},
+						"required": []string{"query"},
+					},
+				},
+			})
+		case BuiltinToolFetchWebpage:
+			converted = append(converted, openai.Tool{
+				Type: openai.ToolTypeFunction,
+				Function: &openai.FunctionDefinition{
+					Name:        BuiltinToolFetchWebpage,
+					Description: "Fetch a webpage and return its content as text.",
+					Parameters: map[string]any{
+						"type": "object",
+						"properties": map[string]any{
+							"url": map[string]any{"type": "string", "description": "The URL of the page to fetch"},
+						},
+						"required": []string{"url"},
+					},
+				},
+			})
+		case BuiltinToolFileSearch:
+			converted = append(converted, openai.Tool{
+				Type: openai.ToolTypeFunction,
+				Function: &openai.FunctionDefinition{
+					Name:        BuiltinToolFileSearch,
+					Description: "Search the uploaded files and return the most relevant passages, numbered for citation.",
+					Parameters: map[string]any{
+						"type": "object",
+						"properties": map[string]any{
+							"query": map[string]any{"type": "string", "description": "The search query"},
+						},
+						"required": []string{"query"},
+					},
+				},
+			})
+		default:
+			if tool.Function == nil {
+				continue
+			}
+			definition := &openai.FunctionDefinition{
+				Name:       tool.Function.Name,
+				Parameters: tool.Function.Parameters,
+			}
+			if tool.Function.Description != nil {
+				definition.Description = *tool.Function.Description
+			}
+			converted = append(converted, openai.Tool{
+				Type:     openai.ToolTypeFunction,
+				Function: definition,
+			})
+		}
+	}
+	return converted
+}
+
+// ConvertToolChoice converts a request tool choice to the chat completion format
+func ConvertToolChoice(choice *requesttypes.ToolChoice) any {
+	if choice == nil {
+		return nil
+	}
+	if choice.Type == "function" && choice.Function != nil {
+		return openai.ToolChoice{
+			Type: openai.ToolTypeFunction,
+			Function: openai.ToolFunction{
+				Name: choice.Function.Name,
+			},
+		}
+	}
+	return choice.Type
+}
+
+// toolRoundMessages builds the assistant tool call turn and the tool outputs that follow it
+func toolRoundMessages(content string, calls []openai.ToolCall, executions []*ToolExecution) []openai.ChatCompletionMessage {
+	messages := make([]openai.ChatCompletionMessage, 0, len(executions)+1)
+	messages = append(messages, openai.ChatCompletionMessage{
+		Role:      openai.ChatMessageRoleAssistant,
+		Content:   content,
+		ToolCalls: calls,
+	})
+	for _, execution := range executions {
+		messages = append(messages, openai.ChatCompletionMessage{
+			Role:       openai.ChatMessageRoleTool,
+			Content:    execution.Output,
+			Name:       execution.Name,
+			ToolCallID: execution.CallID,
+		})
+	}
+	return messages
+}
+
+// toolExecutionOutput converts an execution to a response output entry
+func toolExecutionOutput(execution *ToolExecution) responsetypes.ResponseOutput {
+	if execution.Name == BuiltinToolWebSearch {
+		return responsetypes.ResponseOutput{
+			Type: responsetypes.OutputTypeWebSearch,
+			WebSearch: &responsetypes.WebSearchOutput{
+				Query:   execution.Query,
+				Results: execution.Results,
+			},
+		}
+	}
+
+	if execution.Name == BuiltinToolFileSearch {
+		return responsetypes.ResponseOutput{
+			Type: responsetypes.OutputTypeFileSearch,
+			FileSearch: &responsetypes.FileSearchOutput{
+				Query:   execution.Query,
+				Results: fileSearchResults(execution),
+			},
+		}
+	}
+
+	call := responsetypes.FunctionCallResult{
+		Name:   execution.Name,
+		Result: execution.Output,
+	}
+	_ = json.Unmarshal([]byte(execution.Arguments), &call.Arguments)
+	if execution.Err != nil {
+		call.Error = ptr.ToString(execution.Err.Error())
+	}
+	return responsetypes.ResponseOutput{
+		Type: responsetypes.OutputTypeFunctionCalls,
+		FunctionCalls: &responsetypes.FunctionCallsOutput{
+			Calls: []responsetypes.FunctionCallResult{call},
+		},
+	}
+}
+
+// fileSearchResults converts the retrieved chunks of a file_search call
+func fileSearchResults(execution *ToolExecution) []responsetypes.FileSearchResult {
+	results := make([]responsetypes.FileSearchResult, 0, len(execution.FileResults))
+	for _, result := range execution.FileResults {
+		results = append(results, responsetypes.FileSearchResult{
+			FileID:  result.FilePublicID,
+			Name:    result.Filename,
+			Snippet: result.Text,
+			Score:   result.Score,
+		})
+	}
+	return results
+}
+
+// fileCitations annotates the [n] markers the model wrote for file_search results.
+// When the model cited nothing, every retrieved file is cited at the end of the text.
+func fileCitations(text string, executions []*ToolExecution) []responsetypes.Annotation {
+	annotations := []responsetypes.Annotation{}
+	var cited []*vectorstore.SearchResult
+	for _, execution := range executions {
+		for i, result := range execution.FileResults {
+			cited = append(cited, result)
+			marker := fmt.Sprintf("[%d]", execution.FirstCitation+i)
+			for offset := 0; ; {
+				index := strings.Index(text[offset:], marker)
+				if index < 0 {
+					break
+				}
+				start := utf8.RuneCountInString(text[:offset+index])
+				annotations = append(annotations, responsetypes.Annotation{
+					Type:       "file_citation",
+					StartIndex: start,
+					EndIndex:   start + len(marker),
+					Text:       marker,
+					FileID:     result.FilePublicID,
+					Filename:   result.Filename,
+				})
+				offset += index + len(marker)
+			}
+		}
+	}
+	if len(annotations) > 0 || len(cited) == 0 {
+		sort.SliceStable(annotations, func(i, j int) bool {
+			return annotations[i].StartIndex < annotations[j].StartIndex
+		})
+		return annotations
+	}
+
+	end := utf8.RuneCountInString(text)
+	seen := make(map[string]bool)
+	for _, result := range cited {
+		if seen[result.FilePublicID] {
+			continue
+		}
+		seen[result.FilePublicID] = true
+		annotations = append(annotations, responsetypes.Annotation{
+			Type:       "file_citation",
+			StartIndex: end,
+			EndIndex:   end,
+			FileID:     result.FilePublicID,
+			Filename:   result.Filename,
+		})
+	}
+	return annotations
+}
+
+// recordToolRound appends a completed tool round to the chat request and persists it on the conversation
+func (h *ResponseModelService) recordToolRound(ctx context.Context, conv *conversation.Conversation, responseID *uint, request *openai.ChatCompletionRequest, content string, calls []openai.ToolCall, executions []*ToolExecution) {
+	messages := toolRoundMessages(content, calls, executions)
+	request.Messages = append(request.Messages, messages...)
+
+	if conv == nil {
+		return
+	}
+	success, err := h.responseService.AppendMessagesToConversation(ctx, conv, messages, responseID)
+	if !success {
+		logger.GetLogger().Errorf("Failed to append tool round to conversation: %s - %s", err.GetCode(), err.Error())
+	}
+}
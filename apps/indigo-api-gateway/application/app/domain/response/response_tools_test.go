@@ -0,0 +1,178 @@
+package response
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"unicode/utf8"
+
+	openai "github.com/sashabaranov/go-openai"
+	"menlo.ai/indigo-api-gateway/app/domain/conversation"
+	domainmodel "menlo.ai/indigo-api-gateway/app/domain/model"
+	"menlo.ai/indigo-api-gateway/app/infrastructure/inference"
+	requesttypes "menlo.ai/indigo-api-gateway/app/interfaces/http/requests"
+	"menlo.ai/indigo-api-gateway/app/utils/ptr"
+)
+
+func TestToolCallAccumulatorAdd(t *testing.T) {
+	var acc toolCallAccumulator
+	acc.add([]openai.ToolCall{{Index: ptr.ToInt(0), ID: "call_1", Function: openai.FunctionCall{Name: "web_search", Arguments: `{"que`}}})
+	acc.add([]openai.ToolCall{{Index: ptr.ToInt(1), ID: "call_2", Function: openai.FunctionCall{Name: "fetch_webpage", Arguments: `{"url"`}}})
+	acc.add([]openai.ToolCall{{Index: ptr.ToInt(0), Function: openai.FunctionCall{Arguments: `ry":"go"}`}}})
+	acc.add([]openai.ToolCall{{Index: ptr.ToInt(1), Function: openai.FunctionCall{Arguments: `:"https://go.dev"}`}}})
+
+	if len(acc.calls) != 2 {
+		t.Fatalf("expected 2 calls, got %d", len(acc.calls))
+	}
+	if acc.calls[0].ID != "call_1" || acc.calls[0].Function.Name != "web_search" || acc.calls[0].Function.Arguments != `{"query":"go"}` {
+		t.Fatalf("unexpected first call %+v", acc.calls[0])
+	}
+	if acc.calls[1].ID != "call_2" || acc.calls[1].Function.Arguments != `{"url":"https://go.dev"}` {
+		t.Fatalf("unexpected second call %+v", acc.calls[1])
+	}
+	if acc.calls[0].Type != openai.ToolTypeFunction {
+		t.Fatalf("expected function type, got %q", acc.calls[0].Type)
+	}
+}
+
+func TestToolCallAccumulatorAddWithoutIndex(t *testing.T) {
+	var acc toolCallAccumulator
+	acc.add([]openai.ToolCall{{ID: "call_1", Function: openai.FunctionCall{Name: "web_search", Arguments: `{"query":`}}})
+	acc.add([]openai.ToolCall{{Function: openai.FunctionCall{Arguments: `"go"}`}}})
+	acc.add([]openai.ToolCall{{ID: "call_2", Function: openai.FunctionCall{Name: "file_search", Arguments: `{}`}}})
+
+	if len(acc.calls) != 2 {
+		t.Fatalf("expected a new call for each id, got %d", len(acc.calls))
+	}
+	if acc.calls[0].Function.Arguments != `{"query":"go"}` {
+		t.Fatalf("expected fragments without id to extend the last call, got %q", acc.calls[0].Function.Arguments)
+	}
+	if acc.calls[1].ID != "call_2" || acc.calls[1].Function.Name != "file_search" {
+		t.Fatalf("unexpected second call %+v", acc.calls[1])
+	}
+}
+
+func TestConvertTools(t *testing.T) {
+	description := "Look up the weather"
+	tools := ConvertTools([]requesttypes.Tool{
+		{Type: BuiltinToolWebSearch},
+		{Type: BuiltinToolFetchWebpage},
+		{Type: BuiltinToolFileSearch, VectorStoreIDs: []string{"vs_1"}},
+		{Type: "function", Function: &requesttypes.FunctionDefinition{Name: "get_weather", Description: &description}},
+		{Type: "function"},
+	})
+
+	names := make([]string, 0, len(tools))
+	for _, tool := range tools {
+		if tool.Type != openai.ToolTypeFunction || tool.Function == nil {
+			t.Fatalf("expected every tool to be a function, got %+v", tool)
+		}
+		names = append(names, tool.Function.Name)
+	}
+	expected := []string{BuiltinToolWebSearch, BuiltinToolFetchWebpage, BuiltinToolFileSearch, "get_weather"}
+	if fmt.Sprint(names) != fmt.Sprint(expected) {
+		t.Fatalf("expected %v, got %v", expected, names)
+	}
+	if tools[3].Function.Description != description {
+		t.Fatalf("expected the function description to be kept, got %q", tools[3].Function.Description)
+	}
+
+	if ConvertTools(nil) != nil {
+		t.Fatal("expected no tools for an empty request")
+	}
+}
+
+func TestConvertToolChoice(t *testing.T) {
+	if ConvertToolChoice(nil) != nil {
+		t.Fatal("expected nil for a missing tool choice")
+	}
+	if choice := ConvertToolChoice(&requesttypes.ToolChoice{Type: "auto"}); choice != "auto" {
+		t.Fatalf("expected auto, got %v", choice)
+	}
+
+	choice, ok := ConvertToolChoice(&requesttypes.ToolChoice{
+		Type:     "function",
+		Function: &requesttypes.FunctionChoice{Name: "get_weather"},
+	}).(openai.ToolChoice)
+	if !ok || choice.Type != openai.ToolTypeFunction || choice.Function.Name != "get_weather" {
+		t.Fatalf("unexpected function choice %+v", choice)
+	}
+}
+
+func TestTruncateUTF8(t *testing.T) {
+	text := "aé日本"
+	for limit := 0; limit <= len(text)+1; limit++ {
+		truncated := truncateUTF8(text, limit)
+		if len(truncated) > limit || !utf8.ValidString(truncated) {
+			t.Fatalf("limit %d: got %q", limit, truncated)
+		}
+	}
+	if truncateUTF8(text, 4) != "aé" {
+		t.Fatalf("expected the partial character to be dropped, got %q", truncateUTF8(text, 4))
+	}
+}
+
+func TestAppendToolItemMessageSkipsEmptyContent(t *testing.T) {
+	messages := appendToolItemMessage(nil, &conversation.Item{})
+	if len(messages) != 0 {
+		t.Fatalf("expected an item without content to be skipped, got %+v", messages)
+	}
+}
+
+func TestNonStreamResponseStopsAtToolStepLimit(t *testing.T) {
+	var calls atomic.Int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		// the model keeps asking for a page, the empty url fails without reaching the fetch backend
+		_ = json.NewEncoder(w).Encode(openai.ChatCompletionResponse{
+			Choices: []openai.ChatCompletionChoice{{
+				Message: openai.ChatCompletionMessage{
+					Role: openai.ChatMessageRoleAssistant,
+					ToolCalls: []openai.ToolCall{{
+						ID:       fmt.Sprintf("call_%d", n),
+						Type:     openai.ToolTypeFunction,
+						Function: openai.FunctionCall{Name: BuiltinToolFetchWebpage, Arguments: `{"url":""}`},
+					}},
+				},
+				FinishReason: openai.FinishReasonToolCalls,
+			}},
+		})
+	}))
+	defer upstream.Close()
+
+	responseRepo := &memoryResponseRepo{responses: map[uint]*Response{1: {ID: 1, PublicID: "resp_1", Status: ResponseStatusRunning}}}
+	service := NewNonStreamModelService(&ResponseModelService{
+		responseService:   NewResponseService(responseRepo, nil, nil, nil),
+		inferenceProvider: inference.NewInferenceProvider(),
+		cancellations:     NewCancellationRegistry(),
+		toolExecutor:      NewToolExecutor(nil, nil),
+	})
+
+	request := &requesttypes.CreateResponseRequest{Model: "test-model", Tools: []requesttypes.Tool{{Type: BuiltinToolFetchWebpage}}}
+	chatRequest := &openai.ChatCompletionRequest{
+		Model:    "test-model",
+		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "read go.dev"}},
+		Tools:    ConvertTools(request.Tools),
+	}
+	provider := &domainmodel.Provider{DisplayName: "test", BaseURL: upstream.URL}
+
+	_, err := service.CreateNonStreamResponse(context.Background(), request, provider, "", nil, responseRepo.responses[1], chatRequest)
+	if err != nil {
+		t.Fatalf("unexpected error %s: %s", err.GetCode(), err.Error())
+	}
+	if got := calls.Load(); got != MaxToolSteps+1 {
+		t.Fatalf("expected %d upstream calls, got %d", MaxToolSteps+1, got)
+	}
+	// every executed round is fed back as an assistant turn followed by its tool output
+	if len(chatRequest.Messages) != 1+2*MaxToolSteps {
+		t.Fatalf("expected %d messages, got %d", 1+2*MaxToolSteps, len(chatRequest.Messages))
+	}
+	if responseRepo.responses[1].Status != ResponseStatusCompleted {
+		t.Fatalf("expected the response to complete, got %s", responseRepo.responses[1].Status)
+	}
+}
@@ -1,11 +1,14 @@
 package response
 
 import (
+	"encoding/json"
 	"fmt"
 	"strings"
 
 	"menlo.ai/indigo-api-gateway/app/domain/common"
+	"menlo.ai/indigo-api-gateway/app/domain/vectorstore"
 	requesttypes "menlo.ai/indigo-api-gateway/app/interfaces/http/requests"
+	"menlo.ai/indigo-api-gateway/app/utils/jsonschema"
 )
 
 // ValidationError represents a validation error
@@ -31,6 +34,10 @@ func ValidateCreateResponseRequest(req *requesttypes.CreateResponseRequest) (boo
 		return false, common.NewErrorWithMessage("input validation error", "b2c3d4e5-f6g7-8901-bcde-f23456789012")
 	}
 
+	if req.Background != nil && *req.Background && req.Stream != nil && *req.Stream {
+		return false, common.NewErrorWithMessage("background responses cannot be streamed", "8c0e2a4b-6d8f-4c0e-a2a4-b6d8f0c2e4a6")
+	}
+
 	// Validate temperature
 	if req.Temperature != nil {
 		if *req.Temperature < 0 || *req.Temperature > 2 {
@@ -230,6 +237,9 @@ func convertToCreateResponseInput(inputMap map[string]any) *requesttypes.CreateR
 				if data, ok := imageData["data"].(string); ok {
 					imageInput.Data = &data
 				}
+				if fileID, ok := imageData["file_id"].(string); ok {
+					imageInput.FileID = &fileID
+				}
 				if detail, ok := imageData["detail"].(string); ok {
 					imageInput.Detail = &detail
 				}
@@ -490,19 +500,23 @@ func validateCreateResponseInput(input *requesttypes.CreateResponseInput) *[]Val
 func validateImageInput(image *requesttypes.ImageInput) *[]ValidationError {
 	var errors []ValidationError
 
-	// Either URL or data must be provided
-	if image.URL == nil && image.Data == nil {
+	// Exactly one of URL, data or file_id must be provided
+	sources := 0
+	for _, source := range []*string{image.URL, image.Data, image.FileID} {
+		if source != nil {
+			sources++
+		}
+	}
+	if sources == 0 {
 		errors = append(errors, ValidationError{
 			Field:   "input.image",
-			Message: "either url or data must be provided for image input",
+			Message: "one of url, data or file_id must be provided for image input",
 		})
 	}
-
-	// Both URL and data cannot be provided
-	if image.URL != nil && image.Data != nil {
+	if sources > 1 {
 		errors = append(errors, ValidationError{
 			Field:   "input.image",
-			Message: "either url or data must be provided, not both",
+			Message: "only one of url, data or file_id can be provided",
 		})
 	}
 
@@ -709,13 +723,32 @@ func validateResponseFormat(format *requesttypes.ResponseFormat) *[]ValidationEr
 			Field:   "response_format.type",
 			Message: "type is required",
 		})
-	} else if format.Type != "text" && format.Type != "json_object" {
+	} else if format.Type != "text" && format.Type != "json_object" && format.Type != "json_schema" {
 		errors = append(errors, ValidationError{
 			Field:   "response_format.type",
-			Message: "type must be one of: text, json_object",
+			Message: "type must be one of: text, json_object, json_schema",
 		})
 	}
 
+	if format.Type == "json_schema" {
+		if format.JSONSchema == nil || format.JSONSchema.Schema == nil {
+			errors = append(errors, ValidationError{
+				Field:   "response_format.json_schema.schema",
+				Message: "schema is required for json_schema response formats",
+			})
+		} else if raw, err := json.Marshal(format.JSONSchema.Schema); err != nil {
+			errors = append(errors, ValidationError{
+				Field:   "response_format.json_schema.schema",
+				Message: err.Error(),
+			})
+		} else if _, err := jsonschema.Compile(raw); err != nil {
+			errors = append(errors, ValidationError{
+				Field:   "response_format.json_schema.schema",
+				Message: err.Error(),
+			})
+		}
+	}
+
 	if len(errors) > 0 {
 		return &errors
 	}
@@ -733,10 +766,24 @@ func validateTools(tools []requesttypes.Tool) *[]ValidationError {
 				Field:   fmt.Sprintf("tools[%d].type", i),
 				Message: "type is required",
 			})
-		} else if tool.Type != "function" {
+		} else if tool.Type != "function" && !IsBuiltinTool(tool.Type) {
 			errors = append(errors, ValidationError{
 				Field:   fmt.Sprintf("tools[%d].type", i),
-				Message: "type must be 'function'",
+				Message: "type must be one of: function, web_search, fetch_webpage, file_search",
+			})
+		}
+
+		if tool.Type == BuiltinToolFileSearch && len(tool.VectorStoreIDs) == 0 {
+			errors = append(errors, ValidationError{
+				Field:   fmt.Sprintf("tools[%d].vector_store_ids", i),
+				Message: "vector_store_ids is required for file_search tools",
+			})
+		}
+
+		if tool.MaxNumResults != nil && (*tool.MaxNumResults < 1 || *tool.MaxNumResults > vectorstore.MaxSearchResults) {
+			errors = append(errors, ValidationError{
+				Field:   fmt.Sprintf("tools[%d].max_num_results", i),
+				Message: fmt.Sprintf("max_num_results must be between 1 and %d", vectorstore.MaxSearchResults),
 			})
 		}
 
@@ -0,0 +1,36 @@
+package response
+
+import (
+	"context"
+
+	"menlo.ai/indigo-api-gateway/app/domain/webhook"
+	"menlo.ai/indigo-api-gateway/app/utils/logger"
+)
+
+// responseEventTypes maps the final statuses of a response to the webhook event announcing them
+var responseEventTypes = map[ResponseStatus]webhook.EventType{
+	ResponseStatusCompleted:  webhook.EventTypeResponseCompleted,
+	ResponseStatusFailed:     webhook.EventTypeResponseFailed,
+	ResponseStatusCancelled:  webhook.EventTypeResponseCancelled,
+	ResponseStatusIncomplete: webhook.EventTypeResponseIncomplete,
+}
+
+// PublishResponseEvent notifies the webhook endpoints of the project once a background response reached a final status.
+// Failures are logged, they never change the outcome of the response.
+func (s *ResponseService) PublishResponseEvent(ctx context.Context, responseID uint) {
+	responseEntity, err := s.GetResponseByID(ctx, responseID)
+	if err != nil {
+		logger.GetLogger().Errorf("failed to load response %d for webhooks: %s", responseID, err.Error())
+		return
+	}
+	if responseEntity.ProjectID == nil || responseEntity.Background == nil || !*responseEntity.Background {
+		return
+	}
+	eventType, ok := responseEventTypes[responseEntity.Status]
+	if !ok {
+		return
+	}
+	if err := s.webhookService.Publish(ctx, *responseEntity.ProjectID, eventType, s.ConvertDomainResponseToAPIResponse(responseEntity)); err != nil {
+		logger.GetLogger().Errorf("failed to publish %s for response %s: %s", eventType, responseEntity.PublicID, err.Error())
+	}
+}
@@ -0,0 +1,277 @@
+package response
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"menlo.ai/indigo-api-gateway/app/domain/conversation"
+	domainmodel "menlo.ai/indigo-api-gateway/app/domain/model"
+	"menlo.ai/indigo-api-gateway/app/domain/organization"
+	responsetypes "menlo.ai/indigo-api-gateway/app/interfaces/http/responses"
+	"menlo.ai/indigo-api-gateway/app/utils/idgen"
+	"menlo.ai/indigo-api-gateway/app/utils/logger"
+	"menlo.ai/indigo-api-gateway/config/environment_variables"
+)
+
+const (
+	defaultWorkerConcurrency = 4
+	workerPollInterval       = time.Second
+	// jobLease is how long a job stays assigned to a worker without a heartbeat
+	jobLease          = time.Minute
+	heartbeatInterval = jobLease / 4
+	// maxJobAttempts bounds how often a job is claimed, the claim reaching it fails the job instead of running it again
+	maxJobAttempts = 3
+)
+
+// cancelPollInterval is how often a running job checks whether it was cancelled from another replica, a
+// cancellation is otherwise only seen at the next heartbeat
+var cancelPollInterval = 2 * time.Second
+
+// ResponseWorker processes the background responses queued in the ResponseJobRepository
+type ResponseWorker struct {
+	jobRepo               ResponseJobRepository
+	responseService       *ResponseService
+	conversationService   *conversation.ConversationService
+	providerRegistry      *domainmodel.ProviderRegistryService
+	nonStreamModelService *NonStreamModelService
+	workerID              string
+	running               sync.WaitGroup
+	// cancelJobs interrupts the jobs still running when the shutdown deadline passes
+	cancelJobs context.CancelFunc
+}
+
+func NewResponseWorker(
+	jobRepo ResponseJobRepository,
+	responseService *ResponseService,
+	conversationService *conversation.ConversationService,
+	providerRegistry *domainmodel.ProviderRegistryService,
+	nonStreamModelService *NonStreamModelService,
+) *ResponseWorker {
+	hostname, _ := os.Hostname()
+	suffix, _ := idgen.GenerateSecureID("worker", 8)
+	return &ResponseWorker{
+		jobRepo:               jobRepo,
+		responseService:       responseService,
+		conversationService:   conversationService,
+		providerRegistry:      providerRegistry,
+		nonStreamModelService: nonStreamModelService,
+		workerID:              fmt.Sprintf("%s-%s", hostname, suffix),
+	}
+}
+
+// Start launches the worker goroutines, they stop claiming jobs when ctx is cancelled and finish their
+// current job, see Shutdown
+func (w *ResponseWorker) Start(ctx context.Context) {
+	concurrency := environment_variables.Current().RESPONSE_WORKER_CONCURRENCY
+	if concurrency <= 0 {
+		concurrency = defaultWorkerConcurrency
+	}
+	logger.GetLogger().Infof("starting %d background response workers as %s", concurrency, w.workerID)
+	jobsCtx, cancelJobs := context.WithCancel(context.WithoutCancel(ctx))
+	w.cancelJobs = cancelJobs
+	for i := 0; i < concurrency; i++ {
+		w.running.Add(1)
+		go func() {
+			defer w.running.Done()
+			w.run(ctx, jobsCtx)
+		}()
+	}
+}
+
+// Shutdown waits, once the context of Start is cancelled, for the workers to finish their jobs; the jobs
+// still running when ctx is done are interrupted and picked up again once their lease expires
+func (w *ResponseWorker) Shutdown(ctx context.Context) {
+	stopped := make(chan struct{})
+	go func() {
+		w.running.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		return
+	case <-ctx.Done():
+	}
+	if w.cancelJobs != nil {
+		w.cancelJobs()
+	}
+	<-stopped
+}
+
+func (w *ResponseWorker) run(ctx context.Context, jobsCtx context.Context) {
+	for {
+		job, err := w.jobRepo.Claim(ctx, w.workerID, jobLease)
+		if err != nil && ctx.Err() == nil {
+			logger.GetLogger().Errorf("failed to claim background response job: %v", err)
+		}
+		if job != nil {
+			w.process(jobsCtx, job)
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(workerPollInterval):
+		}
+	}
+}
+
+func (w *ResponseWorker) process(ctx context.Context, job *ResponseJob) {
+	log := logger.GetLogger()
+	responseEntity, err := w.responseService.GetResponseByID(ctx, job.ResponseID)
+	if err != nil {
+		log.Errorf("background response job %d: %s", job.ID, err.Error())
+		w.finish(job, ResponseJobStatusFailed, err.Error())
+		return
+	}
+	if responseEntity.Status.IsTerminal() {
+		// cancelled while queued or finished before the previous worker died
+		status := ResponseJobStatusCompleted
+		if responseEntity.Status == ResponseStatusCancelled {
+			status = ResponseJobStatusCancelled
+		}
+		w.finish(job, status, "")
+		return
+	}
+	if job.Attempts >= maxJobAttempts {
+		w.fail(ctx, job, responseEntity, "f4b6d8e0-2a4c-4f6b-8d0e-2a4c6f8b0d2f", "background response exceeded the maximum number of attempts")
+		return
+	}
+
+	var payload ResponseJobPayload
+	if jsonErr := json.Unmarshal([]byte(job.Payload), &payload); jsonErr != nil {
+		w.fail(ctx, job, responseEntity, "b6d8f0a2-4c6e-4b8d-a0a2-4c6e8b0d2f4a", "invalid background response payload")
+		return
+	}
+
+	var conv *conversation.Conversation
+	if responseEntity.ConversationID != nil {
+		conv, err = w.conversationService.GetConversationByID(ctx, *responseEntity.ConversationID)
+		if err != nil {
+			w.fail(ctx, job, responseEntity, err.GetCode(), err.Error())
+			return
+		}
+	}
+	// the tools, like web_search, act for the organization of the job too
+	organizationID := jobOrganizationID(job)
+	ctx = organization.WithOrganizationID(ctx, organizationID)
+	provider, providerErr := w.providerRegistry.GetProviderForModel(ctx, responseEntity.Model, organizationID, providerProjectIDs(job.ProjectID))
+	if providerErr != nil {
+		log.Warnf("Failed to find provider for model '%s': %v, using default provider", responseEntity.Model, providerErr)
+	}
+
+	if success, err := w.responseService.UpdateResponseStatus(ctx, responseEntity.ID, ResponseStatusRunning); !success {
+		log.Errorf("background response %s: failed to mark running: %s", responseEntity.PublicID, err.Error())
+	}
+
+	jobCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var leaseLost atomic.Bool
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		w.heartbeat(jobCtx, job, done, cancel, &leaseLost)
+	}()
+
+	_, callErr := w.nonStreamModelService.CreateNonStreamResponse(jobCtx, &payload.Request, provider, "", conv, responseEntity, &payload.ChatCompletionRequest)
+	close(done)
+	wg.Wait()
+
+	if callErr == nil {
+		w.finish(job, ResponseJobStatusCompleted, "")
+		w.responseService.PublishResponseEvent(context.Background(), responseEntity.ID)
+		return
+	}
+	if leaseLost.Load() {
+		log.Warnf("background response %s: lease lost, leaving the job to its new worker", responseEntity.PublicID)
+		return
+	}
+	if latest, err := w.responseService.GetResponseByID(context.Background(), responseEntity.ID); err == nil && latest.Status == ResponseStatusCancelled {
+		// the cancellation event was published by CancelResponse
+		w.finish(job, ResponseJobStatusCancelled, "")
+		return
+	}
+	if ctx.Err() != nil {
+		// shutting down, the job is picked up again once its lease expires
+		return
+	}
+	w.fail(ctx, job, responseEntity, callErr.GetCode(), callErr.Error())
+}
+
+// jobOrganizationID returns the organization the job was queued for, jobs queued before it was recorded use the default organization
+func jobOrganizationID(job *ResponseJob) uint {
+	if job.OrganizationID == 0 {
+		return organization.DEFAULT_ORGANIZATION.ID
+	}
+	return job.OrganizationID
+}
+
+// heartbeat extends the lease while the job runs and cancels it when requested from another replica, the
+// cancellation flag is polled every cancelPollInterval between two heartbeats
+func (w *ResponseWorker) heartbeat(ctx context.Context, job *ResponseJob, done <-chan struct{}, cancel context.CancelFunc, leaseLost *atomic.Bool) {
+	ticker := time.NewTicker(heartbeatInterval)
+	defer ticker.Stop()
+	cancelTicker := time.NewTicker(cancelPollInterval)
+	defer cancelTicker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ctx.Done():
+			return
+		case <-cancelTicker.C:
+			cancelRequested, err := w.jobRepo.IsCancelRequested(ctx, job.ID)
+			if err != nil {
+				logger.GetLogger().Errorf("background response job %d: cancellation check failed: %v", job.ID, err)
+				continue
+			}
+			if cancelRequested {
+				cancel()
+				return
+			}
+		case <-ticker.C:
+			cancelRequested, ok, err := w.jobRepo.Heartbeat(ctx, job.ID, w.workerID, jobLease)
+			if err != nil {
+				logger.GetLogger().Errorf("background response job %d: heartbeat failed: %v", job.ID, err)
+				continue
+			}
+			if !ok {
+				leaseLost.Store(true)
+				cancel()
+				return
+			}
+			if cancelRequested {
+				cancel()
+				return
+			}
+		}
+	}
+}
+
+func (w *ResponseWorker) fail(ctx context.Context, job *ResponseJob, responseEntity *Response, code string, message string) {
+	if success, err := w.responseService.UpdateResponseError(ctx, responseEntity.ID, responsetypes.ResponseError{
+		Code:    code,
+		Message: message,
+	}); !success {
+		logger.GetLogger().Errorf("background response %s: failed to store error: %s", responseEntity.PublicID, err.Error())
+	}
+	w.finish(job, ResponseJobStatusFailed, message)
+	w.responseService.PublishResponseEvent(context.Background(), responseEntity.ID)
+}
+
+func (w *ResponseWorker) finish(job *ResponseJob, status ResponseJobStatus, lastError string) {
+	var lastErrorPtr *string
+	if lastError != "" {
+		lastErrorPtr = &lastError
+	}
+	// the job outcome is recorded even when the worker is shutting down
+	if err := w.jobRepo.Finish(context.Background(), job.ID, w.workerID, status, lastErrorPtr); err != nil {
+		logger.GetLogger().Errorf("background response job %d: failed to finish: %v", job.ID, err)
+	}
+}
@@ -0,0 +1,243 @@
+package response
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"menlo.ai/indigo-api-gateway/app/domain/organization"
+	"menlo.ai/indigo-api-gateway/app/utils/ptr"
+)
+
+// memoryResponseRepo keeps responses by ID, only the lookups used by the worker are implemented
+type memoryResponseRepo struct {
+	ResponseRepository
+	mu        sync.Mutex
+	responses map[uint]*Response
+}
+
+func (m *memoryResponseRepo) FindByID(ctx context.Context, id uint) (*Response, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if r, ok := m.responses[id]; ok {
+		cp := *r
+		return &cp, nil
+	}
+	return nil, nil
+}
+
+func (m *memoryResponseRepo) Update(ctx context.Context, r *Response) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	cp := *r
+	m.responses[r.ID] = &cp
+	return nil
+}
+
+type finishedJob struct {
+	status    ResponseJobStatus
+	lastError *string
+}
+
+type memoryJobRepo struct {
+	ResponseJobRepository
+	mu              sync.Mutex
+	finished        map[uint]finishedJob
+	cancelRequested map[uint]bool
+}
+
+func (m *memoryJobRepo) IsCancelRequested(ctx context.Context, jobID uint) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.cancelRequested[jobID], nil
+}
+
+func (m *memoryJobRepo) Finish(ctx context.Context, jobID uint, workerID string, status ResponseJobStatus, lastError *string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.finished[jobID] = finishedJob{status: status, lastError: lastError}
+	return nil
+}
+
+func newTestWorker(responses ...*Response) (*ResponseWorker, *memoryResponseRepo, *memoryJobRepo) {
+	responseRepo := &memoryResponseRepo{responses: make(map[uint]*Response)}
+	for _, r := range responses {
+		responseRepo.responses[r.ID] = r
+	}
+	jobRepo := &memoryJobRepo{finished: make(map[uint]finishedJob), cancelRequested: make(map[uint]bool)}
+	worker := &ResponseWorker{
+		jobRepo:         jobRepo,
+		responseService: NewResponseService(responseRepo, nil, nil, nil),
+		workerID:        "worker-test",
+	}
+	return worker, responseRepo, jobRepo
+}
+
+func TestResponseWorkerFailsJobAtMaxAttempts(t *testing.T) {
+	worker, responseRepo, jobRepo := newTestWorker(&Response{ID: 1, PublicID: "resp_1", Status: ResponseStatusQueued})
+
+	worker.process(context.Background(), &ResponseJob{ID: 10, ResponseID: 1, Attempts: maxJobAttempts, Payload: "{}"})
+
+	finished, ok := jobRepo.finished[10]
+	if !ok || finished.status != ResponseJobStatusFailed {
+		t.Fatalf("expected the job to fail on attempt %d, got %+v", maxJobAttempts, finished)
+	}
+	stored := responseRepo.responses[1]
+	if stored.Status != ResponseStatusFailed || stored.Error == nil || !strings.Contains(*stored.Error, "maximum number of attempts") {
+		t.Fatalf("expected the response to fail with the attempts error, got %s %v", stored.Status, stored.Error)
+	}
+}
+
+func TestResponseWorkerFailsInvalidPayload(t *testing.T) {
+	worker, responseRepo, jobRepo := newTestWorker(&Response{ID: 1, PublicID: "resp_1", Status: ResponseStatusQueued})
+
+	worker.process(context.Background(), &ResponseJob{ID: 10, ResponseID: 1, Attempts: 1, Payload: "not json"})
+
+	if finished := jobRepo.finished[10]; finished.status != ResponseJobStatusFailed {
+		t.Fatalf("expected the job to fail, got %+v", finished)
+	}
+	if stored := responseRepo.responses[1]; stored.Status != ResponseStatusFailed {
+		t.Fatalf("expected the response to fail, got %s", stored.Status)
+	}
+}
+
+func TestResponseWorkerSkipsFinishedResponses(t *testing.T) {
+	worker, _, jobRepo := newTestWorker(
+		&Response{ID: 1, PublicID: "resp_1", Status: ResponseStatusCancelled},
+		&Response{ID: 2, PublicID: "resp_2", Status: ResponseStatusCompleted},
+	)
+
+	// Terminal responses are finished without running, whatever the attempt
+	worker.process(context.Background(), &ResponseJob{ID: 10, ResponseID: 1, Attempts: maxJobAttempts})
+	worker.process(context.Background(), &ResponseJob{ID: 20, ResponseID: 2, Attempts: 1})
+
+	if finished := jobRepo.finished[10]; finished.status != ResponseJobStatusCancelled {
+		t.Fatalf("expected the job of the cancelled response to be cancelled, got %+v", finished)
+	}
+	if finished := jobRepo.finished[20]; finished.status != ResponseJobStatusCompleted {
+		t.Fatalf("expected the job of the completed response to be completed, got %+v", finished)
+	}
+}
+
+func TestResponseWorkerSeesCancellationBeforeTheHeartbeat(t *testing.T) {
+	previous := cancelPollInterval
+	cancelPollInterval = 5 * time.Millisecond
+	defer func() { cancelPollInterval = previous }()
+	worker, _, jobRepo := newTestWorker()
+
+	jobCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var leaseLost atomic.Bool
+	stopped := make(chan struct{})
+	go func() {
+		worker.heartbeat(jobCtx, &ResponseJob{ID: 10}, make(chan struct{}), cancel, &leaseLost)
+		close(stopped)
+	}()
+
+	jobRepo.mu.Lock()
+	jobRepo.cancelRequested[10] = true
+	jobRepo.mu.Unlock()
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatalf("expected the cancellation to be seen within the poll interval, the heartbeat runs every %s", heartbeatInterval)
+	}
+	if jobCtx.Err() == nil || leaseLost.Load() {
+		t.Fatalf("expected the job to be cancelled without losing its lease")
+	}
+}
+
+func TestResponseWorkerShutdownWaitsForJobs(t *testing.T) {
+	worker, _, _ := newTestWorker()
+	worker.running.Add(1)
+	released := make(chan struct{})
+	go func() {
+		<-released
+		worker.running.Done()
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	jobsCtx, cancelJobs := context.WithCancel(context.Background())
+	worker.cancelJobs = func() {
+		cancelJobs()
+		close(released)
+	}
+	worker.Shutdown(ctx)
+	if jobsCtx.Err() == nil {
+		t.Fatal("expected the running jobs to be interrupted once the shutdown deadline passed")
+	}
+}
+
+func TestResponseWorkerShutdownDrainsJobs(t *testing.T) {
+	worker, _, _ := newTestWorker()
+	worker.running.Add(1)
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		worker.running.Done()
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	interrupted := false
+	worker.cancelJobs = func() { interrupted = true }
+	worker.Shutdown(ctx)
+	if interrupted {
+		t.Fatal("expected the jobs finishing before the deadline not to be interrupted")
+	}
+}
+
+// orphanSweepRepo records the arguments of FailOrphaned
+type orphanSweepRepo struct {
+	ResponseRepository
+	updatedBefore time.Time
+	errorJSON     string
+}
+
+func (r *orphanSweepRepo) FailOrphaned(ctx context.Context, updatedBefore time.Time, errorJSON string) (int64, error) {
+	r.updatedBefore = updatedBefore
+	r.errorJSON = errorJSON
+	return 2, nil
+}
+
+func TestFailOrphanedResponses(t *testing.T) {
+	repo := &orphanSweepRepo{}
+	service := NewResponseService(repo, nil, nil, nil)
+
+	failed, err := service.FailOrphanedResponses(context.Background())
+	if err != nil {
+		t.Fatalf("fail orphaned responses: %v", err)
+	}
+	if failed != 2 {
+		t.Fatalf("expected the count of the repository, got %d", failed)
+	}
+	// responses still served by other replicas are newer than the cutoff
+	if age := time.Since(repo.updatedBefore); age < orphanedResponseAge || age > orphanedResponseAge+time.Minute {
+		t.Fatalf("expected a cutoff %s ago, got %s", orphanedResponseAge, age)
+	}
+	if !strings.Contains(repo.errorJSON, "interrupted by a server restart") {
+		t.Fatalf("unexpected error %s", repo.errorJSON)
+	}
+}
+
+func TestJobProviderScope(t *testing.T) {
+	previous := organization.DEFAULT_ORGANIZATION
+	organization.DEFAULT_ORGANIZATION = &organization.Organization{ID: 1}
+	defer func() { organization.DEFAULT_ORGANIZATION = previous }()
+
+	if got := jobOrganizationID(&ResponseJob{}); got != 1 {
+		t.Fatalf("expected jobs without an organization to use the default organization, got %d", got)
+	}
+	if got := jobOrganizationID(&ResponseJob{OrganizationID: 7}); got != 7 {
+		t.Fatalf("expected organization 7, got %d", got)
+	}
+	if ids := providerProjectIDs(nil); ids != nil {
+		t.Fatalf("expected no projects, got %v", ids)
+	}
+	if ids := providerProjectIDs(ptr.ToUint(4)); len(ids) != 1 || ids[0] != 4 {
+		t.Fatalf("expected project 4, got %v", ids)
+	}
+}
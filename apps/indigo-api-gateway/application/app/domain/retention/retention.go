@@ -0,0 +1,37 @@
+package retention
+
+import (
+	"context"
+	"time"
+)
+
+// Scope selects the conversations of an organization a retention rule applies to
+type Scope struct {
+	OrganizationID uint
+	// WorkspacePublicIDs restricts the rule to conversations in these workspaces
+	WorkspacePublicIDs []string
+	// ExcludeWorkspacePublicIDs skips conversations in workspaces that carry their own rule
+	ExcludeWorkspacePublicIDs []string
+}
+
+type RetentionRepository interface {
+	// ArchiveInactiveConversations archives up to limit active conversations not updated since inactiveSince
+	ArchiveInactiveConversations(ctx context.Context, scope Scope, inactiveSince time.Time, limit int) (int64, error)
+	// PurgeDeletedConversations permanently removes up to limit conversations deleted before deletedBefore,
+	// together with their items and responses
+	PurgeDeletedConversations(ctx context.Context, scope Scope, deletedBefore time.Time, limit int) (int64, error)
+	// PurgeDeletedItems permanently removes up to limit trashed items deleted before deletedBefore
+	PurgeDeletedItems(ctx context.Context, scope Scope, deletedBefore time.Time, limit int) (int64, error)
+	// PurgeOrphanResponses permanently removes up to limit responses of the organization whose conversation no longer exists
+	// or was deleted before deletedBefore; a zero deletedBefore only matches conversations that no longer exist
+	PurgeOrphanResponses(ctx context.Context, organizationID uint, deletedBefore time.Time, limit int) (int64, error)
+}
+
+// Report summarizes a retention run for one organization
+type Report struct {
+	OrganizationID        uint  `json:"organization_id"`
+	ArchivedConversations int64 `json:"archived_conversations"`
+	PurgedConversations   int64 `json:"purged_conversations"`
+	PurgedItems           int64 `json:"purged_items"`
+	PurgedResponses       int64 `json:"purged_responses"`
+}
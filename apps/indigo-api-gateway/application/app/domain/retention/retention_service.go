@@ -0,0 +1,173 @@
+package retention
+
+import (
+	"context"
+	"time"
+
+	"menlo.ai/indigo-api-gateway/app/domain/common"
+	"menlo.ai/indigo-api-gateway/app/domain/organization"
+	"menlo.ai/indigo-api-gateway/app/domain/settings"
+	"menlo.ai/indigo-api-gateway/app/utils/logger"
+	"menlo.ai/indigo-api-gateway/app/utils/ptr"
+)
+
+const (
+	// BatchSize bounds the rows changed by a single statement so purges do not hold long locks
+	BatchSize = 500
+	// maxBatchesPerRule caps the work of one run, the remainder is picked up by the next run
+	maxBatchesPerRule = 200
+)
+
+type RetentionService struct {
+	repo                RetentionRepository
+	organizationService *organization.OrganizationService
+	settingsService     *settings.Service
+}
+
+func NewRetentionService(
+	repo RetentionRepository,
+	organizationService *organization.OrganizationService,
+	settingsService *settings.Service,
+) *RetentionService {
+	return &RetentionService{
+		repo:                repo,
+		organizationService: organizationService,
+		settingsService:     settingsService,
+	}
+}
+
+// rule is a resolved retention policy for one scope
+type rule struct {
+	scope                 Scope
+	archiveAfterDays      int
+	purgeDeletedAfterDays int
+}
+
+// Run applies the retention policy of every enabled organization
+func (s *RetentionService) Run(ctx context.Context) ([]*Report, *common.Error) {
+	organizations, err := s.organizationService.FindOrganizations(ctx, organization.OrganizationFilter{
+		Enabled: ptr.ToBool(true),
+	}, nil)
+	if err != nil {
+		return nil, common.NewError(err, "3e7b9d1f-4a6c-4e8a-b2d4-6f8a0c2e4b6d")
+	}
+
+	reports := make([]*Report, 0, len(organizations))
+	for _, org := range organizations {
+		report, runErr := s.RunForOrganization(ctx, org.ID)
+		if runErr != nil {
+			// One organization failing must not block the others
+			logger.GetLogger().Errorf("retention run failed for organization %d: %s - %s", org.ID, runErr.GetCode(), runErr.Error())
+			continue
+		}
+		reports = append(reports, report)
+	}
+	return reports, nil
+}
+
+// RunForOrganization archives inactive conversations and purges expired data of one organization
+func (s *RetentionService) RunForOrganization(ctx context.Context, organizationID uint) (*Report, *common.Error) {
+	policy, err := s.settingsService.GetRetentionPolicy(ctx, organizationID)
+	if err != nil {
+		return nil, common.NewError(err, "8c0e2a4b-6d8f-4a1c-9e3b-5d7f9b1c3e5a")
+	}
+
+	report := &Report{OrganizationID: organizationID}
+	now := time.Now()
+	for _, r := range resolveRules(organizationID, policy) {
+		if r.archiveAfterDays > 0 {
+			cutoff := now.AddDate(0, 0, -r.archiveAfterDays)
+			count, runErr := s.inBatches(ctx, func(ctx context.Context) (int64, error) {
+				return s.repo.ArchiveInactiveConversations(ctx, r.scope, cutoff, BatchSize)
+			})
+			report.ArchivedConversations += count
+			if runErr != nil {
+				return report, common.NewError(runErr, "1b3d5f7a-9c1e-4b3d-8f5a-7c9e1b3d5f7a")
+			}
+		}
+		if r.purgeDeletedAfterDays > 0 {
+			cutoff := now.AddDate(0, 0, -r.purgeDeletedAfterDays)
+			count, runErr := s.inBatches(ctx, func(ctx context.Context) (int64, error) {
+				return s.repo.PurgeDeletedConversations(ctx, r.scope, cutoff, BatchSize)
+			})
+			report.PurgedConversations += count
+			if runErr != nil {
+				return report, common.NewError(runErr, "6f8a0c2e-4b6d-4f8a-a0c2-e4b6d8f0a2c4")
+			}
+			count, runErr = s.inBatches(ctx, func(ctx context.Context) (int64, error) {
+				return s.repo.PurgeDeletedItems(ctx, r.scope, cutoff, BatchSize)
+			})
+			report.PurgedItems += count
+			if runErr != nil {
+				return report, common.NewError(runErr, "a2c4e6f8-0b2d-4e6a-8c0f-2b4d6e8a0c3f")
+			}
+		}
+	}
+
+	if policy.PurgeOrphanResponses {
+		// Responses of soft-deleted conversations follow the organization purge period, they are kept while it is disabled
+		var deletedBefore time.Time
+		if policy.PurgeDeletedAfterDays > 0 {
+			deletedBefore = now.AddDate(0, 0, -policy.PurgeDeletedAfterDays)
+		}
+		count, runErr := s.inBatches(ctx, func(ctx context.Context) (int64, error) {
+			return s.repo.PurgeOrphanResponses(ctx, organizationID, deletedBefore, BatchSize)
+		})
+		report.PurgedResponses += count
+		if runErr != nil {
+			return report, common.NewError(runErr, "0d2f4b6a-8c0e-4d2f-b6a8-c0e2d4f6b8a0")
+		}
+	}
+	return report, nil
+}
+
+// inBatches repeats step until it affects fewer rows than a full batch
+func (s *RetentionService) inBatches(ctx context.Context, step func(ctx context.Context) (int64, error)) (int64, error) {
+	var total int64
+	for i := 0; i < maxBatchesPerRule; i++ {
+		if err := ctx.Err(); err != nil {
+			return total, err
+		}
+		affected, err := step(ctx)
+		total += affected
+		if err != nil {
+			return total, err
+		}
+		if affected < BatchSize {
+			break
+		}
+	}
+	return total, nil
+}
+
+// resolveRules splits the policy into the organization default and one rule per workspace override
+func resolveRules(organizationID uint, policy *settings.RetentionPolicy) []rule {
+	rules := make([]rule, 0, len(policy.Overrides)+1)
+	overridden := make([]string, 0, len(policy.Overrides))
+	for _, override := range policy.Overrides {
+		overridden = append(overridden, override.WorkspacePublicID)
+		r := rule{
+			scope: Scope{
+				OrganizationID:     organizationID,
+				WorkspacePublicIDs: []string{override.WorkspacePublicID},
+			},
+			archiveAfterDays:      policy.ArchiveAfterDays,
+			purgeDeletedAfterDays: policy.PurgeDeletedAfterDays,
+		}
+		if override.ArchiveAfterDays != nil {
+			r.archiveAfterDays = *override.ArchiveAfterDays
+		}
+		if override.PurgeDeletedAfterDays != nil {
+			r.purgeDeletedAfterDays = *override.PurgeDeletedAfterDays
+		}
+		rules = append(rules, r)
+	}
+	return append(rules, rule{
+		scope: Scope{
+			OrganizationID:            organizationID,
+			ExcludeWorkspacePublicIDs: overridden,
+		},
+		archiveAfterDays:      policy.ArchiveAfterDays,
+		purgeDeletedAfterDays: policy.PurgeDeletedAfterDays,
+	})
+}
@@ -0,0 +1,127 @@
+package secrets
+
+import (
+	"context"
+
+	"menlo.ai/indigo-api-gateway/app/domain/common"
+	"menlo.ai/indigo-api-gateway/app/utils/crypto"
+	"menlo.ai/indigo-api-gateway/app/utils/logger"
+)
+
+// SecretKind is a place where encrypted secrets are stored
+type SecretKind string
+
+const (
+	SecretKindProviderAPIKey   SecretKind = "provider_api_key"
+	SecretKindMCPServerHeaders SecretKind = "mcp_server_headers"
+	SecretKindSetting          SecretKind = "setting"
+	SecretKindWebhookSecret    SecretKind = "webhook_secret"
+)
+
+// SecretKinds lists the kinds in the order they are rotated
+var SecretKinds = []SecretKind{SecretKindProviderAPIKey, SecretKindMCPServerHeaders, SecretKindSetting, SecretKindWebhookSecret}
+
+const rotationBatchSize = 100
+
+// EncryptedSecret is a stored secret, Field names it among the secrets of the same row
+type EncryptedSecret struct {
+	Kind  SecretKind
+	ID    uint
+	Field string
+	Value string
+	// Plaintext marks the secrets stored in clear before they were encrypted
+	Plaintext bool
+}
+
+type EncryptedSecretRepository interface {
+	// FindSecrets reads at most limit rows of a kind after afterID, deleted ones included, and returns their
+	// secrets with the ID of the last row read, 0 when there are no more rows
+	FindSecrets(ctx context.Context, kind SecretKind, afterID uint, limit int) ([]*EncryptedSecret, uint, error)
+	// ReplaceSecret stores the new ciphertext of the secret unless its value changed since it was read
+	ReplaceSecret(ctx context.Context, secret *EncryptedSecret, ciphertext string) (bool, error)
+}
+
+// RotationReport counts the secrets of a kind by outcome
+type RotationReport struct {
+	Kind    SecretKind `json:"kind"`
+	Scanned int        `json:"scanned"`
+	Rotated int        `json:"rotated"`
+	Current int        `json:"current"`
+	// Changed secrets were updated by someone else during the rotation, they are encrypted with the primary key
+	Changed int `json:"changed"`
+	Failed  int `json:"failed"`
+}
+
+// RotationService re-encrypts the stored secrets with the primary master key, so the previous master keys
+// and MODEL_PROVIDER_SECRET values can be retired once no ciphertext uses them
+type RotationService struct {
+	repo EncryptedSecretRepository
+}
+
+func NewRotationService(repo EncryptedSecretRepository) *RotationService {
+	return &RotationService{repo: repo}
+}
+
+// Rotate re-encrypts the secrets that are not encrypted with the primary master key; it can be run again
+// after a failure, secrets that fail to decrypt are reported and left as they are
+func (s *RotationService) Rotate(ctx context.Context) ([]RotationReport, *common.Error) {
+	keyring, err := crypto.DefaultKeyring()
+	if err != nil {
+		return nil, common.NewError(err, "8b3f1d6e-2a9c-4e7b-b5d1-7c4a9e2f6b38")
+	}
+	reports := make([]RotationReport, 0, len(SecretKinds))
+	for _, kind := range SecretKinds {
+		report := RotationReport{Kind: kind}
+		afterID := uint(0)
+		for {
+			batch, lastID, err := s.repo.FindSecrets(ctx, kind, afterID, rotationBatchSize)
+			if err != nil {
+				return reports, common.NewError(err, "4d9a2e7c-6f1b-4a3d-8e5c-2b7f9d4a1e63")
+			}
+			for _, secret := range batch {
+				report.Scanned++
+				s.rotate(ctx, keyring, secret, &report)
+			}
+			if lastID == 0 {
+				break
+			}
+			afterID = lastID
+		}
+		reports = append(reports, report)
+	}
+	return reports, nil
+}
+
+func (s *RotationService) rotate(ctx context.Context, keyring *crypto.Keyring, secret *EncryptedSecret, report *RotationReport) {
+	log := logger.GetLogger().WithField("kind", secret.Kind).WithField("id", secret.ID).WithField("field", secret.Field)
+	if !secret.Plaintext && !keyring.NeedsRotation(secret.Value) {
+		report.Current++
+		return
+	}
+	plaintext := secret.Value
+	if !secret.Plaintext {
+		var err error
+		if plaintext, err = keyring.Decrypt(ctx, secret.Value); err != nil {
+			log.Errorf("failed to decrypt secret encrypted with key %q: %v", crypto.KeyID(secret.Value), err)
+			report.Failed++
+			return
+		}
+	}
+	ciphertext, err := keyring.Encrypt(ctx, plaintext)
+	if err != nil {
+		log.Errorf("failed to encrypt secret: %v", err)
+		report.Failed++
+		return
+	}
+	replaced, err := s.repo.ReplaceSecret(ctx, secret, ciphertext)
+	if err != nil {
+		log.Errorf("failed to store rotated secret: %v", err)
+		report.Failed++
+		return
+	}
+	if !replaced {
+		report.Changed++
+		return
+	}
+	report.Rotated++
+}
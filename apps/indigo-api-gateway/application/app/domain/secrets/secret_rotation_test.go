@@ -0,0 +1,131 @@
+package secrets_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"menlo.ai/indigo-api-gateway/app/domain/secrets"
+	"menlo.ai/indigo-api-gateway/app/utils/crypto"
+	"menlo.ai/indigo-api-gateway/config/environment_variables"
+)
+
+// memorySecretRepo serves the secrets in ID order and records the replaced ciphertexts
+type memorySecretRepo struct {
+	secrets  map[secrets.SecretKind][]*secrets.EncryptedSecret
+	replaced map[uint]string
+	// changed are the IDs whose value changed since they were read
+	changed map[uint]bool
+	// broken are the IDs whose update fails
+	broken map[uint]bool
+}
+
+func (r *memorySecretRepo) FindSecrets(ctx context.Context, kind secrets.SecretKind, afterID uint, limit int) ([]*secrets.EncryptedSecret, uint, error) {
+	var batch []*secrets.EncryptedSecret
+	lastID := uint(0)
+	for _, secret := range r.secrets[kind] {
+		if secret.ID > afterID && len(batch) < limit {
+			batch = append(batch, secret)
+			lastID = secret.ID
+		}
+	}
+	return batch, lastID, nil
+}
+
+func (r *memorySecretRepo) ReplaceSecret(ctx context.Context, secret *secrets.EncryptedSecret, ciphertext string) (bool, error) {
+	if r.broken[secret.ID] {
+		return false, errors.New("database is gone")
+	}
+	if r.changed[secret.ID] {
+		return false, nil
+	}
+	r.replaced[secret.ID] = ciphertext
+	return true, nil
+}
+
+func encryptWith(t *testing.T, secret string, plaintext string) string {
+	t.Helper()
+	provider, err := crypto.NewSecretKeyProvider(secret, nil)
+	if err != nil {
+		t.Fatalf("provider: %v", err)
+	}
+	ciphertext, err := crypto.NewKeyring(provider, nil).Encrypt(context.Background(), plaintext)
+	if err != nil {
+		t.Fatalf("encrypt: %v", err)
+	}
+	return ciphertext
+}
+
+func TestRotate(t *testing.T) {
+	environment_variables.Override("secret_rotation_test", func(env *environment_variables.EnvironmentVariable) {
+		env.MODEL_PROVIDER_SECRET = "rotation-new"
+		env.MODEL_PROVIDER_PREVIOUS_SECRETS = []string{"rotation-old"}
+	})
+	ctx := context.Background()
+	legacy, err := crypto.EncryptString("rotation-old", "legacy-key")
+	if err != nil {
+		t.Fatalf("legacy encrypt: %v", err)
+	}
+	providerKey := func(id uint, value string) *secrets.EncryptedSecret {
+		return &secrets.EncryptedSecret{Kind: secrets.SecretKindProviderAPIKey, ID: id, Field: "encrypted_api_key", Value: value}
+	}
+	repo := &memorySecretRepo{
+		secrets: map[secrets.SecretKind][]*secrets.EncryptedSecret{
+			secrets.SecretKindProviderAPIKey: {
+				providerKey(1, encryptWith(t, "rotation-new", "current-key")),
+				providerKey(2, encryptWith(t, "rotation-old", "old-key")),
+				providerKey(3, legacy),
+				providerKey(4, encryptWith(t, "retired-secret", "lost-key")),
+				providerKey(5, encryptWith(t, "rotation-old", "edited-key")),
+				providerKey(6, encryptWith(t, "rotation-old", "unsaved-key")),
+			},
+			secrets.SecretKindSetting: {
+				{Kind: secrets.SecretKindSetting, ID: 7, Field: "password", Value: "smtp-password", Plaintext: true},
+			},
+		},
+		replaced: map[uint]string{},
+		changed:  map[uint]bool{5: true},
+		broken:   map[uint]bool{6: true},
+	}
+
+	reports, rotateErr := secrets.NewRotationService(repo).Rotate(ctx)
+	if rotateErr != nil {
+		t.Fatalf("rotate: %v", rotateErr)
+	}
+	if len(reports) != len(secrets.SecretKinds) {
+		t.Fatalf("expected a report per kind, got %+v", reports)
+	}
+	expected := map[secrets.SecretKind]secrets.RotationReport{
+		secrets.SecretKindProviderAPIKey: {Kind: secrets.SecretKindProviderAPIKey, Scanned: 6, Rotated: 2, Current: 1, Changed: 1, Failed: 2},
+		secrets.SecretKindSetting:        {Kind: secrets.SecretKindSetting, Scanned: 1, Rotated: 1},
+	}
+	for _, report := range reports {
+		want, ok := expected[report.Kind]
+		if !ok {
+			want = secrets.RotationReport{Kind: report.Kind}
+		}
+		if report != want {
+			t.Fatalf("expected %+v, got %+v", want, report)
+		}
+	}
+
+	keyring, err := crypto.DefaultKeyring()
+	if err != nil {
+		t.Fatalf("keyring: %v", err)
+	}
+	for id, plaintext := range map[uint]string{2: "old-key", 3: "legacy-key", 7: "smtp-password"} {
+		ciphertext, ok := repo.replaced[id]
+		if !ok {
+			t.Fatalf("expected secret %d to be replaced", id)
+		}
+		if keyring.NeedsRotation(ciphertext) {
+			t.Fatalf("expected secret %d to use the primary key, got %q", id, ciphertext)
+		}
+		if decrypted, err := keyring.Decrypt(ctx, ciphertext); err != nil || decrypted != plaintext {
+			t.Fatalf("secret %d: got %q, %v", id, decrypted, err)
+		}
+	}
+	if len(repo.replaced) != 3 {
+		t.Fatalf("expected only the rotated secrets to be replaced, got %v", repo.replaced)
+	}
+}
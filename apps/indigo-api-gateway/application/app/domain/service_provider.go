@@ -4,16 +4,25 @@ import (
 	"github.com/google/wire"
 	"menlo.ai/indigo-api-gateway/app/domain/apikey"
 	"menlo.ai/indigo-api-gateway/app/domain/auth"
+	"menlo.ai/indigo-api-gateway/app/domain/completioncache"
+	"menlo.ai/indigo-api-gateway/app/domain/contextwindow"
 	"menlo.ai/indigo-api-gateway/app/domain/conversation"
+	"menlo.ai/indigo-api-gateway/app/domain/conversationtitle"
 	"menlo.ai/indigo-api-gateway/app/domain/cron"
+	"menlo.ai/indigo-api-gateway/app/domain/file"
 	"menlo.ai/indigo-api-gateway/app/domain/invite"
+	"menlo.ai/indigo-api-gateway/app/domain/mcp/federatedmcp"
+	"menlo.ai/indigo-api-gateway/app/domain/mcp/mcpactivity"
 	"menlo.ai/indigo-api-gateway/app/domain/mcp/serpermcp"
 	domainmodel "menlo.ai/indigo-api-gateway/app/domain/model"
 	"menlo.ai/indigo-api-gateway/app/domain/organization"
 	"menlo.ai/indigo-api-gateway/app/domain/project"
 	"menlo.ai/indigo-api-gateway/app/domain/response"
+	"menlo.ai/indigo-api-gateway/app/domain/retention"
 	"menlo.ai/indigo-api-gateway/app/domain/settings"
 	"menlo.ai/indigo-api-gateway/app/domain/user"
+	"menlo.ai/indigo-api-gateway/app/domain/vectorstore"
+	"menlo.ai/indigo-api-gateway/app/domain/webhook"
 	"menlo.ai/indigo-api-gateway/app/domain/workspace"
 )
 
@@ -26,15 +35,31 @@ var ServiceProvider = wire.NewSet(
 	user.NewService,
 	conversation.NewService,
 	workspace.NewWorkspaceService,
+	file.NewFileService,
+	vectorstore.NewVectorStoreService,
+	wire.Bind(new(conversation.WorkspaceAccessProvider), new(*workspace.WorkspaceService)),
 	domainmodel.NewProviderModelService,
 	domainmodel.NewModelCatalogService,
 	domainmodel.NewProviderRegistryService,
+	contextwindow.NewContextWindowService,
+	conversationtitle.NewTitleService,
 	response.NewResponseService,
 	response.NewResponseModelService,
 	response.NewStreamModelService,
 	response.NewNonStreamModelService,
+	response.NewCancellationRegistry,
+	response.NewStreamBuffer,
+	response.NewToolExecutor,
+	response.NewResponseWorker,
 	serpermcp.NewSerperService,
+	federatedmcp.NewFederatedMCPService,
+	mcpactivity.NewActivityService,
+	retention.NewRetentionService,
 	cron.NewCronService,
 	settings.NewService,
 	settings.NewAuditService,
+	webhook.NewWebhookService,
+	webhook.NewWebhookDispatcher,
+	vectorstore.NewVectorStoreIndexer,
+	completioncache.NewCompletionCacheService,
 )
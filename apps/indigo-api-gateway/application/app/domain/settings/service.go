@@ -4,8 +4,11 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/url"
 	"strings"
 	"time"
+
+	"menlo.ai/indigo-api-gateway/app/utils/crypto"
 )
 
 type Service struct {
@@ -58,12 +61,20 @@ func (s *Service) GetSMTPSettings(ctx context.Context, organizationID uint) (*SM
 	if hasPassword, ok := payload["has_password"].(bool); ok {
 		result.HasPassword = hasPassword
 	}
-	if password, ok := payload["password"].(string); ok {
+	if password, ok := payload[PayloadSMTPLegacyPassword].(string); ok {
 		result.Password = password
 		if password != "" {
 			result.HasPassword = true
 		}
 	}
+	if encrypted, ok := payload[PayloadSMTPPassword].(string); ok && encrypted != "" {
+		password, err := decryptSettingSecret(ctx, encrypted)
+		if err != nil {
+			return nil, err
+		}
+		result.Password = password
+		result.HasPassword = true
+	}
 	return result, nil
 }
 
@@ -100,11 +111,16 @@ func (s *Service) UpdateSMTPSettings(ctx context.Context, organizationID uint, i
 		existingPayload = existing.Payload
 	}
 
-	var existingPassword string
+	var encryptedPassword string
 	hasPassword := false
 	if existingPayload != nil {
-		if value, ok := existingPayload["password"].(string); ok {
-			existingPassword = value
+		if value, ok := existingPayload[PayloadSMTPPassword].(string); ok {
+			encryptedPassword = value
+		} else if value, ok := existingPayload[PayloadSMTPLegacyPassword].(string); ok && value != "" {
+			// encrypt the password saved in clear
+			if encryptedPassword, err = encryptSettingSecret(ctx, value); err != nil {
+				return nil, err
+			}
 		}
 		if value, ok := existingPayload["has_password"].(bool); ok {
 			hasPassword = value
@@ -120,12 +136,15 @@ func (s *Service) UpdateSMTPSettings(ctx context.Context, organizationID uint, i
 		"updated_at": time.Now().UTC().Format(time.RFC3339),
 	}
 	if input.Password != nil {
-		passwordValue := strings.TrimSpace(*input.Password)
-		payload["password"] = passwordValue
-		hasPassword = passwordValue != ""
-	} else {
-		payload["password"] = existingPassword
+		encryptedPassword = ""
+		if passwordValue := strings.TrimSpace(*input.Password); passwordValue != "" {
+			if encryptedPassword, err = encryptSettingSecret(ctx, passwordValue); err != nil {
+				return nil, err
+			}
+		}
+		hasPassword = encryptedPassword != ""
 	}
+	payload[PayloadSMTPPassword] = encryptedPassword
 	payload["has_password"] = hasPassword
 
 	setting := &SystemSetting{
@@ -242,3 +261,412 @@ func (s *Service) UpdateWorkspaceQuota(ctx context.Context, organizationID uint,
 		Overrides:    cleanOverrides,
 	}, nil
 }
+
+func (s *Service) defaultConversationTitleSettings() *ConversationTitleSettings {
+	return &ConversationTitleSettings{
+		Enabled: true,
+		Model:   "",
+	}
+}
+
+func (s *Service) GetConversationTitleSettings(ctx context.Context, organizationID uint) (*ConversationTitleSettings, error) {
+	setting, err := s.repo.FindByKey(ctx, organizationID, SettingKeyConversationTitle)
+	if err != nil {
+		if errors.Is(err, ErrSettingNotFound) {
+			return s.defaultConversationTitleSettings(), nil
+		}
+		return nil, err
+	}
+
+	payload := setting.Payload
+	result := s.defaultConversationTitleSettings()
+	if enabled, ok := payload["enabled"].(bool); ok {
+		result.Enabled = enabled
+	}
+	if model, ok := payload["model"].(string); ok {
+		result.Model = model
+	}
+	return result, nil
+}
+
+// UpdateConversationTitleSettingsInput updates the fields that are set and keeps the current value of the others
+type UpdateConversationTitleSettingsInput struct {
+	Enabled    *bool
+	Model      *string
+	ActorID    *uint
+	ActorEmail *string
+}
+
+func (s *Service) UpdateConversationTitleSettings(ctx context.Context, organizationID uint, input UpdateConversationTitleSettingsInput) (*ConversationTitleSettings, error) {
+	current, err := s.GetConversationTitleSettings(ctx, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	enabled := current.Enabled
+	if input.Enabled != nil {
+		enabled = *input.Enabled
+	}
+	model := current.Model
+	if input.Model != nil {
+		model = strings.TrimSpace(*input.Model)
+	}
+	if len(model) > 255 {
+		return nil, fmt.Errorf("model is too long")
+	}
+
+	payload := map[string]interface{}{
+		"enabled":    enabled,
+		"model":      model,
+		"updated_at": time.Now().UTC().Format(time.RFC3339),
+	}
+
+	setting := &SystemSetting{
+		OrganizationID: organizationID,
+		Key:            SettingKeyConversationTitle,
+		Payload:        payload,
+		LastUpdatedBy:  input.ActorID,
+		UpdatedByEmail: input.ActorEmail,
+	}
+	if err := s.repo.Upsert(ctx, setting); err != nil {
+		return nil, err
+	}
+
+	return &ConversationTitleSettings{
+		Enabled: enabled,
+		Model:   model,
+	}, nil
+}
+
+// MaxRetentionDays bounds the configurable retention periods
+const MaxRetentionDays = 36500
+
+func (s *Service) defaultRetentionPolicy() *RetentionPolicy {
+	return &RetentionPolicy{
+		ArchiveAfterDays:      0,
+		PurgeDeletedAfterDays: 0,
+		PurgeOrphanResponses:  false,
+		Overrides:             []RetentionOverride{},
+	}
+}
+
+func (s *Service) GetRetentionPolicy(ctx context.Context, organizationID uint) (*RetentionPolicy, error) {
+	setting, err := s.repo.FindByKey(ctx, organizationID, SettingKeyRetention)
+	if err != nil {
+		if errors.Is(err, ErrSettingNotFound) {
+			return s.defaultRetentionPolicy(), nil
+		}
+		return nil, err
+	}
+
+	payload := setting.Payload
+	result := s.defaultRetentionPolicy()
+	if archiveAfterDays, ok := payload["archive_after_days"].(float64); ok {
+		result.ArchiveAfterDays = int(archiveAfterDays)
+	}
+	if purgeDeletedAfterDays, ok := payload["purge_deleted_after_days"].(float64); ok {
+		result.PurgeDeletedAfterDays = int(purgeDeletedAfterDays)
+	}
+	if purgeOrphanResponses, ok := payload["purge_orphan_responses"].(bool); ok {
+		result.PurgeOrphanResponses = purgeOrphanResponses
+	}
+	if overrides, ok := payload["overrides"].([]interface{}); ok {
+		policyOverrides := make([]RetentionOverride, 0, len(overrides))
+		for _, item := range overrides {
+			entry, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			workspaceID, _ := entry["workspace_id"].(string)
+			if strings.TrimSpace(workspaceID) == "" {
+				continue
+			}
+			override := RetentionOverride{WorkspacePublicID: workspaceID}
+			if value, ok := entry["archive_after_days"].(float64); ok {
+				days := int(value)
+				override.ArchiveAfterDays = &days
+			}
+			if value, ok := entry["purge_deleted_after_days"].(float64); ok {
+				days := int(value)
+				override.PurgeDeletedAfterDays = &days
+			}
+			policyOverrides = append(policyOverrides, override)
+		}
+		result.Overrides = policyOverrides
+	}
+	return result, nil
+}
+
+type UpdateRetentionPolicyInput struct {
+	ArchiveAfterDays      int
+	PurgeDeletedAfterDays int
+	PurgeOrphanResponses  bool
+	Overrides             []RetentionOverride
+	ActorID               *uint
+	ActorEmail            *string
+}
+
+func (s *Service) UpdateRetentionPolicy(ctx context.Context, organizationID uint, input UpdateRetentionPolicyInput) (*RetentionPolicy, error) {
+	if err := validateRetentionDays("archive_after_days", &input.ArchiveAfterDays); err != nil {
+		return nil, err
+	}
+	if err := validateRetentionDays("purge_deleted_after_days", &input.PurgeDeletedAfterDays); err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool, len(input.Overrides))
+	cleanOverrides := make([]RetentionOverride, 0, len(input.Overrides))
+	for _, override := range input.Overrides {
+		workspaceID := strings.TrimSpace(override.WorkspacePublicID)
+		if workspaceID == "" {
+			continue
+		}
+		if seen[workspaceID] {
+			return nil, fmt.Errorf("duplicate override for workspace %s", workspaceID)
+		}
+		seen[workspaceID] = true
+		if err := validateRetentionDays("override archive_after_days", override.ArchiveAfterDays); err != nil {
+			return nil, err
+		}
+		if err := validateRetentionDays("override purge_deleted_after_days", override.PurgeDeletedAfterDays); err != nil {
+			return nil, err
+		}
+		cleanOverrides = append(cleanOverrides, RetentionOverride{
+			WorkspacePublicID:     workspaceID,
+			ArchiveAfterDays:      override.ArchiveAfterDays,
+			PurgeDeletedAfterDays: override.PurgeDeletedAfterDays,
+		})
+	}
+
+	payload := map[string]interface{}{
+		"archive_after_days":       input.ArchiveAfterDays,
+		"purge_deleted_after_days": input.PurgeDeletedAfterDays,
+		"purge_orphan_responses":   input.PurgeOrphanResponses,
+		"overrides":                cleanOverrides,
+		"updated_at":               time.Now().UTC().Format(time.RFC3339),
+	}
+
+	setting := &SystemSetting{
+		OrganizationID: organizationID,
+		Key:            SettingKeyRetention,
+		Payload:        payload,
+		LastUpdatedBy:  input.ActorID,
+		UpdatedByEmail: input.ActorEmail,
+	}
+	if err := s.repo.Upsert(ctx, setting); err != nil {
+		return nil, err
+	}
+
+	return &RetentionPolicy{
+		ArchiveAfterDays:      input.ArchiveAfterDays,
+		PurgeDeletedAfterDays: input.PurgeDeletedAfterDays,
+		PurgeOrphanResponses:  input.PurgeOrphanResponses,
+		Overrides:             cleanOverrides,
+	}, nil
+}
+
+func validateRetentionDays(field string, days *int) error {
+	if days == nil {
+		return nil
+	}
+	if *days < 0 {
+		return fmt.Errorf("%s must not be negative", field)
+	}
+	if *days > MaxRetentionDays {
+		return fmt.Errorf("%s must not exceed %d", field, MaxRetentionDays)
+	}
+	return nil
+}
+
+func (s *Service) defaultWebSearchSettings() *WebSearchSettings {
+	return &WebSearchSettings{
+		Backend: WebSearchBackendSerper,
+	}
+}
+
+// GetWebSearchSettings returns the web search settings with the decrypted API key
+func (s *Service) GetWebSearchSettings(ctx context.Context, organizationID uint) (*WebSearchSettings, error) {
+	setting, err := s.repo.FindByKey(ctx, organizationID, SettingKeyWebSearch)
+	if err != nil {
+		if errors.Is(err, ErrSettingNotFound) {
+			return s.defaultWebSearchSettings(), nil
+		}
+		return nil, err
+	}
+
+	payload := setting.Payload
+	result := s.defaultWebSearchSettings()
+	if backend, ok := payload["backend"].(string); ok && backend != "" {
+		result.Backend = backend
+	}
+	if baseURL, ok := payload["base_url"].(string); ok {
+		result.BaseURL = baseURL
+	}
+	if encrypted, ok := payload[PayloadWebSearchAPIKey].(string); ok && encrypted != "" {
+		apiKey, err := decryptSettingSecret(ctx, encrypted)
+		if err != nil {
+			return nil, err
+		}
+		result.APIKey = apiKey
+		result.HasAPIKey = true
+	}
+	return result, nil
+}
+
+type UpdateWebSearchSettingsInput struct {
+	Backend string
+	BaseURL string
+	// APIKey replaces the stored key when set, an empty value removes it
+	APIKey     *string
+	ActorID    *uint
+	ActorEmail *string
+}
+
+func (s *Service) UpdateWebSearchSettings(ctx context.Context, organizationID uint, input UpdateWebSearchSettingsInput) (*WebSearchSettings, error) {
+	backend := strings.ToLower(strings.TrimSpace(input.Backend))
+	switch backend {
+	case WebSearchBackendSerper, WebSearchBackendSearXNG, WebSearchBackendBrave, WebSearchBackendTavily:
+	default:
+		return nil, fmt.Errorf("backend must be one of serper, searxng, brave or tavily")
+	}
+	baseURL := strings.TrimRight(strings.TrimSpace(input.BaseURL), "/")
+	if baseURL != "" {
+		parsed, err := url.Parse(baseURL)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return nil, fmt.Errorf("base_url must be an http or https URL")
+		}
+	}
+	if backend == WebSearchBackendSearXNG && baseURL == "" {
+		return nil, fmt.Errorf("base_url is required for searxng")
+	}
+
+	encryptedKey := ""
+	existing, err := s.repo.FindByKey(ctx, organizationID, SettingKeyWebSearch)
+	if err != nil {
+		if !errors.Is(err, ErrSettingNotFound) {
+			return nil, err
+		}
+	} else if value, ok := existing.Payload[PayloadWebSearchAPIKey].(string); ok {
+		encryptedKey = value
+	}
+	if input.APIKey != nil {
+		encryptedKey = ""
+		if apiKey := strings.TrimSpace(*input.APIKey); apiKey != "" {
+			encryptedKey, err = encryptSettingSecret(ctx, apiKey)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	if encryptedKey == "" && (backend == WebSearchBackendBrave || backend == WebSearchBackendTavily) {
+		return nil, fmt.Errorf("api_key is required for %s", backend)
+	}
+
+	payload := map[string]interface{}{
+		"backend":              backend,
+		"base_url":             baseURL,
+		PayloadWebSearchAPIKey: encryptedKey,
+		"updated_at":           time.Now().UTC().Format(time.RFC3339),
+	}
+	setting := &SystemSetting{
+		OrganizationID: organizationID,
+		Key:            SettingKeyWebSearch,
+		Payload:        payload,
+		LastUpdatedBy:  input.ActorID,
+		UpdatedByEmail: input.ActorEmail,
+	}
+	if err := s.repo.Upsert(ctx, setting); err != nil {
+		return nil, err
+	}
+
+	return &WebSearchSettings{
+		Backend:   backend,
+		BaseURL:   baseURL,
+		HasAPIKey: encryptedKey != "",
+	}, nil
+}
+
+// GetCronJobOverrides returns the cron job overrides by job name
+func (s *Service) GetCronJobOverrides(ctx context.Context, organizationID uint) (map[string]CronJobOverride, error) {
+	setting, err := s.repo.FindByKey(ctx, organizationID, SettingKeyCronJobs)
+	if err != nil {
+		if errors.Is(err, ErrSettingNotFound) {
+			return map[string]CronJobOverride{}, nil
+		}
+		return nil, err
+	}
+
+	result := map[string]CronJobOverride{}
+	jobs, _ := setting.Payload["jobs"].(map[string]interface{})
+	for name, item := range jobs {
+		entry, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		override := CronJobOverride{}
+		if schedule, ok := entry["schedule"].(string); ok && schedule != "" {
+			override.Schedule = &schedule
+		}
+		if enabled, ok := entry["enabled"].(bool); ok {
+			override.Enabled = &enabled
+		}
+		if override.Schedule != nil || override.Enabled != nil {
+			result[name] = override
+		}
+	}
+	return result, nil
+}
+
+type UpdateCronJobOverrideInput struct {
+	Job string
+	// Override replaces the override of the job, an empty one restores its defaults
+	Override   CronJobOverride
+	ActorID    *uint
+	ActorEmail *string
+}
+
+func (s *Service) UpdateCronJobOverride(ctx context.Context, organizationID uint, input UpdateCronJobOverrideInput) (map[string]CronJobOverride, error) {
+	overrides, err := s.GetCronJobOverrides(ctx, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	if input.Override.Schedule != nil && strings.TrimSpace(*input.Override.Schedule) == "" {
+		input.Override.Schedule = nil
+	}
+	if input.Override.Schedule == nil && input.Override.Enabled == nil {
+		delete(overrides, input.Job)
+	} else {
+		overrides[input.Job] = input.Override
+	}
+
+	payload := map[string]interface{}{
+		"jobs":       overrides,
+		"updated_at": time.Now().UTC().Format(time.RFC3339),
+	}
+	setting := &SystemSetting{
+		OrganizationID: organizationID,
+		Key:            SettingKeyCronJobs,
+		Payload:        payload,
+		LastUpdatedBy:  input.ActorID,
+		UpdatedByEmail: input.ActorEmail,
+	}
+	if err := s.repo.Upsert(ctx, setting); err != nil {
+		return nil, err
+	}
+	return overrides, nil
+}
+
+// encryptSettingSecret encrypts a credential kept in the settings with the keyring of the configuration
+func encryptSettingSecret(ctx context.Context, plaintext string) (string, error) {
+	keyring, err := crypto.DefaultKeyring()
+	if err != nil {
+		return "", err
+	}
+	return keyring.Encrypt(ctx, plaintext)
+}
+
+func decryptSettingSecret(ctx context.Context, ciphertext string) (string, error) {
+	keyring, err := crypto.DefaultKeyring()
+	if err != nil {
+		return "", err
+	}
+	return keyring.Decrypt(ctx, ciphertext)
+}
@@ -7,8 +7,28 @@ import (
 )
 
 const (
-	SettingKeySMTP           = "smtp"
-	SettingKeyWorkspaceQuota = "workspace_quota"
+	SettingKeySMTP              = "smtp"
+	SettingKeyWorkspaceQuota    = "workspace_quota"
+	SettingKeyConversationTitle = "conversation_title"
+	SettingKeyRetention         = "conversation_retention"
+	SettingKeyWebSearch         = "web_search"
+	SettingKeyCronJobs          = "cron_jobs"
+)
+
+// Payload fields of the settings holding encrypted secrets
+const (
+	PayloadWebSearchAPIKey = "api_key_encrypted"
+	PayloadSMTPPassword    = "password_encrypted"
+	// PayloadSMTPLegacyPassword is the clear SMTP password of the settings saved before it was encrypted
+	PayloadSMTPLegacyPassword = "password"
+)
+
+// Web search backends selectable in the web search settings
+const (
+	WebSearchBackendSerper  = "serper"
+	WebSearchBackendSearXNG = "searxng"
+	WebSearchBackendBrave   = "brave"
+	WebSearchBackendTavily  = "tavily"
 )
 
 type SystemSetting struct {
@@ -47,6 +67,41 @@ type WorkspaceQuotaConfig struct {
 	Overrides    []WorkspaceQuotaOverride `json:"overrides"`
 }
 
+type ConversationTitleSettings struct {
+	Enabled bool   `json:"enabled"`
+	Model   string `json:"model"`
+}
+
+// RetentionPolicy controls how long conversations are kept; a value of 0 days disables the step
+type RetentionPolicy struct {
+	ArchiveAfterDays      int                 `json:"archive_after_days"`
+	PurgeDeletedAfterDays int                 `json:"purge_deleted_after_days"`
+	PurgeOrphanResponses  bool                `json:"purge_orphan_responses"`
+	Overrides             []RetentionOverride `json:"overrides"`
+}
+
+// RetentionOverride replaces the organization policy for conversations in a workspace, unset fields are inherited
+type RetentionOverride struct {
+	WorkspacePublicID     string `json:"workspace_id"`
+	ArchiveAfterDays      *int   `json:"archive_after_days,omitempty"`
+	PurgeDeletedAfterDays *int   `json:"purge_deleted_after_days,omitempty"`
+}
+
+// WebSearchSettings selects the backend of the web search tools. BaseURL overrides the endpoint of the backend
+// and is required for SearXNG, the Serper backend falls back to SERPER_API_KEY when no API key is set.
+type WebSearchSettings struct {
+	Backend   string `json:"backend"`
+	BaseURL   string `json:"base_url"`
+	APIKey    string `json:"-"`
+	HasAPIKey bool   `json:"has_api_key"`
+}
+
+// CronJobOverride replaces the schedule of a cron job or disables it, unset fields keep the defaults of the job
+type CronJobOverride struct {
+	Schedule *string `json:"schedule,omitempty"`
+	Enabled  *bool   `json:"enabled,omitempty"`
+}
+
 type AuditLog struct {
 	ID             uint                   `json:"id"`
 	OrganizationID uint                   `json:"organization_id"`
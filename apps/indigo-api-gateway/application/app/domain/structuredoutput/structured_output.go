@@ -0,0 +1,192 @@
+package structuredoutput
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	openai "github.com/sashabaranov/go-openai"
+	"menlo.ai/indigo-api-gateway/app/domain/common"
+	"menlo.ai/indigo-api-gateway/app/utils/jsonschema"
+	"menlo.ai/indigo-api-gateway/config/environment_variables"
+)
+
+const (
+	// MaxRepairAttempts bounds STRUCTURED_OUTPUT_REPAIR_ATTEMPTS
+	MaxRepairAttempts = 3
+	// IncompleteReason is reported in incomplete_details when the output never matched the schema
+	IncompleteReason = "json_schema_validation"
+	// maxReportedErrors keeps repair prompts and error messages short
+	maxReportedErrors = 10
+
+	repairInstruction = "Your previous reply does not match the required JSON schema:\n%s\n" +
+		"Reply again with only the corrected JSON document, without explanations or code fences."
+)
+
+// Format is the JSON output a completion has to produce
+type Format struct {
+	Name string
+	// Schema is nil for json_object, which only requires a JSON object
+	Schema *jsonschema.Schema
+}
+
+// Completer runs one upstream chat completion
+type Completer func(ctx context.Context, request openai.ChatCompletionRequest) (*openai.ChatCompletionResponse, error)
+
+// Result reports how the output of a completion was validated
+type Result struct {
+	// Repairs is the number of repair completions that were run
+	Repairs int
+	// Errors are the validation errors of the final output, empty when it is valid
+	Errors []jsonschema.ValidationError
+}
+
+func (r *Result) Valid() bool {
+	return len(r.Errors) == 0
+}
+
+// RepairAttempts returns how many repair completions may follow an invalid output
+func RepairAttempts() int {
+	attempts := environment_variables.Current().STRUCTURED_OUTPUT_REPAIR_ATTEMPTS
+	if attempts < 0 {
+		return 0
+	}
+	if attempts > MaxRepairAttempts {
+		return MaxRepairAttempts
+	}
+	return attempts
+}
+
+// FormatFromChatRequest returns the JSON format requested by response_format, nil for plain text
+func FormatFromChatRequest(request *openai.ChatCompletionRequest) (*Format, *common.Error) {
+	if request.ResponseFormat == nil {
+		return nil, nil
+	}
+	switch request.ResponseFormat.Type {
+	case openai.ChatCompletionResponseFormatTypeJSONObject:
+		return &Format{}, nil
+	case openai.ChatCompletionResponseFormatTypeJSONSchema:
+		definition := request.ResponseFormat.JSONSchema
+		if definition == nil || definition.Schema == nil {
+			return nil, common.NewErrorWithMessage("response_format.json_schema.schema is required", "4e8a2c6f-1b9d-4f3a-8c5e-7d2b9f4a1e63")
+		}
+		raw, err := json.Marshal(definition.Schema)
+		if err != nil {
+			return nil, common.NewError(err, "9b3f7d1a-5e2c-4a8b-b6d4-2f8c5a9e3b71")
+		}
+		schema, err := jsonschema.Compile(raw)
+		if err != nil {
+			return nil, common.NewErrorWithMessage(fmt.Sprintf("response_format.json_schema.schema: %s", err.Error()), "9b3f7d1a-5e2c-4a8b-b6d4-2f8c5a9e3b72")
+		}
+		return &Format{Name: definition.Name, Schema: schema}, nil
+	}
+	return nil, nil
+}
+
+// CheckStreaming rejects a json_schema format on a streamed chat completion, whose chunks reach the client before
+// the output can be validated or repaired
+func CheckStreaming(format *Format, stream bool) *common.Error {
+	if !stream || format == nil || format.Schema == nil {
+		return nil
+	}
+	return common.NewErrorWithMessage("response_format json_schema is not supported with stream, disable streaming to get a validated output", "2a6e9c3f-7d1b-4e5a-9f8c-4b2d6e1a3c97")
+}
+
+// Check validates the output and returns it without surrounding whitespace or markdown code fences
+func (f *Format) Check(content string) (string, []jsonschema.ValidationError) {
+	normalized := stripCodeFence(content)
+	if f.Schema != nil {
+		return normalized, f.Schema.ValidateJSON([]byte(normalized))
+	}
+	var object map[string]any
+	if err := json.Unmarshal([]byte(normalized), &object); err != nil || object == nil {
+		return normalized, []jsonschema.ValidationError{{Message: "output is not a JSON object"}}
+	}
+	return normalized, nil
+}
+
+// Enforce validates the output of a completion. When it does not match the format the validation errors are fed back to
+// the model for up to RepairAttempts more completions. The returned response carries the last output and the usage of
+// every completion that was run; the messages of request are left untouched.
+func Enforce(ctx context.Context, format *Format, request openai.ChatCompletionRequest, response *openai.ChatCompletionResponse, complete Completer) (*openai.ChatCompletionResponse, *Result, error) {
+	result := &Result{}
+	if format == nil || response == nil || len(response.Choices) == 0 {
+		return response, result, nil
+	}
+
+	usage := response.Usage
+	messages := append([]openai.ChatCompletionMessage{}, request.Messages...)
+	for {
+		message := &response.Choices[0].Message
+		if len(message.ToolCalls) > 0 && strings.TrimSpace(message.Content) == "" {
+			// the model is calling tools, the final output comes later
+			result.Errors = nil
+			break
+		}
+		normalized, errs := format.Check(message.Content)
+		result.Errors = errs
+		if len(errs) == 0 {
+			message.Content = normalized
+			break
+		}
+		if result.Repairs >= RepairAttempts() {
+			break
+		}
+
+		messages = append(messages,
+			openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant, Content: message.Content},
+			openai.ChatCompletionMessage{Role: openai.ChatMessageRoleUser, Content: fmt.Sprintf(repairInstruction, Describe(errs))},
+		)
+		request.Messages = messages
+		repaired, err := complete(ctx, request)
+		if err != nil {
+			return nil, result, err
+		}
+		result.Repairs++
+		if len(repaired.Choices) == 0 {
+			return nil, result, fmt.Errorf("repair completion returned no choices")
+		}
+		usage.PromptTokens += repaired.Usage.PromptTokens
+		usage.CompletionTokens += repaired.Usage.CompletionTokens
+		usage.TotalTokens += repaired.Usage.TotalTokens
+		response = repaired
+	}
+	response.Usage = usage
+	return response, result, nil
+}
+
+// Describe lists validation errors one per line
+func Describe(errs []jsonschema.ValidationError) string {
+	lines := make([]string, 0, len(errs))
+	for i, e := range errs {
+		if i == maxReportedErrors {
+			lines = append(lines, fmt.Sprintf("- and %d more", len(errs)-maxReportedErrors))
+			break
+		}
+		lines = append(lines, "- "+e.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ValidationFailedError is returned to clients when the output never matched the format
+func ValidationFailedError(result *Result) *common.Error {
+	return common.NewErrorWithMessage(
+		fmt.Sprintf("model output does not match the requested response_format after %d repair attempts:\n%s", result.Repairs, Describe(result.Errors)),
+		"c4e9a2f7-6b1d-4e8c-a3f5-8d1b6c4e9a27",
+	)
+}
+
+// stripCodeFence removes a markdown code fence wrapping the whole output
+func stripCodeFence(content string) string {
+	trimmed := strings.TrimSpace(content)
+	if !strings.HasPrefix(trimmed, "```") || !strings.HasSuffix(trimmed, "```") || len(trimmed) < 6 {
+		return trimmed
+	}
+	inner := strings.TrimSuffix(trimmed[3:], "```")
+	// drop the language tag of the opening fence
+	if newline := strings.IndexByte(inner, '\n'); newline >= 0 && !strings.ContainsAny(inner[:newline], "{[\"") {
+		inner = inner[newline+1:]
+	}
+	return strings.TrimSpace(inner)
+}
@@ -0,0 +1,149 @@
+package structuredoutput
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	openai "github.com/sashabaranov/go-openai"
+	"menlo.ai/indigo-api-gateway/app/utils/jsonschema"
+	"menlo.ai/indigo-api-gateway/config/environment_variables"
+)
+
+func setRepairAttempts(attempts int) {
+	environment_variables.Override("structured_output_test", func(env *environment_variables.EnvironmentVariable) {
+		env.STRUCTURED_OUTPUT_REPAIR_ATTEMPTS = attempts
+	})
+}
+
+func personFormat(t *testing.T) *Format {
+	t.Helper()
+	schema, err := jsonschema.Compile([]byte(`{"type":"object","properties":{"name":{"type":"string"}},"required":["name"]}`))
+	if err != nil {
+		t.Fatalf("compile schema: %v", err)
+	}
+	return &Format{Name: "person", Schema: schema}
+}
+
+func completion(content string, promptTokens int) *openai.ChatCompletionResponse {
+	return &openai.ChatCompletionResponse{
+		Choices: []openai.ChatCompletionChoice{{Message: openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant, Content: content}}},
+		Usage:   openai.Usage{PromptTokens: promptTokens, CompletionTokens: 1, TotalTokens: promptTokens + 1},
+	}
+}
+
+func TestEnforceRepairsInvalidOutput(t *testing.T) {
+	setRepairAttempts(2)
+	request := openai.ChatCompletionRequest{Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "who?"}}}
+
+	var repairRequests []openai.ChatCompletionRequest
+	complete := func(ctx context.Context, request openai.ChatCompletionRequest) (*openai.ChatCompletionResponse, error) {
+		repairRequests = append(repairRequests, request)
+		return completion("```json\n{\"name\":\"Ada\"}\n```", 20), nil
+	}
+
+	response, result, err := Enforce(context.Background(), personFormat(t), request, completion(`{"age":3}`, 10), complete)
+	if err != nil {
+		t.Fatalf("enforce: %v", err)
+	}
+	if !result.Valid() || result.Repairs != 1 {
+		t.Fatalf("expected a valid output after one repair, got %+v", result)
+	}
+	if response.Choices[0].Message.Content != `{"name":"Ada"}` {
+		t.Fatalf("expected the repaired output without its code fence, got %q", response.Choices[0].Message.Content)
+	}
+	if response.Usage.PromptTokens != 30 || response.Usage.TotalTokens != 32 {
+		t.Fatalf("expected the usage of both completions, got %+v", response.Usage)
+	}
+
+	// the repair sees the invalid answer and the validation errors, the caller's messages are untouched
+	messages := repairRequests[0].Messages
+	if len(messages) != 3 || messages[1].Content != `{"age":3}` || !strings.Contains(messages[2].Content, "name") {
+		t.Fatalf("unexpected repair messages %+v", messages)
+	}
+	if len(request.Messages) != 1 {
+		t.Fatalf("expected the request messages to be left untouched, got %d", len(request.Messages))
+	}
+}
+
+func TestEnforceReportsInvalidOutputAfterRepairs(t *testing.T) {
+	setRepairAttempts(2)
+	calls := 0
+	complete := func(ctx context.Context, request openai.ChatCompletionRequest) (*openai.ChatCompletionResponse, error) {
+		calls++
+		return completion(`not json`, 5), nil
+	}
+
+	response, result, err := Enforce(context.Background(), personFormat(t), openai.ChatCompletionRequest{}, completion(`{}`, 5), complete)
+	if err != nil {
+		t.Fatalf("enforce: %v", err)
+	}
+	if calls != 2 || result.Repairs != 2 {
+		t.Fatalf("expected 2 repairs, got %d calls and %+v", calls, result)
+	}
+	if result.Valid() {
+		t.Fatal("expected the output to stay invalid")
+	}
+	if response.Choices[0].Message.Content != "not json" {
+		t.Fatalf("expected the last output, got %q", response.Choices[0].Message.Content)
+	}
+	if message := ValidationFailedError(result).GetMessage(); !strings.Contains(message, "after 2 repair attempts") {
+		t.Fatalf("unexpected error message %q", message)
+	}
+}
+
+func TestEnforceWithoutRepairs(t *testing.T) {
+	setRepairAttempts(0)
+	complete := func(ctx context.Context, request openai.ChatCompletionRequest) (*openai.ChatCompletionResponse, error) {
+		t.Fatal("expected no repair completion")
+		return nil, nil
+	}
+
+	_, result, err := Enforce(context.Background(), &Format{}, openai.ChatCompletionRequest{}, completion(`[1,2]`, 5), complete)
+	if err != nil {
+		t.Fatalf("enforce: %v", err)
+	}
+	if result.Valid() || result.Repairs != 0 {
+		t.Fatalf("expected json_object to reject an array without repairs, got %+v", result)
+	}
+
+	// tool calls are left for the caller, the final output comes later
+	toolCall := completion("", 5)
+	toolCall.Choices[0].Message.ToolCalls = []openai.ToolCall{{ID: "call_1"}}
+	if _, result, _ := Enforce(context.Background(), personFormat(t), openai.ChatCompletionRequest{}, toolCall, complete); !result.Valid() {
+		t.Fatalf("expected a tool call turn to pass, got %+v", result)
+	}
+}
+
+func TestStripCodeFence(t *testing.T) {
+	cases := []struct {
+		content  string
+		expected string
+	}{
+		{content: `  {"a":1}  `, expected: `{"a":1}`},
+		{content: "```json\n{\"a\":1}\n```", expected: `{"a":1}`},
+		{content: "```\n{\"a\":1}\n```", expected: `{"a":1}`},
+		{content: "```{\"a\":1}```", expected: `{"a":1}`},
+		{content: "```{\"a\":\n1}```", expected: "{\"a\":\n1}"},
+		{content: "``````", expected: ""},
+		{content: "```", expected: "```"},
+		{content: "text ```json\n{}\n```", expected: "text ```json\n{}\n```"},
+	}
+	for _, c := range cases {
+		if got := stripCodeFence(c.content); got != c.expected {
+			t.Fatalf("stripCodeFence(%q) = %q, expected %q", c.content, got, c.expected)
+		}
+	}
+}
+
+func TestCheckStreaming(t *testing.T) {
+	if err := CheckStreaming(personFormat(t), true); err == nil {
+		t.Fatal("expected a streamed json_schema to be rejected")
+	}
+	if err := CheckStreaming(personFormat(t), false); err != nil {
+		t.Fatalf("expected a non-streamed json_schema to pass, got %v", err)
+	}
+	if err := CheckStreaming(&Format{}, true); err != nil {
+		t.Fatalf("expected a streamed json_object to pass, got %v", err)
+	}
+}
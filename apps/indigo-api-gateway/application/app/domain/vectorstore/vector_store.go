@@ -0,0 +1,147 @@
+package vectorstore
+
+import (
+	"context"
+	"time"
+
+	"menlo.ai/indigo-api-gateway/app/domain/query"
+)
+
+// @Enum(in_progress, completed)
+type VectorStoreStatus string
+
+const (
+	VectorStoreStatusInProgress VectorStoreStatus = "in_progress"
+	VectorStoreStatusCompleted  VectorStoreStatus = "completed"
+)
+
+// @Enum(in_progress, completed, failed)
+type VectorStoreFileStatus string
+
+const (
+	VectorStoreFileStatusInProgress VectorStoreFileStatus = "in_progress"
+	VectorStoreFileStatusCompleted  VectorStoreFileStatus = "completed"
+	VectorStoreFileStatusFailed     VectorStoreFileStatus = "failed"
+)
+
+type VectorStore struct {
+	ID        uint
+	PublicID  string
+	UserID    uint
+	ProjectID *uint
+	Name      string
+	// EmbeddingModel is fixed at creation so every chunk of a store lives in the same vector space
+	EmbeddingModel string
+	UsageBytes     int64
+	Metadata       map[string]string
+	LastActiveAt   *time.Time
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+type VectorStoreFile struct {
+	ID            uint
+	VectorStoreID uint
+	FileID        uint
+	FilePublicID  string
+	Filename      string
+	Status        VectorStoreFileStatus
+	LastError     *string
+	UsageBytes    int64
+	ChunkCount    int
+	Chunking      ChunkingStrategy
+	// LockedUntil is the lease of the indexer working on an in_progress file
+	LockedUntil *time.Time
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// ChunkingStrategy sizes are counted in tokens
+type ChunkingStrategy struct {
+	MaxChunkSizeTokens int
+	ChunkOverlapTokens int
+}
+
+// Chunk is an embedded slice of a file
+type Chunk struct {
+	ID                uint
+	VectorStoreID     uint
+	VectorStoreFileID uint
+	FilePublicID      string
+	Filename          string
+	ChunkIndex        int
+	Text              string
+	Embedding         []float32
+}
+
+// SearchResult is a chunk ranked by cosine similarity to the query
+type SearchResult struct {
+	FilePublicID string
+	Filename     string
+	ChunkIndex   int
+	Text         string
+	Score        float64
+}
+
+// FileCounts tallies the files of a store by status
+type FileCounts struct {
+	InProgress int64
+	Completed  int64
+	Failed     int64
+	Total      int64
+}
+
+type VectorStoreFilter struct {
+	ID        *uint
+	PublicID  *string
+	PublicIDs *[]string
+	UserID    *uint
+	ProjectID *uint
+}
+
+type VectorStoreFileFilter struct {
+	ID            *uint
+	VectorStoreID *uint
+	FileID        *uint
+	FilePublicID  *string
+	Status        *VectorStoreFileStatus
+}
+
+// ChunkSearch describes a nearest neighbour query over the chunks of some stores
+type ChunkSearch struct {
+	VectorStoreIDs []uint
+	// FilePublicIDs optionally restricts the search to some files
+	FilePublicIDs []string
+	Embedding     []float32
+	Limit         int
+}
+
+type VectorStoreRepository interface {
+	Create(ctx context.Context, store *VectorStore) error
+	Update(ctx context.Context, store *VectorStore) error
+	FindByFilter(ctx context.Context, filter VectorStoreFilter, pagination *query.Pagination) ([]*VectorStore, error)
+	Count(ctx context.Context, filter VectorStoreFilter) (int64, error)
+	DeleteByID(ctx context.Context, id uint) error
+}
+
+type VectorStoreFileRepository interface {
+	Create(ctx context.Context, storeFile *VectorStoreFile) error
+	Update(ctx context.Context, storeFile *VectorStoreFile) error
+	FindByFilter(ctx context.Context, filter VectorStoreFileFilter, pagination *query.Pagination) ([]*VectorStoreFile, error)
+	Count(ctx context.Context, filter VectorStoreFileFilter) (int64, error)
+	CountByStatus(ctx context.Context, vectorStoreID uint) (map[VectorStoreFileStatus]int64, error)
+	// ClaimPending leases up to limit in_progress files that no indexer holds
+	ClaimPending(ctx context.Context, limit int, lease time.Duration) ([]*VectorStoreFile, error)
+	// FinishIndexing records the outcome of an indexing run and releases the lease, it reports false when the
+	// file was detached in the meantime
+	FinishIndexing(ctx context.Context, storeFile *VectorStoreFile) (bool, error)
+	DeleteByID(ctx context.Context, id uint) error
+	DeleteByVectorStoreID(ctx context.Context, vectorStoreID uint) error
+}
+
+type ChunkRepository interface {
+	CreateBatch(ctx context.Context, chunks []*Chunk) error
+	DeleteByVectorStoreFileID(ctx context.Context, vectorStoreFileID uint) error
+	DeleteByVectorStoreID(ctx context.Context, vectorStoreID uint) error
+	Search(ctx context.Context, search ChunkSearch) ([]*SearchResult, error)
+}
@@ -0,0 +1,40 @@
+package vectorstore
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	indexerPollInterval = 2 * time.Second
+	indexerBatchSize    = 4
+)
+
+// VectorStoreIndexer extracts, chunks and embeds the files attached to vector stores outside of the request
+type VectorStoreIndexer struct {
+	vectorStoreService *VectorStoreService
+}
+
+func NewVectorStoreIndexer(vectorStoreService *VectorStoreService) *VectorStoreIndexer {
+	return &VectorStoreIndexer{vectorStoreService: vectorStoreService}
+}
+
+// Start launches the indexing loop, it stops when ctx is cancelled
+func (i *VectorStoreIndexer) Start(ctx context.Context) {
+	go i.run(ctx)
+}
+
+func (i *VectorStoreIndexer) run(ctx context.Context) {
+	for {
+		claimed := i.vectorStoreService.IndexPending(ctx, indexerBatchSize)
+		if claimed == indexerBatchSize {
+			// more files are probably queued
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(indexerPollInterval):
+		}
+	}
+}
@@ -0,0 +1,587 @@
+package vectorstore
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"menlo.ai/indigo-api-gateway/app/domain/auth"
+	"menlo.ai/indigo-api-gateway/app/domain/common"
+	"menlo.ai/indigo-api-gateway/app/domain/file"
+	domainmodel "menlo.ai/indigo-api-gateway/app/domain/model"
+	"menlo.ai/indigo-api-gateway/app/domain/organization"
+	"menlo.ai/indigo-api-gateway/app/domain/query"
+	"menlo.ai/indigo-api-gateway/app/infrastructure/inference"
+	"menlo.ai/indigo-api-gateway/app/interfaces/http/responses"
+	"menlo.ai/indigo-api-gateway/app/utils/idgen"
+	"menlo.ai/indigo-api-gateway/app/utils/logger"
+	"menlo.ai/indigo-api-gateway/app/utils/tokenizer"
+	"menlo.ai/indigo-api-gateway/config/environment_variables"
+)
+
+type VectorStoreContextKey string
+
+const (
+	VectorStoreContextKeyPublicID VectorStoreContextKey = "vector_store_id"
+	VectorStoreContextEntity      VectorStoreContextKey = "VectorStoreContextEntity"
+)
+
+const (
+	defaultEmbeddingModel = "text-embedding-3-small"
+	// Chunking defaults and bounds follow the OpenAI static chunking strategy
+	DefaultMaxChunkSizeTokens = 800
+	DefaultChunkOverlapTokens = 400
+	minChunkSizeTokens        = 100
+	maxChunkSizeTokens        = 4096
+	// embeddingBatchSize bounds the number of chunks sent in one embeddings request
+	embeddingBatchSize = 64
+	// DefaultSearchResults and MaxSearchResults bound the number of chunks returned by a search
+	DefaultSearchResults = 10
+	MaxSearchResults     = 50
+	// indexLease bounds how long an indexer holds a file before another replica may take it over
+	indexLease = 10 * time.Minute
+)
+
+type VectorStoreService struct {
+	storeRepo         VectorStoreRepository
+	storeFileRepo     VectorStoreFileRepository
+	chunkRepo         ChunkRepository
+	fileService       *file.FileService
+	providerRegistry  *domainmodel.ProviderRegistryService
+	inferenceProvider *inference.InferenceProvider
+}
+
+func NewVectorStoreService(
+	storeRepo VectorStoreRepository,
+	storeFileRepo VectorStoreFileRepository,
+	chunkRepo ChunkRepository,
+	fileService *file.FileService,
+	providerRegistry *domainmodel.ProviderRegistryService,
+	inferenceProvider *inference.InferenceProvider,
+) *VectorStoreService {
+	return &VectorStoreService{
+		storeRepo:         storeRepo,
+		storeFileRepo:     storeFileRepo,
+		chunkRepo:         chunkRepo,
+		fileService:       fileService,
+		providerRegistry:  providerRegistry,
+		inferenceProvider: inferenceProvider,
+	}
+}
+
+// EmbeddingModel returns the model new stores are indexed with
+func EmbeddingModel() string {
+	if model := strings.TrimSpace(environment_variables.Current().VECTOR_STORE_EMBEDDING_MODEL); model != "" {
+		return model
+	}
+	return defaultEmbeddingModel
+}
+
+// DefaultChunkingStrategy returns the strategy used when a request does not specify one
+func DefaultChunkingStrategy() ChunkingStrategy {
+	return ChunkingStrategy{
+		MaxChunkSizeTokens: DefaultMaxChunkSizeTokens,
+		ChunkOverlapTokens: DefaultChunkOverlapTokens,
+	}
+}
+
+// ValidateChunkingStrategy checks the bounds of a static chunking strategy
+func ValidateChunkingStrategy(strategy ChunkingStrategy) *common.Error {
+	if strategy.MaxChunkSizeTokens < minChunkSizeTokens || strategy.MaxChunkSizeTokens > maxChunkSizeTokens {
+		return common.NewErrorWithMessage(fmt.Sprintf("max_chunk_size_tokens must be between %d and %d", minChunkSizeTokens, maxChunkSizeTokens), "4b8e2d6a-1c9f-4a3e-b7d5-2e6a9c1f4b83")
+	}
+	if strategy.ChunkOverlapTokens < 0 || strategy.ChunkOverlapTokens > strategy.MaxChunkSizeTokens/2 {
+		return common.NewErrorWithMessage("chunk_overlap_tokens must be between 0 and half of max_chunk_size_tokens", "9d3a7f1e-5c2b-4e8d-a6f4-1b9e3d7c5a26")
+	}
+	return nil
+}
+
+// ownerFilter restricts a filter to the stores the owner can access
+func ownerFilter(owner file.Owner, filter VectorStoreFilter) VectorStoreFilter {
+	filter.UserID = &owner.UserID
+	if owner.ProjectID != nil {
+		filter.ProjectID = owner.ProjectID
+	}
+	return filter
+}
+
+type CreateVectorStoreInput struct {
+	Name     string
+	Metadata map[string]string
+	FileIDs  []string
+	Chunking ChunkingStrategy
+}
+
+// CreateVectorStore creates a store and queues the given files for indexing
+func (s *VectorStoreService) CreateVectorStore(ctx context.Context, owner file.Owner, input CreateVectorStoreInput) (*VectorStore, *common.Error) {
+	if err := ValidateChunkingStrategy(input.Chunking); err != nil {
+		return nil, err
+	}
+	// resolve every file first so a typo does not leave a half populated store behind
+	files := make([]*file.File, 0, len(input.FileIDs))
+	for _, fileID := range input.FileIDs {
+		f, err := s.fileService.GetFile(ctx, owner, fileID)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+	}
+
+	publicID, err := idgen.GenerateSecureID("vs", 24)
+	if err != nil {
+		return nil, common.NewError(err, "2f6c9a3e-8d1b-4e7a-b5c2-6a9d3f1e8c47")
+	}
+	now := time.Now()
+	store := &VectorStore{
+		PublicID:       publicID,
+		UserID:         owner.UserID,
+		ProjectID:      owner.ProjectID,
+		Name:           input.Name,
+		EmbeddingModel: EmbeddingModel(),
+		Metadata:       input.Metadata,
+		LastActiveAt:   &now,
+	}
+	if err := s.storeRepo.Create(ctx, store); err != nil {
+		return nil, common.NewError(err, "7a1e4c8f-3b6d-4f2a-9e5c-1d8b4a7f3e69")
+	}
+
+	for _, f := range files {
+		if _, err := s.attachFile(ctx, store, f, input.Chunking); err != nil {
+			return nil, err
+		}
+	}
+	return store, nil
+}
+
+func (s *VectorStoreService) GetVectorStore(ctx context.Context, owner file.Owner, publicID string) (*VectorStore, *common.Error) {
+	if publicID == "" {
+		return nil, common.NewErrorWithMessage("vector store id is required", "5e2b8d4a-9c1f-4a6e-8b3d-7f2c5e9a1b64")
+	}
+	stores, err := s.storeRepo.FindByFilter(ctx, ownerFilter(owner, VectorStoreFilter{PublicID: &publicID}), nil)
+	if err != nil {
+		return nil, common.NewError(err, "1c7f3a9e-6b2d-4e8a-a4c1-9e5b2d7f3a18")
+	}
+	if len(stores) == 0 {
+		return nil, common.NewErrorWithMessage("vector store not found", "8b4d1f6a-2e9c-4a7d-b3e5-4c1a8f6d2b95")
+	}
+	return stores[0], nil
+}
+
+// FindVectorStores resolves the stores referenced by a request, every id must belong to the owner
+func (s *VectorStoreService) FindVectorStores(ctx context.Context, owner file.Owner, publicIDs []string) ([]*VectorStore, *common.Error) {
+	if len(publicIDs) == 0 {
+		return nil, nil
+	}
+	stores, err := s.storeRepo.FindByFilter(ctx, ownerFilter(owner, VectorStoreFilter{PublicIDs: &publicIDs}), nil)
+	if err != nil {
+		return nil, common.NewError(err, "3d9a5e1c-7f4b-4c2e-9a6d-5b1e3c8f7a42")
+	}
+	found := make(map[string]bool, len(stores))
+	for _, store := range stores {
+		found[store.PublicID] = true
+	}
+	for _, publicID := range publicIDs {
+		if !found[publicID] {
+			return nil, common.NewErrorWithMessage(fmt.Sprintf("vector store %s not found", publicID), "6f1b9d3e-4a8c-4e5b-8d2f-9a3c6e1b7d54")
+		}
+	}
+	return stores, nil
+}
+
+func (s *VectorStoreService) ListVectorStores(ctx context.Context, owner file.Owner, pagination *query.Pagination) ([]*VectorStore, int64, *common.Error) {
+	filter := ownerFilter(owner, VectorStoreFilter{})
+	stores, err := s.storeRepo.FindByFilter(ctx, filter, pagination)
+	if err != nil {
+		return nil, 0, common.NewError(err, "9c5e2a7f-1d4b-4f8e-b6a3-2e7d9c4f1a86")
+	}
+	total, err := s.storeRepo.Count(ctx, filter)
+	if err != nil {
+		return nil, 0, common.NewError(err, "4a8f6c2e-3b9d-4e1a-a7c5-8d2f4b6e9c13")
+	}
+	return stores, total, nil
+}
+
+type UpdateVectorStoreInput struct {
+	Name     *string
+	Metadata map[string]string
+}
+
+func (s *VectorStoreService) UpdateVectorStore(ctx context.Context, store *VectorStore, input UpdateVectorStoreInput) (*VectorStore, *common.Error) {
+	if input.Name != nil {
+		store.Name = *input.Name
+	}
+	if input.Metadata != nil {
+		store.Metadata = input.Metadata
+	}
+	if err := s.storeRepo.Update(ctx, store); err != nil {
+		return nil, common.NewError(err, "2b6d9f4a-8e1c-4a3b-9f7e-5c2a8d6b1e39")
+	}
+	return store, nil
+}
+
+// DeleteVectorStore removes the store with its chunks, the underlying files are kept
+func (s *VectorStoreService) DeleteVectorStore(ctx context.Context, store *VectorStore) *common.Error {
+	if err := s.chunkRepo.DeleteByVectorStoreID(ctx, store.ID); err != nil {
+		return common.NewError(err, "7e3c1a9f-5d2b-4f6e-a8c4-3b9e7d1f5a62")
+	}
+	if err := s.storeFileRepo.DeleteByVectorStoreID(ctx, store.ID); err != nil {
+		return common.NewError(err, "1f8a4d6c-9b3e-4c7a-b2d5-6e1f8a4c9b37")
+	}
+	if err := s.storeRepo.DeleteByID(ctx, store.ID); err != nil {
+		return common.NewError(err, "5d2e9b7a-3c6f-4a1e-9d8b-2f5c7a3e1d94")
+	}
+	return nil
+}
+
+func (s *VectorStoreService) GetFileCounts(ctx context.Context, store *VectorStore) (*FileCounts, *common.Error) {
+	byStatus, err := s.storeFileRepo.CountByStatus(ctx, store.ID)
+	if err != nil {
+		return nil, common.NewError(err, "8a3f7c1e-6d4b-4e9a-b5c2-1e8d3a7f6c45")
+	}
+	counts := &FileCounts{
+		InProgress: byStatus[VectorStoreFileStatusInProgress],
+		Completed:  byStatus[VectorStoreFileStatusCompleted],
+		Failed:     byStatus[VectorStoreFileStatusFailed],
+	}
+	counts.Total = counts.InProgress + counts.Completed + counts.Failed
+	return counts, nil
+}
+
+// AttachFile queues an uploaded file for indexing into the store, the file stays in_progress until the indexer is done with it
+func (s *VectorStoreService) AttachFile(ctx context.Context, owner file.Owner, store *VectorStore, filePublicID string, chunking ChunkingStrategy) (*VectorStoreFile, *common.Error) {
+	if err := ValidateChunkingStrategy(chunking); err != nil {
+		return nil, err
+	}
+	f, err := s.fileService.GetFile(ctx, owner, filePublicID)
+	if err != nil {
+		return nil, err
+	}
+	return s.attachFile(ctx, store, f, chunking)
+}
+
+func (s *VectorStoreService) attachFile(ctx context.Context, store *VectorStore, f *file.File, chunking ChunkingStrategy) (*VectorStoreFile, *common.Error) {
+	existing, findErr := s.storeFileRepo.FindByFilter(ctx, VectorStoreFileFilter{VectorStoreID: &store.ID, FilePublicID: &f.PublicID}, nil)
+	if findErr != nil {
+		return nil, common.NewError(findErr, "4c9e2b6f-1a7d-4e3c-8f5a-9b2d6e4c1a78")
+	}
+	if len(existing) > 0 {
+		return existing[0], nil
+	}
+
+	storeFile := &VectorStoreFile{
+		VectorStoreID: store.ID,
+		FileID:        f.ID,
+		FilePublicID:  f.PublicID,
+		Filename:      f.Filename,
+		Status:        VectorStoreFileStatusInProgress,
+		Chunking:      chunking,
+	}
+	if err := s.storeFileRepo.Create(ctx, storeFile); err != nil {
+		return nil, common.NewError(err, "9f4a1d7c-2e8b-4a5f-b3c6-7d1e9a4f2c56")
+	}
+	return storeFile, nil
+}
+
+// IndexPending claims up to limit queued files and indexes them, it returns the number of files claimed
+func (s *VectorStoreService) IndexPending(ctx context.Context, limit int) int {
+	storeFiles, err := s.storeFileRepo.ClaimPending(ctx, limit, indexLease)
+	if err != nil {
+		if ctx.Err() == nil {
+			logger.GetLogger().Errorf("failed to claim vector store files: %v", err)
+		}
+		return 0
+	}
+	for _, storeFile := range storeFiles {
+		s.indexStoreFile(ctx, storeFile)
+	}
+	return len(storeFiles)
+}
+
+// indexStoreFile indexes a claimed file. Extraction or embedding failures mark the file as failed, an interrupted
+// run leaves it in_progress for another indexer once the lease expires.
+func (s *VectorStoreService) indexStoreFile(ctx context.Context, storeFile *VectorStoreFile) {
+	stores, err := s.storeRepo.FindByFilter(ctx, VectorStoreFilter{ID: &storeFile.VectorStoreID}, nil)
+	if err != nil {
+		logger.GetLogger().Errorf("vector store file %d: failed to load store: %v", storeFile.ID, err)
+		return
+	}
+	if len(stores) == 0 {
+		return
+	}
+	store := stores[0]
+
+	chunks, indexErr := s.indexFile(ctx, store, storeFile)
+	if ctx.Err() != nil {
+		return
+	}
+	if indexErr != nil {
+		logger.GetLogger().Warnf("failed to index file %s into vector store %s: %v", storeFile.FilePublicID, store.PublicID, indexErr)
+		message := indexErr.Error()
+		storeFile.Status = VectorStoreFileStatusFailed
+		storeFile.LastError = &message
+	} else {
+		storeFile.Status = VectorStoreFileStatusCompleted
+		storeFile.ChunkCount = len(chunks)
+		for _, chunk := range chunks {
+			storeFile.UsageBytes += int64(len(chunk.Text))
+		}
+	}
+
+	attached, err := s.storeFileRepo.FinishIndexing(ctx, storeFile)
+	if err != nil {
+		logger.GetLogger().Errorf("vector store file %d: failed to record indexing: %v", storeFile.ID, err)
+		return
+	}
+	if !attached {
+		// the file was detached while it was indexed
+		if err := s.chunkRepo.DeleteByVectorStoreFileID(ctx, storeFile.ID); err != nil {
+			logger.GetLogger().Errorf("vector store file %d: failed to drop chunks: %v", storeFile.ID, err)
+		}
+		return
+	}
+
+	now := time.Now()
+	store.UsageBytes += storeFile.UsageBytes
+	store.LastActiveAt = &now
+	if err := s.storeRepo.Update(ctx, store); err != nil {
+		logger.GetLogger().Errorf("failed to update usage of vector store %s: %v", store.PublicID, err)
+	}
+}
+
+// indexFile extracts, chunks and embeds a file then stores its chunks, replacing those of an interrupted run
+func (s *VectorStoreService) indexFile(ctx context.Context, store *VectorStore, storeFile *VectorStoreFile) ([]*Chunk, error) {
+	f, err := s.fileService.GetFileByID(ctx, storeFile.FileID)
+	if err != nil {
+		return nil, err
+	}
+	text, err := s.fileService.ReadText(ctx, f)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.chunkRepo.DeleteByVectorStoreFileID(ctx, storeFile.ID); err != nil {
+		return nil, err
+	}
+	pieces := tokenizer.SplitText(text, storeFile.Chunking.MaxChunkSizeTokens, storeFile.Chunking.ChunkOverlapTokens)
+	if len(pieces) == 0 {
+		return nil, fmt.Errorf("file %s has no text content", f.PublicID)
+	}
+
+	vectors, embedErr := s.embed(ctx, store.EmbeddingModel, pieces)
+	if embedErr != nil {
+		return nil, embedErr
+	}
+
+	chunks := make([]*Chunk, 0, len(pieces))
+	for i, piece := range pieces {
+		chunks = append(chunks, &Chunk{
+			VectorStoreID:     store.ID,
+			VectorStoreFileID: storeFile.ID,
+			FilePublicID:      f.PublicID,
+			Filename:          f.Filename,
+			ChunkIndex:        i,
+			Text:              piece,
+			Embedding:         vectors[i],
+		})
+	}
+	if err := s.chunkRepo.CreateBatch(ctx, chunks); err != nil {
+		return nil, err
+	}
+	return chunks, nil
+}
+
+// embed computes embeddings through the provider serving the model
+func (s *VectorStoreService) embed(ctx context.Context, model string, inputs []string) ([][]float32, error) {
+	provider, err := s.providerRegistry.GetProviderForModel(ctx, model, organization.DEFAULT_ORGANIZATION.ID, nil)
+	if err != nil {
+		return nil, err
+	}
+	client, err := s.inferenceProvider.GetEmbeddingClient(provider)
+	if err != nil {
+		return nil, err
+	}
+
+	vectors := make([][]float32, 0, len(inputs))
+	for start := 0; start < len(inputs); start += embeddingBatchSize {
+		end := start + embeddingBatchSize
+		if end > len(inputs) {
+			end = len(inputs)
+		}
+		batch, err := client.CreateEmbeddings(ctx, model, inputs[start:end])
+		if err != nil {
+			return nil, err
+		}
+		vectors = append(vectors, batch...)
+	}
+	return vectors, nil
+}
+
+func (s *VectorStoreService) GetVectorStoreFile(ctx context.Context, store *VectorStore, filePublicID string) (*VectorStoreFile, *common.Error) {
+	storeFiles, err := s.storeFileRepo.FindByFilter(ctx, VectorStoreFileFilter{VectorStoreID: &store.ID, FilePublicID: &filePublicID}, nil)
+	if err != nil {
+		return nil, common.NewError(err, "2d8f5b1e-7a3c-4e9d-b6f2-1c5a8e3d7b49")
+	}
+	if len(storeFiles) == 0 {
+		return nil, common.NewErrorWithMessage("vector store file not found", "7c4a9e2f-5b1d-4f8c-a3e6-9d2b7f4c1e85")
+	}
+	return storeFiles[0], nil
+}
+
+func (s *VectorStoreService) ListVectorStoreFiles(ctx context.Context, store *VectorStore, status *VectorStoreFileStatus, pagination *query.Pagination) ([]*VectorStoreFile, int64, *common.Error) {
+	filter := VectorStoreFileFilter{VectorStoreID: &store.ID, Status: status}
+	storeFiles, err := s.storeFileRepo.FindByFilter(ctx, filter, pagination)
+	if err != nil {
+		return nil, 0, common.NewError(err, "5f2c8a6e-1d9b-4e3f-9a7c-4b8e2d6f1a53")
+	}
+	total, err := s.storeFileRepo.Count(ctx, filter)
+	if err != nil {
+		return nil, 0, common.NewError(err, "8e6b3d1f-4c7a-4a2e-b9d5-6f3e8c1a4b27")
+	}
+	return storeFiles, total, nil
+}
+
+// DetachFile removes a file and its chunks from the store, the uploaded file itself is kept
+func (s *VectorStoreService) DetachFile(ctx context.Context, store *VectorStore, storeFile *VectorStoreFile) *common.Error {
+	if err := s.chunkRepo.DeleteByVectorStoreFileID(ctx, storeFile.ID); err != nil {
+		return common.NewError(err, "1a5d9c3f-8e2b-4f6a-a4d7-3c9f1e5b8d62")
+	}
+	if err := s.storeFileRepo.DeleteByID(ctx, storeFile.ID); err != nil {
+		return common.NewError(err, "4e9b2f7a-6c1d-4a8e-9b3f-7d2a5e9c4f16")
+	}
+	store.UsageBytes -= storeFile.UsageBytes
+	if store.UsageBytes < 0 {
+		store.UsageBytes = 0
+	}
+	if err := s.storeRepo.Update(ctx, store); err != nil {
+		return common.NewError(err, "9d3f6a1c-2b8e-4c5f-a7d1-8e4b2c6f9a35")
+	}
+	return nil
+}
+
+// RemoveFile detaches a deleted file from every store it was indexed in
+func (s *VectorStoreService) RemoveFile(ctx context.Context, f *file.File) *common.Error {
+	storeFiles, err := s.storeFileRepo.FindByFilter(ctx, VectorStoreFileFilter{FileID: &f.ID}, nil)
+	if err != nil {
+		return common.NewError(err, "5b1d8f3a-6e9c-4a2d-b7f4-3e8a1c5d9b62")
+	}
+	for _, storeFile := range storeFiles {
+		stores, err := s.storeRepo.FindByFilter(ctx, VectorStoreFilter{ID: &storeFile.VectorStoreID}, nil)
+		if err != nil {
+			return common.NewError(err, "8d4a2e6f-1c7b-4f3e-9a5d-6b2f8e4c1a37")
+		}
+		if len(stores) == 0 {
+			continue
+		}
+		if detachErr := s.DetachFile(ctx, stores[0], storeFile); detachErr != nil {
+			return detachErr
+		}
+	}
+	return nil
+}
+
+// Search returns the chunks closest to the query across the stores, optionally restricted to some files
+func (s *VectorStoreService) Search(ctx context.Context, stores []*VectorStore, queryText string, filePublicIDs []string, limit int) ([]*SearchResult, *common.Error) {
+	queryText = strings.TrimSpace(queryText)
+	if queryText == "" {
+		return nil, common.NewErrorWithMessage("query is required", "6a2e8c4f-9d1b-4f7a-b3e5-2c6a9f4d1e78")
+	}
+	if limit <= 0 {
+		limit = DefaultSearchResults
+	}
+	if limit > MaxSearchResults {
+		limit = MaxSearchResults
+	}
+
+	// stores indexed with different models live in different vector spaces, search each space separately
+	storeIDsByModel := make(map[string][]uint)
+	for _, store := range stores {
+		storeIDsByModel[store.EmbeddingModel] = append(storeIDsByModel[store.EmbeddingModel], store.ID)
+	}
+
+	var results []*SearchResult
+	for model, storeIDs := range storeIDsByModel {
+		vectors, err := s.embed(ctx, model, []string{queryText})
+		if err != nil {
+			return nil, common.NewError(err, "3c8f1e5a-7b4d-4e2c-9f6a-1d5b8e3c7a94")
+		}
+		modelResults, err := s.chunkRepo.Search(ctx, ChunkSearch{
+			VectorStoreIDs: storeIDs,
+			FilePublicIDs:  filePublicIDs,
+			Embedding:      vectors[0],
+			Limit:          limit,
+		})
+		if err != nil {
+			return nil, common.NewError(err, "8f5a2d9c-1e6b-4a3f-b8c4-5e9d2a6f1c37")
+		}
+		results = append(results, modelResults...)
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+
+	now := time.Now()
+	for _, store := range stores {
+		store.LastActiveAt = &now
+		if err := s.storeRepo.Update(ctx, store); err != nil {
+			logger.GetLogger().Warnf("failed to update last activity of vector store %s: %v", store.PublicID, err)
+		}
+	}
+	return results, nil
+}
+
+// SearchFiles searches the given files across every store of the owner they are indexed in
+func (s *VectorStoreService) SearchFiles(ctx context.Context, owner file.Owner, queryText string, filePublicIDs []string, limit int) ([]*SearchResult, *common.Error) {
+	stores, err := s.storeRepo.FindByFilter(ctx, ownerFilter(owner, VectorStoreFilter{}), nil)
+	if err != nil {
+		return nil, common.NewError(err, "2e7c4a9f-5d1b-4e8c-a6f3-9b2e7d4c1a58")
+	}
+	if len(stores) == 0 {
+		return nil, nil
+	}
+	return s.Search(ctx, stores, queryText, filePublicIDs, limit)
+}
+
+func (s *VectorStoreService) GetVectorStoreMiddleware() gin.HandlerFunc {
+	return func(reqCtx *gin.Context) {
+		ctx := reqCtx.Request.Context()
+		user, ok := auth.GetUserFromContext(reqCtx)
+		if !ok {
+			reqCtx.AbortWithStatusJSON(http.StatusUnauthorized, responses.ErrorResponse{
+				Code: "7b3e9a1d-4f6c-4e2a-8d5b-1f7c3e9a6d42",
+			})
+			return
+		}
+		owner := file.Owner{UserID: user.ID, ProjectID: auth.GetRequestProjectID(reqCtx)}
+		store, err := s.GetVectorStore(ctx, owner, reqCtx.Param(string(VectorStoreContextKeyPublicID)))
+		if err != nil {
+			reqCtx.AbortWithStatusJSON(http.StatusNotFound, responses.ErrorResponse{
+				Code:  err.GetCode(),
+				Error: err.Error(),
+			})
+			return
+		}
+		SetVectorStoreToContext(reqCtx, store)
+		reqCtx.Next()
+	}
+}
+
+func SetVectorStoreToContext(reqCtx *gin.Context, store *VectorStore) {
+	reqCtx.Set(string(VectorStoreContextEntity), store)
+}
+
+func GetVectorStoreFromContext(reqCtx *gin.Context) (*VectorStore, bool) {
+	v, ok := reqCtx.Get(string(VectorStoreContextEntity))
+	if !ok {
+		return nil, false
+	}
+	store, ok := v.(*VectorStore)
+	return store, ok
+}
@@ -0,0 +1,140 @@
+package webhook
+
+import (
+	"context"
+	"time"
+
+	"menlo.ai/indigo-api-gateway/app/domain/query"
+)
+
+// @Enum(response.completed, response.failed, response.cancelled, response.incomplete, webhook.test)
+type EventType string
+
+const (
+	EventTypeResponseCompleted  EventType = "response.completed"
+	EventTypeResponseFailed     EventType = "response.failed"
+	EventTypeResponseCancelled  EventType = "response.cancelled"
+	EventTypeResponseIncomplete EventType = "response.incomplete"
+	// EventTypeTest is only sent by the test-fire endpoint, endpoints do not subscribe to it
+	EventTypeTest EventType = "webhook.test"
+)
+
+// SubscribableEventTypes lists the event types an endpoint can subscribe to
+var SubscribableEventTypes = []EventType{
+	EventTypeResponseCompleted,
+	EventTypeResponseFailed,
+	EventTypeResponseCancelled,
+	EventTypeResponseIncomplete,
+}
+
+func IsSubscribableEventType(eventType string) bool {
+	for _, t := range SubscribableEventTypes {
+		if string(t) == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// @Enum(pending, succeeded, failed)
+type DeliveryStatus string
+
+const (
+	// DeliveryStatusPending deliveries are sent once NextAttemptAt is reached
+	DeliveryStatusPending   DeliveryStatus = "pending"
+	DeliveryStatusSucceeded DeliveryStatus = "succeeded"
+	// DeliveryStatusFailed deliveries ran out of attempts and sit in the dead-letter list until redelivered
+	DeliveryStatusFailed DeliveryStatus = "failed"
+)
+
+// Endpoint is a URL of a project that receives signed event notifications
+type Endpoint struct {
+	ID          uint
+	PublicID    string
+	ProjectID   uint
+	URL         string
+	Description string
+	// Secret signs the payloads, it is only set when the secret was generated so it can be returned once
+	Secret string
+	// EncryptedSecret is the stored form of Secret
+	EncryptedSecret string
+	EventTypes      []string
+	Enabled         bool
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
+
+// Subscribes reports whether the endpoint receives events of the given type
+func (e *Endpoint) Subscribes(eventType EventType) bool {
+	for _, t := range e.EventTypes {
+		if t == string(eventType) {
+			return true
+		}
+	}
+	return false
+}
+
+// Delivery is one event queued for one endpoint
+type Delivery struct {
+	ID             uint
+	PublicID       string
+	EndpointID     uint
+	EventID        string
+	EventType      EventType
+	Payload        string
+	Status         DeliveryStatus
+	Attempts       int
+	NextAttemptAt  time.Time
+	LockedUntil    *time.Time
+	LastStatusCode *int
+	LastError      *string
+	DeliveredAt    *time.Time
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// DeliveryAttempt logs one HTTP request made for a delivery
+type DeliveryAttempt struct {
+	ID           uint
+	DeliveryID   uint
+	Attempt      int
+	StatusCode   *int
+	Error        *string
+	ResponseBody *string
+	DurationMs   int64
+	CreatedAt    time.Time
+}
+
+type EndpointFilter struct {
+	ID        *uint
+	PublicID  *string
+	ProjectID *uint
+	Enabled   *bool
+}
+
+type DeliveryFilter struct {
+	PublicID   *string
+	EndpointID *uint
+	ProjectID  *uint
+	Status     *DeliveryStatus
+}
+
+type EndpointRepository interface {
+	Create(ctx context.Context, endpoint *Endpoint) error
+	Update(ctx context.Context, endpoint *Endpoint) error
+	FindByFilter(ctx context.Context, filter EndpointFilter, pagination *query.Pagination) ([]*Endpoint, error)
+	Count(ctx context.Context, filter EndpointFilter) (int64, error)
+	DeleteByID(ctx context.Context, id uint) error
+}
+
+type DeliveryRepository interface {
+	Create(ctx context.Context, delivery *Delivery) error
+	Update(ctx context.Context, delivery *Delivery) error
+	FindByFilter(ctx context.Context, filter DeliveryFilter, pagination *query.Pagination) ([]*Delivery, error)
+	Count(ctx context.Context, filter DeliveryFilter) (int64, error)
+	// ClaimDue leases pending deliveries whose next attempt is due, so concurrent dispatchers never send the same delivery
+	ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]*Delivery, error)
+	CreateAttempt(ctx context.Context, attempt *DeliveryAttempt) error
+	FindAttempts(ctx context.Context, deliveryID uint) ([]*DeliveryAttempt, error)
+	DeleteByEndpointID(ctx context.Context, endpointID uint) error
+}
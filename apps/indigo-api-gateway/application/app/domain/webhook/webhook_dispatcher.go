@@ -0,0 +1,80 @@
+package webhook
+
+import (
+	"context"
+	"time"
+
+	"menlo.ai/indigo-api-gateway/app/utils/logger"
+)
+
+const (
+	dispatcherPollInterval = 2 * time.Second
+	dispatcherBatchSize    = 20
+)
+
+// WebhookDispatcher sends the queued deliveries, retrying failed ones with exponential backoff
+type WebhookDispatcher struct {
+	webhookService *WebhookService
+}
+
+func NewWebhookDispatcher(webhookService *WebhookService) *WebhookDispatcher {
+	return &WebhookDispatcher{webhookService: webhookService}
+}
+
+// Start launches the dispatch loop, it stops when ctx is cancelled
+func (d *WebhookDispatcher) Start(ctx context.Context) {
+	go d.run(ctx)
+}
+
+func (d *WebhookDispatcher) run(ctx context.Context) {
+	for {
+		claimed := d.dispatchDue(ctx)
+		if claimed == dispatcherBatchSize {
+			// more deliveries are probably due
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(dispatcherPollInterval):
+		}
+	}
+}
+
+func (d *WebhookDispatcher) dispatchDue(ctx context.Context) int {
+	s := d.webhookService
+	deliveries, err := s.deliveryRepo.ClaimDue(ctx, dispatcherBatchSize, DeliveryLease)
+	if err != nil {
+		if ctx.Err() == nil {
+			logger.GetLogger().Errorf("failed to claim webhook deliveries: %v", err)
+		}
+		return 0
+	}
+
+	endpoints := make(map[uint]*Endpoint)
+	for _, delivery := range deliveries {
+		endpoint, ok := endpoints[delivery.EndpointID]
+		if !ok {
+			found, err := s.endpointRepo.FindByFilter(ctx, EndpointFilter{ID: &delivery.EndpointID}, nil)
+			if err != nil {
+				logger.GetLogger().Errorf("webhook delivery %s: failed to load endpoint: %v", delivery.PublicID, err)
+				continue
+			}
+			if len(found) > 0 {
+				endpoint = found[0]
+			}
+			endpoints[delivery.EndpointID] = endpoint
+		}
+		if endpoint == nil || !endpoint.Enabled {
+			// deliveries of disabled endpoints wait in the queue until the endpoint is enabled again
+			delivery.LockedUntil = nil
+			delivery.NextAttemptAt = time.Now().Add(RetryDelay(1))
+			if err := s.deliveryRepo.Update(ctx, delivery); err != nil {
+				logger.GetLogger().Errorf("webhook delivery %s: failed to postpone: %v", delivery.PublicID, err)
+			}
+			continue
+		}
+		s.Deliver(ctx, endpoint, delivery, MaxAttempts())
+	}
+	return len(deliveries)
+}
@@ -0,0 +1,507 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"menlo.ai/indigo-api-gateway/app/domain/common"
+	"menlo.ai/indigo-api-gateway/app/domain/query"
+	"menlo.ai/indigo-api-gateway/app/utils/crypto"
+	"menlo.ai/indigo-api-gateway/app/utils/idgen"
+	"menlo.ai/indigo-api-gateway/app/utils/logger"
+	"menlo.ai/indigo-api-gateway/app/utils/webfetch"
+	"menlo.ai/indigo-api-gateway/config/environment_variables"
+)
+
+const (
+	// Headers sent with every delivery
+	HeaderEventID   = "X-Webhook-Id"
+	HeaderEventType = "X-Webhook-Event"
+	// HeaderSignature carries "t=<unix seconds>,v1=<hex HMAC-SHA256 of "<t>.<body>" keyed by the endpoint secret>"
+	HeaderSignature = "X-Webhook-Signature"
+
+	defaultMaxAttempts = 8
+	// retryBaseDelay doubles after each failed attempt up to maxRetryDelay
+	retryBaseDelay = 30 * time.Second
+	maxRetryDelay  = 6 * time.Hour
+	requestTimeout = 10 * time.Second
+	// DeliveryLease is how long a claimed delivery stays reserved for one dispatcher
+	DeliveryLease = time.Minute
+	// maxLoggedResponseBytes bounds the response body kept in the delivery log
+	maxLoggedResponseBytes = 1024
+	maxEndpointsPerProject = 20
+	maxRedirects           = 3
+	// secretPrefix starts the generated secrets, base64 ciphertexts never contain its '_'
+	secretPrefix = "whsec"
+)
+
+// Event is the JSON body posted to endpoints
+type Event struct {
+	ID        string    `json:"id"`
+	Object    string    `json:"object"`
+	Type      EventType `json:"type"`
+	CreatedAt int64     `json:"created_at"`
+	Data      any       `json:"data"`
+}
+
+type WebhookService struct {
+	endpointRepo EndpointRepository
+	deliveryRepo DeliveryRepository
+	client       *http.Client
+}
+
+func NewWebhookService(endpointRepo EndpointRepository, deliveryRepo DeliveryRepository) *WebhookService {
+	// the guard runs on the resolved address, so a name pointing at the internal network cannot be used
+	dialer := &net.Dialer{Timeout: requestTimeout, Control: webfetch.GuardDial}
+	return &WebhookService{
+		endpointRepo: endpointRepo,
+		deliveryRepo: deliveryRepo,
+		client: &http.Client{
+			Timeout: requestTimeout,
+			Transport: &http.Transport{
+				// proxies from the environment would hide the destination from the guard
+				Proxy:                 nil,
+				DialContext:           dialer.DialContext,
+				TLSHandshakeTimeout:   requestTimeout,
+				ResponseHeaderTimeout: requestTimeout,
+			},
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				if len(via) > maxRedirects {
+					return fmt.Errorf("stopped after %d redirects", maxRedirects)
+				}
+				if err := ValidateEndpointURL(req.URL.String()); err != nil {
+					return fmt.Errorf("redirected to %s: %s", req.URL.Redacted(), err.GetMessage())
+				}
+				return nil
+			},
+		},
+	}
+}
+
+// MaxAttempts returns how many times a delivery is sent before it is moved to the dead-letter list
+func MaxAttempts() int {
+	if attempts := environment_variables.Current().WEBHOOK_MAX_ATTEMPTS; attempts > 0 {
+		return attempts
+	}
+	return defaultMaxAttempts
+}
+
+// RetryDelay returns the wait before the attempt following the given number of failed attempts
+func RetryDelay(failedAttempts int) time.Duration {
+	delay := retryBaseDelay
+	for i := 1; i < failedAttempts; i++ {
+		delay *= 2
+		if delay >= maxRetryDelay {
+			return maxRetryDelay
+		}
+	}
+	return delay
+}
+
+// Sign computes the value of HeaderSignature for a payload
+func Sign(secret string, timestamp time.Time, payload []byte) string {
+	t := strconv.FormatInt(timestamp.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(t))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return fmt.Sprintf("t=%s,v1=%s", t, hex.EncodeToString(mac.Sum(nil)))
+}
+
+// ValidateEndpointURL only accepts absolute http and https URLs that do not name a loopback, private or
+// link-local host; names resolving to such addresses are refused when the delivery connects
+func ValidateEndpointURL(raw string) *common.Error {
+	parsed, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return common.NewErrorWithMessage("url must be an absolute http or https URL", "5c1e7a3f-9b2d-4e6a-8f4c-3a7e1b5d9c28")
+	}
+	if webfetch.IsBlockedHost(parsed.Hostname()) {
+		return common.NewErrorWithMessage("url must not point to a loopback, private or link-local address", "5c1e7a3f-9b2d-4e6a-8f4c-3a7e1b5d9c29")
+	}
+	return nil
+}
+
+// ValidateEventTypes checks a subscription list
+func ValidateEventTypes(eventTypes []string) *common.Error {
+	if len(eventTypes) == 0 {
+		return common.NewErrorWithMessage("event_types must not be empty", "2e8b4d6a-7c1f-4a3e-9b5d-6f2a8c4e1b73")
+	}
+	for _, eventType := range eventTypes {
+		if !IsSubscribableEventType(eventType) {
+			return common.NewErrorWithMessage(fmt.Sprintf("unsupported event type %q", eventType), "8a4f2c6e-3d9b-4e1a-b7c5-9e3f1a6d2b84")
+		}
+	}
+	return nil
+}
+
+type CreateEndpointInput struct {
+	URL         string
+	Description string
+	EventTypes  []string
+}
+
+// CreateEndpoint registers an endpoint and generates its signing secret
+func (s *WebhookService) CreateEndpoint(ctx context.Context, projectID uint, input CreateEndpointInput) (*Endpoint, *common.Error) {
+	if err := ValidateEndpointURL(input.URL); err != nil {
+		return nil, err
+	}
+	if err := ValidateEventTypes(input.EventTypes); err != nil {
+		return nil, err
+	}
+	count, err := s.endpointRepo.Count(ctx, EndpointFilter{ProjectID: &projectID})
+	if err != nil {
+		return nil, common.NewError(err, "6d2a8e4c-1f7b-4c3a-9e5d-2b8f4a6c1e95")
+	}
+	if count >= maxEndpointsPerProject {
+		return nil, common.NewErrorWithMessage(fmt.Sprintf("a project can have at most %d webhook endpoints", maxEndpointsPerProject), "6d2a8e4c-1f7b-4c3a-9e5d-2b8f4a6c1e96")
+	}
+
+	publicID, err := idgen.GenerateSecureID("whk", 24)
+	if err != nil {
+		return nil, common.NewError(err, "9f3b7d1e-5a2c-4e8b-a6d4-1c9e5b3f7a27")
+	}
+	endpoint := &Endpoint{
+		PublicID:    publicID,
+		ProjectID:   projectID,
+		URL:         strings.TrimSpace(input.URL),
+		Description: input.Description,
+		EventTypes:  input.EventTypes,
+		Enabled:     true,
+	}
+	if err := generateSecret(ctx, endpoint); err != nil {
+		return nil, common.NewError(err, "9f3b7d1e-5a2c-4e8b-a6d4-1c9e5b3f7a28")
+	}
+	if err := s.endpointRepo.Create(ctx, endpoint); err != nil {
+		return nil, common.NewError(err, "9f3b7d1e-5a2c-4e8b-a6d4-1c9e5b3f7a29")
+	}
+	return endpoint, nil
+}
+
+type UpdateEndpointInput struct {
+	URL         *string
+	Description *string
+	EventTypes  []string
+	Enabled     *bool
+	// RotateSecret replaces the signing secret, the new one is returned once
+	RotateSecret bool
+}
+
+func (s *WebhookService) UpdateEndpoint(ctx context.Context, endpoint *Endpoint, input UpdateEndpointInput) (*Endpoint, *common.Error) {
+	if input.URL != nil {
+		if err := ValidateEndpointURL(*input.URL); err != nil {
+			return nil, err
+		}
+		endpoint.URL = strings.TrimSpace(*input.URL)
+	}
+	if input.EventTypes != nil {
+		if err := ValidateEventTypes(input.EventTypes); err != nil {
+			return nil, err
+		}
+		endpoint.EventTypes = input.EventTypes
+	}
+	if input.Description != nil {
+		endpoint.Description = *input.Description
+	}
+	if input.Enabled != nil {
+		endpoint.Enabled = *input.Enabled
+	}
+	if input.RotateSecret {
+		if err := generateSecret(ctx, endpoint); err != nil {
+			return nil, common.NewError(err, "3b9d5f1a-7e2c-4a6b-8d4f-5a1c7e3b9d46")
+		}
+	}
+	if err := s.endpointRepo.Update(ctx, endpoint); err != nil {
+		return nil, common.NewError(err, "3b9d5f1a-7e2c-4a6b-8d4f-5a1c7e3b9d47")
+	}
+	return endpoint, nil
+}
+
+// DeleteEndpoint removes the endpoint together with its deliveries and their logs
+func (s *WebhookService) DeleteEndpoint(ctx context.Context, endpoint *Endpoint) *common.Error {
+	if err := s.deliveryRepo.DeleteByEndpointID(ctx, endpoint.ID); err != nil {
+		return common.NewError(err, "7e3a9c5f-1b6d-4f2a-9c8e-4d1b7f3a5c68")
+	}
+	if err := s.endpointRepo.DeleteByID(ctx, endpoint.ID); err != nil {
+		return common.NewError(err, "7e3a9c5f-1b6d-4f2a-9c8e-4d1b7f3a5c69")
+	}
+	return nil
+}
+
+func (s *WebhookService) GetEndpoint(ctx context.Context, projectID uint, publicID string) (*Endpoint, *common.Error) {
+	endpoints, err := s.endpointRepo.FindByFilter(ctx, EndpointFilter{ProjectID: &projectID, PublicID: &publicID}, nil)
+	if err != nil {
+		return nil, common.NewError(err, "4c8e2a6d-9f3b-4d1e-a7c5-8b2f6d4a1e37")
+	}
+	if len(endpoints) == 0 {
+		return nil, common.NewErrorWithMessage("webhook endpoint not found", "4c8e2a6d-9f3b-4d1e-a7c5-8b2f6d4a1e38")
+	}
+	return endpoints[0], nil
+}
+
+func (s *WebhookService) ListEndpoints(ctx context.Context, projectID uint, pagination *query.Pagination) ([]*Endpoint, int64, *common.Error) {
+	filter := EndpointFilter{ProjectID: &projectID}
+	endpoints, err := s.endpointRepo.FindByFilter(ctx, filter, pagination)
+	if err != nil {
+		return nil, 0, common.NewError(err, "1a7d3f9b-5e2c-4b8a-9d6f-3e1b7a5c9d42")
+	}
+	count, err := s.endpointRepo.Count(ctx, filter)
+	if err != nil {
+		return nil, 0, common.NewError(err, "1a7d3f9b-5e2c-4b8a-9d6f-3e1b7a5c9d43")
+	}
+	return endpoints, count, nil
+}
+
+// Publish queues the event for every enabled endpoint of the project subscribed to its type
+func (s *WebhookService) Publish(ctx context.Context, projectID uint, eventType EventType, data any) *common.Error {
+	enabled := true
+	endpoints, err := s.endpointRepo.FindByFilter(ctx, EndpointFilter{ProjectID: &projectID, Enabled: &enabled}, nil)
+	if err != nil {
+		return common.NewError(err, "8b4e1a7d-3c9f-4e5b-a2d8-6f4c1e9b3a57")
+	}
+	var subscribed []*Endpoint
+	for _, endpoint := range endpoints {
+		if endpoint.Subscribes(eventType) {
+			subscribed = append(subscribed, endpoint)
+		}
+	}
+	if len(subscribed) == 0 {
+		return nil
+	}
+
+	event, payload, commonErr := newEvent(eventType, data)
+	if commonErr != nil {
+		return commonErr
+	}
+	for _, endpoint := range subscribed {
+		if _, err := s.enqueue(ctx, endpoint, event, payload, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SendTest posts a webhook.test event to the endpoint right away, the attempt is logged but never retried
+func (s *WebhookService) SendTest(ctx context.Context, endpoint *Endpoint) (*Delivery, *common.Error) {
+	event, payload, err := newEvent(EventTypeTest, map[string]any{
+		"webhook_id": endpoint.PublicID,
+		"message":    "This is a test event.",
+	})
+	if err != nil {
+		return nil, err
+	}
+	// the delivery is locked so the dispatcher does not send it a second time
+	lockedUntil := time.Now().Add(DeliveryLease)
+	delivery, err := s.enqueue(ctx, endpoint, event, payload, &lockedUntil)
+	if err != nil {
+		return nil, err
+	}
+	s.Deliver(ctx, endpoint, delivery, 1)
+	return delivery, nil
+}
+
+func (s *WebhookService) GetDelivery(ctx context.Context, endpoint *Endpoint, publicID string) (*Delivery, *common.Error) {
+	deliveries, err := s.deliveryRepo.FindByFilter(ctx, DeliveryFilter{EndpointID: &endpoint.ID, PublicID: &publicID}, nil)
+	if err != nil {
+		return nil, common.NewError(err, "5f1c9e3a-7b4d-4a2f-8e6c-1d9b5f3a7e84")
+	}
+	if len(deliveries) == 0 {
+		return nil, common.NewErrorWithMessage("webhook delivery not found", "5f1c9e3a-7b4d-4a2f-8e6c-1d9b5f3a7e85")
+	}
+	return deliveries[0], nil
+}
+
+// ListDeliveries lists the deliveries of an endpoint or, without an endpoint, of the whole project
+func (s *WebhookService) ListDeliveries(ctx context.Context, filter DeliveryFilter, pagination *query.Pagination) ([]*Delivery, int64, *common.Error) {
+	deliveries, err := s.deliveryRepo.FindByFilter(ctx, filter, pagination)
+	if err != nil {
+		return nil, 0, common.NewError(err, "2d6a4e8c-9b1f-4c7d-a3e5-7f2b6d4a8c19")
+	}
+	count, err := s.deliveryRepo.Count(ctx, filter)
+	if err != nil {
+		return nil, 0, common.NewError(err, "2d6a4e8c-9b1f-4c7d-a3e5-7f2b6d4a8c20")
+	}
+	return deliveries, count, nil
+}
+
+func (s *WebhookService) ListAttempts(ctx context.Context, delivery *Delivery) ([]*DeliveryAttempt, *common.Error) {
+	attempts, err := s.deliveryRepo.FindAttempts(ctx, delivery.ID)
+	if err != nil {
+		return nil, common.NewError(err, "6e2b8d4f-1a5c-4e9b-b7d3-4c8a2e6f1b95")
+	}
+	return attempts, nil
+}
+
+// Redeliver moves a delivery back to the queue with a fresh attempt budget
+func (s *WebhookService) Redeliver(ctx context.Context, delivery *Delivery) *common.Error {
+	if delivery.Status == DeliveryStatusPending {
+		return common.NewErrorWithMessage("delivery is already queued", "9c5f3b7e-2d8a-4f1c-a6e4-8b3d9f5c2a71")
+	}
+	delivery.Status = DeliveryStatusPending
+	delivery.Attempts = 0
+	delivery.NextAttemptAt = time.Now()
+	delivery.LockedUntil = nil
+	if err := s.deliveryRepo.Update(ctx, delivery); err != nil {
+		return common.NewError(err, "9c5f3b7e-2d8a-4f1c-a6e4-8b3d9f5c2a72")
+	}
+	return nil
+}
+
+// Deliver posts the delivery, logs the attempt and schedules a retry or dead-letters it when the attempt failed
+func (s *WebhookService) Deliver(ctx context.Context, endpoint *Endpoint, delivery *Delivery, maxAttempts int) {
+	start := time.Now()
+	statusCode, body, sendErr := s.send(ctx, endpoint, delivery)
+	delivery.Attempts++
+
+	attempt := &DeliveryAttempt{
+		DeliveryID: delivery.ID,
+		Attempt:    delivery.Attempts,
+		DurationMs: time.Since(start).Milliseconds(),
+	}
+	if statusCode != 0 {
+		attempt.StatusCode = &statusCode
+	}
+	if body != "" {
+		attempt.ResponseBody = &body
+	}
+	if sendErr == nil && (statusCode < 200 || statusCode >= 300) {
+		sendErr = fmt.Errorf("endpoint responded with status %d", statusCode)
+	}
+	if sendErr != nil {
+		message := sendErr.Error()
+		attempt.Error = &message
+	}
+	if err := s.deliveryRepo.CreateAttempt(ctx, attempt); err != nil {
+		logger.GetLogger().Errorf("webhook delivery %s: failed to log attempt: %v", delivery.PublicID, err)
+	}
+
+	delivery.LastStatusCode = attempt.StatusCode
+	delivery.LastError = attempt.Error
+	delivery.LockedUntil = nil
+	switch {
+	case sendErr == nil:
+		now := time.Now()
+		delivery.Status = DeliveryStatusSucceeded
+		delivery.DeliveredAt = &now
+	case delivery.Attempts >= maxAttempts:
+		delivery.Status = DeliveryStatusFailed
+	default:
+		delivery.NextAttemptAt = time.Now().Add(RetryDelay(delivery.Attempts))
+	}
+	if err := s.deliveryRepo.Update(ctx, delivery); err != nil {
+		logger.GetLogger().Errorf("webhook delivery %s: failed to update: %v", delivery.PublicID, err)
+	}
+}
+
+func (s *WebhookService) send(ctx context.Context, endpoint *Endpoint, delivery *Delivery) (int, string, error) {
+	secret, err := decryptSecret(ctx, endpoint)
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to decrypt the signing secret: %w", err)
+	}
+	payload := []byte(delivery.Payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.URL, bytes.NewReader(payload))
+	if err != nil {
+		return 0, "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "indigo-webhooks/1.0")
+	req.Header.Set(HeaderEventID, delivery.EventID)
+	req.Header.Set(HeaderEventType, string(delivery.EventType))
+	req.Header.Set(HeaderSignature, Sign(secret, time.Now(), payload))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxLoggedResponseBytes))
+	return resp.StatusCode, string(body), nil
+}
+
+func (s *WebhookService) enqueue(ctx context.Context, endpoint *Endpoint, event *Event, payload []byte, lockedUntil *time.Time) (*Delivery, *common.Error) {
+	publicID, err := idgen.GenerateSecureID("whdel", 24)
+	if err != nil {
+		return nil, common.NewError(err, "3f7b1d5a-8c2e-4a9f-b4d6-2e8a6c1f5b39")
+	}
+	delivery := &Delivery{
+		PublicID:      publicID,
+		EndpointID:    endpoint.ID,
+		EventID:       event.ID,
+		EventType:     event.Type,
+		Payload:       string(payload),
+		Status:        DeliveryStatusPending,
+		NextAttemptAt: time.Now(),
+		LockedUntil:   lockedUntil,
+	}
+	if err := s.deliveryRepo.Create(ctx, delivery); err != nil {
+		return nil, common.NewError(err, "3f7b1d5a-8c2e-4a9f-b4d6-2e8a6c1f5b40")
+	}
+	return delivery, nil
+}
+
+// generateSecret sets a new signing secret on the endpoint, in clear for the response and encrypted for storage
+func generateSecret(ctx context.Context, endpoint *Endpoint) error {
+	secret, err := idgen.GenerateSecureID(secretPrefix, 40)
+	if err != nil {
+		return err
+	}
+	keyring, err := crypto.DefaultKeyring()
+	if err != nil {
+		return err
+	}
+	encrypted, err := keyring.Encrypt(ctx, secret)
+	if err != nil {
+		return err
+	}
+	endpoint.Secret = secret
+	endpoint.EncryptedSecret = encrypted
+	return nil
+}
+
+// decryptSecret returns the signing secret, the ones stored in clear by earlier versions are used as they are
+// until rotate-secrets encrypts them
+func decryptSecret(ctx context.Context, endpoint *Endpoint) (string, error) {
+	if IsPlaintextSecret(endpoint.EncryptedSecret) {
+		return endpoint.EncryptedSecret, nil
+	}
+	keyring, err := crypto.DefaultKeyring()
+	if err != nil {
+		return "", err
+	}
+	return keyring.Decrypt(ctx, endpoint.EncryptedSecret)
+}
+
+// IsPlaintextSecret reports whether a stored secret was saved in clear
+func IsPlaintextSecret(stored string) bool {
+	return strings.HasPrefix(stored, secretPrefix+"_")
+}
+
+func newEvent(eventType EventType, data any) (*Event, []byte, *common.Error) {
+	eventID, err := idgen.GenerateSecureID("evt", 24)
+	if err != nil {
+		return nil, nil, common.NewError(err, "7a3e9c1f-5d2b-4e8a-9c6f-1b7d3e9a5c62")
+	}
+	event := &Event{
+		ID:        eventID,
+		Object:    "event",
+		Type:      eventType,
+		CreatedAt: time.Now().Unix(),
+		Data:      data,
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return nil, nil, common.NewError(err, "7a3e9c1f-5d2b-4e8a-9c6f-1b7d3e9a5c63")
+	}
+	return event, payload, nil
+}
@@ -0,0 +1,182 @@
+package webhook_test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"menlo.ai/indigo-api-gateway/app/domain/webhook"
+	"menlo.ai/indigo-api-gateway/app/utils/crypto"
+	"menlo.ai/indigo-api-gateway/config/environment_variables"
+)
+
+func TestSign(t *testing.T) {
+	payload := []byte(`{"id":"evt_1"}`)
+	timestamp := time.Unix(1700000000, 0)
+
+	mac := hmac.New(sha256.New, []byte("whsec_test"))
+	mac.Write([]byte("1700000000." + string(payload)))
+	want := "t=1700000000,v1=" + hex.EncodeToString(mac.Sum(nil))
+
+	if got := webhook.Sign("whsec_test", timestamp, payload); got != want {
+		t.Fatalf("Sign() = %q, want %q", got, want)
+	}
+	if webhook.Sign("whsec_other", timestamp, payload) == want {
+		t.Fatal("signatures with different secrets must differ")
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	cases := []struct {
+		failedAttempts int
+		want           time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{4, 4 * time.Minute},
+		{20, 6 * time.Hour},
+	}
+	for _, c := range cases {
+		if got := webhook.RetryDelay(c.failedAttempts); got != c.want {
+			t.Errorf("RetryDelay(%d) = %v, want %v", c.failedAttempts, got, c.want)
+		}
+	}
+}
+
+func TestValidateEventTypes(t *testing.T) {
+	if err := webhook.ValidateEventTypes([]string{"response.completed", "response.failed"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, eventTypes := range [][]string{nil, {"webhook.test"}, {"response.created"}} {
+		if webhook.ValidateEventTypes(eventTypes) == nil {
+			t.Errorf("ValidateEventTypes(%v) should fail", eventTypes)
+		}
+	}
+}
+
+func TestValidateEndpointURL(t *testing.T) {
+	valid := []string{"https://example.com/hooks", "http://93.184.216.34:8080/hooks"}
+	for _, raw := range valid {
+		if err := webhook.ValidateEndpointURL(raw); err != nil {
+			t.Fatalf("ValidateEndpointURL(%q) = %v, want nil", raw, err)
+		}
+	}
+	invalid := []string{
+		"ftp://example.com",
+		"/hooks",
+		"http://localhost:8080/hooks",
+		"http://api.localhost/hooks",
+		"http://metadata.google.internal/computeMetadata",
+		"http://127.0.0.1/hooks",
+		"http://10.0.0.5/hooks",
+		"http://192.168.1.1/hooks",
+		"http://169.254.169.254/latest/meta-data",
+		"http://[::1]/hooks",
+		"http://[fe80::1]/hooks",
+	}
+	for _, raw := range invalid {
+		if err := webhook.ValidateEndpointURL(raw); err == nil {
+			t.Fatalf("ValidateEndpointURL(%q) = nil, want an error", raw)
+		}
+	}
+}
+
+type memoryEndpointRepo struct {
+	webhook.EndpointRepository
+	endpoints []*webhook.Endpoint
+}
+
+func (r *memoryEndpointRepo) Create(ctx context.Context, endpoint *webhook.Endpoint) error {
+	endpoint.ID = uint(len(r.endpoints) + 1)
+	r.endpoints = append(r.endpoints, endpoint)
+	return nil
+}
+
+func (r *memoryEndpointRepo) Update(ctx context.Context, endpoint *webhook.Endpoint) error {
+	return nil
+}
+
+func (r *memoryEndpointRepo) Count(ctx context.Context, filter webhook.EndpointFilter) (int64, error) {
+	return int64(len(r.endpoints)), nil
+}
+
+type memoryDeliveryRepo struct {
+	webhook.DeliveryRepository
+	attempts []*webhook.DeliveryAttempt
+}
+
+func (r *memoryDeliveryRepo) Update(ctx context.Context, delivery *webhook.Delivery) error {
+	return nil
+}
+
+func (r *memoryDeliveryRepo) CreateAttempt(ctx context.Context, attempt *webhook.DeliveryAttempt) error {
+	r.attempts = append(r.attempts, attempt)
+	return nil
+}
+
+func TestEndpointSecretIsEncrypted(t *testing.T) {
+	environment_variables.Override("webhook_service_test", func(env *environment_variables.EnvironmentVariable) {
+		env.MODEL_PROVIDER_SECRET = "webhook-test-secret"
+	})
+	ctx := context.Background()
+	endpointRepo := &memoryEndpointRepo{}
+	service := webhook.NewWebhookService(endpointRepo, &memoryDeliveryRepo{})
+
+	endpoint, err := service.CreateEndpoint(ctx, 1, webhook.CreateEndpointInput{
+		URL:        "https://example.com/hooks",
+		EventTypes: []string{string(webhook.EventTypeResponseCompleted)},
+	})
+	if err != nil {
+		t.Fatalf("create endpoint: %v", err)
+	}
+	if !strings.HasPrefix(endpoint.Secret, "whsec_") {
+		t.Fatalf("expected a generated secret, got %q", endpoint.Secret)
+	}
+	if webhook.IsPlaintextSecret(endpoint.EncryptedSecret) || strings.Contains(endpoint.EncryptedSecret, endpoint.Secret) {
+		t.Fatalf("expected the stored secret to be encrypted, got %q", endpoint.EncryptedSecret)
+	}
+	keyring, _ := crypto.DefaultKeyring()
+	if plaintext, decryptErr := keyring.Decrypt(ctx, endpoint.EncryptedSecret); decryptErr != nil || plaintext != endpoint.Secret {
+		t.Fatalf("expected the stored secret to decrypt to the generated one, got %q, %v", plaintext, decryptErr)
+	}
+
+	previous := endpoint.EncryptedSecret
+	rotated, err := service.UpdateEndpoint(ctx, endpoint, webhook.UpdateEndpointInput{RotateSecret: true})
+	if err != nil {
+		t.Fatalf("rotate secret: %v", err)
+	}
+	if rotated.EncryptedSecret == previous || webhook.IsPlaintextSecret(rotated.EncryptedSecret) {
+		t.Fatalf("expected a new encrypted secret, got %q", rotated.EncryptedSecret)
+	}
+}
+
+func TestDeliverRefusesInternalAddresses(t *testing.T) {
+	called := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer server.Close()
+
+	deliveryRepo := &memoryDeliveryRepo{}
+	service := webhook.NewWebhookService(&memoryEndpointRepo{}, deliveryRepo)
+	// a legacy clear secret, the URL was stored before internal hosts were rejected
+	endpoint := &webhook.Endpoint{ID: 1, URL: server.URL, EncryptedSecret: "whsec_legacy"}
+	delivery := &webhook.Delivery{ID: 1, EndpointID: 1, Payload: `{}`, Status: webhook.DeliveryStatusPending}
+	service.Deliver(context.Background(), endpoint, delivery, 1)
+
+	if called {
+		t.Fatal("expected the loopback endpoint not to be reached")
+	}
+	if len(deliveryRepo.attempts) != 1 || deliveryRepo.attempts[0].Error == nil || !strings.Contains(*deliveryRepo.attempts[0].Error, "not publicly routable") {
+		t.Fatalf("expected a blocked attempt, got %+v", deliveryRepo.attempts)
+	}
+	if delivery.Status != webhook.DeliveryStatusFailed {
+		t.Fatalf("expected the delivery to fail, got %s", delivery.Status)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"menlo.ai/indigo-api-gateway/app/domain/common"
+	"menlo.ai/indigo-api-gateway/app/domain/conversation"
 	"menlo.ai/indigo-api-gateway/app/domain/query"
 )
 
@@ -15,8 +16,12 @@ type Workspace struct {
 	UserID      uint
 	Name        string
 	Instruction *string
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
+	// ContextSettings are the default context window settings for conversations in the workspace
+	ContextSettings *conversation.ContextSettings
+	// ShareConversations lets members see each other's conversations in the workspace
+	ShareConversations bool
+	CreatedAt          time.Time
+	UpdatedAt          time.Time
 }
 
 func (w *Workspace) Normalize() error {
@@ -35,6 +40,80 @@ type WorkspaceFilter struct {
 	PublicID  *string
 	PublicIDs *[]string
 	IDs       *[]uint
+	// MemberID matches the workspaces the user created or was added to
+	MemberID *uint
+}
+
+// @Enum(owner, editor, viewer)
+type WorkspaceRole string
+
+const (
+	WorkspaceRoleOwner  WorkspaceRole = "owner"
+	WorkspaceRoleEditor WorkspaceRole = "editor"
+	WorkspaceRoleViewer WorkspaceRole = "viewer"
+)
+
+func ValidateWorkspaceRole(input string) bool {
+	switch WorkspaceRole(input) {
+	case WorkspaceRoleOwner, WorkspaceRoleEditor, WorkspaceRoleViewer:
+		return true
+	default:
+		return false
+	}
+}
+
+// CanEdit reports whether the role may change the workspace and its conversations
+func (r WorkspaceRole) CanEdit() bool {
+	return r == WorkspaceRoleOwner || r == WorkspaceRoleEditor
+}
+
+// CanManage reports whether the role may manage members, invitations and sharing
+func (r WorkspaceRole) CanManage() bool {
+	return r == WorkspaceRoleOwner
+}
+
+// WorkspaceMember grants a user a role in a workspace, the creator of a workspace is always its owner
+type WorkspaceMember struct {
+	ID          uint
+	WorkspaceID uint
+	UserID      uint
+	Role        WorkspaceRole
+	CreatedAt   time.Time
+}
+
+type WorkspaceMemberFilter struct {
+	WorkspaceID *uint
+	UserID      *uint
+	Role        *WorkspaceRole
+}
+
+// @Enum(pending, accepted, declined, revoked)
+type WorkspaceInvitationStatus string
+
+const (
+	WorkspaceInvitationStatusPending  WorkspaceInvitationStatus = "pending"
+	WorkspaceInvitationStatusAccepted WorkspaceInvitationStatus = "accepted"
+	WorkspaceInvitationStatusDeclined WorkspaceInvitationStatus = "declined"
+	WorkspaceInvitationStatusRevoked  WorkspaceInvitationStatus = "revoked"
+)
+
+type WorkspaceInvitation struct {
+	ID            uint
+	PublicID      string
+	WorkspaceID   uint
+	InviteeUserID uint
+	InviterUserID uint
+	Role          WorkspaceRole
+	Status        WorkspaceInvitationStatus
+	CreatedAt     time.Time
+	RespondedAt   *time.Time
+}
+
+type WorkspaceInvitationFilter struct {
+	PublicID      *string
+	WorkspaceID   *uint
+	InviteeUserID *uint
+	Status        *WorkspaceInvitationStatus
 }
 
 type WorkspaceRepository interface {
@@ -45,4 +124,15 @@ type WorkspaceRepository interface {
 	FindByPublicID(ctx context.Context, publicID string) (*Workspace, error)
 	FindByFilter(ctx context.Context, filter WorkspaceFilter, pagination *query.Pagination) ([]*Workspace, error)
 	Count(ctx context.Context, filter WorkspaceFilter) (int64, error)
+
+	AddMember(ctx context.Context, m *WorkspaceMember) error
+	RemoveMember(ctx context.Context, workspaceID, userID uint) error
+	UpdateMemberRole(ctx context.Context, workspaceID, userID uint, role WorkspaceRole) error
+	FindMembersByFilter(ctx context.Context, filter WorkspaceMemberFilter) ([]*WorkspaceMember, error)
+
+	CreateInvitation(ctx context.Context, invitation *WorkspaceInvitation) error
+	UpdateInvitation(ctx context.Context, invitation *WorkspaceInvitation) error
+	// AcceptInvitation adds the member and saves the invitation in one transaction
+	AcceptInvitation(ctx context.Context, invitation *WorkspaceInvitation, member *WorkspaceMember) error
+	FindInvitationsByFilter(ctx context.Context, filter WorkspaceInvitationFilter) ([]*WorkspaceInvitation, error)
 }
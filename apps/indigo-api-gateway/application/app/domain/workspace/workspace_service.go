@@ -2,14 +2,18 @@ package workspace
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 
 	"menlo.ai/indigo-api-gateway/app/domain/auth"
 	"menlo.ai/indigo-api-gateway/app/domain/common"
 	"menlo.ai/indigo-api-gateway/app/domain/conversation"
+	"menlo.ai/indigo-api-gateway/app/domain/organization"
 	"menlo.ai/indigo-api-gateway/app/domain/query"
 	"menlo.ai/indigo-api-gateway/app/interfaces/http/responses"
 	"menlo.ai/indigo-api-gateway/app/utils/idgen"
@@ -22,15 +26,27 @@ const (
 	WorkspaceContextEntity      WorkspaceContextKey = "WorkspaceContextEntity"
 )
 
+const (
+	WorkspaceContextRole WorkspaceContextKey = "WorkspaceContextRole"
+)
+
 type WorkspaceService struct {
-	repo             WorkspaceRepository
-	conversationRepo conversation.ConversationRepository
+	repo                WorkspaceRepository
+	conversationRepo    conversation.ConversationRepository
+	organizationService *organization.OrganizationService
 }
 
-func NewWorkspaceService(repo WorkspaceRepository, conversationRepo conversation.ConversationRepository) *WorkspaceService {
+var _ conversation.WorkspaceAccessProvider = (*WorkspaceService)(nil)
+
+func NewWorkspaceService(
+	repo WorkspaceRepository,
+	conversationRepo conversation.ConversationRepository,
+	organizationService *organization.OrganizationService,
+) *WorkspaceService {
 	return &WorkspaceService{
-		repo:             repo,
-		conversationRepo: conversationRepo,
+		repo:                repo,
+		conversationRepo:    conversationRepo,
+		organizationService: organizationService,
 	}
 }
 
@@ -69,7 +85,7 @@ func (s *WorkspaceService) GetWorkspaceByPublicIDAndUserID(ctx context.Context,
 
 	workspaces, err := s.repo.FindByFilter(ctx, WorkspaceFilter{
 		PublicID: &publicID,
-		UserID:   &userID,
+		MemberID: &userID,
 	}, nil)
 	if err != nil {
 		return nil, common.NewError(err, "ad9be074-4c1e-4d43-828d-fc9e7efc0c52")
@@ -83,6 +99,279 @@ func (s *WorkspaceService) GetWorkspaceByPublicIDAndUserID(ctx context.Context,
 	return workspaces[0], nil
 }
 
+// GetWorkspaceForMember returns a workspace the user created or is a member of together with the user's role
+func (s *WorkspaceService) GetWorkspaceForMember(ctx context.Context, publicID string, userID uint) (*Workspace, WorkspaceRole, *common.Error) {
+	workspace, err := s.GetWorkspaceByPublicIDAndUserID(ctx, publicID, userID)
+	if err != nil {
+		return nil, "", err
+	}
+	role, ok, err := s.MemberRole(ctx, workspace, userID)
+	if err != nil {
+		return nil, "", err
+	}
+	if !ok {
+		return nil, "", common.NewErrorWithMessage("workspace not found", "c8bc424c-5b20-4cf9-8ca1-7d9ad1b098c8")
+	}
+	return workspace, role, nil
+}
+
+// MemberRole resolves the role of a user in the workspace, ok is false when the user is not a member
+func (s *WorkspaceService) MemberRole(ctx context.Context, workspace *Workspace, userID uint) (WorkspaceRole, bool, *common.Error) {
+	if workspace.UserID == userID {
+		return WorkspaceRoleOwner, true, nil
+	}
+	members, err := s.repo.FindMembersByFilter(ctx, WorkspaceMemberFilter{
+		WorkspaceID: &workspace.ID,
+		UserID:      &userID,
+	})
+	if err != nil {
+		return "", false, common.NewError(err, "b3d5f7a9-1c3e-4b5d-8f7a-9c1e3b5d7f9a")
+	}
+	if len(members) == 0 {
+		return "", false, nil
+	}
+	return members[0].Role, true, nil
+}
+
+// RolesForUser resolves the role of the user in each of the given workspaces, keyed by workspace ID
+func (s *WorkspaceService) RolesForUser(ctx context.Context, workspaces []*Workspace, userID uint) (map[uint]WorkspaceRole, *common.Error) {
+	roles := make(map[uint]WorkspaceRole, len(workspaces))
+	memberships, err := s.repo.FindMembersByFilter(ctx, WorkspaceMemberFilter{
+		UserID: &userID,
+	})
+	if err != nil {
+		return nil, common.NewError(err, "c5e7a9b1-3d5f-4c7e-a9b1-3d5f7c9e1a4c")
+	}
+	for _, membership := range memberships {
+		roles[membership.WorkspaceID] = membership.Role
+	}
+	for _, workspace := range workspaces {
+		if workspace.UserID == userID {
+			roles[workspace.ID] = WorkspaceRoleOwner
+		}
+	}
+	return roles, nil
+}
+
+// SharedConversationAccess implements conversation.WorkspaceAccessProvider
+func (s *WorkspaceService) SharedConversationAccess(ctx context.Context, workspacePublicID string, userID uint) (conversation.ConversationAccess, bool, error) {
+	workspace, err := s.repo.FindByPublicID(ctx, workspacePublicID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", false, nil
+		}
+		return "", false, err
+	}
+	if !workspace.ShareConversations {
+		return "", false, nil
+	}
+	role, ok, roleErr := s.MemberRole(ctx, workspace, userID)
+	if roleErr != nil {
+		return "", false, roleErr
+	}
+	if !ok {
+		return "", false, nil
+	}
+	switch role {
+	case WorkspaceRoleOwner:
+		return conversation.ConversationAccessOwner, true, nil
+	case WorkspaceRoleEditor:
+		return conversation.ConversationAccessEditor, true, nil
+	default:
+		return conversation.ConversationAccessViewer, true, nil
+	}
+}
+
+func (s *WorkspaceService) UpdateWorkspaceSharing(ctx context.Context, workspace *Workspace, shareConversations bool) (*Workspace, *common.Error) {
+	workspace.ShareConversations = shareConversations
+	if err := s.repo.Update(ctx, workspace); err != nil {
+		return nil, common.NewError(err, "d7f9b1c3-5e7a-4d9f-b1c3-5e7a9d1f3b5c")
+	}
+	return workspace, nil
+}
+
+// ListMembers returns the members of the workspace, starting with its creator
+func (s *WorkspaceService) ListMembers(ctx context.Context, workspace *Workspace) ([]*WorkspaceMember, *common.Error) {
+	members, err := s.repo.FindMembersByFilter(ctx, WorkspaceMemberFilter{
+		WorkspaceID: &workspace.ID,
+	})
+	if err != nil {
+		return nil, common.NewError(err, "f1b3d5e7-9a1c-4f3b-a5d7-e9a1c3f5b7d9")
+	}
+	result := make([]*WorkspaceMember, 0, len(members)+1)
+	result = append(result, &WorkspaceMember{
+		WorkspaceID: workspace.ID,
+		UserID:      workspace.UserID,
+		Role:        WorkspaceRoleOwner,
+		CreatedAt:   workspace.CreatedAt,
+	})
+	return append(result, members...), nil
+}
+
+func (s *WorkspaceService) UpdateMemberRole(ctx context.Context, workspace *Workspace, userID uint, role WorkspaceRole) *common.Error {
+	if !ValidateWorkspaceRole(string(role)) {
+		return common.NewErrorWithMessage("invalid workspace role", "0a2c4e6f-8b0d-4a2c-9e4f-6b8d0a2c4e6f")
+	}
+	if userID == workspace.UserID {
+		return common.NewErrorWithMessage("the workspace creator is always an owner", "5b7d9f1a-3c5e-4b7d-9f1a-3c5e7b9d1f3a")
+	}
+	if _, ok, err := s.MemberRole(ctx, workspace, userID); err != nil {
+		return err
+	} else if !ok {
+		return common.NewErrorWithMessage("workspace member not found", "9d1f3b5c-7e9a-4d1f-b3c5-7e9a1d3f5b7c")
+	}
+	if err := s.repo.UpdateMemberRole(ctx, workspace.ID, userID, role); err != nil {
+		return common.NewError(err, "3e5a7c9b-1d3f-4e5a-8c9b-1d3f5e7a9c1b")
+	}
+	return nil
+}
+
+func (s *WorkspaceService) RemoveMember(ctx context.Context, workspace *Workspace, userID uint) *common.Error {
+	if userID == workspace.UserID {
+		return common.NewErrorWithMessage("the workspace creator cannot be removed", "7c9e1a3b-5d7f-4c9e-a1b3-5d7f9c1e3a5b")
+	}
+	if err := s.repo.RemoveMember(ctx, workspace.ID, userID); err != nil {
+		return common.NewError(err, "1a3c5e7f-9b1d-4a3c-8e7f-9b1d3a5c7e9f")
+	}
+	return nil
+}
+
+// InviteMember invites a user of the inviter's organization to join the workspace with the given role
+func (s *WorkspaceService) InviteMember(ctx context.Context, workspace *Workspace, inviterID uint, inviteeID uint, role WorkspaceRole) (*WorkspaceInvitation, *common.Error) {
+	if !ValidateWorkspaceRole(string(role)) {
+		return nil, common.NewErrorWithMessage("invalid workspace role", "0a2c4e6f-8b0d-4a2c-9e4f-6b8d0a2c4e6f")
+	}
+	if _, ok, err := s.MemberRole(ctx, workspace, inviteeID); err != nil {
+		return nil, err
+	} else if ok {
+		return nil, common.NewErrorWithMessage("user is already a workspace member", "4f6b8d0e-2a4c-4f6b-8d0e-2a4c6f8b0d2e")
+	}
+	sameOrganization, err := s.shareOrganization(ctx, inviterID, inviteeID)
+	if err != nil {
+		return nil, common.NewError(err, "8a0c2e4f-6b8d-4a0c-9e4f-6b8d0a2c4e7a")
+	}
+	if !sameOrganization {
+		return nil, common.NewErrorWithMessage("only users of the same organization can be invited", "2d4f6a8c-0e2b-4d4f-a8c0-e2b4d6f8a0c3")
+	}
+
+	pending := WorkspaceInvitationStatusPending
+	existing, err := s.repo.FindInvitationsByFilter(ctx, WorkspaceInvitationFilter{
+		WorkspaceID:   &workspace.ID,
+		InviteeUserID: &inviteeID,
+		Status:        &pending,
+	})
+	if err != nil {
+		return nil, common.NewError(err, "6e8a0c2d-4f6b-4e8a-9c2d-4f6b8e0a2c4d")
+	}
+	if len(existing) > 0 {
+		return nil, common.NewErrorWithMessage("user already has a pending invitation", "c0e2a4b6-8d0f-4c2e-a4b6-8d0f2c4e6a8b")
+	}
+
+	publicID, genErr := idgen.GenerateSecureID("wsinv", 24)
+	if genErr != nil {
+		return nil, common.NewError(genErr, "e4a6c8d0-2b4f-4e6a-8c0d-2b4f6e8a0c2d")
+	}
+	invitation := &WorkspaceInvitation{
+		PublicID:      publicID,
+		WorkspaceID:   workspace.ID,
+		InviteeUserID: inviteeID,
+		InviterUserID: inviterID,
+		Role:          role,
+		Status:        WorkspaceInvitationStatusPending,
+	}
+	if err := s.repo.CreateInvitation(ctx, invitation); err != nil {
+		return nil, common.NewError(err, "a8c0e2f4-6b8d-4a0c-b2e4-6f8a0c2e4b6d")
+	}
+	return invitation, nil
+}
+
+func (s *WorkspaceService) FindInvitationsByFilter(ctx context.Context, filter WorkspaceInvitationFilter) ([]*WorkspaceInvitation, *common.Error) {
+	invitations, err := s.repo.FindInvitationsByFilter(ctx, filter)
+	if err != nil {
+		return nil, common.NewError(err, "5c7e9a1b-3d5f-4c7e-9a1b-3d5f7c9e1a3b")
+	}
+	return invitations, nil
+}
+
+// GetPendingInvitation returns a pending invitation addressed to the user
+func (s *WorkspaceService) GetPendingInvitation(ctx context.Context, publicID string, inviteeID uint) (*WorkspaceInvitation, *common.Error) {
+	pending := WorkspaceInvitationStatusPending
+	invitations, err := s.FindInvitationsByFilter(ctx, WorkspaceInvitationFilter{
+		PublicID:      &publicID,
+		InviteeUserID: &inviteeID,
+		Status:        &pending,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(invitations) == 0 {
+		return nil, common.NewErrorWithMessage("workspace invitation not found", "9e1a3c5d-7f9b-4e1a-8c5d-7f9b1e3a5c7d")
+	}
+	return invitations[0], nil
+}
+
+// RespondToInvitation accepts or declines a pending invitation, accepting adds the invitee as a member
+func (s *WorkspaceService) RespondToInvitation(ctx context.Context, invitation *WorkspaceInvitation, accept bool) (*WorkspaceInvitation, *common.Error) {
+	if !accept {
+		invitation.Status = WorkspaceInvitationStatusDeclined
+		return s.closeInvitation(ctx, invitation)
+	}
+	now := time.Now()
+	invitation.Status = WorkspaceInvitationStatusAccepted
+	invitation.RespondedAt = &now
+	if err := s.repo.AcceptInvitation(ctx, invitation, &WorkspaceMember{
+		WorkspaceID: invitation.WorkspaceID,
+		UserID:      invitation.InviteeUserID,
+		Role:        invitation.Role,
+	}); err != nil {
+		invitation.Status = WorkspaceInvitationStatusPending
+		invitation.RespondedAt = nil
+		return nil, common.NewError(err, "3a5c7e9f-1b3d-4a5c-8e9f-1b3d5a7c9e1f")
+	}
+	return invitation, nil
+}
+
+func (s *WorkspaceService) RevokeInvitation(ctx context.Context, invitation *WorkspaceInvitation) (*WorkspaceInvitation, *common.Error) {
+	if invitation.Status != WorkspaceInvitationStatusPending {
+		return nil, common.NewErrorWithMessage("only pending invitations can be revoked", "7e9a1c3d-5f7b-4e9a-a1c3-d5f7b9e1a3c5")
+	}
+	invitation.Status = WorkspaceInvitationStatusRevoked
+	return s.closeInvitation(ctx, invitation)
+}
+
+func (s *WorkspaceService) closeInvitation(ctx context.Context, invitation *WorkspaceInvitation) (*WorkspaceInvitation, *common.Error) {
+	now := time.Now()
+	invitation.RespondedAt = &now
+	if err := s.repo.UpdateInvitation(ctx, invitation); err != nil {
+		return nil, common.NewError(err, "1c3e5a7b-9d1f-4c3e-b5a7-9d1f3c5e7a9b")
+	}
+	return invitation, nil
+}
+
+// shareOrganization reports whether both users are members of at least one common organization
+func (s *WorkspaceService) shareOrganization(ctx context.Context, userID uint, otherUserID uint) (bool, error) {
+	memberships, err := s.organizationService.FindMembersByFilter(ctx, organization.OrganizationMemberFilter{
+		UserID: &userID,
+	}, nil)
+	if err != nil {
+		return false, err
+	}
+	for _, membership := range memberships {
+		orgID := membership.OrganizationID
+		member, err := s.organizationService.FindOneMemberByFilter(ctx, organization.OrganizationMemberFilter{
+			UserID:         &otherUserID,
+			OrganizationID: &orgID,
+		})
+		if err != nil {
+			return false, err
+		}
+		if member != nil {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
 func (s *WorkspaceService) UpdateWorkspaceName(ctx context.Context, workspace *Workspace, name string) (*Workspace, *common.Error) {
 	workspace.Name = strings.TrimSpace(name)
 	if err := workspace.Normalize(); err != nil {
@@ -102,6 +391,20 @@ func (s *WorkspaceService) UpdateWorkspaceInstruction(ctx context.Context, works
 	return workspace, nil
 }
 
+func (s *WorkspaceService) UpdateWorkspaceContextSettings(ctx context.Context, workspace *Workspace, settings *conversation.ContextSettings) (*Workspace, *common.Error) {
+	if err := settings.Validate(); err != nil {
+		return nil, common.NewError(err, "e2b7c4d1-58a3-4f0e-9b6d-3c1a7e5f9d82")
+	}
+	if settings != nil && settings.Strategy == nil && settings.KeepLastN == nil && settings.SummaryModel == nil {
+		settings = nil
+	}
+	workspace.ContextSettings = settings
+	if err := s.repo.Update(ctx, workspace); err != nil {
+		return nil, common.NewError(err, "5f8e1a3c-7b2d-4c90-a6e4-0d9b2f7c1e35")
+	}
+	return workspace, nil
+}
+
 func (s *WorkspaceService) DeleteWorkspaceWithConversations(ctx context.Context, workspace *Workspace) *common.Error {
 	if workspace == nil {
 		return common.NewErrorWithMessage("workspace is required", "5d35c9b3-61f6-4c40-b6f8-31e0de1d7688")
@@ -141,7 +444,7 @@ func (s *WorkspaceService) GetWorkspaceMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		workspace, err := s.GetWorkspaceByPublicIDAndUserID(ctx, workspaceID, user.ID)
+		workspace, role, err := s.GetWorkspaceForMember(ctx, workspaceID, user.ID)
 		if err != nil {
 			status := http.StatusInternalServerError
 			if err.GetCode() == "c8bc424c-5b20-4cf9-8ca1-7d9ad1b098c8" {
@@ -155,6 +458,22 @@ func (s *WorkspaceService) GetWorkspaceMiddleware() gin.HandlerFunc {
 		}
 
 		SetWorkspaceOnContext(reqCtx, workspace)
+		reqCtx.Set(string(WorkspaceContextRole), role)
+		reqCtx.Next()
+	}
+}
+
+// RequireWorkspaceRole aborts requests of members whose role does not satisfy allowed, it runs after GetWorkspaceMiddleware
+func RequireWorkspaceRole(allowed func(WorkspaceRole) bool) gin.HandlerFunc {
+	return func(reqCtx *gin.Context) {
+		role, ok := GetWorkspaceRoleFromContext(reqCtx)
+		if !ok || !allowed(role) {
+			reqCtx.AbortWithStatusJSON(http.StatusForbidden, responses.ErrorResponse{
+				Code:  "5a7c9e1b-3d5f-4a7c-9e1b-3d5f7a9c1e3b",
+				Error: "insufficient workspace role",
+			})
+			return
+		}
 		reqCtx.Next()
 	}
 }
@@ -185,3 +504,12 @@ func GetWorkspaceFromContext(reqCtx *gin.Context) (*Workspace, bool) {
 	}
 	return workspace, true
 }
+
+func GetWorkspaceRoleFromContext(reqCtx *gin.Context) (WorkspaceRole, bool) {
+	value, ok := reqCtx.Get(string(WorkspaceContextRole))
+	if !ok {
+		return "", false
+	}
+	role, ok := value.(WorkspaceRole)
+	return role, ok
+}
@@ -6,4 +6,17 @@ const (
 
 	// UserByPublicIDKey is the cache key template for user lookups by public ID.
 	UserByPublicIDKey = CacheVersion + ":user:public_id:%s"
+
+	// ResponseStreamKey is the key template of the Redis stream buffering the events of a streamed response.
+	ResponseStreamKey = CacheVersion + ":response:stream:%s"
+
+	// WebpageKey is the key template of a fetched webpage, by the SHA-256 of its URL.
+	WebpageKey = CacheVersion + ":webpage:%s"
+
+	// CompletionCacheKey is the key template of a cached chat completion, by project and request hash.
+	CompletionCacheKey = CacheVersion + ":completion_cache:%d:%s"
+
+	// CompletionCacheSemanticKey is the key template of the prompt embeddings compared in the semantic mode,
+	// by project and hash of the request without its messages.
+	CompletionCacheSemanticKey = CacheVersion + ":completion_cache:semantic:%d:%s"
 )
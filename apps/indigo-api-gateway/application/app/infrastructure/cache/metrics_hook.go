@@ -0,0 +1,49 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"net"
+
+	"github.com/redis/go-redis/v9"
+	"menlo.ai/indigo-api-gateway/app/utils/metrics"
+)
+
+// metricsHook counts the failed Redis commands; redis.Nil is a cache miss, not an error
+type metricsHook struct{}
+
+func (metricsHook) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := next(ctx, network, addr)
+		if err != nil {
+			metrics.IncRedisError("dial")
+		}
+		return conn, err
+	}
+}
+
+func (metricsHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		err := next(ctx, cmd)
+		if isRedisError(err) {
+			metrics.IncRedisError(cmd.Name())
+		}
+		return err
+	}
+}
+
+func (metricsHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		err := next(ctx, cmds)
+		for _, cmd := range cmds {
+			if isRedisError(cmd.Err()) {
+				metrics.IncRedisError(cmd.Name())
+			}
+		}
+		return err
+	}
+}
+
+func isRedisError(err error) bool {
+	return err != nil && !errors.Is(err, redis.Nil)
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/go-redsync/redsync/v4"
 	"github.com/go-redsync/redsync/v4/redis/goredis/v9"
+	"github.com/redis/go-redis/extra/redisotel/v9"
 	"github.com/redis/go-redis/v9"
 	"menlo.ai/indigo-api-gateway/app/utils/logger"
 	"menlo.ai/indigo-api-gateway/config/environment_variables"
@@ -19,7 +20,7 @@ type RedisCacheService struct {
 }
 
 func NewRedisCacheService() *RedisCacheService {
-	redisURL := environment_variables.EnvironmentVariables.REDIS_URL
+	redisURL := environment_variables.Current().REDIS_URL
 	if redisURL == "" {
 		panic("REDIS_URL environment variable must be set")
 	}
@@ -29,11 +30,11 @@ func NewRedisCacheService() *RedisCacheService {
 		panic(fmt.Sprintf("failed to parse Redis URL: %v", err))
 	}
 
-	if pwd := environment_variables.EnvironmentVariables.REDIS_PASSWORD; pwd != "" {
+	if pwd := environment_variables.Current().REDIS_PASSWORD; pwd != "" {
 		opts.Password = pwd
 	}
 
-	if dbVal := environment_variables.EnvironmentVariables.REDIS_DB; dbVal != 0 {
+	if dbVal := environment_variables.Current().REDIS_DB; dbVal != 0 {
 		opts.DB = dbVal
 	}
 
@@ -43,6 +44,10 @@ func NewRedisCacheService() *RedisCacheService {
 	}
 
 	client := redis.NewUniversalClient(opts)
+	client.AddHook(metricsHook{})
+	if err := redisotel.InstrumentTracing(client); err != nil {
+		logger.GetLogger().Warnf("unable to trace Redis commands: %v", err)
+	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -200,6 +205,87 @@ func (r *RedisCacheService) Exists(ctx context.Context, key string) (bool, error
 	return result > 0, nil
 }
 
+// StreamEntry is one entry of a Redis stream
+type StreamEntry struct {
+	ID     string
+	Values map[string]string
+}
+
+// StreamAppend adds an entry to a Redis stream and refreshes the expiration of the stream
+func (r *RedisCacheService) StreamAppend(ctx context.Context, key string, values map[string]string, expiration time.Duration) error {
+	pipe := r.client.TxPipeline()
+	pipe.XAdd(ctx, &redis.XAddArgs{
+		Stream: key,
+		Values: values,
+	})
+	pipe.Expire(ctx, key, expiration)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to append to stream: %w", err)
+	}
+	return nil
+}
+
+// ListPushCapped prepends a value to a list, keeps its maxLen newest values and refreshes its expiration
+func (r *RedisCacheService) ListPushCapped(ctx context.Context, key string, value string, maxLen int64, expiration time.Duration) error {
+	pipe := r.client.TxPipeline()
+	pipe.LPush(ctx, key, value)
+	pipe.LTrim(ctx, key, 0, maxLen-1)
+	pipe.Expire(ctx, key, expiration)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to push to list: %w", err)
+	}
+	return nil
+}
+
+// ListRange returns all the values of a list, newest first for lists filled by ListPushCapped
+func (r *RedisCacheService) ListRange(ctx context.Context, key string) ([]string, error) {
+	values, err := r.client.LRange(ctx, key, 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read list: %w", err)
+	}
+	return values, nil
+}
+
+// StreamRange returns all the entries of a Redis stream
+func (r *RedisCacheService) StreamRange(ctx context.Context, key string) ([]StreamEntry, error) {
+	messages, err := r.client.XRange(ctx, key, "-", "+").Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read stream: %w", err)
+	}
+	return toStreamEntries(messages), nil
+}
+
+// StreamRead waits up to block for entries added after afterID, it returns no entries when none arrived in time
+func (r *RedisCacheService) StreamRead(ctx context.Context, key string, afterID string, block time.Duration) ([]StreamEntry, error) {
+	streams, err := r.client.XRead(ctx, &redis.XReadArgs{
+		Streams: []string{key, afterID},
+		Block:   block,
+	}).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read stream: %w", err)
+	}
+	var entries []StreamEntry
+	for _, stream := range streams {
+		entries = append(entries, toStreamEntries(stream.Messages)...)
+	}
+	return entries, nil
+}
+
+func toStreamEntries(messages []redis.XMessage) []StreamEntry {
+	entries := make([]StreamEntry, 0, len(messages))
+	for _, message := range messages {
+		values := make(map[string]string, len(message.Values))
+		for k, v := range message.Values {
+			values[k] = fmt.Sprint(v)
+		}
+		entries = append(entries, StreamEntry{ID: message.ID, Values: values})
+	}
+	return entries
+}
+
 func (r *RedisCacheService) Close() error {
 	return r.client.Close()
 }
@@ -6,6 +6,7 @@ import (
 	"gorm.io/gorm/schema"
 	"gorm.io/plugin/dbresolver"
 	"menlo.ai/indigo-api-gateway/app/utils/logger"
+	"menlo.ai/indigo-api-gateway/app/utils/metrics"
 	"menlo.ai/indigo-api-gateway/config/environment_variables"
 )
 
@@ -15,10 +16,17 @@ func RegisterSchemaForAutoMigrate(models ...interface{}) {
 	SchemaRegistry = append(SchemaRegistry, models...)
 }
 
+// ExtensionRegistry lists the Postgres extensions created before the schemas are migrated
+var ExtensionRegistry []string
+
+func RegisterExtension(names ...string) {
+	ExtensionRegistry = append(ExtensionRegistry, names...)
+}
+
 var DB *gorm.DB
 
 func NewDB() (*gorm.DB, error) {
-	db, err := gorm.Open(postgres.Open(environment_variables.EnvironmentVariables.DB_POSTGRESQL_WRITE_DSN), &gorm.Config{
+	db, err := gorm.Open(postgres.Open(environment_variables.Current().DB_POSTGRESQL_WRITE_DSN), &gorm.Config{
 		NamingStrategy: schema.NamingStrategy{
 			SingularTable: true,
 		},
@@ -31,7 +39,7 @@ func NewDB() (*gorm.DB, error) {
 	}
 	err = db.Use(dbresolver.Register(dbresolver.Config{
 		Replicas: []gorm.Dialector{postgres.Open(
-			environment_variables.EnvironmentVariables.DB_POSTGRESQL_READ1_DSN,
+			environment_variables.Current().DB_POSTGRESQL_READ1_DSN,
 		)},
 		Policy: dbresolver.RandomPolicy{},
 	}))
@@ -41,6 +49,14 @@ func NewDB() (*gorm.DB, error) {
 			Fatalf("unable to connect to setup replica: %v", err)
 		return nil, err
 	}
+	if err := db.Use(tracingPlugin{}); err != nil {
+		logger.GetLogger().Warnf("unable to trace database queries: %v", err)
+	}
+	if sqlDB, err := db.DB(); err == nil {
+		if err := metrics.RegisterDBStats(sqlDB, "postgres"); err != nil {
+			logger.GetLogger().Warnf("unable to expose database pool metrics: %v", err)
+		}
+	}
 	DB = db
 	return DB, nil
 }
@@ -0,0 +1,61 @@
+package dbschema
+
+import (
+	"time"
+
+	"menlo.ai/indigo-api-gateway/app/domain/completioncache"
+	"menlo.ai/indigo-api-gateway/app/infrastructure/database"
+)
+
+func init() {
+	database.RegisterSchemaForAutoMigrate(CompletionCacheSettings{})
+}
+
+// CompletionCacheSettings rows are replaced as a whole, a project has at most one
+type CompletionCacheSettings struct {
+	ID                  uint    `gorm:"primarykey"`
+	ProjectID           uint    `gorm:"not null;uniqueIndex"`
+	Enabled             bool    `gorm:"not null;default:false"`
+	Mode                string  `gorm:"type:varchar(20);not null"`
+	TTLSeconds          int     `gorm:"not null"`
+	SimilarityThreshold float64 `gorm:"not null"`
+	EmbeddingModel      string  `gorm:"type:varchar(255)"`
+	CreatedAt           time.Time
+	UpdatedAt           time.Time
+}
+
+func (CompletionCacheSettings) TableName() string {
+	return "completion_cache_settings"
+}
+
+func NewSchemaCompletionCacheSettings(s *completioncache.Settings) *CompletionCacheSettings {
+	return &CompletionCacheSettings{
+		ID:                  s.ID,
+		ProjectID:           s.ProjectID,
+		Enabled:             s.Enabled,
+		Mode:                string(s.Mode),
+		TTLSeconds:          s.TTLSeconds,
+		SimilarityThreshold: s.SimilarityThreshold,
+		EmbeddingModel:      s.EmbeddingModel,
+		CreatedAt:           s.CreatedAt,
+		UpdatedAt:           s.UpdatedAt,
+	}
+}
+
+func (s *CompletionCacheSettings) EtoD() *completioncache.Settings {
+	mode := completioncache.Mode(s.Mode)
+	if mode == "" {
+		mode = completioncache.ModeExact
+	}
+	return &completioncache.Settings{
+		ID:                  s.ID,
+		ProjectID:           s.ProjectID,
+		Enabled:             s.Enabled,
+		Mode:                mode,
+		TTLSeconds:          s.TTLSeconds,
+		SimilarityThreshold: s.SimilarityThreshold,
+		EmbeddingModel:      s.EmbeddingModel,
+		CreatedAt:           s.CreatedAt,
+		UpdatedAt:           s.UpdatedAt,
+	}
+}
@@ -22,7 +22,7 @@ type Conversation struct {
 	WorkspacePublicID *string    `gorm:"type:varchar(50);index"`
 	Status            string     `gorm:"type:varchar(20);not null;default:'active';index"`
 	Metadata          string     `gorm:"type:text"`
-	IsPrivate         bool       `gorm:"not null;default:true;index"`
+	IsPrivate         *bool      `gorm:"not null;default:true;index"` // a pointer so false is written instead of the column default
 	Items             []Item     `gorm:"foreignKey:ConversationID;constraint:OnDelete:CASCADE;"`
 	User              User       `gorm:"foreignKey:UserID"`
 	Workspace         *Workspace `gorm:"foreignKey:WorkspacePublicID;references:PublicID;constraint:OnDelete:CASCADE;"`
@@ -65,7 +65,7 @@ func NewSchemaConversation(c *conversation.Conversation) *Conversation {
 		WorkspacePublicID: c.WorkspacePublicID,
 		Status:            string(c.Status),
 		Metadata:          metadataJSON,
-		IsPrivate:         c.IsPrivate,
+		IsPrivate:         ptr.ToBool(c.IsPrivate),
 	}
 }
 
@@ -76,6 +76,11 @@ func (c *Conversation) EtoD() *conversation.Conversation {
 	}
 
 	title := ptr.ToString(c.Title)
+	// a conversation in the trash is reported as deleted, its stored status is restored with it
+	status := conversation.ConversationStatus(c.Status)
+	if c.DeletedAt.Valid {
+		status = conversation.ConversationStatusDeleted
+	}
 
 	return &conversation.Conversation{
 		ID:                c.ID,
@@ -83,11 +88,12 @@ func (c *Conversation) EtoD() *conversation.Conversation {
 		Title:             title,
 		UserID:            c.UserID,
 		WorkspacePublicID: c.WorkspacePublicID,
-		Status:            conversation.ConversationStatus(c.Status),
+		Status:            status,
 		Metadata:          metadata,
-		IsPrivate:         c.IsPrivate,
+		IsPrivate:         c.IsPrivate == nil || *c.IsPrivate,
 		CreatedAt:         c.CreatedAt,
 		UpdatedAt:         c.UpdatedAt,
+		DeletedAt:         deletedAtPtr(c.DeletedAt),
 	}
 }
 
@@ -150,5 +156,6 @@ func (i *Item) EtoD() *conversation.Item {
 		ConversationID:    i.ConversationID,
 		ResponseID:        i.ResponseID,
 		CreatedAt:         i.CreatedAt,
+		DeletedAt:         deletedAtPtr(i.DeletedAt),
 	}
 }
@@ -0,0 +1,66 @@
+package dbschema
+
+import (
+	"time"
+
+	"menlo.ai/indigo-api-gateway/app/domain/cron"
+	"menlo.ai/indigo-api-gateway/app/infrastructure/database"
+)
+
+func init() {
+	database.RegisterSchemaForAutoMigrate(CronJobRun{})
+}
+
+// CronJobRun rows are the run history of the cron jobs, hard deleted once they leave the retention period
+type CronJobRun struct {
+	ID          uint      `gorm:"primarykey"`
+	PublicID    string    `gorm:"type:varchar(50);uniqueIndex;not null"`
+	Job         string    `gorm:"type:varchar(64);not null;index:idx_cron_job_run_job_started,priority:1"`
+	Trigger     string    `gorm:"type:varchar(20);not null"`
+	Status      string    `gorm:"type:varchar(20);not null;index"`
+	TriggeredBy *string   `gorm:"type:varchar(255)"`
+	Hostname    string    `gorm:"type:varchar(255);not null"`
+	Summary     *string   `gorm:"type:text"`
+	Error       *string   `gorm:"type:text"`
+	StartedAt   time.Time `gorm:"not null;index:idx_cron_job_run_job_started,priority:2"`
+	FinishedAt  *time.Time
+	DurationMs  int64 `gorm:"not null;default:0"`
+}
+
+func (CronJobRun) TableName() string {
+	return "cron_job_runs"
+}
+
+func NewSchemaCronJobRun(r *cron.JobRun) *CronJobRun {
+	return &CronJobRun{
+		ID:          r.ID,
+		PublicID:    r.PublicID,
+		Job:         r.Job,
+		Trigger:     r.Trigger,
+		Status:      r.Status,
+		TriggeredBy: r.TriggeredBy,
+		Hostname:    r.Hostname,
+		Summary:     r.Summary,
+		Error:       r.Error,
+		StartedAt:   r.StartedAt,
+		FinishedAt:  r.FinishedAt,
+		DurationMs:  r.DurationMs,
+	}
+}
+
+func (r *CronJobRun) EtoD() *cron.JobRun {
+	return &cron.JobRun{
+		ID:          r.ID,
+		PublicID:    r.PublicID,
+		Job:         r.Job,
+		Trigger:     r.Trigger,
+		Status:      r.Status,
+		TriggeredBy: r.TriggeredBy,
+		Hostname:    r.Hostname,
+		Summary:     r.Summary,
+		Error:       r.Error,
+		StartedAt:   r.StartedAt,
+		FinishedAt:  r.FinishedAt,
+		DurationMs:  r.DurationMs,
+	}
+}
@@ -0,0 +1,52 @@
+package dbschema
+
+import (
+	"menlo.ai/indigo-api-gateway/app/domain/file"
+	"menlo.ai/indigo-api-gateway/app/infrastructure/database"
+)
+
+func init() {
+	database.RegisterSchemaForAutoMigrate(File{})
+}
+
+type File struct {
+	BaseModel
+	PublicID   string `gorm:"type:varchar(50);uniqueIndex;not null"`
+	UserID     uint   `gorm:"not null;index"`
+	ProjectID  *uint  `gorm:"index"`
+	Filename   string `gorm:"type:varchar(255);not null"`
+	Purpose    string `gorm:"type:varchar(20);not null"`
+	Bytes      int64  `gorm:"not null"`
+	MimeType   string `gorm:"type:varchar(100);not null"`
+	StorageKey string `gorm:"type:varchar(255);not null"`
+}
+
+func NewSchemaFile(f *file.File) *File {
+	return &File{
+		BaseModel:  BaseModel{ID: f.ID},
+		PublicID:   f.PublicID,
+		UserID:     f.UserID,
+		ProjectID:  f.ProjectID,
+		Filename:   f.Filename,
+		Purpose:    string(f.Purpose),
+		Bytes:      f.Bytes,
+		MimeType:   f.MimeType,
+		StorageKey: f.StorageKey,
+	}
+}
+
+func (f *File) EtoD() *file.File {
+	return &file.File{
+		ID:         f.ID,
+		PublicID:   f.PublicID,
+		UserID:     f.UserID,
+		ProjectID:  f.ProjectID,
+		Filename:   f.Filename,
+		Purpose:    file.FilePurpose(f.Purpose),
+		Bytes:      f.Bytes,
+		MimeType:   f.MimeType,
+		StorageKey: f.StorageKey,
+		CreatedAt:  f.CreatedAt,
+		UpdatedAt:  f.UpdatedAt,
+	}
+}
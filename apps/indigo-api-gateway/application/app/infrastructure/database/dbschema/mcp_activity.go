@@ -0,0 +1,69 @@
+package dbschema
+
+import (
+	"time"
+
+	"menlo.ai/indigo-api-gateway/app/domain/mcp/mcpactivity"
+	"menlo.ai/indigo-api-gateway/app/infrastructure/database"
+)
+
+func init() {
+	database.RegisterSchemaForAutoMigrate(MCPActivity{})
+}
+
+// MCPActivity rows are append only and hard deleted once they leave the retention period
+type MCPActivity struct {
+	ID             uint      `gorm:"primarykey"`
+	PublicID       string    `gorm:"type:varchar(50);uniqueIndex;not null"`
+	OrganizationID uint      `gorm:"not null;index:idx_mcp_activity_org_created,priority:1"`
+	ProjectID      *uint     `gorm:"index"`
+	UserPublicID   *string   `gorm:"type:varchar(50);index"`
+	APIKeyPublicID *string   `gorm:"type:varchar(50);index"`
+	Method         string    `gorm:"type:varchar(64);not null"`
+	Tool           *string   `gorm:"type:varchar(128);index"`
+	ArgumentBytes  int       `gorm:"not null;default:0"`
+	LatencyMs      int64     `gorm:"not null;default:0"`
+	Success        bool      `gorm:"not null"`
+	Error          *string   `gorm:"type:text"`
+	CreatedAt      time.Time `gorm:"not null;index:idx_mcp_activity_org_created,priority:2"`
+}
+
+func (MCPActivity) TableName() string {
+	return "mcp_activities"
+}
+
+func NewSchemaMCPActivity(a *mcpactivity.Activity) *MCPActivity {
+	return &MCPActivity{
+		ID:             a.ID,
+		PublicID:       a.PublicID,
+		OrganizationID: a.OrganizationID,
+		ProjectID:      a.ProjectID,
+		UserPublicID:   a.UserPublicID,
+		APIKeyPublicID: a.APIKeyPublicID,
+		Method:         a.Method,
+		Tool:           a.Tool,
+		ArgumentBytes:  a.ArgumentBytes,
+		LatencyMs:      a.LatencyMs,
+		Success:        a.Success,
+		Error:          a.Error,
+		CreatedAt:      a.CreatedAt,
+	}
+}
+
+func (a *MCPActivity) EtoD() *mcpactivity.Activity {
+	return &mcpactivity.Activity{
+		ID:             a.ID,
+		PublicID:       a.PublicID,
+		OrganizationID: a.OrganizationID,
+		ProjectID:      a.ProjectID,
+		UserPublicID:   a.UserPublicID,
+		APIKeyPublicID: a.APIKeyPublicID,
+		Method:         a.Method,
+		Tool:           a.Tool,
+		ArgumentBytes:  a.ArgumentBytes,
+		LatencyMs:      a.LatencyMs,
+		Success:        a.Success,
+		Error:          a.Error,
+		CreatedAt:      a.CreatedAt,
+	}
+}
@@ -0,0 +1,105 @@
+package dbschema
+
+import (
+	"encoding/json"
+	"time"
+
+	"gorm.io/datatypes"
+	"menlo.ai/indigo-api-gateway/app/domain/mcp/federatedmcp"
+	"menlo.ai/indigo-api-gateway/app/infrastructure/database"
+)
+
+func init() {
+	database.RegisterSchemaForAutoMigrate(MCPServer{})
+	database.RegisterSchemaForAutoMigrate(MCPToolAllowlist{})
+}
+
+type MCPServer struct {
+	BaseModel
+	PublicID         string         `gorm:"type:varchar(50);uniqueIndex;not null"`
+	OrganizationID   uint           `gorm:"not null;index"`
+	ProjectID        *uint          `gorm:"index"`
+	Name             string         `gorm:"type:varchar(32);not null"`
+	URL              string         `gorm:"type:text;not null"`
+	Transport        string         `gorm:"type:varchar(20);not null"`
+	EncryptedHeaders string         `gorm:"type:text"`
+	HeaderNames      datatypes.JSON `gorm:"type:jsonb"`
+	Active           bool           `gorm:"not null;default:true"`
+}
+
+func (MCPServer) TableName() string {
+	return "mcp_servers"
+}
+
+// MCPToolAllowlist rows are replaced as a whole, a project has at most one
+type MCPToolAllowlist struct {
+	ID        uint           `gorm:"primarykey"`
+	ProjectID uint           `gorm:"not null;uniqueIndex"`
+	Tools     datatypes.JSON `gorm:"type:jsonb;not null"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (MCPToolAllowlist) TableName() string {
+	return "mcp_tool_allowlists"
+}
+
+func NewSchemaMCPServer(s *federatedmcp.Server) *MCPServer {
+	headerNames, _ := json.Marshal(s.HeaderNames)
+	return &MCPServer{
+		BaseModel: BaseModel{
+			ID:        s.ID,
+			CreatedAt: s.CreatedAt,
+			UpdatedAt: s.UpdatedAt,
+		},
+		PublicID:         s.PublicID,
+		OrganizationID:   s.OrganizationID,
+		ProjectID:        s.ProjectID,
+		Name:             s.Name,
+		URL:              s.URL,
+		Transport:        string(s.Transport),
+		EncryptedHeaders: s.EncryptedHeaders,
+		HeaderNames:      datatypes.JSON(headerNames),
+		Active:           s.Active,
+	}
+}
+
+func (s *MCPServer) EtoD() *federatedmcp.Server {
+	var headerNames []string
+	_ = json.Unmarshal(s.HeaderNames, &headerNames)
+	return &federatedmcp.Server{
+		ID:               s.ID,
+		PublicID:         s.PublicID,
+		OrganizationID:   s.OrganizationID,
+		ProjectID:        s.ProjectID,
+		Name:             s.Name,
+		URL:              s.URL,
+		Transport:        federatedmcp.Transport(s.Transport),
+		EncryptedHeaders: s.EncryptedHeaders,
+		HeaderNames:      headerNames,
+		Active:           s.Active,
+		CreatedAt:        s.CreatedAt,
+		UpdatedAt:        s.UpdatedAt,
+	}
+}
+
+func NewSchemaMCPToolAllowlist(a *federatedmcp.ToolAllowlist) *MCPToolAllowlist {
+	tools, _ := json.Marshal(a.Tools)
+	return &MCPToolAllowlist{
+		ID:        a.ID,
+		ProjectID: a.ProjectID,
+		Tools:     datatypes.JSON(tools),
+		UpdatedAt: a.UpdatedAt,
+	}
+}
+
+func (a *MCPToolAllowlist) EtoD() *federatedmcp.ToolAllowlist {
+	tools := []string{}
+	_ = json.Unmarshal(a.Tools, &tools)
+	return &federatedmcp.ToolAllowlist{
+		ID:        a.ID,
+		ProjectID: a.ProjectID,
+		Tools:     tools,
+		UpdatedAt: a.UpdatedAt,
+	}
+}
@@ -13,6 +13,7 @@ type Response struct {
 	PublicID           string  `gorm:"size:255;not null;uniqueIndex"`
 	UserID             uint    `gorm:"not null;index"`
 	ConversationID     *uint   `gorm:"index"`
+	ProjectID          *uint   `gorm:"index"`
 	PreviousResponseID *string `gorm:"size:255;index"`
 	Model              string  `gorm:"size:255;not null;index"`
 	Status             string  `gorm:"size:50;not null;default:'pending';index"`
@@ -39,6 +40,7 @@ type Response struct {
 	User               *string `gorm:"size:255"`
 	Usage              *string `gorm:"type:text"`
 	Error              *string `gorm:"type:text"`
+	IncompleteDetails  *string `gorm:"type:text"`
 	CompletedAt        *time.Time
 	CancelledAt        *time.Time
 	FailedAt           *time.Time
@@ -67,6 +69,7 @@ func NewSchemaResponse(r *response.Response) *Response {
 		PublicID:           r.PublicID,
 		UserID:             r.UserID,
 		ConversationID:     r.ConversationID,
+		ProjectID:          r.ProjectID,
 		PreviousResponseID: r.PreviousResponseID,
 		Model:              r.Model,
 		Status:             string(r.Status),
@@ -93,6 +96,7 @@ func NewSchemaResponse(r *response.Response) *Response {
 		User:               r.User,
 		Usage:              r.Usage,
 		Error:              r.Error,
+		IncompleteDetails:  r.IncompleteDetails,
 		CompletedAt:        r.CompletedAt,
 		CancelledAt:        r.CancelledAt,
 		FailedAt:           r.FailedAt,
@@ -106,6 +110,7 @@ func (r *Response) EtoD() *response.Response {
 		PublicID:           r.PublicID,
 		UserID:             r.UserID,
 		ConversationID:     r.ConversationID,
+		ProjectID:          r.ProjectID,
 		PreviousResponseID: r.PreviousResponseID,
 		Model:              r.Model,
 		Status:             response.ResponseStatus(r.Status),
@@ -132,6 +137,7 @@ func (r *Response) EtoD() *response.Response {
 		User:               r.User,
 		Usage:              r.Usage,
 		Error:              r.Error,
+		IncompleteDetails:  r.IncompleteDetails,
 		CompletedAt:        r.CompletedAt,
 		CancelledAt:        r.CancelledAt,
 		FailedAt:           r.FailedAt,
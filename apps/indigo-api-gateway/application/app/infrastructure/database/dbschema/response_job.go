@@ -0,0 +1,63 @@
+package dbschema
+
+import (
+	"time"
+
+	"menlo.ai/indigo-api-gateway/app/domain/response"
+	"menlo.ai/indigo-api-gateway/app/infrastructure/database"
+)
+
+// ResponseJob is the durable queue of background responses
+type ResponseJob struct {
+	BaseModel
+	ResponseID      uint `gorm:"not null;uniqueIndex"`
+	OrganizationID  uint `gorm:"not null;default:0"`
+	ProjectID       *uint
+	Payload         string  `gorm:"type:text;not null"`
+	Status          string  `gorm:"size:20;not null;index"`
+	Attempts        int     `gorm:"not null;default:0"`
+	LockedBy        *string `gorm:"size:255"`
+	LockedUntil     *time.Time
+	CancelRequested bool    `gorm:"not null;default:false"`
+	LastError       *string `gorm:"type:text"`
+}
+
+func init() {
+	database.RegisterSchemaForAutoMigrate(ResponseJob{})
+}
+
+func NewSchemaResponseJob(j *response.ResponseJob) *ResponseJob {
+	return &ResponseJob{
+		BaseModel: BaseModel{
+			ID: j.ID,
+		},
+		ResponseID:      j.ResponseID,
+		OrganizationID:  j.OrganizationID,
+		ProjectID:       j.ProjectID,
+		Payload:         j.Payload,
+		Status:          string(j.Status),
+		Attempts:        j.Attempts,
+		LockedBy:        j.LockedBy,
+		LockedUntil:     j.LockedUntil,
+		CancelRequested: j.CancelRequested,
+		LastError:       j.LastError,
+	}
+}
+
+func (j *ResponseJob) EtoD() *response.ResponseJob {
+	return &response.ResponseJob{
+		ID:              j.ID,
+		ResponseID:      j.ResponseID,
+		OrganizationID:  j.OrganizationID,
+		ProjectID:       j.ProjectID,
+		Payload:         j.Payload,
+		Status:          response.ResponseJobStatus(j.Status),
+		Attempts:        j.Attempts,
+		LockedBy:        j.LockedBy,
+		LockedUntil:     j.LockedUntil,
+		CancelRequested: j.CancelRequested,
+		LastError:       j.LastError,
+		CreatedAt:       j.CreatedAt,
+		UpdatedAt:       j.UpdatedAt,
+	}
+}
@@ -0,0 +1,208 @@
+package dbschema
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/datatypes"
+	"menlo.ai/indigo-api-gateway/app/domain/vectorstore"
+	"menlo.ai/indigo-api-gateway/app/infrastructure/database"
+)
+
+func init() {
+	database.RegisterExtension("vector")
+	database.RegisterSchemaForAutoMigrate(VectorStore{})
+	database.RegisterSchemaForAutoMigrate(VectorStoreFile{})
+	database.RegisterSchemaForAutoMigrate(VectorStoreChunk{})
+}
+
+type VectorStore struct {
+	BaseModel
+	PublicID       string         `gorm:"type:varchar(50);uniqueIndex;not null"`
+	UserID         uint           `gorm:"not null;index"`
+	ProjectID      *uint          `gorm:"index"`
+	Name           string         `gorm:"type:varchar(255)"`
+	EmbeddingModel string         `gorm:"type:varchar(255);not null"`
+	UsageBytes     int64          `gorm:"not null;default:0"`
+	Metadata       datatypes.JSON `gorm:"type:jsonb"`
+	LastActiveAt   *time.Time
+}
+
+// VectorStoreFile rows are hard deleted so a file can be attached again after being removed
+type VectorStoreFile struct {
+	ID                 uint    `gorm:"primarykey"`
+	VectorStoreID      uint    `gorm:"not null;index:idx_vector_store_file,unique"`
+	FileID             uint    `gorm:"not null;index"`
+	FilePublicID       string  `gorm:"type:varchar(50);not null;index:idx_vector_store_file,unique"`
+	Filename           string  `gorm:"type:varchar(255);not null"`
+	Status             string  `gorm:"type:varchar(20);not null;index"`
+	LastError          *string `gorm:"type:text"`
+	UsageBytes         int64   `gorm:"not null;default:0"`
+	ChunkCount         int     `gorm:"not null;default:0"`
+	MaxChunkSizeTokens int     `gorm:"not null"`
+	ChunkOverlapTokens int     `gorm:"not null"`
+	LockedUntil        *time.Time
+	CreatedAt          time.Time
+	UpdatedAt          time.Time
+}
+
+// VectorStoreChunk holds an embedded slice of a file, searched with the pgvector cosine distance operator
+type VectorStoreChunk struct {
+	ID                uint   `gorm:"primarykey"`
+	VectorStoreID     uint   `gorm:"not null;index"`
+	VectorStoreFileID uint   `gorm:"not null;index"`
+	FilePublicID      string `gorm:"type:varchar(50);not null;index"`
+	Filename          string `gorm:"type:varchar(255);not null"`
+	ChunkIndex        int    `gorm:"not null"`
+	Text              string `gorm:"type:text;not null"`
+	Embedding         Vector `gorm:"type:vector;not null"`
+	CreatedAt         time.Time
+}
+
+// Vector maps a float slice to the pgvector text representation
+type Vector []float32
+
+func (v Vector) Value() (driver.Value, error) {
+	var b strings.Builder
+	b.WriteByte('[')
+	for i, value := range v {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(strconv.FormatFloat(float64(value), 'f', -1, 32))
+	}
+	b.WriteByte(']')
+	return b.String(), nil
+}
+
+func (v *Vector) Scan(src any) error {
+	var text string
+	switch value := src.(type) {
+	case string:
+		text = value
+	case []byte:
+		text = string(value)
+	case nil:
+		*v = nil
+		return nil
+	default:
+		return fmt.Errorf("cannot scan %T into Vector", src)
+	}
+	text = strings.Trim(strings.TrimSpace(text), "[]")
+	if text == "" {
+		*v = Vector{}
+		return nil
+	}
+	parts := strings.Split(text, ",")
+	vector := make(Vector, len(parts))
+	for i, part := range parts {
+		value, err := strconv.ParseFloat(strings.TrimSpace(part), 32)
+		if err != nil {
+			return err
+		}
+		vector[i] = float32(value)
+	}
+	*v = vector
+	return nil
+}
+
+func NewSchemaVectorStore(s *vectorstore.VectorStore) *VectorStore {
+	var metadataJSON datatypes.JSON
+	if len(s.Metadata) > 0 {
+		if data, err := json.Marshal(s.Metadata); err == nil {
+			metadataJSON = datatypes.JSON(data)
+		}
+	}
+	return &VectorStore{
+		BaseModel: BaseModel{
+			ID:        s.ID,
+			CreatedAt: s.CreatedAt,
+			UpdatedAt: s.UpdatedAt,
+		},
+		PublicID:       s.PublicID,
+		UserID:         s.UserID,
+		ProjectID:      s.ProjectID,
+		Name:           s.Name,
+		EmbeddingModel: s.EmbeddingModel,
+		UsageBytes:     s.UsageBytes,
+		Metadata:       metadataJSON,
+		LastActiveAt:   s.LastActiveAt,
+	}
+}
+
+func (s *VectorStore) EtoD() *vectorstore.VectorStore {
+	var metadata map[string]string
+	if len(s.Metadata) > 0 {
+		_ = json.Unmarshal(s.Metadata, &metadata)
+	}
+	return &vectorstore.VectorStore{
+		ID:             s.ID,
+		PublicID:       s.PublicID,
+		UserID:         s.UserID,
+		ProjectID:      s.ProjectID,
+		Name:           s.Name,
+		EmbeddingModel: s.EmbeddingModel,
+		UsageBytes:     s.UsageBytes,
+		Metadata:       metadata,
+		LastActiveAt:   s.LastActiveAt,
+		CreatedAt:      s.CreatedAt,
+		UpdatedAt:      s.UpdatedAt,
+	}
+}
+
+func NewSchemaVectorStoreFile(f *vectorstore.VectorStoreFile) *VectorStoreFile {
+	return &VectorStoreFile{
+		ID:                 f.ID,
+		VectorStoreID:      f.VectorStoreID,
+		FileID:             f.FileID,
+		FilePublicID:       f.FilePublicID,
+		Filename:           f.Filename,
+		Status:             string(f.Status),
+		LastError:          f.LastError,
+		UsageBytes:         f.UsageBytes,
+		ChunkCount:         f.ChunkCount,
+		MaxChunkSizeTokens: f.Chunking.MaxChunkSizeTokens,
+		ChunkOverlapTokens: f.Chunking.ChunkOverlapTokens,
+		LockedUntil:        f.LockedUntil,
+		CreatedAt:          f.CreatedAt,
+		UpdatedAt:          f.UpdatedAt,
+	}
+}
+
+func (f *VectorStoreFile) EtoD() *vectorstore.VectorStoreFile {
+	return &vectorstore.VectorStoreFile{
+		ID:            f.ID,
+		VectorStoreID: f.VectorStoreID,
+		FileID:        f.FileID,
+		FilePublicID:  f.FilePublicID,
+		Filename:      f.Filename,
+		Status:        vectorstore.VectorStoreFileStatus(f.Status),
+		LastError:     f.LastError,
+		UsageBytes:    f.UsageBytes,
+		ChunkCount:    f.ChunkCount,
+		Chunking: vectorstore.ChunkingStrategy{
+			MaxChunkSizeTokens: f.MaxChunkSizeTokens,
+			ChunkOverlapTokens: f.ChunkOverlapTokens,
+		},
+		LockedUntil: f.LockedUntil,
+		CreatedAt:   f.CreatedAt,
+		UpdatedAt:   f.UpdatedAt,
+	}
+}
+
+func NewSchemaVectorStoreChunk(c *vectorstore.Chunk) *VectorStoreChunk {
+	return &VectorStoreChunk{
+		ID:                c.ID,
+		VectorStoreID:     c.VectorStoreID,
+		VectorStoreFileID: c.VectorStoreFileID,
+		FilePublicID:      c.FilePublicID,
+		Filename:          c.Filename,
+		ChunkIndex:        c.ChunkIndex,
+		Text:              c.Text,
+		Embedding:         Vector(c.Embedding),
+	}
+}
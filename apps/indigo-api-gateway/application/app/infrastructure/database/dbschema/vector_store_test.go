@@ -0,0 +1,56 @@
+package dbschema
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestVectorValueScanRoundTrip(t *testing.T) {
+	vectors := []Vector{
+		{0.1, -2.5, 3, 1e-7, 123456.75},
+		{},
+	}
+	for _, vector := range vectors {
+		value, err := vector.Value()
+		if err != nil {
+			t.Fatalf("failed to encode %v: %v", vector, err)
+		}
+
+		var fromString, fromBytes Vector
+		if err := fromString.Scan(value); err != nil {
+			t.Fatalf("failed to scan %v: %v", value, err)
+		}
+		if err := fromBytes.Scan([]byte(value.(string))); err != nil {
+			t.Fatalf("failed to scan bytes %v: %v", value, err)
+		}
+		if !reflect.DeepEqual(fromString, vector) || !reflect.DeepEqual(fromBytes, vector) {
+			t.Fatalf("expected %v, got %v and %v", vector, fromString, fromBytes)
+		}
+	}
+}
+
+func TestVectorValueFormat(t *testing.T) {
+	value, err := Vector{1, 0.5, -0.25}.Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value != "[1,0.5,-0.25]" {
+		t.Fatalf("expected the pgvector text format, got %v", value)
+	}
+}
+
+func TestVectorScan(t *testing.T) {
+	var vector Vector
+	if err := vector.Scan("[ 1, 2.5 ,3 ]"); err != nil || !reflect.DeepEqual(vector, Vector{1, 2.5, 3}) {
+		t.Fatalf("expected spaces to be ignored, got %v, %v", vector, err)
+	}
+	if err := vector.Scan(nil); err != nil || vector != nil {
+		t.Fatalf("expected NULL to scan to nil, got %v, %v", vector, err)
+	}
+	if err := vector.Scan("[1,abc]"); err == nil {
+		t.Fatal("expected an invalid component to fail")
+	}
+	if err := vector.Scan(42); err == nil {
+		t.Fatal("expected an unsupported source type to fail")
+	}
+}
@@ -0,0 +1,159 @@
+package dbschema
+
+import (
+	"encoding/json"
+	"time"
+
+	"gorm.io/datatypes"
+	"menlo.ai/indigo-api-gateway/app/domain/webhook"
+	"menlo.ai/indigo-api-gateway/app/infrastructure/database"
+)
+
+func init() {
+	database.RegisterSchemaForAutoMigrate(WebhookEndpoint{})
+	database.RegisterSchemaForAutoMigrate(WebhookDelivery{})
+	database.RegisterSchemaForAutoMigrate(WebhookDeliveryAttempt{})
+}
+
+type WebhookEndpoint struct {
+	BaseModel
+	PublicID    string `gorm:"type:varchar(50);uniqueIndex;not null"`
+	ProjectID   uint   `gorm:"not null;index"`
+	URL         string `gorm:"type:text;not null"`
+	Description string `gorm:"type:varchar(255)"`
+	// EncryptedSecret keeps the column of the signing secrets stored in clear by earlier versions
+	EncryptedSecret string         `gorm:"column:secret;type:text;not null"`
+	EventTypes      datatypes.JSON `gorm:"type:jsonb;not null"`
+	Enabled         bool           `gorm:"not null;default:true"`
+}
+
+// WebhookDelivery rows are hard deleted together with their endpoint
+type WebhookDelivery struct {
+	ID             uint      `gorm:"primarykey"`
+	PublicID       string    `gorm:"type:varchar(50);uniqueIndex;not null"`
+	EndpointID     uint      `gorm:"not null;index"`
+	EventID        string    `gorm:"type:varchar(50);not null;index"`
+	EventType      string    `gorm:"type:varchar(50);not null"`
+	Payload        string    `gorm:"type:text;not null"`
+	Status         string    `gorm:"type:varchar(20);not null;index:idx_webhook_delivery_due,priority:1"`
+	Attempts       int       `gorm:"not null;default:0"`
+	NextAttemptAt  time.Time `gorm:"not null;index:idx_webhook_delivery_due,priority:2"`
+	LockedUntil    *time.Time
+	LastStatusCode *int
+	LastError      *string `gorm:"type:text"`
+	DeliveredAt    *time.Time
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+type WebhookDeliveryAttempt struct {
+	ID           uint `gorm:"primarykey"`
+	DeliveryID   uint `gorm:"not null;index"`
+	Attempt      int  `gorm:"not null"`
+	StatusCode   *int
+	Error        *string `gorm:"type:text"`
+	ResponseBody *string `gorm:"type:text"`
+	DurationMs   int64   `gorm:"not null"`
+	CreatedAt    time.Time
+}
+
+func NewSchemaWebhookEndpoint(e *webhook.Endpoint) *WebhookEndpoint {
+	eventTypes, _ := json.Marshal(e.EventTypes)
+	return &WebhookEndpoint{
+		BaseModel: BaseModel{
+			ID:        e.ID,
+			CreatedAt: e.CreatedAt,
+			UpdatedAt: e.UpdatedAt,
+		},
+		PublicID:        e.PublicID,
+		ProjectID:       e.ProjectID,
+		URL:             e.URL,
+		Description:     e.Description,
+		EncryptedSecret: e.EncryptedSecret,
+		EventTypes:      datatypes.JSON(eventTypes),
+		Enabled:         e.Enabled,
+	}
+}
+
+func (e *WebhookEndpoint) EtoD() *webhook.Endpoint {
+	var eventTypes []string
+	_ = json.Unmarshal(e.EventTypes, &eventTypes)
+	return &webhook.Endpoint{
+		ID:              e.ID,
+		PublicID:        e.PublicID,
+		ProjectID:       e.ProjectID,
+		URL:             e.URL,
+		Description:     e.Description,
+		EncryptedSecret: e.EncryptedSecret,
+		EventTypes:      eventTypes,
+		Enabled:         e.Enabled,
+		CreatedAt:       e.CreatedAt,
+		UpdatedAt:       e.UpdatedAt,
+	}
+}
+
+func NewSchemaWebhookDelivery(d *webhook.Delivery) *WebhookDelivery {
+	return &WebhookDelivery{
+		ID:             d.ID,
+		PublicID:       d.PublicID,
+		EndpointID:     d.EndpointID,
+		EventID:        d.EventID,
+		EventType:      string(d.EventType),
+		Payload:        d.Payload,
+		Status:         string(d.Status),
+		Attempts:       d.Attempts,
+		NextAttemptAt:  d.NextAttemptAt,
+		LockedUntil:    d.LockedUntil,
+		LastStatusCode: d.LastStatusCode,
+		LastError:      d.LastError,
+		DeliveredAt:    d.DeliveredAt,
+		CreatedAt:      d.CreatedAt,
+		UpdatedAt:      d.UpdatedAt,
+	}
+}
+
+func (d *WebhookDelivery) EtoD() *webhook.Delivery {
+	return &webhook.Delivery{
+		ID:             d.ID,
+		PublicID:       d.PublicID,
+		EndpointID:     d.EndpointID,
+		EventID:        d.EventID,
+		EventType:      webhook.EventType(d.EventType),
+		Payload:        d.Payload,
+		Status:         webhook.DeliveryStatus(d.Status),
+		Attempts:       d.Attempts,
+		NextAttemptAt:  d.NextAttemptAt,
+		LockedUntil:    d.LockedUntil,
+		LastStatusCode: d.LastStatusCode,
+		LastError:      d.LastError,
+		DeliveredAt:    d.DeliveredAt,
+		CreatedAt:      d.CreatedAt,
+		UpdatedAt:      d.UpdatedAt,
+	}
+}
+
+func NewSchemaWebhookDeliveryAttempt(a *webhook.DeliveryAttempt) *WebhookDeliveryAttempt {
+	return &WebhookDeliveryAttempt{
+		ID:           a.ID,
+		DeliveryID:   a.DeliveryID,
+		Attempt:      a.Attempt,
+		StatusCode:   a.StatusCode,
+		Error:        a.Error,
+		ResponseBody: a.ResponseBody,
+		DurationMs:   a.DurationMs,
+		CreatedAt:    a.CreatedAt,
+	}
+}
+
+func (a *WebhookDeliveryAttempt) EtoD() *webhook.DeliveryAttempt {
+	return &webhook.DeliveryAttempt{
+		ID:           a.ID,
+		DeliveryID:   a.DeliveryID,
+		Attempt:      a.Attempt,
+		StatusCode:   a.StatusCode,
+		Error:        a.Error,
+		ResponseBody: a.ResponseBody,
+		DurationMs:   a.DurationMs,
+		CreatedAt:    a.CreatedAt,
+	}
+}
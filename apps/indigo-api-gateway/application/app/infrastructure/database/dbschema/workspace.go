@@ -1,42 +1,133 @@
 package dbschema
 
 import (
+	"encoding/json"
+	"time"
+
+	"gorm.io/datatypes"
+
+	"menlo.ai/indigo-api-gateway/app/domain/conversation"
 	"menlo.ai/indigo-api-gateway/app/domain/workspace"
 	"menlo.ai/indigo-api-gateway/app/infrastructure/database"
 )
 
 func init() {
 	database.RegisterSchemaForAutoMigrate(Workspace{})
+	database.RegisterSchemaForAutoMigrate(WorkspaceMember{})
+	database.RegisterSchemaForAutoMigrate(WorkspaceInvitation{})
 }
 
 type Workspace struct {
 	BaseModel
-	PublicID      string         `gorm:"type:varchar(50);uniqueIndex;not null"`
-	UserID        uint           `gorm:"not null;index"`
-	Name          string         `gorm:"type:varchar(255);not null"`
-	Instruction   *string        `gorm:"type:text"`
-	Conversations []Conversation `gorm:"foreignKey:WorkspacePublicID;references:PublicID;constraint:OnDelete:CASCADE;"`
-	User          User           `gorm:"foreignKey:UserID"`
+	PublicID           string         `gorm:"type:varchar(50);uniqueIndex;not null"`
+	UserID             uint           `gorm:"not null;index"`
+	Name               string         `gorm:"type:varchar(255);not null"`
+	Instruction        *string        `gorm:"type:text"`
+	ContextSettings    datatypes.JSON `gorm:"type:jsonb"`
+	ShareConversations bool           `gorm:"not null;default:false"`
+	Conversations      []Conversation `gorm:"foreignKey:WorkspacePublicID;references:PublicID;constraint:OnDelete:CASCADE;"`
+	User               User           `gorm:"foreignKey:UserID"`
 }
 
 func NewSchemaWorkspace(w *workspace.Workspace) *Workspace {
+	var contextSettings datatypes.JSON
+	if w.ContextSettings != nil {
+		if data, err := json.Marshal(w.ContextSettings); err == nil {
+			contextSettings = datatypes.JSON(data)
+		}
+	}
 	return &Workspace{
-		BaseModel:   BaseModel{ID: w.ID},
-		PublicID:    w.PublicID,
-		UserID:      w.UserID,
-		Name:        w.Name,
-		Instruction: w.Instruction,
+		BaseModel:          BaseModel{ID: w.ID},
+		PublicID:           w.PublicID,
+		UserID:             w.UserID,
+		Name:               w.Name,
+		Instruction:        w.Instruction,
+		ContextSettings:    contextSettings,
+		ShareConversations: w.ShareConversations,
 	}
 }
 
 func (w *Workspace) EtoD() *workspace.Workspace {
+	var contextSettings *conversation.ContextSettings
+	if len(w.ContextSettings) > 0 {
+		var settings conversation.ContextSettings
+		if err := json.Unmarshal(w.ContextSettings, &settings); err == nil {
+			contextSettings = &settings
+		}
+	}
 	return &workspace.Workspace{
-		ID:          w.ID,
-		PublicID:    w.PublicID,
-		UserID:      w.UserID,
-		Name:        w.Name,
-		Instruction: w.Instruction,
-		CreatedAt:   w.CreatedAt,
-		UpdatedAt:   w.UpdatedAt,
+		ID:                 w.ID,
+		PublicID:           w.PublicID,
+		UserID:             w.UserID,
+		Name:               w.Name,
+		Instruction:        w.Instruction,
+		ContextSettings:    contextSettings,
+		ShareConversations: w.ShareConversations,
+		CreatedAt:          w.CreatedAt,
+		UpdatedAt:          w.UpdatedAt,
+	}
+}
+
+type WorkspaceMember struct {
+	BaseModel
+	WorkspaceID uint   `gorm:"not null;index:idx_user_workspace,unique"`
+	UserID      uint   `gorm:"not null;index:idx_user_workspace,unique;index"`
+	Role        string `gorm:"type:varchar(20);not null"`
+}
+
+func NewSchemaWorkspaceMember(m *workspace.WorkspaceMember) *WorkspaceMember {
+	return &WorkspaceMember{
+		BaseModel:   BaseModel{ID: m.ID},
+		WorkspaceID: m.WorkspaceID,
+		UserID:      m.UserID,
+		Role:        string(m.Role),
+	}
+}
+
+func (m *WorkspaceMember) EtoD() *workspace.WorkspaceMember {
+	return &workspace.WorkspaceMember{
+		ID:          m.ID,
+		WorkspaceID: m.WorkspaceID,
+		UserID:      m.UserID,
+		Role:        workspace.WorkspaceRole(m.Role),
+		CreatedAt:   m.CreatedAt,
+	}
+}
+
+type WorkspaceInvitation struct {
+	BaseModel
+	PublicID      string     `gorm:"type:varchar(50);uniqueIndex;not null"`
+	WorkspaceID   uint       `gorm:"not null;index"`
+	InviteeUserID uint       `gorm:"not null;index"`
+	InviterUserID uint       `gorm:"not null"`
+	Role          string     `gorm:"type:varchar(20);not null"`
+	Status        string     `gorm:"type:varchar(20);not null;index"`
+	RespondedAt   *time.Time `gorm:"type:timestamp"`
+}
+
+func NewSchemaWorkspaceInvitation(i *workspace.WorkspaceInvitation) *WorkspaceInvitation {
+	return &WorkspaceInvitation{
+		BaseModel:     BaseModel{ID: i.ID},
+		PublicID:      i.PublicID,
+		WorkspaceID:   i.WorkspaceID,
+		InviteeUserID: i.InviteeUserID,
+		InviterUserID: i.InviterUserID,
+		Role:          string(i.Role),
+		Status:        string(i.Status),
+		RespondedAt:   i.RespondedAt,
+	}
+}
+
+func (i *WorkspaceInvitation) EtoD() *workspace.WorkspaceInvitation {
+	return &workspace.WorkspaceInvitation{
+		ID:            i.ID,
+		PublicID:      i.PublicID,
+		WorkspaceID:   i.WorkspaceID,
+		InviteeUserID: i.InviteeUserID,
+		InviterUserID: i.InviterUserID,
+		Role:          workspace.WorkspaceRole(i.Role),
+		Status:        workspace.WorkspaceInvitationStatus(i.Status),
+		CreatedAt:     i.CreatedAt,
+		RespondedAt:   i.RespondedAt,
 	}
 }
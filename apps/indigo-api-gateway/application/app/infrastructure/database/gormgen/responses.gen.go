@@ -35,6 +35,7 @@ func newResponse(db *gorm.DB, opts ...gen.DOOption) response {
 	_response.PublicID = field.NewString(tableName, "public_id")
 	_response.UserID = field.NewUint(tableName, "user_id")
 	_response.ConversationID = field.NewUint(tableName, "conversation_id")
+	_response.ProjectID = field.NewUint(tableName, "project_id")
 	_response.PreviousResponseID = field.NewString(tableName, "previous_response_id")
 	_response.Model = field.NewString(tableName, "model")
 	_response.Status = field.NewString(tableName, "status")
@@ -61,6 +62,7 @@ func newResponse(db *gorm.DB, opts ...gen.DOOption) response {
 	_response.User = field.NewString(tableName, "user")
 	_response.Usage = field.NewString(tableName, "usage")
 	_response.Error = field.NewString(tableName, "error")
+	_response.IncompleteDetails = field.NewString(tableName, "incomplete_details")
 	_response.CompletedAt = field.NewTime(tableName, "completed_at")
 	_response.CancelledAt = field.NewTime(tableName, "cancelled_at")
 	_response.FailedAt = field.NewTime(tableName, "failed_at")
@@ -200,6 +202,7 @@ type response struct {
 	PublicID           field.String
 	UserID             field.Uint
 	ConversationID     field.Uint
+	ProjectID          field.Uint
 	PreviousResponseID field.String
 	Model              field.String
 	Status             field.String
@@ -226,6 +229,7 @@ type response struct {
 	User               field.String
 	Usage              field.String
 	Error              field.String
+	IncompleteDetails  field.String
 	CompletedAt        field.Time
 	CancelledAt        field.Time
 	FailedAt           field.Time
@@ -257,6 +261,7 @@ func (r *response) updateTableName(table string) *response {
 	r.PublicID = field.NewString(table, "public_id")
 	r.UserID = field.NewUint(table, "user_id")
 	r.ConversationID = field.NewUint(table, "conversation_id")
+	r.ProjectID = field.NewUint(table, "project_id")
 	r.PreviousResponseID = field.NewString(table, "previous_response_id")
 	r.Model = field.NewString(table, "model")
 	r.Status = field.NewString(table, "status")
@@ -283,6 +288,7 @@ func (r *response) updateTableName(table string) *response {
 	r.User = field.NewString(table, "user")
 	r.Usage = field.NewString(table, "usage")
 	r.Error = field.NewString(table, "error")
+	r.IncompleteDetails = field.NewString(table, "incomplete_details")
 	r.CompletedAt = field.NewTime(table, "completed_at")
 	r.CancelledAt = field.NewTime(table, "cancelled_at")
 	r.FailedAt = field.NewTime(table, "failed_at")
@@ -302,7 +308,7 @@ func (r *response) GetFieldByName(fieldName string) (field.OrderExpr, bool) {
 }
 
 func (r *response) fillFieldMap() {
-	r.fieldMap = make(map[string]field.Expr, 39)
+	r.fieldMap = make(map[string]field.Expr, 41)
 	r.fieldMap["id"] = r.ID
 	r.fieldMap["created_at"] = r.CreatedAt
 	r.fieldMap["updated_at"] = r.UpdatedAt
@@ -310,6 +316,7 @@ func (r *response) fillFieldMap() {
 	r.fieldMap["public_id"] = r.PublicID
 	r.fieldMap["user_id"] = r.UserID
 	r.fieldMap["conversation_id"] = r.ConversationID
+	r.fieldMap["project_id"] = r.ProjectID
 	r.fieldMap["previous_response_id"] = r.PreviousResponseID
 	r.fieldMap["model"] = r.Model
 	r.fieldMap["status"] = r.Status
@@ -336,6 +343,7 @@ func (r *response) fillFieldMap() {
 	r.fieldMap["user"] = r.User
 	r.fieldMap["usage"] = r.Usage
 	r.fieldMap["error"] = r.Error
+	r.fieldMap["incomplete_details"] = r.IncompleteDetails
 	r.fieldMap["completed_at"] = r.CompletedAt
 	r.fieldMap["cancelled_at"] = r.CancelledAt
 	r.fieldMap["failed_at"] = r.FailedAt
@@ -0,0 +1,423 @@
+// Code generated by gorm.io/gen. DO NOT EDIT.
+// Code generated by gorm.io/gen. DO NOT EDIT.
+// Code generated by gorm.io/gen. DO NOT EDIT.
+
+package gormgen
+
+import (
+	"context"
+	"database/sql"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+
+	"gorm.io/gen"
+	"gorm.io/gen/field"
+
+	"gorm.io/plugin/dbresolver"
+
+	"menlo.ai/indigo-api-gateway/app/infrastructure/database/dbschema"
+)
+
+func newWorkspaceInvitation(db *gorm.DB, opts ...gen.DOOption) workspaceInvitation {
+	_workspaceInvitation := workspaceInvitation{}
+
+	_workspaceInvitation.workspaceInvitationDo.UseDB(db, opts...)
+	_workspaceInvitation.workspaceInvitationDo.UseModel(&dbschema.WorkspaceInvitation{})
+
+	tableName := _workspaceInvitation.workspaceInvitationDo.TableName()
+	_workspaceInvitation.ALL = field.NewAsterisk(tableName)
+	_workspaceInvitation.ID = field.NewUint(tableName, "id")
+	_workspaceInvitation.CreatedAt = field.NewTime(tableName, "created_at")
+	_workspaceInvitation.UpdatedAt = field.NewTime(tableName, "updated_at")
+	_workspaceInvitation.DeletedAt = field.NewField(tableName, "deleted_at")
+	_workspaceInvitation.PublicID = field.NewString(tableName, "public_id")
+	_workspaceInvitation.WorkspaceID = field.NewUint(tableName, "workspace_id")
+	_workspaceInvitation.InviteeUserID = field.NewUint(tableName, "invitee_user_id")
+	_workspaceInvitation.InviterUserID = field.NewUint(tableName, "inviter_user_id")
+	_workspaceInvitation.Role = field.NewString(tableName, "role")
+	_workspaceInvitation.Status = field.NewString(tableName, "status")
+	_workspaceInvitation.RespondedAt = field.NewTime(tableName, "responded_at")
+
+	_workspaceInvitation.fillFieldMap()
+
+	return _workspaceInvitation
+}
+
+type workspaceInvitation struct {
+	workspaceInvitationDo
+
+	ALL           field.Asterisk
+	ID            field.Uint
+	CreatedAt     field.Time
+	UpdatedAt     field.Time
+	DeletedAt     field.Field
+	PublicID      field.String
+	WorkspaceID   field.Uint
+	InviteeUserID field.Uint
+	InviterUserID field.Uint
+	Role          field.String
+	Status        field.String
+	RespondedAt   field.Time
+
+	fieldMap map[string]field.Expr
+}
+
+func (w workspaceInvitation) Table(newTableName string) *workspaceInvitation {
+	w.workspaceInvitationDo.UseTable(newTableName)
+	return w.updateTableName(newTableName)
+}
+
+func (w workspaceInvitation) As(alias string) *workspaceInvitation {
+	w.workspaceInvitationDo.DO = *(w.workspaceInvitationDo.As(alias).(*gen.DO))
+	return w.updateTableName(alias)
+}
+
+func (w *workspaceInvitation) updateTableName(table string) *workspaceInvitation {
+	w.ALL = field.NewAsterisk(table)
+	w.ID = field.NewUint(table, "id")
+	w.CreatedAt = field.NewTime(table, "created_at")
+	w.UpdatedAt = field.NewTime(table, "updated_at")
+	w.DeletedAt = field.NewField(table, "deleted_at")
+	w.PublicID = field.NewString(table, "public_id")
+	w.WorkspaceID = field.NewUint(table, "workspace_id")
+	w.InviteeUserID = field.NewUint(table, "invitee_user_id")
+	w.InviterUserID = field.NewUint(table, "inviter_user_id")
+	w.Role = field.NewString(table, "role")
+	w.Status = field.NewString(table, "status")
+	w.RespondedAt = field.NewTime(table, "responded_at")
+
+	w.fillFieldMap()
+
+	return w
+}
+
+func (w *workspaceInvitation) GetFieldByName(fieldName string) (field.OrderExpr, bool) {
+	_f, ok := w.fieldMap[fieldName]
+	if !ok || _f == nil {
+		return nil, false
+	}
+	_oe, ok := _f.(field.OrderExpr)
+	return _oe, ok
+}
+
+func (w *workspaceInvitation) fillFieldMap() {
+	w.fieldMap = make(map[string]field.Expr, 11)
+	w.fieldMap["id"] = w.ID
+	w.fieldMap["created_at"] = w.CreatedAt
+	w.fieldMap["updated_at"] = w.UpdatedAt
+	w.fieldMap["deleted_at"] = w.DeletedAt
+	w.fieldMap["public_id"] = w.PublicID
+	w.fieldMap["workspace_id"] = w.WorkspaceID
+	w.fieldMap["invitee_user_id"] = w.InviteeUserID
+	w.fieldMap["inviter_user_id"] = w.InviterUserID
+	w.fieldMap["role"] = w.Role
+	w.fieldMap["status"] = w.Status
+	w.fieldMap["responded_at"] = w.RespondedAt
+}
+
+func (w workspaceInvitation) clone(db *gorm.DB) workspaceInvitation {
+	w.workspaceInvitationDo.ReplaceConnPool(db.Statement.ConnPool)
+	return w
+}
+
+func (w workspaceInvitation) replaceDB(db *gorm.DB) workspaceInvitation {
+	w.workspaceInvitationDo.ReplaceDB(db)
+	return w
+}
+
+type workspaceInvitationDo struct{ gen.DO }
+
+type IWorkspaceInvitationDo interface {
+	gen.SubQuery
+	Debug() IWorkspaceInvitationDo
+	WithContext(ctx context.Context) IWorkspaceInvitationDo
+	WithResult(fc func(tx gen.Dao)) gen.ResultInfo
+	ReplaceDB(db *gorm.DB)
+	ReadDB() IWorkspaceInvitationDo
+	WriteDB() IWorkspaceInvitationDo
+	As(alias string) gen.Dao
+	Session(config *gorm.Session) IWorkspaceInvitationDo
+	Columns(cols ...field.Expr) gen.Columns
+	Clauses(conds ...clause.Expression) IWorkspaceInvitationDo
+	Not(conds ...gen.Condition) IWorkspaceInvitationDo
+	Or(conds ...gen.Condition) IWorkspaceInvitationDo
+	Select(conds ...field.Expr) IWorkspaceInvitationDo
+	Where(conds ...gen.Condition) IWorkspaceInvitationDo
+	Order(conds ...field.Expr) IWorkspaceInvitationDo
+	Distinct(cols ...field.Expr) IWorkspaceInvitationDo
+	Omit(cols ...field.Expr) IWorkspaceInvitationDo
+	Join(table schema.Tabler, on ...field.Expr) IWorkspaceInvitationDo
+	LeftJoin(table schema.Tabler, on ...field.Expr) IWorkspaceInvitationDo
+	RightJoin(table schema.Tabler, on ...field.Expr) IWorkspaceInvitationDo
+	Group(cols ...field.Expr) IWorkspaceInvitationDo
+	Having(conds ...gen.Condition) IWorkspaceInvitationDo
+	Limit(limit int) IWorkspaceInvitationDo
+	Offset(offset int) IWorkspaceInvitationDo
+	Count() (count int64, err error)
+	Scopes(funcs ...func(gen.Dao) gen.Dao) IWorkspaceInvitationDo
+	Unscoped() IWorkspaceInvitationDo
+	Create(values ...*dbschema.WorkspaceInvitation) error
+	CreateInBatches(values []*dbschema.WorkspaceInvitation, batchSize int) error
+	Save(values ...*dbschema.WorkspaceInvitation) error
+	First() (*dbschema.WorkspaceInvitation, error)
+	Take() (*dbschema.WorkspaceInvitation, error)
+	Last() (*dbschema.WorkspaceInvitation, error)
+	Find() ([]*dbschema.WorkspaceInvitation, error)
+	FindInBatch(batchSize int, fc func(tx gen.Dao, batch int) error) (results []*dbschema.WorkspaceInvitation, err error)
+	FindInBatches(result *[]*dbschema.WorkspaceInvitation, batchSize int, fc func(tx gen.Dao, batch int) error) error
+	Pluck(column field.Expr, dest interface{}) error
+	Delete(...*dbschema.WorkspaceInvitation) (info gen.ResultInfo, err error)
+	Update(column field.Expr, value interface{}) (info gen.ResultInfo, err error)
+	UpdateSimple(columns ...field.AssignExpr) (info gen.ResultInfo, err error)
+	Updates(value interface{}) (info gen.ResultInfo, err error)
+	UpdateColumn(column field.Expr, value interface{}) (info gen.ResultInfo, err error)
+	UpdateColumnSimple(columns ...field.AssignExpr) (info gen.ResultInfo, err error)
+	UpdateColumns(value interface{}) (info gen.ResultInfo, err error)
+	UpdateFrom(q gen.SubQuery) gen.Dao
+	Attrs(attrs ...field.AssignExpr) IWorkspaceInvitationDo
+	Assign(attrs ...field.AssignExpr) IWorkspaceInvitationDo
+	Joins(fields ...field.RelationField) IWorkspaceInvitationDo
+	Preload(fields ...field.RelationField) IWorkspaceInvitationDo
+	FirstOrInit() (*dbschema.WorkspaceInvitation, error)
+	FirstOrCreate() (*dbschema.WorkspaceInvitation, error)
+	FindByPage(offset int, limit int) (result []*dbschema.WorkspaceInvitation, count int64, err error)
+	ScanByPage(result interface{}, offset int, limit int) (count int64, err error)
+	Rows() (*sql.Rows, error)
+	Row() *sql.Row
+	Scan(result interface{}) (err error)
+	Returning(value interface{}, columns ...string) IWorkspaceInvitationDo
+	UnderlyingDB() *gorm.DB
+	schema.Tabler
+}
+
+func (w workspaceInvitationDo) Debug() IWorkspaceInvitationDo {
+	return w.withDO(w.DO.Debug())
+}
+
+func (w workspaceInvitationDo) WithContext(ctx context.Context) IWorkspaceInvitationDo {
+	return w.withDO(w.DO.WithContext(ctx))
+}
+
+func (w workspaceInvitationDo) ReadDB() IWorkspaceInvitationDo {
+	return w.Clauses(dbresolver.Read)
+}
+
+func (w workspaceInvitationDo) WriteDB() IWorkspaceInvitationDo {
+	return w.Clauses(dbresolver.Write)
+}
+
+func (w workspaceInvitationDo) Session(config *gorm.Session) IWorkspaceInvitationDo {
+	return w.withDO(w.DO.Session(config))
+}
+
+func (w workspaceInvitationDo) Clauses(conds ...clause.Expression) IWorkspaceInvitationDo {
+	return w.withDO(w.DO.Clauses(conds...))
+}
+
+func (w workspaceInvitationDo) Returning(value interface{}, columns ...string) IWorkspaceInvitationDo {
+	return w.withDO(w.DO.Returning(value, columns...))
+}
+
+func (w workspaceInvitationDo) Not(conds ...gen.Condition) IWorkspaceInvitationDo {
+	return w.withDO(w.DO.Not(conds...))
+}
+
+func (w workspaceInvitationDo) Or(conds ...gen.Condition) IWorkspaceInvitationDo {
+	return w.withDO(w.DO.Or(conds...))
+}
+
+func (w workspaceInvitationDo) Select(conds ...field.Expr) IWorkspaceInvitationDo {
+	return w.withDO(w.DO.Select(conds...))
+}
+
+func (w workspaceInvitationDo) Where(conds ...gen.Condition) IWorkspaceInvitationDo {
+	return w.withDO(w.DO.Where(conds...))
+}
+
+func (w workspaceInvitationDo) Order(conds ...field.Expr) IWorkspaceInvitationDo {
+	return w.withDO(w.DO.Order(conds...))
+}
+
+func (w workspaceInvitationDo) Distinct(cols ...field.Expr) IWorkspaceInvitationDo {
+	return w.withDO(w.DO.Distinct(cols...))
+}
+
+func (w workspaceInvitationDo) Omit(cols ...field.Expr) IWorkspaceInvitationDo {
+	return w.withDO(w.DO.Omit(cols...))
+}
+
+func (w workspaceInvitationDo) Join(table schema.Tabler, on ...field.Expr) IWorkspaceInvitationDo {
+	return w.withDO(w.DO.Join(table, on...))
+}
+
+func (w workspaceInvitationDo) LeftJoin(table schema.Tabler, on ...field.Expr) IWorkspaceInvitationDo {
+	return w.withDO(w.DO.LeftJoin(table, on...))
+}
+
+func (w workspaceInvitationDo) RightJoin(table schema.Tabler, on ...field.Expr) IWorkspaceInvitationDo {
+	return w.withDO(w.DO.RightJoin(table, on...))
+}
+
+func (w workspaceInvitationDo) Group(cols ...field.Expr) IWorkspaceInvitationDo {
+	return w.withDO(w.DO.Group(cols...))
+}
+
+func (w workspaceInvitationDo) Having(conds ...gen.Condition) IWorkspaceInvitationDo {
+	return w.withDO(w.DO.Having(conds...))
+}
+
+func (w workspaceInvitationDo) Limit(limit int) IWorkspaceInvitationDo {
+	return w.withDO(w.DO.Limit(limit))
+}
+
+func (w workspaceInvitationDo) Offset(offset int) IWorkspaceInvitationDo {
+	return w.withDO(w.DO.Offset(offset))
+}
+
+func (w workspaceInvitationDo) Scopes(funcs ...func(gen.Dao) gen.Dao) IWorkspaceInvitationDo {
+	return w.withDO(w.DO.Scopes(funcs...))
+}
+
+func (w workspaceInvitationDo) Unscoped() IWorkspaceInvitationDo {
+	return w.withDO(w.DO.Unscoped())
+}
+
+func (w workspaceInvitationDo) Create(values ...*dbschema.WorkspaceInvitation) error {
+	if len(values) == 0 {
+		return nil
+	}
+	return w.DO.Create(values)
+}
+
+func (w workspaceInvitationDo) CreateInBatches(values []*dbschema.WorkspaceInvitation, batchSize int) error {
+	return w.DO.CreateInBatches(values, batchSize)
+}
+
+// Save : !!! underlying implementation is different with GORM
+// The method is equivalent to executing the statement: db.Clauses(clause.OnConflict{UpdateAll: true}).Create(values)
+func (w workspaceInvitationDo) Save(values ...*dbschema.WorkspaceInvitation) error {
+	if len(values) == 0 {
+		return nil
+	}
+	return w.DO.Save(values)
+}
+
+func (w workspaceInvitationDo) First() (*dbschema.WorkspaceInvitation, error) {
+	if result, err := w.DO.First(); err != nil {
+		return nil, err
+	} else {
+		return result.(*dbschema.WorkspaceInvitation), nil
+	}
+}
+
+func (w workspaceInvitationDo) Take() (*dbschema.WorkspaceInvitation, error) {
+	if result, err := w.DO.Take(); err != nil {
+		return nil, err
+	} else {
+		return result.(*dbschema.WorkspaceInvitation), nil
+	}
+}
+
+func (w workspaceInvitationDo) Last() (*dbschema.WorkspaceInvitation, error) {
+	if result, err := w.DO.Last(); err != nil {
+		return nil, err
+	} else {
+		return result.(*dbschema.WorkspaceInvitation), nil
+	}
+}
+
+func (w workspaceInvitationDo) Find() ([]*dbschema.WorkspaceInvitation, error) {
+	result, err := w.DO.Find()
+	return result.([]*dbschema.WorkspaceInvitation), err
+}
+
+func (w workspaceInvitationDo) FindInBatch(batchSize int, fc func(tx gen.Dao, batch int) error) (results []*dbschema.WorkspaceInvitation, err error) {
+	buf := make([]*dbschema.WorkspaceInvitation, 0, batchSize)
+	err = w.DO.FindInBatches(&buf, batchSize, func(tx gen.Dao, batch int) error {
+		defer func() { results = append(results, buf...) }()
+		return fc(tx, batch)
+	})
+	return results, err
+}
+
+func (w workspaceInvitationDo) FindInBatches(result *[]*dbschema.WorkspaceInvitation, batchSize int, fc func(tx gen.Dao, batch int) error) error {
+	return w.DO.FindInBatches(result, batchSize, fc)
+}
+
+func (w workspaceInvitationDo) Attrs(attrs ...field.AssignExpr) IWorkspaceInvitationDo {
+	return w.withDO(w.DO.Attrs(attrs...))
+}
+
+func (w workspaceInvitationDo) Assign(attrs ...field.AssignExpr) IWorkspaceInvitationDo {
+	return w.withDO(w.DO.Assign(attrs...))
+}
+
+func (w workspaceInvitationDo) Joins(fields ...field.RelationField) IWorkspaceInvitationDo {
+	for _, _f := range fields {
+		w = *w.withDO(w.DO.Joins(_f))
+	}
+	return &w
+}
+
+func (w workspaceInvitationDo) Preload(fields ...field.RelationField) IWorkspaceInvitationDo {
+	for _, _f := range fields {
+		w = *w.withDO(w.DO.Preload(_f))
+	}
+	return &w
+}
+
+func (w workspaceInvitationDo) FirstOrInit() (*dbschema.WorkspaceInvitation, error) {
+	if result, err := w.DO.FirstOrInit(); err != nil {
+		return nil, err
+	} else {
+		return result.(*dbschema.WorkspaceInvitation), nil
+	}
+}
+
+func (w workspaceInvitationDo) FirstOrCreate() (*dbschema.WorkspaceInvitation, error) {
+	if result, err := w.DO.FirstOrCreate(); err != nil {
+		return nil, err
+	} else {
+		return result.(*dbschema.WorkspaceInvitation), nil
+	}
+}
+
+func (w workspaceInvitationDo) FindByPage(offset int, limit int) (result []*dbschema.WorkspaceInvitation, count int64, err error) {
+	result, err = w.Offset(offset).Limit(limit).Find()
+	if err != nil {
+		return
+	}
+
+	if size := len(result); 0 < limit && 0 < size && size < limit {
+		count = int64(size + offset)
+		return
+	}
+
+	count, err = w.Offset(-1).Limit(-1).Count()
+	return
+}
+
+func (w workspaceInvitationDo) ScanByPage(result interface{}, offset int, limit int) (count int64, err error) {
+	count, err = w.Count()
+	if err != nil {
+		return
+	}
+
+	err = w.Offset(offset).Limit(limit).Scan(result)
+	return
+}
+
+func (w workspaceInvitationDo) Scan(result interface{}) (err error) {
+	return w.DO.Scan(result)
+}
+
+func (w workspaceInvitationDo) Delete(models ...*dbschema.WorkspaceInvitation) (result gen.ResultInfo, err error) {
+	return w.DO.Delete(models)
+}
+
+func (w *workspaceInvitationDo) withDO(do gen.Dao) *workspaceInvitationDo {
+	w.DO = *do.(*gen.DO)
+	return w
+}
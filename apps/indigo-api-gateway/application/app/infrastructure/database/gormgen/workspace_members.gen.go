@@ -0,0 +1,407 @@
+// Code generated by gorm.io/gen. DO NOT EDIT.
+// Code generated by gorm.io/gen. DO NOT EDIT.
+// Code generated by gorm.io/gen. DO NOT EDIT.
+
+package gormgen
+
+import (
+	"context"
+	"database/sql"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+
+	"gorm.io/gen"
+	"gorm.io/gen/field"
+
+	"gorm.io/plugin/dbresolver"
+
+	"menlo.ai/indigo-api-gateway/app/infrastructure/database/dbschema"
+)
+
+func newWorkspaceMember(db *gorm.DB, opts ...gen.DOOption) workspaceMember {
+	_workspaceMember := workspaceMember{}
+
+	_workspaceMember.workspaceMemberDo.UseDB(db, opts...)
+	_workspaceMember.workspaceMemberDo.UseModel(&dbschema.WorkspaceMember{})
+
+	tableName := _workspaceMember.workspaceMemberDo.TableName()
+	_workspaceMember.ALL = field.NewAsterisk(tableName)
+	_workspaceMember.ID = field.NewUint(tableName, "id")
+	_workspaceMember.CreatedAt = field.NewTime(tableName, "created_at")
+	_workspaceMember.UpdatedAt = field.NewTime(tableName, "updated_at")
+	_workspaceMember.DeletedAt = field.NewField(tableName, "deleted_at")
+	_workspaceMember.WorkspaceID = field.NewUint(tableName, "workspace_id")
+	_workspaceMember.UserID = field.NewUint(tableName, "user_id")
+	_workspaceMember.Role = field.NewString(tableName, "role")
+
+	_workspaceMember.fillFieldMap()
+
+	return _workspaceMember
+}
+
+type workspaceMember struct {
+	workspaceMemberDo
+
+	ALL         field.Asterisk
+	ID          field.Uint
+	CreatedAt   field.Time
+	UpdatedAt   field.Time
+	DeletedAt   field.Field
+	WorkspaceID field.Uint
+	UserID      field.Uint
+	Role        field.String
+
+	fieldMap map[string]field.Expr
+}
+
+func (w workspaceMember) Table(newTableName string) *workspaceMember {
+	w.workspaceMemberDo.UseTable(newTableName)
+	return w.updateTableName(newTableName)
+}
+
+func (w workspaceMember) As(alias string) *workspaceMember {
+	w.workspaceMemberDo.DO = *(w.workspaceMemberDo.As(alias).(*gen.DO))
+	return w.updateTableName(alias)
+}
+
+func (w *workspaceMember) updateTableName(table string) *workspaceMember {
+	w.ALL = field.NewAsterisk(table)
+	w.ID = field.NewUint(table, "id")
+	w.CreatedAt = field.NewTime(table, "created_at")
+	w.UpdatedAt = field.NewTime(table, "updated_at")
+	w.DeletedAt = field.NewField(table, "deleted_at")
+	w.WorkspaceID = field.NewUint(table, "workspace_id")
+	w.UserID = field.NewUint(table, "user_id")
+	w.Role = field.NewString(table, "role")
+
+	w.fillFieldMap()
+
+	return w
+}
+
+func (w *workspaceMember) GetFieldByName(fieldName string) (field.OrderExpr, bool) {
+	_f, ok := w.fieldMap[fieldName]
+	if !ok || _f == nil {
+		return nil, false
+	}
+	_oe, ok := _f.(field.OrderExpr)
+	return _oe, ok
+}
+
+func (w *workspaceMember) fillFieldMap() {
+	w.fieldMap = make(map[string]field.Expr, 7)
+	w.fieldMap["id"] = w.ID
+	w.fieldMap["created_at"] = w.CreatedAt
+	w.fieldMap["updated_at"] = w.UpdatedAt
+	w.fieldMap["deleted_at"] = w.DeletedAt
+	w.fieldMap["workspace_id"] = w.WorkspaceID
+	w.fieldMap["user_id"] = w.UserID
+	w.fieldMap["role"] = w.Role
+}
+
+func (w workspaceMember) clone(db *gorm.DB) workspaceMember {
+	w.workspaceMemberDo.ReplaceConnPool(db.Statement.ConnPool)
+	return w
+}
+
+func (w workspaceMember) replaceDB(db *gorm.DB) workspaceMember {
+	w.workspaceMemberDo.ReplaceDB(db)
+	return w
+}
+
+type workspaceMemberDo struct{ gen.DO }
+
+type IWorkspaceMemberDo interface {
+	gen.SubQuery
+	Debug() IWorkspaceMemberDo
+	WithContext(ctx context.Context) IWorkspaceMemberDo
+	WithResult(fc func(tx gen.Dao)) gen.ResultInfo
+	ReplaceDB(db *gorm.DB)
+	ReadDB() IWorkspaceMemberDo
+	WriteDB() IWorkspaceMemberDo
+	As(alias string) gen.Dao
+	Session(config *gorm.Session) IWorkspaceMemberDo
+	Columns(cols ...field.Expr) gen.Columns
+	Clauses(conds ...clause.Expression) IWorkspaceMemberDo
+	Not(conds ...gen.Condition) IWorkspaceMemberDo
+	Or(conds ...gen.Condition) IWorkspaceMemberDo
+	Select(conds ...field.Expr) IWorkspaceMemberDo
+	Where(conds ...gen.Condition) IWorkspaceMemberDo
+	Order(conds ...field.Expr) IWorkspaceMemberDo
+	Distinct(cols ...field.Expr) IWorkspaceMemberDo
+	Omit(cols ...field.Expr) IWorkspaceMemberDo
+	Join(table schema.Tabler, on ...field.Expr) IWorkspaceMemberDo
+	LeftJoin(table schema.Tabler, on ...field.Expr) IWorkspaceMemberDo
+	RightJoin(table schema.Tabler, on ...field.Expr) IWorkspaceMemberDo
+	Group(cols ...field.Expr) IWorkspaceMemberDo
+	Having(conds ...gen.Condition) IWorkspaceMemberDo
+	Limit(limit int) IWorkspaceMemberDo
+	Offset(offset int) IWorkspaceMemberDo
+	Count() (count int64, err error)
+	Scopes(funcs ...func(gen.Dao) gen.Dao) IWorkspaceMemberDo
+	Unscoped() IWorkspaceMemberDo
+	Create(values ...*dbschema.WorkspaceMember) error
+	CreateInBatches(values []*dbschema.WorkspaceMember, batchSize int) error
+	Save(values ...*dbschema.WorkspaceMember) error
+	First() (*dbschema.WorkspaceMember, error)
+	Take() (*dbschema.WorkspaceMember, error)
+	Last() (*dbschema.WorkspaceMember, error)
+	Find() ([]*dbschema.WorkspaceMember, error)
+	FindInBatch(batchSize int, fc func(tx gen.Dao, batch int) error) (results []*dbschema.WorkspaceMember, err error)
+	FindInBatches(result *[]*dbschema.WorkspaceMember, batchSize int, fc func(tx gen.Dao, batch int) error) error
+	Pluck(column field.Expr, dest interface{}) error
+	Delete(...*dbschema.WorkspaceMember) (info gen.ResultInfo, err error)
+	Update(column field.Expr, value interface{}) (info gen.ResultInfo, err error)
+	UpdateSimple(columns ...field.AssignExpr) (info gen.ResultInfo, err error)
+	Updates(value interface{}) (info gen.ResultInfo, err error)
+	UpdateColumn(column field.Expr, value interface{}) (info gen.ResultInfo, err error)
+	UpdateColumnSimple(columns ...field.AssignExpr) (info gen.ResultInfo, err error)
+	UpdateColumns(value interface{}) (info gen.ResultInfo, err error)
+	UpdateFrom(q gen.SubQuery) gen.Dao
+	Attrs(attrs ...field.AssignExpr) IWorkspaceMemberDo
+	Assign(attrs ...field.AssignExpr) IWorkspaceMemberDo
+	Joins(fields ...field.RelationField) IWorkspaceMemberDo
+	Preload(fields ...field.RelationField) IWorkspaceMemberDo
+	FirstOrInit() (*dbschema.WorkspaceMember, error)
+	FirstOrCreate() (*dbschema.WorkspaceMember, error)
+	FindByPage(offset int, limit int) (result []*dbschema.WorkspaceMember, count int64, err error)
+	ScanByPage(result interface{}, offset int, limit int) (count int64, err error)
+	Rows() (*sql.Rows, error)
+	Row() *sql.Row
+	Scan(result interface{}) (err error)
+	Returning(value interface{}, columns ...string) IWorkspaceMemberDo
+	UnderlyingDB() *gorm.DB
+	schema.Tabler
+}
+
+func (w workspaceMemberDo) Debug() IWorkspaceMemberDo {
+	return w.withDO(w.DO.Debug())
+}
+
+func (w workspaceMemberDo) WithContext(ctx context.Context) IWorkspaceMemberDo {
+	return w.withDO(w.DO.WithContext(ctx))
+}
+
+func (w workspaceMemberDo) ReadDB() IWorkspaceMemberDo {
+	return w.Clauses(dbresolver.Read)
+}
+
+func (w workspaceMemberDo) WriteDB() IWorkspaceMemberDo {
+	return w.Clauses(dbresolver.Write)
+}
+
+func (w workspaceMemberDo) Session(config *gorm.Session) IWorkspaceMemberDo {
+	return w.withDO(w.DO.Session(config))
+}
+
+func (w workspaceMemberDo) Clauses(conds ...clause.Expression) IWorkspaceMemberDo {
+	return w.withDO(w.DO.Clauses(conds...))
+}
+
+func (w workspaceMemberDo) Returning(value interface{}, columns ...string) IWorkspaceMemberDo {
+	return w.withDO(w.DO.Returning(value, columns...))
+}
+
+func (w workspaceMemberDo) Not(conds ...gen.Condition) IWorkspaceMemberDo {
+	return w.withDO(w.DO.Not(conds...))
+}
+
+func (w workspaceMemberDo) Or(conds ...gen.Condition) IWorkspaceMemberDo {
+	return w.withDO(w.DO.Or(conds...))
+}
+
+func (w workspaceMemberDo) Select(conds ...field.Expr) IWorkspaceMemberDo {
+	return w.withDO(w.DO.Select(conds...))
+}
+
+func (w workspaceMemberDo) Where(conds ...gen.Condition) IWorkspaceMemberDo {
+	return w.withDO(w.DO.Where(conds...))
+}
+
+func (w workspaceMemberDo) Order(conds ...field.Expr) IWorkspaceMemberDo {
+	return w.withDO(w.DO.Order(conds...))
+}
+
+func (w workspaceMemberDo) Distinct(cols ...field.Expr) IWorkspaceMemberDo {
+	return w.withDO(w.DO.Distinct(cols...))
+}
+
+func (w workspaceMemberDo) Omit(cols ...field.Expr) IWorkspaceMemberDo {
+	return w.withDO(w.DO.Omit(cols...))
+}
+
+func (w workspaceMemberDo) Join(table schema.Tabler, on ...field.Expr) IWorkspaceMemberDo {
+	return w.withDO(w.DO.Join(table, on...))
+}
+
+func (w workspaceMemberDo) LeftJoin(table schema.Tabler, on ...field.Expr) IWorkspaceMemberDo {
+	return w.withDO(w.DO.LeftJoin(table, on...))
+}
+
+func (w workspaceMemberDo) RightJoin(table schema.Tabler, on ...field.Expr) IWorkspaceMemberDo {
+	return w.withDO(w.DO.RightJoin(table, on...))
+}
+
+func (w workspaceMemberDo) Group(cols ...field.Expr) IWorkspaceMemberDo {
+	return w.withDO(w.DO.Group(cols...))
+}
+
+func (w workspaceMemberDo) Having(conds ...gen.Condition) IWorkspaceMemberDo {
+	return w.withDO(w.DO.Having(conds...))
+}
+
+func (w workspaceMemberDo) Limit(limit int) IWorkspaceMemberDo {
+	return w.withDO(w.DO.Limit(limit))
+}
+
+func (w workspaceMemberDo) Offset(offset int) IWorkspaceMemberDo {
+	return w.withDO(w.DO.Offset(offset))
+}
+
+func (w workspaceMemberDo) Scopes(funcs ...func(gen.Dao) gen.Dao) IWorkspaceMemberDo {
+	return w.withDO(w.DO.Scopes(funcs...))
+}
+
+func (w workspaceMemberDo) Unscoped() IWorkspaceMemberDo {
+	return w.withDO(w.DO.Unscoped())
+}
+
+func (w workspaceMemberDo) Create(values ...*dbschema.WorkspaceMember) error {
+	if len(values) == 0 {
+		return nil
+	}
+	return w.DO.Create(values)
+}
+
+func (w workspaceMemberDo) CreateInBatches(values []*dbschema.WorkspaceMember, batchSize int) error {
+	return w.DO.CreateInBatches(values, batchSize)
+}
+
+// Save : !!! underlying implementation is different with GORM
+// The method is equivalent to executing the statement: db.Clauses(clause.OnConflict{UpdateAll: true}).Create(values)
+func (w workspaceMemberDo) Save(values ...*dbschema.WorkspaceMember) error {
+	if len(values) == 0 {
+		return nil
+	}
+	return w.DO.Save(values)
+}
+
+func (w workspaceMemberDo) First() (*dbschema.WorkspaceMember, error) {
+	if result, err := w.DO.First(); err != nil {
+		return nil, err
+	} else {
+		return result.(*dbschema.WorkspaceMember), nil
+	}
+}
+
+func (w workspaceMemberDo) Take() (*dbschema.WorkspaceMember, error) {
+	if result, err := w.DO.Take(); err != nil {
+		return nil, err
+	} else {
+		return result.(*dbschema.WorkspaceMember), nil
+	}
+}
+
+func (w workspaceMemberDo) Last() (*dbschema.WorkspaceMember, error) {
+	if result, err := w.DO.Last(); err != nil {
+		return nil, err
+	} else {
+		return result.(*dbschema.WorkspaceMember), nil
+	}
+}
+
+func (w workspaceMemberDo) Find() ([]*dbschema.WorkspaceMember, error) {
+	result, err := w.DO.Find()
+	return result.([]*dbschema.WorkspaceMember), err
+}
+
+func (w workspaceMemberDo) FindInBatch(batchSize int, fc func(tx gen.Dao, batch int) error) (results []*dbschema.WorkspaceMember, err error) {
+	buf := make([]*dbschema.WorkspaceMember, 0, batchSize)
+	err = w.DO.FindInBatches(&buf, batchSize, func(tx gen.Dao, batch int) error {
+		defer func() { results = append(results, buf...) }()
+		return fc(tx, batch)
+	})
+	return results, err
+}
+
+func (w workspaceMemberDo) FindInBatches(result *[]*dbschema.WorkspaceMember, batchSize int, fc func(tx gen.Dao, batch int) error) error {
+	return w.DO.FindInBatches(result, batchSize, fc)
+}
+
+func (w workspaceMemberDo) Attrs(attrs ...field.AssignExpr) IWorkspaceMemberDo {
+	return w.withDO(w.DO.Attrs(attrs...))
+}
+
+func (w workspaceMemberDo) Assign(attrs ...field.AssignExpr) IWorkspaceMemberDo {
+	return w.withDO(w.DO.Assign(attrs...))
+}
+
+func (w workspaceMemberDo) Joins(fields ...field.RelationField) IWorkspaceMemberDo {
+	for _, _f := range fields {
+		w = *w.withDO(w.DO.Joins(_f))
+	}
+	return &w
+}
+
+func (w workspaceMemberDo) Preload(fields ...field.RelationField) IWorkspaceMemberDo {
+	for _, _f := range fields {
+		w = *w.withDO(w.DO.Preload(_f))
+	}
+	return &w
+}
+
+func (w workspaceMemberDo) FirstOrInit() (*dbschema.WorkspaceMember, error) {
+	if result, err := w.DO.FirstOrInit(); err != nil {
+		return nil, err
+	} else {
+		return result.(*dbschema.WorkspaceMember), nil
+	}
+}
+
+func (w workspaceMemberDo) FirstOrCreate() (*dbschema.WorkspaceMember, error) {
+	if result, err := w.DO.FirstOrCreate(); err != nil {
+		return nil, err
+	} else {
+		return result.(*dbschema.WorkspaceMember), nil
+	}
+}
+
+func (w workspaceMemberDo) FindByPage(offset int, limit int) (result []*dbschema.WorkspaceMember, count int64, err error) {
+	result, err = w.Offset(offset).Limit(limit).Find()
+	if err != nil {
+		return
+	}
+
+	if size := len(result); 0 < limit && 0 < size && size < limit {
+		count = int64(size + offset)
+		return
+	}
+
+	count, err = w.Offset(-1).Limit(-1).Count()
+	return
+}
+
+func (w workspaceMemberDo) ScanByPage(result interface{}, offset int, limit int) (count int64, err error) {
+	count, err = w.Count()
+	if err != nil {
+		return
+	}
+
+	err = w.Offset(offset).Limit(limit).Scan(result)
+	return
+}
+
+func (w workspaceMemberDo) Scan(result interface{}) (err error) {
+	return w.DO.Scan(result)
+}
+
+func (w workspaceMemberDo) Delete(models ...*dbschema.WorkspaceMember) (result gen.ResultInfo, err error) {
+	return w.DO.Delete(models)
+}
+
+func (w *workspaceMemberDo) withDO(do gen.Dao) *workspaceMemberDo {
+	w.DO = *do.(*gen.DO)
+	return w
+}
@@ -36,6 +36,8 @@ func newWorkspace(db *gorm.DB, opts ...gen.DOOption) workspace {
 	_workspace.UserID = field.NewUint(tableName, "user_id")
 	_workspace.Name = field.NewString(tableName, "name")
 	_workspace.Instruction = field.NewString(tableName, "instruction")
+	_workspace.ContextSettings = field.NewField(tableName, "context_settings")
+	_workspace.ShareConversations = field.NewBool(tableName, "share_conversations")
 	_workspace.Conversations = workspaceHasManyConversations{
 		db: db.Session(&gorm.Session{}),
 
@@ -152,16 +154,18 @@ func newWorkspace(db *gorm.DB, opts ...gen.DOOption) workspace {
 type workspace struct {
 	workspaceDo
 
-	ALL           field.Asterisk
-	ID            field.Uint
-	CreatedAt     field.Time
-	UpdatedAt     field.Time
-	DeletedAt     field.Field
-	PublicID      field.String
-	UserID        field.Uint
-	Name          field.String
-	Instruction   field.String
-	Conversations workspaceHasManyConversations
+	ALL                field.Asterisk
+	ID                 field.Uint
+	CreatedAt          field.Time
+	UpdatedAt          field.Time
+	DeletedAt          field.Field
+	PublicID           field.String
+	UserID             field.Uint
+	Name               field.String
+	Instruction        field.String
+	ContextSettings    field.Field
+	ShareConversations field.Bool
+	Conversations      workspaceHasManyConversations
 
 	User workspaceBelongsToUser
 
@@ -188,6 +192,8 @@ func (w *workspace) updateTableName(table string) *workspace {
 	w.UserID = field.NewUint(table, "user_id")
 	w.Name = field.NewString(table, "name")
 	w.Instruction = field.NewString(table, "instruction")
+	w.ContextSettings = field.NewField(table, "context_settings")
+	w.ShareConversations = field.NewBool(table, "share_conversations")
 
 	w.fillFieldMap()
 
@@ -204,7 +210,7 @@ func (w *workspace) GetFieldByName(fieldName string) (field.OrderExpr, bool) {
 }
 
 func (w *workspace) fillFieldMap() {
-	w.fieldMap = make(map[string]field.Expr, 10)
+	w.fieldMap = make(map[string]field.Expr, 12)
 	w.fieldMap["id"] = w.ID
 	w.fieldMap["created_at"] = w.CreatedAt
 	w.fieldMap["updated_at"] = w.UpdatedAt
@@ -213,6 +219,8 @@ func (w *workspace) fillFieldMap() {
 	w.fieldMap["user_id"] = w.UserID
 	w.fieldMap["name"] = w.Name
 	w.fieldMap["instruction"] = w.Instruction
+	w.fieldMap["context_settings"] = w.ContextSettings
+	w.fieldMap["share_conversations"] = w.ShareConversations
 
 }
 
@@ -109,6 +109,15 @@ func (d *DBMigrator) Migrate() (err error) {
 	if err = d.initialize(); err != nil {
 		return err
 	}
+	for _, extension := range ExtensionRegistry {
+		err = d.db.Exec(fmt.Sprintf("CREATE EXTENSION IF NOT EXISTS %q", extension)).Error
+		if err != nil {
+			logger.GetLogger().
+				WithField("error_code", "3f7a1c9e-5b2d-4e8a-9c6f-1d4b8e2a7c53").
+				Fatalf("failed to create extension %s: %v", extension, err)
+			return err
+		}
+	}
 	for _, model := range SchemaRegistry {
 		err = d.db.AutoMigrate(model)
 		if err != nil {
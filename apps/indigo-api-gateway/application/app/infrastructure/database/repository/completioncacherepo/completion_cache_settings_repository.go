@@ -0,0 +1,55 @@
+package completioncacherepo
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"menlo.ai/indigo-api-gateway/app/domain/completioncache"
+	"menlo.ai/indigo-api-gateway/app/infrastructure/database/dbschema"
+	"menlo.ai/indigo-api-gateway/app/infrastructure/database/repository/transaction"
+)
+
+type CompletionCacheSettingsRepository struct {
+	db *transaction.Database
+}
+
+var _ completioncache.SettingsRepository = (*CompletionCacheSettingsRepository)(nil)
+
+func NewCompletionCacheSettingsRepository(db *transaction.Database) completioncache.SettingsRepository {
+	return &CompletionCacheSettingsRepository{db: db}
+}
+
+func (r *CompletionCacheSettingsRepository) FindByProjectID(ctx context.Context, projectID uint) (*completioncache.Settings, error) {
+	var model dbschema.CompletionCacheSettings
+	if err := r.db.GetTx(ctx).WithContext(ctx).Where("project_id = ?", projectID).First(&model).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return model.EtoD(), nil
+}
+
+func (r *CompletionCacheSettingsRepository) Upsert(ctx context.Context, settings *completioncache.Settings) error {
+	model := dbschema.NewSchemaCompletionCacheSettings(settings)
+	err := r.db.GetTx(ctx).WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "project_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"enabled":              model.Enabled,
+			"mode":                 model.Mode,
+			"ttl_seconds":          model.TTLSeconds,
+			"similarity_threshold": model.SimilarityThreshold,
+			"embedding_model":      model.EmbeddingModel,
+			"updated_at":           gorm.Expr("NOW()"),
+		}),
+	}).Create(model).Error
+	if err != nil {
+		return err
+	}
+	settings.ID = model.ID
+	settings.CreatedAt = model.CreatedAt
+	settings.UpdatedAt = model.UpdatedAt
+	return nil
+}
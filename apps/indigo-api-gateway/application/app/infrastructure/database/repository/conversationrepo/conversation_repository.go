@@ -3,7 +3,10 @@ package conversationrepo
 import (
 	"context"
 	"strings"
+	"time"
 
+	"gorm.io/gen/field"
+	"gorm.io/gorm"
 	domain "menlo.ai/indigo-api-gateway/app/domain/conversation"
 	"menlo.ai/indigo-api-gateway/app/domain/query"
 	"menlo.ai/indigo-api-gateway/app/infrastructure/database/dbschema"
@@ -62,18 +65,68 @@ func (r *ConversationGormRepository) Update(ctx context.Context, conversation *d
 	query := r.db.GetQuery(ctx)
 
 	// select to update workspace nil as removing
-	err := query.Conversation.WithContext(ctx).
+	return query.Conversation.WithContext(ctx).
 		Where(query.Conversation.ID.Eq(conversation.ID)).
 		Save(model)
-	return err
 }
 
+// ReplaceTitle compares and sets the title in a single statement so a rename is never overwritten
+func (r *ConversationGormRepository) ReplaceTitle(ctx context.Context, id uint, title string, placeholders []string) (bool, error) {
+	result := r.db.GetTx(ctx).WithContext(ctx).
+		Model(&dbschema.Conversation{}).
+		Where("id = ? AND (title IS NULL OR TRIM(title) IN ?)", id, placeholders).
+		Update("title", title)
+	return result.RowsAffected == 1, result.Error
+}
+
+// Delete only sets deleted_at, the status is kept so a restored conversation comes back active or archived
 func (r *ConversationGormRepository) Delete(ctx context.Context, id uint) error {
 	query := r.db.GetQuery(ctx)
-	_, err := query.Conversation.WithContext(ctx).Where(query.Conversation.ID.Eq(id)).Delete()
+	_, err := query.Conversation.WithContext(ctx).
+		Where(query.Conversation.ID.Eq(id)).
+		UpdateColumn(query.Conversation.DeletedAt, time.Now())
 	return err
 }
 
+// Restore clears deleted_at; conversations trashed with the deleted status by earlier versions come back active
+func (r *ConversationGormRepository) Restore(ctx context.Context, id uint) error {
+	query := r.db.GetQuery(ctx)
+	_, err := query.Conversation.WithContext(ctx).
+		Unscoped().
+		Where(query.Conversation.ID.Eq(id)).
+		UpdateColumns(map[string]interface{}{
+			"status": gorm.Expr("CASE WHEN status = ? THEN ? ELSE status END",
+				string(domain.ConversationStatusDeleted), string(domain.ConversationStatusActive)),
+			"deleted_at": nil,
+		})
+	return err
+}
+
+func (r *ConversationGormRepository) DeletePermanently(ctx context.Context, id uint) error {
+	return r.db.GetQuery(ctx).Transaction(func(tx *gormgen.Query) error {
+		var responseIDs []uint
+		if err := tx.Response.WithContext(ctx).
+			Unscoped().
+			Where(tx.Response.ConversationID.Eq(id)).
+			Pluck(tx.Response.ID, &responseIDs); err != nil {
+			return err
+		}
+		if len(responseIDs) > 0 {
+			if _, err := tx.Item.WithContext(ctx).Unscoped().Where(tx.Item.ResponseID.In(responseIDs...)).Delete(); err != nil {
+				return err
+			}
+			if _, err := tx.Response.WithContext(ctx).Unscoped().Where(tx.Response.ID.In(responseIDs...)).Delete(); err != nil {
+				return err
+			}
+		}
+		if _, err := tx.Item.WithContext(ctx).Unscoped().Where(tx.Item.ConversationID.Eq(id)).Delete(); err != nil {
+			return err
+		}
+		_, err := tx.Conversation.WithContext(ctx).Unscoped().Where(tx.Conversation.ID.Eq(id)).Delete()
+		return err
+	})
+}
+
 func (r *ConversationGormRepository) DeleteByWorkspacePublicID(ctx context.Context, workspacePublicID string) error {
 	query := r.db.GetQuery(ctx)
 	_, err := query.Conversation.WithContext(ctx).Where(query.Conversation.WorkspacePublicID.Eq(workspacePublicID)).Delete()
@@ -176,12 +229,28 @@ func (repo *ConversationGormRepository) applyFilter(
 	sql gormgen.IConversationDo,
 	filter domain.ConversationFilter,
 ) gormgen.IConversationDo {
+	if filter.IncludeDeleted {
+		sql = sql.Unscoped()
+	}
+	if filter.Status != nil {
+		if *filter.Status == domain.ConversationStatusDeleted {
+			sql = sql.Unscoped().Where(query.Conversation.DeletedAt.IsNotNull())
+		} else {
+			sql = sql.Where(query.Conversation.Status.Eq(string(*filter.Status)), query.Conversation.DeletedAt.IsNull())
+		}
+	}
 	if filter.PublicID != nil {
 		sql = sql.Where(query.Conversation.PublicID.Eq(*filter.PublicID))
 	}
 	if filter.UserID != nil {
 		sql = sql.Where(query.Conversation.UserID.Eq(*filter.UserID))
 	}
+	if filter.VisibleToUserID != nil {
+		sql = sql.Where(field.Or(
+			query.Conversation.IsPrivate.Is(false),
+			query.Conversation.UserID.Eq(*filter.VisibleToUserID),
+		))
+	}
 	if filter.WorkspacePublicID != nil {
 		if strings.EqualFold(*filter.WorkspacePublicID, "none") {
 			sql = sql.Where(query.Conversation.WorkspacePublicID.IsNull())
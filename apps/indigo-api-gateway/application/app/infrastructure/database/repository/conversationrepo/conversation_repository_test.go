@@ -0,0 +1,175 @@
+package conversationrepo
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+	domain "menlo.ai/indigo-api-gateway/app/domain/conversation"
+	"menlo.ai/indigo-api-gateway/app/infrastructure/database/dbschema"
+	"menlo.ai/indigo-api-gateway/app/infrastructure/database/repository/transaction"
+)
+
+func newTestDatabase(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{
+		NamingStrategy:                           schema.NamingStrategy{SingularTable: true},
+		DisableForeignKeyConstraintWhenMigrating: true,
+	})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	if err := db.AutoMigrate(&dbschema.Conversation{}, &dbschema.Item{}, &dbschema.Response{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return db
+}
+
+var conversationCount int
+
+func createConversation(t *testing.T, repo domain.ConversationRepository, title *string) *domain.Conversation {
+	t.Helper()
+	conversationCount++
+	conv := &domain.Conversation{
+		PublicID: fmt.Sprintf("conv_%d", conversationCount),
+		Title:    title,
+		UserID:   1,
+		Status:   domain.ConversationStatusActive,
+	}
+	if err := repo.Create(context.Background(), conv); err != nil {
+		t.Fatalf("create conversation: %v", err)
+	}
+	return conv
+}
+
+func TestReplaceTitleKeepsRenames(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDatabase(t)
+	repo := NewConversationGormRepository(transaction.NewDatabase(db))
+	placeholders := []string{"", "Hello there", domain.DefaultConversationTitle}
+
+	for _, title := range []string{"", "  ", "Hello there", domain.DefaultConversationTitle} {
+		conv := createConversation(t, repo, &title)
+		replaced, err := repo.ReplaceTitle(ctx, conv.ID, "Generated", placeholders)
+		if err != nil || !replaced {
+			t.Fatalf("expected the placeholder %q to be replaced, got %v, %v", title, replaced, err)
+		}
+		stored, err := repo.FindByID(ctx, conv.ID)
+		if err != nil || stored.Title == nil || *stored.Title != "Generated" {
+			t.Fatalf("expected the generated title, got %v, %v", stored, err)
+		}
+	}
+
+	renamed := "Trip planning"
+	conv := createConversation(t, repo, &renamed)
+	replaced, err := repo.ReplaceTitle(ctx, conv.ID, "Generated", placeholders)
+	if err != nil || replaced {
+		t.Fatalf("expected the rename to be kept, got %v, %v", replaced, err)
+	}
+	stored, err := repo.FindByID(ctx, conv.ID)
+	if err != nil || *stored.Title != renamed {
+		t.Fatalf("expected the renamed title, got %v, %v", stored, err)
+	}
+}
+
+func TestTrashKeepsTheConversationStatus(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDatabase(t)
+	repo := NewConversationGormRepository(transaction.NewDatabase(db))
+	active := createConversation(t, repo, nil)
+	archived := createConversation(t, repo, nil)
+	if err := db.Model(&dbschema.Conversation{}).Where("id = ?", archived.ID).UpdateColumn("status", domain.ConversationStatusArchived).Error; err != nil {
+		t.Fatalf("archive conversation: %v", err)
+	}
+	// trashed by an earlier version, with the deleted status
+	legacy := createConversation(t, repo, nil)
+	if err := db.Model(&dbschema.Conversation{}).Where("id = ?", legacy.ID).UpdateColumn("status", domain.ConversationStatusDeleted).Error; err != nil {
+		t.Fatalf("mark conversation deleted: %v", err)
+	}
+	for _, conv := range []*domain.Conversation{active, archived, legacy} {
+		if err := repo.Delete(ctx, conv.ID); err != nil {
+			t.Fatalf("delete conversation: %v", err)
+		}
+	}
+
+	userID := uint(1)
+	if listed, err := repo.FindByFilter(ctx, domain.ConversationFilter{UserID: &userID}, nil); err != nil || len(listed) != 0 {
+		t.Fatalf("expected the trashed conversations to be hidden, got %v, %v", listed, err)
+	}
+	deleted := domain.ConversationStatusDeleted
+	trash, err := repo.FindByFilter(ctx, domain.ConversationFilter{UserID: &userID, Status: &deleted}, nil)
+	if err != nil || len(trash) != 3 {
+		t.Fatalf("expected the trash to list the conversations, got %v, %v", trash, err)
+	}
+	for _, conv := range trash {
+		if conv.Status != domain.ConversationStatusDeleted || conv.DeletedAt == nil {
+			t.Fatalf("expected a trashed conversation to be reported deleted, got %+v", conv)
+		}
+	}
+	archivedStatus := domain.ConversationStatusArchived
+	if listed, err := repo.FindByFilter(ctx, domain.ConversationFilter{UserID: &userID, Status: &archivedStatus, IncludeDeleted: true}, nil); err != nil || len(listed) != 0 {
+		t.Fatalf("expected the archived filter to leave out the trash, got %v, %v", listed, err)
+	}
+
+	expected := map[uint]domain.ConversationStatus{
+		active.ID:   domain.ConversationStatusActive,
+		archived.ID: domain.ConversationStatusArchived,
+		legacy.ID:   domain.ConversationStatusActive,
+	}
+	for id, status := range expected {
+		if err := repo.Restore(ctx, id); err != nil {
+			t.Fatalf("restore conversation: %v", err)
+		}
+		restored, err := repo.FindByID(ctx, id)
+		if err != nil || restored.Status != status || restored.DeletedAt != nil {
+			t.Fatalf("conversation %d: expected %s, got %+v, %v", id, status, restored, err)
+		}
+	}
+}
+
+func TestDeleteConversationPermanently(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDatabase(t)
+	repo := NewConversationGormRepository(transaction.NewDatabase(db))
+	removed := createConversation(t, repo, nil)
+	kept := createConversation(t, repo, nil)
+
+	response := &dbschema.Response{PublicID: "resp_removed", UserID: 1, ConversationID: &removed.ID, Model: "test", Status: "completed", Input: "{}"}
+	if err := db.Create(response).Error; err != nil {
+		t.Fatalf("create response: %v", err)
+	}
+	items := []*dbschema.Item{
+		{PublicID: "msg_removed", ConversationID: removed.ID, Type: "message"},
+		{PublicID: "msg_trashed", ConversationID: removed.ID, Type: "message"},
+		{PublicID: "msg_response", ConversationID: removed.ID, ResponseID: &response.ID, Type: "message"},
+		{PublicID: "msg_kept", ConversationID: kept.ID, Type: "message"},
+	}
+	for _, item := range items {
+		if err := db.Create(item).Error; err != nil {
+			t.Fatalf("create item: %v", err)
+		}
+	}
+	if err := db.Delete(items[1]).Error; err != nil {
+		t.Fatalf("trash item: %v", err)
+	}
+	if err := repo.Delete(ctx, removed.ID); err != nil {
+		t.Fatalf("trash conversation: %v", err)
+	}
+
+	if err := repo.DeletePermanently(ctx, removed.ID); err != nil {
+		t.Fatalf("delete permanently: %v", err)
+	}
+	var conversations, responses int64
+	db.Unscoped().Model(&dbschema.Conversation{}).Where("id = ?", removed.ID).Count(&conversations)
+	db.Unscoped().Model(&dbschema.Response{}).Count(&responses)
+	var remaining []string
+	if err := db.Unscoped().Model(&dbschema.Item{}).Pluck("public_id", &remaining).Error; err != nil {
+		t.Fatalf("list items: %v", err)
+	}
+	if conversations != 0 || responses != 0 || len(remaining) != 1 || remaining[0] != "msg_kept" {
+		t.Fatalf("expected only the other conversation to remain, got %d conversations, %d responses, items %v", conversations, responses, remaining)
+	}
+}
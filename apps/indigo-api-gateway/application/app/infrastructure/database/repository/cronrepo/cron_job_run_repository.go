@@ -0,0 +1,125 @@
+package cronrepo
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"menlo.ai/indigo-api-gateway/app/domain/cron"
+	"menlo.ai/indigo-api-gateway/app/domain/query"
+	"menlo.ai/indigo-api-gateway/app/infrastructure/database/dbschema"
+	"menlo.ai/indigo-api-gateway/app/infrastructure/database/repository/transaction"
+	"menlo.ai/indigo-api-gateway/app/utils/functional"
+)
+
+type CronJobRunRepository struct {
+	db *transaction.Database
+}
+
+var _ cron.JobRunRepository = (*CronJobRunRepository)(nil)
+
+func NewCronJobRunRepository(db *transaction.Database) cron.JobRunRepository {
+	return &CronJobRunRepository{db: db}
+}
+
+func (r *CronJobRunRepository) Create(ctx context.Context, run *cron.JobRun) error {
+	model := dbschema.NewSchemaCronJobRun(run)
+	if err := r.db.GetTx(ctx).WithContext(ctx).Create(model).Error; err != nil {
+		return err
+	}
+	run.ID = model.ID
+	return nil
+}
+
+func (r *CronJobRunRepository) Update(ctx context.Context, run *cron.JobRun) error {
+	model := dbschema.NewSchemaCronJobRun(run)
+	return r.db.GetTx(ctx).WithContext(ctx).Save(model).Error
+}
+
+func (r *CronJobRunRepository) FindByFilter(ctx context.Context, filter cron.JobRunFilter, pagination *query.Pagination) ([]*cron.JobRun, error) {
+	sql := applyRunFilter(r.db.GetTx(ctx).WithContext(ctx).Model(&dbschema.CronJobRun{}), filter)
+	order := "id ASC"
+	if pagination != nil {
+		if pagination.Limit != nil && *pagination.Limit > 0 {
+			sql = sql.Limit(*pagination.Limit)
+		}
+		if pagination.Offset != nil {
+			sql = sql.Offset(*pagination.Offset)
+		}
+		if pagination.After != nil {
+			if pagination.Order == "desc" {
+				sql = sql.Where("id < ?", *pagination.After)
+			} else {
+				sql = sql.Where("id > ?", *pagination.After)
+			}
+		}
+		if pagination.Order == "desc" {
+			order = "id DESC"
+		}
+	}
+
+	var rows []*dbschema.CronJobRun
+	if err := sql.Order(order).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	return functional.Map(rows, func(item *dbschema.CronJobRun) *cron.JobRun {
+		return item.EtoD()
+	}), nil
+}
+
+func (r *CronJobRunRepository) Count(ctx context.Context, filter cron.JobRunFilter) (int64, error) {
+	var count int64
+	err := applyRunFilter(r.db.GetTx(ctx).WithContext(ctx).Model(&dbschema.CronJobRun{}), filter).Count(&count).Error
+	return count, err
+}
+
+func (r *CronJobRunRepository) FindLatest(ctx context.Context) (map[string]*cron.JobRun, error) {
+	var rows []*dbschema.CronJobRun
+	err := r.db.GetTx(ctx).WithContext(ctx).
+		Raw(`SELECT DISTINCT ON (job) * FROM cron_job_runs ORDER BY job, started_at DESC, id DESC`).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	latest := make(map[string]*cron.JobRun, len(rows))
+	for _, row := range rows {
+		latest[row.Job] = row.EtoD()
+	}
+	return latest, nil
+}
+
+func (r *CronJobRunRepository) FailStale(ctx context.Context, job string, startedBefore time.Time, message string) (int64, error) {
+	now := time.Now()
+	result := r.db.GetTx(ctx).WithContext(ctx).Model(&dbschema.CronJobRun{}).
+		Where("job = ? AND status = ? AND started_at < ?", job, cron.RunStatusRunning, startedBefore).
+		Updates(map[string]interface{}{
+			"status":      cron.RunStatusFailed,
+			"error":       message,
+			"finished_at": now,
+		})
+	return result.RowsAffected, result.Error
+}
+
+func (r *CronJobRunRepository) DeleteBefore(ctx context.Context, cutoff time.Time, limit int) (int64, error) {
+	db := r.db.GetTx(ctx).WithContext(ctx)
+	candidates := db.Model(&dbschema.CronJobRun{}).
+		Select("id").
+		Where("started_at < ?", cutoff).
+		Order("id").
+		Limit(limit)
+	result := db.Where("id IN (?)", candidates).Delete(&dbschema.CronJobRun{})
+	return result.RowsAffected, result.Error
+}
+
+func applyRunFilter(sql *gorm.DB, filter cron.JobRunFilter) *gorm.DB {
+	if filter.PublicID != nil {
+		sql = sql.Where("public_id = ?", *filter.PublicID)
+	}
+	if filter.Job != nil {
+		sql = sql.Where("job = ?", *filter.Job)
+	}
+	if filter.Status != nil {
+		sql = sql.Where("status = ?", *filter.Status)
+	}
+	return sql
+}
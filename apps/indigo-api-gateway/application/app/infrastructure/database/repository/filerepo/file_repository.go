@@ -0,0 +1,94 @@
+package filerepo
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+	"menlo.ai/indigo-api-gateway/app/domain/file"
+	"menlo.ai/indigo-api-gateway/app/domain/query"
+	"menlo.ai/indigo-api-gateway/app/infrastructure/database/dbschema"
+	"menlo.ai/indigo-api-gateway/app/infrastructure/database/repository/transaction"
+	"menlo.ai/indigo-api-gateway/app/utils/functional"
+)
+
+type FileRepository struct {
+	db *transaction.Database
+}
+
+var _ file.FileRepository = (*FileRepository)(nil)
+
+func NewFileRepository(db *transaction.Database) file.FileRepository {
+	return &FileRepository{db: db}
+}
+
+func (r *FileRepository) Create(ctx context.Context, f *file.File) error {
+	model := dbschema.NewSchemaFile(f)
+	if err := r.db.GetTx(ctx).WithContext(ctx).Create(model).Error; err != nil {
+		return err
+	}
+	f.ID = model.ID
+	f.CreatedAt = model.CreatedAt
+	f.UpdatedAt = model.UpdatedAt
+	return nil
+}
+
+func (r *FileRepository) FindByFilter(ctx context.Context, filter file.FileFilter, pagination *query.Pagination) ([]*file.File, error) {
+	sql := applyFilter(r.db.GetTx(ctx).WithContext(ctx).Model(&dbschema.File{}), filter)
+
+	order := "id ASC"
+	if pagination != nil {
+		if pagination.Limit != nil && *pagination.Limit > 0 {
+			sql = sql.Limit(*pagination.Limit)
+		}
+		if pagination.Offset != nil {
+			sql = sql.Offset(*pagination.Offset)
+		}
+		if pagination.After != nil {
+			if pagination.Order == "desc" {
+				sql = sql.Where("id < ?", *pagination.After)
+			} else {
+				sql = sql.Where("id > ?", *pagination.After)
+			}
+		}
+		if pagination.Order == "desc" {
+			order = "id DESC"
+		}
+	}
+
+	var rows []*dbschema.File
+	if err := sql.Order(order).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	return functional.Map(rows, func(item *dbschema.File) *file.File {
+		return item.EtoD()
+	}), nil
+}
+
+func (r *FileRepository) Count(ctx context.Context, filter file.FileFilter) (int64, error) {
+	var count int64
+	err := applyFilter(r.db.GetTx(ctx).WithContext(ctx).Model(&dbschema.File{}), filter).Count(&count).Error
+	return count, err
+}
+
+func (r *FileRepository) DeleteByID(ctx context.Context, id uint) error {
+	return r.db.GetTx(ctx).WithContext(ctx).Delete(&dbschema.File{}, id).Error
+}
+
+func applyFilter(sql *gorm.DB, filter file.FileFilter) *gorm.DB {
+	if filter.ID != nil {
+		sql = sql.Where("id = ?", *filter.ID)
+	}
+	if filter.PublicID != nil {
+		sql = sql.Where("public_id = ?", *filter.PublicID)
+	}
+	if filter.UserID != nil {
+		sql = sql.Where("user_id = ?", *filter.UserID)
+	}
+	if filter.ProjectID != nil {
+		sql = sql.Where("project_id = ?", *filter.ProjectID)
+	}
+	if filter.Purpose != nil {
+		sql = sql.Where("purpose = ?", string(*filter.Purpose))
+	}
+	return sql
+}
@@ -99,6 +99,21 @@ func (r *ItemGormRepository) Delete(ctx context.Context, id uint) error {
 	return err
 }
 
+func (r *ItemGormRepository) Restore(ctx context.Context, id uint) error {
+	query := r.db.GetQuery(ctx)
+	_, err := query.Item.WithContext(ctx).
+		Unscoped().
+		Where(query.Item.ID.Eq(id)).
+		UpdateColumn(query.Item.DeletedAt, nil)
+	return err
+}
+
+func (r *ItemGormRepository) DeletePermanently(ctx context.Context, id uint) error {
+	query := r.db.GetQuery(ctx)
+	_, err := query.Item.WithContext(ctx).Unscoped().Where(query.Item.ID.Eq(id)).Delete()
+	return err
+}
+
 // BulkCreate creates multiple items in a single batch operation
 func (r *ItemGormRepository) BulkCreate(ctx context.Context, items []*domain.Item) error {
 	if len(items) == 0 {
@@ -185,6 +200,11 @@ func (repo *ItemGormRepository) applyFilter(
 	sql gormgen.IItemDo,
 	filter domain.ItemFilter,
 ) gormgen.IItemDo {
+	if filter.OnlyDeleted {
+		sql = sql.Unscoped().Where(query.Item.DeletedAt.IsNotNull())
+	} else if filter.IncludeDeleted {
+		sql = sql.Unscoped()
+	}
 	if filter.PublicID != nil {
 		sql = sql.Where(query.Item.PublicID.Eq(*filter.PublicID))
 	}
@@ -194,6 +214,9 @@ func (repo *ItemGormRepository) applyFilter(
 	if filter.Role != nil {
 		sql = sql.Where(query.Item.Role.Eq(string(*filter.Role)))
 	}
+	if filter.Type != nil {
+		sql = sql.Where(query.Item.Type.Eq(string(*filter.Type)))
+	}
 	if filter.ResponseID != nil {
 		sql = sql.Where(query.Item.ResponseID.Eq(*filter.ResponseID))
 	}
@@ -0,0 +1,92 @@
+package itemrepo
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+	domain "menlo.ai/indigo-api-gateway/app/domain/conversation"
+	"menlo.ai/indigo-api-gateway/app/infrastructure/database/dbschema"
+	"menlo.ai/indigo-api-gateway/app/infrastructure/database/repository/transaction"
+)
+
+func newTestDatabase(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{
+		NamingStrategy:                           schema.NamingStrategy{SingularTable: true},
+		DisableForeignKeyConstraintWhenMigrating: true,
+	})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	if err := db.AutoMigrate(&dbschema.Item{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return db
+}
+
+func TestItemTrash(t *testing.T) {
+	ctx := context.Background()
+	repo := NewItemGormRepository(transaction.NewDatabase(newTestDatabase(t)))
+	const conversationID = 1
+	items := make(map[string]*domain.Item)
+	for _, publicID := range []string{"msg_live", "msg_trashed", "msg_removed"} {
+		role, status := domain.ItemRoleUser, domain.ItemStatusCompleted
+		item := &domain.Item{PublicID: publicID, ConversationID: conversationID, Type: domain.ItemTypeMessage, Role: &role, Status: &status}
+		if err := repo.Create(ctx, item); err != nil {
+			t.Fatalf("create item: %v", err)
+		}
+		items[publicID] = item
+	}
+	for _, publicID := range []string{"msg_trashed", "msg_removed"} {
+		if err := repo.Delete(ctx, items[publicID].ID); err != nil {
+			t.Fatalf("trash item: %v", err)
+		}
+	}
+
+	trashed := map[string]bool{"msg_trashed": true, "msg_removed": true}
+	list := func(filter domain.ItemFilter) string {
+		t.Helper()
+		filter.ConversationID = &[]uint{conversationID}[0]
+		found, err := repo.FindByFilter(ctx, filter, nil)
+		if err != nil {
+			t.Fatalf("find items: %v", err)
+		}
+		ids := make([]string, 0, len(found))
+		for _, item := range found {
+			if (item.DeletedAt != nil) != trashed[item.PublicID] {
+				t.Fatalf("unexpected deleted_at for %s: %v", item.PublicID, item.DeletedAt)
+			}
+			ids = append(ids, item.PublicID)
+		}
+		sort.Strings(ids)
+		return strings.Join(ids, ",")
+	}
+	if got := list(domain.ItemFilter{}); got != "msg_live" {
+		t.Fatalf("expected the trash to be hidden, got %s", got)
+	}
+	if got := list(domain.ItemFilter{OnlyDeleted: true}); got != "msg_removed,msg_trashed" {
+		t.Fatalf("expected only the trash, got %s", got)
+	}
+	if got := list(domain.ItemFilter{IncludeDeleted: true}); got != "msg_live,msg_removed,msg_trashed" {
+		t.Fatalf("expected every item, got %s", got)
+	}
+
+	if err := repo.Restore(ctx, items["msg_trashed"].ID); err != nil {
+		t.Fatalf("restore item: %v", err)
+	}
+	trashed["msg_trashed"] = false
+	if err := repo.DeletePermanently(ctx, items["msg_removed"].ID); err != nil {
+		t.Fatalf("delete item permanently: %v", err)
+	}
+	if got := list(domain.ItemFilter{IncludeDeleted: true}); got != "msg_live,msg_trashed" {
+		t.Fatalf("expected the restored item back and the removed one gone, got %s", got)
+	}
+	if got := list(domain.ItemFilter{OnlyDeleted: true}); got != "" {
+		t.Fatalf("expected an empty trash, got %s", got)
+	}
+}
@@ -0,0 +1,152 @@
+package mcprepo
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"menlo.ai/indigo-api-gateway/app/domain/mcp/mcpactivity"
+	"menlo.ai/indigo-api-gateway/app/domain/query"
+	"menlo.ai/indigo-api-gateway/app/infrastructure/database/dbschema"
+	"menlo.ai/indigo-api-gateway/app/infrastructure/database/repository/transaction"
+	"menlo.ai/indigo-api-gateway/app/utils/functional"
+)
+
+type MCPActivityRepository struct {
+	db *transaction.Database
+}
+
+var _ mcpactivity.ActivityRepository = (*MCPActivityRepository)(nil)
+
+func NewMCPActivityRepository(db *transaction.Database) mcpactivity.ActivityRepository {
+	return &MCPActivityRepository{db: db}
+}
+
+func (r *MCPActivityRepository) Create(ctx context.Context, activity *mcpactivity.Activity) error {
+	model := dbschema.NewSchemaMCPActivity(activity)
+	if err := r.db.GetTx(ctx).WithContext(ctx).Create(model).Error; err != nil {
+		return err
+	}
+	activity.ID = model.ID
+	activity.CreatedAt = model.CreatedAt
+	return nil
+}
+
+func (r *MCPActivityRepository) FindByFilter(ctx context.Context, filter mcpactivity.ActivityFilter, pagination *query.Pagination) ([]*mcpactivity.Activity, error) {
+	sql := applyActivityFilter(r.db.GetTx(ctx).WithContext(ctx).Model(&dbschema.MCPActivity{}), filter)
+	order := "id ASC"
+	if pagination != nil {
+		if pagination.Limit != nil && *pagination.Limit > 0 {
+			sql = sql.Limit(*pagination.Limit)
+		}
+		if pagination.Offset != nil {
+			sql = sql.Offset(*pagination.Offset)
+		}
+		if pagination.After != nil {
+			if pagination.Order == "desc" {
+				sql = sql.Where("id < ?", *pagination.After)
+			} else {
+				sql = sql.Where("id > ?", *pagination.After)
+			}
+		}
+		if pagination.Order == "desc" {
+			order = "id DESC"
+		}
+	}
+
+	var rows []*dbschema.MCPActivity
+	if err := sql.Order(order).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	return functional.Map(rows, func(item *dbschema.MCPActivity) *mcpactivity.Activity {
+		return item.EtoD()
+	}), nil
+}
+
+func (r *MCPActivityRepository) Count(ctx context.Context, filter mcpactivity.ActivityFilter) (int64, error) {
+	var count int64
+	err := applyActivityFilter(r.db.GetTx(ctx).WithContext(ctx).Model(&dbschema.MCPActivity{}), filter).Count(&count).Error
+	return count, err
+}
+
+func (r *MCPActivityRepository) ToolStats(ctx context.Context, filter mcpactivity.ActivityFilter) ([]*mcpactivity.ToolStats, error) {
+	var rows []struct {
+		Tool         string
+		Calls        int64
+		Errors       int64
+		AvgLatencyMs float64
+		P95LatencyMs float64
+		LastCalledAt time.Time
+	}
+	err := applyActivityFilter(r.db.GetTx(ctx).WithContext(ctx).Model(&dbschema.MCPActivity{}), filter).
+		Select(`tool,
+			COUNT(*) AS calls,
+			COUNT(*) FILTER (WHERE NOT success) AS errors,
+			AVG(latency_ms) AS avg_latency_ms,
+			PERCENTILE_CONT(0.95) WITHIN GROUP (ORDER BY latency_ms) AS p95_latency_ms,
+			MAX(created_at) AS last_called_at`).
+		Where("method = ? AND tool IS NOT NULL", "tools/call").
+		Group("tool").
+		Order("calls DESC, tool ASC").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	stats := make([]*mcpactivity.ToolStats, 0, len(rows))
+	for _, row := range rows {
+		stats = append(stats, &mcpactivity.ToolStats{
+			Tool:         row.Tool,
+			Calls:        row.Calls,
+			Errors:       row.Errors,
+			AvgLatencyMs: row.AvgLatencyMs,
+			P95LatencyMs: row.P95LatencyMs,
+			LastCalledAt: row.LastCalledAt,
+		})
+	}
+	return stats, nil
+}
+
+func (r *MCPActivityRepository) DeleteBefore(ctx context.Context, cutoff time.Time, limit int) (int64, error) {
+	db := r.db.GetTx(ctx).WithContext(ctx)
+	candidates := db.Model(&dbschema.MCPActivity{}).
+		Select("id").
+		Where("created_at < ?", cutoff).
+		Order("id").
+		Limit(limit)
+	result := db.Where("id IN (?)", candidates).Delete(&dbschema.MCPActivity{})
+	return result.RowsAffected, result.Error
+}
+
+func applyActivityFilter(sql *gorm.DB, filter mcpactivity.ActivityFilter) *gorm.DB {
+	if filter.PublicID != nil {
+		sql = sql.Where("public_id = ?", *filter.PublicID)
+	}
+	if filter.OrganizationID != nil {
+		sql = sql.Where("organization_id = ?", *filter.OrganizationID)
+	}
+	if filter.ProjectID != nil {
+		sql = sql.Where("project_id = ?", *filter.ProjectID)
+	}
+	if filter.UserPublicID != nil {
+		sql = sql.Where("user_public_id = ?", *filter.UserPublicID)
+	}
+	if filter.APIKeyPublicID != nil {
+		sql = sql.Where("api_key_public_id = ?", *filter.APIKeyPublicID)
+	}
+	if filter.Method != nil {
+		sql = sql.Where("method = ?", *filter.Method)
+	}
+	if filter.Tool != nil {
+		sql = sql.Where("tool = ?", *filter.Tool)
+	}
+	if filter.Success != nil {
+		sql = sql.Where("success = ?", *filter.Success)
+	}
+	if filter.CreatedAfter != nil {
+		sql = sql.Where("created_at >= ?", *filter.CreatedAfter)
+	}
+	if filter.CreatedBefore != nil {
+		sql = sql.Where("created_at < ?", *filter.CreatedBefore)
+	}
+	return sql
+}
@@ -0,0 +1,108 @@
+package mcprepo
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+	"menlo.ai/indigo-api-gateway/app/domain/mcp/federatedmcp"
+	"menlo.ai/indigo-api-gateway/app/domain/query"
+	"menlo.ai/indigo-api-gateway/app/infrastructure/database/dbschema"
+	"menlo.ai/indigo-api-gateway/app/infrastructure/database/repository/transaction"
+	"menlo.ai/indigo-api-gateway/app/utils/functional"
+)
+
+type MCPServerRepository struct {
+	db *transaction.Database
+}
+
+var _ federatedmcp.ServerRepository = (*MCPServerRepository)(nil)
+
+func NewMCPServerRepository(db *transaction.Database) federatedmcp.ServerRepository {
+	return &MCPServerRepository{db: db}
+}
+
+func (r *MCPServerRepository) Create(ctx context.Context, server *federatedmcp.Server) error {
+	model := dbschema.NewSchemaMCPServer(server)
+	if err := r.db.GetTx(ctx).WithContext(ctx).Create(model).Error; err != nil {
+		return err
+	}
+	server.ID = model.ID
+	server.CreatedAt = model.CreatedAt
+	server.UpdatedAt = model.UpdatedAt
+	return nil
+}
+
+func (r *MCPServerRepository) Update(ctx context.Context, server *federatedmcp.Server) error {
+	model := dbschema.NewSchemaMCPServer(server)
+	if err := r.db.GetTx(ctx).WithContext(ctx).Save(model).Error; err != nil {
+		return err
+	}
+	server.UpdatedAt = model.UpdatedAt
+	return nil
+}
+
+func (r *MCPServerRepository) FindByFilter(ctx context.Context, filter federatedmcp.ServerFilter, pagination *query.Pagination) ([]*federatedmcp.Server, error) {
+	sql := applyServerFilter(r.db.GetTx(ctx).WithContext(ctx).Model(&dbschema.MCPServer{}), filter)
+	order := "id ASC"
+	if pagination != nil {
+		if pagination.Limit != nil && *pagination.Limit > 0 {
+			sql = sql.Limit(*pagination.Limit)
+		}
+		if pagination.Offset != nil {
+			sql = sql.Offset(*pagination.Offset)
+		}
+		if pagination.Order == "desc" {
+			order = "id DESC"
+		}
+	}
+
+	var rows []*dbschema.MCPServer
+	if err := sql.Order(order).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	return functional.Map(rows, func(item *dbschema.MCPServer) *federatedmcp.Server {
+		return item.EtoD()
+	}), nil
+}
+
+func (r *MCPServerRepository) Count(ctx context.Context, filter federatedmcp.ServerFilter) (int64, error) {
+	var count int64
+	err := applyServerFilter(r.db.GetTx(ctx).WithContext(ctx).Model(&dbschema.MCPServer{}), filter).Count(&count).Error
+	return count, err
+}
+
+func (r *MCPServerRepository) DeleteByID(ctx context.Context, id uint) error {
+	return r.db.GetTx(ctx).WithContext(ctx).Delete(&dbschema.MCPServer{}, id).Error
+}
+
+func applyServerFilter(sql *gorm.DB, filter federatedmcp.ServerFilter) *gorm.DB {
+	if filter.ID != nil {
+		sql = sql.Where("id = ?", *filter.ID)
+	}
+	if filter.PublicID != nil {
+		sql = sql.Where("public_id = ?", *filter.PublicID)
+	}
+	if filter.OrganizationID != nil {
+		sql = sql.Where("organization_id = ?", *filter.OrganizationID)
+	}
+	if filter.Name != nil {
+		sql = sql.Where("name = ?", *filter.Name)
+	}
+	if filter.Active != nil {
+		sql = sql.Where("active = ?", *filter.Active)
+	}
+	if filter.ProjectID != nil {
+		sql = sql.Where("project_id = ?", *filter.ProjectID)
+	}
+	if filter.WithoutProject != nil {
+		if *filter.WithoutProject {
+			sql = sql.Where("project_id IS NULL")
+		} else {
+			sql = sql.Where("project_id IS NOT NULL")
+		}
+	}
+	if filter.VisibleToProjectID != nil {
+		sql = sql.Where("project_id IS NULL OR project_id = ?", *filter.VisibleToProjectID)
+	}
+	return sql
+}
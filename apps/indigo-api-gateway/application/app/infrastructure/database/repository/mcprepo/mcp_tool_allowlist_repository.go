@@ -0,0 +1,54 @@
+package mcprepo
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"menlo.ai/indigo-api-gateway/app/domain/mcp/federatedmcp"
+	"menlo.ai/indigo-api-gateway/app/infrastructure/database/dbschema"
+	"menlo.ai/indigo-api-gateway/app/infrastructure/database/repository/transaction"
+)
+
+type MCPToolAllowlistRepository struct {
+	db *transaction.Database
+}
+
+var _ federatedmcp.ToolAllowlistRepository = (*MCPToolAllowlistRepository)(nil)
+
+func NewMCPToolAllowlistRepository(db *transaction.Database) federatedmcp.ToolAllowlistRepository {
+	return &MCPToolAllowlistRepository{db: db}
+}
+
+func (r *MCPToolAllowlistRepository) FindByProjectID(ctx context.Context, projectID uint) (*federatedmcp.ToolAllowlist, error) {
+	var model dbschema.MCPToolAllowlist
+	if err := r.db.GetTx(ctx).WithContext(ctx).Where("project_id = ?", projectID).First(&model).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return model.EtoD(), nil
+}
+
+func (r *MCPToolAllowlistRepository) Upsert(ctx context.Context, allowlist *federatedmcp.ToolAllowlist) error {
+	model := dbschema.NewSchemaMCPToolAllowlist(allowlist)
+	err := r.db.GetTx(ctx).WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "project_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"tools":      model.Tools,
+			"updated_at": gorm.Expr("NOW()"),
+		}),
+	}).Create(model).Error
+	if err != nil {
+		return err
+	}
+	allowlist.ID = model.ID
+	allowlist.UpdatedAt = model.UpdatedAt
+	return nil
+}
+
+func (r *MCPToolAllowlistRepository) DeleteByProjectID(ctx context.Context, projectID uint) error {
+	return r.db.GetTx(ctx).WithContext(ctx).Where("project_id = ?", projectID).Delete(&dbschema.MCPToolAllowlist{}).Error
+}
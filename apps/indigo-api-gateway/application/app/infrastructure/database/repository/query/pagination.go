@@ -0,0 +1,31 @@
+package query
+
+import (
+	"gorm.io/gorm"
+	"menlo.ai/indigo-api-gateway/app/domain/query"
+)
+
+// ApplyPagination applies cursor pagination on the id column and returns the matching order clause
+func ApplyPagination(sql *gorm.DB, pagination *query.Pagination) (*gorm.DB, string) {
+	order := "id ASC"
+	if pagination == nil {
+		return sql, order
+	}
+	if pagination.Limit != nil && *pagination.Limit > 0 {
+		sql = sql.Limit(*pagination.Limit)
+	}
+	if pagination.Offset != nil {
+		sql = sql.Offset(*pagination.Offset)
+	}
+	if pagination.After != nil {
+		if pagination.Order == "desc" {
+			sql = sql.Where("id < ?", *pagination.After)
+		} else {
+			sql = sql.Where("id > ?", *pagination.After)
+		}
+	}
+	if pagination.Order == "desc" {
+		order = "id DESC"
+	}
+	return sql, order
+}
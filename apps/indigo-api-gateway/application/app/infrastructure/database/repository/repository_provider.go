@@ -3,16 +3,24 @@ package repository
 import (
 	"github.com/google/wire"
 	"menlo.ai/indigo-api-gateway/app/infrastructure/database/repository/apikeyrepo"
+	"menlo.ai/indigo-api-gateway/app/infrastructure/database/repository/completioncacherepo"
 	"menlo.ai/indigo-api-gateway/app/infrastructure/database/repository/conversationrepo"
+	"menlo.ai/indigo-api-gateway/app/infrastructure/database/repository/cronrepo"
+	"menlo.ai/indigo-api-gateway/app/infrastructure/database/repository/filerepo"
 	"menlo.ai/indigo-api-gateway/app/infrastructure/database/repository/inviterepo"
 	"menlo.ai/indigo-api-gateway/app/infrastructure/database/repository/itemrepo"
+	"menlo.ai/indigo-api-gateway/app/infrastructure/database/repository/mcprepo"
 	"menlo.ai/indigo-api-gateway/app/infrastructure/database/repository/modelrepo"
 	"menlo.ai/indigo-api-gateway/app/infrastructure/database/repository/organizationrepo"
 	"menlo.ai/indigo-api-gateway/app/infrastructure/database/repository/projectrepo"
 	"menlo.ai/indigo-api-gateway/app/infrastructure/database/repository/responserepo"
+	"menlo.ai/indigo-api-gateway/app/infrastructure/database/repository/retentionrepo"
+	"menlo.ai/indigo-api-gateway/app/infrastructure/database/repository/secretrepo"
 	"menlo.ai/indigo-api-gateway/app/infrastructure/database/repository/settingsrepo"
 	"menlo.ai/indigo-api-gateway/app/infrastructure/database/repository/transaction"
 	"menlo.ai/indigo-api-gateway/app/infrastructure/database/repository/userrepo"
+	"menlo.ai/indigo-api-gateway/app/infrastructure/database/repository/vectorstorerepo"
+	"menlo.ai/indigo-api-gateway/app/infrastructure/database/repository/webhookrepo"
 	"menlo.ai/indigo-api-gateway/app/infrastructure/database/repository/workspacerepo"
 )
 
@@ -28,8 +36,22 @@ var RepositoryProvider = wire.NewSet(
 	modelrepo.NewProviderModelGormRepository,
 	modelrepo.NewModelCatalogGormRepository,
 	responserepo.NewResponseGormRepository,
+	responserepo.NewResponseJobRepository,
 	workspacerepo.NewWorkspaceGormRepository,
+	filerepo.NewFileRepository,
+	vectorstorerepo.NewVectorStoreRepository,
+	vectorstorerepo.NewVectorStoreFileRepository,
+	vectorstorerepo.NewChunkRepository,
+	retentionrepo.NewRetentionRepository,
 	settingsrepo.NewSettingRepository,
 	settingsrepo.NewAuditRepository,
+	webhookrepo.NewWebhookEndpointRepository,
+	webhookrepo.NewWebhookDeliveryRepository,
+	mcprepo.NewMCPServerRepository,
+	mcprepo.NewMCPToolAllowlistRepository,
+	mcprepo.NewMCPActivityRepository,
+	cronrepo.NewCronJobRunRepository,
+	completioncacherepo.NewCompletionCacheSettingsRepository,
+	secretrepo.NewEncryptedSecretRepository,
 	transaction.NewDatabase,
 )
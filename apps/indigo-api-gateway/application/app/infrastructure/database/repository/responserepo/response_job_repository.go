@@ -0,0 +1,121 @@
+package responserepo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"menlo.ai/indigo-api-gateway/app/domain/response"
+	"menlo.ai/indigo-api-gateway/app/infrastructure/database/dbschema"
+	"menlo.ai/indigo-api-gateway/app/infrastructure/database/repository/transaction"
+)
+
+type ResponseJobRepository struct {
+	db *transaction.Database
+}
+
+var _ response.ResponseJobRepository = (*ResponseJobRepository)(nil)
+
+func NewResponseJobRepository(db *transaction.Database) response.ResponseJobRepository {
+	return &ResponseJobRepository{db: db}
+}
+
+func (r *ResponseJobRepository) Enqueue(ctx context.Context, job *response.ResponseJob) error {
+	model := dbschema.NewSchemaResponseJob(job)
+	if err := r.db.GetTx(ctx).WithContext(ctx).Create(model).Error; err != nil {
+		return err
+	}
+	job.ID = model.ID
+	job.CreatedAt = model.CreatedAt
+	job.UpdatedAt = model.UpdatedAt
+	return nil
+}
+
+// Claim locks the oldest runnable job with SKIP LOCKED so concurrent workers across replicas never pick the same job
+func (r *ResponseJobRepository) Claim(ctx context.Context, workerID string, lease time.Duration) (*response.ResponseJob, error) {
+	var claimed *dbschema.ResponseJob
+	err := r.db.GetTx(ctx).WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		var job dbschema.ResponseJob
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? OR (status = ? AND locked_until < ?)", response.ResponseJobStatusQueued, response.ResponseJobStatusRunning, now).
+			Order("id").
+			Take(&job).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil
+			}
+			return err
+		}
+		lockedUntil := now.Add(lease)
+		if err := tx.Model(&job).Updates(map[string]any{
+			"status":       response.ResponseJobStatusRunning,
+			"locked_by":    workerID,
+			"locked_until": lockedUntil,
+			"attempts":     gorm.Expr("attempts + 1"),
+		}).Error; err != nil {
+			return err
+		}
+		job.Status = string(response.ResponseJobStatusRunning)
+		job.LockedBy = &workerID
+		job.LockedUntil = &lockedUntil
+		job.Attempts++
+		claimed = &job
+		return nil
+	})
+	if err != nil || claimed == nil {
+		return nil, err
+	}
+	return claimed.EtoD(), nil
+}
+
+func (r *ResponseJobRepository) Heartbeat(ctx context.Context, jobID uint, workerID string, lease time.Duration) (bool, bool, error) {
+	db := r.db.GetTx(ctx).WithContext(ctx)
+	result := db.Model(&dbschema.ResponseJob{}).
+		Where("id = ? AND locked_by = ? AND status = ?", jobID, workerID, response.ResponseJobStatusRunning).
+		Update("locked_until", time.Now().Add(lease))
+	if result.Error != nil {
+		return false, false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return false, false, nil
+	}
+	var job dbschema.ResponseJob
+	if err := db.Select("cancel_requested").Where("id = ?", jobID).Take(&job).Error; err != nil {
+		return false, true, err
+	}
+	return job.CancelRequested, true, nil
+}
+
+func (r *ResponseJobRepository) IsCancelRequested(ctx context.Context, jobID uint) (bool, error) {
+	var job dbschema.ResponseJob
+	if err := r.db.GetTx(ctx).WithContext(ctx).Select("cancel_requested").Where("id = ?", jobID).Take(&job).Error; err != nil {
+		return false, err
+	}
+	return job.CancelRequested, nil
+}
+
+func (r *ResponseJobRepository) Finish(ctx context.Context, jobID uint, workerID string, status response.ResponseJobStatus, lastError *string) error {
+	return r.db.GetTx(ctx).WithContext(ctx).Model(&dbschema.ResponseJob{}).
+		Where("id = ? AND locked_by = ?", jobID, workerID).
+		Updates(map[string]any{
+			"status":       status,
+			"last_error":   lastError,
+			"locked_by":    nil,
+			"locked_until": nil,
+		}).Error
+}
+
+func (r *ResponseJobRepository) RequestCancel(ctx context.Context, responseID uint) error {
+	db := r.db.GetTx(ctx).WithContext(ctx)
+	if err := db.Model(&dbschema.ResponseJob{}).
+		Where("response_id = ? AND status = ?", responseID, response.ResponseJobStatusQueued).
+		Update("status", response.ResponseJobStatusCancelled).Error; err != nil {
+		return err
+	}
+	return db.Model(&dbschema.ResponseJob{}).
+		Where("response_id = ? AND status = ?", responseID, response.ResponseJobStatusRunning).
+		Update("cancel_requested", true).Error
+}
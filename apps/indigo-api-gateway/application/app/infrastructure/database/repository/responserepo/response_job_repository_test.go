@@ -0,0 +1,153 @@
+package responserepo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+	"menlo.ai/indigo-api-gateway/app/domain/response"
+	"menlo.ai/indigo-api-gateway/app/infrastructure/database/dbschema"
+	"menlo.ai/indigo-api-gateway/app/infrastructure/database/repository/transaction"
+	"menlo.ai/indigo-api-gateway/app/utils/ptr"
+)
+
+func newTestDatabase(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{
+		NamingStrategy:                           schema.NamingStrategy{SingularTable: true},
+		DisableForeignKeyConstraintWhenMigrating: true,
+	})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	if err := db.AutoMigrate(&dbschema.Response{}, &dbschema.ResponseJob{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return db
+}
+
+func enqueue(t *testing.T, repo response.ResponseJobRepository, responseID uint) *response.ResponseJob {
+	t.Helper()
+	job := &response.ResponseJob{
+		ResponseID:     responseID,
+		OrganizationID: 3,
+		ProjectID:      ptr.ToUint(5),
+		Payload:        "{}",
+		Status:         response.ResponseJobStatusQueued,
+	}
+	if err := repo.Enqueue(context.Background(), job); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	return job
+}
+
+func TestResponseJobClaimLeasesOldestJob(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDatabase(t)
+	repo := NewResponseJobRepository(transaction.NewDatabase(db))
+	first := enqueue(t, repo, 1)
+	second := enqueue(t, repo, 2)
+
+	claimed, err := repo.Claim(ctx, "worker-a", time.Minute)
+	if err != nil {
+		t.Fatalf("claim: %v", err)
+	}
+	if claimed == nil || claimed.ID != first.ID {
+		t.Fatalf("expected the oldest job %d, got %+v", first.ID, claimed)
+	}
+	if claimed.Status != response.ResponseJobStatusRunning || claimed.Attempts != 1 {
+		t.Fatalf("expected a running job on its first attempt, got %s attempt %d", claimed.Status, claimed.Attempts)
+	}
+	if claimed.LockedBy == nil || *claimed.LockedBy != "worker-a" {
+		t.Fatalf("expected the job locked by worker-a, got %v", claimed.LockedBy)
+	}
+	if claimed.OrganizationID != 3 || claimed.ProjectID == nil || *claimed.ProjectID != 5 {
+		t.Fatalf("expected the organization and project of the request, got %d and %v", claimed.OrganizationID, claimed.ProjectID)
+	}
+
+	next, err := repo.Claim(ctx, "worker-b", time.Minute)
+	if err != nil {
+		t.Fatalf("claim: %v", err)
+	}
+	if next == nil || next.ID != second.ID {
+		t.Fatalf("expected the leased job to be skipped for %d, got %+v", second.ID, next)
+	}
+	empty, err := repo.Claim(ctx, "worker-c", time.Minute)
+	if err != nil || empty != nil {
+		t.Fatalf("expected an empty queue, got %+v, %v", empty, err)
+	}
+
+	// A job whose lease expired is claimed again by another worker with one more attempt
+	if err := db.Model(&dbschema.ResponseJob{}).Where("id = ?", first.ID).Update("locked_until", time.Now().Add(-time.Second)).Error; err != nil {
+		t.Fatalf("expire lease: %v", err)
+	}
+	reclaimed, err := repo.Claim(ctx, "worker-c", time.Minute)
+	if err != nil {
+		t.Fatalf("claim: %v", err)
+	}
+	if reclaimed == nil || reclaimed.ID != first.ID || reclaimed.Attempts != 2 {
+		t.Fatalf("expected job %d on its second attempt, got %+v", first.ID, reclaimed)
+	}
+	if _, ok, err := repo.Heartbeat(ctx, first.ID, "worker-a", time.Minute); err != nil || ok {
+		t.Fatalf("expected the previous worker to have lost the lease, got ok=%v err=%v", ok, err)
+	}
+	if err := repo.Finish(ctx, first.ID, "worker-a", response.ResponseJobStatusCompleted, nil); err != nil {
+		t.Fatalf("finish: %v", err)
+	}
+	var stored dbschema.ResponseJob
+	if err := db.First(&stored, first.ID).Error; err != nil {
+		t.Fatalf("load job: %v", err)
+	}
+	if stored.Status != string(response.ResponseJobStatusRunning) {
+		t.Fatalf("expected a finish from the previous worker to be ignored, got %s", stored.Status)
+	}
+}
+
+func TestResponseJobRequestCancel(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDatabase(t)
+	repo := NewResponseJobRepository(transaction.NewDatabase(db))
+	running := enqueue(t, repo, 1)
+	if _, err := repo.Claim(ctx, "worker-a", time.Minute); err != nil {
+		t.Fatalf("claim: %v", err)
+	}
+	queued := enqueue(t, repo, 2)
+
+	if err := repo.RequestCancel(ctx, 2); err != nil {
+		t.Fatalf("cancel queued: %v", err)
+	}
+	if job, err := repo.Claim(ctx, "worker-b", time.Minute); err != nil || job != nil {
+		t.Fatalf("expected the cancelled job %d not to be claimed, got %+v, %v", queued.ID, job, err)
+	}
+
+	cancelRequested, ok, err := repo.Heartbeat(ctx, running.ID, "worker-a", time.Minute)
+	if err != nil || !ok || cancelRequested {
+		t.Fatalf("expected a plain heartbeat, got cancel=%v ok=%v err=%v", cancelRequested, ok, err)
+	}
+	if cancelRequested, err := repo.IsCancelRequested(ctx, running.ID); err != nil || cancelRequested {
+		t.Fatalf("expected no cancellation yet, got %v, %v", cancelRequested, err)
+	}
+	if err := repo.RequestCancel(ctx, 1); err != nil {
+		t.Fatalf("cancel running: %v", err)
+	}
+	if cancelRequested, err := repo.IsCancelRequested(ctx, running.ID); err != nil || !cancelRequested {
+		t.Fatalf("expected the cancellation to be read without a heartbeat, got %v, %v", cancelRequested, err)
+	}
+	cancelRequested, ok, err = repo.Heartbeat(ctx, running.ID, "worker-a", time.Minute)
+	if err != nil || !ok || !cancelRequested {
+		t.Fatalf("expected the heartbeat to report the cancellation, got cancel=%v ok=%v err=%v", cancelRequested, ok, err)
+	}
+	if err := repo.Finish(ctx, running.ID, "worker-a", response.ResponseJobStatusCancelled, nil); err != nil {
+		t.Fatalf("finish: %v", err)
+	}
+	var stored dbschema.ResponseJob
+	if err := db.First(&stored, running.ID).Error; err != nil {
+		t.Fatalf("load job: %v", err)
+	}
+	if stored.Status != string(response.ResponseJobStatusCancelled) || stored.LockedBy != nil {
+		t.Fatalf("expected a released cancelled job, got %s locked by %v", stored.Status, stored.LockedBy)
+	}
+}
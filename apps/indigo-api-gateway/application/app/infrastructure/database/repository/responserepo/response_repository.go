@@ -2,7 +2,9 @@ package responserepo
 
 import (
 	"context"
+	"time"
 
+	"gorm.io/gorm"
 	"menlo.ai/indigo-api-gateway/app/domain/query"
 	"menlo.ai/indigo-api-gateway/app/domain/response"
 	"menlo.ai/indigo-api-gateway/app/infrastructure/database/dbschema"
@@ -163,3 +165,29 @@ func (r *ResponseGormRepository) applyFilter(
 	}
 	return sql
 }
+
+// FailOrphaned fails the unfinished responses last updated before the cutoff that no background job is working on
+func (r *ResponseGormRepository) FailOrphaned(ctx context.Context, updatedBefore time.Time, errorJSON string) (int64, error) {
+	db := r.db.GetTx(ctx).WithContext(ctx)
+	activeJobs := db.Session(&gorm.Session{NewDB: true}).Model(&dbschema.ResponseJob{}).
+		Select("1").
+		Where("response_job.response_id = responses.id AND response_job.status IN ?", []response.ResponseJobStatus{
+			response.ResponseJobStatusQueued,
+			response.ResponseJobStatusRunning,
+		})
+	now := time.Now()
+	result := db.Model(&dbschema.Response{}).
+		Where("status IN ? AND updated_at < ?", []response.ResponseStatus{
+			response.ResponseStatusPending,
+			response.ResponseStatusQueued,
+			response.ResponseStatusRunning,
+		}, updatedBefore).
+		Where("NOT EXISTS (?)", activeJobs).
+		Updates(map[string]any{
+			"status":     response.ResponseStatusFailed,
+			"error":      errorJSON,
+			"failed_at":  now,
+			"updated_at": now,
+		})
+	return result.RowsAffected, result.Error
+}
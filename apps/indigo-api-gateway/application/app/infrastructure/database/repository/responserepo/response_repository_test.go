@@ -0,0 +1,82 @@
+package responserepo
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+	"menlo.ai/indigo-api-gateway/app/domain/response"
+	"menlo.ai/indigo-api-gateway/app/infrastructure/database/dbschema"
+	"menlo.ai/indigo-api-gateway/app/infrastructure/database/repository/transaction"
+)
+
+func createResponse(t *testing.T, db *gorm.DB, status response.ResponseStatus, updatedAt time.Time) *dbschema.Response {
+	t.Helper()
+	row := &dbschema.Response{
+		PublicID: fmt.Sprintf("resp_%d", updatedAt.UnixNano()),
+		UserID:   1,
+		Model:    "test-model",
+		Status:   string(status),
+		Input:    "{}",
+	}
+	row.CreatedAt = updatedAt
+	row.UpdatedAt = updatedAt
+	if err := db.Create(row).Error; err != nil {
+		t.Fatalf("create response: %v", err)
+	}
+	return row
+}
+
+func TestResponseFailOrphaned(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDatabase(t)
+	repo := NewResponseGormRepository(transaction.NewDatabase(db))
+	jobRepo := NewResponseJobRepository(transaction.NewDatabase(db))
+	cutoff := time.Now().Add(-time.Hour)
+	old := cutoff.Add(-time.Minute)
+
+	orphaned := createResponse(t, db, response.ResponseStatusRunning, old)
+	pending := createResponse(t, db, response.ResponseStatusPending, old.Add(-time.Second))
+	recent := createResponse(t, db, response.ResponseStatusRunning, time.Now())
+	completed := createResponse(t, db, response.ResponseStatusCompleted, old.Add(-2*time.Second))
+	// a background response whose job is still queued belongs to the workers
+	queued := createResponse(t, db, response.ResponseStatusQueued, old.Add(-3*time.Second))
+	enqueue(t, jobRepo, queued.ID)
+	// a finished job no longer protects its response
+	abandoned := createResponse(t, db, response.ResponseStatusRunning, old.Add(-4*time.Second))
+	job := enqueue(t, jobRepo, abandoned.ID)
+	if err := db.Model(&dbschema.ResponseJob{}).Where("id = ?", job.ID).Update("status", response.ResponseJobStatusFailed).Error; err != nil {
+		t.Fatalf("finish job: %v", err)
+	}
+
+	failed, err := repo.FailOrphaned(ctx, cutoff, `{"code":"test"}`)
+	if err != nil {
+		t.Fatalf("fail orphaned: %v", err)
+	}
+	if failed != 3 {
+		t.Fatalf("expected 3 orphaned responses, got %d", failed)
+	}
+
+	expected := map[uint]response.ResponseStatus{
+		orphaned.ID:  response.ResponseStatusFailed,
+		pending.ID:   response.ResponseStatusFailed,
+		abandoned.ID: response.ResponseStatusFailed,
+		recent.ID:    response.ResponseStatusRunning,
+		completed.ID: response.ResponseStatusCompleted,
+		queued.ID:    response.ResponseStatusQueued,
+	}
+	for id, status := range expected {
+		var row dbschema.Response
+		if err := db.First(&row, id).Error; err != nil {
+			t.Fatalf("find response %d: %v", id, err)
+		}
+		if row.Status != string(status) {
+			t.Fatalf("response %d: expected %s, got %s", id, status, row.Status)
+		}
+		if status == response.ResponseStatusFailed && (row.Error == nil || *row.Error != `{"code":"test"}` || row.FailedAt == nil) {
+			t.Fatalf("response %d: expected the error and failure time, got %v %v", id, row.Error, row.FailedAt)
+		}
+	}
+}
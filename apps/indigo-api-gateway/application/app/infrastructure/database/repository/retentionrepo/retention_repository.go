@@ -0,0 +1,130 @@
+package retentionrepo
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"menlo.ai/indigo-api-gateway/app/domain/conversation"
+	"menlo.ai/indigo-api-gateway/app/domain/retention"
+	"menlo.ai/indigo-api-gateway/app/infrastructure/database/dbschema"
+	"menlo.ai/indigo-api-gateway/app/infrastructure/database/repository/transaction"
+)
+
+const organizationMembersQuery = "SELECT user_id FROM organization_member WHERE organization_id = ? AND deleted_at IS NULL"
+
+type RetentionRepository struct {
+	db *transaction.Database
+}
+
+var _ retention.RetentionRepository = (*RetentionRepository)(nil)
+
+func NewRetentionRepository(db *transaction.Database) retention.RetentionRepository {
+	return &RetentionRepository{db: db}
+}
+
+func (r *RetentionRepository) ArchiveInactiveConversations(ctx context.Context, scope retention.Scope, inactiveSince time.Time, limit int) (int64, error) {
+	db := r.db.GetTx(ctx).WithContext(ctx)
+	candidates := applyScope(db.Model(&dbschema.Conversation{}), scope).
+		Select("id").
+		Where("status = ? AND updated_at < ?", conversation.ConversationStatusActive, inactiveSince).
+		Order("id").
+		Limit(limit)
+
+	// UpdateColumn keeps updated_at so the last activity stays visible after archiving
+	result := db.Model(&dbschema.Conversation{}).
+		Where("id IN (?)", candidates).
+		UpdateColumn("status", conversation.ConversationStatusArchived)
+	return result.RowsAffected, result.Error
+}
+
+func (r *RetentionRepository) PurgeDeletedConversations(ctx context.Context, scope retention.Scope, deletedBefore time.Time, limit int) (int64, error) {
+	db := r.db.GetTx(ctx).WithContext(ctx)
+	var ids []uint
+	if err := applyScope(db.Unscoped().Model(&dbschema.Conversation{}), scope).
+		Where("((deleted_at IS NOT NULL AND deleted_at < ?) OR (deleted_at IS NULL AND status = ? AND updated_at < ?))",
+			deletedBefore, conversation.ConversationStatusDeleted, deletedBefore).
+		Order("id").
+		Limit(limit).
+		Pluck("id", &ids).Error; err != nil {
+		return 0, err
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	var purged int64
+	err := db.Transaction(func(tx *gorm.DB) error {
+		responseIDs := tx.Unscoped().Model(&dbschema.Response{}).Select("id").Where("conversation_id IN ?", ids)
+		if err := tx.Unscoped().
+			Where("(conversation_id IN ? OR response_id IN (?))", ids, responseIDs).
+			Delete(&dbschema.Item{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("conversation_id IN ?", ids).Delete(&dbschema.Response{}).Error; err != nil {
+			return err
+		}
+		result := tx.Unscoped().Where("id IN ?", ids).Delete(&dbschema.Conversation{})
+		purged = result.RowsAffected
+		return result.Error
+	})
+	return purged, err
+}
+
+func (r *RetentionRepository) PurgeDeletedItems(ctx context.Context, scope retention.Scope, deletedBefore time.Time, limit int) (int64, error) {
+	db := r.db.GetTx(ctx).WithContext(ctx)
+	conversationIDs := applyScope(db.Unscoped().Model(&dbschema.Conversation{}), scope).Select("id")
+	candidates := db.Unscoped().Model(&dbschema.Item{}).
+		Select("id").
+		Where("conversation_id IN (?)", conversationIDs).
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", deletedBefore).
+		Order("id").
+		Limit(limit)
+
+	result := db.Unscoped().Where("id IN (?)", candidates).Delete(&dbschema.Item{})
+	return result.RowsAffected, result.Error
+}
+
+func (r *RetentionRepository) PurgeOrphanResponses(ctx context.Context, organizationID uint, deletedBefore time.Time, limit int) (int64, error) {
+	db := r.db.GetTx(ctx).WithContext(ctx)
+	var ids []uint
+	if err := db.Unscoped().Model(&dbschema.Response{}).
+		Where("user_id IN (?)", gorm.Expr(organizationMembersQuery, organizationID)).
+		Where("conversation_id IS NOT NULL").
+		// A soft-deleted conversation can still be restored, its responses are kept until the deletion passes the cutoff
+		Where("NOT EXISTS (SELECT 1 FROM conversation WHERE conversation.id = responses.conversation_id AND (conversation.deleted_at IS NULL OR conversation.deleted_at >= ?))", deletedBefore).
+		Order("id").
+		Limit(limit).
+		Pluck("id", &ids).Error; err != nil {
+		return 0, err
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	var purged int64
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// Items outlive the response so a deleted conversation can still be restored with its messages
+		if err := tx.Unscoped().Model(&dbschema.Item{}).
+			Where("response_id IN ?", ids).
+			UpdateColumn("response_id", nil).Error; err != nil {
+			return err
+		}
+		result := tx.Unscoped().Where("id IN ?", ids).Delete(&dbschema.Response{})
+		purged = result.RowsAffected
+		return result.Error
+	})
+	return purged, err
+}
+
+// applyScope limits a conversation query to the organization members and the scope's workspaces
+func applyScope(db *gorm.DB, scope retention.Scope) *gorm.DB {
+	db = db.Where("user_id IN (?)", gorm.Expr(organizationMembersQuery, scope.OrganizationID))
+	if len(scope.WorkspacePublicIDs) > 0 {
+		db = db.Where("workspace_public_id IN ?", scope.WorkspacePublicIDs)
+	}
+	if len(scope.ExcludeWorkspacePublicIDs) > 0 {
+		db = db.Where("(workspace_public_id IS NULL OR workspace_public_id NOT IN ?)", scope.ExcludeWorkspacePublicIDs)
+	}
+	return db
+}
@@ -0,0 +1,197 @@
+package retentionrepo
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+	"menlo.ai/indigo-api-gateway/app/domain/retention"
+	"menlo.ai/indigo-api-gateway/app/infrastructure/database/dbschema"
+	"menlo.ai/indigo-api-gateway/app/infrastructure/database/repository/transaction"
+)
+
+func newTestDatabase(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{
+		NamingStrategy:                           schema.NamingStrategy{SingularTable: true},
+		DisableForeignKeyConstraintWhenMigrating: true,
+	})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	if err := db.AutoMigrate(&dbschema.OrganizationMember{}, &dbschema.Conversation{}, &dbschema.Response{}, &dbschema.Item{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return db
+}
+
+func TestPurgeOrphanResponses(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDatabase(t)
+	repo := NewRetentionRepository(transaction.NewDatabase(db))
+
+	const organizationID, userID = 1, 7
+	if err := db.Create(&dbschema.OrganizationMember{OrganizationID: organizationID, UserID: userID, Role: "owner"}).Error; err != nil {
+		t.Fatalf("seed member: %v", err)
+	}
+
+	now := time.Now()
+	cutoff := now.AddDate(0, 0, -30)
+	conversations := map[string]*dbschema.Conversation{
+		"active":         {PublicID: "conv_active", UserID: userID, Status: "active"},
+		"recent_delete":  {PublicID: "conv_recent", UserID: userID, Status: "active"},
+		"expired_delete": {PublicID: "conv_expired", UserID: userID, Status: "active"},
+		"hard_deleted":   {PublicID: "conv_gone", UserID: userID, Status: "active"},
+	}
+	for _, conv := range conversations {
+		if err := db.Create(conv).Error; err != nil {
+			t.Fatalf("seed conversation: %v", err)
+		}
+	}
+	responses := make(map[string]*dbschema.Response)
+	for key, conv := range conversations {
+		response := &dbschema.Response{PublicID: "resp_" + key, UserID: userID, ConversationID: &conv.ID, Model: "test", Status: "completed", Input: "{}"}
+		if err := db.Create(response).Error; err != nil {
+			t.Fatalf("seed response: %v", err)
+		}
+		responses[key] = response
+	}
+	item := &dbschema.Item{PublicID: "msg_expired", ConversationID: conversations["expired_delete"].ID, ResponseID: &responses["expired_delete"].ID, Type: "message"}
+	if err := db.Create(item).Error; err != nil {
+		t.Fatalf("seed item: %v", err)
+	}
+
+	setDeletedAt := func(conv *dbschema.Conversation, deletedAt time.Time) {
+		if err := db.Unscoped().Model(conv).UpdateColumn("deleted_at", deletedAt).Error; err != nil {
+			t.Fatalf("delete conversation: %v", err)
+		}
+	}
+	setDeletedAt(conversations["recent_delete"], now.Add(-time.Hour))
+	setDeletedAt(conversations["expired_delete"], cutoff.Add(-time.Hour))
+	if err := db.Unscoped().Delete(conversations["hard_deleted"]).Error; err != nil {
+		t.Fatalf("hard delete conversation: %v", err)
+	}
+
+	remaining := func() map[string]bool {
+		var ids []string
+		if err := db.Unscoped().Model(&dbschema.Response{}).Pluck("public_id", &ids).Error; err != nil {
+			t.Fatalf("list responses: %v", err)
+		}
+		found := make(map[string]bool, len(ids))
+		for _, id := range ids {
+			found[id] = true
+		}
+		return found
+	}
+
+	// Without a cutoff only responses of conversations that no longer exist are orphans
+	purged, err := repo.PurgeOrphanResponses(ctx, organizationID, time.Time{}, 100)
+	if err != nil {
+		t.Fatalf("purge without cutoff: %v", err)
+	}
+	if purged != 1 {
+		t.Fatalf("expected 1 purged response, got %d", purged)
+	}
+	if found := remaining(); found["resp_hard_deleted"] || !found["resp_expired_delete"] || !found["resp_recent_delete"] {
+		t.Fatalf("unexpected remaining responses: %v", found)
+	}
+
+	purged, err = repo.PurgeOrphanResponses(ctx, organizationID, cutoff, 100)
+	if err != nil {
+		t.Fatalf("purge with cutoff: %v", err)
+	}
+	if purged != 1 {
+		t.Fatalf("expected 1 purged response, got %d", purged)
+	}
+	found := remaining()
+	if found["resp_expired_delete"] {
+		t.Fatal("expected the response of the conversation deleted before the cutoff to be purged")
+	}
+	if !found["resp_active"] || !found["resp_recent_delete"] {
+		t.Fatalf("expected the responses of active and recently deleted conversations to be kept: %v", found)
+	}
+
+	var kept dbschema.Item
+	if err := db.Unscoped().First(&kept, item.ID).Error; err != nil {
+		t.Fatalf("expected the item to outlive its response: %v", err)
+	}
+	if kept.ResponseID != nil {
+		t.Fatalf("expected the item to be detached from the purged response, got %d", *kept.ResponseID)
+	}
+}
+
+func TestRetentionOfTheTrash(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDatabase(t)
+	repo := NewRetentionRepository(transaction.NewDatabase(db))
+
+	const organizationID, userID = 1, 7
+	if err := db.Create(&dbschema.OrganizationMember{OrganizationID: organizationID, UserID: userID, Role: "owner"}).Error; err != nil {
+		t.Fatalf("seed member: %v", err)
+	}
+	now := time.Now()
+	cutoff := now.AddDate(0, 0, -30)
+	scope := retention.Scope{OrganizationID: organizationID}
+
+	live := &dbschema.Conversation{PublicID: "conv_live", UserID: userID, Status: "active"}
+	recentTrash := &dbschema.Conversation{PublicID: "conv_recent", UserID: userID, Status: "active"}
+	expiredTrash := &dbschema.Conversation{PublicID: "conv_expired", UserID: userID, Status: "archived"}
+	for _, conv := range []*dbschema.Conversation{live, recentTrash, expiredTrash} {
+		if err := db.Create(conv).Error; err != nil {
+			t.Fatalf("seed conversation: %v", err)
+		}
+	}
+	// inactive for long, but a trashed conversation is not archived
+	if err := db.Model(&dbschema.Conversation{}).Where("id IN ?", []uint{live.ID, recentTrash.ID}).UpdateColumn("updated_at", cutoff.Add(-time.Hour)).Error; err != nil {
+		t.Fatalf("age conversations: %v", err)
+	}
+	items := map[string]*dbschema.Item{
+		"msg_live":          {PublicID: "msg_live", ConversationID: live.ID, Type: "message"},
+		"msg_recent_trash":  {PublicID: "msg_recent_trash", ConversationID: live.ID, Type: "message"},
+		"msg_expired_trash": {PublicID: "msg_expired_trash", ConversationID: live.ID, Type: "message"},
+		"msg_expired_conv":  {PublicID: "msg_expired_conv", ConversationID: expiredTrash.ID, Type: "message"},
+	}
+	for _, item := range items {
+		if err := db.Create(item).Error; err != nil {
+			t.Fatalf("seed item: %v", err)
+		}
+	}
+	setDeletedAt := func(model any, deletedAt time.Time) {
+		if err := db.Unscoped().Model(model).UpdateColumn("deleted_at", deletedAt).Error; err != nil {
+			t.Fatalf("trash: %v", err)
+		}
+	}
+	setDeletedAt(recentTrash, now.Add(-time.Hour))
+	setDeletedAt(expiredTrash, cutoff.Add(-time.Hour))
+	setDeletedAt(items["msg_recent_trash"], now.Add(-time.Hour))
+	setDeletedAt(items["msg_expired_trash"], cutoff.Add(-time.Hour))
+
+	archived, err := repo.ArchiveInactiveConversations(ctx, scope, cutoff, 100)
+	if err != nil || archived != 1 {
+		t.Fatalf("expected only the live conversation to be archived, got %d, %v", archived, err)
+	}
+	var restoredStatus string
+	if err := db.Unscoped().Model(&dbschema.Conversation{}).Where("id = ?", recentTrash.ID).Pluck("status", &restoredStatus).Error; err != nil || restoredStatus != "active" {
+		t.Fatalf("expected the trashed conversation to keep its status, got %q, %v", restoredStatus, err)
+	}
+
+	purgedItems, err := repo.PurgeDeletedItems(ctx, scope, cutoff, 100)
+	if err != nil || purgedItems != 1 {
+		t.Fatalf("expected the item trashed before the cutoff to be purged, got %d, %v", purgedItems, err)
+	}
+	purgedConversations, err := repo.PurgeDeletedConversations(ctx, scope, cutoff, 100)
+	if err != nil || purgedConversations != 1 {
+		t.Fatalf("expected the conversation trashed before the cutoff to be purged, got %d, %v", purgedConversations, err)
+	}
+
+	var conversations, remaining []string
+	db.Unscoped().Model(&dbschema.Conversation{}).Order("public_id").Pluck("public_id", &conversations)
+	db.Unscoped().Model(&dbschema.Item{}).Order("public_id").Pluck("public_id", &remaining)
+	if strings.Join(conversations, ",") != "conv_live,conv_recent" || strings.Join(remaining, ",") != "msg_live,msg_recent_trash" {
+		t.Fatalf("unexpected remaining conversations %v and items %v", conversations, remaining)
+	}
+}
@@ -0,0 +1,158 @@
+package secretrepo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"menlo.ai/indigo-api-gateway/app/domain/secrets"
+	"menlo.ai/indigo-api-gateway/app/domain/settings"
+	"menlo.ai/indigo-api-gateway/app/domain/webhook"
+	"menlo.ai/indigo-api-gateway/app/infrastructure/database/dbschema"
+	"menlo.ai/indigo-api-gateway/app/infrastructure/database/repository/transaction"
+)
+
+type secretColumn struct {
+	model  any
+	column string
+	// plaintext reports the values stored in clear by earlier versions
+	plaintext func(value string) bool
+}
+
+// secretColumns are the columns of the kinds stored in a column of their own
+var secretColumns = map[secrets.SecretKind]secretColumn{
+	secrets.SecretKindProviderAPIKey:   {model: &dbschema.Provider{}, column: "encrypted_api_key"},
+	secrets.SecretKindMCPServerHeaders: {model: &dbschema.MCPServer{}, column: "encrypted_headers"},
+	secrets.SecretKindWebhookSecret:    {model: &dbschema.WebhookEndpoint{}, column: "secret", plaintext: webhook.IsPlaintextSecret},
+}
+
+// settingSecretFields are the payload fields of the settings holding secrets
+var settingSecretFields = []string{
+	settings.PayloadWebSearchAPIKey,
+	settings.PayloadSMTPPassword,
+	settings.PayloadSMTPLegacyPassword,
+}
+
+type EncryptedSecretRepository struct {
+	db *transaction.Database
+}
+
+var _ secrets.EncryptedSecretRepository = (*EncryptedSecretRepository)(nil)
+
+func NewEncryptedSecretRepository(db *transaction.Database) secrets.EncryptedSecretRepository {
+	return &EncryptedSecretRepository{db: db}
+}
+
+func (r *EncryptedSecretRepository) FindSecrets(ctx context.Context, kind secrets.SecretKind, afterID uint, limit int) ([]*secrets.EncryptedSecret, uint, error) {
+	db := r.db.GetTx(ctx).WithContext(ctx).Unscoped()
+	if kind == secrets.SecretKindSetting {
+		return findSettingSecrets(db, afterID, limit)
+	}
+	column, ok := secretColumns[kind]
+	if !ok {
+		return nil, 0, fmt.Errorf("unknown secret kind %q", kind)
+	}
+	var rows []struct {
+		ID    uint
+		Value string
+	}
+	err := db.Model(column.model).
+		Select("id", column.column+" AS value").
+		Where("id > ? AND "+column.column+" <> ''", afterID).
+		Order("id").
+		Limit(limit).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	result := make([]*secrets.EncryptedSecret, 0, len(rows))
+	lastID := uint(0)
+	for _, row := range rows {
+		lastID = row.ID
+		result = append(result, &secrets.EncryptedSecret{
+			Kind:      kind,
+			ID:        row.ID,
+			Field:     column.column,
+			Value:     row.Value,
+			Plaintext: column.plaintext != nil && column.plaintext(row.Value),
+		})
+	}
+	return result, lastID, nil
+}
+
+func findSettingSecrets(db *gorm.DB, afterID uint, limit int) ([]*secrets.EncryptedSecret, uint, error) {
+	var rows []dbschema.SystemSetting
+	if err := db.Where("id > ?", afterID).Order("id").Limit(limit).Find(&rows).Error; err != nil {
+		return nil, 0, err
+	}
+	var result []*secrets.EncryptedSecret
+	lastID := uint(0)
+	for _, row := range rows {
+		lastID = row.ID
+		payload := map[string]any{}
+		if err := json.Unmarshal(row.Payload, &payload); err != nil {
+			return nil, 0, fmt.Errorf("setting %d: %w", row.ID, err)
+		}
+		for _, field := range settingSecretFields {
+			if value, ok := payload[field].(string); ok && value != "" {
+				result = append(result, &secrets.EncryptedSecret{
+					Kind:      secrets.SecretKindSetting,
+					ID:        row.ID,
+					Field:     field,
+					Value:     value,
+					Plaintext: field == settings.PayloadSMTPLegacyPassword,
+				})
+			}
+		}
+	}
+	return result, lastID, nil
+}
+
+// ReplaceSecret compares and swaps the value; the clear SMTP password is moved to its encrypted field
+func (r *EncryptedSecretRepository) ReplaceSecret(ctx context.Context, secret *secrets.EncryptedSecret, ciphertext string) (bool, error) {
+	db := r.db.GetTx(ctx).WithContext(ctx).Unscoped()
+	if secret.Kind != secrets.SecretKindSetting {
+		column, ok := secretColumns[secret.Kind]
+		if !ok {
+			return false, fmt.Errorf("unknown secret kind %q", secret.Kind)
+		}
+		// UpdateColumn keeps updated_at, a rotation is not a change of the row
+		result := db.Model(column.model).
+			Where("id = ? AND "+column.column+" = ?", secret.ID, secret.Value).
+			UpdateColumn(column.column, ciphertext)
+		return result.RowsAffected == 1, result.Error
+	}
+
+	replaced := false
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var row dbschema.SystemSetting
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", secret.ID).Take(&row).Error; err != nil {
+			return err
+		}
+		payload := map[string]any{}
+		if err := json.Unmarshal(row.Payload, &payload); err != nil {
+			return err
+		}
+		if value, ok := payload[secret.Field].(string); !ok || value != secret.Value {
+			return nil
+		}
+		if secret.Plaintext {
+			delete(payload, secret.Field)
+			payload[settings.PayloadSMTPPassword] = ciphertext
+		} else {
+			payload[secret.Field] = ciphertext
+		}
+		encoded, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(&row).UpdateColumn("payload", encoded).Error; err != nil {
+			return err
+		}
+		replaced = true
+		return nil
+	})
+	return replaced, err
+}
@@ -0,0 +1,139 @@
+package secretrepo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+	"menlo.ai/indigo-api-gateway/app/domain/secrets"
+	"menlo.ai/indigo-api-gateway/app/domain/settings"
+	"menlo.ai/indigo-api-gateway/app/infrastructure/database/dbschema"
+	"menlo.ai/indigo-api-gateway/app/infrastructure/database/repository/transaction"
+)
+
+func newTestDatabase(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{
+		NamingStrategy:                           schema.NamingStrategy{SingularTable: true},
+		DisableForeignKeyConstraintWhenMigrating: true,
+	})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	if err := db.AutoMigrate(&dbschema.Provider{}, &dbschema.SystemSetting{}, &dbschema.WebhookEndpoint{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return db
+}
+
+func TestReplaceSecretComparesAndSwaps(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDatabase(t)
+	repo := NewEncryptedSecretRepository(transaction.NewDatabase(db))
+	provider := &dbschema.Provider{PublicID: "prov_1", Slug: "openai", DisplayName: "OpenAI", Kind: "openai", EncryptedAPIKey: "old-ciphertext"}
+	if err := db.Create(provider).Error; err != nil {
+		t.Fatalf("create provider: %v", err)
+	}
+
+	found, lastID, err := repo.FindSecrets(ctx, secrets.SecretKindProviderAPIKey, 0, 10)
+	if err != nil {
+		t.Fatalf("find secrets: %v", err)
+	}
+	if len(found) != 1 || lastID != provider.ID || found[0].Value != "old-ciphertext" || found[0].Plaintext {
+		t.Fatalf("unexpected secrets %+v, last id %d", found, lastID)
+	}
+
+	// the key was updated after it was read, the rotation must not overwrite it
+	if err := db.Model(provider).UpdateColumn("encrypted_api_key", "edited-ciphertext").Error; err != nil {
+		t.Fatalf("edit provider: %v", err)
+	}
+	replaced, err := repo.ReplaceSecret(ctx, found[0], "rotated-ciphertext")
+	if err != nil || replaced {
+		t.Fatalf("expected the changed secret to be kept, got %v, %v", replaced, err)
+	}
+	found[0].Value = "edited-ciphertext"
+	replaced, err = repo.ReplaceSecret(ctx, found[0], "rotated-ciphertext")
+	if err != nil || !replaced {
+		t.Fatalf("expected the secret to be replaced, got %v, %v", replaced, err)
+	}
+	var row dbschema.Provider
+	if err := db.First(&row, provider.ID).Error; err != nil {
+		t.Fatalf("find provider: %v", err)
+	}
+	if row.EncryptedAPIKey != "rotated-ciphertext" {
+		t.Fatalf("expected the rotated ciphertext, got %q", row.EncryptedAPIKey)
+	}
+}
+
+func TestReplaceSecretMovesLegacySMTPPassword(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDatabase(t)
+	repo := NewEncryptedSecretRepository(transaction.NewDatabase(db))
+	payload, _ := json.Marshal(map[string]any{"host": "smtp.example.com", settings.PayloadSMTPLegacyPassword: "clear-password"})
+	setting := &dbschema.SystemSetting{OrganizationID: 1, Key: "smtp", Payload: payload}
+	if err := db.Create(setting).Error; err != nil {
+		t.Fatalf("create setting: %v", err)
+	}
+
+	found, _, err := repo.FindSecrets(ctx, secrets.SecretKindSetting, 0, 10)
+	if err != nil {
+		t.Fatalf("find secrets: %v", err)
+	}
+	if len(found) != 1 || found[0].Field != settings.PayloadSMTPLegacyPassword || !found[0].Plaintext {
+		t.Fatalf("expected the clear password, got %+v", found)
+	}
+	stale := *found[0]
+	stale.Value = "previous-password"
+	if replaced, err := repo.ReplaceSecret(ctx, &stale, "encrypted-password"); err != nil || replaced {
+		t.Fatalf("expected the stale password to be kept, got %v, %v", replaced, err)
+	}
+	if replaced, err := repo.ReplaceSecret(ctx, found[0], "encrypted-password"); err != nil || !replaced {
+		t.Fatalf("expected the password to be moved, got %v, %v", replaced, err)
+	}
+
+	var row dbschema.SystemSetting
+	if err := db.First(&row, setting.ID).Error; err != nil {
+		t.Fatalf("find setting: %v", err)
+	}
+	stored := map[string]any{}
+	if err := json.Unmarshal(row.Payload, &stored); err != nil {
+		t.Fatalf("decode payload: %v", err)
+	}
+	if _, ok := stored[settings.PayloadSMTPLegacyPassword]; ok {
+		t.Fatalf("expected the clear password to be removed, got %v", stored)
+	}
+	if stored[settings.PayloadSMTPPassword] != "encrypted-password" || stored["host"] != "smtp.example.com" {
+		t.Fatalf("unexpected payload %v", stored)
+	}
+}
+
+func TestFindSecretsMarksClearWebhookSecrets(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDatabase(t)
+	repo := NewEncryptedSecretRepository(transaction.NewDatabase(db))
+	for i, secret := range []string{"whsec_clear", "enc:v1:key:wrapped:ciphertext"} {
+		endpoint := &dbschema.WebhookEndpoint{PublicID: fmt.Sprintf("wh_%d", i), ProjectID: 1, URL: "https://example.com/hooks", EncryptedSecret: secret, EventTypes: []byte("[]")}
+		if err := db.Create(endpoint).Error; err != nil {
+			t.Fatalf("create endpoint: %v", err)
+		}
+	}
+
+	found, _, err := repo.FindSecrets(ctx, secrets.SecretKindWebhookSecret, 0, 10)
+	if err != nil {
+		t.Fatalf("find secrets: %v", err)
+	}
+	if len(found) != 2 || !found[0].Plaintext || found[1].Plaintext || found[0].Field != "secret" {
+		t.Fatalf("expected only the clear secret to be marked, got %+v", found)
+	}
+	if replaced, err := repo.ReplaceSecret(ctx, found[0], "enc:v1:key:wrapped:new"); err != nil || !replaced {
+		t.Fatalf("expected the clear secret to be encrypted, got %v, %v", replaced, err)
+	}
+	var row dbschema.WebhookEndpoint
+	if err := db.First(&row, found[0].ID).Error; err != nil || row.EncryptedSecret != "enc:v1:key:wrapped:new" {
+		t.Fatalf("expected the encrypted secret to be stored, got %q, %v", row.EncryptedSecret, err)
+	}
+}
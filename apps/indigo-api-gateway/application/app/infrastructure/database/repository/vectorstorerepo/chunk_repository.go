@@ -0,0 +1,91 @@
+package vectorstorerepo
+
+import (
+	"context"
+
+	"menlo.ai/indigo-api-gateway/app/domain/vectorstore"
+	"menlo.ai/indigo-api-gateway/app/infrastructure/database/dbschema"
+	"menlo.ai/indigo-api-gateway/app/infrastructure/database/repository/transaction"
+	"menlo.ai/indigo-api-gateway/app/utils/functional"
+)
+
+const chunkInsertBatchSize = 100
+
+type ChunkRepository struct {
+	db *transaction.Database
+}
+
+var _ vectorstore.ChunkRepository = (*ChunkRepository)(nil)
+
+func NewChunkRepository(db *transaction.Database) vectorstore.ChunkRepository {
+	return &ChunkRepository{db: db}
+}
+
+func (r *ChunkRepository) CreateBatch(ctx context.Context, chunks []*vectorstore.Chunk) error {
+	if len(chunks) == 0 {
+		return nil
+	}
+	models := functional.Map(chunks, dbschema.NewSchemaVectorStoreChunk)
+	if err := r.db.GetTx(ctx).WithContext(ctx).CreateInBatches(models, chunkInsertBatchSize).Error; err != nil {
+		return err
+	}
+	for i, model := range models {
+		chunks[i].ID = model.ID
+	}
+	return nil
+}
+
+func (r *ChunkRepository) DeleteByVectorStoreFileID(ctx context.Context, vectorStoreFileID uint) error {
+	return r.db.GetTx(ctx).WithContext(ctx).
+		Where("vector_store_file_id = ?", vectorStoreFileID).
+		Delete(&dbschema.VectorStoreChunk{}).Error
+}
+
+func (r *ChunkRepository) DeleteByVectorStoreID(ctx context.Context, vectorStoreID uint) error {
+	return r.db.GetTx(ctx).WithContext(ctx).
+		Where("vector_store_id = ?", vectorStoreID).
+		Delete(&dbschema.VectorStoreChunk{}).Error
+}
+
+// Search ranks chunks by cosine similarity using the pgvector <=> distance operator
+func (r *ChunkRepository) Search(ctx context.Context, search vectorstore.ChunkSearch) ([]*vectorstore.SearchResult, error) {
+	if len(search.VectorStoreIDs) == 0 || len(search.Embedding) == 0 {
+		return nil, nil
+	}
+	embedding, err := dbschema.Vector(search.Embedding).Value()
+	if err != nil {
+		return nil, err
+	}
+
+	sql := r.db.GetTx(ctx).WithContext(ctx).
+		Model(&dbschema.VectorStoreChunk{}).
+		Select("file_public_id, filename, chunk_index, text, 1 - (embedding <=> ?::vector) AS score", embedding).
+		Where("vector_store_id IN ?", search.VectorStoreIDs)
+	if len(search.FilePublicIDs) > 0 {
+		sql = sql.Where("file_public_id IN ?", search.FilePublicIDs)
+	}
+
+	var rows []struct {
+		FilePublicID string
+		Filename     string
+		ChunkIndex   int
+		Text         string
+		Score        float64
+	}
+	err = sql.Order("score DESC").Limit(search.Limit).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*vectorstore.SearchResult, 0, len(rows))
+	for _, row := range rows {
+		results = append(results, &vectorstore.SearchResult{
+			FilePublicID: row.FilePublicID,
+			Filename:     row.Filename,
+			ChunkIndex:   row.ChunkIndex,
+			Text:         row.Text,
+			Score:        row.Score,
+		})
+	}
+	return results, nil
+}
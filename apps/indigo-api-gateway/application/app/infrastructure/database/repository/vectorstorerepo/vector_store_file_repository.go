@@ -0,0 +1,166 @@
+package vectorstorerepo
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"menlo.ai/indigo-api-gateway/app/domain/query"
+	"menlo.ai/indigo-api-gateway/app/domain/vectorstore"
+	"menlo.ai/indigo-api-gateway/app/infrastructure/database/dbschema"
+	repoquery "menlo.ai/indigo-api-gateway/app/infrastructure/database/repository/query"
+	"menlo.ai/indigo-api-gateway/app/infrastructure/database/repository/transaction"
+	"menlo.ai/indigo-api-gateway/app/utils/functional"
+)
+
+type VectorStoreFileRepository struct {
+	db *transaction.Database
+}
+
+var _ vectorstore.VectorStoreFileRepository = (*VectorStoreFileRepository)(nil)
+
+func NewVectorStoreFileRepository(db *transaction.Database) vectorstore.VectorStoreFileRepository {
+	return &VectorStoreFileRepository{db: db}
+}
+
+func (r *VectorStoreFileRepository) Create(ctx context.Context, storeFile *vectorstore.VectorStoreFile) error {
+	model := dbschema.NewSchemaVectorStoreFile(storeFile)
+	if err := r.db.GetTx(ctx).WithContext(ctx).Create(model).Error; err != nil {
+		return err
+	}
+	storeFile.ID = model.ID
+	storeFile.CreatedAt = model.CreatedAt
+	storeFile.UpdatedAt = model.UpdatedAt
+	return nil
+}
+
+func (r *VectorStoreFileRepository) Update(ctx context.Context, storeFile *vectorstore.VectorStoreFile) error {
+	model := dbschema.NewSchemaVectorStoreFile(storeFile)
+	if err := r.db.GetTx(ctx).WithContext(ctx).Save(model).Error; err != nil {
+		return err
+	}
+	storeFile.UpdatedAt = model.UpdatedAt
+	return nil
+}
+
+func (r *VectorStoreFileRepository) FindByFilter(ctx context.Context, filter vectorstore.VectorStoreFileFilter, pagination *query.Pagination) ([]*vectorstore.VectorStoreFile, error) {
+	sql := applyFileFilter(r.db.GetTx(ctx).WithContext(ctx).Model(&dbschema.VectorStoreFile{}), filter)
+	sql, order := repoquery.ApplyPagination(sql, pagination)
+
+	var rows []*dbschema.VectorStoreFile
+	if err := sql.Order(order).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	return functional.Map(rows, func(item *dbschema.VectorStoreFile) *vectorstore.VectorStoreFile {
+		return item.EtoD()
+	}), nil
+}
+
+func (r *VectorStoreFileRepository) Count(ctx context.Context, filter vectorstore.VectorStoreFileFilter) (int64, error) {
+	var count int64
+	err := applyFileFilter(r.db.GetTx(ctx).WithContext(ctx).Model(&dbschema.VectorStoreFile{}), filter).Count(&count).Error
+	return count, err
+}
+
+func (r *VectorStoreFileRepository) CountByStatus(ctx context.Context, vectorStoreID uint) (map[vectorstore.VectorStoreFileStatus]int64, error) {
+	var rows []struct {
+		Status string
+		Count  int64
+	}
+	err := r.db.GetTx(ctx).WithContext(ctx).
+		Model(&dbschema.VectorStoreFile{}).
+		Select("status, COUNT(*) AS count").
+		Where("vector_store_id = ?", vectorStoreID).
+		Group("status").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[vectorstore.VectorStoreFileStatus]int64, len(rows))
+	for _, row := range rows {
+		counts[vectorstore.VectorStoreFileStatus(row.Status)] = row.Count
+	}
+	return counts, nil
+}
+
+func (r *VectorStoreFileRepository) ClaimPending(ctx context.Context, limit int, lease time.Duration) ([]*vectorstore.VectorStoreFile, error) {
+	var claimed []*dbschema.VectorStoreFile
+	err := r.db.GetTx(ctx).WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ?", string(vectorstore.VectorStoreFileStatusInProgress)).
+			Where("locked_until IS NULL OR locked_until < ?", now).
+			Order("id").
+			Limit(limit).
+			Find(&claimed).Error
+		if err != nil || len(claimed) == 0 {
+			return err
+		}
+		lockedUntil := now.Add(lease)
+		ids := functional.Map(claimed, func(item *dbschema.VectorStoreFile) uint {
+			return item.ID
+		})
+		if err := tx.Model(&dbschema.VectorStoreFile{}).Where("id IN ?", ids).Update("locked_until", lockedUntil).Error; err != nil {
+			return err
+		}
+		for _, storeFile := range claimed {
+			storeFile.LockedUntil = &lockedUntil
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return functional.Map(claimed, func(item *dbschema.VectorStoreFile) *vectorstore.VectorStoreFile {
+		return item.EtoD()
+	}), nil
+}
+
+func (r *VectorStoreFileRepository) FinishIndexing(ctx context.Context, storeFile *vectorstore.VectorStoreFile) (bool, error) {
+	result := r.db.GetTx(ctx).WithContext(ctx).
+		Model(&dbschema.VectorStoreFile{}).
+		Where("id = ?", storeFile.ID).
+		Updates(map[string]any{
+			"status":       string(storeFile.Status),
+			"last_error":   storeFile.LastError,
+			"usage_bytes":  storeFile.UsageBytes,
+			"chunk_count":  storeFile.ChunkCount,
+			"locked_until": nil,
+			"updated_at":   time.Now(),
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	storeFile.LockedUntil = nil
+	return result.RowsAffected > 0, nil
+}
+
+func (r *VectorStoreFileRepository) DeleteByID(ctx context.Context, id uint) error {
+	return r.db.GetTx(ctx).WithContext(ctx).Delete(&dbschema.VectorStoreFile{}, id).Error
+}
+
+func (r *VectorStoreFileRepository) DeleteByVectorStoreID(ctx context.Context, vectorStoreID uint) error {
+	return r.db.GetTx(ctx).WithContext(ctx).
+		Where("vector_store_id = ?", vectorStoreID).
+		Delete(&dbschema.VectorStoreFile{}).Error
+}
+
+func applyFileFilter(sql *gorm.DB, filter vectorstore.VectorStoreFileFilter) *gorm.DB {
+	if filter.ID != nil {
+		sql = sql.Where("id = ?", *filter.ID)
+	}
+	if filter.VectorStoreID != nil {
+		sql = sql.Where("vector_store_id = ?", *filter.VectorStoreID)
+	}
+	if filter.FileID != nil {
+		sql = sql.Where("file_id = ?", *filter.FileID)
+	}
+	if filter.FilePublicID != nil {
+		sql = sql.Where("file_public_id = ?", *filter.FilePublicID)
+	}
+	if filter.Status != nil {
+		sql = sql.Where("status = ?", string(*filter.Status))
+	}
+	return sql
+}
@@ -0,0 +1,127 @@
+package vectorstorerepo
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+	"menlo.ai/indigo-api-gateway/app/domain/vectorstore"
+	"menlo.ai/indigo-api-gateway/app/infrastructure/database/dbschema"
+	"menlo.ai/indigo-api-gateway/app/infrastructure/database/repository/transaction"
+)
+
+func newTestRepository(t *testing.T) vectorstore.VectorStoreFileRepository {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{
+		NamingStrategy:                           schema.NamingStrategy{SingularTable: true},
+		DisableForeignKeyConstraintWhenMigrating: true,
+	})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	if err := db.AutoMigrate(&dbschema.VectorStoreFile{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return NewVectorStoreFileRepository(transaction.NewDatabase(db))
+}
+
+func attach(t *testing.T, repo vectorstore.VectorStoreFileRepository, fileID uint, status vectorstore.VectorStoreFileStatus) *vectorstore.VectorStoreFile {
+	t.Helper()
+	storeFile := &vectorstore.VectorStoreFile{
+		VectorStoreID: 1,
+		FileID:        fileID,
+		FilePublicID:  fmt.Sprintf("file_%d", fileID),
+		Filename:      "notes.txt",
+		Status:        status,
+		Chunking:      vectorstore.DefaultChunkingStrategy(),
+	}
+	if err := repo.Create(context.Background(), storeFile); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	return storeFile
+}
+
+func TestVectorStoreFileClaimPendingLeasesQueuedFiles(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepository(t)
+	first := attach(t, repo, 1, vectorstore.VectorStoreFileStatusInProgress)
+	attach(t, repo, 2, vectorstore.VectorStoreFileStatusCompleted)
+	third := attach(t, repo, 3, vectorstore.VectorStoreFileStatusInProgress)
+
+	claimed, err := repo.ClaimPending(ctx, 10, time.Minute)
+	if err != nil {
+		t.Fatalf("claim: %v", err)
+	}
+	if len(claimed) != 2 || claimed[0].ID != first.ID || claimed[1].ID != third.ID {
+		t.Fatalf("expected the two in_progress files, got %+v", claimed)
+	}
+	if claimed[0].LockedUntil == nil || !claimed[0].LockedUntil.After(time.Now()) {
+		t.Fatalf("expected a lease, got %v", claimed[0].LockedUntil)
+	}
+
+	again, err := repo.ClaimPending(ctx, 10, time.Minute)
+	if err != nil {
+		t.Fatalf("claim again: %v", err)
+	}
+	if len(again) != 0 {
+		t.Fatalf("expected leased files to be skipped, got %+v", again)
+	}
+}
+
+func TestVectorStoreFileClaimPendingTakesOverExpiredLeases(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepository(t)
+	storeFile := attach(t, repo, 1, vectorstore.VectorStoreFileStatusInProgress)
+
+	if _, err := repo.ClaimPending(ctx, 10, -time.Second); err != nil {
+		t.Fatalf("claim: %v", err)
+	}
+	claimed, err := repo.ClaimPending(ctx, 10, time.Minute)
+	if err != nil {
+		t.Fatalf("claim again: %v", err)
+	}
+	if len(claimed) != 1 || claimed[0].ID != storeFile.ID {
+		t.Fatalf("expected the expired lease to be taken over, got %+v", claimed)
+	}
+}
+
+func TestVectorStoreFileFinishIndexing(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepository(t)
+	storeFile := attach(t, repo, 1, vectorstore.VectorStoreFileStatusInProgress)
+	claimed, err := repo.ClaimPending(ctx, 1, time.Minute)
+	if err != nil || len(claimed) != 1 {
+		t.Fatalf("claim: %+v, %v", claimed, err)
+	}
+
+	claimed[0].Status = vectorstore.VectorStoreFileStatusCompleted
+	claimed[0].ChunkCount = 3
+	claimed[0].UsageBytes = 120
+	attached, err := repo.FinishIndexing(ctx, claimed[0])
+	if err != nil || !attached {
+		t.Fatalf("finish: %v, %v", attached, err)
+	}
+	stored, err := repo.FindByFilter(ctx, vectorstore.VectorStoreFileFilter{ID: &storeFile.ID}, nil)
+	if err != nil || len(stored) != 1 {
+		t.Fatalf("find: %+v, %v", stored, err)
+	}
+	if stored[0].Status != vectorstore.VectorStoreFileStatusCompleted || stored[0].ChunkCount != 3 || stored[0].UsageBytes != 120 || stored[0].LockedUntil != nil {
+		t.Fatalf("unexpected stored file %+v", stored[0])
+	}
+
+	// a file detached while it was indexed is not written back
+	if err := repo.DeleteByID(ctx, storeFile.ID); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	attached, err = repo.FinishIndexing(ctx, claimed[0])
+	if err != nil || attached {
+		t.Fatalf("expected a detached file to be reported, got %v, %v", attached, err)
+	}
+	if count, _ := repo.Count(ctx, vectorstore.VectorStoreFileFilter{}); count != 0 {
+		t.Fatalf("expected the detached file to stay deleted, got %d rows", count)
+	}
+}
@@ -0,0 +1,85 @@
+package vectorstorerepo
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+	"menlo.ai/indigo-api-gateway/app/domain/query"
+	"menlo.ai/indigo-api-gateway/app/domain/vectorstore"
+	"menlo.ai/indigo-api-gateway/app/infrastructure/database/dbschema"
+	repoquery "menlo.ai/indigo-api-gateway/app/infrastructure/database/repository/query"
+	"menlo.ai/indigo-api-gateway/app/infrastructure/database/repository/transaction"
+	"menlo.ai/indigo-api-gateway/app/utils/functional"
+)
+
+type VectorStoreRepository struct {
+	db *transaction.Database
+}
+
+var _ vectorstore.VectorStoreRepository = (*VectorStoreRepository)(nil)
+
+func NewVectorStoreRepository(db *transaction.Database) vectorstore.VectorStoreRepository {
+	return &VectorStoreRepository{db: db}
+}
+
+func (r *VectorStoreRepository) Create(ctx context.Context, store *vectorstore.VectorStore) error {
+	model := dbschema.NewSchemaVectorStore(store)
+	if err := r.db.GetTx(ctx).WithContext(ctx).Create(model).Error; err != nil {
+		return err
+	}
+	store.ID = model.ID
+	store.CreatedAt = model.CreatedAt
+	store.UpdatedAt = model.UpdatedAt
+	return nil
+}
+
+func (r *VectorStoreRepository) Update(ctx context.Context, store *vectorstore.VectorStore) error {
+	model := dbschema.NewSchemaVectorStore(store)
+	if err := r.db.GetTx(ctx).WithContext(ctx).Save(model).Error; err != nil {
+		return err
+	}
+	store.UpdatedAt = model.UpdatedAt
+	return nil
+}
+
+func (r *VectorStoreRepository) FindByFilter(ctx context.Context, filter vectorstore.VectorStoreFilter, pagination *query.Pagination) ([]*vectorstore.VectorStore, error) {
+	sql := applyStoreFilter(r.db.GetTx(ctx).WithContext(ctx).Model(&dbschema.VectorStore{}), filter)
+	sql, order := repoquery.ApplyPagination(sql, pagination)
+
+	var rows []*dbschema.VectorStore
+	if err := sql.Order(order).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	return functional.Map(rows, func(item *dbschema.VectorStore) *vectorstore.VectorStore {
+		return item.EtoD()
+	}), nil
+}
+
+func (r *VectorStoreRepository) Count(ctx context.Context, filter vectorstore.VectorStoreFilter) (int64, error) {
+	var count int64
+	err := applyStoreFilter(r.db.GetTx(ctx).WithContext(ctx).Model(&dbschema.VectorStore{}), filter).Count(&count).Error
+	return count, err
+}
+
+func (r *VectorStoreRepository) DeleteByID(ctx context.Context, id uint) error {
+	return r.db.GetTx(ctx).WithContext(ctx).Delete(&dbschema.VectorStore{}, id).Error
+}
+
+func applyStoreFilter(sql *gorm.DB, filter vectorstore.VectorStoreFilter) *gorm.DB {
+	if filter.ID != nil {
+		sql = sql.Where("id = ?", *filter.ID)
+	}
+	if filter.PublicID != nil {
+		sql = sql.Where("public_id = ?", *filter.PublicID)
+	}
+	if filter.PublicIDs != nil {
+		sql = sql.Where("public_id IN ?", *filter.PublicIDs)
+	}
+	if filter.UserID != nil {
+		sql = sql.Where("user_id = ?", *filter.UserID)
+	}
+	if filter.ProjectID != nil {
+		sql = sql.Where("project_id = ?", *filter.ProjectID)
+	}
+	return sql
+}
@@ -0,0 +1,144 @@
+package webhookrepo
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"menlo.ai/indigo-api-gateway/app/domain/query"
+	"menlo.ai/indigo-api-gateway/app/domain/webhook"
+	"menlo.ai/indigo-api-gateway/app/infrastructure/database/dbschema"
+	repoquery "menlo.ai/indigo-api-gateway/app/infrastructure/database/repository/query"
+	"menlo.ai/indigo-api-gateway/app/infrastructure/database/repository/transaction"
+	"menlo.ai/indigo-api-gateway/app/utils/functional"
+)
+
+type WebhookDeliveryRepository struct {
+	db *transaction.Database
+}
+
+var _ webhook.DeliveryRepository = (*WebhookDeliveryRepository)(nil)
+
+func NewWebhookDeliveryRepository(db *transaction.Database) webhook.DeliveryRepository {
+	return &WebhookDeliveryRepository{db: db}
+}
+
+func (r *WebhookDeliveryRepository) Create(ctx context.Context, delivery *webhook.Delivery) error {
+	model := dbschema.NewSchemaWebhookDelivery(delivery)
+	if err := r.db.GetTx(ctx).WithContext(ctx).Create(model).Error; err != nil {
+		return err
+	}
+	delivery.ID = model.ID
+	delivery.CreatedAt = model.CreatedAt
+	delivery.UpdatedAt = model.UpdatedAt
+	return nil
+}
+
+func (r *WebhookDeliveryRepository) Update(ctx context.Context, delivery *webhook.Delivery) error {
+	model := dbschema.NewSchemaWebhookDelivery(delivery)
+	if err := r.db.GetTx(ctx).WithContext(ctx).Save(model).Error; err != nil {
+		return err
+	}
+	delivery.UpdatedAt = model.UpdatedAt
+	return nil
+}
+
+func (r *WebhookDeliveryRepository) FindByFilter(ctx context.Context, filter webhook.DeliveryFilter, pagination *query.Pagination) ([]*webhook.Delivery, error) {
+	sql := applyDeliveryFilter(r.db.GetTx(ctx).WithContext(ctx).Model(&dbschema.WebhookDelivery{}), filter)
+	sql, order := repoquery.ApplyPagination(sql, pagination)
+
+	var rows []*dbschema.WebhookDelivery
+	if err := sql.Order(order).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	return functional.Map(rows, func(item *dbschema.WebhookDelivery) *webhook.Delivery {
+		return item.EtoD()
+	}), nil
+}
+
+func (r *WebhookDeliveryRepository) Count(ctx context.Context, filter webhook.DeliveryFilter) (int64, error) {
+	var count int64
+	err := applyDeliveryFilter(r.db.GetTx(ctx).WithContext(ctx).Model(&dbschema.WebhookDelivery{}), filter).Count(&count).Error
+	return count, err
+}
+
+// ClaimDue locks due deliveries with SKIP LOCKED and leases them so concurrent dispatchers across replicas never send the same delivery
+func (r *WebhookDeliveryRepository) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]*webhook.Delivery, error) {
+	var claimed []*dbschema.WebhookDelivery
+	err := r.db.GetTx(ctx).WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", webhook.DeliveryStatusPending, now).
+			Where("locked_until IS NULL OR locked_until < ?", now).
+			Order("next_attempt_at").
+			Limit(limit).
+			Find(&claimed).Error
+		if err != nil || len(claimed) == 0 {
+			return err
+		}
+		lockedUntil := now.Add(lease)
+		ids := functional.Map(claimed, func(item *dbschema.WebhookDelivery) uint {
+			return item.ID
+		})
+		if err := tx.Model(&dbschema.WebhookDelivery{}).Where("id IN ?", ids).Update("locked_until", lockedUntil).Error; err != nil {
+			return err
+		}
+		for _, delivery := range claimed {
+			delivery.LockedUntil = &lockedUntil
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return functional.Map(claimed, func(item *dbschema.WebhookDelivery) *webhook.Delivery {
+		return item.EtoD()
+	}), nil
+}
+
+func (r *WebhookDeliveryRepository) CreateAttempt(ctx context.Context, attempt *webhook.DeliveryAttempt) error {
+	model := dbschema.NewSchemaWebhookDeliveryAttempt(attempt)
+	if err := r.db.GetTx(ctx).WithContext(ctx).Create(model).Error; err != nil {
+		return err
+	}
+	attempt.ID = model.ID
+	attempt.CreatedAt = model.CreatedAt
+	return nil
+}
+
+func (r *WebhookDeliveryRepository) FindAttempts(ctx context.Context, deliveryID uint) ([]*webhook.DeliveryAttempt, error) {
+	var rows []*dbschema.WebhookDeliveryAttempt
+	if err := r.db.GetTx(ctx).WithContext(ctx).Where("delivery_id = ?", deliveryID).Order("id").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	return functional.Map(rows, func(item *dbschema.WebhookDeliveryAttempt) *webhook.DeliveryAttempt {
+		return item.EtoD()
+	}), nil
+}
+
+func (r *WebhookDeliveryRepository) DeleteByEndpointID(ctx context.Context, endpointID uint) error {
+	db := r.db.GetTx(ctx).WithContext(ctx)
+	deliveryIDs := db.Model(&dbschema.WebhookDelivery{}).Select("id").Where("endpoint_id = ?", endpointID)
+	if err := db.Where("delivery_id IN (?)", deliveryIDs).Delete(&dbschema.WebhookDeliveryAttempt{}).Error; err != nil {
+		return err
+	}
+	return db.Where("endpoint_id = ?", endpointID).Delete(&dbschema.WebhookDelivery{}).Error
+}
+
+func applyDeliveryFilter(sql *gorm.DB, filter webhook.DeliveryFilter) *gorm.DB {
+	if filter.PublicID != nil {
+		sql = sql.Where("public_id = ?", *filter.PublicID)
+	}
+	if filter.EndpointID != nil {
+		sql = sql.Where("endpoint_id = ?", *filter.EndpointID)
+	}
+	if filter.ProjectID != nil {
+		sql = sql.Where("endpoint_id IN (?)", sql.Session(&gorm.Session{NewDB: true}).
+			Model(&dbschema.WebhookEndpoint{}).Select("id").Where("project_id = ?", *filter.ProjectID))
+	}
+	if filter.Status != nil {
+		sql = sql.Where("status = ?", *filter.Status)
+	}
+	return sql
+}
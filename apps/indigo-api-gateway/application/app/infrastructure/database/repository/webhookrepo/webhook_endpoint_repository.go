@@ -0,0 +1,82 @@
+package webhookrepo
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+	"menlo.ai/indigo-api-gateway/app/domain/query"
+	"menlo.ai/indigo-api-gateway/app/domain/webhook"
+	"menlo.ai/indigo-api-gateway/app/infrastructure/database/dbschema"
+	repoquery "menlo.ai/indigo-api-gateway/app/infrastructure/database/repository/query"
+	"menlo.ai/indigo-api-gateway/app/infrastructure/database/repository/transaction"
+	"menlo.ai/indigo-api-gateway/app/utils/functional"
+)
+
+type WebhookEndpointRepository struct {
+	db *transaction.Database
+}
+
+var _ webhook.EndpointRepository = (*WebhookEndpointRepository)(nil)
+
+func NewWebhookEndpointRepository(db *transaction.Database) webhook.EndpointRepository {
+	return &WebhookEndpointRepository{db: db}
+}
+
+func (r *WebhookEndpointRepository) Create(ctx context.Context, endpoint *webhook.Endpoint) error {
+	model := dbschema.NewSchemaWebhookEndpoint(endpoint)
+	if err := r.db.GetTx(ctx).WithContext(ctx).Create(model).Error; err != nil {
+		return err
+	}
+	endpoint.ID = model.ID
+	endpoint.CreatedAt = model.CreatedAt
+	endpoint.UpdatedAt = model.UpdatedAt
+	return nil
+}
+
+func (r *WebhookEndpointRepository) Update(ctx context.Context, endpoint *webhook.Endpoint) error {
+	model := dbschema.NewSchemaWebhookEndpoint(endpoint)
+	if err := r.db.GetTx(ctx).WithContext(ctx).Save(model).Error; err != nil {
+		return err
+	}
+	endpoint.UpdatedAt = model.UpdatedAt
+	return nil
+}
+
+func (r *WebhookEndpointRepository) FindByFilter(ctx context.Context, filter webhook.EndpointFilter, pagination *query.Pagination) ([]*webhook.Endpoint, error) {
+	sql := applyEndpointFilter(r.db.GetTx(ctx).WithContext(ctx).Model(&dbschema.WebhookEndpoint{}), filter)
+	sql, order := repoquery.ApplyPagination(sql, pagination)
+
+	var rows []*dbschema.WebhookEndpoint
+	if err := sql.Order(order).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	return functional.Map(rows, func(item *dbschema.WebhookEndpoint) *webhook.Endpoint {
+		return item.EtoD()
+	}), nil
+}
+
+func (r *WebhookEndpointRepository) Count(ctx context.Context, filter webhook.EndpointFilter) (int64, error) {
+	var count int64
+	err := applyEndpointFilter(r.db.GetTx(ctx).WithContext(ctx).Model(&dbschema.WebhookEndpoint{}), filter).Count(&count).Error
+	return count, err
+}
+
+func (r *WebhookEndpointRepository) DeleteByID(ctx context.Context, id uint) error {
+	return r.db.GetTx(ctx).WithContext(ctx).Delete(&dbschema.WebhookEndpoint{}, id).Error
+}
+
+func applyEndpointFilter(sql *gorm.DB, filter webhook.EndpointFilter) *gorm.DB {
+	if filter.ID != nil {
+		sql = sql.Where("id = ?", *filter.ID)
+	}
+	if filter.PublicID != nil {
+		sql = sql.Where("public_id = ?", *filter.PublicID)
+	}
+	if filter.ProjectID != nil {
+		sql = sql.Where("project_id = ?", *filter.ProjectID)
+	}
+	if filter.Enabled != nil {
+		sql = sql.Where("enabled = ?", *filter.Enabled)
+	}
+	return sql
+}
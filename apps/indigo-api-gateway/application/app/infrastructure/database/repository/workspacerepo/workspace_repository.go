@@ -3,6 +3,8 @@ package workspacerepo
 import (
 	"context"
 
+	"gorm.io/gen/field"
+
 	"menlo.ai/indigo-api-gateway/app/domain/query"
 	domain "menlo.ai/indigo-api-gateway/app/domain/workspace"
 	"menlo.ai/indigo-api-gateway/app/infrastructure/database/dbschema"
@@ -34,6 +36,44 @@ func (repo *WorkspaceGormRepository) applyFilter(query *gormgen.Query, sql gormg
 	if filter.IDs != nil && len(*filter.IDs) > 0 {
 		sql = sql.Where(query.Workspace.ID.In((*filter.IDs)...))
 	}
+	if filter.MemberID != nil {
+		memberships := query.WorkspaceMember.
+			Select(query.WorkspaceMember.WorkspaceID).
+			Where(query.WorkspaceMember.UserID.Eq(*filter.MemberID))
+		sql = sql.Where(field.Or(
+			query.Workspace.UserID.Eq(*filter.MemberID),
+			sql.Columns(query.Workspace.ID).In(memberships),
+		))
+	}
+	return sql
+}
+
+func (repo *WorkspaceGormRepository) applyMemberFilter(query *gormgen.Query, sql gormgen.IWorkspaceMemberDo, filter domain.WorkspaceMemberFilter) gormgen.IWorkspaceMemberDo {
+	if filter.WorkspaceID != nil {
+		sql = sql.Where(query.WorkspaceMember.WorkspaceID.Eq(*filter.WorkspaceID))
+	}
+	if filter.UserID != nil {
+		sql = sql.Where(query.WorkspaceMember.UserID.Eq(*filter.UserID))
+	}
+	if filter.Role != nil {
+		sql = sql.Where(query.WorkspaceMember.Role.Eq(string(*filter.Role)))
+	}
+	return sql
+}
+
+func (repo *WorkspaceGormRepository) applyInvitationFilter(query *gormgen.Query, sql gormgen.IWorkspaceInvitationDo, filter domain.WorkspaceInvitationFilter) gormgen.IWorkspaceInvitationDo {
+	if filter.PublicID != nil {
+		sql = sql.Where(query.WorkspaceInvitation.PublicID.Eq(*filter.PublicID))
+	}
+	if filter.WorkspaceID != nil {
+		sql = sql.Where(query.WorkspaceInvitation.WorkspaceID.Eq(*filter.WorkspaceID))
+	}
+	if filter.InviteeUserID != nil {
+		sql = sql.Where(query.WorkspaceInvitation.InviteeUserID.Eq(*filter.InviteeUserID))
+	}
+	if filter.Status != nil {
+		sql = sql.Where(query.WorkspaceInvitation.Status.Eq(string(*filter.Status)))
+	}
 	return sql
 }
 
@@ -60,9 +100,16 @@ func (repo *WorkspaceGormRepository) Update(ctx context.Context, workspace *doma
 }
 
 func (repo *WorkspaceGormRepository) Delete(ctx context.Context, id uint) error {
-	query := repo.db.GetQuery(ctx)
-	_, err := query.Workspace.WithContext(ctx).Where(query.Workspace.ID.Eq(id)).Delete()
-	return err
+	return repo.db.GetQuery(ctx).Transaction(func(tx *gormgen.Query) error {
+		if _, err := tx.WorkspaceMember.WithContext(ctx).Unscoped().Where(tx.WorkspaceMember.WorkspaceID.Eq(id)).Delete(); err != nil {
+			return err
+		}
+		if _, err := tx.WorkspaceInvitation.WithContext(ctx).Where(tx.WorkspaceInvitation.WorkspaceID.Eq(id)).Delete(); err != nil {
+			return err
+		}
+		_, err := tx.Workspace.WithContext(ctx).Where(tx.Workspace.ID.Eq(id)).Delete()
+		return err
+	})
 }
 
 func (repo *WorkspaceGormRepository) FindByID(ctx context.Context, id uint) (*domain.Workspace, error) {
@@ -127,3 +174,94 @@ func (repo *WorkspaceGormRepository) Count(ctx context.Context, filter domain.Wo
 	sql = repo.applyFilter(query, sql, filter)
 	return sql.Count()
 }
+
+func (repo *WorkspaceGormRepository) AddMember(ctx context.Context, m *domain.WorkspaceMember) error {
+	model := dbschema.NewSchemaWorkspaceMember(m)
+	query := repo.db.GetQuery(ctx)
+	if err := query.WorkspaceMember.WithContext(ctx).Create(model); err != nil {
+		return err
+	}
+	m.ID = model.ID
+	m.CreatedAt = model.CreatedAt
+	return nil
+}
+
+// RemoveMember deletes the membership row so the user can be invited again later
+func (repo *WorkspaceGormRepository) RemoveMember(ctx context.Context, workspaceID, userID uint) error {
+	query := repo.db.GetQuery(ctx)
+	_, err := query.WorkspaceMember.WithContext(ctx).
+		Unscoped().
+		Where(query.WorkspaceMember.WorkspaceID.Eq(workspaceID), query.WorkspaceMember.UserID.Eq(userID)).
+		Delete()
+	return err
+}
+
+func (repo *WorkspaceGormRepository) UpdateMemberRole(ctx context.Context, workspaceID, userID uint, role domain.WorkspaceRole) error {
+	query := repo.db.GetQuery(ctx)
+	_, err := query.WorkspaceMember.WithContext(ctx).
+		Where(query.WorkspaceMember.WorkspaceID.Eq(workspaceID), query.WorkspaceMember.UserID.Eq(userID)).
+		Update(query.WorkspaceMember.Role, string(role))
+	return err
+}
+
+func (repo *WorkspaceGormRepository) FindMembersByFilter(ctx context.Context, filter domain.WorkspaceMemberFilter) ([]*domain.WorkspaceMember, error) {
+	query := repo.db.GetQuery(ctx)
+	sql := query.WorkspaceMember.WithContext(ctx)
+	sql = repo.applyMemberFilter(query, sql, filter)
+	rows, err := sql.Order(query.WorkspaceMember.ID.Asc()).Find()
+	if err != nil {
+		return nil, err
+	}
+	return functional.Map(rows, func(item *dbschema.WorkspaceMember) *domain.WorkspaceMember {
+		return item.EtoD()
+	}), nil
+}
+
+func (repo *WorkspaceGormRepository) CreateInvitation(ctx context.Context, invitation *domain.WorkspaceInvitation) error {
+	model := dbschema.NewSchemaWorkspaceInvitation(invitation)
+	query := repo.db.GetQuery(ctx)
+	if err := query.WorkspaceInvitation.WithContext(ctx).Create(model); err != nil {
+		return err
+	}
+	invitation.ID = model.ID
+	invitation.CreatedAt = model.CreatedAt
+	return nil
+}
+
+func (repo *WorkspaceGormRepository) UpdateInvitation(ctx context.Context, invitation *domain.WorkspaceInvitation) error {
+	model := dbschema.NewSchemaWorkspaceInvitation(invitation)
+	model.CreatedAt = invitation.CreatedAt
+	query := repo.db.GetQuery(ctx)
+	return query.WorkspaceInvitation.WithContext(ctx).Save(model)
+}
+
+func (repo *WorkspaceGormRepository) AcceptInvitation(ctx context.Context, invitation *domain.WorkspaceInvitation, member *domain.WorkspaceMember) error {
+	memberModel := dbschema.NewSchemaWorkspaceMember(member)
+	invitationModel := dbschema.NewSchemaWorkspaceInvitation(invitation)
+	invitationModel.CreatedAt = invitation.CreatedAt
+	err := repo.db.GetQuery(ctx).Transaction(func(tx *gormgen.Query) error {
+		if err := tx.WorkspaceMember.WithContext(ctx).Create(memberModel); err != nil {
+			return err
+		}
+		return tx.WorkspaceInvitation.WithContext(ctx).Save(invitationModel)
+	})
+	if err != nil {
+		return err
+	}
+	member.ID = memberModel.ID
+	member.CreatedAt = memberModel.CreatedAt
+	return nil
+}
+
+func (repo *WorkspaceGormRepository) FindInvitationsByFilter(ctx context.Context, filter domain.WorkspaceInvitationFilter) ([]*domain.WorkspaceInvitation, error) {
+	query := repo.db.GetQuery(ctx)
+	sql := query.WorkspaceInvitation.WithContext(ctx)
+	sql = repo.applyInvitationFilter(query, sql, filter)
+	rows, err := sql.Order(query.WorkspaceInvitation.ID.Desc()).Find()
+	if err != nil {
+		return nil, err
+	}
+	return functional.Map(rows, func(item *dbschema.WorkspaceInvitation) *domain.WorkspaceInvitation {
+		return item.EtoD()
+	}), nil
+}
@@ -0,0 +1,102 @@
+package workspacerepo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+	domain "menlo.ai/indigo-api-gateway/app/domain/workspace"
+	"menlo.ai/indigo-api-gateway/app/infrastructure/database/dbschema"
+	"menlo.ai/indigo-api-gateway/app/infrastructure/database/repository/transaction"
+)
+
+func newTestRepository(t *testing.T) domain.WorkspaceRepository {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{
+		NamingStrategy:                           schema.NamingStrategy{SingularTable: true},
+		DisableForeignKeyConstraintWhenMigrating: true,
+	})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	if err := db.AutoMigrate(&dbschema.Workspace{}, &dbschema.WorkspaceMember{}, &dbschema.WorkspaceInvitation{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return NewWorkspaceGormRepository(transaction.NewDatabase(db))
+}
+
+func TestAcceptInvitationIsAtomic(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepository(t)
+	ws := &domain.Workspace{PublicID: "ws_team", UserID: 1, Name: "Team"}
+	if err := repo.Create(ctx, ws); err != nil {
+		t.Fatalf("create workspace: %v", err)
+	}
+	invite := func(publicID string, inviteeID uint) *domain.WorkspaceInvitation {
+		invitation := &domain.WorkspaceInvitation{
+			PublicID:      publicID,
+			WorkspaceID:   ws.ID,
+			InviteeUserID: inviteeID,
+			InviterUserID: 1,
+			Role:          domain.WorkspaceRoleEditor,
+			Status:        domain.WorkspaceInvitationStatusPending,
+		}
+		if err := repo.CreateInvitation(ctx, invitation); err != nil {
+			t.Fatalf("create invitation: %v", err)
+		}
+		return invitation
+	}
+	accept := func(invitation *domain.WorkspaceInvitation) error {
+		now := time.Now()
+		invitation.Status = domain.WorkspaceInvitationStatusAccepted
+		invitation.RespondedAt = &now
+		return repo.AcceptInvitation(ctx, invitation, &domain.WorkspaceMember{
+			WorkspaceID: invitation.WorkspaceID,
+			UserID:      invitation.InviteeUserID,
+			Role:        invitation.Role,
+		})
+	}
+	statusOf := func(publicID string) domain.WorkspaceInvitationStatus {
+		invitations, err := repo.FindInvitationsByFilter(ctx, domain.WorkspaceInvitationFilter{PublicID: &publicID})
+		if err != nil || len(invitations) != 1 {
+			t.Fatalf("find invitation: %v", err)
+		}
+		return invitations[0].Status
+	}
+	countMembers := func(userID uint) int {
+		members, err := repo.FindMembersByFilter(ctx, domain.WorkspaceMemberFilter{WorkspaceID: &ws.ID, UserID: &userID})
+		if err != nil {
+			t.Fatalf("find members: %v", err)
+		}
+		return len(members)
+	}
+
+	if err := accept(invite("inv_first", 2)); err != nil {
+		t.Fatalf("accept invitation: %v", err)
+	}
+	if statusOf("inv_first") != domain.WorkspaceInvitationStatusAccepted || countMembers(2) != 1 {
+		t.Fatalf("expected the invitee to be a member and the invitation accepted")
+	}
+
+	// the membership already exists, so the invitation stays pending
+	if err := accept(invite("inv_again", 2)); err == nil {
+		t.Fatalf("expected a second membership to be rejected")
+	}
+	if statusOf("inv_again") != domain.WorkspaceInvitationStatusPending {
+		t.Fatalf("expected the invitation to stay pending when the member is not added")
+	}
+
+	// the invitation cannot be saved, so the member is not added
+	broken := invite("inv_broken", 3)
+	broken.ID = 0
+	broken.PublicID = "inv_first"
+	if err := accept(broken); err == nil {
+		t.Fatalf("expected the invitation save to fail")
+	}
+	if countMembers(3) != 0 {
+		t.Fatalf("expected the member to be rolled back with the invitation")
+	}
+}
@@ -9,7 +9,6 @@ import (
 	"menlo.ai/indigo-api-gateway/app/utils/crypto"
 	httpclients "menlo.ai/indigo-api-gateway/app/utils/httpclients"
 	chatclient "menlo.ai/indigo-api-gateway/app/utils/httpclients/chat"
-	"menlo.ai/indigo-api-gateway/config/environment_variables"
 	"resty.dev/v3"
 )
 
@@ -43,6 +42,17 @@ func (ip *InferenceProvider) GetChatModelClient(provider *domainmodel.Provider)
 	return chatclient.NewChatModelClient(client, clientName, provider.BaseURL), nil
 }
 
+// GetEmbeddingClient returns an embedding client configured for the provider
+func (ip *InferenceProvider) GetEmbeddingClient(provider *domainmodel.Provider) (*chatclient.EmbeddingClient, error) {
+	client, err := ip.createRestyClient(provider)
+	if err != nil {
+		return nil, err
+	}
+
+	clientName := provider.DisplayName
+	return chatclient.NewEmbeddingClient(client, clientName, provider.BaseURL), nil
+}
+
 // ListModels retrieves the available models for the given provider.
 func (ip *InferenceProvider) ListModels(ctx context.Context, provider *domainmodel.Provider) ([]chatclient.Model, error) {
 	modelClient, err := ip.GetChatModelClient(provider)
@@ -85,12 +95,12 @@ func (ip *InferenceProvider) decryptAPIKey(encryptedAPIKey string) (string, erro
 		return "", nil
 	}
 
-	secret := strings.TrimSpace(environment_variables.EnvironmentVariables.MODEL_PROVIDER_SECRET)
-	if secret == "" {
-		return "", fmt.Errorf("MODEL_PROVIDER_SECRET not configured")
+	keyring, err := crypto.DefaultKeyring()
+	if err != nil {
+		return "", err
 	}
 
-	plainText, err := crypto.DecryptString(secret, encryptedAPIKey)
+	plainText, err := keyring.Decrypt(context.Background(), encryptedAPIKey)
 	if err != nil {
 		return "", err
 	}
@@ -4,9 +4,11 @@ import (
 	"github.com/google/wire"
 	"menlo.ai/indigo-api-gateway/app/infrastructure/cache"
 	"menlo.ai/indigo-api-gateway/app/infrastructure/inference"
+	"menlo.ai/indigo-api-gateway/app/infrastructure/storage"
 )
 
 var InfrastructureProvider = wire.NewSet(
 	inference.NewInferenceProvider,
 	cache.NewRedisCacheService,
+	storage.NewBlobStorage,
 )
@@ -0,0 +1,63 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+
+	"menlo.ai/indigo-api-gateway/app/domain/file"
+	"menlo.ai/indigo-api-gateway/app/utils/logger"
+	"menlo.ai/indigo-api-gateway/config/environment_variables"
+)
+
+const (
+	DriverLocal = "local"
+	DriverS3    = "s3"
+
+	defaultLocalDir = "./data/files"
+	defaultS3Region = "us-east-1"
+)
+
+// NewBlobStorage creates the blob storage selected by FILE_STORAGE_DRIVER
+func NewBlobStorage() file.BlobStorage {
+	env := environment_variables.Current()
+	driver := strings.ToLower(strings.TrimSpace(env.FILE_STORAGE_DRIVER))
+	switch driver {
+	case "", DriverLocal:
+		dir := env.FILE_STORAGE_LOCAL_DIR
+		if dir == "" {
+			dir = defaultLocalDir
+		}
+		return NewLocalStorage(dir)
+	case DriverS3:
+		region := env.FILE_STORAGE_S3_REGION
+		if region == "" {
+			region = defaultS3Region
+		}
+		if env.FILE_STORAGE_S3_ENDPOINT == "" || env.FILE_STORAGE_S3_BUCKET == "" {
+			panic("FILE_STORAGE_S3_ENDPOINT and FILE_STORAGE_S3_BUCKET must be set for the s3 file storage driver")
+		}
+		return NewS3Storage(S3Config{
+			Endpoint:  env.FILE_STORAGE_S3_ENDPOINT,
+			Region:    region,
+			Bucket:    env.FILE_STORAGE_S3_BUCKET,
+			AccessKey: env.FILE_STORAGE_S3_ACCESS_KEY,
+			SecretKey: env.FILE_STORAGE_S3_SECRET_KEY,
+		})
+	default:
+		logger.GetLogger().Errorf("Unknown FILE_STORAGE_DRIVER %q, using %s", driver, DriverLocal)
+		return NewLocalStorage(defaultLocalDir)
+	}
+}
+
+// validateKey rejects keys that could escape the storage root
+func validateKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") {
+		return fmt.Errorf("invalid storage key %q", key)
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return fmt.Errorf("invalid storage key %q", key)
+		}
+	}
+	return nil
+}
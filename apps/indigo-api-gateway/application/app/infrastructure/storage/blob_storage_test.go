@@ -0,0 +1,125 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"menlo.ai/indigo-api-gateway/app/domain/file"
+)
+
+// fakeS3 is a minimal S3-compatible stand-in serving path-style object requests from memory
+type fakeS3 struct {
+	t       *testing.T
+	mu      sync.Mutex
+	objects map[string][]byte
+	types   map[string]string
+}
+
+func newFakeS3(t *testing.T) *fakeS3 {
+	return &fakeS3{
+		t:       t,
+		objects: make(map[string][]byte),
+		types:   make(map[string]string),
+	}
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	authorization := r.Header.Get("Authorization")
+	if !strings.HasPrefix(authorization, "AWS4-HMAC-SHA256 Credential=test-access/20250102/us-east-1/s3/aws4_request, SignedHeaders=") ||
+		!strings.Contains(authorization, "Signature=") {
+		f.t.Errorf("unexpected authorization header %q", authorization)
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	if r.Header.Get("x-amz-date") != "20250102T030405Z" || r.Header.Get("x-amz-content-sha256") != s3UnsignedBody {
+		f.t.Errorf("missing signature headers: %v", r.Header)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch r.Method {
+	case http.MethodPut:
+		body, _ := io.ReadAll(r.Body)
+		f.objects[r.URL.Path] = body
+		f.types[r.URL.Path] = r.Header.Get("Content-Type")
+	case http.MethodGet:
+		body, ok := f.objects[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte("<Error><Code>NoSuchKey</Code></Error>"))
+			return
+		}
+		_, _ = w.Write(body)
+	case http.MethodDelete:
+		delete(f.objects, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func exerciseBlobStorage(t *testing.T, storage file.BlobStorage) {
+	ctx := context.Background()
+	content := []byte("hello files")
+
+	if err := storage.Put(ctx, "42/file-abc", bytes.NewReader(content), int64(len(content)), "text/plain"); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+
+	reader, err := storage.Get(ctx, "42/file-abc")
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	got, _ := io.ReadAll(reader)
+	reader.Close()
+	if !bytes.Equal(got, content) {
+		t.Fatalf("expected %q, got %q", content, got)
+	}
+
+	if err := storage.Delete(ctx, "42/file-abc"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, err := storage.Get(ctx, "42/file-abc"); err == nil {
+		t.Fatalf("expected an error reading a deleted blob")
+	}
+
+	if err := storage.Put(ctx, "../escape", bytes.NewReader(content), int64(len(content)), "text/plain"); err == nil {
+		t.Fatalf("expected keys escaping the storage root to be rejected")
+	}
+}
+
+func TestLocalStorage(t *testing.T) {
+	exerciseBlobStorage(t, NewLocalStorage(t.TempDir()))
+}
+
+func TestS3Storage(t *testing.T) {
+	fake := newFakeS3(t)
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	storage := NewS3Storage(S3Config{
+		Endpoint:  server.URL + "/",
+		Region:    "us-east-1",
+		Bucket:    "uploads",
+		AccessKey: "test-access",
+		SecretKey: "test-secret",
+	})
+	storage.now = func() time.Time { return time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC) }
+
+	exerciseBlobStorage(t, storage)
+
+	content := []byte("kept")
+	if err := storage.Put(context.Background(), "7/file-kept", bytes.NewReader(content), int64(len(content)), "image/png"); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	if fake.types["/uploads/7/file-kept"] != "image/png" {
+		t.Fatalf("expected object stored under the bucket path with its content type, got %v", fake.types)
+	}
+}
@@ -1,9 +1,13 @@
 package http
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
+	"sync/atomic"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
@@ -17,9 +21,14 @@ import (
 	_ "menlo.ai/indigo-api-gateway/docs"
 )
 
+const serverPort = 8080
+
 type HttpServer struct {
 	engine  *gin.Engine
 	v1Route *v1.V1Route
+	server  *http.Server
+	// ready is cleared on shutdown so the load balancers stop routing new requests to the replica
+	ready atomic.Bool
 }
 
 func (s *HttpServer) bindSwagger() {
@@ -49,16 +58,24 @@ func NewHttpServer(v1Route *v1.V1Route) *HttpServer {
 		gin.SetMode(gin.ReleaseMode)
 	}
 	server := HttpServer{
-		gin.New(),
-		v1Route,
+		engine:  gin.New(),
+		v1Route: v1Route,
+	}
+	server.server = &http.Server{
+		Addr:    fmt.Sprintf(":%d", serverPort),
+		Handler: server.engine,
 	}
 	// TODO: we should enable cors later
 	server.engine.Use(middleware.CORS())
+	server.engine.Use(middleware.MetricsMiddleware())
+	server.engine.Use(middleware.TracingMiddleware())
+	server.engine.Use(middleware.TraceIDMiddleware())
 	server.engine.Use(middleware.LoggerMiddleware(logger.Logger))
 	server.engine.Use(middleware.TransactionMiddleware())
 	server.engine.GET("/healthcheck", func(c *gin.Context) {
 		c.JSON(200, "ok")
 	})
+	server.engine.GET("/readiness", server.readiness)
 	server.bindSwagger()
 	if config.IsDev() {
 		server.bindDev()
@@ -66,12 +83,37 @@ func NewHttpServer(v1Route *v1.V1Route) *HttpServer {
 	return &server
 }
 
+func (s *HttpServer) readiness(c *gin.Context) {
+	if !s.ready.Load() {
+		c.JSON(http.StatusServiceUnavailable, "shutting down")
+		return
+	}
+	c.JSON(http.StatusOK, "ok")
+}
+
+// Run serves the API until Shutdown is called
 func (httpServer *HttpServer) Run() error {
-	port := 8080
 	root := httpServer.engine.Group("/")
 	httpServer.v1Route.RegisterRouter(root)
-	if err := httpServer.engine.Run(fmt.Sprintf(":%d", port)); err != nil {
+	httpServer.ready.Store(true)
+	if err := httpServer.server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 		return err
 	}
 	return nil
 }
+
+// Shutdown fails the readiness probe, keeps serving for readinessDelay while the load balancers stop
+// routing to the replica, then stops accepting connections and waits for the open requests and streams
+// until ctx is done; the requests still open at that point are closed
+func (httpServer *HttpServer) Shutdown(ctx context.Context, readinessDelay time.Duration) error {
+	httpServer.ready.Store(false)
+	select {
+	case <-time.After(readinessDelay):
+	case <-ctx.Done():
+	}
+	err := httpServer.server.Shutdown(ctx)
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		return errors.Join(err, httpServer.server.Close())
+	}
+	return err
+}
@@ -0,0 +1,52 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func readinessStatus(server *HttpServer) int {
+	recorder := httptest.NewRecorder()
+	server.engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/readiness", nil))
+	return recorder.Code
+}
+
+func TestShutdownFailsReadinessFirst(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	server := &HttpServer{engine: gin.New(), server: &http.Server{}}
+	server.engine.GET("/readiness", server.readiness)
+
+	if status := readinessStatus(server); status != http.StatusServiceUnavailable {
+		t.Fatalf("expected the server to be unready before Run, got %d", status)
+	}
+	server.ready.Store(true)
+	if status := readinessStatus(server); status != http.StatusOK {
+		t.Fatalf("expected the server to be ready, got %d", status)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- server.Shutdown(context.Background(), 100*time.Millisecond)
+	}()
+	// the readiness probe fails while the server keeps serving during the delay
+	deadline := time.Now().Add(time.Second)
+	for readinessStatus(server) != http.StatusServiceUnavailable {
+		if time.Now().After(deadline) {
+			t.Fatal("expected the readiness probe to fail during the shutdown")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	select {
+	case <-done:
+		t.Fatal("expected the shutdown to wait for the readiness delay")
+	default:
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("shutdown: %v", err)
+	}
+}
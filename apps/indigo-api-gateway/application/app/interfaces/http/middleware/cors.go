@@ -13,7 +13,7 @@ func CORS() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		host := c.Request.Header.Get("Origin")
 		isValidHost := false
-		for _, allowedHost := range environment_variables.EnvironmentVariables.ALLOWED_CORS_HOSTS {
+		for _, allowedHost := range environment_variables.Current().ALLOWED_CORS_HOSTS {
 			// wildcard
 			if strings.HasPrefix(allowedHost, "*") {
 				suffix := strings.TrimPrefix(allowedHost, "*")
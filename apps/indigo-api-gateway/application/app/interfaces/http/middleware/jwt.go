@@ -22,7 +22,7 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 		token, err := jwt.ParseWithClaims(tokenString, &auth.UserClaim{}, func(token *jwt.Token) (interface{}, error) {
-			return environment_variables.EnvironmentVariables.JWT_SECRET, nil
+			return environment_variables.Current().JWT_SECRET, nil
 		})
 		if err != nil || !token.Valid {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, responses.ErrorResponse{
@@ -52,7 +52,7 @@ func OptionalAuthMiddleware() gin.HandlerFunc {
 			return
 		}
 		token, err := jwt.ParseWithClaims(tokenString, &auth.UserClaim{}, func(token *jwt.Token) (interface{}, error) {
-			return environment_variables.EnvironmentVariables.JWT_SECRET, nil
+			return environment_variables.Current().JWT_SECRET, nil
 		})
 		if err != nil || !token.Valid {
 			c.Next()
@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"context"
 	"io"
+	"math/rand/v2"
+	"strconv"
 	"strings"
 	"time"
 
@@ -11,26 +13,64 @@ import (
 	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
 	"menlo.ai/indigo-api-gateway/app/utils/contextkeys"
+	"menlo.ai/indigo-api-gateway/app/utils/redact"
+	"menlo.ai/indigo-api-gateway/app/utils/tracing"
+	"menlo.ai/indigo-api-gateway/config/environment_variables"
 )
 
+// maxCapturedResponseBytes bounds the response captured for the log, larger responses are not logged
+const maxCapturedResponseBytes = 1 << 20
+
+// defaultOmitBodyRoutes carry prompts and completions, their bodies are not logged unless LOG_OMIT_BODY_ROUTES is set
+var defaultOmitBodyRoutes = []string{
+	"/v1/chat/completions",
+	"/v1/conv/*",
+	"/v1/responses*",
+	"/v1/conversations*",
+}
+
 type BodyLogWriter struct {
 	gin.ResponseWriter
 	body *bytes.Buffer
+	size int
 }
 
-func (w BodyLogWriter) Write(b []byte) (int, error) {
-	w.body.Write(b) // capture response
+func (w *BodyLogWriter) Write(b []byte) (int, error) {
+	// capture response up to the limit
+	w.size += len(b)
+	if w.body.Len()+len(b) <= maxCapturedResponseBytes {
+		w.body.Write(b)
+	}
 	return w.ResponseWriter.Write(b)
 }
 
+func (w *BodyLogWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// OmitBodiesFromLogs opts a route out of body logging, for the routes whose bodies carry user content
+func OmitBodiesFromLogs() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), contextkeys.OmitLogBodies{}, true))
+		c.Next()
+	}
+}
+
 func LoggerMiddleware(logger *logrus.Logger) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		start := time.Now()
+		env := environment_variables.Current()
+		policy := redact.PolicyFromEnv()
 
 		// Generate and set request ID
 		requestID := uuid.New().String()
 		ctx := c.Request.Context()
 		ctx = context.WithValue(ctx, contextkeys.RequestId{}, requestID)
+		omitBodies := routeMatches(c.FullPath(), env.LOG_OMIT_BODY_ROUTES)
+		if omitBodies {
+			// Also keeps the bodies of the upstream calls of the request out of the logs
+			ctx = context.WithValue(ctx, contextkeys.OmitLogBodies{}, true)
+		}
 		c.Request = c.Request.WithContext(ctx)
 		c.Writer.Header().Set("X-Request-ID", requestID)
 
@@ -42,12 +82,21 @@ func LoggerMiddleware(logger *logrus.Logger) gin.HandlerFunc {
 			c.Request.Body = io.NopCloser(bytes.NewBuffer(reqBody))
 		}
 
-		// Wrap writer only if not streaming
 		var blw = &BodyLogWriter{body: bytes.NewBufferString(""), ResponseWriter: c.Writer}
 		c.Writer = blw
 
 		// Process request
 		c.Next()
+
+		// Errors are always logged, the other requests are sampled
+		status := c.Writer.Status()
+		if status < 400 && !sampled(env.LOG_SAMPLE_RATE) {
+			return
+		}
+		if omitted, ok := c.Request.Context().Value(contextkeys.OmitLogBodies{}).(bool); ok && omitted {
+			omitBodies = true
+		}
+
 		contentType := c.Writer.Header().Get("Content-Type")
 		isStream := strings.HasPrefix(contentType, "text/event-stream") ||
 			strings.HasPrefix(contentType, "application/octet-stream") ||
@@ -55,22 +104,70 @@ func LoggerMiddleware(logger *logrus.Logger) gin.HandlerFunc {
 
 		// Log everything
 		duration := time.Since(start)
+		requestBody := redact.Omitted(len(reqBody))
 		responseBody := ""
 		if !isStream {
-			responseBody = blw.body.String()
+			responseBody = redact.Omitted(blw.size)
+		}
+		if !omitBodies {
+			requestBody = policy.Body(reqBody, c.GetHeader("Content-Type"))
+			if !isStream && blw.size == blw.body.Len() {
+				responseBody = policy.Body(blw.body.Bytes(), contentType)
+			}
 		}
 		logger.WithFields(logrus.Fields{
 			"request_id": requestID,
-			"status":     c.Writer.Status(),
+			"trace_id":   tracing.TraceID(c.Request.Context()),
+			"status":     status,
 			"method":     c.Request.Method,
 			"host":       c.Request.Host,
 			"path":       c.Request.URL.Path,
-			"query":      c.Request.URL.RawQuery,
-			"headers":    c.Request.Header,
-			"req_body":   string(reqBody),
+			"query":      policy.Query(c.Request.URL.RawQuery),
+			"headers":    policy.Headers(c.Request.Header),
+			"req_body":   requestBody,
 			"resp_body":  responseBody,
 			"latency":    duration.String(),
 			"client_ip":  c.ClientIP(),
 		}).Info("")
 	}
 }
+
+// routeMatches reports whether the route template is listed, an entry ending with '*' matches a prefix;
+// without entries the prompt and completion routes match
+func routeMatches(route string, entries []string) bool {
+	if route == "" {
+		return false
+	}
+	if len(entries) == 0 {
+		entries = defaultOmitBodyRoutes
+	}
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if prefix, ok := strings.CutSuffix(entry, "*"); ok {
+			if strings.HasPrefix(route, prefix) {
+				return true
+			}
+			continue
+		}
+		if route == entry {
+			return true
+		}
+	}
+	return false
+}
+
+// sampled keeps a request with the probability of LOG_SAMPLE_RATE, all of them when it is not set
+func sampled(rate string) bool {
+	rate = strings.TrimSpace(rate)
+	if rate == "" {
+		return true
+	}
+	value, err := strconv.ParseFloat(rate, 64)
+	if err != nil || value >= 1 {
+		return true
+	}
+	return rand.Float64() < value
+}
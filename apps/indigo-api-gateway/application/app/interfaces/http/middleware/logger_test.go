@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus/hooks/test"
+	"menlo.ai/indigo-api-gateway/app/utils/redact"
+)
+
+func TestLoggerMiddlewareRedacts(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger, hook := test.NewNullLogger()
+	engine := gin.New()
+	engine.Use(LoggerMiddleware(logger))
+	engine.POST("/v1/organization/admin_api_keys", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"key": "sk-admin-secret", "name": "ci"})
+	})
+	engine.POST("/v1/chat/completions", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"choices": []string{"a private answer"}})
+	})
+
+	request := httptest.NewRequest(http.MethodPost, "/v1/organization/admin_api_keys", strings.NewReader(`{"name":"ci","password":"hunter2"}`))
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Authorization", "Bearer sk-caller")
+	engine.ServeHTTP(httptest.NewRecorder(), request)
+
+	entry := hook.LastEntry()
+	if entry == nil {
+		t.Fatal("the request was not logged")
+	}
+	if headers := entry.Data["headers"].(http.Header); headers.Get("Authorization") != redact.Placeholder {
+		t.Errorf("Authorization was logged: %v", headers)
+	}
+	for _, field := range []string{"req_body", "resp_body"} {
+		body := entry.Data[field].(string)
+		if strings.Contains(body, "hunter2") || strings.Contains(body, "sk-admin-secret") || !strings.Contains(body, `"name":"ci"`) {
+			t.Errorf("%s = %s", field, body)
+		}
+	}
+
+	request = httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(`{"messages":[{"content":"a private question"}]}`))
+	request.Header.Set("Content-Type", "application/json")
+	engine.ServeHTTP(httptest.NewRecorder(), request)
+	entry = hook.LastEntry()
+	if strings.Contains(entry.Data["req_body"].(string), "private") || strings.Contains(entry.Data["resp_body"].(string), "private") {
+		t.Errorf("prompt content was logged: %v", entry.Data)
+	}
+}
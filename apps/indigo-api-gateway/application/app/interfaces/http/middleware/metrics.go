@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"menlo.ai/indigo-api-gateway/app/utils/metrics"
+)
+
+// streamWriter counts the response as an open SSE stream once its event-stream headers are written
+type streamWriter struct {
+	gin.ResponseWriter
+	streamEnded func()
+}
+
+func (w *streamWriter) WriteHeader(code int) {
+	w.detectStream()
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *streamWriter) WriteHeaderNow() {
+	w.detectStream()
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *streamWriter) Write(b []byte) (int, error) {
+	w.detectStream()
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *streamWriter) WriteString(s string) (int, error) {
+	w.detectStream()
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *streamWriter) detectStream() {
+	if w.streamEnded != nil || w.ResponseWriter.Written() {
+		return
+	}
+	if strings.HasPrefix(w.Header().Get("Content-Type"), "text/event-stream") {
+		w.streamEnded = metrics.StreamStarted()
+	}
+}
+
+// MetricsMiddleware records the latency of every request by route template and status, and the open SSE streams
+func MetricsMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		writer := &streamWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		defer func() {
+			if writer.streamEnded != nil {
+				writer.streamEnded()
+			}
+		}()
+
+		c.Next()
+		metrics.ObserveHTTPRequest(c.Request.Method, c.FullPath(), c.Writer.Status(), time.Since(start))
+	}
+}
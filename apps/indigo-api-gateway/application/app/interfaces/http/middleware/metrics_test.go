@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"menlo.ai/indigo-api-gateway/app/utils/metrics"
+)
+
+func TestMetricsMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(MetricsMiddleware())
+	engine.GET("/metrics", gin.WrapH(metrics.Handler()))
+	engine.GET("/items/:id", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"id": c.Param("id")})
+	})
+	streamOpen := make(chan string)
+	engine.GET("/stream", func(c *gin.Context) {
+		c.Header("Content-Type", "text/event-stream")
+		c.Writer.WriteHeaderNow()
+		streamOpen <- scrape(engine)
+	})
+
+	engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/items/42", nil))
+	done := make(chan struct{})
+	go func() {
+		engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/stream", nil))
+		close(done)
+	}()
+
+	during := <-streamOpen
+	<-done
+	if !strings.Contains(during, "indigo_http_active_sse_streams 1") {
+		t.Errorf("the open stream was not counted")
+	}
+	after := scrape(engine)
+	for _, want := range []string{
+		`indigo_http_request_duration_seconds_count{method="GET",route="/items/:id",status="200"}`,
+		`indigo_http_request_duration_seconds_count{method="GET",route="/stream",status="200"}`,
+		"indigo_http_active_sse_streams 0",
+	} {
+		if !strings.Contains(after, want) {
+			t.Errorf("metrics miss %q", want)
+		}
+	}
+}
+
+func scrape(engine *gin.Engine) string {
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	return recorder.Body.String()
+}
@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"menlo.ai/indigo-api-gateway/app/utils/tracing"
+)
+
+const traceIDHeader = "X-Trace-ID"
+
+// traceWriter adds the trace ID to the JSON body of error responses
+type traceWriter struct {
+	gin.ResponseWriter
+	traceID string
+	written bool
+}
+
+func (w *traceWriter) Write(b []byte) (int, error) {
+	if w.written || w.Status() < 400 || !strings.HasPrefix(w.Header().Get("Content-Type"), "application/json") {
+		w.written = true
+		return w.ResponseWriter.Write(b)
+	}
+	w.written = true
+	trimmed := bytes.TrimLeft(b, " \t\r\n")
+	if len(trimmed) < 2 || trimmed[0] != '{' {
+		return w.ResponseWriter.Write(b)
+	}
+	field := `"trace_id":"` + w.traceID + `"`
+	if rest := bytes.TrimLeft(trimmed[1:], " \t\r\n"); len(rest) > 0 && rest[0] != '}' {
+		field += ","
+	}
+	body := make([]byte, 0, len(trimmed)+len(field))
+	body = append(body, '{')
+	body = append(body, field...)
+	body = append(body, trimmed[1:]...)
+	if _, err := w.ResponseWriter.Write(body); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+func (w *traceWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// TracingMiddleware starts the server span of the request, continuing the trace of its W3C traceparent header
+func TracingMiddleware() gin.HandlerFunc {
+	return otelgin.Middleware(tracing.ServiceName, otelgin.WithGinFilter(func(c *gin.Context) bool {
+		return c.FullPath() != "/healthcheck" && c.FullPath() != "/readiness"
+	}))
+}
+
+// TraceIDMiddleware echoes the trace ID of the request in the X-Trace-ID header and in the trace_id field of
+// JSON error responses; it runs after TracingMiddleware, once the span of the request exists
+func TraceIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		traceID := tracing.TraceID(c.Request.Context())
+		if traceID == "" {
+			c.Next()
+			return
+		}
+		c.Header(traceIDHeader, traceID)
+		c.Writer = &traceWriter{ResponseWriter: c.Writer, traceID: traceID}
+		c.Next()
+	}
+}
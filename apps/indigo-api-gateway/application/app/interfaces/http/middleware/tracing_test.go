@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"menlo.ai/indigo-api-gateway/app/interfaces/http/responses"
+	"menlo.ai/indigo-api-gateway/app/utils/tracing"
+)
+
+func TestTraceIDMiddleware(t *testing.T) {
+	shutdown, err := tracing.Init(context.Background())
+	if err != nil {
+		t.Fatalf("tracing.Init: %v", err)
+	}
+	defer shutdown(context.Background())
+
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(TracingMiddleware(), TraceIDMiddleware())
+	engine.GET("/fail", func(c *gin.Context) {
+		c.AbortWithStatusJSON(http.StatusBadRequest, responses.ErrorResponse{Code: "code", Error: "bad"})
+	})
+	engine.GET("/ok", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"ok": true})
+	})
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	request := httptest.NewRequest(http.MethodGet, "/fail", nil)
+	request.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, request)
+
+	if got := recorder.Header().Get("X-Trace-ID"); got != traceID {
+		t.Errorf("X-Trace-ID = %q, want the propagated trace %q", got, traceID)
+	}
+	var body map[string]string
+	if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid error body %q: %v", recorder.Body.String(), err)
+	}
+	if body["trace_id"] != traceID || body["code"] != "code" || body["error"] != "bad" {
+		t.Errorf("unexpected error body: %v", body)
+	}
+
+	recorder = httptest.NewRecorder()
+	engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/ok", nil))
+	if recorder.Header().Get("X-Trace-ID") == "" {
+		t.Error("no trace ID for a request without traceparent")
+	}
+	if recorder.Body.String() != `{"ok":true}` {
+		t.Errorf("a successful body was changed: %s", recorder.Body.String())
+	}
+}
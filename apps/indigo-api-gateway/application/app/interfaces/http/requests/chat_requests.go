@@ -0,0 +1,15 @@
+package requests
+
+import openai "github.com/sashabaranov/go-openai"
+
+// ChatCompletionRequest is the OpenAI chat completion request extended with the gateway MCP tools
+type ChatCompletionRequest struct {
+	openai.ChatCompletionRequest
+
+	// MCPTools names the tools of the gateway MCP endpoint the gateway calls on behalf of the model,
+	// "*" selects every tool available to the project of the API key.
+	MCPTools []string `json:"mcp_tools,omitempty"`
+
+	// MaxToolSteps bounds the tool rounds run before the model has to answer.
+	MaxToolSteps *int `json:"max_tool_steps,omitempty"`
+}
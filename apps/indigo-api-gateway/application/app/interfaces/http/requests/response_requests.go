@@ -77,6 +77,21 @@ type CreateResponseRequest struct {
 
 	// Whether to store the conversation. If false, no conversation will be created or used.
 	Store *bool `json:"store,omitempty"`
+
+	// How conversation history is fitted into the model context window. Unset fields fall back to workspace settings.
+	ContextManagement *ContextManagement `json:"context_management,omitempty"`
+}
+
+// ContextManagement selects the context window strategy for a request
+type ContextManagement struct {
+	// One of truncate_oldest, keep_last_n, summarize or none.
+	Strategy *string `json:"strategy,omitempty"`
+
+	// Number of recent messages kept by the keep_last_n strategy.
+	KeepLastN *int `json:"keep_last_n,omitempty"`
+
+	// Model used to summarize older turns with the summarize strategy.
+	SummaryModel *string `json:"summary_model,omitempty"`
 }
 
 // CreateResponseInput represents the input to the model
@@ -131,6 +146,9 @@ type ImageInput struct {
 	// The base64 encoded image data.
 	Data *string `json:"data,omitempty"`
 
+	// The ID of an uploaded image file.
+	FileID *string `json:"file_id,omitempty"`
+
 	// The detail level for the image.
 	Detail *string `json:"detail,omitempty"`
 }
@@ -206,8 +224,26 @@ type ReasoningInput struct {
 
 // ResponseFormat represents the format of the response
 type ResponseFormat struct {
-	// The type of response format.
+	// The type of response format: text, json_object or json_schema.
 	Type string `json:"type" binding:"required"`
+
+	// The schema the output must match, required for json_schema.
+	JSONSchema *JSONSchemaFormat `json:"json_schema,omitempty"`
+}
+
+// JSONSchemaFormat describes the JSON Schema of a json_schema response format
+type JSONSchemaFormat struct {
+	// The name of the schema.
+	Name string `json:"name"`
+
+	// A description of what the output represents.
+	Description *string `json:"description,omitempty"`
+
+	// The JSON Schema the output is validated against.
+	Schema map[string]any `json:"schema"`
+
+	// Whether the model should follow the schema strictly.
+	Strict *bool `json:"strict,omitempty"`
 }
 
 // Tool represents a tool that can be used by the model
@@ -217,6 +253,12 @@ type Tool struct {
 
 	// The function definition for function tools.
 	Function *FunctionDefinition `json:"function,omitempty"`
+
+	// The IDs of the vector stores searched by file_search tools.
+	VectorStoreIDs []string `json:"vector_store_ids,omitempty"`
+
+	// The maximum number of chunks returned by file_search tools.
+	MaxNumResults *int `json:"max_num_results,omitempty"`
 }
 
 // FunctionDefinition represents a function definition
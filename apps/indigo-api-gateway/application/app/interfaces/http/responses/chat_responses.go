@@ -0,0 +1,29 @@
+package responses
+
+import openai "github.com/sashabaranov/go-openai"
+
+// ChatCompletionResponse is the OpenAI chat completion response with the gateway MCP tool calls made to produce it
+type ChatCompletionResponse struct {
+	openai.ChatCompletionResponse
+	MCPToolCalls []MCPToolCall `json:"mcp_tool_calls,omitempty"`
+}
+
+// MCPToolCall is a tool of the gateway MCP endpoint called on behalf of the model
+type MCPToolCall struct {
+	// Step is the tool round of the call, starting at 1
+	Step      int    `json:"step"`
+	CallID    string `json:"call_id"`
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+	// Output is the text fed back to the model
+	Output    string `json:"output"`
+	IsError   bool   `json:"is_error"`
+	LatencyMs int64  `json:"latency_ms"`
+}
+
+// MCPToolCallEvent is streamed as a named SSE event while the gateway calls an MCP tool
+type MCPToolCallEvent struct {
+	// Type is mcp.tool_call.started or mcp.tool_call.completed
+	Type string `json:"type"`
+	MCPToolCall
+}
@@ -4,6 +4,8 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/gin-gonic/gin"
+	"menlo.ai/indigo-api-gateway/app/domain/common"
 	"menlo.ai/indigo-api-gateway/config"
 )
 
@@ -82,6 +84,14 @@ func BuildCursorPage[T any](
 	return cursorPage, nil
 }
 
+// AbortWithError stops the request with the code and the message of err
+func AbortWithError(reqCtx *gin.Context, status int, err *common.Error) {
+	reqCtx.AbortWithStatusJSON(status, ErrorResponse{
+		Code:          err.GetCode(),
+		ErrorInstance: err.GetError(),
+	})
+}
+
 func NewCookieWithSecurity(name string, value string, expires time.Time) *http.Cookie {
 	if config.IsDev() {
 		return &http.Cookie{
@@ -119,13 +119,24 @@ type Response struct {
 type ResponseStatus string
 
 const (
-	ResponseStatusPending   ResponseStatus = "pending"
-	ResponseStatusRunning   ResponseStatus = "running"
-	ResponseStatusCompleted ResponseStatus = "completed"
-	ResponseStatusCancelled ResponseStatus = "cancelled"
-	ResponseStatusFailed    ResponseStatus = "failed"
+	ResponseStatusPending    ResponseStatus = "pending"
+	ResponseStatusQueued     ResponseStatus = "queued"
+	ResponseStatusRunning    ResponseStatus = "running"
+	ResponseStatusCompleted  ResponseStatus = "completed"
+	ResponseStatusCancelled  ResponseStatus = "cancelled"
+	ResponseStatusFailed     ResponseStatus = "failed"
+	ResponseStatusIncomplete ResponseStatus = "incomplete"
 )
 
+// IncompleteDetails explains why a response finished with status incomplete
+type IncompleteDetails struct {
+	// The reason the response is incomplete.
+	Reason string `json:"reason"`
+
+	// The validation errors of the final output, for json_schema_validation.
+	Errors []string `json:"errors,omitempty"`
+}
+
 // ResponseOutput represents the output generated by the model
 type ResponseOutput struct {
 	// The type of output.
@@ -328,6 +339,12 @@ type Annotation struct {
 	// The text of the annotation.
 	Text string `json:"text"`
 
+	// The ID of the cited file, set for file_citation annotations.
+	FileID string `json:"file_id,omitempty"`
+
+	// The name of the cited file, set for file_citation annotations.
+	Filename string `json:"filename,omitempty"`
+
 	// The metadata for the annotation.
 	Metadata map[string]any `json:"metadata,omitempty"`
 }
@@ -54,7 +54,31 @@ type ResponseOutputItem struct {
 	Type    string                `json:"type"`
 	Status  string                `json:"status"`
 	Content []ResponseContentPart `json:"content"`
-	Role    string                `json:"role"`
+	Role    string                `json:"role,omitempty"`
+	Action  *WebSearchAction      `json:"action,omitempty"`
+	Queries []string              `json:"queries,omitempty"`
+	Results []FileSearchResult    `json:"results,omitempty"`
+}
+
+// WebSearchAction describes what a web_search_call or fetch_webpage_call item did
+type WebSearchAction struct {
+	// The action type, "search" or "open_page".
+	Type string `json:"type"`
+
+	// The search query, for search actions.
+	Query string `json:"query,omitempty"`
+
+	// The page URL, for open_page actions.
+	URL string `json:"url,omitempty"`
+}
+
+// ResponseWebSearchCallEvent represents a response.web_search_call.in_progress, .searching or .completed event,
+// file_search_call items emit the same events under response.file_search_call and fetch_webpage_call items emit
+// response.fetch_webpage_call.in_progress, .fetching and .completed
+type ResponseWebSearchCallEvent struct {
+	BaseStreamingEvent
+	OutputIndex int    `json:"output_index"`
+	ItemID      string `json:"item_id"`
 }
 
 // ResponseContentPart represents a content part
@@ -8,6 +8,7 @@ import (
 	chat "menlo.ai/indigo-api-gateway/app/interfaces/http/routes/v1/chat"
 	conv_chat "menlo.ai/indigo-api-gateway/app/interfaces/http/routes/v1/conv"
 	"menlo.ai/indigo-api-gateway/app/interfaces/http/routes/v1/conversations"
+	"menlo.ai/indigo-api-gateway/app/interfaces/http/routes/v1/files"
 	"menlo.ai/indigo-api-gateway/app/interfaces/http/routes/v1/mcp"
 	mcp_impl "menlo.ai/indigo-api-gateway/app/interfaces/http/routes/v1/mcp/mcp_impl"
 	modelroute "menlo.ai/indigo-api-gateway/app/interfaces/http/routes/v1/model"
@@ -15,7 +16,9 @@ import (
 	"menlo.ai/indigo-api-gateway/app/interfaces/http/routes/v1/organization/invites"
 	"menlo.ai/indigo-api-gateway/app/interfaces/http/routes/v1/organization/projects"
 	api_keys "menlo.ai/indigo-api-gateway/app/interfaces/http/routes/v1/organization/projects/api_keys"
+	"menlo.ai/indigo-api-gateway/app/interfaces/http/routes/v1/organization/projects/webhooks"
 	"menlo.ai/indigo-api-gateway/app/interfaces/http/routes/v1/responses"
+	"menlo.ai/indigo-api-gateway/app/interfaces/http/routes/v1/vectorstores"
 )
 
 var RouteProvider = wire.NewSet(
@@ -24,8 +27,11 @@ var RouteProvider = wire.NewSet(
 	projects.NewProjectsRoute,
 	organization.NewAdminApiKeyAPI,
 	organization.NewModelProviderRoute,
+	organization.NewMCPServerRoute,
+	organization.NewCronJobRoute,
 	organization.NewOrganizationRoute,
 	mcp_impl.NewSerperMCP,
+	mcp_impl.NewFederatedMCP,
 	chat.NewChatRoute,
 	chat.NewCompletionAPI,
 	conv_chat.NewConvChatRoute,
@@ -38,8 +44,11 @@ var RouteProvider = wire.NewSet(
 	modelroute.NewModelAPI,
 	modelroute.NewProvidersAPI,
 	responses.NewResponseRoute,
+	files.NewFilesRoute,
+	vectorstores.NewVectorStoresRoute,
 	v1.NewV1Route,
 	conversations.NewConversationAPI,
 	invites.NewInvitesRoute,
 	api_keys.NewProjectApiKeyRoute,
+	webhooks.NewProjectWebhookRoute,
 )
@@ -109,7 +109,9 @@ func TestAuthRouteLocalLogin(t *testing.T) {
 	repo := newMemoryUserRepo()
 	userService := user.NewService(repo, nil)
 	authService := domainauth.NewAuthService(userService, nil, nil, nil, nil)
-	environment_variables.EnvironmentVariables.JWT_SECRET = []byte("test-secret")
+	environment_variables.Override("test", func(env *environment_variables.EnvironmentVariable) {
+		env.JWT_SECRET = []byte("test-secret")
+	})
 
 	ctx := context.Background()
 	admin, err := userService.RegisterUser(ctx, &user.User{
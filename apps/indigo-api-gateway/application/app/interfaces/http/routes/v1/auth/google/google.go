@@ -28,9 +28,9 @@ type GoogleAuthAPI struct {
 
 func NewGoogleAuthAPI(userService *user.UserService, authService *auth.AuthService) *GoogleAuthAPI {
 	oauth2Config := &oauth2.Config{
-		ClientID:     environment_variables.EnvironmentVariables.OAUTH2_GOOGLE_CLIENT_ID,
-		ClientSecret: environment_variables.EnvironmentVariables.OAUTH2_GOOGLE_CLIENT_SECRET,
-		RedirectURL:  environment_variables.EnvironmentVariables.OAUTH2_GOOGLE_REDIRECT_URL,
+		ClientID:     environment_variables.Current().OAUTH2_GOOGLE_CLIENT_ID,
+		ClientSecret: environment_variables.Current().OAUTH2_GOOGLE_CLIENT_SECRET,
+		RedirectURL:  environment_variables.Current().OAUTH2_GOOGLE_REDIRECT_URL,
 		Scopes:       []string{oidc.ScopeOpenID, "profile", "email"},
 		Endpoint:     google.Endpoint,
 	}
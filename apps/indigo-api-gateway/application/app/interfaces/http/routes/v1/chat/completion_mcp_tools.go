@@ -0,0 +1,395 @@
+package chat
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	mcpclient "github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+	openai "github.com/sashabaranov/go-openai"
+	"menlo.ai/indigo-api-gateway/app/domain/common"
+	domainmodel "menlo.ai/indigo-api-gateway/app/domain/model"
+	"menlo.ai/indigo-api-gateway/app/domain/structuredoutput"
+	"menlo.ai/indigo-api-gateway/app/interfaces/http/responses"
+	"menlo.ai/indigo-api-gateway/app/utils/logger"
+)
+
+const (
+	// DefaultMCPToolSteps is the number of tool rounds run when the request does not set max_tool_steps
+	DefaultMCPToolSteps = 5
+	MaxMCPToolSteps     = 10
+	// mcpAllTools selects every tool the project may use
+	mcpAllTools        = "*"
+	mcpToolCallTimeout = 60 * time.Second
+	// maxMCPToolOutputChars caps the tool output fed back to the model
+	maxMCPToolOutputChars = 20000
+
+	mcpToolCallStartedEvent   = "mcp.tool_call.started"
+	mcpToolCallCompletedEvent = "mcp.tool_call.completed"
+)
+
+// mcpToolset is the selection of gateway MCP tools of a completion, called through an in-process client
+type mcpToolset struct {
+	client *mcpclient.Client
+	names  map[string]bool
+	tools  []openai.Tool
+}
+
+// connectMCPTools connects to the MCP server of the project and exposes the requested tools to the model as functions
+func (cApi *CompletionAPI) connectMCPTools(ctx context.Context, projectID *uint, requested []string, clientTools []openai.Tool) (*mcpToolset, *common.Error) {
+	client, err := cApi.federatedMCP.Connect(ctx, projectID)
+	if err != nil {
+		return nil, common.NewError(err, "3f8a1c6e-9d2b-4e7a-b5c3-8e1f4a9d2c67")
+	}
+	listed, err := client.ListTools(ctx, mcp.ListToolsRequest{})
+	if err != nil {
+		_ = client.Close()
+		return nil, common.NewError(err, "7b2e9d4f-1c6a-4a8e-9f3d-5c7b1e8a4d29")
+	}
+
+	available := make(map[string]mcp.Tool, len(listed.Tools))
+	for _, tool := range listed.Tools {
+		available[tool.Name] = tool
+	}
+	selected := make([]mcp.Tool, 0, len(requested))
+	if slices.Contains(requested, mcpAllTools) {
+		selected = append(selected, listed.Tools...)
+	} else {
+		for _, name := range requested {
+			tool, ok := available[name]
+			if !ok {
+				_ = client.Close()
+				return nil, common.NewErrorWithMessage(fmt.Sprintf("MCP tool %q is not available", name), "c4d8e2a7-5f1b-4c9e-8a3d-2b6f9e1c7a54")
+			}
+			selected = append(selected, tool)
+		}
+	}
+
+	toolset := &mcpToolset{
+		client: client,
+		names:  make(map[string]bool, len(selected)),
+		tools:  make([]openai.Tool, 0, len(selected)),
+	}
+	for _, tool := range selected {
+		if toolset.names[tool.Name] {
+			continue
+		}
+		toolset.names[tool.Name] = true
+		toolset.tools = append(toolset.tools, openai.Tool{
+			Type: openai.ToolTypeFunction,
+			Function: &openai.FunctionDefinition{
+				Name:        tool.Name,
+				Description: tool.Description,
+				Parameters:  mcpInputSchema(tool),
+			},
+		})
+	}
+	for _, tool := range clientTools {
+		if tool.Function != nil && toolset.names[tool.Function.Name] {
+			_ = client.Close()
+			return nil, common.NewErrorWithMessage(fmt.Sprintf("tool %q is both a request tool and an MCP tool", tool.Function.Name), "9e1b5d3c-7a4f-4b2e-a6c8-1d9f3b7e5a82")
+		}
+	}
+	return toolset, nil
+}
+
+func mcpInputSchema(tool mcp.Tool) any {
+	if len(tool.RawInputSchema) > 0 {
+		return tool.RawInputSchema
+	}
+	return tool.InputSchema
+}
+
+func (t *mcpToolset) close() {
+	if err := t.client.Close(); err != nil {
+		logger.GetLogger().Warnf("failed to close the MCP tool client: %v", err)
+	}
+}
+
+// canExecute reports whether every call targets a selected MCP tool, calls of the request tools go back to the client
+func (t *mcpToolset) canExecute(calls []openai.ToolCall) bool {
+	if len(calls) == 0 {
+		return false
+	}
+	for _, call := range calls {
+		if !t.names[call.Function.Name] {
+			return false
+		}
+	}
+	return true
+}
+
+// execute calls a tool. Failures are reported to the model through the output instead of aborting the completion.
+func (t *mcpToolset) execute(ctx context.Context, step int, call openai.ToolCall) responses.MCPToolCall {
+	started := time.Now()
+	executed := responses.MCPToolCall{
+		Step:      step,
+		CallID:    call.ID,
+		Name:      call.Function.Name,
+		Arguments: call.Function.Arguments,
+	}
+	output, isError := t.call(ctx, call)
+	if len(output) > maxMCPToolOutputChars {
+		output = output[:maxMCPToolOutputChars]
+	}
+	executed.Output = output
+	executed.IsError = isError
+	executed.LatencyMs = time.Since(started).Milliseconds()
+	return executed
+}
+
+func (t *mcpToolset) call(ctx context.Context, call openai.ToolCall) (string, bool) {
+	var arguments map[string]any
+	if strings.TrimSpace(call.Function.Arguments) != "" {
+		if err := json.Unmarshal([]byte(call.Function.Arguments), &arguments); err != nil {
+			return fmt.Sprintf("error: invalid arguments: %v", err), true
+		}
+	}
+
+	callCtx, cancel := context.WithTimeout(ctx, mcpToolCallTimeout)
+	defer cancel()
+	result, err := t.client.CallTool(callCtx, mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Name:      call.Function.Name,
+			Arguments: arguments,
+		},
+	})
+	if err != nil {
+		logger.GetLogger().Warnf("MCP tool %s failed: %v", call.Function.Name, err)
+		return fmt.Sprintf("error: %s", err.Error()), true
+	}
+	return mcpToolResultText(result), result.IsError
+}
+
+// mcpToolResultText flattens a tool result to the text fed back to the model
+func mcpToolResultText(result *mcp.CallToolResult) string {
+	parts := make([]string, 0, len(result.Content))
+	for _, content := range result.Content {
+		if text, ok := mcp.AsTextContent(content); ok {
+			parts = append(parts, text.Text)
+			continue
+		}
+		if encoded, err := json.Marshal(content); err == nil {
+			parts = append(parts, string(encoded))
+		}
+	}
+	if len(parts) == 0 && result.StructuredContent != nil {
+		if encoded, err := json.Marshal(result.StructuredContent); err == nil {
+			parts = append(parts, string(encoded))
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// mcpToolRoundMessages builds the assistant tool call turn and the tool outputs that follow it
+func mcpToolRoundMessages(message openai.ChatCompletionMessage, calls []responses.MCPToolCall) []openai.ChatCompletionMessage {
+	messages := make([]openai.ChatCompletionMessage, 0, len(calls)+1)
+	messages = append(messages, openai.ChatCompletionMessage{
+		Role:      openai.ChatMessageRoleAssistant,
+		Content:   message.Content,
+		ToolCalls: message.ToolCalls,
+	})
+	for _, call := range calls {
+		messages = append(messages, openai.ChatCompletionMessage{
+			Role:       openai.ChatMessageRoleTool,
+			Content:    call.Output,
+			Name:       call.Name,
+			ToolCallID: call.CallID,
+		})
+	}
+	return messages
+}
+
+func addUsage(total *openai.Usage, usage openai.Usage) {
+	total.PromptTokens += usage.PromptTokens
+	total.CompletionTokens += usage.CompletionTokens
+	total.TotalTokens += usage.TotalTokens
+}
+
+// CallCompletionWithMCPTools runs a non-streaming completion, calling the MCP tools requested by the model
+// until it answers or maxSteps tool rounds ran. The final answer is checked against the requested format.
+func (cApi *CompletionAPI) CallCompletionWithMCPTools(ctx context.Context, provider *domainmodel.Provider, apiKey string, request openai.ChatCompletionRequest, format *structuredoutput.Format, toolset *mcpToolset, maxSteps int) (*responses.ChatCompletionResponse, *structuredoutput.Result, *common.Error) {
+	var calls []responses.MCPToolCall
+	var spent openai.Usage
+	for step := 1; ; step++ {
+		response, err := cApi.CallCompletionAndGetRestResponse(ctx, provider, apiKey, request)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(response.Choices) == 0 || step > maxSteps || !toolset.canExecute(response.Choices[0].Message.ToolCalls) {
+			response, result, enforceErr := structuredoutput.Enforce(ctx, format, request, response, func(ctx context.Context, request openai.ChatCompletionRequest) (*openai.ChatCompletionResponse, error) {
+				repaired, err := cApi.CallCompletionAndGetRestResponse(ctx, provider, apiKey, request)
+				if err != nil {
+					return nil, err
+				}
+				return repaired, nil
+			})
+			if enforceErr != nil {
+				return nil, nil, common.NewError(enforceErr, "5a9c3e7b-2d1f-4e6a-8b4c-7f2e9a5d1c38")
+			}
+			addUsage(&response.Usage, spent)
+			return &responses.ChatCompletionResponse{
+				ChatCompletionResponse: *response,
+				MCPToolCalls:           calls,
+			}, result, nil
+		}
+
+		addUsage(&spent, response.Usage)
+		message := response.Choices[0].Message
+		round := make([]responses.MCPToolCall, 0, len(message.ToolCalls))
+		for _, call := range message.ToolCalls {
+			round = append(round, toolset.execute(ctx, step, call))
+		}
+		calls = append(calls, round...)
+		request.Messages = append(request.Messages, mcpToolRoundMessages(message, round)...)
+	}
+}
+
+// StreamCompletionWithMCPTools streams a completion, calling the MCP tools requested by the model until it answers
+// or maxSteps tool rounds ran. The text of every round is streamed, each tool call is announced with
+// mcp.tool_call.started and mcp.tool_call.completed events.
+func (cApi *CompletionAPI) StreamCompletionWithMCPTools(reqCtx *gin.Context, provider *domainmodel.Provider, apiKey string, request openai.ChatCompletionRequest, toolset *mcpToolset, maxSteps int) *common.Error {
+	chatClient, err := cApi.inferenceProvider.GetChatCompletionClient(provider)
+	if err != nil {
+		return common.NewError(err, "bc82d69c-685b-4556-9d1f-2a4a80ae8ca3")
+	}
+
+	stream := &mcpToolStream{model: request.Model, created: time.Now().Unix()}
+	for step := 1; ; step++ {
+		response, err := chatClient.StreamChatCompletionRoundToContext(reqCtx, apiKey, request, stream.forward)
+		if err != nil {
+			return common.NewError(err, "bc82d69c-685b-4556-9d1f-2a4a80ae8ca4")
+		}
+		message := response.Choices[0].Message
+		if step > maxSteps || !toolset.canExecute(message.ToolCalls) {
+			if err := stream.finish(reqCtx, message.ToolCalls); err != nil {
+				return common.NewError(err, "2e7b4d9a-6c1f-4a3e-b8d5-9f1c3a7e2b46")
+			}
+			return nil
+		}
+
+		round := make([]responses.MCPToolCall, 0, len(message.ToolCalls))
+		for _, call := range message.ToolCalls {
+			pending := responses.MCPToolCall{Step: step, CallID: call.ID, Name: call.Function.Name, Arguments: call.Function.Arguments}
+			if err := stream.writeEvent(reqCtx, mcpToolCallStartedEvent, pending); err != nil {
+				return common.NewError(err, "2e7b4d9a-6c1f-4a3e-b8d5-9f1c3a7e2b46")
+			}
+			executed := toolset.execute(reqCtx.Request.Context(), step, call)
+			if err := stream.writeEvent(reqCtx, mcpToolCallCompletedEvent, executed); err != nil {
+				return common.NewError(err, "2e7b4d9a-6c1f-4a3e-b8d5-9f1c3a7e2b46")
+			}
+			round = append(round, executed)
+		}
+		request.Messages = append(request.Messages, mcpToolRoundMessages(message, round)...)
+	}
+}
+
+// mcpToolStream merges the streams of the rounds of a tool loop into one completion stream.
+// The tool call chunks the gateway handles, the per-round usage and [DONE] markers are held back.
+type mcpToolStream struct {
+	id      string
+	model   string
+	created int64
+	usage   *openai.Usage
+}
+
+func (s *mcpToolStream) forward(line string) bool {
+	data, ok := strings.CutPrefix(line, "data: ")
+	if !ok {
+		return true
+	}
+	if data == "[DONE]" {
+		return false
+	}
+	var chunk openai.ChatCompletionStreamResponse
+	if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+		return true
+	}
+	if chunk.ID != "" {
+		s.id = chunk.ID
+	}
+	if chunk.Model != "" {
+		s.model = chunk.Model
+	}
+	if chunk.Created != 0 {
+		s.created = chunk.Created
+	}
+	if chunk.Usage != nil {
+		if s.usage == nil {
+			s.usage = &openai.Usage{}
+		}
+		addUsage(s.usage, *chunk.Usage)
+		if len(chunk.Choices) == 0 {
+			return false
+		}
+	}
+	for _, choice := range chunk.Choices {
+		if len(choice.Delta.ToolCalls) > 0 || choice.FinishReason == openai.FinishReasonToolCalls {
+			return false
+		}
+	}
+	return true
+}
+
+// finish writes the tool calls left to the client, the usage of all the rounds and the [DONE] marker
+func (s *mcpToolStream) finish(reqCtx *gin.Context, calls []openai.ToolCall) error {
+	if len(calls) > 0 {
+		toolCalls := make([]openai.ToolCall, len(calls))
+		for i, call := range calls {
+			index := i
+			call.Index = &index
+			toolCalls[i] = call
+		}
+		if err := s.writeChunk(reqCtx, []openai.ChatCompletionStreamChoice{{
+			Delta: openai.ChatCompletionStreamChoiceDelta{
+				Role:      openai.ChatMessageRoleAssistant,
+				ToolCalls: toolCalls,
+			},
+			FinishReason: openai.FinishReasonToolCalls,
+		}}, nil); err != nil {
+			return err
+		}
+	}
+	if s.usage != nil {
+		if err := s.writeChunk(reqCtx, []openai.ChatCompletionStreamChoice{}, s.usage); err != nil {
+			return err
+		}
+	}
+	return s.write(reqCtx, "data: [DONE]\n\n")
+}
+
+func (s *mcpToolStream) writeChunk(reqCtx *gin.Context, choices []openai.ChatCompletionStreamChoice, usage *openai.Usage) error {
+	encoded, err := json.Marshal(openai.ChatCompletionStreamResponse{
+		ID:      s.id,
+		Object:  "chat.completion.chunk",
+		Created: s.created,
+		Model:   s.model,
+		Choices: choices,
+		Usage:   usage,
+	})
+	if err != nil {
+		return err
+	}
+	return s.write(reqCtx, "data: "+string(encoded)+"\n\n")
+}
+
+func (s *mcpToolStream) writeEvent(reqCtx *gin.Context, event string, call responses.MCPToolCall) error {
+	encoded, err := json.Marshal(responses.MCPToolCallEvent{Type: event, MCPToolCall: call})
+	if err != nil {
+		return err
+	}
+	return s.write(reqCtx, fmt.Sprintf("event: %s\ndata: %s\n\n", event, encoded))
+}
+
+func (s *mcpToolStream) write(reqCtx *gin.Context, payload string) error {
+	if _, err := reqCtx.Writer.WriteString(payload); err != nil {
+		return err
+	}
+	reqCtx.Writer.Flush()
+	return nil
+}
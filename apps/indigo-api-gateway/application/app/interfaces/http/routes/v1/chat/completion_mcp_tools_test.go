@@ -0,0 +1,65 @@
+package chat
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	mcpserver "github.com/mark3labs/mcp-go/server"
+	openai "github.com/sashabaranov/go-openai"
+	mcpimpl "menlo.ai/indigo-api-gateway/app/interfaces/http/routes/v1/mcp/mcp_impl"
+)
+
+func TestMCPToolsetExecute(t *testing.T) {
+	server := mcpserver.NewMCPServer("test", "1.0.0", mcpserver.WithToolCapabilities(true))
+	server.AddTool(mcp.NewTool("echo", mcp.WithString("text", mcp.Required())), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		text, err := request.RequireString("text")
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+	client, err := mcpimpl.ConnectInProcess(context.Background(), server)
+	if err != nil {
+		t.Fatal(err)
+	}
+	toolset := &mcpToolset{client: client, names: map[string]bool{"echo": true}}
+	defer toolset.close()
+
+	echo := openai.ToolCall{ID: "call_1", Function: openai.FunctionCall{Name: "echo", Arguments: `{"text":"hello"}`}}
+	if toolset.canExecute([]openai.ToolCall{echo, {Function: openai.FunctionCall{Name: "get_weather"}}}) {
+		t.Error("calls of request tools must go back to the client")
+	}
+	if !toolset.canExecute([]openai.ToolCall{echo}) {
+		t.Error("calls of MCP tools must run in the gateway")
+	}
+
+	executed := toolset.execute(context.Background(), 1, echo)
+	if executed.IsError || executed.Output != "hello" || executed.CallID != "call_1" {
+		t.Errorf("execute() = %+v", executed)
+	}
+	failed := toolset.execute(context.Background(), 1, openai.ToolCall{Function: openai.FunctionCall{Name: "echo", Arguments: `{}`}})
+	if !failed.IsError {
+		t.Errorf("execute() without the required argument = %+v, want an error", failed)
+	}
+}
+
+func TestMCPToolStreamForward(t *testing.T) {
+	stream := &mcpToolStream{}
+	cases := map[string]bool{
+		"": true,
+		`data: {"id":"c1","model":"m","choices":[{"index":0,"delta":{"content":"Hi"}}]}`:                                                            true,
+		`data: {"id":"c1","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"echo"}}]}}]}`: false,
+		`data: {"id":"c1","choices":[{"index":0,"delta":{},"finish_reason":"tool_calls"}]}`:                                                         false,
+		`data: {"id":"c1","choices":[],"usage":{"prompt_tokens":3,"completion_tokens":2,"total_tokens":5}}`:                                         false,
+		`data: [DONE]`: false,
+	}
+	for line, forwarded := range cases {
+		if got := stream.forward(line); got != forwarded {
+			t.Errorf("forward(%q) = %v, want %v", line, got, forwarded)
+		}
+	}
+	if stream.id != "c1" || stream.model != "m" || stream.usage == nil || stream.usage.TotalTokens != 5 {
+		t.Errorf("stream state = %+v", stream)
+	}
+}
@@ -2,15 +2,23 @@ package chat
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 	openai "github.com/sashabaranov/go-openai"
+	"menlo.ai/indigo-api-gateway/app/domain/auth"
 	"menlo.ai/indigo-api-gateway/app/domain/common"
+	"menlo.ai/indigo-api-gateway/app/domain/completioncache"
 	domainmodel "menlo.ai/indigo-api-gateway/app/domain/model"
 	"menlo.ai/indigo-api-gateway/app/domain/organization"
+	"menlo.ai/indigo-api-gateway/app/domain/structuredoutput"
 	"menlo.ai/indigo-api-gateway/app/infrastructure/inference"
+	"menlo.ai/indigo-api-gateway/app/interfaces/http/requests"
 	"menlo.ai/indigo-api-gateway/app/interfaces/http/responses"
+	mcpimpl "menlo.ai/indigo-api-gateway/app/interfaces/http/routes/v1/mcp/mcp_impl"
 	"menlo.ai/indigo-api-gateway/app/utils/logger"
 )
 
@@ -18,15 +26,24 @@ import (
 type CompletionAPI struct {
 	inferenceProvider *inference.InferenceProvider
 	providerRegistry  *domainmodel.ProviderRegistryService
+	authService       *auth.AuthService
+	federatedMCP      *mcpimpl.FederatedMCP
+	completionCache   *completioncache.CompletionCacheService
 }
 
 func NewCompletionAPI(
 	inferenceProvider *inference.InferenceProvider,
 	providerRegistry *domainmodel.ProviderRegistryService,
+	authService *auth.AuthService,
+	federatedMCP *mcpimpl.FederatedMCP,
+	completionCache *completioncache.CompletionCacheService,
 ) *CompletionAPI {
 	return &CompletionAPI{
 		inferenceProvider: inferenceProvider,
 		providerRegistry:  providerRegistry,
+		authService:       authService,
+		federatedMCP:      federatedMCP,
+		completionCache:   completionCache,
 	}
 }
 
@@ -52,27 +69,41 @@ func (completionAPI *CompletionAPI) RegisterRouter(router *gin.RouterGroup) {
 // @Description - User authentication required
 // @Description - Direct inference model integration
 // @Description - No conversation persistence (stateless)
+// @Description - Non-streaming outputs requested with response_format json_object or json_schema are validated; invalid outputs are retried with the validation errors up to STRUCTURED_OUTPUT_REPAIR_ATTEMPTS times and rejected with 422 when still invalid
+// @Description - response_format json_schema cannot be streamed, streaming requests with it are rejected with 400
+// @Description
+// @Description **Gateway MCP tools (mcp_tools):**
+// @Description - Lists tools of the /v1/mcp endpoint, or "*" for all of them, that the gateway calls on behalf of the model; requires authentication and honours the tool allowlist of the project of the API key
+// @Description - The tool loop runs until the model answers or max_tool_steps rounds ran (default 5, at most 10); calls of the request tools are returned to the client as usual
+// @Description - Non-streaming responses list the calls in mcp_tool_calls and sum the usage of every round
+// @Description - Streaming responses announce each call with `event: mcp.tool_call.started` and `event: mcp.tool_call.completed` SSE events between the completion chunks
+// @Description
+// @Description **Completion cache:**
+// @Description - When the project of the API key enables its cache, deterministic requests (temperature 0 or a seed, a single choice) are served from the cache
+// @Description - The x-cache response header is hit or miss; a cached completion is replayed as SSE chunks for streaming requests
 // @Tags Chat Completions API
 // @Security BearerAuth
 // @Accept json
 // @Produce json
 // @Produce text/event-stream
-// @Param request body openai.ChatCompletionRequest true "Chat completion request with streaming options"
-// @Success 200 {object} openai.ChatCompletionResponse "Successful non-streaming response (when stream=false)"
+// @Param request body requests.ChatCompletionRequest true "Chat completion request with streaming options"
+// @Success 200 {object} responses.ChatCompletionResponse "Successful non-streaming response (when stream=false)"
 // @Success 200 {string} string "Successful streaming response (when stream=true) - SSE format with data: {json} events"
 // @Failure 400 {object} responses.ErrorResponse "Invalid request payload, empty messages, or inference failure"
-// @Failure 401 {object} responses.ErrorResponse "Unauthorized - missing or invalid authentication"
+// @Failure 401 {object} responses.ErrorResponse "Unauthorized - missing or invalid authentication when mcp_tools is set"
+// @Failure 422 {object} responses.ErrorResponse "Model output does not match the requested response_format"
 // @Failure 500 {object} responses.ErrorResponse "Internal server error"
 // @Router /v1/chat/completions [post]
 func (cApi *CompletionAPI) PostCompletion(reqCtx *gin.Context) {
-	var request openai.ChatCompletionRequest
-	if err := reqCtx.ShouldBindJSON(&request); err != nil {
+	var chatRequest requests.ChatCompletionRequest
+	if err := reqCtx.ShouldBindBodyWith(&chatRequest, binding.JSON); err != nil {
 		reqCtx.AbortWithStatusJSON(http.StatusBadRequest, responses.ErrorResponse{
 			Code:          "0199600b-86d3-7339-8402-8ef1c7840475",
 			ErrorInstance: err,
 		})
 		return
 	}
+	request := chatRequest.ChatCompletionRequest
 
 	if len(request.Messages) == 0 {
 		reqCtx.AbortWithStatusJSON(http.StatusBadRequest, responses.ErrorResponse{
@@ -92,13 +123,56 @@ func (cApi *CompletionAPI) PostCompletion(reqCtx *gin.Context) {
 		return
 	}
 
+	format, formatErr := structuredoutput.FormatFromChatRequest(&request)
+	if formatErr == nil {
+		formatErr = structuredoutput.CheckStreaming(format, request.Stream)
+	}
+	if formatErr != nil {
+		reqCtx.AbortWithStatusJSON(http.StatusBadRequest, responses.ErrorResponse{
+			Code:  formatErr.GetCode(),
+			Error: formatErr.GetMessage(),
+		})
+		return
+	}
+
+	if len(chatRequest.MCPTools) > 0 {
+		cApi.postCompletionWithMCPTools(reqCtx, provider, chatRequest, format)
+		return
+	}
+
+	lookup := cApi.lookupCompletionCache(reqCtx, request)
+	if lookup.Hit() {
+		reqCtx.Header("x-cache", "hit")
+		if request.Stream {
+			includeUsage := request.StreamOptions != nil && request.StreamOptions.IncludeUsage
+			if err := writeCachedStream(reqCtx, completioncache.StreamChunks(lookup.Response, includeUsage)); err != nil {
+				logger.GetLogger().Errorf("failed to replay cached completion: %v", err)
+			}
+			return
+		}
+		reqCtx.JSON(http.StatusOK, lookup.Response)
+		return
+	}
+	if lookup != nil {
+		reqCtx.Header("x-cache", "miss")
+	}
+
 	var err *common.Error
 	var response *openai.ChatCompletionResponse
 
 	if request.Stream {
-		err = cApi.StreamCompletionResponse(reqCtx, provider, "", request)
+		response, err = cApi.StreamCompletionResponse(reqCtx, provider, "", request)
 	} else {
-		response, err = cApi.CallCompletionAndGetRestResponse(reqCtx.Request.Context(), provider, "", request)
+		var result *structuredoutput.Result
+		response, result, err = cApi.CallStructuredCompletion(reqCtx.Request.Context(), provider, "", request, format)
+		if err == nil && !result.Valid() {
+			validationErr := structuredoutput.ValidationFailedError(result)
+			reqCtx.AbortWithStatusJSON(http.StatusUnprocessableEntity, responses.ErrorResponse{
+				Code:  validationErr.GetCode(),
+				Error: validationErr.GetMessage(),
+			})
+			return
+		}
 	}
 
 	if err != nil {
@@ -112,11 +186,141 @@ func (cApi *CompletionAPI) PostCompletion(reqCtx *gin.Context) {
 		return
 	}
 
+	if lookup != nil {
+		if storeErr := cApi.completionCache.Store(reqCtx.Request.Context(), lookup, response); storeErr != nil {
+			logger.GetLogger().Errorf("failed to cache completion: %v", storeErr)
+		}
+	}
+
 	if !request.Stream {
 		reqCtx.JSON(http.StatusOK, response)
 	}
 }
 
+// lookupCompletionCache returns the cache lookup of a deterministic request sent with the API key of a project
+// that enables its cache, nil when the request is not cached
+func (cApi *CompletionAPI) lookupCompletionCache(reqCtx *gin.Context, request openai.ChatCompletionRequest) *completioncache.Lookup {
+	if cApi.completionCache == nil {
+		return nil
+	}
+	body, ok := reqCtx.Get(gin.BodyBytesKey)
+	if !ok {
+		return nil
+	}
+	bodyBytes, ok := body.([]byte)
+	if !ok {
+		return nil
+	}
+	cacheRequest, ok := completioncache.NewRequest(bodyBytes, request)
+	if !ok || !cApi.authService.AuthenticateAppUser(reqCtx) {
+		return nil
+	}
+	projectID := auth.GetRequestProjectID(reqCtx)
+	if projectID == nil {
+		return nil
+	}
+	ctx := reqCtx.Request.Context()
+	settings, settingsErr := cApi.completionCache.GetSettings(ctx, *projectID)
+	if settingsErr != nil {
+		logger.GetLogger().Errorf("failed to load completion cache settings: %v", settingsErr)
+		return nil
+	}
+	if !settings.Enabled {
+		return nil
+	}
+	lookup, err := cApi.completionCache.Lookup(ctx, settings, cacheRequest)
+	if err != nil {
+		logger.GetLogger().Errorf("completion cache lookup failed: %v", err)
+	}
+	return lookup
+}
+
+// writeCachedStream replays the chunks of a cached completion as SSE events
+func writeCachedStream(reqCtx *gin.Context, chunks []openai.ChatCompletionStreamResponse) error {
+	reqCtx.Header("Content-Type", "text/event-stream")
+	reqCtx.Header("Cache-Control", "no-cache")
+	reqCtx.Header("Connection", "keep-alive")
+	reqCtx.Status(http.StatusOK)
+	for _, chunk := range chunks {
+		encoded, err := json.Marshal(chunk)
+		if err != nil {
+			return err
+		}
+		if _, err := reqCtx.Writer.WriteString("data: " + string(encoded) + "\n\n"); err != nil {
+			return err
+		}
+	}
+	if _, err := reqCtx.Writer.WriteString("data: [DONE]\n\n"); err != nil {
+		return err
+	}
+	reqCtx.Writer.Flush()
+	return nil
+}
+
+// postCompletionWithMCPTools serves a completion whose tool calls to the gateway MCP tools are run by the gateway
+func (cApi *CompletionAPI) postCompletionWithMCPTools(reqCtx *gin.Context, provider *domainmodel.Provider, chatRequest requests.ChatCompletionRequest, format *structuredoutput.Format) {
+	if !cApi.authService.AuthenticateAppUser(reqCtx) {
+		reqCtx.AbortWithStatusJSON(http.StatusUnauthorized, responses.ErrorResponse{
+			Code:  "6c2f8a4e-1b7d-4e9a-a3c5-8d1e6b4f9a27",
+			Error: "mcp_tools requires authentication",
+		})
+		return
+	}
+	maxSteps := DefaultMCPToolSteps
+	if chatRequest.MaxToolSteps != nil {
+		maxSteps = *chatRequest.MaxToolSteps
+		if maxSteps < 1 || maxSteps > MaxMCPToolSteps {
+			reqCtx.AbortWithStatusJSON(http.StatusBadRequest, responses.ErrorResponse{
+				Code:  "1d7e3b9f-4a2c-4f6e-8b1d-3e9a7c5f2b84",
+				Error: fmt.Sprintf("max_tool_steps must be between 1 and %d", MaxMCPToolSteps),
+			})
+			return
+		}
+	}
+
+	request := chatRequest.ChatCompletionRequest
+	toolset, toolsErr := cApi.connectMCPTools(reqCtx.Request.Context(), auth.GetRequestProjectID(reqCtx), chatRequest.MCPTools, request.Tools)
+	if toolsErr != nil {
+		reqCtx.AbortWithStatusJSON(http.StatusBadRequest, responses.ErrorResponse{
+			Code:  toolsErr.GetCode(),
+			Error: toolsErr.GetMessage(),
+		})
+		return
+	}
+	defer toolset.close()
+	request.Tools = append(request.Tools, toolset.tools...)
+
+	if request.Stream {
+		if err := cApi.StreamCompletionWithMCPTools(reqCtx, provider, "", request, toolset, maxSteps); err != nil {
+			logger.GetLogger().Errorf("completion failed: %v", err)
+			reqCtx.AbortWithStatusJSON(http.StatusBadRequest, responses.ErrorResponse{
+				Code:          err.GetCode(),
+				ErrorInstance: err.GetError(),
+			})
+		}
+		return
+	}
+
+	response, result, err := cApi.CallCompletionWithMCPTools(reqCtx.Request.Context(), provider, "", request, format, toolset, maxSteps)
+	if err != nil {
+		logger.GetLogger().Errorf("completion failed: %v", err)
+		reqCtx.AbortWithStatusJSON(http.StatusBadRequest, responses.ErrorResponse{
+			Code:          err.GetCode(),
+			ErrorInstance: err.GetError(),
+		})
+		return
+	}
+	if !result.Valid() {
+		validationErr := structuredoutput.ValidationFailedError(result)
+		reqCtx.AbortWithStatusJSON(http.StatusUnprocessableEntity, responses.ErrorResponse{
+			Code:  validationErr.GetCode(),
+			Error: validationErr.GetMessage(),
+		})
+		return
+	}
+	reqCtx.JSON(http.StatusOK, response)
+}
+
 // CallCompletionAndGetRestResponse calls the shared chat client and returns a complete non-streaming response.
 func (cApi *CompletionAPI) CallCompletionAndGetRestResponse(ctx context.Context, provider *domainmodel.Provider, apiKey string, request openai.ChatCompletionRequest) (*openai.ChatCompletionResponse, *common.Error) {
 	chatClient, err := cApi.inferenceProvider.GetChatCompletionClient(provider)
@@ -134,15 +338,35 @@ func (cApi *CompletionAPI) CallCompletionAndGetRestResponse(ctx context.Context,
 	return response, nil
 }
 
-// StreamCompletionResponse streams SSE events directly to the client via the shared chat client.
-func (cApi *CompletionAPI) StreamCompletionResponse(reqCtx *gin.Context, provider *domainmodel.Provider, apiKey string, request openai.ChatCompletionRequest) *common.Error {
+// CallStructuredCompletion runs a non-streaming completion and enforces the requested JSON format on its output
+func (cApi *CompletionAPI) CallStructuredCompletion(ctx context.Context, provider *domainmodel.Provider, apiKey string, request openai.ChatCompletionRequest, format *structuredoutput.Format) (*openai.ChatCompletionResponse, *structuredoutput.Result, *common.Error) {
+	response, err := cApi.CallCompletionAndGetRestResponse(ctx, provider, apiKey, request)
+	if err != nil {
+		return nil, nil, err
+	}
+	response, result, repairErr := structuredoutput.Enforce(ctx, format, request, response, func(ctx context.Context, request openai.ChatCompletionRequest) (*openai.ChatCompletionResponse, error) {
+		repaired, err := cApi.CallCompletionAndGetRestResponse(ctx, provider, apiKey, request)
+		if err != nil {
+			return nil, err
+		}
+		return repaired, nil
+	})
+	if repairErr != nil {
+		return nil, nil, common.NewError(repairErr, "7d2f9b4e-1a6c-4e3d-8b5f-2c9e7a4d1b86")
+	}
+	return response, result, nil
+}
+
+// StreamCompletionResponse streams SSE events directly to the client via the shared chat client and returns the assembled completion.
+func (cApi *CompletionAPI) StreamCompletionResponse(reqCtx *gin.Context, provider *domainmodel.Provider, apiKey string, request openai.ChatCompletionRequest) (*openai.ChatCompletionResponse, *common.Error) {
 	chatClient, err := cApi.inferenceProvider.GetChatCompletionClient(provider)
 	if err != nil {
-		return common.NewError(err, "bc82d69c-685b-4556-9d1f-2a4a80ae8ca3")
+		return nil, common.NewError(err, "bc82d69c-685b-4556-9d1f-2a4a80ae8ca3")
 	}
 
-	if _, err := chatClient.StreamChatCompletionToContext(reqCtx, apiKey, request); err != nil {
-		return common.NewError(err, "bc82d69c-685b-4556-9d1f-2a4a80ae8ca4")
+	response, err := chatClient.StreamChatCompletionToContext(reqCtx, apiKey, request)
+	if err != nil {
+		return nil, common.NewError(err, "bc82d69c-685b-4556-9d1f-2a4a80ae8ca4")
 	}
-	return nil
+	return response, nil
 }
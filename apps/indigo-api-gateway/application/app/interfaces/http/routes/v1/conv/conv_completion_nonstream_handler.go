@@ -7,6 +7,7 @@ import (
 	"menlo.ai/indigo-api-gateway/app/domain/common"
 	"menlo.ai/indigo-api-gateway/app/domain/conversation"
 	domainmodel "menlo.ai/indigo-api-gateway/app/domain/model"
+	"menlo.ai/indigo-api-gateway/app/domain/structuredoutput"
 	"menlo.ai/indigo-api-gateway/app/infrastructure/inference"
 )
 
@@ -39,6 +40,27 @@ func (uc *CompletionNonStreamHandler) CallCompletionAndGetRestResponse(ctx conte
 	return uc.ConvertResponse(response), nil
 }
 
+// CallStructuredCompletion calls the chat completion client and enforces the requested JSON format on the output
+func (uc *CompletionNonStreamHandler) CallStructuredCompletion(ctx context.Context, provider *domainmodel.Provider, apiKey string, request openai.ChatCompletionRequest, format *structuredoutput.Format) (*ExtendedCompletionResponse, *structuredoutput.Result, *common.Error) {
+	chatClient, err := uc.inferenceProvider.GetChatCompletionClient(provider)
+	if err != nil {
+		return nil, nil, common.NewError(err, "c7d8e9f0-g1h2-3456-cdef-789012345677")
+	}
+	complete := func(ctx context.Context, request openai.ChatCompletionRequest) (*openai.ChatCompletionResponse, error) {
+		return chatClient.CreateChatCompletion(ctx, apiKey, request)
+	}
+
+	response, err := complete(ctx, request)
+	if err != nil {
+		return nil, nil, common.NewError(err, "c7d8e9f0-g1h2-3456-cdef-789012345678")
+	}
+	response, result, err := structuredoutput.Enforce(ctx, format, request, response, complete)
+	if err != nil {
+		return nil, nil, common.NewError(err, "3a7e1c9f-5d2b-4f8a-9e6c-1b4d7a3f9e52")
+	}
+	return uc.ConvertResponse(response), result, nil
+}
+
 // ConvertResponse converts OpenAI response to our extended response
 func (uc *CompletionNonStreamHandler) ConvertResponse(response *openai.ChatCompletionResponse) *ExtendedCompletionResponse {
 	return &ExtendedCompletionResponse{
@@ -11,19 +11,23 @@ import (
 	openai "github.com/sashabaranov/go-openai"
 	"menlo.ai/indigo-api-gateway/app/domain/auth"
 	"menlo.ai/indigo-api-gateway/app/domain/common"
+	"menlo.ai/indigo-api-gateway/app/domain/contextwindow"
 	"menlo.ai/indigo-api-gateway/app/domain/conversation"
+	"menlo.ai/indigo-api-gateway/app/domain/conversationtitle"
 	domainmodel "menlo.ai/indigo-api-gateway/app/domain/model"
 	"menlo.ai/indigo-api-gateway/app/domain/project"
+	"menlo.ai/indigo-api-gateway/app/domain/structuredoutput"
 	userdomain "menlo.ai/indigo-api-gateway/app/domain/user"
 	"menlo.ai/indigo-api-gateway/app/infrastructure/inference"
 	"menlo.ai/indigo-api-gateway/app/interfaces/http/responses"
 	modelroute "menlo.ai/indigo-api-gateway/app/interfaces/http/routes/v1/model"
 	"menlo.ai/indigo-api-gateway/app/utils/idgen"
 	"menlo.ai/indigo-api-gateway/app/utils/logger"
+	"menlo.ai/indigo-api-gateway/app/utils/ptr"
 )
 
 const (
-	DefaultConversationTitle = "New Conversation"
+	DefaultConversationTitle = conversation.DefaultConversationTitle
 	MaxTitleLength           = 50
 )
 
@@ -36,6 +40,8 @@ type ConvCompletionAPI struct {
 	providerRegistry           *domainmodel.ProviderRegistryService
 	providerModelService       *domainmodel.ProviderModelService
 	inferenceProvider          *inference.InferenceProvider
+	contextWindowService       *contextwindow.ContextWindowService
+	titleService               *conversationtitle.TitleService
 }
 
 func NewConvCompletionAPI(
@@ -47,6 +53,8 @@ func NewConvCompletionAPI(
 	providerRegistry *domainmodel.ProviderRegistryService,
 	providerModelService *domainmodel.ProviderModelService,
 	inferenceProvider *inference.InferenceProvider,
+	contextWindowService *contextwindow.ContextWindowService,
+	titleService *conversationtitle.TitleService,
 ) *ConvCompletionAPI {
 	return &ConvCompletionAPI{
 		completionNonStreamHandler: completionNonStreamHandler,
@@ -57,6 +65,8 @@ func NewConvCompletionAPI(
 		providerRegistry:           providerRegistry,
 		providerModelService:       providerModelService,
 		inferenceProvider:          inferenceProvider,
+		contextWindowService:       contextWindowService,
+		titleService:               titleService,
 	}
 }
 
@@ -76,9 +86,10 @@ func (completionAPI *ConvCompletionAPI) RegisterRouter(router *gin.RouterGroup)
 // ExtendedChatCompletionRequest extends OpenAI's request with conversation field and store and store_reasoning fields
 type ExtendedChatCompletionRequest struct {
 	openai.ChatCompletionRequest
-	Conversation   string `json:"conversation,omitempty"`
-	Store          bool   `json:"store,omitempty"`           // If true, the response will be stored in the conversation, default is false
-	StoreReasoning bool   `json:"store_reasoning,omitempty"` // If true, the reasoning will be stored in the conversation, default is false
+	Conversation      string                        `json:"conversation,omitempty"`
+	Store             bool                          `json:"store,omitempty"`              // If true, the response will be stored in the conversation, default is false
+	StoreReasoning    bool                          `json:"store_reasoning,omitempty"`    // If true, the reasoning will be stored in the conversation, default is false
+	ContextManagement *conversation.ContextSettings `json:"context_management,omitempty"` // Overrides the workspace context strategy for this request
 }
 
 // ResponseMetadata contains additional metadata about the completion response
@@ -114,6 +125,7 @@ type ModelsWithProviderResponse = modelroute.ModelsWithProviderResponse
 // @Description - Returns Server-Sent Events (SSE) with real-time streaming
 // @Description - First event contains conversation metadata
 // @Description - Subsequent events contain completion chunks
+// @Description - A `conversation.title` event follows when a title was generated for the conversation in time
 // @Description - Final event contains "[DONE]" marker
 // @Description
 // @Description **Non-Streaming Mode (stream=false or omitted):**
@@ -125,11 +137,24 @@ type ModelsWithProviderResponse = modelroute.ModelsWithProviderResponse
 // @Description - `store_reasoning=true`: Includes reasoning content in stored messages
 // @Description - `conversation`: ID of existing conversation or empty for new conversation
 // @Description
+// @Description **Context Management:**
+// @Description - History that exceeds the model context window is fitted before it is sent upstream
+// @Description - `context_management.strategy`: `truncate_oldest` (default), `keep_last_n`, `summarize` or `none`
+// @Description - `context_management.keep_last_n`: number of recent messages kept by `keep_last_n`
+// @Description - `context_management.summary_model`: model used by `summarize`; summaries are stored as `context_summary` items
+// @Description - Unset fields fall back to the conversation's workspace settings
+// @Description
+// @Description **Structured Outputs:**
+// @Description - Non-streaming outputs requested with response_format `json_object` or `json_schema` are validated against the schema
+// @Description - response_format `json_schema` cannot be streamed, streaming requests with it are rejected with 400
+// @Description - Invalid outputs are retried with the validation errors up to STRUCTURED_OUTPUT_REPAIR_ATTEMPTS times, then rejected with 422 and not stored
+// @Description
 // @Description **Features:**
 // @Description - Conversation persistence and history management
 // @Description - Extended request format with conversation and storage options
 // @Description - User authentication required
 // @Description - Automatic conversation creation and management
+// @Description - Titles for new conversations are generated after the first exchange by the organization's title model
 // @Tags Conversation-aware Chat API
 // @Security BearerAuth
 // @Accept json
@@ -141,6 +166,7 @@ type ModelsWithProviderResponse = modelroute.ModelsWithProviderResponse
 // @Failure 400 {object} responses.ErrorResponse "Invalid request payload or conversation not found"
 // @Failure 401 {object} responses.ErrorResponse "Unauthorized - missing or invalid authentication"
 // @Failure 404 {object} responses.ErrorResponse "Conversation not found or user not found"
+// @Failure 422 {object} responses.ErrorResponse "Model output does not match the requested response_format"
 // @Failure 500 {object} responses.ErrorResponse "Internal server error"
 // @Router /v1/conv/chat/completions [post]
 func (api *ConvCompletionAPI) PostCompletion(reqCtx *gin.Context) {
@@ -153,6 +179,18 @@ func (api *ConvCompletionAPI) PostCompletion(reqCtx *gin.Context) {
 		return
 	}
 
+	format, formatErr := structuredoutput.FormatFromChatRequest(&request.ChatCompletionRequest)
+	if formatErr == nil {
+		formatErr = structuredoutput.CheckStreaming(format, request.Stream)
+	}
+	if formatErr != nil {
+		reqCtx.AbortWithStatusJSON(http.StatusBadRequest, responses.ErrorResponse{
+			Code:  formatErr.GetCode(),
+			Error: formatErr.GetMessage(),
+		})
+		return
+	}
+
 	// Get user ID for saving messages
 	user, ok := auth.GetUserFromContext(reqCtx)
 	if !ok {
@@ -189,7 +227,7 @@ func (api *ConvCompletionAPI) PostCompletion(reqCtx *gin.Context) {
 	}
 
 	// Handle conversation management
-	conv, conversationCreated, convErr := api.handleConversationManagement(reqCtx, request.Conversation, request.Messages)
+	conv, conversationCreated, titlePending, convErr := api.handleConversationManagement(reqCtx, request.Conversation, request.Messages)
 	if convErr != nil {
 		// Conversation doesn't exist, return error
 		reqCtx.AbortWithStatusJSON(http.StatusNotFound, responses.ErrorResponse{
@@ -199,6 +237,31 @@ func (api *ConvCompletionAPI) PostCompletion(reqCtx *gin.Context) {
 		return
 	}
 
+	// Fit the history into the model context window before calling upstream
+	maxTokens := request.MaxCompletionTokens
+	if maxTokens == 0 {
+		maxTokens = request.MaxTokens
+	}
+	fitResult, fitErr := api.contextWindowService.FitMessages(reqCtx.Request.Context(), contextwindow.FitInput{
+		OrganizationID: orgID,
+		ProjectIDs:     projectIDs,
+		Provider:       provider,
+		Model:          request.Model,
+		Messages:       request.Messages,
+		MaxTokens:      maxTokens,
+		Settings:       request.ContextManagement,
+		Conversation:   conv,
+	})
+	if fitErr != nil {
+		reqCtx.AbortWithStatusJSON(http.StatusBadRequest, responses.ErrorResponse{
+			Code:          fitErr.GetCode(),
+			ErrorInstance: fitErr.GetError(),
+		})
+		return
+	}
+	upstreamRequest := request.ChatCompletionRequest
+	upstreamRequest.Messages = fitResult.Messages
+
 	// Generate item IDs for tracking
 	askItemID, _ := idgen.GenerateSecureID("msg", 42)
 	completionItemID, _ := idgen.GenerateSecureID("msg", 42)
@@ -209,10 +272,19 @@ func (api *ConvCompletionAPI) PostCompletion(reqCtx *gin.Context) {
 
 	if request.Stream {
 		// Handle streaming completion - streams SSE events and accumulates response
-		response, err = api.completionStreamHandler.StreamCompletionAndAccumulateResponse(reqCtx, provider, "", request.ChatCompletionRequest, conv, conversationCreated, askItemID, completionItemID)
+		response, err = api.completionStreamHandler.StreamCompletionAndAccumulateResponse(reqCtx, provider, "", upstreamRequest, fitResult.Counter, conv, conversationCreated, askItemID, completionItemID)
 	} else {
 		// Handle non-streaming completion
-		response, err = api.completionNonStreamHandler.CallCompletionAndGetRestResponse(reqCtx.Request.Context(), provider, "", request.ChatCompletionRequest)
+		var result *structuredoutput.Result
+		response, result, err = api.completionNonStreamHandler.CallStructuredCompletion(reqCtx.Request.Context(), provider, "", upstreamRequest, format)
+		if err == nil && !result.Valid() {
+			validationErr := structuredoutput.ValidationFailedError(result)
+			reqCtx.AbortWithStatusJSON(http.StatusUnprocessableEntity, responses.ErrorResponse{
+				Code:  validationErr.GetCode(),
+				Error: validationErr.GetMessage(),
+			})
+			return
+		}
 	}
 
 	if err != nil {
@@ -228,8 +300,30 @@ func (api *ConvCompletionAPI) PostCompletion(reqCtx *gin.Context) {
 	// Process response (common logic for both streaming and non-streaming)
 	modifiedResponse := api.processCompletionResponse(reqCtx, response, request, conv, user, askItemID, completionItemID, conversationCreated)
 
+	// Replace the placeholder title once the first exchange is complete
+	var titleChan <-chan string
+	if titlePending && modifiedResponse != nil {
+		titleChan = api.titleService.GenerateAsync(reqCtx.Request.Context(), conversationtitle.TitleInput{
+			OrganizationID:   orgID,
+			ProjectIDs:       projectIDs,
+			Conversation:     conv,
+			PlaceholderTitle: ptr.FromString(conv.Title),
+			UserMessage:      firstUserMessage(request.Messages),
+			AssistantMessage: firstChoiceContent(response),
+			Provider:         provider,
+			Model:            request.Model,
+		})
+	}
+
+	if request.Stream {
+		if err := api.completionStreamHandler.FinishStream(reqCtx, conv, titleChan); err != nil {
+			logger.GetLogger().Errorf("unable to finish completion stream: %v", err)
+		}
+		return
+	}
+
 	// Only send JSON response for non-streaming requests (streaming uses SSE)
-	if !request.Stream && modifiedResponse != nil {
+	if modifiedResponse != nil {
 		reqCtx.JSON(http.StatusOK, modifiedResponse)
 	}
 }
@@ -327,23 +421,26 @@ func (api *ConvCompletionAPI) processCompletionResponse(reqCtx *gin.Context, res
 	return api.completionNonStreamHandler.ModifyCompletionResponse(response, conv, conversationCreated, assistantItem, askItemID, completionItemID, request.Store, request.StoreReasoning)
 }
 
-// handleConversationManagement handles conversation loading or creation and returns conversation, created flag, and error
-func (api *ConvCompletionAPI) handleConversationManagement(reqCtx *gin.Context, conversationID string, messages []openai.ChatCompletionMessage) (*conversation.Conversation, bool, *common.Error) {
+// handleConversationManagement handles conversation loading or creation and returns conversation, created flag,
+// whether the conversation carries a placeholder title that should be generated, and error
+func (api *ConvCompletionAPI) handleConversationManagement(reqCtx *gin.Context, conversationID string, messages []openai.ChatCompletionMessage) (*conversation.Conversation, bool, bool, *common.Error) {
 	if conversationID != "" {
 		// Try to load existing conversation
 		conv, convErr := api.loadConversation(reqCtx, conversationID)
 		if convErr != nil {
-			return nil, false, convErr
+			return nil, false, false, convErr
 		}
+		titlePending := false
 		if conv.Title == nil || *conv.Title == "" || *conv.Title == DefaultConversationTitle {
 			title := api.generateTitleFromMessages(messages)
 			conv.Title = &title
+			titlePending = true
 		}
-		return conv, false, nil
+		return conv, false, titlePending, nil
 	} else {
 		// Create new conversation
 		conv, conversationCreated := api.createNewConversation(reqCtx, messages)
-		return conv, conversationCreated, nil
+		return conv, conversationCreated, conversationCreated, nil
 	}
 }
 
@@ -392,25 +489,35 @@ func (api *ConvCompletionAPI) createNewConversation(reqCtx *gin.Context, message
 	return conv, true // Created new conversation
 }
 
-// TODO should be generate from models, now we just use the first user message
-// generateTitleFromMessages creates a title from the first user message
+// generateTitleFromMessages creates a placeholder title from the first user message,
+// it is replaced by the title service after the first exchange
 func (api *ConvCompletionAPI) generateTitleFromMessages(messages []openai.ChatCompletionMessage) string {
-	if len(messages) == 0 {
+	title := strings.TrimSpace(firstUserMessage(messages))
+	if title == "" {
 		return DefaultConversationTitle
 	}
+	if len(title) > MaxTitleLength {
+		return title[:MaxTitleLength] + "..."
+	}
+	return title
+}
 
-	// Find the first user message
+// firstUserMessage returns the content of the first non-empty user message
+func firstUserMessage(messages []openai.ChatCompletionMessage) string {
 	for _, msg := range messages {
 		if msg.Role == "user" && msg.Content != "" {
-			title := strings.TrimSpace(msg.Content)
-			if len(title) > MaxTitleLength {
-				return title[:MaxTitleLength] + "..."
-			}
-			return title
+			return msg.Content
 		}
 	}
+	return ""
+}
 
-	return DefaultConversationTitle
+// firstChoiceContent returns the assistant content of the first choice
+func firstChoiceContent(response *ExtendedCompletionResponse) string {
+	if response == nil || len(response.Choices) == 0 {
+		return ""
+	}
+	return response.Choices[0].Message.Content
 }
 
 // handleCompletionResponseAndUpdateConversation handles completion response based on finish_reason and updates conversation
@@ -17,6 +17,7 @@ import (
 	"menlo.ai/indigo-api-gateway/app/infrastructure/inference"
 	chatclient "menlo.ai/indigo-api-gateway/app/utils/httpclients/chat"
 	"menlo.ai/indigo-api-gateway/app/utils/logger"
+	"menlo.ai/indigo-api-gateway/app/utils/tokenizer"
 )
 
 // Constants for streaming configuration
@@ -62,7 +63,7 @@ type ToolCallAccumulator struct {
 }
 
 // StreamCompletionAndAccumulateResponse streams SSE events to client and accumulates a complete response for internal processing
-func (s *CompletionStreamHandler) StreamCompletionAndAccumulateResponse(reqCtx *gin.Context, provider *domainmodel.Provider, apiKey string, request openai.ChatCompletionRequest, conv *conversation.Conversation, conversationCreated bool, askItemID string, completionItemID string) (*ExtendedCompletionResponse, *common.Error) {
+func (s *CompletionStreamHandler) StreamCompletionAndAccumulateResponse(reqCtx *gin.Context, provider *domainmodel.Provider, apiKey string, request openai.ChatCompletionRequest, counter tokenizer.Counter, conv *conversation.Conversation, conversationCreated bool, askItemID string, completionItemID string) (*ExtendedCompletionResponse, *common.Error) {
 	// Add timeout context
 	ctx, cancel := context.WithTimeout(reqCtx.Request.Context(), RequestTimeout)
 	defer cancel()
@@ -110,16 +111,19 @@ func (s *CompletionStreamHandler) StreamCompletionAndAccumulateResponse(reqCtx *
 				break
 			}
 
+			// The done marker is held back so conversation events can still follow, see FinishStream
+			data, found := strings.CutPrefix(line, DataPrefix)
+			if found && data == DoneMarker {
+				streamingComplete = true
+				break
+			}
+
 			// Forward the raw line to client
 			if err := s.writeSSELine(reqCtx, line); err != nil {
 				return nil, common.NewError(err, "bc82d69c-685b-4556-9d1f-2a4a80ae8ca4")
 			}
 
-			if data, found := strings.CutPrefix(line, DataPrefix); found {
-				if data == DoneMarker {
-					streamingComplete = true
-					break
-				}
+			if found {
 
 				// Process stream chunk and accumulate content
 				contentChunk, reasoningChunk, functionCallChunk, toolCallChunk := s.processStreamChunkForChannel(data)
@@ -166,7 +170,7 @@ func (s *CompletionStreamHandler) StreamCompletionAndAccumulateResponse(reqCtx *
 	close(errChan)
 
 	// Build the complete response
-	response := s.buildCompleteResponse(fullContent, fullReasoning, functionCallAccumulator, toolCallAccumulator, completionItemID, request.Model, request)
+	response := s.buildCompleteResponse(fullContent, fullReasoning, functionCallAccumulator, toolCallAccumulator, completionItemID, request.Model, request, counter)
 
 	// Return as ExtendedCompletionResponse
 	return &ExtendedCompletionResponse{
@@ -253,6 +257,51 @@ func (s *CompletionStreamHandler) sendConversationMetadata(reqCtx *gin.Context,
 	return s.writeSSEEvent(reqCtx, string(jsonData))
 }
 
+// ConversationTitleEvent is sent at the end of a stream when a conversation title was generated
+type ConversationTitleEvent struct {
+	Object         string `json:"object"`
+	ConversationID string `json:"conversation_id"`
+	Title          string `json:"title"`
+}
+
+// titleEventWait bounds how long the end of a stream waits for the title of a new conversation; a title
+// generated later is only read from the conversation
+var titleEventWait = 5 * time.Second
+
+// FinishStream sends the generated title, waiting for it at most titleEventWait or until the client leaves,
+// and terminates the stream with the done marker
+func (s *CompletionStreamHandler) FinishStream(reqCtx *gin.Context, conv *conversation.Conversation, titleChan <-chan string) error {
+	if conv != nil && titleChan != nil {
+		timer := time.NewTimer(titleEventWait)
+		defer timer.Stop()
+		select {
+		case title, ok := <-titleChan:
+			if ok {
+				if err := s.sendConversationTitle(reqCtx, conv, title); err != nil {
+					return err
+				}
+			}
+		case <-timer.C:
+		case <-reqCtx.Request.Context().Done():
+			return reqCtx.Request.Context().Err()
+		}
+	}
+	return s.writeSSEEvent(reqCtx, DoneMarker)
+}
+
+// sendConversationTitle sends the generated conversation title as SSE event
+func (s *CompletionStreamHandler) sendConversationTitle(reqCtx *gin.Context, conv *conversation.Conversation, title string) error {
+	jsonData, err := json.Marshal(ConversationTitleEvent{
+		Object:         "conversation.title",
+		ConversationID: conv.PublicID,
+		Title:          title,
+	})
+	if err != nil {
+		return err
+	}
+	return s.writeSSEEvent(reqCtx, string(jsonData))
+}
+
 // processStreamChunkForChannel processes a single stream chunk and returns separate chunks
 func (s *CompletionStreamHandler) processStreamChunkForChannel(data string) (string, string, *openai.FunctionCall, *openai.ToolCall) {
 	// Parse the JSON data to extract content and calls
@@ -361,7 +410,7 @@ func (s *CompletionStreamHandler) handleStreamingToolCall(toolCall *openai.ToolC
 }
 
 // buildCompleteResponse builds the complete ChatCompletionResponse from accumulated data
-func (s *CompletionStreamHandler) buildCompleteResponse(content string, reasoning string, functionCallAccumulator map[int]*FunctionCallAccumulator, toolCallAccumulator map[int]*ToolCallAccumulator, completionItemID string, model string, request openai.ChatCompletionRequest) openai.ChatCompletionResponse {
+func (s *CompletionStreamHandler) buildCompleteResponse(content string, reasoning string, functionCallAccumulator map[int]*FunctionCallAccumulator, toolCallAccumulator map[int]*ToolCallAccumulator, completionItemID string, model string, request openai.ChatCompletionRequest, counter tokenizer.Counter) openai.ChatCompletionResponse {
 	// Build a single choice that combines all content, reasoning, and calls
 	message := openai.ChatCompletionMessage{
 		Role:    openai.ChatMessageRoleAssistant,
@@ -420,9 +469,12 @@ func (s *CompletionStreamHandler) buildCompleteResponse(content string, reasonin
 		},
 	}
 
-	// Calculate token usage
-	promptTokens := s.estimateTokens(request.Messages)
-	completionTokens := s.estimateTokens([]openai.ChatCompletionMessage{message})
+	// Calculate token usage with the model's tokenizer
+	if counter == nil {
+		counter = tokenizer.ForModel("", model)
+	}
+	promptTokens := counter.CountMessages(request.Messages)
+	completionTokens := tokenizer.CountCompletion(counter, message)
 	totalTokens := promptTokens + completionTokens
 
 	return openai.ChatCompletionResponse{
@@ -438,35 +490,3 @@ func (s *CompletionStreamHandler) buildCompleteResponse(content string, reasonin
 		},
 	}
 }
-
-// TODO it's raw solution, we need to use the official openai tokenizer like tiktoken
-// estimateTokens provides a rough estimation of token count for messages
-func (s *CompletionStreamHandler) estimateTokens(messages []openai.ChatCompletionMessage) int {
-	var allText strings.Builder
-
-	for _, msg := range messages {
-		allText.WriteString(msg.Content)
-		allText.WriteString(" ")
-
-		if msg.FunctionCall != nil {
-			allText.WriteString(msg.FunctionCall.Name)
-			allText.WriteString(" ")
-			allText.WriteString(msg.FunctionCall.Arguments)
-			allText.WriteString(" ")
-		}
-
-		for _, toolCall := range msg.ToolCalls {
-			allText.WriteString(toolCall.ID)
-			allText.WriteString(" ")
-			allText.WriteString(toolCall.Function.Name)
-			allText.WriteString(" ")
-			allText.WriteString(toolCall.Function.Arguments)
-			allText.WriteString(" ")
-		}
-	}
-
-	// Split by spaces and count words, but normalize whitespace
-	normalized := strings.Join(strings.Fields(allText.String()), " ") // Collapse multiple spaces
-	words := strings.Fields(normalized)
-	return len(words)
-}
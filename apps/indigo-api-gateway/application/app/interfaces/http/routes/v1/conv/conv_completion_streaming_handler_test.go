@@ -0,0 +1,65 @@
+package conv
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"menlo.ai/indigo-api-gateway/app/domain/conversation"
+)
+
+func newStreamContext(ctx context.Context) (*gin.Context, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.TestMode)
+	recorder := httptest.NewRecorder()
+	reqCtx, _ := gin.CreateTestContext(recorder)
+	reqCtx.Request = httptest.NewRequest(http.MethodPost, "/v1/conv/chat/completions", nil).WithContext(ctx)
+	return reqCtx, recorder
+}
+
+func TestFinishStreamWaitsForTheTitle(t *testing.T) {
+	handler := &CompletionStreamHandler{}
+	conv := &conversation.Conversation{PublicID: "conv_1"}
+
+	titleChan := make(chan string, 1)
+	go func() {
+		// the title model answers after the completion finished
+		time.Sleep(50 * time.Millisecond)
+		titleChan <- "Generated title"
+		close(titleChan)
+	}()
+	reqCtx, recorder := newStreamContext(context.Background())
+	if err := handler.FinishStream(reqCtx, conv, titleChan); err != nil {
+		t.Fatalf("finish stream: %v", err)
+	}
+	body := recorder.Body.String()
+	titleAt := strings.Index(body, `"title":"Generated title"`)
+	if titleAt < 0 || titleAt > strings.Index(body, "data: [DONE]") {
+		t.Fatalf("expected the title before the done marker, got %q", body)
+	}
+
+	// a title that is not generated in time is left to the conversation
+	previousWait := titleEventWait
+	titleEventWait = 10 * time.Millisecond
+	defer func() { titleEventWait = previousWait }()
+	reqCtx, recorder = newStreamContext(context.Background())
+	if err := handler.FinishStream(reqCtx, conv, make(chan string)); err != nil {
+		t.Fatalf("finish stream: %v", err)
+	}
+	if body := recorder.Body.String(); body != "data: [DONE]\n\n" {
+		t.Fatalf("expected only the done marker, got %q", body)
+	}
+}
+
+func TestFinishStreamStopsWhenTheClientLeaves(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	reqCtx, recorder := newStreamContext(ctx)
+	err := (&CompletionStreamHandler{}).FinishStream(reqCtx, &conversation.Conversation{PublicID: "conv_1"}, make(chan string))
+	if err == nil || recorder.Body.Len() != 0 {
+		t.Fatalf("expected the stream to end without waiting, got %v %q", err, recorder.Body.String())
+	}
+}
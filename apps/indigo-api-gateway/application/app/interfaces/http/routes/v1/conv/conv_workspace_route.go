@@ -9,6 +9,9 @@ import (
 	"github.com/gin-gonic/gin"
 
 	"menlo.ai/indigo-api-gateway/app/domain/auth"
+	"menlo.ai/indigo-api-gateway/app/domain/common"
+	"menlo.ai/indigo-api-gateway/app/domain/conversation"
+	"menlo.ai/indigo-api-gateway/app/domain/user"
 	"menlo.ai/indigo-api-gateway/app/domain/workspace"
 	"menlo.ai/indigo-api-gateway/app/interfaces/http/responses"
 	"menlo.ai/indigo-api-gateway/app/utils/ptr"
@@ -17,8 +20,14 @@ import (
 type WorkspaceRoute struct {
 	authService      *auth.AuthService
 	workspaceService *workspace.WorkspaceService
+	userService      *user.UserService
 }
 
+const (
+	workspaceMemberKeyPublicID     = "user_public_id"
+	workspaceInvitationKeyPublicID = "invitation_id"
+)
+
 type CreateWorkspaceRequest struct {
 	Name        string  `json:"name" binding:"required"`
 	Instruction *string `json:"instruction"`
@@ -32,6 +41,25 @@ type UpdateWorkspaceInstructionRequest struct {
 	Instruction *string `json:"instruction"`
 }
 
+type UpdateWorkspaceSharingRequest struct {
+	ShareConversations *bool `json:"share_conversations" binding:"required"`
+}
+
+type UpdateWorkspaceMemberRequest struct {
+	Role string `json:"role" binding:"required"`
+}
+
+type CreateWorkspaceInvitationRequest struct {
+	Email string `json:"email" binding:"required"`
+	Role  string `json:"role" binding:"required"`
+}
+
+type UpdateWorkspaceContextSettingsRequest struct {
+	Strategy     *conversation.ContextStrategy `json:"strategy"`
+	KeepLastN    *int                          `json:"keep_last_n"`
+	SummaryModel *string                       `json:"summary_model"`
+}
+
 func (req CreateWorkspaceRequest) ConvertToWorkspace(userID uint) *workspace.Workspace {
 	var instruction *string
 	if req.Instruction != nil {
@@ -48,11 +76,33 @@ func (req CreateWorkspaceRequest) ConvertToWorkspace(userID uint) *workspace.Wor
 }
 
 type WorkspaceResponse struct {
-	ID          string    `json:"id"`
-	Name        string    `json:"name"`
-	Instruction *string   `json:"instruction,omitempty"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID                 string                        `json:"id"`
+	Name               string                        `json:"name"`
+	Instruction        *string                       `json:"instruction,omitempty"`
+	ContextSettings    *conversation.ContextSettings `json:"context_settings,omitempty"`
+	ShareConversations bool                          `json:"share_conversations"`
+	Role               workspace.WorkspaceRole       `json:"role,omitempty"`
+	CreatedAt          time.Time                     `json:"created_at"`
+	UpdatedAt          time.Time                     `json:"updated_at"`
+}
+
+type WorkspaceMemberResponse struct {
+	UserID    string                  `json:"user_id"`
+	Name      string                  `json:"name"`
+	Email     string                  `json:"email"`
+	Role      workspace.WorkspaceRole `json:"role"`
+	CreatedAt time.Time               `json:"created_at"`
+}
+
+type WorkspaceInvitationResponse struct {
+	ID           string                              `json:"id"`
+	WorkspaceID  string                              `json:"workspace_id"`
+	Workspace    string                              `json:"workspace_name"`
+	InviteeEmail string                              `json:"invitee_email"`
+	Role         workspace.WorkspaceRole             `json:"role"`
+	Status       workspace.WorkspaceInvitationStatus `json:"status"`
+	CreatedAt    time.Time                           `json:"created_at"`
+	RespondedAt  *time.Time                          `json:"responded_at,omitempty"`
 }
 
 type WorkspaceDeletedResponse struct {
@@ -60,10 +110,11 @@ type WorkspaceDeletedResponse struct {
 	Deleted bool   `json:"deleted"`
 }
 
-func NewWorkspaceRoute(authService *auth.AuthService, workspaceService *workspace.WorkspaceService) *WorkspaceRoute {
+func NewWorkspaceRoute(authService *auth.AuthService, workspaceService *workspace.WorkspaceService, userService *user.UserService) *WorkspaceRoute {
 	return &WorkspaceRoute{
 		authService:      authService,
 		workspaceService: workspaceService,
+		userService:      userService,
 	}
 }
 
@@ -78,21 +129,80 @@ func (route *WorkspaceRoute) RegisterRouter(router gin.IRouter) {
 	workspacesRouter.GET("", route.ListWorkspaces)
 
 	workspaceMiddleware := route.workspaceService.GetWorkspaceMiddleware()
+	editorMiddleware := workspace.RequireWorkspaceRole(workspace.WorkspaceRole.CanEdit)
+	ownerMiddleware := workspace.RequireWorkspaceRole(workspace.WorkspaceRole.CanManage)
 	workspacesRouter.PATCH(
 		fmt.Sprintf("/:%s", workspace.WorkspaceContextKeyPublicID),
 		workspaceMiddleware,
+		editorMiddleware,
 		route.UpdateWorkspaceName,
 	)
 	workspacesRouter.PATCH(
 		fmt.Sprintf("/:%s/instruction", workspace.WorkspaceContextKeyPublicID),
 		workspaceMiddleware,
+		editorMiddleware,
 		route.UpdateWorkspaceInstruction,
 	)
+	workspacesRouter.PATCH(
+		fmt.Sprintf("/:%s/context", workspace.WorkspaceContextKeyPublicID),
+		workspaceMiddleware,
+		editorMiddleware,
+		route.UpdateWorkspaceContextSettings,
+	)
+	workspacesRouter.PATCH(
+		fmt.Sprintf("/:%s/sharing", workspace.WorkspaceContextKeyPublicID),
+		workspaceMiddleware,
+		ownerMiddleware,
+		route.UpdateWorkspaceSharing,
+	)
 	workspacesRouter.DELETE(
 		fmt.Sprintf("/:%s", workspace.WorkspaceContextKeyPublicID),
 		workspaceMiddleware,
+		ownerMiddleware,
 		route.DeleteWorkspace,
 	)
+
+	workspacesRouter.GET(
+		fmt.Sprintf("/:%s/members", workspace.WorkspaceContextKeyPublicID),
+		workspaceMiddleware,
+		route.ListWorkspaceMembers,
+	)
+	workspacesRouter.PATCH(
+		fmt.Sprintf("/:%s/members/:%s", workspace.WorkspaceContextKeyPublicID, workspaceMemberKeyPublicID),
+		workspaceMiddleware,
+		ownerMiddleware,
+		route.UpdateWorkspaceMember,
+	)
+	// members may remove themselves to leave the workspace, the handler checks the role otherwise
+	workspacesRouter.DELETE(
+		fmt.Sprintf("/:%s/members/:%s", workspace.WorkspaceContextKeyPublicID, workspaceMemberKeyPublicID),
+		workspaceMiddleware,
+		route.RemoveWorkspaceMember,
+	)
+
+	workspacesRouter.POST(
+		fmt.Sprintf("/:%s/invitations", workspace.WorkspaceContextKeyPublicID),
+		workspaceMiddleware,
+		ownerMiddleware,
+		route.CreateWorkspaceInvitation,
+	)
+	workspacesRouter.GET(
+		fmt.Sprintf("/:%s/invitations", workspace.WorkspaceContextKeyPublicID),
+		workspaceMiddleware,
+		ownerMiddleware,
+		route.ListWorkspaceInvitations,
+	)
+	workspacesRouter.DELETE(
+		fmt.Sprintf("/:%s/invitations/:%s", workspace.WorkspaceContextKeyPublicID, workspaceInvitationKeyPublicID),
+		workspaceMiddleware,
+		ownerMiddleware,
+		route.RevokeWorkspaceInvitation,
+	)
+
+	invitationsRouter := convRouter.Group("/workspace-invitations")
+	invitationsRouter.GET("", route.ListMyWorkspaceInvitations)
+	invitationsRouter.POST(fmt.Sprintf("/:%s/accept", workspaceInvitationKeyPublicID), route.AcceptWorkspaceInvitation)
+	invitationsRouter.POST(fmt.Sprintf("/:%s/decline", workspaceInvitationKeyPublicID), route.DeclineWorkspaceInvitation)
 }
 
 // CreateWorkspace godoc
@@ -128,8 +238,8 @@ func (route *WorkspaceRoute) CreateWorkspace(reqCtx *gin.Context) {
 	}
 
 	ctx := reqCtx.Request.Context()
-	workspace := request.ConvertToWorkspace(user.ID)
-	workspaceEntity, err := route.workspaceService.CreateWorkspace(ctx, workspace)
+	newWorkspace := request.ConvertToWorkspace(user.ID)
+	workspaceEntity, err := route.workspaceService.CreateWorkspace(ctx, newWorkspace)
 	if err != nil {
 		status := http.StatusInternalServerError
 		if err.GetCode() == "3a5dcb2f-9f1c-4f4b-8893-4a62f72f7a00" || err.GetCode() == "94a6a12b-d4f0-4594-8125-95de7f9ce3d6" {
@@ -142,12 +252,14 @@ func (route *WorkspaceRoute) CreateWorkspace(reqCtx *gin.Context) {
 		return
 	}
 
-	reqCtx.JSON(http.StatusCreated, toWorkspaceResponse(workspaceEntity))
+	response := toWorkspaceResponse(workspaceEntity)
+	response.Role = workspace.WorkspaceRoleOwner
+	reqCtx.JSON(http.StatusCreated, response)
 }
 
 // ListWorkspaces godoc
 // @Summary List Workspaces
-// @Description Lists all workspaces for the authenticated user.
+// @Description Lists the workspaces the authenticated user created or is a member of, with the user's role in each.
 // @Tags conv Workspaces API
 // @Security BearerAuth
 // @Produce json
@@ -167,7 +279,7 @@ func (route *WorkspaceRoute) ListWorkspaces(reqCtx *gin.Context) {
 
 	ctx := reqCtx.Request.Context()
 	workspaces, err := route.workspaceService.FindWorkspacesByFilter(ctx, workspace.WorkspaceFilter{
-		UserID: &user.ID,
+		MemberID: &user.ID,
 	}, nil)
 	if err != nil {
 		reqCtx.AbortWithStatusJSON(http.StatusInternalServerError, responses.ErrorResponse{
@@ -176,10 +288,19 @@ func (route *WorkspaceRoute) ListWorkspaces(reqCtx *gin.Context) {
 		})
 		return
 	}
+	roles, err := route.workspaceService.RolesForUser(ctx, workspaces, user.ID)
+	if err != nil {
+		reqCtx.AbortWithStatusJSON(http.StatusInternalServerError, responses.ErrorResponse{
+			Code:  err.GetCode(),
+			Error: err.Error(),
+		})
+		return
+	}
 
 	responsesList := make([]WorkspaceResponse, len(workspaces))
 	for i, entity := range workspaces {
 		responsesList[i] = toWorkspaceResponse(entity)
+		responsesList[i].Role = roles[entity.ID]
 	}
 
 	var firstID *string
@@ -297,9 +418,64 @@ func (route *WorkspaceRoute) UpdateWorkspaceInstruction(reqCtx *gin.Context) {
 	reqCtx.JSON(http.StatusOK, toWorkspaceResponse(updated))
 }
 
+// UpdateWorkspaceContextSettings godoc
+// @Summary Update Workspace Context Settings
+// @Description Sets the default context window strategy for conversations in a workspace. Request-level `context_management` overrides these values field by field. Send an empty object to reset to the server defaults.
+// @Tags conv Workspaces API
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param workspace_id path string true "Workspace ID"
+// @Param request body UpdateWorkspaceContextSettingsRequest true "Workspace context settings payload"
+// @Success 200 {object} WorkspaceCreateResponse
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 401 {object} responses.ErrorResponse
+// @Failure 404 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /v1/conv/workspaces/{workspace_id}/context [patch]
+func (route *WorkspaceRoute) UpdateWorkspaceContextSettings(reqCtx *gin.Context) {
+	var request UpdateWorkspaceContextSettingsRequest
+	if err := reqCtx.ShouldBindJSON(&request); err != nil {
+		reqCtx.AbortWithStatusJSON(http.StatusBadRequest, responses.ErrorResponse{
+			Code:  "9c4e2a71-3f5b-4d8e-a1c6-7b0d9e2f4a83",
+			Error: "invalid request payload",
+		})
+		return
+	}
+
+	workspaceEntity, ok := workspace.GetWorkspaceFromContext(reqCtx)
+	if !ok {
+		reqCtx.AbortWithStatusJSON(http.StatusNotFound, responses.ErrorResponse{
+			Code:  "c8bc424c-5b20-4cf9-8ca1-7d9ad1b098c8",
+			Error: "workspace not found",
+		})
+		return
+	}
+
+	ctx := reqCtx.Request.Context()
+	updated, err := route.workspaceService.UpdateWorkspaceContextSettings(ctx, workspaceEntity, &conversation.ContextSettings{
+		Strategy:     request.Strategy,
+		KeepLastN:    request.KeepLastN,
+		SummaryModel: request.SummaryModel,
+	})
+	if err != nil {
+		status := http.StatusInternalServerError
+		if err.GetCode() == "e2b7c4d1-58a3-4f0e-9b6d-3c1a7e5f9d82" {
+			status = http.StatusBadRequest
+		}
+		reqCtx.AbortWithStatusJSON(status, responses.ErrorResponse{
+			Code:  err.GetCode(),
+			Error: err.Error(),
+		})
+		return
+	}
+
+	reqCtx.JSON(http.StatusOK, toWorkspaceResponse(updated))
+}
+
 // DeleteWorkspace godoc
 // @Summary Delete Workspace
-// @Description Deletes a workspace and cascades to its conversations.
+// @Description Deletes a workspace and cascades to its conversations, members and invitations. Only owners can delete a workspace.
 // @Tags conv Workspaces API
 // @Security BearerAuth
 // @Param workspace_id path string true "Workspace ID"
@@ -335,6 +511,581 @@ func (route *WorkspaceRoute) DeleteWorkspace(reqCtx *gin.Context) {
 	reqCtx.JSON(http.StatusOK, result)
 }
 
+// UpdateWorkspaceSharing godoc
+// @Summary Update Workspace Sharing
+// @Description Controls whether members see each other's conversations in the workspace. Only owners can change this setting.
+// @Tags conv Workspaces API
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param workspace_id path string true "Workspace ID"
+// @Param request body UpdateWorkspaceSharingRequest true "Workspace sharing payload"
+// @Success 200 {object} WorkspaceCreateResponse
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 403 {object} responses.ErrorResponse
+// @Failure 404 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /v1/conv/workspaces/{workspace_id}/sharing [patch]
+func (route *WorkspaceRoute) UpdateWorkspaceSharing(reqCtx *gin.Context) {
+	var request UpdateWorkspaceSharingRequest
+	if err := reqCtx.ShouldBindJSON(&request); err != nil {
+		reqCtx.AbortWithStatusJSON(http.StatusBadRequest, responses.ErrorResponse{
+			Code:  "4b6d8f0a-2c4e-4b6d-8f0a-2c4e6b8d0f2a",
+			Error: "invalid request payload",
+		})
+		return
+	}
+
+	workspaceEntity, ok := workspace.GetWorkspaceFromContext(reqCtx)
+	if !ok {
+		reqCtx.AbortWithStatusJSON(http.StatusNotFound, responses.ErrorResponse{
+			Code:  "c8bc424c-5b20-4cf9-8ca1-7d9ad1b098c8",
+			Error: "workspace not found",
+		})
+		return
+	}
+
+	ctx := reqCtx.Request.Context()
+	updated, err := route.workspaceService.UpdateWorkspaceSharing(ctx, workspaceEntity, *request.ShareConversations)
+	if err != nil {
+		reqCtx.AbortWithStatusJSON(http.StatusInternalServerError, responses.ErrorResponse{
+			Code:  err.GetCode(),
+			Error: err.Error(),
+		})
+		return
+	}
+
+	response := toWorkspaceResponse(updated)
+	response.Role = workspace.WorkspaceRoleOwner
+	reqCtx.JSON(http.StatusOK, response)
+}
+
+// ListWorkspaceMembers godoc
+// @Summary List Workspace Members
+// @Description Lists the members of a workspace, starting with its creator.
+// @Tags conv Workspaces API
+// @Security BearerAuth
+// @Produce json
+// @Param workspace_id path string true "Workspace ID"
+// @Success 200 {object} responses.ListResponse[WorkspaceMemberResponse]
+// @Failure 401 {object} responses.ErrorResponse
+// @Failure 404 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /v1/conv/workspaces/{workspace_id}/members [get]
+func (route *WorkspaceRoute) ListWorkspaceMembers(reqCtx *gin.Context) {
+	workspaceEntity, ok := workspace.GetWorkspaceFromContext(reqCtx)
+	if !ok {
+		reqCtx.AbortWithStatusJSON(http.StatusNotFound, responses.ErrorResponse{
+			Code:  "c8bc424c-5b20-4cf9-8ca1-7d9ad1b098c8",
+			Error: "workspace not found",
+		})
+		return
+	}
+
+	ctx := reqCtx.Request.Context()
+	members, err := route.workspaceService.ListMembers(ctx, workspaceEntity)
+	if err != nil {
+		reqCtx.AbortWithStatusJSON(http.StatusInternalServerError, responses.ErrorResponse{
+			Code:  err.GetCode(),
+			Error: err.Error(),
+		})
+		return
+	}
+
+	results := make([]WorkspaceMemberResponse, 0, len(members))
+	for _, member := range members {
+		memberUser, userErr := route.userService.FindByID(ctx, member.UserID)
+		if userErr != nil || memberUser == nil {
+			continue
+		}
+		results = append(results, WorkspaceMemberResponse{
+			UserID:    memberUser.PublicID,
+			Name:      memberUser.Name,
+			Email:     memberUser.Email,
+			Role:      member.Role,
+			CreatedAt: member.CreatedAt,
+		})
+	}
+
+	reqCtx.JSON(http.StatusOK, responses.ListResponse[WorkspaceMemberResponse]{
+		Status:  responses.ResponseCodeOk,
+		Total:   int64(len(results)),
+		Results: results,
+	})
+}
+
+// UpdateWorkspaceMember godoc
+// @Summary Update Workspace Member Role
+// @Description Changes the role of a workspace member. Only owners can change roles and the creator of the workspace always stays owner.
+// @Tags conv Workspaces API
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param workspace_id path string true "Workspace ID"
+// @Param user_public_id path string true "User ID"
+// @Param request body UpdateWorkspaceMemberRequest true "Workspace member payload"
+// @Success 200 {object} WorkspaceMemberResponse
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 403 {object} responses.ErrorResponse
+// @Failure 404 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /v1/conv/workspaces/{workspace_id}/members/{user_public_id} [patch]
+func (route *WorkspaceRoute) UpdateWorkspaceMember(reqCtx *gin.Context) {
+	var request UpdateWorkspaceMemberRequest
+	if err := reqCtx.ShouldBindJSON(&request); err != nil {
+		reqCtx.AbortWithStatusJSON(http.StatusBadRequest, responses.ErrorResponse{
+			Code:  "8f0a2c4e-6b8d-4f0a-a2c4-e6b8d0f2a4c6",
+			Error: "invalid request payload",
+		})
+		return
+	}
+	workspaceEntity, memberUser, ok := route.getWorkspaceAndMemberUser(reqCtx)
+	if !ok {
+		return
+	}
+
+	ctx := reqCtx.Request.Context()
+	role := workspace.WorkspaceRole(request.Role)
+	if err := route.workspaceService.UpdateMemberRole(ctx, workspaceEntity, memberUser.ID, role); err != nil {
+		reqCtx.AbortWithStatusJSON(workspaceMembershipErrorStatus(err), responses.ErrorResponse{
+			Code:  err.GetCode(),
+			Error: err.Error(),
+		})
+		return
+	}
+
+	reqCtx.JSON(http.StatusOK, WorkspaceMemberResponse{
+		UserID: memberUser.PublicID,
+		Name:   memberUser.Name,
+		Email:  memberUser.Email,
+		Role:   role,
+	})
+}
+
+// RemoveWorkspaceMember godoc
+// @Summary Remove Workspace Member
+// @Description Removes a member from a workspace. Owners can remove any member except the creator, other members can only remove themselves to leave the workspace.
+// @Tags conv Workspaces API
+// @Security BearerAuth
+// @Param workspace_id path string true "Workspace ID"
+// @Param user_public_id path string true "User ID"
+// @Success 204
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 403 {object} responses.ErrorResponse
+// @Failure 404 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /v1/conv/workspaces/{workspace_id}/members/{user_public_id} [delete]
+func (route *WorkspaceRoute) RemoveWorkspaceMember(reqCtx *gin.Context) {
+	workspaceEntity, memberUser, ok := route.getWorkspaceAndMemberUser(reqCtx)
+	if !ok {
+		return
+	}
+	currentUser, _ := auth.GetUserFromContext(reqCtx)
+	role, _ := workspace.GetWorkspaceRoleFromContext(reqCtx)
+	if !role.CanManage() && (currentUser == nil || currentUser.ID != memberUser.ID) {
+		reqCtx.AbortWithStatusJSON(http.StatusForbidden, responses.ErrorResponse{
+			Code:  "5a7c9e1b-3d5f-4a7c-9e1b-3d5f7a9c1e3b",
+			Error: "insufficient workspace role",
+		})
+		return
+	}
+
+	ctx := reqCtx.Request.Context()
+	if err := route.workspaceService.RemoveMember(ctx, workspaceEntity, memberUser.ID); err != nil {
+		reqCtx.AbortWithStatusJSON(workspaceMembershipErrorStatus(err), responses.ErrorResponse{
+			Code:  err.GetCode(),
+			Error: err.Error(),
+		})
+		return
+	}
+
+	reqCtx.Status(http.StatusNoContent)
+}
+
+// CreateWorkspaceInvitation godoc
+// @Summary Invite Workspace Member
+// @Description Invites a user of the same organization to the workspace. The invitee joins once they accept the invitation.
+// @Tags conv Workspaces API
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param workspace_id path string true "Workspace ID"
+// @Param request body CreateWorkspaceInvitationRequest true "Workspace invitation payload"
+// @Success 201 {object} WorkspaceInvitationResponse
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 403 {object} responses.ErrorResponse
+// @Failure 404 {object} responses.ErrorResponse
+// @Failure 409 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /v1/conv/workspaces/{workspace_id}/invitations [post]
+func (route *WorkspaceRoute) CreateWorkspaceInvitation(reqCtx *gin.Context) {
+	var request CreateWorkspaceInvitationRequest
+	if err := reqCtx.ShouldBindJSON(&request); err != nil {
+		reqCtx.AbortWithStatusJSON(http.StatusBadRequest, responses.ErrorResponse{
+			Code:  "2c4e6a8b-0d2f-4c4e-8a0b-d2f4c6e8a0b2",
+			Error: "invalid request payload",
+		})
+		return
+	}
+	workspaceEntity, ok := workspace.GetWorkspaceFromContext(reqCtx)
+	if !ok {
+		reqCtx.AbortWithStatusJSON(http.StatusNotFound, responses.ErrorResponse{
+			Code:  "c8bc424c-5b20-4cf9-8ca1-7d9ad1b098c8",
+			Error: "workspace not found",
+		})
+		return
+	}
+	currentUser, ok := auth.GetUserFromContext(reqCtx)
+	if !ok {
+		reqCtx.AbortWithStatusJSON(http.StatusUnauthorized, responses.ErrorResponse{
+			Code:  "6a8c0e2d-4f6b-4a8c-9e2d-4f6b8a0c2e4f",
+			Error: "user not found",
+		})
+		return
+	}
+
+	ctx := reqCtx.Request.Context()
+	invitee, err := route.userService.FindByEmail(ctx, strings.TrimSpace(request.Email))
+	if err != nil {
+		reqCtx.AbortWithStatusJSON(http.StatusInternalServerError, responses.ErrorResponse{
+			Code:  "0e2a4c6d-8f0b-4e2a-b4c6-d8f0b2e4a6c8",
+			Error: err.Error(),
+		})
+		return
+	}
+	if invitee == nil {
+		reqCtx.AbortWithStatusJSON(http.StatusNotFound, responses.ErrorResponse{
+			Code:  "4c6e8a0b-2d4f-4c6e-a0b2-d4f6c8e0a2b4",
+			Error: "user not found",
+		})
+		return
+	}
+
+	invitation, invErr := route.workspaceService.InviteMember(ctx, workspaceEntity, currentUser.ID, invitee.ID, workspace.WorkspaceRole(request.Role))
+	if invErr != nil {
+		reqCtx.AbortWithStatusJSON(workspaceMembershipErrorStatus(invErr), responses.ErrorResponse{
+			Code:  invErr.GetCode(),
+			Error: invErr.Error(),
+		})
+		return
+	}
+
+	reqCtx.JSON(http.StatusCreated, toWorkspaceInvitationResponse(invitation, workspaceEntity, invitee))
+}
+
+// ListWorkspaceInvitations godoc
+// @Summary List Workspace Invitations
+// @Description Lists the invitations of a workspace.
+// @Tags conv Workspaces API
+// @Security BearerAuth
+// @Produce json
+// @Param workspace_id path string true "Workspace ID"
+// @Param status query string false "Filter by status (pending, accepted, declined, revoked)"
+// @Success 200 {object} responses.ListResponse[WorkspaceInvitationResponse]
+// @Failure 403 {object} responses.ErrorResponse
+// @Failure 404 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /v1/conv/workspaces/{workspace_id}/invitations [get]
+func (route *WorkspaceRoute) ListWorkspaceInvitations(reqCtx *gin.Context) {
+	workspaceEntity, ok := workspace.GetWorkspaceFromContext(reqCtx)
+	if !ok {
+		reqCtx.AbortWithStatusJSON(http.StatusNotFound, responses.ErrorResponse{
+			Code:  "c8bc424c-5b20-4cf9-8ca1-7d9ad1b098c8",
+			Error: "workspace not found",
+		})
+		return
+	}
+
+	filter := workspace.WorkspaceInvitationFilter{
+		WorkspaceID: &workspaceEntity.ID,
+	}
+	if status := reqCtx.Query("status"); status != "" {
+		invitationStatus := workspace.WorkspaceInvitationStatus(status)
+		filter.Status = &invitationStatus
+	}
+
+	ctx := reqCtx.Request.Context()
+	invitations, err := route.workspaceService.FindInvitationsByFilter(ctx, filter)
+	if err != nil {
+		reqCtx.AbortWithStatusJSON(http.StatusInternalServerError, responses.ErrorResponse{
+			Code:  err.GetCode(),
+			Error: err.Error(),
+		})
+		return
+	}
+
+	results := make([]WorkspaceInvitationResponse, 0, len(invitations))
+	for _, invitation := range invitations {
+		invitee, _ := route.userService.FindByID(ctx, invitation.InviteeUserID)
+		results = append(results, toWorkspaceInvitationResponse(invitation, workspaceEntity, invitee))
+	}
+
+	reqCtx.JSON(http.StatusOK, responses.ListResponse[WorkspaceInvitationResponse]{
+		Status:  responses.ResponseCodeOk,
+		Total:   int64(len(results)),
+		Results: results,
+	})
+}
+
+// RevokeWorkspaceInvitation godoc
+// @Summary Revoke Workspace Invitation
+// @Description Revokes a pending invitation of a workspace.
+// @Tags conv Workspaces API
+// @Security BearerAuth
+// @Produce json
+// @Param workspace_id path string true "Workspace ID"
+// @Param invitation_id path string true "Invitation ID"
+// @Success 200 {object} WorkspaceInvitationResponse
+// @Failure 400 {object} responses.ErrorResponse
+// @Failure 403 {object} responses.ErrorResponse
+// @Failure 404 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /v1/conv/workspaces/{workspace_id}/invitations/{invitation_id} [delete]
+func (route *WorkspaceRoute) RevokeWorkspaceInvitation(reqCtx *gin.Context) {
+	workspaceEntity, ok := workspace.GetWorkspaceFromContext(reqCtx)
+	if !ok {
+		reqCtx.AbortWithStatusJSON(http.StatusNotFound, responses.ErrorResponse{
+			Code:  "c8bc424c-5b20-4cf9-8ca1-7d9ad1b098c8",
+			Error: "workspace not found",
+		})
+		return
+	}
+
+	ctx := reqCtx.Request.Context()
+	publicID := reqCtx.Param(workspaceInvitationKeyPublicID)
+	invitations, err := route.workspaceService.FindInvitationsByFilter(ctx, workspace.WorkspaceInvitationFilter{
+		PublicID:    &publicID,
+		WorkspaceID: &workspaceEntity.ID,
+	})
+	if err != nil {
+		reqCtx.AbortWithStatusJSON(http.StatusInternalServerError, responses.ErrorResponse{
+			Code:  err.GetCode(),
+			Error: err.Error(),
+		})
+		return
+	}
+	if len(invitations) == 0 {
+		reqCtx.AbortWithStatusJSON(http.StatusNotFound, responses.ErrorResponse{
+			Code:  "9e1a3c5d-7f9b-4e1a-8c5d-7f9b1e3a5c7d",
+			Error: "workspace invitation not found",
+		})
+		return
+	}
+
+	revoked, err := route.workspaceService.RevokeInvitation(ctx, invitations[0])
+	if err != nil {
+		reqCtx.AbortWithStatusJSON(workspaceMembershipErrorStatus(err), responses.ErrorResponse{
+			Code:  err.GetCode(),
+			Error: err.Error(),
+		})
+		return
+	}
+
+	invitee, _ := route.userService.FindByID(ctx, revoked.InviteeUserID)
+	reqCtx.JSON(http.StatusOK, toWorkspaceInvitationResponse(revoked, workspaceEntity, invitee))
+}
+
+// ListMyWorkspaceInvitations godoc
+// @Summary List My Workspace Invitations
+// @Description Lists the pending workspace invitations addressed to the authenticated user.
+// @Tags conv Workspaces API
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} responses.ListResponse[WorkspaceInvitationResponse]
+// @Failure 401 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /v1/conv/workspace-invitations [get]
+func (route *WorkspaceRoute) ListMyWorkspaceInvitations(reqCtx *gin.Context) {
+	currentUser, ok := auth.GetUserFromContext(reqCtx)
+	if !ok {
+		reqCtx.AbortWithStatusJSON(http.StatusUnauthorized, responses.ErrorResponse{
+			Code:  "b0d2f4a6-8c0e-4b0d-a4a6-8c0e2b4d6f8a",
+			Error: "user not found",
+		})
+		return
+	}
+
+	ctx := reqCtx.Request.Context()
+	pending := workspace.WorkspaceInvitationStatusPending
+	invitations, err := route.workspaceService.FindInvitationsByFilter(ctx, workspace.WorkspaceInvitationFilter{
+		InviteeUserID: &currentUser.ID,
+		Status:        &pending,
+	})
+	if err != nil {
+		reqCtx.AbortWithStatusJSON(http.StatusInternalServerError, responses.ErrorResponse{
+			Code:  err.GetCode(),
+			Error: err.Error(),
+		})
+		return
+	}
+
+	workspaceIDs := make([]uint, 0, len(invitations))
+	for _, invitation := range invitations {
+		workspaceIDs = append(workspaceIDs, invitation.WorkspaceID)
+	}
+	workspacesByID := make(map[uint]*workspace.Workspace, len(workspaceIDs))
+	if len(workspaceIDs) > 0 {
+		workspaces, err := route.workspaceService.FindWorkspacesByFilter(ctx, workspace.WorkspaceFilter{
+			IDs: &workspaceIDs,
+		}, nil)
+		if err != nil {
+			reqCtx.AbortWithStatusJSON(http.StatusInternalServerError, responses.ErrorResponse{
+				Code:  err.GetCode(),
+				Error: err.Error(),
+			})
+			return
+		}
+		for _, entity := range workspaces {
+			workspacesByID[entity.ID] = entity
+		}
+	}
+
+	results := make([]WorkspaceInvitationResponse, 0, len(invitations))
+	for _, invitation := range invitations {
+		workspaceEntity, ok := workspacesByID[invitation.WorkspaceID]
+		if !ok {
+			continue
+		}
+		results = append(results, toWorkspaceInvitationResponse(invitation, workspaceEntity, currentUser))
+	}
+
+	reqCtx.JSON(http.StatusOK, responses.ListResponse[WorkspaceInvitationResponse]{
+		Status:  responses.ResponseCodeOk,
+		Total:   int64(len(results)),
+		Results: results,
+	})
+}
+
+// AcceptWorkspaceInvitation godoc
+// @Summary Accept Workspace Invitation
+// @Description Accepts a pending workspace invitation and joins the workspace with the invited role.
+// @Tags conv Workspaces API
+// @Security BearerAuth
+// @Produce json
+// @Param invitation_id path string true "Invitation ID"
+// @Success 200 {object} WorkspaceInvitationResponse
+// @Failure 401 {object} responses.ErrorResponse
+// @Failure 404 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /v1/conv/workspace-invitations/{invitation_id}/accept [post]
+func (route *WorkspaceRoute) AcceptWorkspaceInvitation(reqCtx *gin.Context) {
+	route.respondToInvitation(reqCtx, true)
+}
+
+// DeclineWorkspaceInvitation godoc
+// @Summary Decline Workspace Invitation
+// @Description Declines a pending workspace invitation.
+// @Tags conv Workspaces API
+// @Security BearerAuth
+// @Produce json
+// @Param invitation_id path string true "Invitation ID"
+// @Success 200 {object} WorkspaceInvitationResponse
+// @Failure 401 {object} responses.ErrorResponse
+// @Failure 404 {object} responses.ErrorResponse
+// @Failure 500 {object} responses.ErrorResponse
+// @Router /v1/conv/workspace-invitations/{invitation_id}/decline [post]
+func (route *WorkspaceRoute) DeclineWorkspaceInvitation(reqCtx *gin.Context) {
+	route.respondToInvitation(reqCtx, false)
+}
+
+func (route *WorkspaceRoute) respondToInvitation(reqCtx *gin.Context, accept bool) {
+	currentUser, ok := auth.GetUserFromContext(reqCtx)
+	if !ok {
+		reqCtx.AbortWithStatusJSON(http.StatusUnauthorized, responses.ErrorResponse{
+			Code:  "d2f4a6c8-0e2b-4d2f-a6c8-0e2b4d6f8a0c",
+			Error: "user not found",
+		})
+		return
+	}
+
+	ctx := reqCtx.Request.Context()
+	invitation, err := route.workspaceService.GetPendingInvitation(ctx, reqCtx.Param(workspaceInvitationKeyPublicID), currentUser.ID)
+	if err != nil {
+		reqCtx.AbortWithStatusJSON(workspaceMembershipErrorStatus(err), responses.ErrorResponse{
+			Code:  err.GetCode(),
+			Error: err.Error(),
+		})
+		return
+	}
+	workspaces, err := route.workspaceService.FindWorkspacesByFilter(ctx, workspace.WorkspaceFilter{
+		IDs: &[]uint{invitation.WorkspaceID},
+	}, nil)
+	if err != nil {
+		reqCtx.AbortWithStatusJSON(http.StatusInternalServerError, responses.ErrorResponse{
+			Code:  err.GetCode(),
+			Error: err.Error(),
+		})
+		return
+	}
+	if len(workspaces) == 0 {
+		reqCtx.AbortWithStatusJSON(http.StatusNotFound, responses.ErrorResponse{
+			Code:  "c8bc424c-5b20-4cf9-8ca1-7d9ad1b098c8",
+			Error: "workspace not found",
+		})
+		return
+	}
+
+	updated, err := route.workspaceService.RespondToInvitation(ctx, invitation, accept)
+	if err != nil {
+		reqCtx.AbortWithStatusJSON(http.StatusInternalServerError, responses.ErrorResponse{
+			Code:  err.GetCode(),
+			Error: err.Error(),
+		})
+		return
+	}
+
+	reqCtx.JSON(http.StatusOK, toWorkspaceInvitationResponse(updated, workspaces[0], currentUser))
+}
+
+func (route *WorkspaceRoute) getWorkspaceAndMemberUser(reqCtx *gin.Context) (*workspace.Workspace, *user.User, bool) {
+	workspaceEntity, ok := workspace.GetWorkspaceFromContext(reqCtx)
+	if !ok {
+		reqCtx.AbortWithStatusJSON(http.StatusNotFound, responses.ErrorResponse{
+			Code:  "c8bc424c-5b20-4cf9-8ca1-7d9ad1b098c8",
+			Error: "workspace not found",
+		})
+		return nil, nil, false
+	}
+	memberUser, err := route.userService.FindByPublicID(reqCtx.Request.Context(), reqCtx.Param(workspaceMemberKeyPublicID))
+	if err != nil || memberUser == nil {
+		reqCtx.AbortWithStatusJSON(http.StatusNotFound, responses.ErrorResponse{
+			Code:  "9d1f3b5c-7e9a-4d1f-b3c5-7e9a1d3f5b7c",
+			Error: "workspace member not found",
+		})
+		return nil, nil, false
+	}
+	return workspaceEntity, memberUser, true
+}
+
+func workspaceMembershipErrorStatus(err *common.Error) int {
+	switch err.GetCode() {
+	case "0a2c4e6f-8b0d-4a2c-9e4f-6b8d0a2c4e6f", "5b7d9f1a-3c5e-4b7d-9f1a-3c5e7b9d1f3a", "7c9e1a3b-5d7f-4c9e-a1b3-5d7f9c1e3a5b", "2d4f6a8c-0e2b-4d4f-a8c0-e2b4d6f8a0c3", "7e9a1c3d-5f7b-4e9a-a1c3-d5f7b9e1a3c5":
+		return http.StatusBadRequest
+	case "9d1f3b5c-7e9a-4d1f-b3c5-7e9a1d3f5b7c", "9e1a3c5d-7f9b-4e1a-8c5d-7f9b1e3a5c7d":
+		return http.StatusNotFound
+	case "4f6b8d0e-2a4c-4f6b-8d0e-2a4c6f8b0d2e", "c0e2a4b6-8d0f-4c2e-a4b6-8d0f2c4e6a8b":
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func toWorkspaceInvitationResponse(invitation *workspace.WorkspaceInvitation, workspaceEntity *workspace.Workspace, invitee *user.User) WorkspaceInvitationResponse {
+	response := WorkspaceInvitationResponse{
+		ID:          invitation.PublicID,
+		WorkspaceID: workspaceEntity.PublicID,
+		Workspace:   workspaceEntity.Name,
+		Role:        invitation.Role,
+		Status:      invitation.Status,
+		CreatedAt:   invitation.CreatedAt,
+		RespondedAt: invitation.RespondedAt,
+	}
+	if invitee != nil {
+		response.InviteeEmail = invitee.Email
+	}
+	return response
+}
+
 func toWorkspaceResponse(entity *workspace.Workspace) WorkspaceResponse {
 	var instruction *string
 	if entity.Instruction != nil {
@@ -342,11 +1093,13 @@ func toWorkspaceResponse(entity *workspace.Workspace) WorkspaceResponse {
 	}
 
 	return WorkspaceResponse{
-		ID:          entity.PublicID,
-		Name:        entity.Name,
-		Instruction: instruction,
-		CreatedAt:   entity.CreatedAt,
-		UpdatedAt:   entity.UpdatedAt,
+		ID:                 entity.PublicID,
+		Name:               entity.Name,
+		Instruction:        instruction,
+		ContextSettings:    entity.ContextSettings,
+		ShareConversations: entity.ShareConversations,
+		CreatedAt:          entity.CreatedAt,
+		UpdatedAt:          entity.UpdatedAt,
 	}
 }
 
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"menlo.ai/indigo-api-gateway/app/domain/auth"
@@ -30,11 +31,16 @@ type CreateConversationRequest struct {
 	Metadata    map[string]string         `json:"metadata,omitempty"`
 	Items       []ConversationItemRequest `json:"items,omitempty"`
 	WorkspaceID string                    `json:"workspace_id,omitempty"`
+	// IsPrivate defaults to false in a workspace that shares its conversations and to true otherwise,
+	// only conversations that are not private are shared with the workspace members
+	IsPrivate *bool `json:"is_private,omitempty"`
 }
 
 type UpdateConversationRequest struct {
 	Title    *string            `json:"title"`
 	Metadata *map[string]string `json:"metadata"`
+	// IsPrivate can only be changed by the owner of the conversation
+	IsPrivate *bool `json:"is_private"`
 }
 
 type UpdateConversationWorkspaceRequest struct {
@@ -62,14 +68,17 @@ type ExtendedConversationResponse struct {
 	Title             string            `json:"title"`
 	Object            string            `json:"object"`
 	WorkspacePublicID string            `json:"workspace_id,omitempty"`
+	IsPrivate         bool              `json:"is_private"`
 	CreatedAt         int64             `json:"created_at"`
+	DeletedAt         *int64            `json:"deleted_at,omitempty"`
 	Metadata          map[string]string `json:"metadata"`
 }
 
 type DeletedConversationResponse struct {
-	ID      string `json:"id"`
-	Object  string `json:"object"`
-	Deleted bool   `json:"deleted"`
+	ID        string `json:"id"`
+	Object    string `json:"object"`
+	Deleted   bool   `json:"deleted"`
+	Permanent bool   `json:"permanent"`
 }
 
 type ConversationItemResponse struct {
@@ -79,6 +88,7 @@ type ConversationItemResponse struct {
 	Role      *string           `json:"role,omitempty"`
 	Status    *string           `json:"status,omitempty"`
 	CreatedAt int64             `json:"created_at"`
+	DeletedAt *int64            `json:"deleted_at,omitempty"`
 	Content   []ContentResponse `json:"content,omitempty"`
 }
 
@@ -148,14 +158,18 @@ func (api *ConversationAPI) RegisterRouter(router *gin.RouterGroup) {
 	conversationsRouter.GET("", api.ListConversationsHandler)
 
 	conversationMiddleWare := api.conversationService.GetConversationMiddleWare()
+	ownerMiddleWare := conversation.RequireConversationOwner()
 	conversationsRouter.PATCH(
 		fmt.Sprintf("/:%s/workspace", conversation.ConversationContextKeyPublicID),
 		conversationMiddleWare,
+		ownerMiddleWare,
 		api.UpdateConversationWorkspaceHandler,
 	)
 	conversationsRouter.GET(fmt.Sprintf("/:%s", conversation.ConversationContextKeyPublicID), conversationMiddleWare, api.GetConversationHandler)
 	conversationsRouter.PATCH(fmt.Sprintf("/:%s", conversation.ConversationContextKeyPublicID), conversationMiddleWare, api.UpdateConversationHandler)
-	conversationsRouter.DELETE(fmt.Sprintf("/:%s", conversation.ConversationContextKeyPublicID), conversationMiddleWare, api.DeleteConversationHandler)
+	conversationWithTrashMiddleWare := api.conversationService.GetConversationWithTrashMiddleWare()
+	conversationsRouter.DELETE(fmt.Sprintf("/:%s", conversation.ConversationContextKeyPublicID), conversationWithTrashMiddleWare, ownerMiddleWare, api.DeleteConversationHandler)
+	conversationsRouter.POST(fmt.Sprintf("/:%s/restore", conversation.ConversationContextKeyPublicID), conversationWithTrashMiddleWare, ownerMiddleWare, api.RestoreConversationHandler)
 	conversationsRouter.POST(fmt.Sprintf("/:%s/items", conversation.ConversationContextKeyPublicID), conversationMiddleWare, api.CreateItemsHandler)
 	conversationsRouter.GET(fmt.Sprintf("/:%s/items", conversation.ConversationContextKeyPublicID), conversationMiddleWare, api.ListItemsHandler)
 
@@ -177,9 +191,19 @@ func (api *ConversationAPI) RegisterRouter(router *gin.RouterGroup) {
 			conversation.ConversationItemContextKeyPublicID,
 		),
 		conversationMiddleWare,
-		conversationItemMiddleWare,
+		api.conversationService.GetConversationItemWithTrashMiddleWare(),
 		api.DeleteItemHandler,
 	)
+	conversationsRouter.POST(
+		fmt.Sprintf(
+			"/:%s/items/:%s/restore",
+			conversation.ConversationContextKeyPublicID,
+			conversation.ConversationItemContextKeyPublicID,
+		),
+		conversationMiddleWare,
+		api.conversationService.GetConversationItemWithTrashMiddleWare(),
+		api.RestoreItemHandler,
+	)
 }
 
 // @Summary List Conversations
@@ -190,8 +214,9 @@ func (api *ConversationAPI) RegisterRouter(router *gin.RouterGroup) {
 // @Param after query string false "A cursor for use in pagination. The ID of the last object from the previous page"
 // @Param order query string false "Order of items (asc/desc)"
 // @Param workspace_id query string false "Filter conversations by workspace public ID"
+// @Param status query string false "Filter conversations by status (active/archived/deleted), deleted lists the trash"
 // @Success 200 {object} object "Successfully retrieved the list of conversations"
-// @Failure 400 {object} responses.ErrorResponse "Bad Request - Invalid pagination parameters"
+// @Failure 400 {object} responses.ErrorResponse "Bad Request - Invalid pagination parameters or status"
 // @Failure 401 {object} responses.ErrorResponse "Unauthorized - invalid or missing API key"
 // @Failure 500 {object} responses.ErrorResponse "Internal Server Error"
 // @Router /v1/conversations [get]
@@ -202,10 +227,45 @@ func (api *ConversationAPI) ListConversationsHandler(reqCtx *gin.Context) {
 
 	workspaceIDParam := strings.TrimSpace(reqCtx.Query("workspace_id"))
 
+	var statusFilter *conversation.ConversationStatus
+	if statusParam := strings.TrimSpace(reqCtx.Query("status")); statusParam != "" {
+		status := conversation.ConversationStatus(statusParam)
+		switch status {
+		case conversation.ConversationStatusActive, conversation.ConversationStatusArchived, conversation.ConversationStatusDeleted:
+			statusFilter = &status
+		default:
+			reqCtx.AbortWithStatusJSON(http.StatusBadRequest, responses.ErrorResponse{
+				Code:  "3b5d7f9a-1c3e-4a5b-9d7f-1a3c5e7b9d0f",
+				Error: "Invalid status, expected active, archived or deleted",
+			})
+			return
+		}
+	}
+
+	// members of a workspace that shares conversations see the conversations in it that are not private
+	ownerFilter := &userID
+	var visibleFilter *uint
+	if workspaceIDParam != "" {
+		_, shared, sharedErr := api.workspaceService.SharedConversationAccess(ctx, workspaceIDParam, userID)
+		if sharedErr != nil {
+			reqCtx.AbortWithStatusJSON(http.StatusInternalServerError, responses.ErrorResponse{
+				Code:          "1d3f5b7c-9e1a-4d3f-b7c9-e1a3d5f7b9c1",
+				ErrorInstance: sharedErr,
+			})
+			return
+		}
+		if shared {
+			ownerFilter = nil
+			visibleFilter = &userID
+		}
+	}
+
 	pagination, err := query.GetCursorPaginationFromQuery(reqCtx, func(lastID string) (*uint, error) {
 		filter := conversation.ConversationFilter{
-			UserID:   &userID,
-			PublicID: &lastID,
+			UserID:          ownerFilter,
+			VisibleToUserID: visibleFilter,
+			PublicID:        &lastID,
+			Status:          statusFilter,
 		}
 		if workspaceIDParam != "" {
 			filter.WorkspacePublicID = &workspaceIDParam
@@ -228,7 +288,9 @@ func (api *ConversationAPI) ListConversationsHandler(reqCtx *gin.Context) {
 	}
 
 	filter := conversation.ConversationFilter{
-		UserID: &userID,
+		UserID:          ownerFilter,
+		VisibleToUserID: visibleFilter,
+		Status:          statusFilter,
 	}
 	if workspaceIDParam != "" {
 		filter.WorkspacePublicID = &workspaceIDParam
@@ -296,6 +358,7 @@ func (api *ConversationAPI) ListConversationsHandler(reqCtx *gin.Context) {
 // @Success 200 {object} ExtendedConversationResponse "Created conversation"
 // @Failure 400 {object} responses.ErrorResponse "Invalid request - Bad payload, too many items, or invalid item format"
 // @Failure 401 {object} responses.ErrorResponse "Unauthorized"
+// @Failure 403 {object} responses.ErrorResponse "Workspace role does not allow creating conversations"
 // @Failure 500 {object} responses.ErrorResponse "Internal server error"
 // @Router /v1/conversations [post]
 func (api *ConversationAPI) CreateConversationHandler(reqCtx *gin.Context) {
@@ -343,10 +406,11 @@ func (api *ConversationAPI) CreateConversationHandler(reqCtx *gin.Context) {
 		return
 	}
 
-	// validate workspace ID belongs to user and fetch the workspace public ID
+	// validate the user can edit the workspace and fetch the workspace public ID
 	var workspacePublicID *string
+	isPrivate := true
 	if trimmedID := strings.TrimSpace(request.WorkspaceID); trimmedID != "" {
-		workspace, err := api.workspaceService.GetWorkspaceByPublicIDAndUserID(ctx, trimmedID, userId)
+		workspace, role, err := api.workspaceService.GetWorkspaceForMember(ctx, trimmedID, userId)
 		if err != nil {
 			reqCtx.AbortWithStatusJSON(http.StatusBadRequest, responses.ErrorResponse{
 				Code:          "019952d0-1dc9-746e-82ff-dd42b1e7930f",
@@ -354,12 +418,23 @@ func (api *ConversationAPI) CreateConversationHandler(reqCtx *gin.Context) {
 			})
 			return
 		}
+		if !role.CanEdit() {
+			reqCtx.AbortWithStatusJSON(http.StatusForbidden, responses.ErrorResponse{
+				Code:  "5f7b9d1e-3a5c-4f7b-9d1e-3a5c7f9b1d3e",
+				Error: "insufficient workspace role",
+			})
+			return
+		}
 
 		workspacePublicID = ptr.ToString(workspace.PublicID)
+		isPrivate = !workspace.ShareConversations
 	}
 
 	// Create conversation
-	conv, err := api.conversationService.CreateConversation(ctx, userId, &request.Title, true, request.Metadata, workspacePublicID)
+	if request.IsPrivate != nil {
+		isPrivate = *request.IsPrivate
+	}
+	conv, err := api.conversationService.CreateConversation(ctx, userId, &request.Title, isPrivate, request.Metadata, workspacePublicID)
 	if err != nil {
 		reqCtx.AbortWithStatusJSON(http.StatusBadRequest, responses.ErrorResponse{
 			Code:          "019952d0-3e32-76ba-a97f-711223df2c84",
@@ -442,6 +517,16 @@ func (api *ConversationAPI) UpdateConversationHandler(reqCtx *gin.Context) {
 	if request.Metadata != nil {
 		conv.Metadata = *request.Metadata
 	}
+	if request.IsPrivate != nil && *request.IsPrivate != conv.IsPrivate {
+		if !conversation.GetConversationAccessFromContext(reqCtx).IsOwner() {
+			reqCtx.AbortWithStatusJSON(http.StatusForbidden, responses.ErrorResponse{
+				Code:  "6c8e0a2b-4d6f-4e8a-b0c2-e4f6a8c0e2b4",
+				Error: "only the owner of the conversation can change its visibility",
+			})
+			return
+		}
+		conv.IsPrivate = *request.IsPrivate
+	}
 
 	conv, err := api.conversationService.UpdateConversation(ctx, conv)
 	if err != nil {
@@ -457,11 +542,12 @@ func (api *ConversationAPI) UpdateConversationHandler(reqCtx *gin.Context) {
 }
 
 // @Summary Delete a conversation
-// @Description Deletes a conversation and all its items permanently
+// @Description Moves a conversation to the trash. Conversations already in the trash, or deleted with permanent=true, are removed permanently with all their items
 // @Tags Conversations API
 // @Security BearerAuth
 // @Produce json
 // @Param conversation_id path string true "Conversation ID"
+// @Param permanent query bool false "Delete permanently instead of moving to the trash"
 // @Success 200 {object} DeletedConversationResponse "Deleted conversation"
 // @Failure 401 {object} responses.ErrorResponse "Unauthorized"
 // @Failure 403 {object} responses.ErrorResponse "Access denied"
@@ -479,18 +565,69 @@ func (api *ConversationAPI) DeleteConversationHandler(reqCtx *gin.Context) {
 		return
 	}
 
+	if conv.DeletedAt != nil || reqCtx.Query("permanent") == "true" {
+		if err := api.conversationService.DeleteConversationPermanently(ctx, conv); err != nil {
+			reqCtx.AbortWithStatusJSON(http.StatusBadRequest, responses.ErrorResponse{
+				Code:          "c8e0a2b4-6d8f-4c1a-9e3b-5d7f9a1c3e5b",
+				ErrorInstance: err.GetError(),
+			})
+			return
+		}
+		response := domainToDeletedConversationResponse(conv)
+		response.Permanent = true
+		reqCtx.JSON(http.StatusOK, response)
+		return
+	}
+
 	success, err := api.conversationService.DeleteConversation(ctx, conv)
 	if !success {
 		reqCtx.AbortWithStatusJSON(http.StatusBadRequest, responses.ErrorResponse{
 			Code:          "019952c3-9836-75ea-9785-a8d035a7c136",
 			ErrorInstance: err.GetError(),
 		})
+		return
 	}
 	response := domainToDeletedConversationResponse(conv)
 
 	reqCtx.JSON(http.StatusOK, response)
 }
 
+// @Summary Restore a conversation
+// @Description Moves a conversation out of the trash
+// @Tags Conversations API
+// @Security BearerAuth
+// @Produce json
+// @Param conversation_id path string true "Conversation ID"
+// @Success 200 {object} ExtendedConversationResponse "Restored conversation"
+// @Failure 400 {object} responses.ErrorResponse "Conversation is not in the trash"
+// @Failure 401 {object} responses.ErrorResponse "Unauthorized"
+// @Failure 403 {object} responses.ErrorResponse "Only the owner can restore the conversation"
+// @Failure 404 {object} responses.ErrorResponse "Conversation not found"
+// @Failure 500 {object} responses.ErrorResponse "Internal server error"
+// @Router /v1/conversations/{conversation_id}/restore [post]
+func (api *ConversationAPI) RestoreConversationHandler(reqCtx *gin.Context) {
+	ctx := reqCtx.Request.Context()
+	conv, ok := conversation.GetConversationFromContext(reqCtx)
+	if !ok {
+		reqCtx.AbortWithStatusJSON(http.StatusBadRequest, responses.ErrorResponse{
+			Code:  "f2a4c6e8-0b2d-4f4a-8c6e-0a2b4d6f8a1c",
+			Error: "Conversation not found",
+		})
+		return
+	}
+
+	conv, err := api.conversationService.RestoreConversation(ctx, conv)
+	if err != nil {
+		reqCtx.AbortWithStatusJSON(http.StatusBadRequest, responses.ErrorResponse{
+			Code:          err.GetCode(),
+			ErrorInstance: err.GetError(),
+		})
+		return
+	}
+
+	reqCtx.JSON(http.StatusOK, domainToExtendedConversationResponse(conv))
+}
+
 // @Summary Update conversation workspace
 // @Description Moves a conversation to another workspace or removes it from a workspace
 // @Tags Conversations API
@@ -528,7 +665,7 @@ func (api *ConversationAPI) UpdateConversationWorkspaceHandler(reqCtx *gin.Conte
 	var workspacePublicID *string
 	if request.WorkspaceID != nil && strings.TrimSpace(*request.WorkspaceID) != "" {
 		trimmedWorkspaceID := strings.TrimSpace(*request.WorkspaceID)
-		workspaceEntity, err := api.workspaceService.GetWorkspaceByPublicIDAndUserID(ctx, trimmedWorkspaceID, conv.UserID)
+		workspaceEntity, role, err := api.workspaceService.GetWorkspaceForMember(ctx, trimmedWorkspaceID, conv.UserID)
 		if err != nil {
 			status := http.StatusInternalServerError
 			if err.GetCode() == "c8bc424c-5b20-4cf9-8ca1-7d9ad1b098c8" {
@@ -540,6 +677,13 @@ func (api *ConversationAPI) UpdateConversationWorkspaceHandler(reqCtx *gin.Conte
 			})
 			return
 		}
+		if !role.CanEdit() {
+			reqCtx.AbortWithStatusJSON(http.StatusForbidden, responses.ErrorResponse{
+				Code:  "5f7b9d1e-3a5c-4f7b-9d1e-3a5c7f9b1d3e",
+				Error: "insufficient workspace role",
+			})
+			return
+		}
 		publicID := workspaceEntity.PublicID
 		workspacePublicID = &publicID
 	} else {
@@ -646,6 +790,7 @@ func (api *ConversationAPI) CreateItemsHandler(reqCtx *gin.Context) {
 // @Param limit query int false "Number of items to return (1-100)"
 // @Param after query string false "Cursor for pagination - ID of the last item from previous page"
 // @Param order query string false "Order of items (asc/desc)"
+// @Param deleted query bool false "List the items in the trash instead of the live items"
 // @Success 200 {object} openai.ListResponse[ConversationItemResponse] "List of items"
 // @Failure 400 {object} responses.ErrorResponse "Bad Request - Invalid pagination parameters"
 // @Failure 401 {object} responses.ErrorResponse "Unauthorized"
@@ -656,11 +801,13 @@ func (api *ConversationAPI) CreateItemsHandler(reqCtx *gin.Context) {
 func (api *ConversationAPI) ListItemsHandler(reqCtx *gin.Context) {
 	ctx := reqCtx.Request.Context()
 	conv, _ := conversation.GetConversationFromContext(reqCtx)
+	onlyDeleted := reqCtx.Query("deleted") == "true"
 
 	pagination, err := query.GetCursorPaginationFromQuery(reqCtx, func(lastID string) (*uint, error) {
 		items, err := api.conversationService.FindItemsByFilter(ctx, conversation.ItemFilter{
 			PublicID:       &lastID,
 			ConversationID: &conv.ID,
+			OnlyDeleted:    onlyDeleted,
 		}, nil)
 		if err != nil {
 			return nil, fmt.Errorf("%s: %s", err.GetCode(), err.Error())
@@ -680,6 +827,7 @@ func (api *ConversationAPI) ListItemsHandler(reqCtx *gin.Context) {
 
 	filter := conversation.ItemFilter{
 		ConversationID: &conv.ID,
+		OnlyDeleted:    onlyDeleted,
 	}
 	itemEntities, filterErr := api.conversationService.FindItemsByFilter(ctx, filter, pagination)
 	if filterErr != nil {
@@ -749,12 +897,13 @@ func (api *ConversationAPI) GetItemHandler(reqCtx *gin.Context) {
 }
 
 // @Summary Delete an item from a conversation
-// @Description Deletes a specific item from a conversation and returns the deleted item details
+// @Description Moves an item of a conversation to the trash. Items already in the trash, or deleted with permanent=true, are removed permanently
 // @Tags Conversations API
 // @Security BearerAuth
 // @Produce json
 // @Param conversation_id path string true "Conversation ID"
 // @Param item_id path string true "Item ID"
+// @Param permanent query bool false "Delete permanently instead of moving to the trash"
 // @Success 200 {object} ConversationItemResponse "Deleted item details"
 // @Failure 400 {object} responses.ErrorResponse "Bad Request - Deletion failed"
 // @Failure 401 {object} responses.ErrorResponse "Unauthorized"
@@ -779,6 +928,18 @@ func (api *ConversationAPI) DeleteItemHandler(reqCtx *gin.Context) {
 		return
 	}
 
+	if item.DeletedAt != nil || reqCtx.Query("permanent") == "true" {
+		if err := api.conversationService.DeleteItemPermanently(ctx, conv, item); err != nil {
+			reqCtx.AbortWithStatusJSON(http.StatusBadRequest, responses.ErrorResponse{
+				Code:          "d4f6a8c0-2e4a-4c6d-8f0b-2d4f6a8c0e3b",
+				ErrorInstance: err,
+			})
+			return
+		}
+		reqCtx.JSON(http.StatusOK, domainToExtendedConversationResponse(conv))
+		return
+	}
+
 	// Use efficient deletion with item public ID instead of loading all items
 	_, err := api.conversationService.DeleteItemWithConversation(ctx, conv, item)
 	if err != nil {
@@ -794,6 +955,48 @@ func (api *ConversationAPI) DeleteItemHandler(reqCtx *gin.Context) {
 	reqCtx.JSON(http.StatusOK, response)
 }
 
+// @Summary Restore an item of a conversation
+// @Description Moves an item out of the trash back into its conversation
+// @Tags Conversations API
+// @Security BearerAuth
+// @Produce json
+// @Param conversation_id path string true "Conversation ID"
+// @Param item_id path string true "Item ID"
+// @Success 200 {object} ConversationItemResponse "Restored item"
+// @Failure 400 {object} responses.ErrorResponse "Item is not in the trash"
+// @Failure 401 {object} responses.ErrorResponse "Unauthorized"
+// @Failure 404 {object} responses.ErrorResponse "Conversation or item not found"
+// @Failure 500 {object} responses.ErrorResponse "Internal server error"
+// @Router /v1/conversations/{conversation_id}/items/{item_id}/restore [post]
+func (api *ConversationAPI) RestoreItemHandler(reqCtx *gin.Context) {
+	ctx := reqCtx.Request.Context()
+	conv, ok := conversation.GetConversationFromContext(reqCtx)
+	if !ok {
+		reqCtx.AbortWithStatusJSON(http.StatusInternalServerError, responses.ErrorResponse{
+			Code: "b0d2f4a6-8c0e-4b2d-a4f6-8c0e2a4b6d8f",
+		})
+		return
+	}
+	item, ok := conversation.GetConversationItemFromContext(reqCtx)
+	if !ok {
+		reqCtx.AbortWithStatusJSON(http.StatusInternalServerError, responses.ErrorResponse{
+			Code: "e6a8c0d2-4f6b-4e8a-b0c2-4e6f8a0b2c4d",
+		})
+		return
+	}
+
+	item, err := api.conversationService.RestoreItemWithConversation(ctx, conv, item)
+	if err != nil {
+		reqCtx.AbortWithStatusJSON(http.StatusBadRequest, responses.ErrorResponse{
+			Code:          err.GetCode(),
+			ErrorInstance: err.GetError(),
+		})
+		return
+	}
+
+	reqCtx.JSON(http.StatusOK, domainToConversationItemResponse(item))
+}
+
 func NewItemFromConversationItemRequest(itemReq ConversationItemRequest) (*conversation.Item, bool) {
 	ok := conversation.ValidateItemType(string(itemReq.Type))
 	if !ok {
@@ -838,11 +1041,20 @@ func domainToExtendedConversationResponse(entity *conversation.Conversation) *Ex
 		Object:            "conversation",
 		Title:             ptr.FromString(entity.Title),
 		WorkspacePublicID: ptr.FromString(entity.WorkspacePublicID),
+		IsPrivate:         entity.IsPrivate,
 		CreatedAt:         entity.CreatedAt.Unix(),
+		DeletedAt:         unixOrNil(entity.DeletedAt),
 		Metadata:          metadata,
 	}
 }
 
+func unixOrNil(t *time.Time) *int64 {
+	if t == nil {
+		return nil
+	}
+	return ptr.ToInt64(t.Unix())
+}
+
 func domainToDeletedConversationResponse(entity *conversation.Conversation) *DeletedConversationResponse {
 	return &DeletedConversationResponse{
 		ID:      entity.PublicID,
@@ -858,6 +1070,7 @@ func domainToConversationItemResponse(entity *conversation.Item) *ConversationIt
 		Type:      string(entity.Type),
 		Status:    conversation.ItemStatusToStringPtr(entity.Status),
 		CreatedAt: entity.CreatedAt.Unix(),
+		DeletedAt: unixOrNil(entity.DeletedAt),
 		Content:   domainToContentResponse(entity.Content),
 	}
 
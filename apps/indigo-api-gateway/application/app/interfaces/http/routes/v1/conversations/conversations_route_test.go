@@ -0,0 +1,404 @@
+package conversations
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+	"menlo.ai/indigo-api-gateway/app/domain/auth"
+	"menlo.ai/indigo-api-gateway/app/domain/conversation"
+	"menlo.ai/indigo-api-gateway/app/domain/user"
+	"menlo.ai/indigo-api-gateway/app/domain/workspace"
+	"menlo.ai/indigo-api-gateway/app/infrastructure/database/dbschema"
+	"menlo.ai/indigo-api-gateway/app/infrastructure/database/repository/conversationrepo"
+	"menlo.ai/indigo-api-gateway/app/infrastructure/database/repository/itemrepo"
+	"menlo.ai/indigo-api-gateway/app/infrastructure/database/repository/transaction"
+	"menlo.ai/indigo-api-gateway/app/infrastructure/database/repository/workspacerepo"
+	"menlo.ai/indigo-api-gateway/app/interfaces/http/responses/openai"
+	"menlo.ai/indigo-api-gateway/app/utils/ptr"
+)
+
+const (
+	ownerID uint = iota + 1
+	editorID
+	viewerID
+	outsiderID
+)
+
+type sharedWorkspaceFixture struct {
+	db            *gorm.DB
+	router        *gin.Engine
+	service       *conversation.ConversationService
+	workspaces    *workspace.WorkspaceService
+	workspaceID   string
+	shared        *conversation.Conversation
+	ownerPrivate  *conversation.Conversation
+	editorPrivate *conversation.Conversation
+}
+
+func newSharedWorkspaceFixture(t *testing.T) *sharedWorkspaceFixture {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	ctx := context.Background()
+
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{
+		NamingStrategy:                           schema.NamingStrategy{SingularTable: true},
+		DisableForeignKeyConstraintWhenMigrating: true,
+	})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	if err := db.AutoMigrate(&dbschema.Workspace{}, &dbschema.WorkspaceMember{}, &dbschema.Conversation{}, &dbschema.Response{}, &dbschema.Item{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	database := transaction.NewDatabase(db)
+	conversationRepo := conversationrepo.NewConversationGormRepository(database)
+	workspaceRepo := workspacerepo.NewWorkspaceGormRepository(database)
+	workspaceService := workspace.NewWorkspaceService(workspaceRepo, conversationRepo, nil)
+	service := conversation.NewService(conversationRepo, itemrepo.NewItemGormRepository(database), workspaceService)
+
+	ws := &workspace.Workspace{PublicID: "ws_shared", UserID: ownerID, Name: "Team", ShareConversations: true}
+	if err := workspaceRepo.Create(ctx, ws); err != nil {
+		t.Fatalf("seed workspace: %v", err)
+	}
+	for memberID, role := range map[uint]workspace.WorkspaceRole{editorID: workspace.WorkspaceRoleEditor, viewerID: workspace.WorkspaceRoleViewer} {
+		if err := workspaceRepo.AddMember(ctx, &workspace.WorkspaceMember{WorkspaceID: ws.ID, UserID: memberID, Role: role}); err != nil {
+			t.Fatalf("seed member: %v", err)
+		}
+	}
+
+	create := func(userID uint, title string, isPrivate bool) *conversation.Conversation {
+		conv, createErr := service.CreateConversation(ctx, userID, ptr.ToString(title), isPrivate, nil, ptr.ToString(ws.PublicID))
+		if createErr != nil {
+			t.Fatalf("seed conversation: %v", createErr)
+		}
+		return conv
+	}
+	fixture := &sharedWorkspaceFixture{
+		db:            db,
+		service:       service,
+		workspaces:    workspaceService,
+		workspaceID:   ws.PublicID,
+		shared:        create(ownerID, "shared", false),
+		ownerPrivate:  create(ownerID, "owner private", true),
+		editorPrivate: create(editorID, "editor private", true),
+	}
+
+	api := NewConversationAPI(service, nil, workspaceService)
+	router := gin.New()
+	group := router.Group("/v1/conversations", func(reqCtx *gin.Context) {
+		var userID uint
+		fmt.Sscan(reqCtx.GetHeader("X-Test-User"), &userID)
+		auth.SetUserToContext(reqCtx, &user.User{ID: userID})
+	})
+	conversationMiddleWare := service.GetConversationMiddleWare()
+	group.GET("", api.ListConversationsHandler)
+	group.POST("", api.CreateConversationHandler)
+	group.GET(fmt.Sprintf("/:%s", conversation.ConversationContextKeyPublicID), conversationMiddleWare, api.GetConversationHandler)
+	group.PATCH(fmt.Sprintf("/:%s", conversation.ConversationContextKeyPublicID), conversationMiddleWare, api.UpdateConversationHandler)
+	group.POST(fmt.Sprintf("/:%s/items", conversation.ConversationContextKeyPublicID), conversationMiddleWare, api.CreateItemsHandler)
+	group.GET(fmt.Sprintf("/:%s/items", conversation.ConversationContextKeyPublicID), conversationMiddleWare, api.ListItemsHandler)
+	withTrash := service.GetConversationWithTrashMiddleWare()
+	ownerMiddleWare := conversation.RequireConversationOwner()
+	group.DELETE(fmt.Sprintf("/:%s", conversation.ConversationContextKeyPublicID), withTrash, ownerMiddleWare, api.DeleteConversationHandler)
+	group.POST(fmt.Sprintf("/:%s/restore", conversation.ConversationContextKeyPublicID), withTrash, ownerMiddleWare, api.RestoreConversationHandler)
+	itemPath := fmt.Sprintf("/:%s/items/:%s", conversation.ConversationContextKeyPublicID, conversation.ConversationItemContextKeyPublicID)
+	itemWithTrash := service.GetConversationItemWithTrashMiddleWare()
+	group.DELETE(itemPath, conversationMiddleWare, itemWithTrash, api.DeleteItemHandler)
+	group.POST(itemPath+"/restore", conversationMiddleWare, itemWithTrash, api.RestoreItemHandler)
+	fixture.router = router
+	return fixture
+}
+
+func (f *sharedWorkspaceFixture) do(userID uint, method, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Test-User", fmt.Sprint(userID))
+	recorder := httptest.NewRecorder()
+	f.router.ServeHTTP(recorder, req)
+	return recorder
+}
+
+func TestListConversationsInSharedWorkspaceHidesPrivateConversations(t *testing.T) {
+	f := newSharedWorkspaceFixture(t)
+	cases := map[uint][]string{
+		ownerID:    {"owner private", "shared"},
+		editorID:   {"editor private", "shared"},
+		viewerID:   {"shared"},
+		outsiderID: {},
+	}
+	for userID, expected := range cases {
+		recorder := f.do(userID, http.MethodGet, "/v1/conversations?workspace_id="+f.workspaceID, "")
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("user %d: expected 200, got %d: %s", userID, recorder.Code, recorder.Body.String())
+		}
+		var response openai.ListResponse[*ExtendedConversationResponse]
+		if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+			t.Fatalf("decode response: %v", err)
+		}
+		titles := make([]string, 0, len(response.Data))
+		for _, conv := range response.Data {
+			titles = append(titles, conv.Title)
+		}
+		sort.Strings(titles)
+		if strings.Join(titles, ",") != strings.Join(expected, ",") {
+			t.Errorf("user %d: expected %v, got %v", userID, expected, titles)
+		}
+		if response.Total != int64(len(expected)) {
+			t.Errorf("user %d: expected total %d, got %d", userID, len(expected), response.Total)
+		}
+	}
+}
+
+func TestConversationAccessByWorkspaceRole(t *testing.T) {
+	f := newSharedWorkspaceFixture(t)
+	items := `{"items":[{"type":"message","role":"user","content":[{"type":"text","text":"hello"}]}]}`
+	cases := []struct {
+		name     string
+		userID   uint
+		conv     *conversation.Conversation
+		method   string
+		suffix   string
+		body     string
+		expected int
+	}{
+		{"owner reads private", ownerID, f.ownerPrivate, http.MethodGet, "", "", http.StatusOK},
+		{"owner writes private", ownerID, f.ownerPrivate, http.MethodPost, "/items", items, http.StatusOK},
+		{"editor reads shared", editorID, f.shared, http.MethodGet, "", "", http.StatusOK},
+		{"editor writes shared", editorID, f.shared, http.MethodPost, "/items", items, http.StatusOK},
+		{"editor reads private", editorID, f.ownerPrivate, http.MethodGet, "", "", http.StatusNotFound},
+		{"editor writes private", editorID, f.ownerPrivate, http.MethodPost, "/items", items, http.StatusNotFound},
+		{"viewer reads shared", viewerID, f.shared, http.MethodGet, "", "", http.StatusOK},
+		{"viewer writes shared", viewerID, f.shared, http.MethodPost, "/items", items, http.StatusForbidden},
+		{"viewer reads private", viewerID, f.ownerPrivate, http.MethodGet, "", "", http.StatusNotFound},
+		{"outsider reads shared", outsiderID, f.shared, http.MethodGet, "", "", http.StatusNotFound},
+		{"editor changes visibility", editorID, f.shared, http.MethodPatch, "", `{"is_private":true}`, http.StatusForbidden},
+		{"owner changes visibility", ownerID, f.ownerPrivate, http.MethodPatch, "", `{"is_private":false}`, http.StatusOK},
+	}
+	for _, tc := range cases {
+		recorder := f.do(tc.userID, tc.method, "/v1/conversations/"+tc.conv.PublicID+tc.suffix, tc.body)
+		if recorder.Code != tc.expected {
+			t.Errorf("%s: expected %d, got %d: %s", tc.name, tc.expected, recorder.Code, recorder.Body.String())
+		}
+	}
+
+	recorder := f.do(viewerID, http.MethodGet, "/v1/conversations/"+f.ownerPrivate.PublicID, "")
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected the conversation made shared by its owner to be visible, got %d", recorder.Code)
+	}
+}
+
+func TestWorkspaceSharingToggle(t *testing.T) {
+	f := newSharedWorkspaceFixture(t)
+	ctx := context.Background()
+	create := func(body string) *ExtendedConversationResponse {
+		recorder := f.do(ownerID, http.MethodPost, "/v1/conversations", body)
+		var created ExtendedConversationResponse
+		if recorder.Code != http.StatusOK || json.Unmarshal(recorder.Body.Bytes(), &created) != nil {
+			t.Fatalf("create conversation: expected 200, got %d: %s", recorder.Code, recorder.Body.String())
+		}
+		return &created
+	}
+	listQuery := "?workspace_id=" + f.workspaceID
+
+	// a conversation created in a sharing workspace is shared unless asked otherwise
+	if created := create(`{"title":"team notes","workspace_id":"` + f.workspaceID + `"}`); created.IsPrivate {
+		t.Fatalf("expected the conversation to be shared by default")
+	}
+	if created := create(`{"title":"kept private","workspace_id":"` + f.workspaceID + `","is_private":true}`); !created.IsPrivate {
+		t.Fatalf("expected is_private to be kept")
+	}
+	if titles := f.listTitles(t, viewerID, listQuery); strings.Join(titles, ",") != "shared,team notes" {
+		t.Fatalf("expected the viewer to see the shared conversations, got %v", titles)
+	}
+
+	ws, _, err := f.workspaces.GetWorkspaceForMember(ctx, f.workspaceID, ownerID)
+	if err != nil {
+		t.Fatalf("get workspace: %v", err)
+	}
+	if _, err := f.workspaces.UpdateWorkspaceSharing(ctx, ws, false); err != nil {
+		t.Fatalf("stop sharing: %v", err)
+	}
+	if titles := f.listTitles(t, viewerID, listQuery); len(titles) != 0 {
+		t.Fatalf("expected nothing to be shared once sharing is off, got %v", titles)
+	}
+	if created := create(`{"title":"after sharing","workspace_id":"` + f.workspaceID + `"}`); !created.IsPrivate {
+		t.Fatalf("expected the conversation to be private by default once sharing is off")
+	}
+
+	if _, err := f.workspaces.UpdateWorkspaceSharing(ctx, ws, true); err != nil {
+		t.Fatalf("share again: %v", err)
+	}
+	if titles := f.listTitles(t, viewerID, listQuery); strings.Join(titles, ",") != "shared,team notes" {
+		t.Fatalf("expected the shared conversations to come back, got %v", titles)
+	}
+}
+
+func TestAddItemChecksSharedRole(t *testing.T) {
+	f := newSharedWorkspaceFixture(t)
+	ctx := context.Background()
+	role := conversation.ItemRoleUser
+	content := []conversation.Content{{Type: "text", Text: &conversation.Text{Value: "hello"}}}
+	cases := []struct {
+		name    string
+		userID  uint
+		conv    *conversation.Conversation
+		allowed bool
+	}{
+		{"owner on private", ownerID, f.ownerPrivate, true},
+		{"editor on shared", editorID, f.shared, true},
+		{"editor on private", editorID, f.ownerPrivate, false},
+		{"viewer on shared", viewerID, f.shared, false},
+		{"outsider on shared", outsiderID, f.shared, false},
+	}
+	for _, tc := range cases {
+		_, err := f.service.AddItem(ctx, tc.conv, tc.userID, conversation.ItemTypeMessage, &role, content)
+		if tc.allowed && err != nil {
+			t.Errorf("%s: expected the item to be added, got %v", tc.name, err)
+		}
+		if !tc.allowed && err == nil {
+			t.Errorf("%s: expected the item to be rejected", tc.name)
+		}
+	}
+}
+
+func (f *sharedWorkspaceFixture) listTitles(t *testing.T, userID uint, query string) []string {
+	t.Helper()
+	recorder := f.do(userID, http.MethodGet, "/v1/conversations"+query, "")
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("list conversations: expected 200, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	var response openai.ListResponse[*ExtendedConversationResponse]
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	titles := make([]string, 0, len(response.Data))
+	for _, conv := range response.Data {
+		titles = append(titles, conv.Title)
+	}
+	sort.Strings(titles)
+	return titles
+}
+
+func TestConversationTrash(t *testing.T) {
+	f := newSharedWorkspaceFixture(t)
+	ctx := context.Background()
+	path := "/v1/conversations/" + f.ownerPrivate.PublicID
+
+	if recorder := f.do(editorID, http.MethodDelete, "/v1/conversations/"+f.shared.PublicID, ""); recorder.Code != http.StatusForbidden {
+		t.Fatalf("expected only the owner to trash a conversation, got %d", recorder.Code)
+	}
+	// an archived conversation comes back archived
+	if err := f.db.Model(&dbschema.Conversation{}).Where("id = ?", f.ownerPrivate.ID).UpdateColumn("status", conversation.ConversationStatusArchived).Error; err != nil {
+		t.Fatalf("archive conversation: %v", err)
+	}
+	recorder := f.do(ownerID, http.MethodDelete, path, "")
+	var deleted DeletedConversationResponse
+	if recorder.Code != http.StatusOK || json.Unmarshal(recorder.Body.Bytes(), &deleted) != nil || !deleted.Deleted || deleted.Permanent {
+		t.Fatalf("expected the conversation to be trashed, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	if recorder := f.do(ownerID, http.MethodGet, path, ""); recorder.Code != http.StatusNotFound {
+		t.Fatalf("expected the trashed conversation to be hidden, got %d", recorder.Code)
+	}
+	if titles := f.listTitles(t, ownerID, ""); strings.Join(titles, ",") != "shared" {
+		t.Fatalf("expected the trash to be left out of the list, got %v", titles)
+	}
+	if titles := f.listTitles(t, ownerID, "?status=deleted"); strings.Join(titles, ",") != "owner private" {
+		t.Fatalf("expected the trash to be listed, got %v", titles)
+	}
+	if recorder := f.do(ownerID, http.MethodGet, "/v1/conversations?status=gone", ""); recorder.Code != http.StatusBadRequest {
+		t.Fatalf("expected an unknown status to be rejected, got %d", recorder.Code)
+	}
+
+	if recorder := f.do(ownerID, http.MethodPost, path+"/restore", ""); recorder.Code != http.StatusOK {
+		t.Fatalf("expected the conversation to be restored, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	restored, err := f.service.GetConversationByID(ctx, f.ownerPrivate.ID)
+	if err != nil || restored.Status != conversation.ConversationStatusArchived || restored.DeletedAt != nil {
+		t.Fatalf("expected the conversation back archived, got %+v, %v", restored, err)
+	}
+	if recorder := f.do(ownerID, http.MethodPost, path+"/restore", ""); recorder.Code != http.StatusBadRequest {
+		t.Fatalf("expected a conversation out of the trash not to be restored, got %d", recorder.Code)
+	}
+
+	// deleting a trashed conversation removes it for good
+	f.do(ownerID, http.MethodDelete, path, "")
+	recorder = f.do(ownerID, http.MethodDelete, path, "")
+	if recorder.Code != http.StatusOK || json.Unmarshal(recorder.Body.Bytes(), &deleted) != nil || !deleted.Permanent {
+		t.Fatalf("expected the trashed conversation to be deleted permanently, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	if recorder := f.do(ownerID, http.MethodPost, path+"/restore", ""); recorder.Code != http.StatusNotFound {
+		t.Fatalf("expected the conversation to be gone, got %d", recorder.Code)
+	}
+}
+
+func TestConversationItemTrash(t *testing.T) {
+	f := newSharedWorkspaceFixture(t)
+	path := "/v1/conversations/" + f.ownerPrivate.PublicID
+	recorder := f.do(ownerID, http.MethodPost, path+"/items", `{"items":[{"type":"message","role":"user","content":[{"type":"text","text":"first"}]},{"type":"message","role":"user","content":[{"type":"text","text":"second"}]}]}`)
+	var created openai.ListResponse[*ConversationItemResponse]
+	if recorder.Code != http.StatusOK || json.Unmarshal(recorder.Body.Bytes(), &created) != nil || len(created.Data) != 2 {
+		t.Fatalf("create items: %d %s", recorder.Code, recorder.Body.String())
+	}
+	trashed, removed := created.Data[0].ID, created.Data[1].ID
+
+	listItems := func(query string) []string {
+		t.Helper()
+		recorder := f.do(ownerID, http.MethodGet, path+"/items"+query, "")
+		var response openai.ListResponse[*ConversationItemResponse]
+		if recorder.Code != http.StatusOK || json.Unmarshal(recorder.Body.Bytes(), &response) != nil {
+			t.Fatalf("list items: %d %s", recorder.Code, recorder.Body.String())
+		}
+		ids := make([]string, 0, len(response.Data))
+		for _, item := range response.Data {
+			ids = append(ids, item.ID)
+		}
+		sort.Strings(ids)
+		return ids
+	}
+	sorted := func(ids ...string) string {
+		sort.Strings(ids)
+		return strings.Join(ids, ",")
+	}
+
+	for _, id := range []string{trashed, removed} {
+		if recorder := f.do(ownerID, http.MethodDelete, path+"/items/"+id, ""); recorder.Code != http.StatusOK {
+			t.Fatalf("trash item: %d %s", recorder.Code, recorder.Body.String())
+		}
+	}
+	if ids := listItems(""); len(ids) != 0 {
+		t.Fatalf("expected the trashed items to be hidden, got %v", ids)
+	}
+	if ids := listItems("?deleted=true"); strings.Join(ids, ",") != sorted(trashed, removed) {
+		t.Fatalf("expected the trash to list the items, got %v", ids)
+	}
+
+	if recorder := f.do(ownerID, http.MethodPost, path+"/items/"+trashed+"/restore", ""); recorder.Code != http.StatusOK {
+		t.Fatalf("restore item: %d %s", recorder.Code, recorder.Body.String())
+	}
+	if recorder := f.do(ownerID, http.MethodPost, path+"/items/"+trashed+"/restore", ""); recorder.Code != http.StatusBadRequest {
+		t.Fatalf("expected an item out of the trash not to be restored, got %d", recorder.Code)
+	}
+	// a trashed item is deleted permanently on the second delete
+	if recorder := f.do(ownerID, http.MethodDelete, path+"/items/"+removed, ""); recorder.Code != http.StatusOK {
+		t.Fatalf("delete item permanently: %d %s", recorder.Code, recorder.Body.String())
+	}
+	if ids := listItems(""); strings.Join(ids, ",") != trashed {
+		t.Fatalf("expected the restored item back, got %v", ids)
+	}
+	if ids := listItems("?deleted=true"); len(ids) != 0 {
+		t.Fatalf("expected an empty trash, got %v", ids)
+	}
+	if recorder := f.do(ownerID, http.MethodPost, path+"/items/"+removed+"/restore", ""); recorder.Code != http.StatusNotFound {
+		t.Fatalf("expected the item to be gone, got %d", recorder.Code)
+	}
+}
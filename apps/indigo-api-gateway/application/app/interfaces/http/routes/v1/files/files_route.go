@@ -0,0 +1,310 @@
+package files
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"menlo.ai/indigo-api-gateway/app/domain/auth"
+	"menlo.ai/indigo-api-gateway/app/domain/file"
+	"menlo.ai/indigo-api-gateway/app/domain/query"
+	"menlo.ai/indigo-api-gateway/app/domain/vectorstore"
+	"menlo.ai/indigo-api-gateway/app/interfaces/http/responses"
+	"menlo.ai/indigo-api-gateway/app/interfaces/http/responses/openai"
+	"menlo.ai/indigo-api-gateway/app/utils/functional"
+	"menlo.ai/indigo-api-gateway/app/utils/ptr"
+)
+
+// multipartOverheadBytes leaves room for the multipart boundaries and form fields around the file
+const multipartOverheadBytes = 1024 * 1024
+
+type FilesRoute struct {
+	authService        *auth.AuthService
+	fileService        *file.FileService
+	vectorStoreService *vectorstore.VectorStoreService
+}
+
+func NewFilesRoute(authService *auth.AuthService, fileService *file.FileService, vectorStoreService *vectorstore.VectorStoreService) *FilesRoute {
+	return &FilesRoute{
+		authService:        authService,
+		fileService:        fileService,
+		vectorStoreService: vectorStoreService,
+	}
+}
+
+func (route *FilesRoute) RegisterRouter(router gin.IRouter) {
+	filesRouter := router.Group("/files",
+		route.authService.AppUserAuthMiddleware(),
+		route.authService.RegisteredUserMiddleware(),
+	)
+	filesRouter.POST("", route.UploadFile)
+	filesRouter.GET("", route.ListFiles)
+
+	fileMiddleware := route.fileService.GetFileMiddleware()
+	fileIDParam := fmt.Sprintf("/:%s", file.FileContextKeyPublicID)
+	filesRouter.GET(fileIDParam, fileMiddleware, route.GetFile)
+	filesRouter.GET(fileIDParam+"/content", fileMiddleware, route.GetFileContent)
+	filesRouter.DELETE(fileIDParam, fileMiddleware, route.DeleteFile)
+}
+
+type FileResponse struct {
+	ID        string `json:"id"`
+	Object    string `json:"object"`
+	Bytes     int64  `json:"bytes"`
+	CreatedAt int64  `json:"created_at"`
+	Filename  string `json:"filename"`
+	Purpose   string `json:"purpose"`
+	MimeType  string `json:"mime_type"`
+}
+
+func domainToFileResponse(f *file.File) *FileResponse {
+	return &FileResponse{
+		ID:        f.PublicID,
+		Object:    "file",
+		Bytes:     f.Bytes,
+		CreatedAt: f.CreatedAt.Unix(),
+		Filename:  f.Filename,
+		Purpose:   string(f.Purpose),
+		MimeType:  f.MimeType,
+	}
+}
+
+// @Summary Upload a file
+// @Description Uploads a file that can be referenced by ID in conversation items and response inputs.
+// @Description Requests made with a project API key store the file in that project.
+// @Description Images are accepted for every purpose; text, markdown, csv, html, json and pdf documents for `assistants` and `user_data`.
+// @Tags Files API
+// @Security BearerAuth
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "The file to upload"
+// @Param purpose formData string true "Intended use of the file: assistants, vision or user_data"
+// @Success 200 {object} FileResponse "Uploaded file"
+// @Failure 400 {object} responses.ErrorResponse "Invalid file or purpose"
+// @Failure 401 {object} responses.ErrorResponse "Unauthorized"
+// @Failure 413 {object} responses.ErrorResponse "File too large"
+// @Router /v1/files [post]
+func (route *FilesRoute) UploadFile(reqCtx *gin.Context) {
+	ctx := reqCtx.Request.Context()
+	owner, ok := file.GetRequestOwner(reqCtx)
+	if !ok {
+		reqCtx.AbortWithStatusJSON(http.StatusUnauthorized, responses.ErrorResponse{
+			Code: "6d1a8e4c-2f9b-4a7e-b3c5-9e2d6a1f8c47",
+		})
+		return
+	}
+
+	reqCtx.Request.Body = http.MaxBytesReader(reqCtx.Writer, reqCtx.Request.Body, file.MaxUploadBytes()+multipartOverheadBytes)
+	header, err := reqCtx.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			reqCtx.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, responses.ErrorResponse{
+				Code:  "2b8f5d1a-7c3e-4a9b-8e6d-4a1c9f3b7e25",
+				Error: fmt.Sprintf("file exceeds the %d bytes limit", file.MaxUploadBytes()),
+			})
+			return
+		}
+		reqCtx.AbortWithStatusJSON(http.StatusBadRequest, responses.ErrorResponse{
+			Code:  "9c4e1a7d-3b6f-4e2a-a8d1-7f5c2e9b4a63",
+			Error: "file is required",
+		})
+		return
+	}
+	body, err := header.Open()
+	if err != nil {
+		reqCtx.AbortWithStatusJSON(http.StatusBadRequest, responses.ErrorResponse{
+			Code:          "4a9d2f6c-8e1b-4c5a-9f3d-1b6e8a4c2d79",
+			ErrorInstance: err,
+		})
+		return
+	}
+	defer body.Close()
+
+	f, uploadErr := route.fileService.Upload(ctx, owner, file.UploadInput{
+		Filename: header.Filename,
+		Purpose:  file.FilePurpose(reqCtx.PostForm("purpose")),
+		MimeType: header.Header.Get("Content-Type"),
+		Size:     header.Size,
+		Body:     body,
+	})
+	if uploadErr != nil {
+		reqCtx.AbortWithStatusJSON(http.StatusBadRequest, responses.ErrorResponse{
+			Code:  uploadErr.GetCode(),
+			Error: uploadErr.Error(),
+		})
+		return
+	}
+	reqCtx.JSON(http.StatusOK, domainToFileResponse(f))
+}
+
+// @Summary List files
+// @Description Lists the files of the authenticated user, scoped to the project when called with a project API key
+// @Tags Files API
+// @Security BearerAuth
+// @Produce json
+// @Param purpose query string false "Only return files with this purpose"
+// @Param limit query int false "The maximum number of files to return" default(20)
+// @Param last query string false "The ID of the last file of the previous page"
+// @Param order query string false "Order by creation: asc or desc" default(asc)
+// @Success 200 {object} openai.ListResponse[FileResponse] "List of files"
+// @Failure 400 {object} responses.ErrorResponse "Invalid parameters"
+// @Failure 401 {object} responses.ErrorResponse "Unauthorized"
+// @Router /v1/files [get]
+func (route *FilesRoute) ListFiles(reqCtx *gin.Context) {
+	ctx := reqCtx.Request.Context()
+	owner, ok := file.GetRequestOwner(reqCtx)
+	if !ok {
+		reqCtx.AbortWithStatusJSON(http.StatusUnauthorized, responses.ErrorResponse{
+			Code: "8e3b6f2a-1d7c-4a9e-b5f1-3c8a6d2e9b14",
+		})
+		return
+	}
+
+	filter := file.FileFilter{}
+	if purpose := reqCtx.Query("purpose"); purpose != "" {
+		if !file.ValidateFilePurpose(purpose) {
+			reqCtx.AbortWithStatusJSON(http.StatusBadRequest, responses.ErrorResponse{
+				Code:  "3e7a1c9f-5b2d-4f8a-9c6e-2a5f8d1b7c36",
+				Error: "Invalid purpose, expected assistants, vision or user_data",
+			})
+			return
+		}
+		filePurpose := file.FilePurpose(purpose)
+		filter.Purpose = &filePurpose
+	}
+
+	pagination, err := query.GetCursorPaginationFromQuery(reqCtx, func(lastID string) (*uint, error) {
+		f, getErr := route.fileService.GetFile(ctx, owner, lastID)
+		if getErr != nil {
+			return nil, getErr
+		}
+		return &f.ID, nil
+	})
+	if err != nil {
+		reqCtx.AbortWithStatusJSON(http.StatusBadRequest, responses.ErrorResponse{
+			Code:  "7b2d9e4a-6c1f-4e3b-a7d2-5e9c1a4f8b63",
+			Error: "Invalid pagination parameters",
+		})
+		return
+	}
+
+	files, total, listErr := route.fileService.ListFiles(ctx, owner, filter, pagination)
+	if listErr != nil {
+		reqCtx.AbortWithStatusJSON(http.StatusInternalServerError, responses.ErrorResponse{
+			Code:          listErr.GetCode(),
+			ErrorInstance: listErr.GetError(),
+		})
+		return
+	}
+
+	var firstID *string
+	var lastID *string
+	hasMore := false
+	if len(files) > 0 {
+		firstID = &files[0].PublicID
+		lastID = &files[len(files)-1].PublicID
+		more, _, moreErr := route.fileService.ListFiles(ctx, owner, filter, &query.Pagination{
+			Order: pagination.Order,
+			Limit: ptr.ToInt(1),
+			After: &files[len(files)-1].ID,
+		})
+		if moreErr != nil {
+			reqCtx.AbortWithStatusJSON(http.StatusInternalServerError, responses.ErrorResponse{
+				Code:          moreErr.GetCode(),
+				ErrorInstance: moreErr.GetError(),
+			})
+			return
+		}
+		hasMore = len(more) > 0
+	}
+
+	reqCtx.JSON(http.StatusOK, openai.ListResponse[*FileResponse]{
+		Object:  openai.ObjectTypeListList,
+		Data:    functional.Map(files, domainToFileResponse),
+		FirstID: firstID,
+		LastID:  lastID,
+		HasMore: hasMore,
+		Total:   total,
+	})
+}
+
+// @Summary Get a file
+// @Description Returns the metadata of a file
+// @Tags Files API
+// @Security BearerAuth
+// @Produce json
+// @Param file_id path string true "The ID of the file"
+// @Success 200 {object} FileResponse "File metadata"
+// @Failure 401 {object} responses.ErrorResponse "Unauthorized"
+// @Failure 404 {object} responses.ErrorResponse "File not found"
+// @Router /v1/files/{file_id} [get]
+func (route *FilesRoute) GetFile(reqCtx *gin.Context) {
+	f, _ := file.GetFileFromContext(reqCtx)
+	reqCtx.JSON(http.StatusOK, domainToFileResponse(f))
+}
+
+// @Summary Download file content
+// @Description Streams the content of a file
+// @Tags Files API
+// @Security BearerAuth
+// @Produce octet-stream
+// @Param file_id path string true "The ID of the file"
+// @Success 200 {file} file "File content"
+// @Failure 401 {object} responses.ErrorResponse "Unauthorized"
+// @Failure 404 {object} responses.ErrorResponse "File not found"
+// @Router /v1/files/{file_id}/content [get]
+func (route *FilesRoute) GetFileContent(reqCtx *gin.Context) {
+	f, _ := file.GetFileFromContext(reqCtx)
+	content, err := route.fileService.OpenContent(reqCtx.Request.Context(), f)
+	if err != nil {
+		reqCtx.AbortWithStatusJSON(http.StatusInternalServerError, responses.ErrorResponse{
+			Code:          err.GetCode(),
+			ErrorInstance: err.GetError(),
+		})
+		return
+	}
+	defer content.Close()
+
+	reqCtx.Header("Content-Type", f.MimeType)
+	reqCtx.Header("Content-Length", strconv.FormatInt(f.Bytes, 10))
+	reqCtx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", f.Filename))
+	reqCtx.Status(http.StatusOK)
+	_, _ = io.Copy(reqCtx.Writer, content)
+}
+
+// @Summary Delete a file
+// @Description Deletes a file and its stored content, and removes it from every vector store it was attached to
+// @Tags Files API
+// @Security BearerAuth
+// @Produce json
+// @Param file_id path string true "The ID of the file"
+// @Success 200 {object} openai.DeleteResponse "Deleted file"
+// @Failure 401 {object} responses.ErrorResponse "Unauthorized"
+// @Failure 404 {object} responses.ErrorResponse "File not found"
+// @Router /v1/files/{file_id} [delete]
+func (route *FilesRoute) DeleteFile(reqCtx *gin.Context) {
+	f, _ := file.GetFileFromContext(reqCtx)
+	if err := route.vectorStoreService.RemoveFile(reqCtx.Request.Context(), f); err != nil {
+		reqCtx.AbortWithStatusJSON(http.StatusInternalServerError, responses.ErrorResponse{
+			Code:          err.GetCode(),
+			ErrorInstance: err.GetError(),
+		})
+		return
+	}
+	if err := route.fileService.DeleteFile(reqCtx.Request.Context(), f); err != nil {
+		reqCtx.AbortWithStatusJSON(http.StatusInternalServerError, responses.ErrorResponse{
+			Code:          err.GetCode(),
+			ErrorInstance: err.GetError(),
+		})
+		return
+	}
+	reqCtx.JSON(http.StatusOK, openai.DeleteResponse{
+		Object:  "file",
+		ID:      f.PublicID,
+		Deleted: true,
+	})
+}
@@ -0,0 +1,208 @@
+package files
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+	"menlo.ai/indigo-api-gateway/app/domain/apikey"
+	"menlo.ai/indigo-api-gateway/app/domain/auth"
+	"menlo.ai/indigo-api-gateway/app/domain/file"
+	"menlo.ai/indigo-api-gateway/app/domain/user"
+	"menlo.ai/indigo-api-gateway/app/domain/vectorstore"
+	"menlo.ai/indigo-api-gateway/app/infrastructure/database/dbschema"
+	"menlo.ai/indigo-api-gateway/app/infrastructure/database/repository/filerepo"
+	"menlo.ai/indigo-api-gateway/app/infrastructure/database/repository/transaction"
+	"menlo.ai/indigo-api-gateway/app/infrastructure/database/repository/vectorstorerepo"
+	"menlo.ai/indigo-api-gateway/app/infrastructure/storage"
+	"menlo.ai/indigo-api-gateway/app/utils/ptr"
+	"menlo.ai/indigo-api-gateway/config/environment_variables"
+)
+
+// newTestRouter serves the file routes, X-Test-User sets the user and X-Test-Project the project of the API key
+func newTestRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{
+		NamingStrategy:                           schema.NamingStrategy{SingularTable: true},
+		DisableForeignKeyConstraintWhenMigrating: true,
+	})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	if err := db.AutoMigrate(&dbschema.File{}, &dbschema.VectorStore{}, &dbschema.VectorStoreFile{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	database := transaction.NewDatabase(db)
+	fileService := file.NewFileService(filerepo.NewFileRepository(database), storage.NewLocalStorage(t.TempDir()))
+	vectorStoreService := vectorstore.NewVectorStoreService(
+		vectorstorerepo.NewVectorStoreRepository(database),
+		vectorstorerepo.NewVectorStoreFileRepository(database),
+		nil, fileService, nil, nil,
+	)
+	route := NewFilesRoute(nil, fileService, vectorStoreService)
+
+	router := gin.New()
+	filesRouter := router.Group("/v1/files", func(reqCtx *gin.Context) {
+		var userID uint
+		fmt.Sscan(reqCtx.GetHeader("X-Test-User"), &userID)
+		auth.SetUserToContext(reqCtx, &user.User{ID: userID})
+		if project := reqCtx.GetHeader("X-Test-Project"); project != "" {
+			var projectID uint
+			fmt.Sscan(project, &projectID)
+			auth.SetAppApiKeyToContext(reqCtx, &apikey.ApiKey{ProjectID: ptr.ToUint(projectID)})
+		}
+	})
+	filesRouter.POST("", route.UploadFile)
+	filesRouter.GET("", route.ListFiles)
+	fileMiddleware := fileService.GetFileMiddleware()
+	fileIDParam := fmt.Sprintf("/:%s", file.FileContextKeyPublicID)
+	filesRouter.GET(fileIDParam, fileMiddleware, route.GetFile)
+	filesRouter.GET(fileIDParam+"/content", fileMiddleware, route.GetFileContent)
+	filesRouter.DELETE(fileIDParam, fileMiddleware, route.DeleteFile)
+	return router
+}
+
+func multipartUpload(t *testing.T, purpose string, filename string, contentType string, content []byte) (*bytes.Buffer, string) {
+	t.Helper()
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	if purpose != "" {
+		_ = writer.WriteField("purpose", purpose)
+	}
+	if filename != "" {
+		header := make(map[string][]string)
+		header["Content-Disposition"] = []string{fmt.Sprintf(`form-data; name="file"; filename=%q`, filename)}
+		header["Content-Type"] = []string{contentType}
+		part, err := writer.CreatePart(header)
+		if err != nil {
+			t.Fatalf("create part: %v", err)
+		}
+		_, _ = part.Write(content)
+	}
+	_ = writer.Close()
+	return body, writer.FormDataContentType()
+}
+
+func do(router *gin.Engine, userID uint, projectID string, method string, path string, body *bytes.Buffer, contentType string) *httptest.ResponseRecorder {
+	if body == nil {
+		body = &bytes.Buffer{}
+	}
+	req := httptest.NewRequest(method, path, body)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	req.Header.Set("X-Test-User", fmt.Sprint(userID))
+	if projectID != "" {
+		req.Header.Set("X-Test-Project", projectID)
+	}
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	return recorder
+}
+
+func uploadFile(t *testing.T, router *gin.Engine, userID uint, projectID string, filename string, content []byte) *FileResponse {
+	t.Helper()
+	body, contentType := multipartUpload(t, "user_data", filename, "text/plain", content)
+	recorder := do(router, userID, projectID, http.MethodPost, "/v1/files", body, contentType)
+	var uploaded FileResponse
+	if recorder.Code != http.StatusOK || json.Unmarshal(recorder.Body.Bytes(), &uploaded) != nil {
+		t.Fatalf("upload: expected 200, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	return &uploaded
+}
+
+func TestUploadFileValidatesTheRequest(t *testing.T) {
+	environment_variables.Override("files_route_test", func(env *environment_variables.EnvironmentVariable) {
+		env.FILE_MAX_UPLOAD_BYTES = 1024
+	})
+	defer environment_variables.Override("files_route_test", func(env *environment_variables.EnvironmentVariable) {})
+	router := newTestRouter(t)
+
+	cases := []struct {
+		name     string
+		purpose  string
+		filename string
+		mimeType string
+		size     int
+		expected int
+	}{
+		{"accepted", "assistants", "notes.txt", "text/plain", 10, http.StatusOK},
+		{"at the limit", "user_data", "notes.txt", "text/plain", 1024, http.StatusOK},
+		{"over the limit", "user_data", "notes.txt", "text/plain", 1025, http.StatusBadRequest},
+		{"over the request limit", "user_data", "notes.txt", "text/plain", multipartOverheadBytes + 2048, http.StatusRequestEntityTooLarge},
+		{"missing file", "assistants", "", "", 0, http.StatusBadRequest},
+		{"missing purpose", "", "notes.txt", "text/plain", 10, http.StatusBadRequest},
+		{"unknown purpose", "fine-tune", "notes.txt", "text/plain", 10, http.StatusBadRequest},
+		{"document for vision", "vision", "notes.txt", "text/plain", 10, http.StatusBadRequest},
+	}
+	for _, tc := range cases {
+		body, contentType := multipartUpload(t, tc.purpose, tc.filename, tc.mimeType, bytes.Repeat([]byte("a"), tc.size))
+		recorder := do(router, 1, "", http.MethodPost, "/v1/files", body, contentType)
+		if recorder.Code != tc.expected {
+			t.Errorf("%s: expected %d, got %d: %s", tc.name, tc.expected, recorder.Code, recorder.Body.String())
+		}
+	}
+
+	body, contentType := multipartUpload(t, "user_data", "notes.txt", "text/plain", []byte("hello"))
+	recorder := do(router, 1, "", http.MethodPost, "/v1/files", body, contentType)
+	var uploaded FileResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &uploaded); err != nil || uploaded.Bytes != 5 || uploaded.Purpose != "user_data" || uploaded.MimeType != "text/plain" {
+		t.Fatalf("unexpected upload response %+v, %v", uploaded, err)
+	}
+}
+
+func TestFileRoutesAreScopedToTheOwner(t *testing.T) {
+	router := newTestRouter(t)
+	personal := uploadFile(t, router, 1, "", "personal.txt", []byte("personal"))
+	project := uploadFile(t, router, 1, "5", "project.txt", []byte("project"))
+
+	cases := []struct {
+		name     string
+		userID   uint
+		project  string
+		file     *FileResponse
+		expected int
+	}{
+		{"owner", 1, "", personal, http.StatusOK},
+		{"owner with the project key", 1, "5", project, http.StatusOK},
+		{"owner with another project key", 1, "6", project, http.StatusNotFound},
+		{"personal file with a project key", 1, "5", personal, http.StatusNotFound},
+		{"another user", 2, "", personal, http.StatusNotFound},
+	}
+	for _, tc := range cases {
+		for _, suffix := range []string{"", "/content"} {
+			recorder := do(router, tc.userID, tc.project, http.MethodGet, "/v1/files/"+tc.file.ID+suffix, nil, "")
+			if recorder.Code != tc.expected {
+				t.Errorf("%s: GET %s expected %d, got %d", tc.name, suffix, tc.expected, recorder.Code)
+			}
+		}
+	}
+
+	recorder := do(router, 1, "", http.MethodGet, "/v1/files/"+personal.ID+"/content", nil, "")
+	if recorder.Body.String() != "personal" || recorder.Header().Get("Content-Type") != "text/plain" ||
+		recorder.Header().Get("Content-Disposition") != `attachment; filename="personal.txt"` {
+		t.Fatalf("unexpected content %q with headers %v", recorder.Body.String(), recorder.Header())
+	}
+
+	if recorder := do(router, 2, "", http.MethodDelete, "/v1/files/"+personal.ID, nil, ""); recorder.Code != http.StatusNotFound {
+		t.Fatalf("expected another user not to delete the file, got %d", recorder.Code)
+	}
+	if recorder := do(router, 1, "6", http.MethodDelete, "/v1/files/"+project.ID, nil, ""); recorder.Code != http.StatusNotFound {
+		t.Fatalf("expected another project key not to delete the file, got %d", recorder.Code)
+	}
+	if recorder := do(router, 1, "", http.MethodDelete, "/v1/files/"+personal.ID, nil, ""); recorder.Code != http.StatusOK {
+		t.Fatalf("expected the owner to delete the file, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	if recorder := do(router, 1, "", http.MethodGet, "/v1/files/"+personal.ID+"/content", nil, ""); recorder.Code != http.StatusNotFound {
+		t.Fatalf("expected the deleted file to be gone, got %d", recorder.Code)
+	}
+}
@@ -4,15 +4,14 @@ import (
 	"bytes"
 	"encoding/json"
 	"io"
-	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	mcpserver "github.com/mark3labs/mcp-go/server"
 	"menlo.ai/indigo-api-gateway/app/domain/auth"
-	"menlo.ai/indigo-api-gateway/app/interfaces/http/responses/openai"
+	"menlo.ai/indigo-api-gateway/app/domain/mcp/mcpactivity"
+	"menlo.ai/indigo-api-gateway/app/domain/project"
+	"menlo.ai/indigo-api-gateway/app/interfaces/http/middleware"
 	mcpimpl "menlo.ai/indigo-api-gateway/app/interfaces/http/routes/v1/mcp/mcp_impl"
-	"menlo.ai/indigo-api-gateway/app/utils/ptr"
 )
 
 func MCPMethodGuard(allowedMethods map[string]bool, api *MCPAPI) gin.HandlerFunc {
@@ -24,8 +23,8 @@ func MCPMethodGuard(allowedMethods map[string]bool, api *MCPAPI) gin.HandlerFunc
 		}
 		c.Request.Body = io.NopCloser(bytes.NewBuffer(bodyBytes))
 		var req struct {
-			Method string                 `json:"method"`
-			Params map[string]interface{} `json:"params"`
+			Method string          `json:"method"`
+			Params json.RawMessage `json:"params"`
 		}
 
 		if err := json.Unmarshal(bodyBytes, &req); err != nil {
@@ -38,70 +37,63 @@ func MCPMethodGuard(allowedMethods map[string]bool, api *MCPAPI) gin.HandlerFunc
 			return
 		}
 
-		if api != nil {
-			var userID string
-			if id, ok := auth.GetUserIDFromContext(c); ok {
-				userID = id
-			}
-			tool := extractToolName(req.Method, req.Params)
-			api.appendActivity(userID, req.Method, tool)
+		if api == nil || api.activityService == nil {
+			c.Next()
+			return
 		}
 
+		started := time.Now()
+		capture := &responseCapture{ResponseWriter: c.Writer}
+		c.Writer = capture
 		c.Next()
+
+		api.activityService.Record(c.Request.Context(), newActivity(c, req.Method, req.Params, started, capture))
 	}
 }
 
-func extractToolName(method string, params map[string]interface{}) string {
-	if method != "tools/call" {
+func extractToolName(method string, params json.RawMessage) string {
+	if method != "tools/call" || len(params) == 0 {
 		return ""
 	}
-	if params == nil {
+	var call struct {
+		Name string `json:"name"`
+		Tool string `json:"tool"`
+	}
+	if err := json.Unmarshal(params, &call); err != nil {
 		return ""
 	}
-	if raw, ok := params["name"]; ok {
-		if value, okCast := raw.(string); okCast {
-			return value
-		}
+	if call.Name != "" {
+		return call.Name
 	}
-	if raw, ok := params["tool"]; ok {
-		if value, okCast := raw.(string); okCast {
-			return value
-		}
-	}
-	return ""
+	return call.Tool
 }
 
 type MCPAPI struct {
-	SerperMCP   *mcpimpl.SerperMCP
-	MCPServer   *mcpserver.MCPServer
-	authService *auth.AuthService
-	activityLog []*MCPActivityResponse
-	activityMu  sync.Mutex
-}
-
-type MCPActivityResponse struct {
-	Object    string  `json:"object"`
-	Method    string  `json:"method"`
-	Tool      *string `json:"tool,omitempty"`
-	UserID    *string `json:"user_id,omitempty"`
-	CreatedAt int64   `json:"created_at"`
+	FederatedMCP    *mcpimpl.FederatedMCP
+	authService     *auth.AuthService
+	activityService *mcpactivity.ActivityService
+	projectService  *project.ProjectService
 }
 
-func NewMCPAPI(serperMCP *mcpimpl.SerperMCP, authService *auth.AuthService) *MCPAPI {
-	mcpSrv := mcpserver.NewMCPServer("demo", "0.1.0",
-		mcpserver.WithToolCapabilities(true),
-		mcpserver.WithRecovery(),
-	)
+func NewMCPAPI(
+	federatedMCP *mcpimpl.FederatedMCP,
+	authService *auth.AuthService,
+	activityService *mcpactivity.ActivityService,
+	projectService *project.ProjectService,
+) *MCPAPI {
 	return &MCPAPI{
-		SerperMCP:   serperMCP,
-		MCPServer:   mcpSrv,
-		authService: authService,
+		FederatedMCP:    federatedMCP,
+		authService:     authService,
+		activityService: activityService,
+		projectService:  projectService,
 	}
 }
 
 // MCPStream
 // @Summary MCP streamable endpoint
 // @Description Handles Model Context Protocol (MCP) requests over an HTTP stream. The response is sent as a continuous stream of data.
+// @Description Besides the built-in tools, the tools and prompts of the external MCP servers registered by the organization are exposed as `<server>__<name>`,
+// @Description limited to the servers and the tool allowlist of the project of the API key.
 // @Tags Chat Completions API
 // @Security BearerAuth
 // @Accept json
@@ -110,11 +102,10 @@ func NewMCPAPI(serperMCP *mcpimpl.SerperMCP, authService *auth.AuthService) *MCP
 // @Success 200 {string} string "Streamed response (SSE or chunked transfer)"
 // @Router /v1/mcp [post]
 func (mcpAPI *MCPAPI) RegisterRouter(router *gin.RouterGroup) {
-	mcpAPI.SerperMCP.RegisterTool(mcpAPI.MCPServer)
-
-	mcpHttpHandler := mcpserver.NewStreamableHTTPServer(mcpAPI.MCPServer)
 	router.Any(
 		"/mcp",
+		// Tool arguments and results carry user content
+		middleware.OmitBodiesFromLogs(),
 		mcpAPI.authService.AppUserAuthMiddleware(),
 		MCPMethodGuard(map[string]bool{
 			// Initialization / handshake
@@ -128,7 +119,7 @@ func (mcpAPI *MCPAPI) RegisterRouter(router *gin.RouterGroup) {
 
 			// Prompts
 			"prompts/list": true,
-			"prompts/call": true,
+			"prompts/get":  true,
 
 			// Resources
 			"resources/list":           true,
@@ -138,51 +129,19 @@ func (mcpAPI *MCPAPI) RegisterRouter(router *gin.RouterGroup) {
 			// If you support subscription:
 			"resources/subscribe": true,
 		}, mcpAPI),
-		gin.WrapH(mcpHttpHandler))
+		mcpAPI.serveMCP)
 
-	router.GET(
-		"/mcp/activity",
+	activityRouter := router.Group("/mcp/activity",
 		mcpAPI.authService.AdminUserAuthMiddleware(),
-		mcpAPI.GetActivity,
+		mcpAPI.authService.RegisteredUserMiddleware(),
+		mcpAPI.authService.OrganizationMemberRoleMiddleware(auth.OrganizationMemberRuleAll),
 	)
+	activityRouter.GET("", mcpAPI.GetActivity)
+	activityRouter.GET("/stats", mcpAPI.GetActivityStats)
 }
 
-// GetActivity godoc
-// @Summary List MCP activity
-// @Description Returns recent Model Context Protocol interactions captured by the gateway.
-// @Tags Administration API
-// @Security BearerAuth
-// @Success 200 {object} openai.ListResponse[*MCPActivityResponse]
-// @Router /v1/mcp/activity [get]
-func (mcpAPI *MCPAPI) GetActivity(reqCtx *gin.Context) {
-	mcpAPI.activityMu.Lock()
-	data := make([]*MCPActivityResponse, len(mcpAPI.activityLog))
-	copy(data, mcpAPI.activityLog)
-	mcpAPI.activityMu.Unlock()
-	reqCtx.JSON(200, openai.ListResponse[*MCPActivityResponse]{
-		Object: "list",
-		Data:   data,
-		Total:  int64(len(data)),
-	})
-}
-
-func (mcpAPI *MCPAPI) appendActivity(userID string, method string, tool string) {
-	entry := &MCPActivityResponse{
-		Object:    "mcp.activity",
-		Method:    method,
-		CreatedAt: time.Now().Unix(),
-	}
-	if tool != "" {
-		entry.Tool = ptr.ToString(tool)
-	}
-	if userID != "" {
-		entry.UserID = ptr.ToString(userID)
-	}
-	mcpAPI.activityMu.Lock()
-	defer mcpAPI.activityMu.Unlock()
-	mcpAPI.activityLog = append(mcpAPI.activityLog, entry)
-	const maxEntries = 200
-	if len(mcpAPI.activityLog) > maxEntries {
-		mcpAPI.activityLog = mcpAPI.activityLog[len(mcpAPI.activityLog)-maxEntries:]
-	}
+// serveMCP hands the request to the MCP server of the project of the API key
+func (mcpAPI *MCPAPI) serveMCP(reqCtx *gin.Context) {
+	handler := mcpAPI.FederatedMCP.Handler(reqCtx.Request.Context(), auth.GetRequestProjectID(reqCtx))
+	handler.ServeHTTP(reqCtx.Writer, reqCtx.Request)
 }
@@ -0,0 +1,415 @@
+package mcp
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"menlo.ai/indigo-api-gateway/app/domain/auth"
+	"menlo.ai/indigo-api-gateway/app/domain/mcp/mcpactivity"
+	"menlo.ai/indigo-api-gateway/app/domain/organization"
+	"menlo.ai/indigo-api-gateway/app/domain/project"
+	"menlo.ai/indigo-api-gateway/app/domain/query"
+	"menlo.ai/indigo-api-gateway/app/interfaces/http/responses"
+	"menlo.ai/indigo-api-gateway/app/interfaces/http/responses/openai"
+	"menlo.ai/indigo-api-gateway/app/utils/ptr"
+)
+
+// maxCapturedResponseBytes bounds the response kept to find out whether an MCP request failed,
+// larger responses are judged by their status code only
+const maxCapturedResponseBytes = 1 << 20
+
+// responseCapture copies the MCP response while it is written to the client
+type responseCapture struct {
+	gin.ResponseWriter
+	body      bytes.Buffer
+	truncated bool
+}
+
+func (w *responseCapture) Write(data []byte) (int, error) {
+	w.capture(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *responseCapture) WriteString(data string) (int, error) {
+	w.capture([]byte(data))
+	return w.ResponseWriter.WriteString(data)
+}
+
+func (w *responseCapture) capture(data []byte) {
+	if w.truncated {
+		return
+	}
+	if w.body.Len()+len(data) > maxCapturedResponseBytes {
+		w.truncated = true
+		w.body.Reset()
+		return
+	}
+	w.body.Write(data)
+}
+
+func newActivity(c *gin.Context, method string, params json.RawMessage, started time.Time, capture *responseCapture) *mcpactivity.Activity {
+	success, errMessage := exchangeOutcome(capture.Status(), capture.Header().Get("Content-Type"), capture.body.Bytes(), capture.truncated)
+	activity := &mcpactivity.Activity{
+		Method:        method,
+		ArgumentBytes: len(params),
+		LatencyMs:     time.Since(started).Milliseconds(),
+		Success:       success,
+		Error:         errMessage,
+		CreatedAt:     started,
+	}
+	if organization.DEFAULT_ORGANIZATION != nil {
+		activity.OrganizationID = organization.DEFAULT_ORGANIZATION.ID
+	}
+	if tool := extractToolName(method, params); tool != "" {
+		activity.Tool = ptr.ToString(tool)
+	}
+	if userID, ok := auth.GetUserIDFromContext(c); ok && userID != "" {
+		activity.UserPublicID = ptr.ToString(userID)
+	}
+	if apiKey, ok := auth.GetAppApiKeyFromContext(c); ok {
+		activity.APIKeyPublicID = ptr.ToString(apiKey.PublicID)
+		activity.ProjectID = apiKey.ProjectID
+		if apiKey.OrganizationID != nil {
+			activity.OrganizationID = *apiKey.OrganizationID
+		}
+	}
+	return activity
+}
+
+// exchangeOutcome reports whether an MCP request succeeded, from its HTTP status and its JSON-RPC response.
+// Tool results flagged with isError count as failures.
+func exchangeOutcome(status int, contentType string, body []byte, truncated bool) (bool, *string) {
+	if status >= http.StatusBadRequest {
+		message := strings.TrimSpace(string(body))
+		if message == "" || truncated {
+			message = http.StatusText(status)
+		}
+		return false, &message
+	}
+	if truncated {
+		return true, nil
+	}
+
+	payload := body
+	if strings.HasPrefix(contentType, "text/event-stream") {
+		// the JSON-RPC response is the last event of the stream, notifications come before it
+		payload = nil
+		for _, line := range bytes.Split(body, []byte("\n")) {
+			if data, ok := bytes.CutPrefix(line, []byte("data:")); ok {
+				payload = bytes.TrimSpace(data)
+			}
+		}
+	}
+	if len(bytes.TrimSpace(payload)) == 0 {
+		return true, nil
+	}
+
+	var message struct {
+		Error *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+		Result *struct {
+			IsError bool `json:"isError"`
+			Content []struct {
+				Type string `json:"type"`
+				Text string `json:"text"`
+			} `json:"content"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(payload, &message); err != nil {
+		return true, nil
+	}
+	if message.Error != nil {
+		return false, ptr.ToString(message.Error.Message)
+	}
+	if message.Result != nil && message.Result.IsError {
+		for _, content := range message.Result.Content {
+			if content.Type == "text" && content.Text != "" {
+				return false, ptr.ToString(content.Text)
+			}
+		}
+		return false, ptr.ToString("tool returned an error")
+	}
+	return true, nil
+}
+
+type MCPActivityResponse struct {
+	Object        string  `json:"object"`
+	ID            string  `json:"id"`
+	Method        string  `json:"method"`
+	Tool          *string `json:"tool,omitempty"`
+	UserID        *string `json:"user_id,omitempty"`
+	APIKeyID      *string `json:"api_key_id,omitempty"`
+	Project       *string `json:"project_public_id,omitempty"`
+	ArgumentBytes int     `json:"argument_bytes"`
+	LatencyMs     int64   `json:"latency_ms"`
+	// Status is success or error
+	Status    string  `json:"status"`
+	Error     *string `json:"error,omitempty"`
+	CreatedAt int64   `json:"created_at"`
+}
+
+type MCPToolStatsResponse struct {
+	Object       string  `json:"object"`
+	Tool         string  `json:"tool"`
+	Calls        int64   `json:"calls"`
+	Errors       int64   `json:"errors"`
+	ErrorRate    float64 `json:"error_rate"`
+	AvgLatencyMs float64 `json:"avg_latency_ms"`
+	P95LatencyMs float64 `json:"p95_latency_ms"`
+	LastCalledAt int64   `json:"last_called_at"`
+}
+
+// GetActivity godoc
+// @Summary List MCP activity
+// @Description Returns the MCP requests handled by the gateway, newest first unless order=asc. Use `last` with the id of the last entry of a page to get the next one.
+// @Tags Administration API
+// @Security BearerAuth
+// @Produce json
+// @Param project_public_id query string false "Only requests made with API keys of this project"
+// @Param user_id query string false "Only requests of this user"
+// @Param api_key_id query string false "Only requests made with this API key"
+// @Param method query string false "JSON-RPC method, e.g. tools/call"
+// @Param tool query string false "Called tool"
+// @Param status query string false "success or error"
+// @Param since query int false "Only requests made at or after this unix timestamp"
+// @Param until query int false "Only requests made before this unix timestamp"
+// @Param limit query int false "The maximum number of items to return" default(20)
+// @Param last query string false "The id of the last entry of the previous page"
+// @Param order query string false "desc (default) or asc"
+// @Success 200 {object} openai.ListResponse[MCPActivityResponse]
+// @Failure 400 {object} responses.ErrorResponse "Invalid parameters"
+// @Failure 401 {object} responses.ErrorResponse "Unauthorized"
+// @Router /v1/mcp/activity [get]
+func (mcpAPI *MCPAPI) GetActivity(reqCtx *gin.Context) {
+	ctx := reqCtx.Request.Context()
+	filter, ok := mcpAPI.activityFilterFromQuery(reqCtx)
+	if !ok {
+		return
+	}
+	if method := strings.TrimSpace(reqCtx.Query("method")); method != "" {
+		filter.Method = &method
+	}
+	if tool := strings.TrimSpace(reqCtx.Query("tool")); tool != "" {
+		filter.Tool = &tool
+	}
+	switch reqCtx.Query("status") {
+	case "":
+	case "success":
+		filter.Success = ptr.ToBool(true)
+	case "error":
+		filter.Success = ptr.ToBool(false)
+	default:
+		reqCtx.AbortWithStatusJSON(http.StatusBadRequest, responses.ErrorResponse{
+			Code:  "2c9e6a3f-7b1d-4e8a-a5c4-9f3b7d1e6a52",
+			Error: "status must be success or error",
+		})
+		return
+	}
+
+	pagination, err := query.GetCursorPaginationFromQuery(reqCtx, func(lastID string) (*uint, error) {
+		activity, findErr := mcpAPI.activityService.FindActivity(ctx, filter, lastID)
+		if findErr != nil {
+			return nil, fmt.Errorf("%s: %s", findErr.GetCode(), findErr.Error())
+		}
+		if activity == nil {
+			return nil, fmt.Errorf("invalid activity")
+		}
+		return &activity.ID, nil
+	})
+	if err != nil {
+		reqCtx.AbortWithStatusJSON(http.StatusBadRequest, responses.ErrorResponse{
+			Code:  "6f3a8d1c-4e9b-4b7a-9c2e-1d5f8a3c7b64",
+			Error: "Invalid pagination parameters",
+		})
+		return
+	}
+	if reqCtx.Query("order") == "" {
+		pagination.Order = "desc"
+	}
+
+	activities, total, listErr := mcpAPI.activityService.ListActivity(ctx, filter, pagination)
+	if listErr != nil {
+		reqCtx.AbortWithStatusJSON(http.StatusInternalServerError, responses.ErrorResponse{
+			Code:  listErr.GetCode(),
+			Error: listErr.GetMessage(),
+		})
+		return
+	}
+
+	var firstID *string
+	var lastID *string
+	hasMore := false
+	if len(activities) > 0 {
+		firstID = &activities[0].PublicID
+		lastID = &activities[len(activities)-1].PublicID
+		more, _, moreErr := mcpAPI.activityService.ListActivity(ctx, filter, &query.Pagination{
+			Order: pagination.Order,
+			Limit: ptr.ToInt(1),
+			After: &activities[len(activities)-1].ID,
+		})
+		if moreErr != nil {
+			reqCtx.AbortWithStatusJSON(http.StatusInternalServerError, responses.ErrorResponse{
+				Code:  moreErr.GetCode(),
+				Error: moreErr.GetMessage(),
+			})
+			return
+		}
+		hasMore = len(more) > 0
+	}
+
+	projectPublicIDs := make(map[uint]string)
+	data := make([]MCPActivityResponse, 0, len(activities))
+	for _, activity := range activities {
+		data = append(data, mcpAPI.toActivityResponse(reqCtx, activity, projectPublicIDs))
+	}
+	reqCtx.JSON(http.StatusOK, openai.ListResponse[MCPActivityResponse]{
+		Object:  openai.ObjectTypeListList,
+		Data:    data,
+		FirstID: firstID,
+		LastID:  lastID,
+		HasMore: hasMore,
+		Total:   total,
+	})
+}
+
+// GetActivityStats godoc
+// @Summary Get MCP tool statistics
+// @Description Aggregates the tools/call activity per tool: number of calls and errors, error rate, average and p95 latency and time of the last call
+// @Tags Administration API
+// @Security BearerAuth
+// @Produce json
+// @Param project_public_id query string false "Only requests made with API keys of this project"
+// @Param user_id query string false "Only requests of this user"
+// @Param api_key_id query string false "Only requests made with this API key"
+// @Param since query int false "Only requests made at or after this unix timestamp"
+// @Param until query int false "Only requests made before this unix timestamp"
+// @Success 200 {object} openai.ListResponse[MCPToolStatsResponse]
+// @Failure 400 {object} responses.ErrorResponse "Invalid parameters"
+// @Failure 401 {object} responses.ErrorResponse "Unauthorized"
+// @Router /v1/mcp/activity/stats [get]
+func (mcpAPI *MCPAPI) GetActivityStats(reqCtx *gin.Context) {
+	filter, ok := mcpAPI.activityFilterFromQuery(reqCtx)
+	if !ok {
+		return
+	}
+	stats, err := mcpAPI.activityService.ToolStats(reqCtx.Request.Context(), filter)
+	if err != nil {
+		reqCtx.AbortWithStatusJSON(http.StatusInternalServerError, responses.ErrorResponse{
+			Code:  err.GetCode(),
+			Error: err.GetMessage(),
+		})
+		return
+	}
+
+	data := make([]MCPToolStatsResponse, 0, len(stats))
+	for _, stat := range stats {
+		item := MCPToolStatsResponse{
+			Object:       "mcp.tool_stats",
+			Tool:         stat.Tool,
+			Calls:        stat.Calls,
+			Errors:       stat.Errors,
+			AvgLatencyMs: stat.AvgLatencyMs,
+			P95LatencyMs: stat.P95LatencyMs,
+			LastCalledAt: stat.LastCalledAt.Unix(),
+		}
+		if stat.Calls > 0 {
+			item.ErrorRate = float64(stat.Errors) / float64(stat.Calls)
+		}
+		data = append(data, item)
+	}
+	reqCtx.JSON(http.StatusOK, openai.ListResponse[MCPToolStatsResponse]{
+		Object: openai.ObjectTypeListList,
+		Data:   data,
+		Total:  int64(len(data)),
+	})
+}
+
+// activityFilterFromQuery reads the filters shared by the activity list and the statistics, it aborts on invalid values
+func (mcpAPI *MCPAPI) activityFilterFromQuery(reqCtx *gin.Context) (mcpactivity.ActivityFilter, bool) {
+	filter := mcpactivity.ActivityFilter{}
+	orgEntity, ok := auth.GetAdminOrganizationFromContext(reqCtx)
+	if !ok {
+		return filter, false
+	}
+	filter.OrganizationID = &orgEntity.ID
+
+	if projectPublicID := strings.TrimSpace(reqCtx.Query("project_public_id")); projectPublicID != "" {
+		projectEntity, err := mcpAPI.projectService.FindOne(reqCtx.Request.Context(), project.ProjectFilter{
+			PublicID:       &projectPublicID,
+			OrganizationID: &orgEntity.ID,
+		})
+		if err != nil || projectEntity == nil {
+			reqCtx.AbortWithStatusJSON(http.StatusBadRequest, responses.ErrorResponse{
+				Code:  "9b4e1d7a-3c6f-4a2e-8d5b-7f1a4c9e2d36",
+				Error: "invalid project_public_id",
+			})
+			return filter, false
+		}
+		filter.ProjectID = &projectEntity.ID
+	}
+	if userID := strings.TrimSpace(reqCtx.Query("user_id")); userID != "" {
+		filter.UserPublicID = &userID
+	}
+	if apiKeyID := strings.TrimSpace(reqCtx.Query("api_key_id")); apiKeyID != "" {
+		filter.APIKeyPublicID = &apiKeyID
+	}
+	for param, target := range map[string]**time.Time{
+		"since": &filter.CreatedAfter,
+		"until": &filter.CreatedBefore,
+	} {
+		raw := reqCtx.Query(param)
+		if raw == "" {
+			continue
+		}
+		seconds, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || seconds < 0 {
+			reqCtx.AbortWithStatusJSON(http.StatusBadRequest, responses.ErrorResponse{
+				Code:  "4d7a2f9c-1e6b-4c8d-b3a5-8e2d6f1c9a47",
+				Error: param + " must be a unix timestamp",
+			})
+			return filter, false
+		}
+		value := time.Unix(seconds, 0)
+		*target = &value
+	}
+	return filter, true
+}
+
+func (mcpAPI *MCPAPI) toActivityResponse(reqCtx *gin.Context, activity *mcpactivity.Activity, projectPublicIDs map[uint]string) MCPActivityResponse {
+	resp := MCPActivityResponse{
+		Object:        "mcp.activity",
+		ID:            activity.PublicID,
+		Method:        activity.Method,
+		Tool:          activity.Tool,
+		UserID:        activity.UserPublicID,
+		APIKeyID:      activity.APIKeyPublicID,
+		ArgumentBytes: activity.ArgumentBytes,
+		LatencyMs:     activity.LatencyMs,
+		Status:        "success",
+		Error:         activity.Error,
+		CreatedAt:     activity.CreatedAt.Unix(),
+	}
+	if !activity.Success {
+		resp.Status = "error"
+	}
+	if activity.ProjectID != nil {
+		publicID, ok := projectPublicIDs[*activity.ProjectID]
+		if !ok {
+			if projectEntity, err := mcpAPI.projectService.FindProjectByID(reqCtx.Request.Context(), *activity.ProjectID); err == nil && projectEntity != nil {
+				publicID = projectEntity.PublicID
+			}
+			projectPublicIDs[*activity.ProjectID] = publicID
+		}
+		if publicID != "" {
+			resp.Project = ptr.ToString(publicID)
+		}
+	}
+	return resp
+}
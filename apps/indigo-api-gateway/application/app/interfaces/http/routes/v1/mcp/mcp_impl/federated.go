@@ -0,0 +1,279 @@
+package mcpimpl
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	mcpclient "github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+	mcpserver "github.com/mark3labs/mcp-go/server"
+	"golang.org/x/sync/singleflight"
+	"menlo.ai/indigo-api-gateway/app/domain/mcp/federatedmcp"
+	"menlo.ai/indigo-api-gateway/app/domain/organization"
+	"menlo.ai/indigo-api-gateway/app/utils/logger"
+)
+
+const (
+	federatedServerName    = "indigo-api-gateway"
+	federatedServerVersion = "1.0.0"
+	// scopeRefreshInterval is how often a scope is rebuilt in the background to follow the backends,
+	// changes made through this instance are picked up immediately
+	scopeRefreshInterval = time.Minute
+	discoveryTimeout     = 15 * time.Second
+)
+
+// FederatedMCP exposes the built-in tools together with the tools, prompts and resources of the registered MCP servers.
+// Every project gets its own MCP server so it only sees the backends and tools it is allowed to use.
+type FederatedMCP struct {
+	serperMCP        *SerperMCP
+	federatedService *federatedmcp.FederatedMCPService
+
+	mu     sync.Mutex
+	scopes map[scopeKey]*federatedScope
+	// rebuilds runs a single rebuild per project at a time, concurrent requests share its result
+	rebuilds singleflight.Group
+}
+
+// scopeKey identifies the MCP server of a project, projectID is 0 for the keys of the organization without project
+type scopeKey struct {
+	organizationID uint
+	projectID      uint
+}
+
+type federatedScope struct {
+	server     *mcpserver.MCPServer
+	handler    http.Handler
+	generation uint64
+	builtAt    time.Time
+}
+
+func NewFederatedMCP(serperMCP *SerperMCP, federatedService *federatedmcp.FederatedMCPService) *FederatedMCP {
+	return &FederatedMCP{
+		serperMCP:        serperMCP,
+		federatedService: federatedService,
+		scopes:           make(map[scopeKey]*federatedScope),
+	}
+}
+
+// Handler returns the streamable HTTP handler of a project, or of the keys without project when projectID is nil,
+// in the organization the request acts for, see organization.WithOrganizationID
+func (f *FederatedMCP) Handler(ctx context.Context, projectID *uint) http.Handler {
+	return f.scope(ctx, projectID).handler
+}
+
+// Connect returns an initialized in-process client of the MCP server of a project, the caller closes it
+func (f *FederatedMCP) Connect(ctx context.Context, projectID *uint) (*mcpclient.Client, error) {
+	return ConnectInProcess(ctx, f.scope(ctx, projectID).server)
+}
+
+// ConnectInProcess returns an initialized client calling the server directly
+func ConnectInProcess(ctx context.Context, server *mcpserver.MCPServer) (*mcpclient.Client, error) {
+	client, err := mcpclient.NewInProcessClient(server)
+	if err != nil {
+		return nil, err
+	}
+	if err := client.Start(ctx); err != nil {
+		return nil, err
+	}
+	_, err = client.Initialize(ctx, mcp.InitializeRequest{
+		Params: mcp.InitializeParams{
+			ProtocolVersion: mcp.LATEST_PROTOCOL_VERSION,
+			ClientInfo: mcp.Implementation{
+				Name:    federatedServerName,
+				Version: federatedServerVersion,
+			},
+		},
+	})
+	if err != nil {
+		_ = client.Close()
+		return nil, err
+	}
+	return client, nil
+}
+
+// scope returns the MCP server of a project. A scope older than scopeRefreshInterval keeps being served while it is
+// rebuilt in the background; a missing scope or one built before a change made through this instance is waited for.
+func (f *FederatedMCP) scope(ctx context.Context, projectID *uint) *federatedScope {
+	key := scopeKey{organizationID: organization.DEFAULT_ORGANIZATION.ID}
+	if organizationID, ok := organization.OrganizationIDFromContext(ctx); ok {
+		key.organizationID = organizationID
+	}
+	if projectID != nil {
+		key.projectID = *projectID
+	}
+	generation := f.federatedService.Generation()
+
+	f.mu.Lock()
+	scope, ok := f.scopes[key]
+	f.mu.Unlock()
+	if ok && scope.generation == generation {
+		if time.Since(scope.builtAt) >= scopeRefreshInterval {
+			f.rebuild(ctx, projectID, key, generation)
+		}
+		return scope
+	}
+	return (<-f.rebuild(ctx, projectID, key, generation)).Val.(*federatedScope)
+}
+
+// rebuild builds the scope of a project unless a rebuild for the same generation is already running,
+// the channel is buffered so callers serving the stale scope do not have to read it
+func (f *FederatedMCP) rebuild(ctx context.Context, projectID *uint, key scopeKey, generation uint64) <-chan singleflight.Result {
+	// the rebuild outlives the request that started it
+	ctx = context.WithoutCancel(ctx)
+	return f.rebuilds.DoChan(fmt.Sprintf("%d:%d:%d", key.organizationID, key.projectID, generation), func() (any, error) {
+		server := f.buildServer(ctx, key.organizationID, projectID)
+		scope := &federatedScope{
+			server:     server,
+			handler:    mcpserver.NewStreamableHTTPServer(server),
+			generation: generation,
+			builtAt:    time.Now(),
+		}
+		f.mu.Lock()
+		// a rebuild started before a change must not replace the scope built after it
+		if current, ok := f.scopes[key]; !ok || current.generation <= generation {
+			f.scopes[key] = scope
+		}
+		f.mu.Unlock()
+		return scope, nil
+	})
+}
+
+func (f *FederatedMCP) buildServer(ctx context.Context, organizationID uint, projectID *uint) *mcpserver.MCPServer {
+	server := mcpserver.NewMCPServer(federatedServerName, federatedServerVersion,
+		mcpserver.WithToolCapabilities(true),
+		mcpserver.WithPromptCapabilities(true),
+		mcpserver.WithResourceCapabilities(false, true),
+		mcpserver.WithRecovery(),
+	)
+
+	// tools, prompts and resources left out of the allowlist are not registered, so they can neither be listed nor used
+	var allowlist *federatedmcp.ToolAllowlist
+	if projectID != nil {
+		found, err := f.federatedService.GetToolAllowlist(ctx, *projectID)
+		if err != nil {
+			logger.GetLogger().Errorf("failed to load the MCP tool allowlist of project %d: %s", *projectID, err.GetMessage())
+			// fail closed rather than exposing every tool
+			found = &federatedmcp.ToolAllowlist{}
+		}
+		allowlist = found
+	}
+
+	for _, tool := range f.serperMCP.Tools() {
+		if allowlist.Allows(tool.Tool.Name) {
+			server.AddTools(tool)
+		}
+	}
+
+	backends, err := f.federatedService.ListExposedServers(ctx, organizationID, projectID)
+	if err != nil {
+		logger.GetLogger().Errorf("failed to list the federated MCP servers: %s", err.GetMessage())
+		return server
+	}
+
+	discovered := make([]*federatedmcp.Capabilities, len(backends))
+	var wg sync.WaitGroup
+	for i, backend := range backends {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			discoverCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), discoveryTimeout)
+			defer cancel()
+			capabilities, err := f.federatedService.Discover(discoverCtx, backend)
+			if err != nil {
+				// an unreachable backend only hides its own tools
+				logger.GetLogger().Warnf("failed to discover MCP server %s: %v", backend.Name, err)
+				return
+			}
+			discovered[i] = capabilities
+		}()
+	}
+	wg.Wait()
+
+	resourceOwners := make(map[string]string)
+	for i, backend := range backends {
+		capabilities := discovered[i]
+		if capabilities == nil {
+			continue
+		}
+		for _, tool := range capabilities.Tools {
+			name := federatedmcp.NamespacedName(backend.Name, tool.Name)
+			if !allowlist.Allows(name) {
+				continue
+			}
+			server.AddTool(namespacedTool(tool, name), f.proxyTool(backend, tool.Name))
+		}
+		for _, prompt := range capabilities.Prompts {
+			backendName := prompt.Name
+			prompt.Name = federatedmcp.NamespacedName(backend.Name, backendName)
+			if !allowlist.Allows(prompt.Name) {
+				continue
+			}
+			server.AddPrompt(prompt, f.proxyPrompt(backend, backendName))
+		}
+		// resources keep their URIs so links returned by tools stay valid, the first server claiming a URI serves it
+		for _, resource := range capabilities.Resources {
+			if !allowlist.Allows(federatedmcp.NamespacedName(backend.Name, resource.URI)) {
+				continue
+			}
+			if owner, ok := resourceOwners[resource.URI]; ok {
+				logger.GetLogger().Warnf("MCP server %s exposes resource %s already served by %s", backend.Name, resource.URI, owner)
+				continue
+			}
+			resourceOwners[resource.URI] = backend.Name
+			server.AddResource(resource, f.proxyResource(backend))
+		}
+		for _, template := range capabilities.ResourceTemplates {
+			if template.URITemplate == nil {
+				continue
+			}
+			uriTemplate := template.URITemplate.Raw()
+			if !allowlist.Allows(federatedmcp.NamespacedName(backend.Name, uriTemplate)) {
+				continue
+			}
+			if owner, ok := resourceOwners[uriTemplate]; ok {
+				logger.GetLogger().Warnf("MCP server %s exposes resource template %s already served by %s", backend.Name, uriTemplate, owner)
+				continue
+			}
+			resourceOwners[uriTemplate] = backend.Name
+			server.AddResourceTemplate(template, mcpserver.ResourceTemplateHandlerFunc(f.proxyResource(backend)))
+		}
+	}
+	return server
+}
+
+func namespacedTool(tool mcp.Tool, name string) mcp.Tool {
+	tool.Name = name
+	if tool.Annotations.Title != "" {
+		tool.Annotations.Title = name + ": " + tool.Annotations.Title
+	}
+	return tool
+}
+
+func (f *FederatedMCP) proxyTool(backend *federatedmcp.Server, backendName string) mcpserver.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		result, err := f.federatedService.CallTool(ctx, backend, backendName, request.Params.Arguments)
+		if err != nil {
+			return mcp.NewToolResultErrorf("MCP server %s failed: %v", backend.Name, err), nil
+		}
+		return result, nil
+	}
+}
+
+func (f *FederatedMCP) proxyPrompt(backend *federatedmcp.Server, backendName string) mcpserver.PromptHandlerFunc {
+	return func(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+		return f.federatedService.GetPrompt(ctx, backend, backendName, request.Params.Arguments)
+	}
+}
+
+func (f *FederatedMCP) proxyResource(backend *federatedmcp.Server) mcpserver.ResourceHandlerFunc {
+	return func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		result, err := f.federatedService.ReadResource(ctx, backend, request.Params.URI)
+		if err != nil {
+			return nil, err
+		}
+		return result.Contents, nil
+	}
+}
@@ -0,0 +1,264 @@
+package mcpimpl
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	mcpserver "github.com/mark3labs/mcp-go/server"
+	"menlo.ai/indigo-api-gateway/app/domain/mcp/federatedmcp"
+	"menlo.ai/indigo-api-gateway/app/domain/organization"
+	"menlo.ai/indigo-api-gateway/app/domain/query"
+	"menlo.ai/indigo-api-gateway/app/utils/ptr"
+)
+
+type memoryServerRepo struct {
+	servers []*federatedmcp.Server
+}
+
+func (r *memoryServerRepo) Create(ctx context.Context, server *federatedmcp.Server) error {
+	server.ID = uint(len(r.servers) + 1)
+	server.CreatedAt = time.Now()
+	server.UpdatedAt = server.CreatedAt
+	stored := *server
+	r.servers = append(r.servers, &stored)
+	return nil
+}
+
+func (r *memoryServerRepo) Update(ctx context.Context, server *federatedmcp.Server) error {
+	server.UpdatedAt = time.Now()
+	for i, existing := range r.servers {
+		if existing != nil && existing.ID == server.ID {
+			stored := *server
+			r.servers[i] = &stored
+		}
+	}
+	return nil
+}
+
+func (r *memoryServerRepo) FindByFilter(ctx context.Context, filter federatedmcp.ServerFilter, pagination *query.Pagination) ([]*federatedmcp.Server, error) {
+	var found []*federatedmcp.Server
+	for _, server := range r.servers {
+		if server == nil ||
+			(filter.PublicID != nil && server.PublicID != *filter.PublicID) ||
+			(filter.OrganizationID != nil && server.OrganizationID != *filter.OrganizationID) ||
+			(filter.Name != nil && server.Name != *filter.Name) ||
+			(filter.Active != nil && server.Active != *filter.Active) ||
+			(filter.WithoutProject != nil && *filter.WithoutProject != (server.ProjectID == nil)) ||
+			(filter.VisibleToProjectID != nil && server.ProjectID != nil && *server.ProjectID != *filter.VisibleToProjectID) {
+			continue
+		}
+		copied := *server
+		found = append(found, &copied)
+	}
+	return found, nil
+}
+
+func (r *memoryServerRepo) Count(ctx context.Context, filter federatedmcp.ServerFilter) (int64, error) {
+	found, _ := r.FindByFilter(ctx, filter, nil)
+	return int64(len(found)), nil
+}
+
+func (r *memoryServerRepo) DeleteByID(ctx context.Context, id uint) error {
+	r.servers[id-1] = nil
+	return nil
+}
+
+type memoryAllowlistRepo struct {
+	allowlists map[uint]*federatedmcp.ToolAllowlist
+}
+
+func (r *memoryAllowlistRepo) FindByProjectID(ctx context.Context, projectID uint) (*federatedmcp.ToolAllowlist, error) {
+	return r.allowlists[projectID], nil
+}
+
+func (r *memoryAllowlistRepo) Upsert(ctx context.Context, allowlist *federatedmcp.ToolAllowlist) error {
+	r.allowlists[allowlist.ProjectID] = allowlist
+	return nil
+}
+
+func (r *memoryAllowlistRepo) DeleteByProjectID(ctx context.Context, projectID uint) error {
+	delete(r.allowlists, projectID)
+	return nil
+}
+
+func newTestFederatedService() *federatedmcp.FederatedMCPService {
+	return federatedmcp.NewFederatedMCPService(&memoryServerRepo{}, &memoryAllowlistRepo{allowlists: map[uint]*federatedmcp.ToolAllowlist{}})
+}
+
+// newBackend starts an MCP server offering two tools, a prompt, a resource and a resource template
+func newBackend(t *testing.T) string {
+	t.Helper()
+	backend := mcpserver.NewMCPServer("backend", "1.0.0",
+		mcpserver.WithToolCapabilities(true),
+		mcpserver.WithPromptCapabilities(true),
+		mcpserver.WithResourceCapabilities(false, true),
+	)
+	backend.AddTool(mcp.NewTool("echo", mcp.WithString("text", mcp.Required())), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultText("echo: " + request.GetString("text", "")), nil
+	})
+	backend.AddTool(mcp.NewTool("wipe"), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultText("wiped"), nil
+	})
+	backend.AddPrompt(mcp.NewPrompt("greet"), func(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+		return mcp.NewGetPromptResult("greeting", []mcp.PromptMessage{mcp.NewPromptMessage(mcp.RoleUser, mcp.NewTextContent("hello"))}), nil
+	})
+	backend.AddResource(mcp.NewResource("file:///readme", "readme"), func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		return []mcp.ResourceContents{mcp.TextResourceContents{URI: request.Params.URI, Text: "read me"}}, nil
+	})
+	backend.AddResourceTemplate(mcp.NewResourceTemplate("file:///notes/{name}", "notes"), func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		return []mcp.ResourceContents{mcp.TextResourceContents{URI: request.Params.URI, Text: "note"}}, nil
+	})
+	server := mcpserver.NewTestStreamableHTTPServer(backend)
+	t.Cleanup(server.Close)
+	return server.URL
+}
+
+type exposed struct {
+	tools     []string
+	prompts   []string
+	resources []string
+}
+
+func listExposed(t *testing.T, f *FederatedMCP, ctx context.Context, projectID *uint) exposed {
+	t.Helper()
+	client, err := f.Connect(ctx, projectID)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	defer client.Close()
+
+	var result exposed
+	tools, err := client.ListTools(ctx, mcp.ListToolsRequest{})
+	if err != nil {
+		t.Fatalf("list tools: %v", err)
+	}
+	for _, tool := range tools.Tools {
+		if strings.Contains(tool.Name, federatedmcp.NameSeparator) {
+			result.tools = append(result.tools, tool.Name)
+		}
+	}
+	if prompts, err := client.ListPrompts(ctx, mcp.ListPromptsRequest{}); err == nil {
+		for _, prompt := range prompts.Prompts {
+			result.prompts = append(result.prompts, prompt.Name)
+		}
+	}
+	if resources, err := client.ListResources(ctx, mcp.ListResourcesRequest{}); err == nil {
+		for _, resource := range resources.Resources {
+			result.resources = append(result.resources, resource.URI)
+		}
+	}
+	if templates, err := client.ListResourceTemplates(ctx, mcp.ListResourceTemplatesRequest{}); err == nil {
+		for _, template := range templates.ResourceTemplates {
+			result.resources = append(result.resources, template.URITemplate.Raw())
+		}
+	}
+	sort.Strings(result.tools)
+	sort.Strings(result.prompts)
+	sort.Strings(result.resources)
+	return result
+}
+
+func withDefaultOrganization(t *testing.T) {
+	previous := organization.DEFAULT_ORGANIZATION
+	organization.DEFAULT_ORGANIZATION = &organization.Organization{ID: 1}
+	t.Cleanup(func() { organization.DEFAULT_ORGANIZATION = previous })
+}
+
+func TestRegisterServerRefusesInternalAddresses(t *testing.T) {
+	withDefaultOrganization(t)
+	ctx := context.Background()
+	service := newTestFederatedService()
+	for _, url := range []string{"http://127.0.0.1:8080/mcp", "http://localhost/mcp", "http://10.0.0.5/mcp", "http://[::1]/mcp", "http://169.254.169.254/latest", "ftp://mcp.example.com"} {
+		if _, err := service.RegisterServer(ctx, federatedmcp.RegisterServerInput{Name: "internal", URL: url, Active: true}); err == nil {
+			t.Errorf("expected %s to be refused", url)
+		}
+	}
+
+	server, err := service.RegisterServer(ctx, federatedmcp.RegisterServerInput{Name: "github", URL: "https://mcp.example.com/mcp", Active: true})
+	if err != nil {
+		t.Fatalf("register server: %v", err)
+	}
+	if server.OrganizationID != 1 || server.Transport != federatedmcp.TransportStreamableHTTP {
+		t.Fatalf("unexpected server %+v", server)
+	}
+	if _, err := service.RegisterServer(ctx, federatedmcp.RegisterServerInput{Name: "github", URL: "https://other.example.com/mcp"}); err == nil {
+		t.Fatalf("expected a duplicate name to be refused")
+	}
+	if _, err := service.UpdateServer(ctx, server, federatedmcp.UpdateServerInput{URL: ptr.ToString("http://192.168.1.10/mcp")}); err == nil {
+		t.Fatalf("expected an update to an internal address to be refused")
+	}
+
+	// a server whose name resolves to an internal address is refused when the gateway connects
+	if _, err := service.Discover(ctx, &federatedmcp.Server{ID: 99, Name: "resolved", URL: newBackend(t), Transport: federatedmcp.TransportStreamableHTTP}); err == nil {
+		t.Fatalf("expected the connection to an internal address to be refused")
+	}
+}
+
+func TestFederatedMCPProxiesTheRegisteredServers(t *testing.T) {
+	withDefaultOrganization(t)
+	ctx := organization.WithOrganizationID(context.Background(), 1)
+	service := newTestFederatedService()
+	service.AllowPrivateNetworks()
+	f := NewFederatedMCP(NewSerperMCP(nil), service)
+
+	server, err := service.RegisterServer(ctx, federatedmcp.RegisterServerInput{OrganizationID: 1, Name: "demo", URL: newBackend(t), Active: true})
+	if err != nil {
+		t.Fatalf("register server: %v", err)
+	}
+
+	all := listExposed(t, f, ctx, nil)
+	if strings.Join(all.tools, ",") != "demo__echo,demo__wipe" || strings.Join(all.prompts, ",") != "demo__greet" ||
+		strings.Join(all.resources, ",") != "file:///notes/{name},file:///readme" {
+		t.Fatalf("expected everything the server offers to be exposed, got %+v", all)
+	}
+
+	client, connectErr := f.Connect(ctx, nil)
+	if connectErr != nil {
+		t.Fatalf("connect: %v", connectErr)
+	}
+	defer client.Close()
+	call := mcp.CallToolRequest{}
+	call.Params.Name = "demo__echo"
+	call.Params.Arguments = map[string]any{"text": "hi"}
+	result, callErr := client.CallTool(ctx, call)
+	if callErr != nil || len(result.Content) != 1 || result.Content[0].(mcp.TextContent).Text != "echo: hi" {
+		t.Fatalf("expected the call to be proxied, got %+v, %v", result, callErr)
+	}
+	prompt := mcp.GetPromptRequest{}
+	prompt.Params.Name = "demo__greet"
+	if rendered, promptErr := client.GetPrompt(ctx, prompt); promptErr != nil || rendered.Description != "greeting" {
+		t.Fatalf("expected the prompt to be proxied, got %+v, %v", rendered, promptErr)
+	}
+	read := mcp.ReadResourceRequest{}
+	read.Params.URI = "file:///readme"
+	if contents, readErr := client.ReadResource(ctx, read); readErr != nil || contents.Contents[0].(mcp.TextResourceContents).Text != "read me" {
+		t.Fatalf("expected the resource to be proxied, got %+v, %v", contents, readErr)
+	}
+
+	// the allowlist of a project applies to prompts and resources as well as tools
+	projectID := ptr.ToUint(7)
+	if _, err := service.SetToolAllowlist(ctx, *projectID, []string{"demo__echo", "demo__file:///readme"}); err != nil {
+		t.Fatalf("set allowlist: %v", err)
+	}
+	limited := listExposed(t, f, ctx, projectID)
+	if strings.Join(limited.tools, ",") != "demo__echo" || len(limited.prompts) != 0 || strings.Join(limited.resources, ",") != "file:///readme" {
+		t.Fatalf("expected the allowlist to be applied, got %+v", limited)
+	}
+
+	// another organization does not see the server
+	if other := listExposed(t, f, organization.WithOrganizationID(context.Background(), 2), nil); len(other.tools) != 0 {
+		t.Fatalf("expected the servers of another organization to be hidden, got %+v", other)
+	}
+
+	// a change made through the service is picked up by the next request
+	if _, err := service.UpdateServer(ctx, server, federatedmcp.UpdateServerInput{Active: ptr.ToBool(false)}); err != nil {
+		t.Fatalf("deactivate server: %v", err)
+	}
+	if refreshed := listExposed(t, f, ctx, nil); len(refreshed.tools) != 0 || len(refreshed.prompts) != 0 {
+		t.Fatalf("expected the inactive server to be hidden, got %+v", refreshed)
+	}
+}
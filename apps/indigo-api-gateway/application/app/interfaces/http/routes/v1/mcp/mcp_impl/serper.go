@@ -38,71 +38,81 @@ type SerperScrapeArgs struct {
 }
 
 func (s *SerperMCP) RegisterTool(handler *mcpserver.MCPServer) {
-	handler.AddTool(
-		mcp.NewTool("google_search",
-			mcpservice.ReflectToMCPOptions(
-				"Tool to perform web searches via Serper API and retrieve rich results. It is able to retrieve organic search results, people also ask, related searches, and knowledge graph.",
-				SerperSearchArgs{},
-			)...,
-		),
-		func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-			q, err := req.RequireString("q")
-			if err != nil {
-				return nil, err
-			}
-			searchReq := serpermcp.SearchRequest{
-				Q:           q,
-				GL:          ptr.ToString(req.GetString("gl", "us")),
-				Num:         ptr.ToInt(req.GetInt("num", 10)),
-				Page:        ptr.ToInt(req.GetInt("page", 1)),
-				Autocorrect: ptr.ToBool(req.GetBool("autocorrect", true)),
-			}
-			hl := req.GetString("hl", "")
-			if hl != "" {
-				searchReq.HL = &hl
-			}
-			location := req.GetString("location", "")
-			if location != "" {
-				searchReq.Location = &location
-			}
+	handler.AddTools(s.Tools()...)
+}
+
+// Tools returns the built-in search tools with their handlers
+func (s *SerperMCP) Tools() []mcpserver.ServerTool {
+	return []mcpserver.ServerTool{
+		{
+			Tool: mcp.NewTool("google_search",
+				mcpservice.ReflectToMCPOptions(
+					"Tool to perform web searches via the web search backend of the organization and retrieve rich results. It is able to retrieve organic search results, people also ask, related searches, and knowledge graph.",
+					SerperSearchArgs{},
+				)...,
+			),
+			Handler: func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+				q, err := req.RequireString("q")
+				if err != nil {
+					return nil, err
+				}
+				searchReq := serpermcp.SearchRequest{
+					Q:           q,
+					GL:          ptr.ToString(req.GetString("gl", "us")),
+					Num:         ptr.ToInt(req.GetInt("num", 10)),
+					Page:        ptr.ToInt(req.GetInt("page", 1)),
+					Autocorrect: ptr.ToBool(req.GetBool("autocorrect", true)),
+				}
+				hl := req.GetString("hl", "")
+				if hl != "" {
+					searchReq.HL = &hl
+				}
+				location := req.GetString("location", "")
+				if location != "" {
+					searchReq.Location = &location
+				}
+				if tbs := serpermcp.TBSTimeRange(req.GetString("tbs", "")); tbs != serpermcp.TBSAny {
+					searchReq.TBS = &tbs
+				}
 
-			searchResp, err := s.SerperService.Search(ctx, searchReq)
-			if err != nil {
-				return nil, err
-			}
-			jsonBytes, err := json.Marshal(searchResp)
-			if err != nil {
-				return nil, err
-			}
+				searchResp, err := s.SerperService.Search(ctx, searchReq)
+				if err != nil {
+					return nil, err
+				}
+				jsonBytes, err := json.Marshal(searchResp)
+				if err != nil {
+					return nil, err
+				}
 
-			return mcp.NewToolResultText(string(jsonBytes)), nil
+				return mcp.NewToolResultText(string(jsonBytes)), nil
+			},
 		},
-	)
-	handler.AddTool(
-		mcp.NewTool("scrape",
-			mcpservice.ReflectToMCPOptions(
-				"This is a tool to scrape a webpage and retrieve the text, with an option to provide the output in Markdown format.",
-				SerperScrapeArgs{},
-			)...,
-		),
-		func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-			url, err := req.RequireString("url")
-			if err != nil {
-				return nil, err
-			}
-			scrapeReq := serpermcp.FetchWebpageRequest{
-				Url:             url,
-				IncludeMarkdown: ptr.ToBool(req.GetBool("includeMarkdown", false)),
-			}
-			searchResp, err := s.SerperService.FetchWebpage(ctx, scrapeReq)
-			if err != nil {
-				return nil, err
-			}
-			jsonBytes, err := json.Marshal(searchResp)
-			if err != nil {
-				return nil, err
-			}
-			return mcp.NewToolResultText(string(jsonBytes)), nil
+		{
+			Tool: mcp.NewTool("scrape",
+				mcpservice.ReflectToMCPOptions(
+					"This is a tool to scrape a webpage or PDF and retrieve its main content as text, with an option to provide the output in Markdown format.",
+					SerperScrapeArgs{},
+				)...,
+			),
+			Handler: func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+				url, err := req.RequireString("url")
+				if err != nil {
+					return nil, err
+				}
+				scrapeReq := serpermcp.FetchWebpageRequest{
+					Url:             url,
+					IncludeMarkdown: ptr.ToBool(req.GetBool("includeMarkdown", false)),
+				}
+				searchResp, err := s.SerperService.FetchWebpage(ctx, scrapeReq)
+				if err != nil {
+					return nil, err
+				}
+				jsonBytes, err := json.Marshal(searchResp)
+				if err != nil {
+					return nil, err
+				}
+				return mcp.NewToolResultText(string(jsonBytes)), nil
+			},
 		},
-	)
+	}
 }
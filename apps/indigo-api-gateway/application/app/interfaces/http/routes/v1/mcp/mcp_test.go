@@ -2,70 +2,92 @@ package mcp
 
 import (
 	"bytes"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
-func TestMCPMethodGuardAppendsActivity(t *testing.T) {
+func TestMCPMethodGuard(t *testing.T) {
 	gin.SetMode(gin.TestMode)
-	api := &MCPAPI{}
 	guard := MCPMethodGuard(map[string]bool{
 		"ping":       true,
 		"tools/call": true,
-	}, api)
+	}, nil)
 
-	recorder := httptest.NewRecorder()
-	ctx, _ := gin.CreateTestContext(recorder)
-	req, _ := http.NewRequest(http.MethodPost, "/mcp", bytes.NewBufferString(`{"method":"ping"}`))
-	req.Header.Set("Content-Type", "application/json")
-	ctx.Request = req
-
-	guard(ctx)
-
-	if len(api.activityLog) != 1 {
-		t.Fatalf("expected 1 activity entry, got %d", len(api.activityLog))
-	}
-	if api.activityLog[0].Method != "ping" {
-		t.Fatalf("expected method ping, got %s", api.activityLog[0].Method)
+	cases := map[string]bool{
+		`{"method":"ping"}`: false,
+		`{"method":"tools/call","params":{"name":"scrape"}}`: false,
+		`{"method":"logging/setLevel"}`:                      true,
+		`not json`:                                           true,
 	}
-	if api.activityLog[0].UserID != nil {
-		t.Fatalf("expected no user for synthetic request")
+	for body, aborted := range cases {
+		ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+		ctx.Request, _ = http.NewRequest(http.MethodPost, "/mcp", bytes.NewBufferString(body))
+		guard(ctx)
+		if ctx.IsAborted() != aborted {
+			t.Errorf("guard(%s) aborted = %v, want %v", body, ctx.IsAborted(), aborted)
+		}
 	}
+}
 
-	recorder2 := httptest.NewRecorder()
-	ctx2, _ := gin.CreateTestContext(recorder2)
-	req2, _ := http.NewRequest(http.MethodPost, "/mcp", bytes.NewBufferString(`{"method":"tools/call","params":{"name":"google_search"}}`))
-	req2.Header.Set("Content-Type", "application/json")
-	ctx2.Request = req2
-
-	guard(ctx2)
-
-	if len(api.activityLog) != 2 {
-		t.Fatalf("expected 2 activity entries, got %d", len(api.activityLog))
+func TestExtractToolName(t *testing.T) {
+	if got := extractToolName("tools/call", json.RawMessage(`{"name":"google_search","arguments":{"q":"x"}}`)); got != "google_search" {
+		t.Errorf("extractToolName() = %q, want google_search", got)
 	}
-	if api.activityLog[1].Tool == nil || *api.activityLog[1].Tool != "google_search" {
-		if api.activityLog[1].Tool == nil {
-			t.Fatalf("expected tool google_search, got nil")
-		}
-		t.Fatalf("expected tool google_search, got %s", *api.activityLog[1].Tool)
+	if got := extractToolName("prompts/get", json.RawMessage(`{"name":"summarize"}`)); got != "" {
+		t.Errorf("extractToolName() = %q for a non tools/call method", got)
 	}
 }
 
-func TestMCPAPIGetActivity(t *testing.T) {
+func TestNewActivity(t *testing.T) {
 	gin.SetMode(gin.TestMode)
-	api := &MCPAPI{}
-	api.appendActivity("user-123", "ping", "")
-	api.appendActivity("", "tools/list", "")
-
-	recorder := httptest.NewRecorder()
-	ctx, _ := gin.CreateTestContext(recorder)
-
-	api.GetActivity(ctx)
+	cases := []struct {
+		name        string
+		status      int
+		contentType string
+		body        string
+		success     bool
+		err         string
+	}{
+		{"result", http.StatusOK, "application/json", `{"jsonrpc":"2.0","id":1,"result":{"content":[{"type":"text","text":"ok"}]}}`, true, ""},
+		{"rpc error", http.StatusOK, "application/json", `{"jsonrpc":"2.0","id":1,"error":{"code":-32602,"message":"tool not found"}}`, false, "tool not found"},
+		{"tool error over sse", http.StatusOK, "text/event-stream", "event: message\ndata: {\"jsonrpc\":\"2.0\",\"method\":\"notifications/progress\"}\n\nevent: message\ndata: {\"jsonrpc\":\"2.0\",\"id\":1,\"result\":{\"isError\":true,\"content\":[{\"type\":\"text\",\"text\":\"quota exceeded\"}]}}\n\n", false, "quota exceeded"},
+		{"http error", http.StatusUnauthorized, "application/json", ``, false, "Unauthorized"},
+		{"notification", http.StatusAccepted, "", ``, true, ""},
+	}
+	for _, tc := range cases {
+		recorder := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(recorder)
+		ctx.Request, _ = http.NewRequest(http.MethodPost, "/mcp", nil)
+		capture := &responseCapture{ResponseWriter: ctx.Writer}
+		ctx.Writer = capture
+		if tc.contentType != "" {
+			ctx.Header("Content-Type", tc.contentType)
+		}
+		ctx.Status(tc.status)
+		_, _ = ctx.Writer.WriteString(tc.body)
 
-	if recorder.Code != http.StatusOK {
-		t.Fatalf("expected status 200, got %d", recorder.Code)
+		params := json.RawMessage(`{"name":"google_search"}`)
+		activity := newActivity(ctx, "tools/call", params, time.Now(), capture)
+		if activity.Success != tc.success {
+			t.Errorf("%s: success = %v, want %v", tc.name, activity.Success, tc.success)
+		}
+		gotErr := ""
+		if activity.Error != nil {
+			gotErr = *activity.Error
+		}
+		if gotErr != tc.err {
+			t.Errorf("%s: error = %q, want %q", tc.name, gotErr, tc.err)
+		}
+		if activity.Tool == nil || *activity.Tool != "google_search" || activity.ArgumentBytes != len(params) {
+			t.Errorf("%s: tool %v, argument bytes %d", tc.name, activity.Tool, activity.ArgumentBytes)
+		}
+		if recorder.Body.String() != tc.body {
+			t.Errorf("%s: the response must reach the client unchanged", tc.name)
+		}
 	}
 }
@@ -0,0 +1,347 @@
+package organization
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"menlo.ai/indigo-api-gateway/app/domain/auth"
+	"menlo.ai/indigo-api-gateway/app/domain/cron"
+	"menlo.ai/indigo-api-gateway/app/domain/query"
+	"menlo.ai/indigo-api-gateway/app/domain/settings"
+	"menlo.ai/indigo-api-gateway/app/interfaces/http/responses"
+	"menlo.ai/indigo-api-gateway/app/interfaces/http/responses/openai"
+	"menlo.ai/indigo-api-gateway/app/utils/ptr"
+)
+
+type CronJobRoute struct {
+	authService  *auth.AuthService
+	cronService  *cron.CronService
+	auditService *settings.AuditService
+}
+
+func NewCronJobRoute(
+	authService *auth.AuthService,
+	cronService *cron.CronService,
+	auditService *settings.AuditService,
+) *CronJobRoute {
+	return &CronJobRoute{
+		authService:  authService,
+		cronService:  cronService,
+		auditService: auditService,
+	}
+}
+
+func (route *CronJobRoute) RegisterRouter(router *gin.RouterGroup) {
+	group := router.Group("/cron",
+		route.authService.AdminUserAuthMiddleware(),
+		route.authService.RegisteredUserMiddleware(),
+		route.authService.OrganizationMemberRoleMiddleware(auth.OrganizationMemberRuleOwnerOnly),
+	)
+	group.GET("/jobs", route.ListJobs)
+	group.PATCH("/jobs/:job_name", route.UpdateJob)
+	group.POST("/jobs/:job_name/run", route.RunJob)
+	group.GET("/runs", route.ListRuns)
+}
+
+type UpdateCronJobRequest struct {
+	// Schedule is a crontab schedule in the time zone of the server, an empty value restores the default one
+	Schedule *string `json:"schedule"`
+	Enabled  *bool   `json:"enabled"`
+}
+
+type CronJobResponse struct {
+	Name            string              `json:"name"`
+	Object          string              `json:"object"`
+	Description     string              `json:"description"`
+	Schedule        string              `json:"schedule"`
+	DefaultSchedule string              `json:"default_schedule"`
+	Enabled         bool                `json:"enabled"`
+	Overridden      bool                `json:"overridden"`
+	TimeoutSeconds  int64               `json:"timeout_seconds"`
+	LastRun         *CronJobRunResponse `json:"last_run"`
+}
+
+type CronJobRunResponse struct {
+	ID          string  `json:"id"`
+	Object      string  `json:"object"`
+	Job         string  `json:"job"`
+	Trigger     string  `json:"trigger"`
+	Status      string  `json:"status"`
+	TriggeredBy *string `json:"triggered_by,omitempty"`
+	Hostname    string  `json:"hostname"`
+	Summary     *string `json:"summary,omitempty"`
+	Error       *string `json:"error,omitempty"`
+	StartedAt   int64   `json:"started_at"`
+	FinishedAt  *int64  `json:"finished_at"`
+	DurationMs  int64   `json:"duration_ms"`
+}
+
+// @Summary List cron jobs
+// @Description Lists the background jobs of the gateway with the schedule in effect and their last run
+// @Tags Administration API
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} openai.ListResponse[CronJobResponse] "Cron jobs"
+// @Failure 401 {object} responses.ErrorResponse "Unauthorized"
+// @Router /v1/organization/cron/jobs [get]
+func (route *CronJobRoute) ListJobs(reqCtx *gin.Context) {
+	states, err := route.cronService.ListJobs(reqCtx.Request.Context())
+	if err != nil {
+		abortWithCommonError(reqCtx, http.StatusInternalServerError, err)
+		return
+	}
+	data := make([]CronJobResponse, 0, len(states))
+	for _, state := range states {
+		data = append(data, toCronJobResponse(state))
+	}
+	reqCtx.JSON(http.StatusOK, openai.ListResponse[CronJobResponse]{
+		Object: openai.ObjectTypeListList,
+		Data:   data,
+		Total:  int64(len(data)),
+	})
+}
+
+// @Summary Update a cron job
+// @Description Changes the schedule of a cron job or disables it. The change applies to every replica within a minute.
+// @Tags Administration API
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param job_name path string true "Job name"
+// @Param request body UpdateCronJobRequest true "Fields to update"
+// @Success 200 {object} CronJobResponse "Updated cron job"
+// @Failure 400 {object} responses.ErrorResponse "Invalid schedule"
+// @Failure 401 {object} responses.ErrorResponse "Unauthorized"
+// @Failure 404 {object} responses.ErrorResponse "Cron job not found"
+// @Router /v1/organization/cron/jobs/{job_name} [patch]
+func (route *CronJobRoute) UpdateJob(reqCtx *gin.Context) {
+	ctx := reqCtx.Request.Context()
+	orgEntity, ok := auth.GetAdminOrganizationFromContext(reqCtx)
+	if !ok {
+		return
+	}
+	userEntity, ok := auth.GetUserFromContext(reqCtx)
+	if !ok {
+		return
+	}
+	var request UpdateCronJobRequest
+	if err := reqCtx.ShouldBindJSON(&request); err != nil {
+		reqCtx.AbortWithStatusJSON(http.StatusBadRequest, responses.ErrorResponse{
+			Code:          "5b8e2d7a-1f4c-4a9e-b6d3-8c2f5a9e1d74",
+			ErrorInstance: err,
+		})
+		return
+	}
+
+	name := reqCtx.Param("job_name")
+	state, err := route.cronService.UpdateJob(ctx, name, cron.UpdateJobInput{
+		Schedule:   request.Schedule,
+		Enabled:    request.Enabled,
+		ActorID:    ptr.ToUint(userEntity.ID),
+		ActorEmail: ptr.ToString(userEntity.Email),
+	})
+	if err != nil {
+		switch err.GetCode() {
+		case "2f8d5a1c-7e4b-4c9a-8b3f-6d1e9c4a7b15":
+			abortWithCommonError(reqCtx, http.StatusNotFound, err)
+		case "9a4e7c2b-1d6f-4b8e-a5c3-7e2b9d4f1a68":
+			abortWithCommonError(reqCtx, http.StatusBadRequest, err)
+		default:
+			abortWithCommonError(reqCtx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	_ = route.auditService.Record(ctx, settings.RecordAuditInput{
+		OrganizationID: orgEntity.ID,
+		UserID:         ptr.ToUint(userEntity.ID),
+		UserEmail:      ptr.ToString(userEntity.Email),
+		Event:          "cron_job.updated",
+		Metadata: map[string]interface{}{
+			"job":      name,
+			"schedule": state.Schedule,
+			"enabled":  state.Enabled,
+		},
+	})
+	reqCtx.JSON(http.StatusOK, toCronJobResponse(state))
+}
+
+// @Summary Run a cron job
+// @Description Starts a run of a cron job now, even when it is disabled. The run is returned as it started, its outcome is in the run history.
+// @Tags Administration API
+// @Security BearerAuth
+// @Produce json
+// @Param job_name path string true "Job name"
+// @Success 202 {object} CronJobRunResponse "Started run"
+// @Failure 401 {object} responses.ErrorResponse "Unauthorized"
+// @Failure 404 {object} responses.ErrorResponse "Cron job not found"
+// @Failure 409 {object} responses.ErrorResponse "The job is already running"
+// @Router /v1/organization/cron/jobs/{job_name}/run [post]
+func (route *CronJobRoute) RunJob(reqCtx *gin.Context) {
+	ctx := reqCtx.Request.Context()
+	orgEntity, ok := auth.GetAdminOrganizationFromContext(reqCtx)
+	if !ok {
+		return
+	}
+	userEntity, ok := auth.GetUserFromContext(reqCtx)
+	if !ok {
+		return
+	}
+
+	name := reqCtx.Param("job_name")
+	run, err := route.cronService.TriggerJob(ctx, name, ptr.ToString(userEntity.Email))
+	if err != nil {
+		switch err.GetCode() {
+		case "5d9a2e6f-3c8b-4f1d-a7e4-2b6f9d3c8a51":
+			abortWithCommonError(reqCtx, http.StatusNotFound, err)
+		case "4c1e8a7d-2b9f-4d6e-a3c5-8f7b1d4e9a26":
+			abortWithCommonError(reqCtx, http.StatusConflict, err)
+		default:
+			abortWithCommonError(reqCtx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	_ = route.auditService.Record(ctx, settings.RecordAuditInput{
+		OrganizationID: orgEntity.ID,
+		UserID:         ptr.ToUint(userEntity.ID),
+		UserEmail:      ptr.ToString(userEntity.Email),
+		Event:          "cron_job.triggered",
+		Metadata: map[string]interface{}{
+			"job":    name,
+			"run_id": run.PublicID,
+		},
+	})
+	reqCtx.JSON(http.StatusAccepted, toCronJobRunResponse(run))
+}
+
+// @Summary List cron job runs
+// @Description Returns the run history of the cron jobs, newest first unless order=asc. Use `last` with the id of the last run of a page to get the next one.
+// @Tags Administration API
+// @Security BearerAuth
+// @Produce json
+// @Param job query string false "Only the runs of this job"
+// @Param status query string false "running, succeeded or failed"
+// @Param limit query int false "The maximum number of items to return" default(20)
+// @Param last query string false "The id of the last run of the previous page"
+// @Param order query string false "desc (default) or asc"
+// @Success 200 {object} openai.ListResponse[CronJobRunResponse]
+// @Failure 400 {object} responses.ErrorResponse "Invalid parameters"
+// @Failure 401 {object} responses.ErrorResponse "Unauthorized"
+// @Router /v1/organization/cron/runs [get]
+func (route *CronJobRoute) ListRuns(reqCtx *gin.Context) {
+	ctx := reqCtx.Request.Context()
+	filter := cron.JobRunFilter{}
+	if job := strings.TrimSpace(reqCtx.Query("job")); job != "" {
+		filter.Job = &job
+	}
+	switch status := reqCtx.Query("status"); status {
+	case "":
+	case cron.RunStatusRunning, cron.RunStatusSucceeded, cron.RunStatusFailed:
+		filter.Status = &status
+	default:
+		reqCtx.AbortWithStatusJSON(http.StatusBadRequest, responses.ErrorResponse{
+			Code:  "8d3f6a1e-9c2b-4e7d-a4f8-1b6e9c3d7a52",
+			Error: "status must be running, succeeded or failed",
+		})
+		return
+	}
+
+	pagination, err := query.GetCursorPaginationFromQuery(reqCtx, func(lastID string) (*uint, error) {
+		run, findErr := route.cronService.FindRun(ctx, filter, lastID)
+		if findErr != nil {
+			return nil, fmt.Errorf("%s: %s", findErr.GetCode(), findErr.Error())
+		}
+		if run == nil {
+			return nil, fmt.Errorf("invalid run")
+		}
+		return &run.ID, nil
+	})
+	if err != nil {
+		reqCtx.AbortWithStatusJSON(http.StatusBadRequest, responses.ErrorResponse{
+			Code:  "2e9a5c8f-6b1d-4f3a-9e7c-4a8d2f6b1e93",
+			Error: "Invalid pagination parameters",
+		})
+		return
+	}
+	if reqCtx.Query("order") == "" {
+		pagination.Order = "desc"
+	}
+
+	runs, total, listErr := route.cronService.ListRuns(ctx, filter, pagination)
+	if listErr != nil {
+		abortWithCommonError(reqCtx, http.StatusInternalServerError, listErr)
+		return
+	}
+
+	var firstID *string
+	var lastID *string
+	hasMore := false
+	if len(runs) > 0 {
+		firstID = &runs[0].PublicID
+		lastID = &runs[len(runs)-1].PublicID
+		more, _, moreErr := route.cronService.ListRuns(ctx, filter, &query.Pagination{
+			Order: pagination.Order,
+			Limit: ptr.ToInt(1),
+			After: &runs[len(runs)-1].ID,
+		})
+		if moreErr != nil {
+			abortWithCommonError(reqCtx, http.StatusInternalServerError, moreErr)
+			return
+		}
+		hasMore = len(more) > 0
+	}
+
+	data := make([]CronJobRunResponse, 0, len(runs))
+	for _, run := range runs {
+		data = append(data, toCronJobRunResponse(run))
+	}
+	reqCtx.JSON(http.StatusOK, openai.ListResponse[CronJobRunResponse]{
+		Object:  openai.ObjectTypeListList,
+		Data:    data,
+		FirstID: firstID,
+		LastID:  lastID,
+		HasMore: hasMore,
+		Total:   total,
+	})
+}
+
+func toCronJobResponse(state *cron.JobState) CronJobResponse {
+	resp := CronJobResponse{
+		Name:            state.Job.Name,
+		Object:          "cron.job",
+		Description:     state.Job.Description,
+		Schedule:        state.Schedule,
+		DefaultSchedule: state.Job.Schedule,
+		Enabled:         state.Enabled,
+		Overridden:      state.Overridden,
+		TimeoutSeconds:  int64(state.Job.Timeout.Seconds()),
+	}
+	if state.LastRun != nil {
+		lastRun := toCronJobRunResponse(state.LastRun)
+		resp.LastRun = &lastRun
+	}
+	return resp
+}
+
+func toCronJobRunResponse(run *cron.JobRun) CronJobRunResponse {
+	resp := CronJobRunResponse{
+		ID:          run.PublicID,
+		Object:      "cron.job.run",
+		Job:         run.Job,
+		Trigger:     run.Trigger,
+		Status:      run.Status,
+		TriggeredBy: run.TriggeredBy,
+		Hostname:    run.Hostname,
+		Summary:     run.Summary,
+		Error:       run.Error,
+		StartedAt:   run.StartedAt.Unix(),
+		DurationMs:  run.DurationMs,
+	}
+	if run.FinishedAt != nil {
+		resp.FinishedAt = ptr.ToInt64(run.FinishedAt.Unix())
+	}
+	return resp
+}
@@ -285,7 +285,7 @@ func (api *InvitesRoute) CreateInvite(reqCtx *gin.Context) {
 		OrgPublicID:  orgEntity.PublicID,
 		InviteLink: fmt.Sprintf(
 			"%s?code=%s",
-			environment_variables.EnvironmentVariables.INVITE_REDIRECT_URL,
+			environment_variables.Current().INVITE_REDIRECT_URL,
 			*inviteEntity.Secrets,
 		),
 	}, requestPayload.Email)
@@ -0,0 +1,583 @@
+package organization
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"menlo.ai/indigo-api-gateway/app/domain/auth"
+	"menlo.ai/indigo-api-gateway/app/domain/common"
+	"menlo.ai/indigo-api-gateway/app/domain/mcp/federatedmcp"
+	"menlo.ai/indigo-api-gateway/app/domain/project"
+	"menlo.ai/indigo-api-gateway/app/domain/query"
+	"menlo.ai/indigo-api-gateway/app/domain/settings"
+	"menlo.ai/indigo-api-gateway/app/interfaces/http/responses"
+	"menlo.ai/indigo-api-gateway/app/interfaces/http/responses/openai"
+	"menlo.ai/indigo-api-gateway/app/utils/ptr"
+)
+
+const mcpServerContextKey = "mcp_server"
+
+type MCPServerRoute struct {
+	authService      *auth.AuthService
+	federatedService *federatedmcp.FederatedMCPService
+	projectService   *project.ProjectService
+	auditService     *settings.AuditService
+}
+
+func NewMCPServerRoute(
+	authService *auth.AuthService,
+	federatedService *federatedmcp.FederatedMCPService,
+	projectService *project.ProjectService,
+	auditService *settings.AuditService,
+) *MCPServerRoute {
+	return &MCPServerRoute{
+		authService:      authService,
+		federatedService: federatedService,
+		projectService:   projectService,
+		auditService:     auditService,
+	}
+}
+
+func (route *MCPServerRoute) RegisterRouter(router *gin.RouterGroup) {
+	group := router.Group("/mcp",
+		route.authService.AdminUserAuthMiddleware(),
+		route.authService.RegisteredUserMiddleware(),
+		route.authService.OrganizationMemberRoleMiddleware(auth.OrganizationMemberRuleOwnerOnly),
+	)
+	group.GET("/servers", route.ListServers)
+	group.POST("/servers", route.RegisterServer)
+
+	serverRouter := group.Group("/servers/:server_public_id", route.serverMiddleware)
+	serverRouter.GET("", route.GetServer)
+	serverRouter.PATCH("", route.UpdateServer)
+	serverRouter.DELETE("", route.DeleteServer)
+	serverRouter.POST("/sync", route.SyncServer)
+
+	group.GET("/projects/:project_public_id/tools", route.GetToolAllowlist)
+	group.POST("/projects/:project_public_id/tools", route.UpdateToolAllowlist)
+}
+
+type RegisterMCPServerRequest struct {
+	Name string `json:"name" binding:"required"`
+	URL  string `json:"url" binding:"required"`
+	// Transport is streamable_http (default) or sse
+	Transport string `json:"transport"`
+	// Headers are sent with every request to the server, they are stored encrypted and never returned
+	Headers map[string]string `json:"headers"`
+	Active  *bool             `json:"active"`
+	Project *string           `json:"project_public_id"`
+}
+
+type UpdateMCPServerRequest struct {
+	Name      *string `json:"name"`
+	URL       *string `json:"url"`
+	Transport *string `json:"transport"`
+	// Headers replaces every configured header, an empty object removes them
+	Headers *map[string]string `json:"headers"`
+	Active  *bool              `json:"active"`
+}
+
+type MCPServerResponse struct {
+	ID          string   `json:"id"`
+	Object      string   `json:"object"`
+	Name        string   `json:"name"`
+	URL         string   `json:"url"`
+	Transport   string   `json:"transport"`
+	HeaderNames []string `json:"header_names"`
+	Active      bool     `json:"active"`
+	Scope       string   `json:"scope"`
+	Project     *string  `json:"project_public_id,omitempty"`
+	CreatedAt   int64    `json:"created_at"`
+	UpdatedAt   int64    `json:"updated_at"`
+}
+
+type MCPServerSyncResponse struct {
+	Server            MCPServerResponse `json:"server"`
+	Tools             []string          `json:"tools"`
+	Prompts           []string          `json:"prompts"`
+	Resources         []string          `json:"resources"`
+	ResourceTemplates []string          `json:"resource_templates"`
+	LatencyMs         int64             `json:"latency_ms"`
+}
+
+type UpdateMCPToolAllowlistRequest struct {
+	// AllowedTools lists namespaced tool names, "<server>__*" or "*"; null exposes every tool again
+	AllowedTools []string `json:"allowed_tools"`
+}
+
+type MCPToolAllowlistResponse struct {
+	Object  string `json:"object"`
+	Project string `json:"project_public_id"`
+	// AllowedTools is null when the project sees every tool
+	AllowedTools []string `json:"allowed_tools"`
+	UpdatedAt    *int64   `json:"updated_at,omitempty"`
+}
+
+func (route *MCPServerRoute) serverMiddleware(reqCtx *gin.Context) {
+	orgEntity, ok := auth.GetAdminOrganizationFromContext(reqCtx)
+	if !ok {
+		return
+	}
+	server, err := route.federatedService.FindServer(reqCtx.Request.Context(), orgEntity.ID, reqCtx.Param("server_public_id"))
+	if err != nil {
+		abortWithCommonError(reqCtx, http.StatusInternalServerError, err)
+		return
+	}
+	if server == nil {
+		reqCtx.AbortWithStatusJSON(http.StatusNotFound, responses.ErrorResponse{
+			Code:  "3c8f1a6e-9d2b-4e7a-b5c4-1f6e9a3d8b27",
+			Error: "MCP server not found",
+		})
+		return
+	}
+	reqCtx.Set(mcpServerContextKey, server)
+	reqCtx.Next()
+}
+
+func getMCPServerFromContext(reqCtx *gin.Context) *federatedmcp.Server {
+	value, _ := reqCtx.Get(mcpServerContextKey)
+	return value.(*federatedmcp.Server)
+}
+
+func abortWithCommonError(reqCtx *gin.Context, status int, err *common.Error) {
+	reqCtx.AbortWithStatusJSON(status, responses.ErrorResponse{
+		Code:  err.GetCode(),
+		Error: err.GetMessage(),
+	})
+}
+
+// @Summary List MCP servers
+// @Description Lists the external MCP servers registered by the organization
+// @Tags Administration API
+// @Security BearerAuth
+// @Produce json
+// @Param project_public_id query string false "Only list the servers of this project"
+// @Param limit query int false "The maximum number of items to return" default(20)
+// @Param offset query int false "The number of items to skip"
+// @Success 200 {object} openai.ListResponse[MCPServerResponse] "List of MCP servers"
+// @Failure 400 {object} responses.ErrorResponse "Invalid parameters"
+// @Failure 401 {object} responses.ErrorResponse "Unauthorized"
+// @Router /v1/organization/mcp/servers [get]
+func (route *MCPServerRoute) ListServers(reqCtx *gin.Context) {
+	ctx := reqCtx.Request.Context()
+	orgEntity, ok := auth.GetAdminOrganizationFromContext(reqCtx)
+	if !ok {
+		return
+	}
+	pagination, err := query.GetPaginationFromQuery(reqCtx)
+	if err != nil {
+		reqCtx.AbortWithStatusJSON(http.StatusBadRequest, responses.ErrorResponse{
+			Code:  "7e2a9c4f-1b6d-4f8e-a3c5-9d1b7e4a2c63",
+			Error: "invalid or missing query parameter",
+		})
+		return
+	}
+	filter := federatedmcp.ServerFilter{OrganizationID: &orgEntity.ID}
+	if projectPublicID := strings.TrimSpace(reqCtx.Query("project_public_id")); projectPublicID != "" {
+		projectEntity := route.findProject(reqCtx, orgEntity.ID, projectPublicID)
+		if projectEntity == nil {
+			return
+		}
+		filter.ProjectID = &projectEntity.ID
+	}
+
+	servers, total, listErr := route.federatedService.ListServers(ctx, filter, pagination)
+	if listErr != nil {
+		abortWithCommonError(reqCtx, http.StatusInternalServerError, listErr)
+		return
+	}
+	projectPublicIDs := make(map[uint]string)
+	data := make([]MCPServerResponse, 0, len(servers))
+	for _, server := range servers {
+		data = append(data, route.toMCPServerResponse(reqCtx, server, projectPublicIDs))
+	}
+	reqCtx.JSON(http.StatusOK, openai.ListResponse[MCPServerResponse]{
+		Object: openai.ObjectTypeListList,
+		Data:   data,
+		Total:  total,
+	})
+}
+
+// @Summary Register an MCP server
+// @Description Registers an external MCP server reachable over streamable HTTP or SSE. Its tools and prompts are exposed on /v1/mcp as `<name>__<tool>`.
+// @Description Servers with a project_public_id are only exposed to the API keys of that project.
+// @Tags Administration API
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body RegisterMCPServerRequest true "MCP server"
+// @Success 200 {object} MCPServerResponse "Registered MCP server"
+// @Failure 400 {object} responses.ErrorResponse "Invalid request"
+// @Failure 401 {object} responses.ErrorResponse "Unauthorized"
+// @Router /v1/organization/mcp/servers [post]
+func (route *MCPServerRoute) RegisterServer(reqCtx *gin.Context) {
+	ctx := reqCtx.Request.Context()
+	orgEntity, ok := auth.GetAdminOrganizationFromContext(reqCtx)
+	if !ok {
+		return
+	}
+	userEntity, ok := auth.GetUserFromContext(reqCtx)
+	if !ok {
+		return
+	}
+	var request RegisterMCPServerRequest
+	if err := reqCtx.ShouldBindJSON(&request); err != nil {
+		reqCtx.AbortWithStatusJSON(http.StatusBadRequest, responses.ErrorResponse{
+			Code:          "5f9b2d7e-3a1c-4e6b-8d4f-2c7a9e1b5d38",
+			ErrorInstance: err,
+		})
+		return
+	}
+
+	var projectID *uint
+	if request.Project != nil && strings.TrimSpace(*request.Project) != "" {
+		projectEntity := route.findProject(reqCtx, orgEntity.ID, strings.TrimSpace(*request.Project))
+		if projectEntity == nil {
+			return
+		}
+		projectID = ptr.ToUint(projectEntity.ID)
+	}
+	active := true
+	if request.Active != nil {
+		active = *request.Active
+	}
+
+	server, err := route.federatedService.RegisterServer(ctx, federatedmcp.RegisterServerInput{
+		OrganizationID: orgEntity.ID,
+		ProjectID:      projectID,
+		Name:           request.Name,
+		URL:            request.URL,
+		Transport:      request.Transport,
+		Headers:        request.Headers,
+		Active:         active,
+	})
+	if err != nil {
+		abortWithCommonError(reqCtx, http.StatusBadRequest, err)
+		return
+	}
+
+	_ = route.auditService.Record(ctx, settings.RecordAuditInput{
+		OrganizationID: orgEntity.ID,
+		UserID:         ptr.ToUint(userEntity.ID),
+		UserEmail:      ptr.ToString(userEntity.Email),
+		Event:          "mcp_server.registered",
+		Metadata: map[string]interface{}{
+			"server_id": server.PublicID,
+			"name":      server.Name,
+			"url":       server.URL,
+			"transport": server.Transport,
+		},
+	})
+	reqCtx.JSON(http.StatusOK, route.toMCPServerResponse(reqCtx, server, map[uint]string{}))
+}
+
+// @Summary Get an MCP server
+// @Tags Administration API
+// @Security BearerAuth
+// @Produce json
+// @Param server_public_id path string true "MCP server ID"
+// @Success 200 {object} MCPServerResponse "MCP server"
+// @Failure 401 {object} responses.ErrorResponse "Unauthorized"
+// @Failure 404 {object} responses.ErrorResponse "MCP server not found"
+// @Router /v1/organization/mcp/servers/{server_public_id} [get]
+func (route *MCPServerRoute) GetServer(reqCtx *gin.Context) {
+	reqCtx.JSON(http.StatusOK, route.toMCPServerResponse(reqCtx, getMCPServerFromContext(reqCtx), map[uint]string{}))
+}
+
+// @Summary Update an MCP server
+// @Description Updates the name, URL, transport, auth headers or active state of an MCP server. Renaming a server renames its exposed tools.
+// @Tags Administration API
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param server_public_id path string true "MCP server ID"
+// @Param request body UpdateMCPServerRequest true "Fields to update"
+// @Success 200 {object} MCPServerResponse "Updated MCP server"
+// @Failure 400 {object} responses.ErrorResponse "Invalid request"
+// @Failure 401 {object} responses.ErrorResponse "Unauthorized"
+// @Failure 404 {object} responses.ErrorResponse "MCP server not found"
+// @Router /v1/organization/mcp/servers/{server_public_id} [patch]
+func (route *MCPServerRoute) UpdateServer(reqCtx *gin.Context) {
+	ctx := reqCtx.Request.Context()
+	orgEntity, ok := auth.GetAdminOrganizationFromContext(reqCtx)
+	if !ok {
+		return
+	}
+	userEntity, ok := auth.GetUserFromContext(reqCtx)
+	if !ok {
+		return
+	}
+	var request UpdateMCPServerRequest
+	if err := reqCtx.ShouldBindJSON(&request); err != nil {
+		reqCtx.AbortWithStatusJSON(http.StatusBadRequest, responses.ErrorResponse{
+			Code:          "2b6e9a4d-7c1f-4d3b-9e5a-8f2c6b1d4e79",
+			ErrorInstance: err,
+		})
+		return
+	}
+
+	server, err := route.federatedService.UpdateServer(ctx, getMCPServerFromContext(reqCtx), federatedmcp.UpdateServerInput{
+		Name:      request.Name,
+		URL:       request.URL,
+		Transport: request.Transport,
+		Headers:   request.Headers,
+		Active:    request.Active,
+	})
+	if err != nil {
+		abortWithCommonError(reqCtx, http.StatusBadRequest, err)
+		return
+	}
+
+	_ = route.auditService.Record(ctx, settings.RecordAuditInput{
+		OrganizationID: orgEntity.ID,
+		UserID:         ptr.ToUint(userEntity.ID),
+		UserEmail:      ptr.ToString(userEntity.Email),
+		Event:          "mcp_server.updated",
+		Metadata: map[string]interface{}{
+			"server_id":       server.PublicID,
+			"name":            server.Name,
+			"url":             server.URL,
+			"transport":       server.Transport,
+			"active":          server.Active,
+			"headers_updated": request.Headers != nil,
+		},
+	})
+	reqCtx.JSON(http.StatusOK, route.toMCPServerResponse(reqCtx, server, map[uint]string{}))
+}
+
+// @Summary Delete an MCP server
+// @Description Deletes an MCP server, its tools stop being exposed right away
+// @Tags Administration API
+// @Security BearerAuth
+// @Produce json
+// @Param server_public_id path string true "MCP server ID"
+// @Success 200 {object} openai.DeleteResponse "Deleted MCP server"
+// @Failure 401 {object} responses.ErrorResponse "Unauthorized"
+// @Failure 404 {object} responses.ErrorResponse "MCP server not found"
+// @Router /v1/organization/mcp/servers/{server_public_id} [delete]
+func (route *MCPServerRoute) DeleteServer(reqCtx *gin.Context) {
+	ctx := reqCtx.Request.Context()
+	orgEntity, ok := auth.GetAdminOrganizationFromContext(reqCtx)
+	if !ok {
+		return
+	}
+	userEntity, ok := auth.GetUserFromContext(reqCtx)
+	if !ok {
+		return
+	}
+	server := getMCPServerFromContext(reqCtx)
+	if err := route.federatedService.DeleteServer(ctx, server); err != nil {
+		abortWithCommonError(reqCtx, http.StatusInternalServerError, err)
+		return
+	}
+
+	_ = route.auditService.Record(ctx, settings.RecordAuditInput{
+		OrganizationID: orgEntity.ID,
+		UserID:         ptr.ToUint(userEntity.ID),
+		UserEmail:      ptr.ToString(userEntity.Email),
+		Event:          "mcp_server.deleted",
+		Metadata: map[string]interface{}{
+			"server_id": server.PublicID,
+			"name":      server.Name,
+		},
+	})
+	reqCtx.JSON(http.StatusOK, openai.DeleteResponse{
+		Object:  "mcp.server.deleted",
+		ID:      server.PublicID,
+		Deleted: true,
+	})
+}
+
+// @Summary Sync an MCP server
+// @Description Connects to the MCP server and returns the namespaced tools and prompts and the resources it currently exposes
+// @Tags Administration API
+// @Security BearerAuth
+// @Produce json
+// @Param server_public_id path string true "MCP server ID"
+// @Success 200 {object} MCPServerSyncResponse "Discovered capabilities"
+// @Failure 401 {object} responses.ErrorResponse "Unauthorized"
+// @Failure 404 {object} responses.ErrorResponse "MCP server not found"
+// @Failure 502 {object} responses.ErrorResponse "The MCP server could not be reached"
+// @Router /v1/organization/mcp/servers/{server_public_id}/sync [post]
+func (route *MCPServerRoute) SyncServer(reqCtx *gin.Context) {
+	server := getMCPServerFromContext(reqCtx)
+	started := time.Now()
+	capabilities, err := route.federatedService.Discover(reqCtx.Request.Context(), server)
+	if err != nil {
+		reqCtx.AbortWithStatusJSON(http.StatusBadGateway, responses.ErrorResponse{
+			Code:          "9d4b7e1a-6c3f-4a8d-b2e9-5f1a8c4d7b36",
+			ErrorInstance: err,
+		})
+		return
+	}
+
+	resp := MCPServerSyncResponse{
+		Server:            route.toMCPServerResponse(reqCtx, server, map[uint]string{}),
+		Tools:             []string{},
+		Prompts:           []string{},
+		Resources:         []string{},
+		ResourceTemplates: []string{},
+		LatencyMs:         time.Since(started).Milliseconds(),
+	}
+	for _, tool := range capabilities.Tools {
+		resp.Tools = append(resp.Tools, federatedmcp.NamespacedName(server.Name, tool.Name))
+	}
+	for _, prompt := range capabilities.Prompts {
+		resp.Prompts = append(resp.Prompts, federatedmcp.NamespacedName(server.Name, prompt.Name))
+	}
+	for _, resource := range capabilities.Resources {
+		resp.Resources = append(resp.Resources, resource.URI)
+	}
+	for _, template := range capabilities.ResourceTemplates {
+		if template.URITemplate != nil {
+			resp.ResourceTemplates = append(resp.ResourceTemplates, template.URITemplate.Raw())
+		}
+	}
+	reqCtx.JSON(http.StatusOK, resp)
+}
+
+// @Summary Get the MCP tool allowlist of a project
+// @Description Returns the tools exposed on /v1/mcp to the API keys of a project. allowed_tools is null when every tool is exposed.
+// @Tags Administration API
+// @Security BearerAuth
+// @Produce json
+// @Param project_public_id path string true "Project Public ID"
+// @Success 200 {object} MCPToolAllowlistResponse "Tool allowlist"
+// @Failure 400 {object} responses.ErrorResponse "Invalid project"
+// @Failure 401 {object} responses.ErrorResponse "Unauthorized"
+// @Router /v1/organization/mcp/projects/{project_public_id}/tools [get]
+func (route *MCPServerRoute) GetToolAllowlist(reqCtx *gin.Context) {
+	orgEntity, ok := auth.GetAdminOrganizationFromContext(reqCtx)
+	if !ok {
+		return
+	}
+	projectEntity := route.findProject(reqCtx, orgEntity.ID, reqCtx.Param("project_public_id"))
+	if projectEntity == nil {
+		return
+	}
+	allowlist, err := route.federatedService.GetToolAllowlist(reqCtx.Request.Context(), projectEntity.ID)
+	if err != nil {
+		abortWithCommonError(reqCtx, http.StatusInternalServerError, err)
+		return
+	}
+	reqCtx.JSON(http.StatusOK, toMCPToolAllowlistResponse(projectEntity.PublicID, allowlist))
+}
+
+// @Summary Update the MCP tool allowlist of a project
+// @Description Restricts the tools exposed on /v1/mcp to the API keys of a project. Entries are namespaced tool names such as `github__create_issue`,
+// @Description `github__*` for every tool of a server, or `*`. Built-in tools are listed by their plain name. Send null to expose every tool again.
+// @Tags Administration API
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param project_public_id path string true "Project Public ID"
+// @Param request body UpdateMCPToolAllowlistRequest true "Allowed tools"
+// @Success 200 {object} MCPToolAllowlistResponse "Updated tool allowlist"
+// @Failure 400 {object} responses.ErrorResponse "Invalid request"
+// @Failure 401 {object} responses.ErrorResponse "Unauthorized"
+// @Router /v1/organization/mcp/projects/{project_public_id}/tools [post]
+func (route *MCPServerRoute) UpdateToolAllowlist(reqCtx *gin.Context) {
+	ctx := reqCtx.Request.Context()
+	orgEntity, ok := auth.GetAdminOrganizationFromContext(reqCtx)
+	if !ok {
+		return
+	}
+	userEntity, ok := auth.GetUserFromContext(reqCtx)
+	if !ok {
+		return
+	}
+	projectEntity := route.findProject(reqCtx, orgEntity.ID, reqCtx.Param("project_public_id"))
+	if projectEntity == nil {
+		return
+	}
+	var request UpdateMCPToolAllowlistRequest
+	if err := reqCtx.ShouldBindJSON(&request); err != nil {
+		reqCtx.AbortWithStatusJSON(http.StatusBadRequest, responses.ErrorResponse{
+			Code:          "6a1d8f3c-4e9b-4b2a-8c7d-3e9f1a5c8b42",
+			ErrorInstance: err,
+		})
+		return
+	}
+
+	allowlist, err := route.federatedService.SetToolAllowlist(ctx, projectEntity.ID, request.AllowedTools)
+	if err != nil {
+		abortWithCommonError(reqCtx, http.StatusBadRequest, err)
+		return
+	}
+
+	_ = route.auditService.Record(ctx, settings.RecordAuditInput{
+		OrganizationID: orgEntity.ID,
+		UserID:         ptr.ToUint(userEntity.ID),
+		UserEmail:      ptr.ToString(userEntity.Email),
+		Event:          "mcp_tool_allowlist.updated",
+		Metadata: map[string]interface{}{
+			"project_id":    projectEntity.PublicID,
+			"allowed_tools": request.AllowedTools,
+		},
+	})
+	reqCtx.JSON(http.StatusOK, toMCPToolAllowlistResponse(projectEntity.PublicID, allowlist))
+}
+
+// findProject resolves a project of the organization and aborts with 400 when there is none
+func (route *MCPServerRoute) findProject(reqCtx *gin.Context, organizationID uint, publicID string) *project.Project {
+	projectEntity, err := route.projectService.FindOne(reqCtx.Request.Context(), project.ProjectFilter{
+		PublicID:       &publicID,
+		OrganizationID: &organizationID,
+	})
+	if err != nil || projectEntity == nil {
+		reqCtx.AbortWithStatusJSON(http.StatusBadRequest, responses.ErrorResponse{
+			Code:  "1e5c9a3f-8b2d-4f6e-a7c1-4d9b2e6f3a85",
+			Error: "invalid project_public_id",
+		})
+		return nil
+	}
+	return projectEntity
+}
+
+// toMCPServerResponse resolves the public id of the project of the server, caching it in projectPublicIDs
+func (route *MCPServerRoute) toMCPServerResponse(reqCtx *gin.Context, server *federatedmcp.Server, projectPublicIDs map[uint]string) MCPServerResponse {
+	resp := MCPServerResponse{
+		ID:          server.PublicID,
+		Object:      "mcp.server",
+		Name:        server.Name,
+		URL:         server.URL,
+		Transport:   string(server.Transport),
+		HeaderNames: server.HeaderNames,
+		Active:      server.Active,
+		Scope:       "organization",
+		CreatedAt:   server.CreatedAt.Unix(),
+		UpdatedAt:   server.UpdatedAt.Unix(),
+	}
+	if resp.HeaderNames == nil {
+		resp.HeaderNames = []string{}
+	}
+	if server.ProjectID != nil {
+		resp.Scope = "project"
+		publicID, ok := projectPublicIDs[*server.ProjectID]
+		if !ok {
+			if projectEntity, err := route.projectService.FindProjectByID(reqCtx.Request.Context(), *server.ProjectID); err == nil && projectEntity != nil {
+				publicID = projectEntity.PublicID
+			}
+			projectPublicIDs[*server.ProjectID] = publicID
+		}
+		if publicID != "" {
+			resp.Project = ptr.ToString(publicID)
+		}
+	}
+	return resp
+}
+
+func toMCPToolAllowlistResponse(projectPublicID string, allowlist *federatedmcp.ToolAllowlist) MCPToolAllowlistResponse {
+	resp := MCPToolAllowlistResponse{
+		Object:  "mcp.tool_allowlist",
+		Project: projectPublicID,
+	}
+	if allowlist != nil {
+		resp.AllowedTools = allowlist.Tools
+		updatedAt := allowlist.UpdatedAt.Unix()
+		resp.UpdatedAt = &updatedAt
+	}
+	return resp
+}
@@ -29,6 +29,8 @@ type OrganizationRoute struct {
 	projectsRoute      *projects.ProjectsRoute
 	inviteRoute        *invites.InvitesRoute
 	modelProviderRoute *ModelProviderRoute
+	mcpServerRoute     *MCPServerRoute
+	cronJobRoute       *CronJobRoute
 	authService        *auth.AuthService
 	organizationSvc    *organization.OrganizationService
 	projectService     *project.ProjectService
@@ -44,6 +46,8 @@ func NewOrganizationRoute(
 	projectsRoute *projects.ProjectsRoute,
 	inviteRoute *invites.InvitesRoute,
 	modelProviderRoute *ModelProviderRoute,
+	mcpServerRoute *MCPServerRoute,
+	cronJobRoute *CronJobRoute,
 	authService *auth.AuthService,
 	organizationSvc *organization.OrganizationService,
 	projectService *project.ProjectService,
@@ -58,6 +62,8 @@ func NewOrganizationRoute(
 		projectsRoute:      projectsRoute,
 		inviteRoute:        inviteRoute,
 		modelProviderRoute: modelProviderRoute,
+		mcpServerRoute:     mcpServerRoute,
+		cronJobRoute:       cronJobRoute,
 		authService:        authService,
 		organizationSvc:    organizationSvc,
 		projectService:     projectService,
@@ -131,6 +137,53 @@ type updateWorkspaceQuotaRequest struct {
 	Overrides    []workspaceQuotaOverrideResponse `json:"overrides"`
 }
 
+type conversationTitleSettingsResponse struct {
+	Object  string `json:"object"`
+	Enabled bool   `json:"enabled"`
+	Model   string `json:"model"`
+}
+
+// updateConversationTitleSettingsRequest keeps the current value of omitted fields
+type updateConversationTitleSettingsRequest struct {
+	Enabled *bool   `json:"enabled"`
+	Model   *string `json:"model"`
+}
+
+type retentionOverrideResponse struct {
+	WorkspaceID           string `json:"workspace_id"`
+	ArchiveAfterDays      *int   `json:"archive_after_days,omitempty"`
+	PurgeDeletedAfterDays *int   `json:"purge_deleted_after_days,omitempty"`
+}
+
+type retentionPolicyResponse struct {
+	Object                string                      `json:"object"`
+	ArchiveAfterDays      int                         `json:"archive_after_days"`
+	PurgeDeletedAfterDays int                         `json:"purge_deleted_after_days"`
+	PurgeOrphanResponses  bool                        `json:"purge_orphan_responses"`
+	Overrides             []retentionOverrideResponse `json:"overrides"`
+}
+
+type updateRetentionPolicyRequest struct {
+	ArchiveAfterDays      int                         `json:"archive_after_days"`
+	PurgeDeletedAfterDays int                         `json:"purge_deleted_after_days"`
+	PurgeOrphanResponses  bool                        `json:"purge_orphan_responses"`
+	Overrides             []retentionOverrideResponse `json:"overrides"`
+}
+
+type webSearchSettingsResponse struct {
+	Object    string `json:"object"`
+	Backend   string `json:"backend"`
+	BaseURL   string `json:"base_url"`
+	HasAPIKey bool   `json:"has_api_key"`
+}
+
+type updateWebSearchSettingsRequest struct {
+	Backend string `json:"backend"`
+	BaseURL string `json:"base_url"`
+	// APIKey replaces the stored key when set, an empty string removes it
+	APIKey *string `json:"api_key,omitempty"`
+}
+
 type auditLogResponse struct {
 	Object    string                 `json:"object"`
 	ID        uint                   `json:"id"`
@@ -249,6 +302,8 @@ func (organizationRoute *OrganizationRoute) RegisterRouter(router gin.IRouter) {
 	organizationRoute.projectsRoute.RegisterRouter(organizationRouter)
 	organizationRoute.inviteRoute.RegisterRouter(organizationRouter)
 	organizationRoute.modelProviderRoute.RegisterRouter(organizationRouter)
+	organizationRoute.mcpServerRoute.RegisterRouter(organizationRouter)
+	organizationRoute.cronJobRoute.RegisterRouter(organizationRouter)
 
 	permissionAll := organizationRoute.authService.OrganizationMemberRoleMiddleware(auth.OrganizationMemberRuleAll)
 	permissionOwnerOnly := organizationRoute.authService.OrganizationMemberRoleMiddleware(auth.OrganizationMemberRuleOwnerOnly)
@@ -286,6 +341,12 @@ func (organizationRoute *OrganizationRoute) RegisterRouter(router gin.IRouter) {
 	settingsRouter.PUT("/smtp", organizationRoute.UpdateSMTPSettings)
 	settingsRouter.GET("/workspace-quotas", organizationRoute.GetWorkspaceQuota)
 	settingsRouter.PUT("/workspace-quotas", organizationRoute.UpdateWorkspaceQuota)
+	settingsRouter.GET("/conversation-titles", organizationRoute.GetConversationTitleSettings)
+	settingsRouter.PUT("/conversation-titles", organizationRoute.UpdateConversationTitleSettings)
+	settingsRouter.GET("/retention", organizationRoute.GetRetentionPolicy)
+	settingsRouter.PUT("/retention", organizationRoute.UpdateRetentionPolicy)
+	settingsRouter.GET("/web-search", organizationRoute.GetWebSearchSettings)
+	settingsRouter.PUT("/web-search", organizationRoute.UpdateWebSearchSettings)
 
 	auditRouter := organizationRouter.Group("/audit-logs",
 		organizationRoute.authService.AdminUserAuthMiddleware(),
@@ -490,14 +551,17 @@ func (organizationRoute *OrganizationRoute) UpdateSMTPSettings(reqCtx *gin.Conte
 	}
 
 	// Apply settings to the in-memory environment variables to reflect changes immediately.
-	env := &environment_variables.EnvironmentVariables
-	env.SMTP_HOST = result.Host
-	env.SMTP_PORT = result.Port
-	env.SMTP_USERNAME = result.Username
+	password := environment_variables.Current().SMTP_PASSWORD
 	if payload.Password != nil {
-		env.SMTP_PASSWORD = strings.TrimSpace(*payload.Password)
+		password = strings.TrimSpace(*payload.Password)
 	}
-	env.SMTP_SENDER_EMAIL = result.FromEmail
+	environment_variables.Override(environment_variables.OverrideSMTP, func(env *environment_variables.EnvironmentVariable) {
+		env.SMTP_HOST = result.Host
+		env.SMTP_PORT = result.Port
+		env.SMTP_USERNAME = result.Username
+		env.SMTP_PASSWORD = password
+		env.SMTP_SENDER_EMAIL = result.FromEmail
+	})
 
 	_ = organizationRoute.auditService.Record(ctx, settings.RecordAuditInput{
 		OrganizationID: orgEntity.ID,
@@ -624,6 +688,263 @@ func (organizationRoute *OrganizationRoute) UpdateWorkspaceQuota(reqCtx *gin.Con
 	reqCtx.JSON(http.StatusOK, resp)
 }
 
+// GetConversationTitleSettings returns the title generation configuration for the organization.
+func (organizationRoute *OrganizationRoute) GetConversationTitleSettings(reqCtx *gin.Context) {
+	ctx := reqCtx.Request.Context()
+	orgEntity, ok := auth.GetAdminOrganizationFromContext(reqCtx)
+	if !ok {
+		return
+	}
+
+	config, err := organizationRoute.settingsService.GetConversationTitleSettings(ctx, orgEntity.ID)
+	if err != nil {
+		reqCtx.AbortWithStatusJSON(http.StatusInternalServerError, responses.ErrorResponse{
+			Code:  "conversation-title-settings-fetch-failed",
+			Error: err.Error(),
+		})
+		return
+	}
+
+	reqCtx.JSON(http.StatusOK, conversationTitleSettingsResponse{
+		Object:  "organization.conversation_title_settings",
+		Enabled: config.Enabled,
+		Model:   config.Model,
+	})
+}
+
+// UpdateConversationTitleSettings updates the title generation configuration and records an audit log entry.
+func (organizationRoute *OrganizationRoute) UpdateConversationTitleSettings(reqCtx *gin.Context) {
+	ctx := reqCtx.Request.Context()
+	orgEntity, ok := auth.GetAdminOrganizationFromContext(reqCtx)
+	if !ok {
+		return
+	}
+	userEntity, ok := auth.GetUserFromContext(reqCtx)
+	if !ok {
+		return
+	}
+
+	var payload updateConversationTitleSettingsRequest
+	if err := reqCtx.ShouldBindJSON(&payload); err != nil {
+		reqCtx.AbortWithStatusJSON(http.StatusBadRequest, responses.ErrorResponse{
+			Code:  "conversation-title-settings-invalid",
+			Error: err.Error(),
+		})
+		return
+	}
+
+	config, err := organizationRoute.settingsService.UpdateConversationTitleSettings(ctx, orgEntity.ID, settings.UpdateConversationTitleSettingsInput{
+		Enabled:    payload.Enabled,
+		Model:      payload.Model,
+		ActorID:    ptr.ToUint(userEntity.ID),
+		ActorEmail: ptr.ToString(userEntity.Email),
+	})
+	if err != nil {
+		reqCtx.AbortWithStatusJSON(http.StatusBadRequest, responses.ErrorResponse{
+			Code:  "conversation-title-settings-update-failed",
+			Error: err.Error(),
+		})
+		return
+	}
+
+	_ = organizationRoute.auditService.Record(ctx, settings.RecordAuditInput{
+		OrganizationID: orgEntity.ID,
+		UserID:         ptr.ToUint(userEntity.ID),
+		UserEmail:      ptr.ToString(userEntity.Email),
+		Event:          "conversation_title_settings.updated",
+		Metadata: map[string]interface{}{
+			"enabled": config.Enabled,
+			"model":   config.Model,
+		},
+	})
+
+	reqCtx.JSON(http.StatusOK, conversationTitleSettingsResponse{
+		Object:  "organization.conversation_title_settings",
+		Enabled: config.Enabled,
+		Model:   config.Model,
+	})
+}
+
+// GetRetentionPolicy returns the conversation retention policy for the organization.
+func (organizationRoute *OrganizationRoute) GetRetentionPolicy(reqCtx *gin.Context) {
+	ctx := reqCtx.Request.Context()
+	orgEntity, ok := auth.GetAdminOrganizationFromContext(reqCtx)
+	if !ok {
+		return
+	}
+
+	policy, err := organizationRoute.settingsService.GetRetentionPolicy(ctx, orgEntity.ID)
+	if err != nil {
+		reqCtx.AbortWithStatusJSON(http.StatusInternalServerError, responses.ErrorResponse{
+			Code:  "retention-policy-fetch-failed",
+			Error: err.Error(),
+		})
+		return
+	}
+
+	reqCtx.JSON(http.StatusOK, toRetentionPolicyResponse(policy))
+}
+
+// UpdateRetentionPolicy updates the conversation retention policy and records an audit log entry.
+func (organizationRoute *OrganizationRoute) UpdateRetentionPolicy(reqCtx *gin.Context) {
+	ctx := reqCtx.Request.Context()
+	orgEntity, ok := auth.GetAdminOrganizationFromContext(reqCtx)
+	if !ok {
+		return
+	}
+	userEntity, ok := auth.GetUserFromContext(reqCtx)
+	if !ok {
+		return
+	}
+
+	var payload updateRetentionPolicyRequest
+	if err := reqCtx.ShouldBindJSON(&payload); err != nil {
+		reqCtx.AbortWithStatusJSON(http.StatusBadRequest, responses.ErrorResponse{
+			Code:  "retention-policy-invalid",
+			Error: err.Error(),
+		})
+		return
+	}
+
+	overrides := make([]settings.RetentionOverride, 0, len(payload.Overrides))
+	for _, override := range payload.Overrides {
+		overrides = append(overrides, settings.RetentionOverride{
+			WorkspacePublicID:     override.WorkspaceID,
+			ArchiveAfterDays:      override.ArchiveAfterDays,
+			PurgeDeletedAfterDays: override.PurgeDeletedAfterDays,
+		})
+	}
+
+	policy, err := organizationRoute.settingsService.UpdateRetentionPolicy(ctx, orgEntity.ID, settings.UpdateRetentionPolicyInput{
+		ArchiveAfterDays:      payload.ArchiveAfterDays,
+		PurgeDeletedAfterDays: payload.PurgeDeletedAfterDays,
+		PurgeOrphanResponses:  payload.PurgeOrphanResponses,
+		Overrides:             overrides,
+		ActorID:               ptr.ToUint(userEntity.ID),
+		ActorEmail:            ptr.ToString(userEntity.Email),
+	})
+	if err != nil {
+		reqCtx.AbortWithStatusJSON(http.StatusBadRequest, responses.ErrorResponse{
+			Code:  "retention-policy-update-failed",
+			Error: err.Error(),
+		})
+		return
+	}
+
+	_ = organizationRoute.auditService.Record(ctx, settings.RecordAuditInput{
+		OrganizationID: orgEntity.ID,
+		UserID:         ptr.ToUint(userEntity.ID),
+		UserEmail:      ptr.ToString(userEntity.Email),
+		Event:          "retention_policy.updated",
+		Metadata: map[string]interface{}{
+			"archive_after_days":       policy.ArchiveAfterDays,
+			"purge_deleted_after_days": policy.PurgeDeletedAfterDays,
+			"purge_orphan_responses":   policy.PurgeOrphanResponses,
+			"override_count":           len(policy.Overrides),
+		},
+	})
+
+	reqCtx.JSON(http.StatusOK, toRetentionPolicyResponse(policy))
+}
+
+func toRetentionPolicyResponse(policy *settings.RetentionPolicy) retentionPolicyResponse {
+	resp := retentionPolicyResponse{
+		Object:                "organization.retention_policy",
+		ArchiveAfterDays:      policy.ArchiveAfterDays,
+		PurgeDeletedAfterDays: policy.PurgeDeletedAfterDays,
+		PurgeOrphanResponses:  policy.PurgeOrphanResponses,
+		Overrides:             make([]retentionOverrideResponse, 0, len(policy.Overrides)),
+	}
+	for _, override := range policy.Overrides {
+		resp.Overrides = append(resp.Overrides, retentionOverrideResponse{
+			WorkspaceID:           override.WorkspacePublicID,
+			ArchiveAfterDays:      override.ArchiveAfterDays,
+			PurgeDeletedAfterDays: override.PurgeDeletedAfterDays,
+		})
+	}
+	return resp
+}
+
+// GetWebSearchSettings returns the web search backend of the organization, the API key is never returned.
+func (organizationRoute *OrganizationRoute) GetWebSearchSettings(reqCtx *gin.Context) {
+	ctx := reqCtx.Request.Context()
+	orgEntity, ok := auth.GetAdminOrganizationFromContext(reqCtx)
+	if !ok {
+		return
+	}
+
+	config, err := organizationRoute.settingsService.GetWebSearchSettings(ctx, orgEntity.ID)
+	if err != nil {
+		reqCtx.AbortWithStatusJSON(http.StatusInternalServerError, responses.ErrorResponse{
+			Code:  "web-search-settings-fetch-failed",
+			Error: err.Error(),
+		})
+		return
+	}
+
+	reqCtx.JSON(http.StatusOK, toWebSearchSettingsResponse(config))
+}
+
+// UpdateWebSearchSettings selects the web search backend and records an audit log entry.
+func (organizationRoute *OrganizationRoute) UpdateWebSearchSettings(reqCtx *gin.Context) {
+	ctx := reqCtx.Request.Context()
+	orgEntity, ok := auth.GetAdminOrganizationFromContext(reqCtx)
+	if !ok {
+		return
+	}
+	userEntity, ok := auth.GetUserFromContext(reqCtx)
+	if !ok {
+		return
+	}
+
+	var payload updateWebSearchSettingsRequest
+	if err := reqCtx.ShouldBindJSON(&payload); err != nil {
+		reqCtx.AbortWithStatusJSON(http.StatusBadRequest, responses.ErrorResponse{
+			Code:  "web-search-settings-invalid",
+			Error: err.Error(),
+		})
+		return
+	}
+
+	config, err := organizationRoute.settingsService.UpdateWebSearchSettings(ctx, orgEntity.ID, settings.UpdateWebSearchSettingsInput{
+		Backend:    payload.Backend,
+		BaseURL:    payload.BaseURL,
+		APIKey:     payload.APIKey,
+		ActorID:    ptr.ToUint(userEntity.ID),
+		ActorEmail: ptr.ToString(userEntity.Email),
+	})
+	if err != nil {
+		reqCtx.AbortWithStatusJSON(http.StatusBadRequest, responses.ErrorResponse{
+			Code:  "web-search-settings-update-failed",
+			Error: err.Error(),
+		})
+		return
+	}
+
+	_ = organizationRoute.auditService.Record(ctx, settings.RecordAuditInput{
+		OrganizationID: orgEntity.ID,
+		UserID:         ptr.ToUint(userEntity.ID),
+		UserEmail:      ptr.ToString(userEntity.Email),
+		Event:          "web_search_settings.updated",
+		Metadata: map[string]interface{}{
+			"backend":         config.Backend,
+			"base_url":        config.BaseURL,
+			"api_key_changed": payload.APIKey != nil,
+		},
+	})
+
+	reqCtx.JSON(http.StatusOK, toWebSearchSettingsResponse(config))
+}
+
+func toWebSearchSettingsResponse(config *settings.WebSearchSettings) webSearchSettingsResponse {
+	return webSearchSettingsResponse{
+		Object:    "organization.web_search_settings",
+		Backend:   config.Backend,
+		BaseURL:   config.BaseURL,
+		HasAPIKey: config.HasAPIKey,
+	}
+}
+
 // ListAuditLogs returns audit log entries for the organization.
 func (organizationRoute *OrganizationRoute) ListAuditLogs(reqCtx *gin.Context) {
 	ctx := reqCtx.Request.Context()
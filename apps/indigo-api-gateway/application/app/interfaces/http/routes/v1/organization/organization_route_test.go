@@ -16,6 +16,7 @@ import (
 	domainauth "menlo.ai/indigo-api-gateway/app/domain/auth"
 	"menlo.ai/indigo-api-gateway/app/domain/organization"
 	"menlo.ai/indigo-api-gateway/app/domain/query"
+	"menlo.ai/indigo-api-gateway/app/domain/settings"
 	"menlo.ai/indigo-api-gateway/app/domain/user"
 	"menlo.ai/indigo-api-gateway/app/interfaces/http/responses/openai"
 )
@@ -446,3 +447,98 @@ func TestOrganizationRoute_UpdateMemberRole(t *testing.T) {
 		t.Fatalf("expected member role reader, got %v", member)
 	}
 }
+
+type memorySettingRepo struct {
+	mu       sync.Mutex
+	settings map[string]*settings.SystemSetting
+}
+
+func (m *memorySettingRepo) FindByKey(ctx context.Context, organizationID uint, key string) (*settings.SystemSetting, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	setting, ok := m.settings[key]
+	if !ok || setting.OrganizationID != organizationID {
+		return nil, settings.ErrSettingNotFound
+	}
+	cp := *setting
+	return &cp, nil
+}
+
+func (m *memorySettingRepo) Upsert(ctx context.Context, setting *settings.SystemSetting) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	cp := *setting
+	m.settings[setting.Key] = &cp
+	return nil
+}
+
+type memoryAuditRepo struct {
+	mu      sync.Mutex
+	entries []*settings.AuditLog
+}
+
+func (m *memoryAuditRepo) Create(ctx context.Context, entry *settings.AuditLog) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries = append(m.entries, entry)
+	return nil
+}
+
+func (m *memoryAuditRepo) FindByFilter(ctx context.Context, filter settings.AuditLogFilter) ([]*settings.AuditLog, error) {
+	return nil, nil
+}
+
+func (m *memoryAuditRepo) Count(ctx context.Context, filter settings.AuditLogFilter) (int64, error) {
+	return 0, nil
+}
+
+func updateConversationTitleSettings(t *testing.T, route *OrganizationRoute, orgEntity *organization.Organization, owner *user.User, body string) conversationTitleSettingsResponse {
+	t.Helper()
+	recorder := httptest.NewRecorder()
+	ginCtx, _ := gin.CreateTestContext(recorder)
+	req := httptest.NewRequest(http.MethodPut, "/v1/organization/settings/conversation-titles", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	ginCtx.Request = req
+	domainauth.SetAdminOrganizationToContext(ginCtx, orgEntity)
+	domainauth.SetUserToContext(ginCtx, owner)
+
+	route.UpdateConversationTitleSettings(ginCtx)
+
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	var response conversationTitleSettingsResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	return response
+}
+
+func TestOrganizationRoute_UpdateConversationTitleSettingsKeepsOmittedFields(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	route, orgEntity, owner, _, _, _, _, _ := seedOrganizationRoute(t)
+	auditRepo := &memoryAuditRepo{}
+	route.settingsService = settings.NewService(&memorySettingRepo{settings: make(map[string]*settings.SystemSetting)})
+	route.auditService = settings.NewAuditService(auditRepo)
+
+	response := updateConversationTitleSettings(t, route, orgEntity, owner, `{"enabled":false,"model":" title-model "}`)
+	if response.Enabled || response.Model != "title-model" {
+		t.Fatalf("expected disabled with title-model, got %+v", response)
+	}
+
+	response = updateConversationTitleSettings(t, route, orgEntity, owner, `{"model":"other-model"}`)
+	if response.Enabled {
+		t.Fatal("expected omitted enabled to keep titles disabled")
+	}
+	if response.Model != "other-model" {
+		t.Fatalf("expected model other-model, got %s", response.Model)
+	}
+
+	response = updateConversationTitleSettings(t, route, orgEntity, owner, `{"enabled":true}`)
+	if !response.Enabled || response.Model != "other-model" {
+		t.Fatalf("expected enabled with the kept model, got %+v", response)
+	}
+	if len(auditRepo.entries) != 3 {
+		t.Fatalf("expected 3 audit entries, got %d", len(auditRepo.entries))
+	}
+}
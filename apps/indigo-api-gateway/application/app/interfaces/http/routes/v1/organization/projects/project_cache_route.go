@@ -0,0 +1,131 @@
+package projects
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"menlo.ai/indigo-api-gateway/app/domain/auth"
+	"menlo.ai/indigo-api-gateway/app/domain/completioncache"
+	"menlo.ai/indigo-api-gateway/app/interfaces/http/responses"
+	"menlo.ai/indigo-api-gateway/app/utils/ptr"
+)
+
+type UpdateCompletionCacheSettingsRequest struct {
+	Enabled *bool `json:"enabled"`
+	// Mode is exact or semantic
+	Mode       *string `json:"mode"`
+	TTLSeconds *int    `json:"ttl_seconds"`
+	// SimilarityThreshold is the cosine similarity a semantic match needs, between 0.5 and 1
+	SimilarityThreshold *float64 `json:"similarity_threshold"`
+	// EmbeddingModel embeds the prompts of the semantic mode, empty uses VECTOR_STORE_EMBEDDING_MODEL
+	EmbeddingModel *string `json:"embedding_model"`
+}
+
+type CompletionCacheSettingsResponse struct {
+	Object              string  `json:"object"`
+	Project             string  `json:"project_public_id"`
+	Enabled             bool    `json:"enabled"`
+	Mode                string  `json:"mode"`
+	TTLSeconds          int     `json:"ttl_seconds"`
+	SimilarityThreshold float64 `json:"similarity_threshold"`
+	EmbeddingModel      string  `json:"embedding_model"`
+	UpdatedAt           *int64  `json:"updated_at,omitempty"`
+}
+
+// GetCompletionCacheSettings godoc
+// @Summary Get Completion Cache Settings
+// @Description Returns the chat completion cache settings of a project. Projects without settings are not cached.
+// @Tags Administration API
+// @Security BearerAuth
+// @Produce json
+// @Param project_id path string true "ID of the project"
+// @Success 200 {object} CompletionCacheSettingsResponse "Cache settings of the project"
+// @Failure 401 {object} responses.ErrorResponse "Unauthorized - invalid or missing API key"
+// @Failure 404 {object} responses.ErrorResponse "Not Found - project with the given ID does not exist"
+// @Failure 500 {object} responses.ErrorResponse "Internal Server Error"
+// @Router /v1/organization/projects/{project_id}/cache [get]
+func (api *ProjectsRoute) GetCompletionCacheSettings(reqCtx *gin.Context) {
+	projectEntity, ok := auth.GetProjectFromContext(reqCtx)
+	if !ok {
+		reqCtx.AbortWithStatusJSON(http.StatusNotFound, responses.ErrorResponse{
+			Code:  "42ad3a04-6c17-40db-a10f-640be569c93f",
+			Error: "project not found",
+		})
+		return
+	}
+	settings, err := api.completionCache.GetSettings(reqCtx.Request.Context(), projectEntity.ID)
+	if err != nil {
+		reqCtx.AbortWithStatusJSON(http.StatusInternalServerError, responses.ErrorResponse{
+			Code:          err.GetCode(),
+			ErrorInstance: err.GetError(),
+		})
+		return
+	}
+	reqCtx.JSON(http.StatusOK, domainToCompletionCacheSettingsResponse(projectEntity.PublicID, settings))
+}
+
+// UpdateCompletionCacheSettings godoc
+// @Summary Update Completion Cache Settings
+// @Description Enables the chat completion cache of a project and sets its mode, TTL and similarity threshold; fields left out keep their value.
+// @Description Only deterministic requests, with an explicit temperature of 0 or a seed, sent with an API key of the project are cached.
+// @Tags Administration API
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param project_id path string true "ID of the project"
+// @Param body body UpdateCompletionCacheSettingsRequest true "Cache settings"
+// @Success 200 {object} CompletionCacheSettingsResponse "Updated cache settings"
+// @Failure 400 {object} responses.ErrorResponse "Bad request - invalid mode, TTL or similarity threshold"
+// @Failure 401 {object} responses.ErrorResponse "Unauthorized - invalid or missing API key"
+// @Failure 404 {object} responses.ErrorResponse "Not Found - project with the given ID does not exist"
+// @Router /v1/organization/projects/{project_id}/cache [post]
+func (api *ProjectsRoute) UpdateCompletionCacheSettings(reqCtx *gin.Context) {
+	var request UpdateCompletionCacheSettingsRequest
+	if err := reqCtx.ShouldBindJSON(&request); err != nil {
+		reqCtx.AbortWithStatusJSON(http.StatusBadRequest, responses.ErrorResponse{
+			Code:          "6b1e8d3f-9a4c-4f2e-b7d5-1c8a3e6f9b42",
+			ErrorInstance: err,
+		})
+		return
+	}
+	projectEntity, ok := auth.GetProjectFromContext(reqCtx)
+	if !ok {
+		reqCtx.AbortWithStatusJSON(http.StatusNotFound, responses.ErrorResponse{
+			Code:  "42ad3a04-6c17-40db-a10f-640be569c93f",
+			Error: "project not found",
+		})
+		return
+	}
+	settings, err := api.completionCache.UpdateSettings(reqCtx.Request.Context(), projectEntity.ID, completioncache.UpdateSettingsInput{
+		Enabled:             request.Enabled,
+		Mode:                request.Mode,
+		TTLSeconds:          request.TTLSeconds,
+		SimilarityThreshold: request.SimilarityThreshold,
+		EmbeddingModel:      request.EmbeddingModel,
+	})
+	if err != nil {
+		reqCtx.AbortWithStatusJSON(http.StatusBadRequest, responses.ErrorResponse{
+			Code:  err.GetCode(),
+			Error: err.GetMessage(),
+		})
+		return
+	}
+	reqCtx.JSON(http.StatusOK, domainToCompletionCacheSettingsResponse(projectEntity.PublicID, settings))
+}
+
+func domainToCompletionCacheSettingsResponse(projectPublicID string, settings *completioncache.Settings) CompletionCacheSettingsResponse {
+	var updatedAt *int64
+	if !settings.UpdatedAt.IsZero() {
+		updatedAt = ptr.ToInt64(settings.UpdatedAt.Unix())
+	}
+	return CompletionCacheSettingsResponse{
+		Object:              "project.completion_cache_settings",
+		Project:             projectPublicID,
+		Enabled:             settings.Enabled,
+		Mode:                string(settings.Mode),
+		TTLSeconds:          settings.TTLSeconds,
+		SimilarityThreshold: settings.SimilarityThreshold,
+		EmbeddingModel:      settings.EmbeddingModel,
+		UpdatedAt:           updatedAt,
+	}
+}
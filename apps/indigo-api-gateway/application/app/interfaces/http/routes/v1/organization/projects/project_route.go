@@ -10,6 +10,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"menlo.ai/indigo-api-gateway/app/domain/apikey"
 	"menlo.ai/indigo-api-gateway/app/domain/auth"
+	"menlo.ai/indigo-api-gateway/app/domain/completioncache"
 	domainmodel "menlo.ai/indigo-api-gateway/app/domain/model"
 	"menlo.ai/indigo-api-gateway/app/domain/organization"
 	"menlo.ai/indigo-api-gateway/app/domain/project"
@@ -18,17 +19,20 @@ import (
 	"menlo.ai/indigo-api-gateway/app/interfaces/http/responses"
 	"menlo.ai/indigo-api-gateway/app/interfaces/http/responses/openai"
 	projectApikeyRoute "menlo.ai/indigo-api-gateway/app/interfaces/http/routes/v1/organization/projects/api_keys"
+	projectWebhooks "menlo.ai/indigo-api-gateway/app/interfaces/http/routes/v1/organization/projects/webhooks"
 	"menlo.ai/indigo-api-gateway/app/utils/functional"
 	"menlo.ai/indigo-api-gateway/app/utils/ptr"
 )
 
 type ProjectsRoute struct {
-	projectService     *project.ProjectService
-	apiKeyService      *apikey.ApiKeyService
-	authService        *auth.AuthService
-	projectApiKeyRoute *projectApikeyRoute.ProjectApiKeyRoute
-	providerRegistry   *domainmodel.ProviderRegistryService
-	inferenceProvider  *inference.InferenceProvider
+	projectService      *project.ProjectService
+	apiKeyService       *apikey.ApiKeyService
+	authService         *auth.AuthService
+	projectApiKeyRoute  *projectApikeyRoute.ProjectApiKeyRoute
+	projectWebhookRoute *projectWebhooks.ProjectWebhookRoute
+	providerRegistry    *domainmodel.ProviderRegistryService
+	inferenceProvider   *inference.InferenceProvider
+	completionCache     *completioncache.CompletionCacheService
 }
 
 func NewProjectsRoute(
@@ -36,16 +40,20 @@ func NewProjectsRoute(
 	apiKeyService *apikey.ApiKeyService,
 	authService *auth.AuthService,
 	projectApiKeyRoute *projectApikeyRoute.ProjectApiKeyRoute,
+	projectWebhookRoute *projectWebhooks.ProjectWebhookRoute,
 	providerRegistry *domainmodel.ProviderRegistryService,
 	inferenceProvider *inference.InferenceProvider,
+	completionCache *completioncache.CompletionCacheService,
 ) *ProjectsRoute {
 	return &ProjectsRoute{
 		projectService,
 		apiKeyService,
 		authService,
 		projectApiKeyRoute,
+		projectWebhookRoute,
 		providerRegistry,
 		inferenceProvider,
+		completionCache,
 	}
 }
 
@@ -89,7 +97,15 @@ func (projectsRoute *ProjectsRoute) RegisterRouter(router gin.IRouter) {
 		permissionOwnerOnly,
 		projectsRoute.updateProjectProvider,
 	)
+	projectIdRouter.GET("/cache",
+		projectsRoute.GetCompletionCacheSettings,
+	)
+	projectIdRouter.POST("/cache",
+		permissionOwnerOnly,
+		projectsRoute.UpdateCompletionCacheSettings,
+	)
 	projectsRoute.projectApiKeyRoute.RegisterRouter(projectIdRouter)
+	projectsRoute.projectWebhookRoute.RegisterRouter(projectIdRouter)
 }
 
 // GetProjects godoc
@@ -0,0 +1,509 @@
+package webhooks
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"menlo.ai/indigo-api-gateway/app/domain/auth"
+	"menlo.ai/indigo-api-gateway/app/domain/query"
+	"menlo.ai/indigo-api-gateway/app/domain/webhook"
+	"menlo.ai/indigo-api-gateway/app/interfaces/http/responses"
+	"menlo.ai/indigo-api-gateway/app/interfaces/http/responses/openai"
+	"menlo.ai/indigo-api-gateway/app/utils/functional"
+)
+
+type ProjectWebhookRoute struct {
+	authService    *auth.AuthService
+	webhookService *webhook.WebhookService
+}
+
+func NewProjectWebhookRoute(
+	authService *auth.AuthService,
+	webhookService *webhook.WebhookService,
+) *ProjectWebhookRoute {
+	return &ProjectWebhookRoute{
+		authService,
+		webhookService,
+	}
+}
+
+func (api *ProjectWebhookRoute) RegisterRouter(router gin.IRouter) {
+	permissionOwnerOnly := api.authService.OrganizationMemberRoleMiddleware(auth.OrganizationMemberRuleOwnerOnly)
+	webhookRouter := router.Group("/webhooks")
+	webhookRouter.GET("", api.ListWebhooks)
+	webhookRouter.POST("", permissionOwnerOnly, api.CreateWebhook)
+	webhookRouter.GET("/dead_letters", api.ListDeadLetters)
+
+	webhookIdRouter := webhookRouter.Group("/:webhook_id", api.webhookMiddleware)
+	webhookIdRouter.GET("", api.GetWebhook)
+	webhookIdRouter.POST("", permissionOwnerOnly, api.UpdateWebhook)
+	webhookIdRouter.DELETE("", permissionOwnerOnly, api.DeleteWebhook)
+	webhookIdRouter.POST("/test", permissionOwnerOnly, api.TestWebhook)
+	webhookIdRouter.GET("/deliveries", api.ListDeliveries)
+	webhookIdRouter.GET("/deliveries/:delivery_id", api.GetDelivery)
+	webhookIdRouter.POST("/deliveries/:delivery_id/redeliver", permissionOwnerOnly, api.Redeliver)
+}
+
+const webhookContextKey = "webhook_endpoint"
+
+// webhookMiddleware loads the endpoint of the path from the project in context
+func (api *ProjectWebhookRoute) webhookMiddleware(reqCtx *gin.Context) {
+	project, ok := auth.GetProjectFromContext(reqCtx)
+	if !ok {
+		reqCtx.AbortWithStatusJSON(http.StatusBadRequest, responses.ErrorResponse{
+			Code: "2c7e4a9f-5b1d-4e8a-b3f6-9d2a7c5e1b48",
+		})
+		return
+	}
+	endpoint, err := api.webhookService.GetEndpoint(reqCtx.Request.Context(), project.ID, reqCtx.Param("webhook_id"))
+	if err != nil {
+		responses.AbortWithError(reqCtx, http.StatusNotFound, err)
+		return
+	}
+	reqCtx.Set(webhookContextKey, endpoint)
+	reqCtx.Next()
+}
+
+func getWebhookFromContext(reqCtx *gin.Context) *webhook.Endpoint {
+	endpoint, _ := reqCtx.Get(webhookContextKey)
+	return endpoint.(*webhook.Endpoint)
+}
+
+type CreateWebhookRequest struct {
+	URL         string   `json:"url" binding:"required"`
+	Description string   `json:"description"`
+	EventTypes  []string `json:"event_types" binding:"required"`
+}
+
+type UpdateWebhookRequest struct {
+	URL          *string  `json:"url"`
+	Description  *string  `json:"description"`
+	EventTypes   []string `json:"event_types"`
+	Enabled      *bool    `json:"enabled"`
+	RotateSecret bool     `json:"rotate_secret"`
+}
+
+type WebhookResponse struct {
+	ID          string   `json:"id"`
+	Object      string   `json:"object"`
+	URL         string   `json:"url"`
+	Description string   `json:"description"`
+	EventTypes  []string `json:"event_types"`
+	Enabled     bool     `json:"enabled"`
+	// Secret is only returned when the endpoint is created or its secret is rotated
+	Secret    *string `json:"secret,omitempty"`
+	CreatedAt int64   `json:"created_at"`
+	UpdatedAt int64   `json:"updated_at"`
+}
+
+type WebhookDeliveryResponse struct {
+	ID             string                           `json:"id"`
+	Object         string                           `json:"object"`
+	WebhookID      string                           `json:"webhook_id"`
+	EventID        string                           `json:"event_id"`
+	EventType      string                           `json:"event_type"`
+	Status         string                           `json:"status"`
+	Attempts       int                              `json:"attempts"`
+	NextAttemptAt  *int64                           `json:"next_attempt_at"`
+	LastStatusCode *int                             `json:"last_status_code"`
+	LastError      *string                          `json:"last_error"`
+	DeliveredAt    *int64                           `json:"delivered_at"`
+	CreatedAt      int64                            `json:"created_at"`
+	Payload        *string                          `json:"payload,omitempty"`
+	AttemptLogs    []WebhookDeliveryAttemptResponse `json:"attempt_logs,omitempty"`
+}
+
+type WebhookDeliveryAttemptResponse struct {
+	Attempt      int     `json:"attempt"`
+	StatusCode   *int    `json:"status_code"`
+	Error        *string `json:"error"`
+	ResponseBody *string `json:"response_body"`
+	DurationMs   int64   `json:"duration_ms"`
+	CreatedAt    int64   `json:"created_at"`
+}
+
+func toWebhookResponse(endpoint *webhook.Endpoint, includeSecret bool) WebhookResponse {
+	response := WebhookResponse{
+		ID:          endpoint.PublicID,
+		Object:      "webhook",
+		URL:         endpoint.URL,
+		Description: endpoint.Description,
+		EventTypes:  endpoint.EventTypes,
+		Enabled:     endpoint.Enabled,
+		CreatedAt:   endpoint.CreatedAt.Unix(),
+		UpdatedAt:   endpoint.UpdatedAt.Unix(),
+	}
+	if includeSecret {
+		response.Secret = &endpoint.Secret
+	}
+	return response
+}
+
+func toDeliveryResponse(webhookID string, delivery *webhook.Delivery) WebhookDeliveryResponse {
+	response := WebhookDeliveryResponse{
+		ID:             delivery.PublicID,
+		Object:         "webhook.delivery",
+		WebhookID:      webhookID,
+		EventID:        delivery.EventID,
+		EventType:      string(delivery.EventType),
+		Status:         string(delivery.Status),
+		Attempts:       delivery.Attempts,
+		LastStatusCode: delivery.LastStatusCode,
+		LastError:      delivery.LastError,
+		CreatedAt:      delivery.CreatedAt.Unix(),
+	}
+	if delivery.Status == webhook.DeliveryStatusPending {
+		nextAttemptAt := delivery.NextAttemptAt.Unix()
+		response.NextAttemptAt = &nextAttemptAt
+	}
+	if delivery.DeliveredAt != nil {
+		deliveredAt := delivery.DeliveredAt.Unix()
+		response.DeliveredAt = &deliveredAt
+	}
+	return response
+}
+
+// @Summary List project webhooks
+// @Description Lists the webhook endpoints of a project. Secrets are not included.
+// @Tags Administration API
+// @Security BearerAuth
+// @Produce json
+// @Param project_public_id path string true "Project Public ID"
+// @Param limit query int false "The maximum number of items to return" default(20)
+// @Param offset query int false "The number of items to skip"
+// @Success 200 {object} openai.ListResponse[WebhookResponse] "List of webhook endpoints"
+// @Failure 400 {object} responses.ErrorResponse "Invalid parameters"
+// @Failure 401 {object} responses.ErrorResponse "Unauthorized"
+// @Failure 500 {object} responses.ErrorResponse "Internal server error"
+// @Router /v1/organization/projects/{project_public_id}/webhooks [get]
+func (api *ProjectWebhookRoute) ListWebhooks(reqCtx *gin.Context) {
+	ctx := reqCtx.Request.Context()
+	project, ok := auth.GetProjectFromContext(reqCtx)
+	if !ok {
+		reqCtx.AbortWithStatusJSON(http.StatusBadRequest, responses.ErrorResponse{
+			Code: "8e3b1d7a-4f9c-4a2e-b6d5-1c8f3a9e7b24",
+		})
+		return
+	}
+	pagination, err := query.GetPaginationFromQuery(reqCtx)
+	if err != nil {
+		reqCtx.AbortWithStatusJSON(http.StatusBadRequest, responses.ErrorResponse{
+			Code:  "8e3b1d7a-4f9c-4a2e-b6d5-1c8f3a9e7b25",
+			Error: "invalid or missing query parameter",
+		})
+		return
+	}
+	endpoints, total, listErr := api.webhookService.ListEndpoints(ctx, project.ID, pagination)
+	if listErr != nil {
+		responses.AbortWithError(reqCtx, http.StatusInternalServerError, listErr)
+		return
+	}
+	reqCtx.JSON(http.StatusOK, openai.ListResponse[WebhookResponse]{
+		Object: openai.ObjectTypeListList,
+		Data: functional.Map(endpoints, func(endpoint *webhook.Endpoint) WebhookResponse {
+			return toWebhookResponse(endpoint, false)
+		}),
+		Total: total,
+	})
+}
+
+// @Summary Create a project webhook
+// @Description Registers a URL that receives signed POST requests for the subscribed response events of the project's background responses. The signing secret is only returned by this call.
+// @Tags Administration API
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param project_public_id path string true "Project Public ID"
+// @Param request body CreateWebhookRequest true "Webhook endpoint"
+// @Success 200 {object} WebhookResponse "Created webhook endpoint, including its secret"
+// @Failure 400 {object} responses.ErrorResponse "Invalid request"
+// @Failure 401 {object} responses.ErrorResponse "Unauthorized"
+// @Router /v1/organization/projects/{project_public_id}/webhooks [post]
+func (api *ProjectWebhookRoute) CreateWebhook(reqCtx *gin.Context) {
+	ctx := reqCtx.Request.Context()
+	project, ok := auth.GetProjectFromContext(reqCtx)
+	if !ok {
+		reqCtx.AbortWithStatusJSON(http.StatusBadRequest, responses.ErrorResponse{
+			Code: "4a9d6f2c-8b3e-4c1a-9f7d-2e6b4a8c1d53",
+		})
+		return
+	}
+	var request CreateWebhookRequest
+	if err := reqCtx.ShouldBindJSON(&request); err != nil {
+		reqCtx.AbortWithStatusJSON(http.StatusBadRequest, responses.ErrorResponse{
+			Code:          "4a9d6f2c-8b3e-4c1a-9f7d-2e6b4a8c1d54",
+			ErrorInstance: err,
+		})
+		return
+	}
+	endpoint, err := api.webhookService.CreateEndpoint(ctx, project.ID, webhook.CreateEndpointInput{
+		URL:         request.URL,
+		Description: request.Description,
+		EventTypes:  request.EventTypes,
+	})
+	if err != nil {
+		responses.AbortWithError(reqCtx, http.StatusBadRequest, err)
+		return
+	}
+	reqCtx.JSON(http.StatusOK, toWebhookResponse(endpoint, true))
+}
+
+// @Summary Get a project webhook
+// @Tags Administration API
+// @Security BearerAuth
+// @Produce json
+// @Param project_public_id path string true "Project Public ID"
+// @Param webhook_id path string true "Webhook ID"
+// @Success 200 {object} WebhookResponse "Webhook endpoint"
+// @Failure 401 {object} responses.ErrorResponse "Unauthorized"
+// @Failure 404 {object} responses.ErrorResponse "Webhook not found"
+// @Router /v1/organization/projects/{project_public_id}/webhooks/{webhook_id} [get]
+func (api *ProjectWebhookRoute) GetWebhook(reqCtx *gin.Context) {
+	reqCtx.JSON(http.StatusOK, toWebhookResponse(getWebhookFromContext(reqCtx), false))
+}
+
+// @Summary Update a project webhook
+// @Description Updates the URL, description, subscribed events or enabled state of a webhook endpoint. With rotate_secret the signing secret is replaced and the new one is returned.
+// @Tags Administration API
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param project_public_id path string true "Project Public ID"
+// @Param webhook_id path string true "Webhook ID"
+// @Param request body UpdateWebhookRequest true "Fields to update"
+// @Success 200 {object} WebhookResponse "Updated webhook endpoint"
+// @Failure 400 {object} responses.ErrorResponse "Invalid request"
+// @Failure 401 {object} responses.ErrorResponse "Unauthorized"
+// @Failure 404 {object} responses.ErrorResponse "Webhook not found"
+// @Router /v1/organization/projects/{project_public_id}/webhooks/{webhook_id} [post]
+func (api *ProjectWebhookRoute) UpdateWebhook(reqCtx *gin.Context) {
+	ctx := reqCtx.Request.Context()
+	var request UpdateWebhookRequest
+	if err := reqCtx.ShouldBindJSON(&request); err != nil {
+		reqCtx.AbortWithStatusJSON(http.StatusBadRequest, responses.ErrorResponse{
+			Code:          "7b2e9d4a-1c6f-4b8e-a5d3-9f1c7b4e2a86",
+			ErrorInstance: err,
+		})
+		return
+	}
+	endpoint, err := api.webhookService.UpdateEndpoint(ctx, getWebhookFromContext(reqCtx), webhook.UpdateEndpointInput{
+		URL:          request.URL,
+		Description:  request.Description,
+		EventTypes:   request.EventTypes,
+		Enabled:      request.Enabled,
+		RotateSecret: request.RotateSecret,
+	})
+	if err != nil {
+		responses.AbortWithError(reqCtx, http.StatusBadRequest, err)
+		return
+	}
+	reqCtx.JSON(http.StatusOK, toWebhookResponse(endpoint, request.RotateSecret))
+}
+
+// @Summary Delete a project webhook
+// @Description Deletes a webhook endpoint together with its deliveries and delivery logs
+// @Tags Administration API
+// @Security BearerAuth
+// @Produce json
+// @Param project_public_id path string true "Project Public ID"
+// @Param webhook_id path string true "Webhook ID"
+// @Success 200 {object} openai.DeleteResponse "Deleted webhook endpoint"
+// @Failure 401 {object} responses.ErrorResponse "Unauthorized"
+// @Failure 404 {object} responses.ErrorResponse "Webhook not found"
+// @Router /v1/organization/projects/{project_public_id}/webhooks/{webhook_id} [delete]
+func (api *ProjectWebhookRoute) DeleteWebhook(reqCtx *gin.Context) {
+	endpoint := getWebhookFromContext(reqCtx)
+	if err := api.webhookService.DeleteEndpoint(reqCtx.Request.Context(), endpoint); err != nil {
+		responses.AbortWithError(reqCtx, http.StatusInternalServerError, err)
+		return
+	}
+	reqCtx.JSON(http.StatusOK, openai.DeleteResponse{
+		Object:  "webhook.deleted",
+		ID:      endpoint.PublicID,
+		Deleted: true,
+	})
+}
+
+// @Summary Send a test event
+// @Description Sends a signed webhook.test event to the endpoint right away and returns the logged delivery. Test deliveries are not retried.
+// @Tags Administration API
+// @Security BearerAuth
+// @Produce json
+// @Param project_public_id path string true "Project Public ID"
+// @Param webhook_id path string true "Webhook ID"
+// @Success 200 {object} WebhookDeliveryResponse "Test delivery with its attempt log"
+// @Failure 401 {object} responses.ErrorResponse "Unauthorized"
+// @Failure 404 {object} responses.ErrorResponse "Webhook not found"
+// @Router /v1/organization/projects/{project_public_id}/webhooks/{webhook_id}/test [post]
+func (api *ProjectWebhookRoute) TestWebhook(reqCtx *gin.Context) {
+	endpoint := getWebhookFromContext(reqCtx)
+	delivery, err := api.webhookService.SendTest(reqCtx.Request.Context(), endpoint)
+	if err != nil {
+		responses.AbortWithError(reqCtx, http.StatusInternalServerError, err)
+		return
+	}
+	api.respondDelivery(reqCtx, endpoint, delivery)
+}
+
+// @Summary List webhook deliveries
+// @Description Lists the deliveries of a webhook endpoint, optionally filtered by status
+// @Tags Administration API
+// @Security BearerAuth
+// @Produce json
+// @Param project_public_id path string true "Project Public ID"
+// @Param webhook_id path string true "Webhook ID"
+// @Param status query string false "pending, succeeded or failed"
+// @Param limit query int false "The maximum number of items to return" default(20)
+// @Param offset query int false "The number of items to skip"
+// @Param order query string false "Order by creation: asc or desc" default(asc)
+// @Success 200 {object} openai.ListResponse[WebhookDeliveryResponse] "List of deliveries"
+// @Failure 400 {object} responses.ErrorResponse "Invalid parameters"
+// @Failure 401 {object} responses.ErrorResponse "Unauthorized"
+// @Failure 404 {object} responses.ErrorResponse "Webhook not found"
+// @Router /v1/organization/projects/{project_public_id}/webhooks/{webhook_id}/deliveries [get]
+func (api *ProjectWebhookRoute) ListDeliveries(reqCtx *gin.Context) {
+	endpoint := getWebhookFromContext(reqCtx)
+	filter := webhook.DeliveryFilter{EndpointID: &endpoint.ID}
+	if status := reqCtx.Query("status"); status != "" {
+		deliveryStatus := webhook.DeliveryStatus(status)
+		switch deliveryStatus {
+		case webhook.DeliveryStatusPending, webhook.DeliveryStatusSucceeded, webhook.DeliveryStatusFailed:
+			filter.Status = &deliveryStatus
+		default:
+			reqCtx.AbortWithStatusJSON(http.StatusBadRequest, responses.ErrorResponse{
+				Code:  "3f8a5c1e-9d2b-4e7f-a4c6-5b1e9d3f8a27",
+				Error: "status must be pending, succeeded or failed",
+			})
+			return
+		}
+	}
+	api.listDeliveries(reqCtx, filter, map[uint]string{endpoint.ID: endpoint.PublicID})
+}
+
+// @Summary List dead-lettered webhook deliveries
+// @Description Lists the deliveries of all webhook endpoints of the project that ran out of attempts. They can be sent again with the redeliver endpoint.
+// @Tags Administration API
+// @Security BearerAuth
+// @Produce json
+// @Param project_public_id path string true "Project Public ID"
+// @Param limit query int false "The maximum number of items to return" default(20)
+// @Param offset query int false "The number of items to skip"
+// @Param order query string false "Order by creation: asc or desc" default(asc)
+// @Success 200 {object} openai.ListResponse[WebhookDeliveryResponse] "List of failed deliveries"
+// @Failure 400 {object} responses.ErrorResponse "Invalid parameters"
+// @Failure 401 {object} responses.ErrorResponse "Unauthorized"
+// @Router /v1/organization/projects/{project_public_id}/webhooks/dead_letters [get]
+func (api *ProjectWebhookRoute) ListDeadLetters(reqCtx *gin.Context) {
+	project, ok := auth.GetProjectFromContext(reqCtx)
+	if !ok {
+		reqCtx.AbortWithStatusJSON(http.StatusBadRequest, responses.ErrorResponse{
+			Code: "6c1f8b4d-2a7e-4d9c-b3f5-8e2a6c1d4f93",
+		})
+		return
+	}
+	endpoints, _, err := api.webhookService.ListEndpoints(reqCtx.Request.Context(), project.ID, nil)
+	if err != nil {
+		responses.AbortWithError(reqCtx, http.StatusInternalServerError, err)
+		return
+	}
+	webhookIDs := make(map[uint]string, len(endpoints))
+	for _, endpoint := range endpoints {
+		webhookIDs[endpoint.ID] = endpoint.PublicID
+	}
+	failed := webhook.DeliveryStatusFailed
+	api.listDeliveries(reqCtx, webhook.DeliveryFilter{ProjectID: &project.ID, Status: &failed}, webhookIDs)
+}
+
+func (api *ProjectWebhookRoute) listDeliveries(reqCtx *gin.Context, filter webhook.DeliveryFilter, webhookIDs map[uint]string) {
+	pagination, err := query.GetPaginationFromQuery(reqCtx)
+	if err != nil {
+		reqCtx.AbortWithStatusJSON(http.StatusBadRequest, responses.ErrorResponse{
+			Code:  "5d9b2e7f-3a1c-4f8d-9e6b-2c7f5a9d3e18",
+			Error: "invalid or missing query parameter",
+		})
+		return
+	}
+	deliveries, total, listErr := api.webhookService.ListDeliveries(reqCtx.Request.Context(), filter, pagination)
+	if listErr != nil {
+		responses.AbortWithError(reqCtx, http.StatusInternalServerError, listErr)
+		return
+	}
+	reqCtx.JSON(http.StatusOK, openai.ListResponse[WebhookDeliveryResponse]{
+		Object: openai.ObjectTypeListList,
+		Data: functional.Map(deliveries, func(delivery *webhook.Delivery) WebhookDeliveryResponse {
+			return toDeliveryResponse(webhookIDs[delivery.EndpointID], delivery)
+		}),
+		Total: total,
+	})
+}
+
+// @Summary Get a webhook delivery
+// @Description Returns a delivery with its payload and the log of every attempt
+// @Tags Administration API
+// @Security BearerAuth
+// @Produce json
+// @Param project_public_id path string true "Project Public ID"
+// @Param webhook_id path string true "Webhook ID"
+// @Param delivery_id path string true "Delivery ID"
+// @Success 200 {object} WebhookDeliveryResponse "Delivery with its attempt log"
+// @Failure 401 {object} responses.ErrorResponse "Unauthorized"
+// @Failure 404 {object} responses.ErrorResponse "Delivery not found"
+// @Router /v1/organization/projects/{project_public_id}/webhooks/{webhook_id}/deliveries/{delivery_id} [get]
+func (api *ProjectWebhookRoute) GetDelivery(reqCtx *gin.Context) {
+	endpoint := getWebhookFromContext(reqCtx)
+	delivery, err := api.webhookService.GetDelivery(reqCtx.Request.Context(), endpoint, reqCtx.Param("delivery_id"))
+	if err != nil {
+		responses.AbortWithError(reqCtx, http.StatusNotFound, err)
+		return
+	}
+	api.respondDelivery(reqCtx, endpoint, delivery)
+}
+
+// @Summary Redeliver a webhook delivery
+// @Description Queues a succeeded or dead-lettered delivery again with a fresh attempt budget
+// @Tags Administration API
+// @Security BearerAuth
+// @Produce json
+// @Param project_public_id path string true "Project Public ID"
+// @Param webhook_id path string true "Webhook ID"
+// @Param delivery_id path string true "Delivery ID"
+// @Success 200 {object} WebhookDeliveryResponse "Queued delivery"
+// @Failure 400 {object} responses.ErrorResponse "Delivery is already queued"
+// @Failure 401 {object} responses.ErrorResponse "Unauthorized"
+// @Failure 404 {object} responses.ErrorResponse "Delivery not found"
+// @Router /v1/organization/projects/{project_public_id}/webhooks/{webhook_id}/deliveries/{delivery_id}/redeliver [post]
+func (api *ProjectWebhookRoute) Redeliver(reqCtx *gin.Context) {
+	ctx := reqCtx.Request.Context()
+	endpoint := getWebhookFromContext(reqCtx)
+	delivery, err := api.webhookService.GetDelivery(ctx, endpoint, reqCtx.Param("delivery_id"))
+	if err != nil {
+		responses.AbortWithError(reqCtx, http.StatusNotFound, err)
+		return
+	}
+	if err := api.webhookService.Redeliver(ctx, delivery); err != nil {
+		responses.AbortWithError(reqCtx, http.StatusBadRequest, err)
+		return
+	}
+	reqCtx.JSON(http.StatusOK, toDeliveryResponse(endpoint.PublicID, delivery))
+}
+
+func (api *ProjectWebhookRoute) respondDelivery(reqCtx *gin.Context, endpoint *webhook.Endpoint, delivery *webhook.Delivery) {
+	attempts, err := api.webhookService.ListAttempts(reqCtx.Request.Context(), delivery)
+	if err != nil {
+		responses.AbortWithError(reqCtx, http.StatusInternalServerError, err)
+		return
+	}
+	response := toDeliveryResponse(endpoint.PublicID, delivery)
+	response.Payload = &delivery.Payload
+	response.AttemptLogs = functional.Map(attempts, func(attempt *webhook.DeliveryAttempt) WebhookDeliveryAttemptResponse {
+		return WebhookDeliveryAttemptResponse{
+			Attempt:      attempt.Attempt,
+			StatusCode:   attempt.StatusCode,
+			Error:        attempt.Error,
+			ResponseBody: attempt.ResponseBody,
+			DurationMs:   attempt.DurationMs,
+			CreatedAt:    attempt.CreatedAt.Unix(),
+		}
+	})
+	reqCtx.JSON(http.StatusOK, response)
+}
@@ -72,6 +72,11 @@ func (responseRoute *ResponseRoute) registerRoutes(router *gin.RouterGroup) {
 // @Description - `function_calls`: Function calls input
 // @Description - `reasoning`: Reasoning input
 // @Description
+// @Description **Structured Outputs:**
+// @Description - `response_format` of type `json_object` or `json_schema` validates the final answer against the schema
+// @Description - Non-streaming answers are retried with the validation errors up to STRUCTURED_OUTPUT_REPAIR_ATTEMPTS times
+// @Description - An answer that still does not match ends with status `incomplete` and `incomplete_details.reason` set to `json_schema_validation`
+// @Description
 // @Description **Example Request:**
 // @Description ```json
 // @Description {
@@ -120,9 +125,15 @@ func (responseRoute *ResponseRoute) registerRoutes(router *gin.RouterGroup) {
 // @Description }
 // @Description ```
 // @Description
+// @Description **Background Responses:**
+// @Description With `background: true` the response is queued and returned right away with status `queued`.
+// @Description Workers process it and update its status, poll `GET /v1/responses/{response_id}` for the result.
+// @Description Background responses cannot be streamed.
+// @Description
 // @Description **Response Status:**
+// @Description - `queued`: Response is waiting for a background worker
+// @Description - `running`: Response is being generated
 // @Description - `completed`: Response generation finished successfully
-// @Description - `processing`: Response is being generated
 // @Description - `failed`: Response generation failed
 // @Description - `cancelled`: Response was cancelled
 // @Tags Responses API
@@ -195,7 +206,7 @@ func (responseRoute *ResponseRoute) CreateResponse(reqCtx *gin.Context) {
 	}
 
 	// Call domain service (pure business logic)
-	result, err := responseRoute.responseModelService.CreateResponse(ctx, userID, domainRequest)
+	result, err := responseRoute.responseModelService.CreateResponse(ctx, userID, auth.GetRequestProjectID(reqCtx), domainRequest)
 	if err != nil {
 		reqCtx.AbortWithStatusJSON(http.StatusBadRequest, responses.ErrorResponse{
 			Code:  err.GetCode(),
@@ -210,6 +221,12 @@ func (responseRoute *ResponseRoute) CreateResponse(reqCtx *gin.Context) {
 
 // handleResponseCreation handles both streaming and non-streaming response creation
 func (responseRoute *ResponseRoute) handleResponseCreation(reqCtx *gin.Context, result *response.ResponseCreationResult, request *requesttypes.CreateResponseRequest) {
+	// Background responses are processed by the workers, poll GET /v1/responses/{id} for the result
+	if result.IsBackground {
+		reqCtx.JSON(http.StatusAccepted, responseRoute.responseService.ConvertDomainResponseToAPIResponse(result.Response))
+		return
+	}
+
 	// Set up streaming headers if needed
 	if result.IsStreaming {
 		reqCtx.Header("Content-Type", "text/event-stream")
@@ -241,11 +258,20 @@ func (responseRoute *ResponseRoute) handleResponseCreation(reqCtx *gin.Context,
 // @Description - `status`: Response status
 // @Description - `input`: Input data
 // @Description - `output`: Generated output
+// @Description
+// @Description **Resuming a Stream:**
+// @Description With `stream=true` the events of a response created with `stream: true` are sent again as server-sent events.
+// @Description Every event carries its sequence number as SSE `id`; events up to `starting_after` (or the `Last-Event-ID` header) are skipped
+// @Description and the stream continues live until the response ends. Events can be replayed for 15 minutes after the last one.
 // @Tags Responses API
 // @Security BearerAuth
 // @Accept json
 // @Produce json
+// @Produce text/event-stream
 // @Param response_id path string true "Unique identifier of the response"
+// @Param stream query bool false "Stream the events of a streamed response"
+// @Param starting_after query int false "Sequence number of the last event received, replay starts after it"
+// @Param Last-Event-ID header int false "Same as starting_after, used by EventSource reconnections"
 // @Success 200 {object} responses.Response "Response details"
 // @Failure 400 {object} responses.ErrorResponse "Invalid request"
 // @Failure 401 {object} responses.ErrorResponse "Unauthorized"
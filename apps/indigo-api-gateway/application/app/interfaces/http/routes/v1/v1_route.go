@@ -8,11 +8,14 @@ import (
 	"menlo.ai/indigo-api-gateway/app/interfaces/http/routes/v1/chat"
 	"menlo.ai/indigo-api-gateway/app/interfaces/http/routes/v1/conv"
 	"menlo.ai/indigo-api-gateway/app/interfaces/http/routes/v1/conversations"
+	"menlo.ai/indigo-api-gateway/app/interfaces/http/routes/v1/files"
 	"menlo.ai/indigo-api-gateway/app/interfaces/http/routes/v1/mcp"
 	modelroute "menlo.ai/indigo-api-gateway/app/interfaces/http/routes/v1/model"
 	"menlo.ai/indigo-api-gateway/app/interfaces/http/routes/v1/organization"
 	"menlo.ai/indigo-api-gateway/app/interfaces/http/routes/v1/responses"
+	"menlo.ai/indigo-api-gateway/app/interfaces/http/routes/v1/vectorstores"
 	"menlo.ai/indigo-api-gateway/config"
+	"menlo.ai/indigo-api-gateway/config/environment_variables"
 )
 
 type V1Route struct {
@@ -26,6 +29,8 @@ type V1Route struct {
 	mcpAPI             *mcp.MCPAPI
 	authRoute          *auth.AuthRoute
 	responsesRoute     *responses.ResponseRoute
+	filesRoute         *files.FilesRoute
+	vectorStoresRoute  *vectorstores.VectorStoresRoute
 }
 
 func NewV1Route(
@@ -39,6 +44,8 @@ func NewV1Route(
 	mcpAPI *mcp.MCPAPI,
 	authRoute *auth.AuthRoute,
 	responsesRoute *responses.ResponseRoute,
+	filesRoute *files.FilesRoute,
+	vectorStoresRoute *vectorstores.VectorStoresRoute,
 ) *V1Route {
 	return &V1Route{
 		organizationRoute,
@@ -51,6 +58,8 @@ func NewV1Route(
 		mcpAPI,
 		authRoute,
 		responsesRoute,
+		filesRoute,
+		vectorStoresRoute,
 	}
 }
 
@@ -67,6 +76,8 @@ func (v1Route *V1Route) RegisterRouter(router gin.IRouter) {
 	v1Route.organizationRoute.RegisterRouter(v1Router)
 	v1Route.authRoute.RegisterRouter(v1Router)
 	v1Route.responsesRoute.RegisterRouter(v1Router)
+	v1Route.filesRoute.RegisterRouter(v1Router)
+	v1Route.vectorStoresRoute.RegisterRouter(v1Router)
 }
 
 // GetVersion godoc
@@ -79,6 +90,6 @@ func (v1Route *V1Route) RegisterRouter(router gin.IRouter) {
 func GetVersion(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"version":         config.Version,
-		"env_reloaded_at": config.EnvReloadedAt,
+		"env_reloaded_at": environment_variables.ReloadedAt(),
 	})
 }
@@ -0,0 +1,662 @@
+package vectorstores
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"menlo.ai/indigo-api-gateway/app/domain/auth"
+	"menlo.ai/indigo-api-gateway/app/domain/common"
+	"menlo.ai/indigo-api-gateway/app/domain/file"
+	"menlo.ai/indigo-api-gateway/app/domain/query"
+	"menlo.ai/indigo-api-gateway/app/domain/vectorstore"
+	"menlo.ai/indigo-api-gateway/app/interfaces/http/responses"
+	"menlo.ai/indigo-api-gateway/app/interfaces/http/responses/openai"
+	"menlo.ai/indigo-api-gateway/app/utils/ptr"
+)
+
+// VectorStoreFileContextKeyPublicID is the path parameter holding the file ID of a vector store file
+const VectorStoreFileContextKeyPublicID = "file_id"
+
+type VectorStoresRoute struct {
+	authService        *auth.AuthService
+	vectorStoreService *vectorstore.VectorStoreService
+}
+
+func NewVectorStoresRoute(authService *auth.AuthService, vectorStoreService *vectorstore.VectorStoreService) *VectorStoresRoute {
+	return &VectorStoresRoute{
+		authService:        authService,
+		vectorStoreService: vectorStoreService,
+	}
+}
+
+func (route *VectorStoresRoute) RegisterRouter(router gin.IRouter) {
+	storesRouter := router.Group("/vector_stores",
+		route.authService.AppUserAuthMiddleware(),
+		route.authService.RegisteredUserMiddleware(),
+	)
+	storesRouter.POST("", route.CreateVectorStore)
+	storesRouter.GET("", route.ListVectorStores)
+
+	storeRouter := storesRouter.Group(fmt.Sprintf("/:%s", vectorstore.VectorStoreContextKeyPublicID),
+		route.vectorStoreService.GetVectorStoreMiddleware(),
+	)
+	storeRouter.GET("", route.GetVectorStore)
+	storeRouter.POST("", route.UpdateVectorStore)
+	storeRouter.DELETE("", route.DeleteVectorStore)
+	storeRouter.POST("/search", route.SearchVectorStore)
+	storeRouter.POST("/files", route.CreateVectorStoreFile)
+	storeRouter.GET("/files", route.ListVectorStoreFiles)
+	fileIDParam := fmt.Sprintf("/files/:%s", VectorStoreFileContextKeyPublicID)
+	storeRouter.GET(fileIDParam, route.GetVectorStoreFile)
+	storeRouter.DELETE(fileIDParam, route.DeleteVectorStoreFile)
+}
+
+type ChunkingStrategyRequest struct {
+	// auto or static
+	Type   string                 `json:"type"`
+	Static *StaticChunkingRequest `json:"static,omitempty"`
+}
+
+type StaticChunkingRequest struct {
+	MaxChunkSizeTokens int `json:"max_chunk_size_tokens"`
+	ChunkOverlapTokens int `json:"chunk_overlap_tokens"`
+}
+
+type CreateVectorStoreRequest struct {
+	Name             string                   `json:"name"`
+	FileIDs          []string                 `json:"file_ids,omitempty"`
+	Metadata         map[string]string        `json:"metadata,omitempty"`
+	ChunkingStrategy *ChunkingStrategyRequest `json:"chunking_strategy,omitempty"`
+}
+
+type UpdateVectorStoreRequest struct {
+	Name     *string           `json:"name,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+type CreateVectorStoreFileRequest struct {
+	FileID           string                   `json:"file_id" binding:"required"`
+	ChunkingStrategy *ChunkingStrategyRequest `json:"chunking_strategy,omitempty"`
+}
+
+type SearchVectorStoreRequest struct {
+	Query         string `json:"query" binding:"required"`
+	MaxNumResults *int   `json:"max_num_results,omitempty"`
+}
+
+type FileCountsResponse struct {
+	InProgress int64 `json:"in_progress"`
+	Completed  int64 `json:"completed"`
+	Failed     int64 `json:"failed"`
+	Cancelled  int64 `json:"cancelled"`
+	Total      int64 `json:"total"`
+}
+
+type VectorStoreResponse struct {
+	ID           string             `json:"id"`
+	Object       string             `json:"object"`
+	CreatedAt    int64              `json:"created_at"`
+	Name         string             `json:"name"`
+	UsageBytes   int64              `json:"usage_bytes"`
+	FileCounts   FileCountsResponse `json:"file_counts"`
+	Status       string             `json:"status"`
+	LastActiveAt *int64             `json:"last_active_at"`
+	Metadata     map[string]string  `json:"metadata"`
+}
+
+type LastErrorResponse struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+type ChunkingStrategyResponse struct {
+	Type   string                `json:"type"`
+	Static StaticChunkingRequest `json:"static"`
+}
+
+type VectorStoreFileResponse struct {
+	ID               string                   `json:"id"`
+	Object           string                   `json:"object"`
+	UsageBytes       int64                    `json:"usage_bytes"`
+	CreatedAt        int64                    `json:"created_at"`
+	VectorStoreID    string                   `json:"vector_store_id"`
+	Status           string                   `json:"status"`
+	LastError        *LastErrorResponse       `json:"last_error"`
+	ChunkingStrategy ChunkingStrategyResponse `json:"chunking_strategy"`
+}
+
+type SearchResultContent struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type SearchResultResponse struct {
+	FileID   string                `json:"file_id"`
+	Filename string                `json:"filename"`
+	Score    float64               `json:"score"`
+	Content  []SearchResultContent `json:"content"`
+}
+
+type SearchResponse struct {
+	Object      string                 `json:"object"`
+	SearchQuery string                 `json:"search_query"`
+	Data        []SearchResultResponse `json:"data"`
+	HasMore     bool                   `json:"has_more"`
+	NextPage    *string                `json:"next_page"`
+}
+
+// toChunkingStrategy converts the request strategy, auto and missing strategies use the defaults
+func toChunkingStrategy(request *ChunkingStrategyRequest) (vectorstore.ChunkingStrategy, *common.Error) {
+	if request == nil || request.Type == "" || request.Type == "auto" {
+		return vectorstore.DefaultChunkingStrategy(), nil
+	}
+	if request.Type != "static" || request.Static == nil {
+		return vectorstore.ChunkingStrategy{}, common.NewErrorWithMessage("chunking_strategy must be auto or static with a static configuration", "2c8e5a1f-7d3b-4e9a-b6c4-1f5d8a2e7c93")
+	}
+	return vectorstore.ChunkingStrategy{
+		MaxChunkSizeTokens: request.Static.MaxChunkSizeTokens,
+		ChunkOverlapTokens: request.Static.ChunkOverlapTokens,
+	}, nil
+}
+
+func (route *VectorStoresRoute) toVectorStoreResponse(reqCtx *gin.Context, store *vectorstore.VectorStore) (*VectorStoreResponse, *common.Error) {
+	counts, err := route.vectorStoreService.GetFileCounts(reqCtx.Request.Context(), store)
+	if err != nil {
+		return nil, err
+	}
+	status := string(vectorstore.VectorStoreStatusCompleted)
+	if counts.InProgress > 0 {
+		status = string(vectorstore.VectorStoreStatusInProgress)
+	}
+	var lastActiveAt *int64
+	if store.LastActiveAt != nil {
+		lastActiveAt = ptr.ToInt64(store.LastActiveAt.Unix())
+	}
+	metadata := store.Metadata
+	if metadata == nil {
+		metadata = map[string]string{}
+	}
+	return &VectorStoreResponse{
+		ID:         store.PublicID,
+		Object:     "vector_store",
+		CreatedAt:  store.CreatedAt.Unix(),
+		Name:       store.Name,
+		UsageBytes: store.UsageBytes,
+		FileCounts: FileCountsResponse{
+			InProgress: counts.InProgress,
+			Completed:  counts.Completed,
+			Failed:     counts.Failed,
+			Total:      counts.Total,
+		},
+		Status:       status,
+		LastActiveAt: lastActiveAt,
+		Metadata:     metadata,
+	}, nil
+}
+
+func toVectorStoreFileResponse(store *vectorstore.VectorStore, storeFile *vectorstore.VectorStoreFile) *VectorStoreFileResponse {
+	var lastError *LastErrorResponse
+	if storeFile.LastError != nil {
+		lastError = &LastErrorResponse{
+			Code:    "server_error",
+			Message: *storeFile.LastError,
+		}
+	}
+	return &VectorStoreFileResponse{
+		ID:            storeFile.FilePublicID,
+		Object:        "vector_store.file",
+		UsageBytes:    storeFile.UsageBytes,
+		CreatedAt:     storeFile.CreatedAt.Unix(),
+		VectorStoreID: store.PublicID,
+		Status:        string(storeFile.Status),
+		LastError:     lastError,
+		ChunkingStrategy: ChunkingStrategyResponse{
+			Type: "static",
+			Static: StaticChunkingRequest{
+				MaxChunkSizeTokens: storeFile.Chunking.MaxChunkSizeTokens,
+				ChunkOverlapTokens: storeFile.Chunking.ChunkOverlapTokens,
+			},
+		},
+	}
+}
+
+// @Summary Create a vector store
+// @Description Creates a vector store and queues the given files for indexing. Files are extracted, chunked and embedded with the configured embedding model in the background; poll the files for their status, files that cannot be indexed are reported as failed.
+// @Tags Vector Stores API
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body CreateVectorStoreRequest true "Vector store"
+// @Success 200 {object} VectorStoreResponse "Created vector store"
+// @Failure 400 {object} responses.ErrorResponse "Invalid request"
+// @Failure 401 {object} responses.ErrorResponse "Unauthorized"
+// @Router /v1/vector_stores [post]
+func (route *VectorStoresRoute) CreateVectorStore(reqCtx *gin.Context) {
+	ctx := reqCtx.Request.Context()
+	owner, ok := file.GetRequestOwner(reqCtx)
+	if !ok {
+		reqCtx.AbortWithStatusJSON(http.StatusUnauthorized, responses.ErrorResponse{
+			Code: "5a1e7c3f-9d2b-4f6a-8e4c-3b7d1f9a5e62",
+		})
+		return
+	}
+	var request CreateVectorStoreRequest
+	if err := reqCtx.ShouldBindJSON(&request); err != nil {
+		reqCtx.AbortWithStatusJSON(http.StatusBadRequest, responses.ErrorResponse{
+			Code:          "8c4f2a6e-1b9d-4e3a-a7f5-6d2c8e4a1b97",
+			ErrorInstance: err,
+		})
+		return
+	}
+	chunking, err := toChunkingStrategy(request.ChunkingStrategy)
+	if err != nil {
+		responses.AbortWithError(reqCtx, http.StatusBadRequest, err)
+		return
+	}
+
+	store, err := route.vectorStoreService.CreateVectorStore(ctx, owner, vectorstore.CreateVectorStoreInput{
+		Name:     request.Name,
+		Metadata: request.Metadata,
+		FileIDs:  request.FileIDs,
+		Chunking: chunking,
+	})
+	if err != nil {
+		responses.AbortWithError(reqCtx, http.StatusBadRequest, err)
+		return
+	}
+	response, err := route.toVectorStoreResponse(reqCtx, store)
+	if err != nil {
+		responses.AbortWithError(reqCtx, http.StatusInternalServerError, err)
+		return
+	}
+	reqCtx.JSON(http.StatusOK, response)
+}
+
+// @Summary List vector stores
+// @Description Lists the vector stores of the authenticated user, scoped to the project when called with a project API key
+// @Tags Vector Stores API
+// @Security BearerAuth
+// @Produce json
+// @Param limit query int false "The maximum number of vector stores to return" default(20)
+// @Param last query string false "The ID of the last vector store of the previous page"
+// @Param order query string false "Order by creation: asc or desc" default(asc)
+// @Success 200 {object} openai.ListResponse[VectorStoreResponse] "List of vector stores"
+// @Failure 400 {object} responses.ErrorResponse "Invalid parameters"
+// @Failure 401 {object} responses.ErrorResponse "Unauthorized"
+// @Router /v1/vector_stores [get]
+func (route *VectorStoresRoute) ListVectorStores(reqCtx *gin.Context) {
+	ctx := reqCtx.Request.Context()
+	owner, ok := file.GetRequestOwner(reqCtx)
+	if !ok {
+		reqCtx.AbortWithStatusJSON(http.StatusUnauthorized, responses.ErrorResponse{
+			Code: "3f9b6d2a-7e1c-4a5f-b8d3-9c2e6a4f1b58",
+		})
+		return
+	}
+	pagination, err := query.GetCursorPaginationFromQuery(reqCtx, func(lastID string) (*uint, error) {
+		store, getErr := route.vectorStoreService.GetVectorStore(ctx, owner, lastID)
+		if getErr != nil {
+			return nil, getErr
+		}
+		return &store.ID, nil
+	})
+	if err != nil {
+		reqCtx.AbortWithStatusJSON(http.StatusBadRequest, responses.ErrorResponse{
+			Code:  "6e2a9c5f-3d8b-4e1a-9f7c-2b5e8d1a4c36",
+			Error: "Invalid pagination parameters",
+		})
+		return
+	}
+
+	stores, total, listErr := route.vectorStoreService.ListVectorStores(ctx, owner, pagination)
+	if listErr != nil {
+		responses.AbortWithError(reqCtx, http.StatusInternalServerError, listErr)
+		return
+	}
+	data := make([]*VectorStoreResponse, 0, len(stores))
+	for _, store := range stores {
+		response, responseErr := route.toVectorStoreResponse(reqCtx, store)
+		if responseErr != nil {
+			responses.AbortWithError(reqCtx, http.StatusInternalServerError, responseErr)
+			return
+		}
+		data = append(data, response)
+	}
+
+	var firstID *string
+	var lastID *string
+	hasMore := false
+	if len(stores) > 0 {
+		firstID = &stores[0].PublicID
+		lastID = &stores[len(stores)-1].PublicID
+		more, _, moreErr := route.vectorStoreService.ListVectorStores(ctx, owner, &query.Pagination{
+			Order: pagination.Order,
+			Limit: ptr.ToInt(1),
+			After: &stores[len(stores)-1].ID,
+		})
+		if moreErr != nil {
+			responses.AbortWithError(reqCtx, http.StatusInternalServerError, moreErr)
+			return
+		}
+		hasMore = len(more) > 0
+	}
+
+	reqCtx.JSON(http.StatusOK, openai.ListResponse[*VectorStoreResponse]{
+		Object:  openai.ObjectTypeListList,
+		Data:    data,
+		FirstID: firstID,
+		LastID:  lastID,
+		HasMore: hasMore,
+		Total:   total,
+	})
+}
+
+// @Summary Get a vector store
+// @Tags Vector Stores API
+// @Security BearerAuth
+// @Produce json
+// @Param vector_store_id path string true "The ID of the vector store"
+// @Success 200 {object} VectorStoreResponse "Vector store"
+// @Failure 401 {object} responses.ErrorResponse "Unauthorized"
+// @Failure 404 {object} responses.ErrorResponse "Vector store not found"
+// @Router /v1/vector_stores/{vector_store_id} [get]
+func (route *VectorStoresRoute) GetVectorStore(reqCtx *gin.Context) {
+	store, _ := vectorstore.GetVectorStoreFromContext(reqCtx)
+	response, err := route.toVectorStoreResponse(reqCtx, store)
+	if err != nil {
+		responses.AbortWithError(reqCtx, http.StatusInternalServerError, err)
+		return
+	}
+	reqCtx.JSON(http.StatusOK, response)
+}
+
+// @Summary Modify a vector store
+// @Tags Vector Stores API
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param vector_store_id path string true "The ID of the vector store"
+// @Param request body UpdateVectorStoreRequest true "Fields to update"
+// @Success 200 {object} VectorStoreResponse "Updated vector store"
+// @Failure 400 {object} responses.ErrorResponse "Invalid request"
+// @Failure 401 {object} responses.ErrorResponse "Unauthorized"
+// @Failure 404 {object} responses.ErrorResponse "Vector store not found"
+// @Router /v1/vector_stores/{vector_store_id} [post]
+func (route *VectorStoresRoute) UpdateVectorStore(reqCtx *gin.Context) {
+	store, _ := vectorstore.GetVectorStoreFromContext(reqCtx)
+	var request UpdateVectorStoreRequest
+	if err := reqCtx.ShouldBindJSON(&request); err != nil {
+		reqCtx.AbortWithStatusJSON(http.StatusBadRequest, responses.ErrorResponse{
+			Code:          "9a5d1f7c-4e2b-4c8a-b3f6-7e1a9d5c2b84",
+			ErrorInstance: err,
+		})
+		return
+	}
+	store, err := route.vectorStoreService.UpdateVectorStore(reqCtx.Request.Context(), store, vectorstore.UpdateVectorStoreInput{
+		Name:     request.Name,
+		Metadata: request.Metadata,
+	})
+	if err != nil {
+		responses.AbortWithError(reqCtx, http.StatusInternalServerError, err)
+		return
+	}
+	response, err := route.toVectorStoreResponse(reqCtx, store)
+	if err != nil {
+		responses.AbortWithError(reqCtx, http.StatusInternalServerError, err)
+		return
+	}
+	reqCtx.JSON(http.StatusOK, response)
+}
+
+// @Summary Delete a vector store
+// @Description Deletes a vector store and its index, the uploaded files are kept
+// @Tags Vector Stores API
+// @Security BearerAuth
+// @Produce json
+// @Param vector_store_id path string true "The ID of the vector store"
+// @Success 200 {object} openai.DeleteResponse "Deleted vector store"
+// @Failure 401 {object} responses.ErrorResponse "Unauthorized"
+// @Failure 404 {object} responses.ErrorResponse "Vector store not found"
+// @Router /v1/vector_stores/{vector_store_id} [delete]
+func (route *VectorStoresRoute) DeleteVectorStore(reqCtx *gin.Context) {
+	store, _ := vectorstore.GetVectorStoreFromContext(reqCtx)
+	if err := route.vectorStoreService.DeleteVectorStore(reqCtx.Request.Context(), store); err != nil {
+		responses.AbortWithError(reqCtx, http.StatusInternalServerError, err)
+		return
+	}
+	reqCtx.JSON(http.StatusOK, openai.DeleteResponse{
+		Object:  "vector_store.deleted",
+		ID:      store.PublicID,
+		Deleted: true,
+	})
+}
+
+// @Summary Search a vector store
+// @Description Returns the chunks most similar to the query
+// @Tags Vector Stores API
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param vector_store_id path string true "The ID of the vector store"
+// @Param request body SearchVectorStoreRequest true "Search query"
+// @Success 200 {object} SearchResponse "Search results"
+// @Failure 400 {object} responses.ErrorResponse "Invalid request"
+// @Failure 401 {object} responses.ErrorResponse "Unauthorized"
+// @Failure 404 {object} responses.ErrorResponse "Vector store not found"
+// @Router /v1/vector_stores/{vector_store_id}/search [post]
+func (route *VectorStoresRoute) SearchVectorStore(reqCtx *gin.Context) {
+	store, _ := vectorstore.GetVectorStoreFromContext(reqCtx)
+	var request SearchVectorStoreRequest
+	if err := reqCtx.ShouldBindJSON(&request); err != nil {
+		reqCtx.AbortWithStatusJSON(http.StatusBadRequest, responses.ErrorResponse{
+			Code:          "4d8b2e6a-1f5c-4a9d-8e3b-5c1f7a4d2e69",
+			ErrorInstance: err,
+		})
+		return
+	}
+	limit := vectorstore.DefaultSearchResults
+	if request.MaxNumResults != nil {
+		limit = *request.MaxNumResults
+	}
+
+	results, err := route.vectorStoreService.Search(reqCtx.Request.Context(), []*vectorstore.VectorStore{store}, request.Query, nil, limit)
+	if err != nil {
+		responses.AbortWithError(reqCtx, http.StatusBadRequest, err)
+		return
+	}
+	data := make([]SearchResultResponse, 0, len(results))
+	for _, result := range results {
+		data = append(data, SearchResultResponse{
+			FileID:   result.FilePublicID,
+			Filename: result.Filename,
+			Score:    result.Score,
+			Content:  []SearchResultContent{{Type: "text", Text: result.Text}},
+		})
+	}
+	reqCtx.JSON(http.StatusOK, SearchResponse{
+		Object:      "vector_store.search_results.page",
+		SearchQuery: request.Query,
+		Data:        data,
+	})
+}
+
+// @Summary Attach a file to a vector store
+// @Description Queues an uploaded file for indexing into the vector store and returns it with the in_progress status. Text documents and PDFs with a text layer can be indexed; other files are reported as failed.
+// @Tags Vector Stores API
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param vector_store_id path string true "The ID of the vector store"
+// @Param request body CreateVectorStoreFileRequest true "File to attach"
+// @Success 200 {object} VectorStoreFileResponse "Vector store file"
+// @Failure 400 {object} responses.ErrorResponse "Invalid request"
+// @Failure 401 {object} responses.ErrorResponse "Unauthorized"
+// @Failure 404 {object} responses.ErrorResponse "Vector store not found"
+// @Router /v1/vector_stores/{vector_store_id}/files [post]
+func (route *VectorStoresRoute) CreateVectorStoreFile(reqCtx *gin.Context) {
+	store, _ := vectorstore.GetVectorStoreFromContext(reqCtx)
+	owner, ok := file.GetRequestOwner(reqCtx)
+	if !ok {
+		reqCtx.AbortWithStatusJSON(http.StatusUnauthorized, responses.ErrorResponse{
+			Code: "1b7e4a9d-6c3f-4e2b-a5d8-9f4c1e7b3a26",
+		})
+		return
+	}
+	var request CreateVectorStoreFileRequest
+	if err := reqCtx.ShouldBindJSON(&request); err != nil {
+		reqCtx.AbortWithStatusJSON(http.StatusBadRequest, responses.ErrorResponse{
+			Code:          "7f3c9e5b-2a8d-4f1c-b6e4-3d9a7f5c1e82",
+			ErrorInstance: err,
+		})
+		return
+	}
+	chunking, err := toChunkingStrategy(request.ChunkingStrategy)
+	if err != nil {
+		responses.AbortWithError(reqCtx, http.StatusBadRequest, err)
+		return
+	}
+
+	storeFile, err := route.vectorStoreService.AttachFile(reqCtx.Request.Context(), owner, store, request.FileID, chunking)
+	if err != nil {
+		responses.AbortWithError(reqCtx, http.StatusBadRequest, err)
+		return
+	}
+	reqCtx.JSON(http.StatusOK, toVectorStoreFileResponse(store, storeFile))
+}
+
+// @Summary List vector store files
+// @Tags Vector Stores API
+// @Security BearerAuth
+// @Produce json
+// @Param vector_store_id path string true "The ID of the vector store"
+// @Param filter query string false "Only return files with this status: in_progress, completed or failed"
+// @Param limit query int false "The maximum number of files to return" default(20)
+// @Param last query string false "The ID of the last file of the previous page"
+// @Param order query string false "Order by creation: asc or desc" default(asc)
+// @Success 200 {object} openai.ListResponse[VectorStoreFileResponse] "List of vector store files"
+// @Failure 400 {object} responses.ErrorResponse "Invalid parameters"
+// @Failure 401 {object} responses.ErrorResponse "Unauthorized"
+// @Failure 404 {object} responses.ErrorResponse "Vector store not found"
+// @Router /v1/vector_stores/{vector_store_id}/files [get]
+func (route *VectorStoresRoute) ListVectorStoreFiles(reqCtx *gin.Context) {
+	ctx := reqCtx.Request.Context()
+	store, _ := vectorstore.GetVectorStoreFromContext(reqCtx)
+
+	var status *vectorstore.VectorStoreFileStatus
+	if filter := reqCtx.Query("filter"); filter != "" {
+		switch vectorstore.VectorStoreFileStatus(filter) {
+		case vectorstore.VectorStoreFileStatusInProgress, vectorstore.VectorStoreFileStatusCompleted, vectorstore.VectorStoreFileStatusFailed:
+			fileStatus := vectorstore.VectorStoreFileStatus(filter)
+			status = &fileStatus
+		default:
+			reqCtx.AbortWithStatusJSON(http.StatusBadRequest, responses.ErrorResponse{
+				Code:  "2e9a6d3f-8c1b-4a7e-9d5f-4b2e8a6c3d17",
+				Error: "Invalid filter, expected in_progress, completed or failed",
+			})
+			return
+		}
+	}
+
+	pagination, err := query.GetCursorPaginationFromQuery(reqCtx, func(lastID string) (*uint, error) {
+		storeFile, getErr := route.vectorStoreService.GetVectorStoreFile(ctx, store, lastID)
+		if getErr != nil {
+			return nil, getErr
+		}
+		return &storeFile.ID, nil
+	})
+	if err != nil {
+		reqCtx.AbortWithStatusJSON(http.StatusBadRequest, responses.ErrorResponse{
+			Code:  "5c1f8b4e-3a7d-4e2c-b9f6-8d3a5e1c7b42",
+			Error: "Invalid pagination parameters",
+		})
+		return
+	}
+
+	storeFiles, total, listErr := route.vectorStoreService.ListVectorStoreFiles(ctx, store, status, pagination)
+	if listErr != nil {
+		responses.AbortWithError(reqCtx, http.StatusInternalServerError, listErr)
+		return
+	}
+
+	var firstID *string
+	var lastID *string
+	hasMore := false
+	if len(storeFiles) > 0 {
+		firstID = &storeFiles[0].FilePublicID
+		lastID = &storeFiles[len(storeFiles)-1].FilePublicID
+		more, _, moreErr := route.vectorStoreService.ListVectorStoreFiles(ctx, store, status, &query.Pagination{
+			Order: pagination.Order,
+			Limit: ptr.ToInt(1),
+			After: &storeFiles[len(storeFiles)-1].ID,
+		})
+		if moreErr != nil {
+			responses.AbortWithError(reqCtx, http.StatusInternalServerError, moreErr)
+			return
+		}
+		hasMore = len(more) > 0
+	}
+
+	data := make([]*VectorStoreFileResponse, 0, len(storeFiles))
+	for _, storeFile := range storeFiles {
+		data = append(data, toVectorStoreFileResponse(store, storeFile))
+	}
+	reqCtx.JSON(http.StatusOK, openai.ListResponse[*VectorStoreFileResponse]{
+		Object:  openai.ObjectTypeListList,
+		Data:    data,
+		FirstID: firstID,
+		LastID:  lastID,
+		HasMore: hasMore,
+		Total:   total,
+	})
+}
+
+// @Summary Get a vector store file
+// @Tags Vector Stores API
+// @Security BearerAuth
+// @Produce json
+// @Param vector_store_id path string true "The ID of the vector store"
+// @Param file_id path string true "The ID of the file"
+// @Success 200 {object} VectorStoreFileResponse "Vector store file"
+// @Failure 401 {object} responses.ErrorResponse "Unauthorized"
+// @Failure 404 {object} responses.ErrorResponse "Vector store file not found"
+// @Router /v1/vector_stores/{vector_store_id}/files/{file_id} [get]
+func (route *VectorStoresRoute) GetVectorStoreFile(reqCtx *gin.Context) {
+	store, _ := vectorstore.GetVectorStoreFromContext(reqCtx)
+	storeFile, err := route.vectorStoreService.GetVectorStoreFile(reqCtx.Request.Context(), store, reqCtx.Param(VectorStoreFileContextKeyPublicID))
+	if err != nil {
+		responses.AbortWithError(reqCtx, http.StatusNotFound, err)
+		return
+	}
+	reqCtx.JSON(http.StatusOK, toVectorStoreFileResponse(store, storeFile))
+}
+
+// @Summary Remove a file from a vector store
+// @Description Removes the file and its chunks from the vector store, the uploaded file is kept
+// @Tags Vector Stores API
+// @Security BearerAuth
+// @Produce json
+// @Param vector_store_id path string true "The ID of the vector store"
+// @Param file_id path string true "The ID of the file"
+// @Success 200 {object} openai.DeleteResponse "Removed vector store file"
+// @Failure 401 {object} responses.ErrorResponse "Unauthorized"
+// @Failure 404 {object} responses.ErrorResponse "Vector store file not found"
+// @Router /v1/vector_stores/{vector_store_id}/files/{file_id} [delete]
+func (route *VectorStoresRoute) DeleteVectorStoreFile(reqCtx *gin.Context) {
+	ctx := reqCtx.Request.Context()
+	store, _ := vectorstore.GetVectorStoreFromContext(reqCtx)
+	storeFile, err := route.vectorStoreService.GetVectorStoreFile(ctx, store, reqCtx.Param(VectorStoreFileContextKeyPublicID))
+	if err != nil {
+		responses.AbortWithError(reqCtx, http.StatusNotFound, err)
+		return
+	}
+	if err := route.vectorStoreService.DetachFile(ctx, store, storeFile); err != nil {
+		responses.AbortWithError(reqCtx, http.StatusInternalServerError, err)
+		return
+	}
+	reqCtx.JSON(http.StatusOK, openai.DeleteResponse{
+		Object:  "vector_store.file.deleted",
+		ID:      storeFile.FilePublicID,
+		Deleted: true,
+	})
+}
@@ -4,5 +4,7 @@ type RequestId struct{}
 type HttpClientStartsAt struct{}
 type HttpClientRequestBody struct{}
 type TransactionContextKey struct{}
+type OmitLogBodies struct{}
+type OrganizationID struct{}
 
 const SkipMiddleware = "SkipMiddleware"
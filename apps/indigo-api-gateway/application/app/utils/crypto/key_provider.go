@@ -0,0 +1,142 @@
+package crypto
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// masterKeySize is the size of the AES-256 master keys
+const masterKeySize = 32
+
+// KeyProvider holds the master keys that wrap the data keys of the secrets, the way a KMS does: the master
+// keys never leave it. Implementations backed by a cloud KMS only need to wrap and unwrap.
+type KeyProvider interface {
+	// PrimaryKeyID is the master key new secrets are encrypted with
+	PrimaryKeyID() string
+	WrapKey(ctx context.Context, keyID string, dataKey []byte) ([]byte, error)
+	UnwrapKey(ctx context.Context, keyID string, wrapped []byte) ([]byte, error)
+}
+
+// LocalKeyProvider keeps the master keys in memory, read from a key file or derived from secrets
+type LocalKeyProvider struct {
+	primary string
+	keys    map[string][]byte
+}
+
+var _ KeyProvider = (*LocalKeyProvider)(nil)
+
+// NewLocalKeyProvider uses the 32-byte master keys by ID, primary encrypting the new secrets
+func NewLocalKeyProvider(primary string, keys map[string][]byte) (*LocalKeyProvider, error) {
+	if _, ok := keys[primary]; !ok {
+		return nil, fmt.Errorf("primary key %q is not one of the keys", primary)
+	}
+	for id, key := range keys {
+		if id == "" || strings.ContainsAny(id, ": ") {
+			return nil, fmt.Errorf("invalid key ID %q, key IDs cannot be empty or contain ':' or spaces", id)
+		}
+		if len(key) != masterKeySize {
+			return nil, fmt.Errorf("key %q must be %d bytes, got %d", id, masterKeySize, len(key))
+		}
+	}
+	return &LocalKeyProvider{primary: primary, keys: keys}, nil
+}
+
+// KeyFile is the format of SECRET_KEYS_FILE, YAML or JSON, with the keys base64 encoded:
+//
+//	primary: 2025-10
+//	keys:
+//	  2025-10: <base64 of 32 random bytes>
+//	  2025-01: <base64 of 32 random bytes>
+type KeyFile struct {
+	Primary string            `yaml:"primary"`
+	Keys    map[string]string `yaml:"keys"`
+}
+
+// LoadKeyFile reads the master keys of a KeyFile
+func LoadKeyFile(path string) (*LocalKeyProvider, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file KeyFile
+	if err := yaml.Unmarshal(content, &file); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	keys := make(map[string][]byte, len(file.Keys))
+	for id, encoded := range file.Keys {
+		key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+		if err != nil {
+			return nil, fmt.Errorf("%s: key %q is not valid base64", path, id)
+		}
+		keys[id] = key
+	}
+	provider, err := NewLocalKeyProvider(file.Primary, keys)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return provider, nil
+}
+
+// NewSecretKeyProvider derives the master keys from a secret and the previous secrets still decrypting
+// older ciphertexts; key IDs are derived too, so a ciphertext names the secret it needs
+func NewSecretKeyProvider(secret string, previousSecrets []string) (*LocalKeyProvider, error) {
+	keys := map[string][]byte{}
+	for _, value := range append([]string{secret}, previousSecrets...) {
+		key, err := deriveKey(value)
+		if err != nil {
+			return nil, err
+		}
+		keys[SecretKeyID(value)] = key
+	}
+	return NewLocalKeyProvider(SecretKeyID(secret), keys)
+}
+
+// AddSecretKeys adds the master keys derived from secrets next to the keys of a key file; they are never
+// primary, so they only decrypt the ciphertexts written before the key file was set up until they are rotated
+func (p *LocalKeyProvider) AddSecretKeys(secrets []string) error {
+	for _, secret := range secrets {
+		id := SecretKeyID(secret)
+		if _, ok := p.keys[id]; ok {
+			continue
+		}
+		key, err := deriveKey(secret)
+		if err != nil {
+			return err
+		}
+		p.keys[id] = key
+	}
+	return nil
+}
+
+// SecretKeyID is the ID of the master key derived from a secret, it does not reveal the secret
+func SecretKeyID(secret string) string {
+	sum := sha256.Sum256([]byte("indigo-key-id:" + secret))
+	return "secret-" + hex.EncodeToString(sum[:6])
+}
+
+func (p *LocalKeyProvider) PrimaryKeyID() string {
+	return p.primary
+}
+
+func (p *LocalKeyProvider) WrapKey(_ context.Context, keyID string, dataKey []byte) ([]byte, error) {
+	key, ok := p.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("unknown master key %q", keyID)
+	}
+	return seal(key, dataKey, []byte(keyID))
+}
+
+func (p *LocalKeyProvider) UnwrapKey(_ context.Context, keyID string, wrapped []byte) ([]byte, error) {
+	key, ok := p.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("unknown master key %q", keyID)
+	}
+	return open(key, wrapped, []byte(keyID))
+}
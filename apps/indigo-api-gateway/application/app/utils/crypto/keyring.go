@@ -0,0 +1,155 @@
+package crypto
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"menlo.ai/indigo-api-gateway/config/environment_variables"
+)
+
+// envelopePrefix starts the ciphertexts of Keyring; the older ciphertexts are bare base64, which has no ':'
+const envelopePrefix = "enc:v1:"
+
+// ErrNoMasterKey is returned when neither SECRET_KEYS_FILE nor MODEL_PROVIDER_SECRET is set
+var ErrNoMasterKey = errors.New("no master key configured, set SECRET_KEYS_FILE or MODEL_PROVIDER_SECRET")
+
+// Keyring encrypts secrets with envelope encryption: each secret has its own data key, wrapped by a master
+// key of the KeyProvider. A ciphertext reads enc:v1:<key ID>:<wrapped data key>:<encrypted secret>, so the
+// master keys can be rotated while the previous ones still decrypt.
+type Keyring struct {
+	provider KeyProvider
+	// legacySecrets decrypt the ciphertexts written before the envelopes, keyed by MODEL_PROVIDER_SECRET
+	legacySecrets []string
+}
+
+func NewKeyring(provider KeyProvider, legacySecrets []string) *Keyring {
+	return &Keyring{provider: provider, legacySecrets: legacySecrets}
+}
+
+func (k *Keyring) Encrypt(ctx context.Context, plaintext string) (string, error) {
+	dataKey := make([]byte, masterKeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return "", err
+	}
+	keyID := k.provider.PrimaryKeyID()
+	wrapped, err := k.provider.WrapKey(ctx, keyID, dataKey)
+	if err != nil {
+		return "", err
+	}
+	ciphertext, err := seal(dataKey, []byte(plaintext), []byte(keyID))
+	if err != nil {
+		return "", err
+	}
+	return envelopePrefix + keyID + ":" +
+		base64.RawStdEncoding.EncodeToString(wrapped) + ":" +
+		base64.RawStdEncoding.EncodeToString(ciphertext), nil
+}
+
+func (k *Keyring) Decrypt(ctx context.Context, ciphertext string) (string, error) {
+	envelope, ok := strings.CutPrefix(ciphertext, envelopePrefix)
+	if !ok {
+		return k.decryptLegacy(ciphertext)
+	}
+	parts := strings.Split(envelope, ":")
+	if len(parts) != 3 {
+		return "", errors.New("malformed ciphertext")
+	}
+	keyID := parts[0]
+	wrapped, err := base64.RawStdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", fmt.Errorf("malformed ciphertext: %w", err)
+	}
+	data, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", fmt.Errorf("malformed ciphertext: %w", err)
+	}
+	dataKey, err := k.provider.UnwrapKey(ctx, keyID, wrapped)
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := open(dataKey, data, []byte(keyID))
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// NeedsRotation reports whether a ciphertext is not encrypted with the primary master key
+func (k *Keyring) NeedsRotation(ciphertext string) bool {
+	return KeyID(ciphertext) != k.provider.PrimaryKeyID()
+}
+
+func (k *Keyring) decryptLegacy(ciphertext string) (string, error) {
+	if len(k.legacySecrets) == 0 {
+		return "", errors.New("ciphertext without key ID needs MODEL_PROVIDER_SECRET")
+	}
+	var err error
+	for _, secret := range k.legacySecrets {
+		var plaintext string
+		if plaintext, err = DecryptString(secret, ciphertext); err == nil {
+			return plaintext, nil
+		}
+	}
+	return "", err
+}
+
+// KeyID returns the master key a ciphertext is encrypted with, empty for the ciphertexts without envelope
+func KeyID(ciphertext string) string {
+	envelope, ok := strings.CutPrefix(ciphertext, envelopePrefix)
+	if !ok {
+		return ""
+	}
+	keyID, _, _ := strings.Cut(envelope, ":")
+	return keyID
+}
+
+var (
+	defaultKeyringOnce sync.Once
+	defaultKeyring     *Keyring
+	defaultKeyringErr  error
+)
+
+// DefaultKeyring is the keyring of the configuration: the master keys of SECRET_KEYS_FILE when set, otherwise
+// keys derived from MODEL_PROVIDER_SECRET and MODEL_PROVIDER_PREVIOUS_SECRETS, which also decrypt the
+// ciphertexts from before the envelopes
+func DefaultKeyring() (*Keyring, error) {
+	defaultKeyringOnce.Do(func() {
+		env := environment_variables.Current()
+		var secrets []string
+		for _, secret := range append([]string{env.MODEL_PROVIDER_SECRET}, env.MODEL_PROVIDER_PREVIOUS_SECRETS...) {
+			if secret = strings.TrimSpace(secret); secret != "" {
+				secrets = append(secrets, secret)
+			}
+		}
+		defaultKeyring, defaultKeyringErr = newKeyring(strings.TrimSpace(env.SECRET_KEYS_FILE), secrets)
+	})
+	return defaultKeyring, defaultKeyringErr
+}
+
+// newKeyring uses the key file when set; the keys derived from the secrets are kept next to its keys so the
+// secrets encrypted before the move to the key file decrypt until rotate-secrets re-encrypts them
+func newKeyring(keysFile string, secrets []string) (*Keyring, error) {
+	if keysFile != "" {
+		provider, err := LoadKeyFile(keysFile)
+		if err != nil {
+			return nil, err
+		}
+		if err := provider.AddSecretKeys(secrets); err != nil {
+			return nil, err
+		}
+		return NewKeyring(provider, secrets), nil
+	}
+	if len(secrets) == 0 {
+		return nil, ErrNoMasterKey
+	}
+	provider, err := NewSecretKeyProvider(secrets[0], secrets[1:])
+	if err != nil {
+		return nil, err
+	}
+	return NewKeyring(provider, secrets), nil
+}
@@ -0,0 +1,148 @@
+package crypto
+
+import (
+	"context"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestKeyringRotatesSecrets(t *testing.T) {
+	ctx := context.Background()
+	legacy, err := EncryptString("old-secret", "legacy-key")
+	if err != nil {
+		t.Fatalf("legacy encrypt: %v", err)
+	}
+	oldProvider, err := NewSecretKeyProvider("old-secret", nil)
+	if err != nil {
+		t.Fatalf("old provider: %v", err)
+	}
+	oldCiphertext, err := NewKeyring(oldProvider, []string{"old-secret"}).Encrypt(ctx, "provider-key")
+	if err != nil {
+		t.Fatalf("encrypt: %v", err)
+	}
+	if KeyID(oldCiphertext) != SecretKeyID("old-secret") || strings.Contains(oldCiphertext, "provider-key") {
+		t.Fatalf("unexpected ciphertext %q", oldCiphertext)
+	}
+
+	provider, err := NewSecretKeyProvider("new-secret", []string{"old-secret"})
+	if err != nil {
+		t.Fatalf("provider: %v", err)
+	}
+	keyring := NewKeyring(provider, []string{"new-secret", "old-secret"})
+	for ciphertext, expected := range map[string]string{legacy: "legacy-key", oldCiphertext: "provider-key"} {
+		if !keyring.NeedsRotation(ciphertext) {
+			t.Fatalf("expected %q to need a rotation", ciphertext)
+		}
+		plaintext, err := keyring.Decrypt(ctx, ciphertext)
+		if err != nil || plaintext != expected {
+			t.Fatalf("decrypt %q: got %q, %v", ciphertext, plaintext, err)
+		}
+	}
+	rotated, err := keyring.Encrypt(ctx, "provider-key")
+	if err != nil {
+		t.Fatalf("encrypt: %v", err)
+	}
+	if keyring.NeedsRotation(rotated) {
+		t.Fatal("expected the new ciphertext to use the primary key")
+	}
+
+	// without the previous secret the old ciphertexts no longer decrypt
+	current, _ := NewSecretKeyProvider("new-secret", nil)
+	if _, err := NewKeyring(current, []string{"new-secret"}).Decrypt(ctx, oldCiphertext); err == nil {
+		t.Fatal("expected the retired key to be unknown")
+	}
+}
+
+func TestKeyringDetectsTampering(t *testing.T) {
+	ctx := context.Background()
+	provider, _ := NewSecretKeyProvider("secret", nil)
+	keyring := NewKeyring(provider, nil)
+	ciphertext, err := keyring.Encrypt(ctx, "value")
+	if err != nil {
+		t.Fatalf("encrypt: %v", err)
+	}
+	parts := strings.Split(ciphertext, ":")
+	data, _ := base64.RawStdEncoding.DecodeString(parts[len(parts)-1])
+	data[len(data)-1] ^= 1
+	parts[len(parts)-1] = base64.RawStdEncoding.EncodeToString(data)
+	if _, err := keyring.Decrypt(ctx, strings.Join(parts, ":")); err == nil {
+		t.Fatal("expected the tampered ciphertext to fail")
+	}
+}
+
+func TestLoadKeyFile(t *testing.T) {
+	first := base64.StdEncoding.EncodeToString([]byte(strings.Repeat("a", masterKeySize)))
+	second := base64.StdEncoding.EncodeToString([]byte(strings.Repeat("b", masterKeySize)))
+	path := filepath.Join(t.TempDir(), "keys.yaml")
+	content := "primary: 2025-10\nkeys:\n  2025-01: " + first + "\n  2025-10: " + second + "\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("write key file: %v", err)
+	}
+	provider, err := LoadKeyFile(path)
+	if err != nil {
+		t.Fatalf("load key file: %v", err)
+	}
+	ciphertext, err := NewKeyring(provider, nil).Encrypt(context.Background(), "value")
+	if err != nil || KeyID(ciphertext) != "2025-10" {
+		t.Fatalf("unexpected ciphertext %q, %v", ciphertext, err)
+	}
+
+	if err := os.WriteFile(path, []byte("primary: 2025-10\nkeys:\n  2025-10: c2hvcnQ=\n"), 0o600); err != nil {
+		t.Fatalf("write key file: %v", err)
+	}
+	if _, err := LoadKeyFile(path); err == nil || !strings.Contains(err.Error(), "must be 32 bytes") {
+		t.Fatalf("expected a key size error, got %v", err)
+	}
+}
+
+func TestKeyringMigratesSecretsToKeyFile(t *testing.T) {
+	ctx := context.Background()
+	legacy, _ := EncryptString("old-secret", "legacy-key")
+	secretKeyring, err := newKeyring("", []string{"old-secret"})
+	if err != nil {
+		t.Fatalf("secret keyring: %v", err)
+	}
+	enveloped, err := secretKeyring.Encrypt(ctx, "provider-key")
+	if err != nil {
+		t.Fatalf("encrypt: %v", err)
+	}
+
+	key := base64.StdEncoding.EncodeToString([]byte(strings.Repeat("k", masterKeySize)))
+	path := filepath.Join(t.TempDir(), "keys.yaml")
+	if err := os.WriteFile(path, []byte("primary: 2025-10\nkeys:\n  2025-10: "+key+"\n"), 0o600); err != nil {
+		t.Fatalf("write key file: %v", err)
+	}
+	keyring, err := newKeyring(path, []string{"old-secret"})
+	if err != nil {
+		t.Fatalf("key file keyring: %v", err)
+	}
+	// the secret-derived key and the legacy secret decrypt until the rotation
+	for ciphertext, expected := range map[string]string{legacy: "legacy-key", enveloped: "provider-key"} {
+		if !keyring.NeedsRotation(ciphertext) {
+			t.Fatalf("expected %q to need a rotation", ciphertext)
+		}
+		plaintext, err := keyring.Decrypt(ctx, ciphertext)
+		if err != nil || plaintext != expected {
+			t.Fatalf("decrypt %q: got %q, %v", ciphertext, plaintext, err)
+		}
+	}
+	rotated, err := keyring.Encrypt(ctx, "provider-key")
+	if err != nil {
+		t.Fatalf("encrypt: %v", err)
+	}
+	if KeyID(rotated) != "2025-10" || keyring.NeedsRotation(rotated) {
+		t.Fatalf("expected the key file primary, got %q", rotated)
+	}
+
+	// once the secrets are removed only the key file decrypts
+	withoutSecrets, err := newKeyring(path, nil)
+	if err != nil {
+		t.Fatalf("key file keyring: %v", err)
+	}
+	if _, err := withoutSecrets.Decrypt(ctx, enveloped); err == nil {
+		t.Fatal("expected the secret-derived key to be unknown")
+	}
+}
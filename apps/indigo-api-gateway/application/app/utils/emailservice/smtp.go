@@ -9,7 +9,7 @@ import (
 )
 
 func SendEmail(to string, subject string, body string) error {
-	envs := environment_variables.EnvironmentVariables
+	envs := environment_variables.Current()
 
 	addr := fmt.Sprintf("%s:%d", envs.SMTP_HOST, envs.SMTP_PORT)
 	tlsConfig := &tls.Config{
@@ -12,7 +12,12 @@ import (
 
 	"github.com/gin-gonic/gin"
 	openai "github.com/sashabaranov/go-openai"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"menlo.ai/indigo-api-gateway/app/utils/logger"
+	"menlo.ai/indigo-api-gateway/app/utils/metrics"
+	"menlo.ai/indigo-api-gateway/app/utils/tokenizer"
+	"menlo.ai/indigo-api-gateway/app/utils/tracing"
 	"resty.dev/v3"
 )
 
@@ -77,7 +82,17 @@ func NewChatCompletionClient(client *resty.Client, name, baseURL string) *ChatCo
 	}
 }
 
-func (c *ChatCompletionClient) CreateChatCompletion(ctx context.Context, apiKey string, request openai.ChatCompletionRequest) (*openai.ChatCompletionResponse, error) {
+func (c *ChatCompletionClient) CreateChatCompletion(ctx context.Context, apiKey string, request openai.ChatCompletionRequest) (response *openai.ChatCompletionResponse, err error) {
+	start := time.Now()
+	ctx, span := c.startSpan(ctx, request, false)
+	defer func() {
+		c.endSpan(span, response, err)
+		metrics.ObserveUpstreamRequest(c.name, request.Model, false, err, time.Since(start))
+		if response != nil {
+			metrics.AddTokens(c.name, request.Model, response.Usage.PromptTokens, response.Usage.CompletionTokens)
+		}
+	}()
+
 	var respBody openai.ChatCompletionResponse
 	resp, err := c.prepareRequest(ctx, apiKey).
 		SetBody(request).
@@ -93,21 +108,32 @@ func (c *ChatCompletionClient) CreateChatCompletion(ctx context.Context, apiKey
 }
 
 func (c *ChatCompletionClient) CreateChatCompletionStream(ctx context.Context, apiKey string, request openai.ChatCompletionRequest, opts ...StreamOption) (io.ReadCloser, error) {
+	start := time.Now()
+	ctx, span := c.startSpan(ctx, request, true)
 	resp, err := c.doStreamingRequest(ctx, apiKey, request, opts...)
 	if err != nil {
+		c.endSpan(span, nil, err)
+		metrics.ObserveUpstreamRequest(c.name, request.Model, true, err, time.Since(start))
 		return nil, err
 	}
 
 	reader, writer := io.Pipe()
 
 	go func() {
+		var copyErr error
 		defer func() {
 			if closeErr := resp.RawResponse.Body.Close(); closeErr != nil {
 				logger.GetLogger().Errorf("%s: unable to close response body: %v", c.name, closeErr)
 			}
+			c.endSpan(span, nil, copyErr)
+			metrics.ObserveUpstreamRequest(c.name, request.Model, true, copyErr, time.Since(start))
 		}()
 
-		if _, copyErr := io.Copy(writer, resp.RawResponse.Body); copyErr != nil {
+		body := &firstReadObserver{reader: resp.RawResponse.Body, onFirstRead: func() {
+			span.AddEvent("first_token")
+			metrics.ObserveTimeToFirstToken(c.name, request.Model, time.Since(start))
+		}}
+		if _, copyErr = io.Copy(writer, body); copyErr != nil {
 			_ = writer.CloseWithError(copyErr)
 			return
 		}
@@ -117,15 +143,74 @@ func (c *ChatCompletionClient) CreateChatCompletionStream(ctx context.Context, a
 	return reader, nil
 }
 
+// startSpan starts the client span of a chat completion, the HTTP span of the request is its child
+func (c *ChatCompletionClient) startSpan(ctx context.Context, request openai.ChatCompletionRequest, stream bool) (context.Context, trace.Span) {
+	return tracing.Start(ctx, "chat "+request.Model,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			tracing.AttributeProvider.String(c.name),
+			tracing.AttributeModel.String(request.Model),
+			tracing.AttributeStream.Bool(stream),
+		),
+	)
+}
+
+func (c *ChatCompletionClient) endSpan(span trace.Span, response *openai.ChatCompletionResponse, err error) {
+	if response != nil {
+		span.SetAttributes(
+			attribute.Int("gen_ai.usage.input_tokens", response.Usage.PromptTokens),
+			attribute.Int("gen_ai.usage.output_tokens", response.Usage.CompletionTokens),
+		)
+	}
+	tracing.RecordError(span, err)
+	span.End()
+}
+
+// firstReadObserver calls onFirstRead once, when the first bytes of the stream arrive
+type firstReadObserver struct {
+	reader      io.Reader
+	onFirstRead func()
+}
+
+func (o *firstReadObserver) Read(p []byte) (int, error) {
+	n, err := o.reader.Read(p)
+	if n > 0 && o.onFirstRead != nil {
+		o.onFirstRead()
+		o.onFirstRead = nil
+	}
+	return n, err
+}
+
 // StreamChatCompletionToContext streams the completion to the provided Gin context while
 // accumulating the complete response, mirroring the SSE handling found in the conversation
 // completion flow.
 func (c *ChatCompletionClient) StreamChatCompletionToContext(reqCtx *gin.Context, apiKey string, request openai.ChatCompletionRequest, opts ...StreamOption) (*openai.ChatCompletionResponse, error) {
+	return c.streamToContext(reqCtx, apiKey, request, nil, opts)
+}
+
+// StreamChatCompletionRoundToContext streams one completion of a multi-round exchange, such as a tool loop:
+// only the SSE lines accepted by forward reach the client, the response is accumulated from every line.
+func (c *ChatCompletionClient) StreamChatCompletionRoundToContext(reqCtx *gin.Context, apiKey string, request openai.ChatCompletionRequest, forward func(line string) bool, opts ...StreamOption) (*openai.ChatCompletionResponse, error) {
+	return c.streamToContext(reqCtx, apiKey, request, forward, opts)
+}
+
+func (c *ChatCompletionClient) streamToContext(reqCtx *gin.Context, apiKey string, request openai.ChatCompletionRequest, forward func(line string) bool, opts []StreamOption) (response *openai.ChatCompletionResponse, err error) {
 	if reqCtx == nil {
 		return nil, fmt.Errorf("%s: streaming request failed: nil gin context", c.name)
 	}
 
-	ctx, cancel := context.WithTimeout(reqCtx.Request.Context(), requestTimeout)
+	start := time.Now()
+	firstChunk := true
+	spanCtx, span := c.startSpan(reqCtx.Request.Context(), request, true)
+	defer func() {
+		c.endSpan(span, response, err)
+		metrics.ObserveUpstreamRequest(c.name, request.Model, true, err, time.Since(start))
+		if response != nil {
+			metrics.AddTokens(c.name, request.Model, response.Usage.PromptTokens, response.Usage.CompletionTokens)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(spanCtx, requestTimeout)
 	defer cancel()
 
 	c.SetupSSEHeaders(reqCtx)
@@ -153,13 +238,20 @@ func (c *ChatCompletionClient) StreamChatCompletionToContext(reqCtx *gin.Context
 				break
 			}
 
-			if err := c.writeSSELine(reqCtx, line); err != nil {
-				cancel()
-				wg.Wait()
-				return nil, fmt.Errorf("%s: unable to write SSE line: %w", c.name, err)
+			if forward == nil || forward(line) {
+				if err := c.writeSSELine(reqCtx, line); err != nil {
+					cancel()
+					wg.Wait()
+					return nil, fmt.Errorf("%s: unable to write SSE line: %w", c.name, err)
+				}
 			}
 
 			if data, found := strings.CutPrefix(line, dataPrefix); found {
+				if firstChunk && data != doneMarker {
+					firstChunk = false
+					span.AddEvent("first_token")
+					metrics.ObserveTimeToFirstToken(c.name, request.Model, time.Since(start))
+				}
 				if data == doneMarker {
 					streamingComplete = true
 					cancel()
@@ -209,7 +301,7 @@ func (c *ChatCompletionClient) StreamChatCompletionToContext(reqCtx *gin.Context
 	close(dataChan)
 	close(errChan)
 
-	response := c.buildCompleteResponse(
+	complete := c.buildCompleteResponse(
 		contentBuilder.String(),
 		reasoningBuilder.String(),
 		functionCallAccumulator,
@@ -218,7 +310,7 @@ func (c *ChatCompletionClient) StreamChatCompletionToContext(reqCtx *gin.Context
 		request,
 	)
 
-	return &response, nil
+	return &complete, nil
 }
 
 // SetupSSEHeaders configures the Gin context for Server-Sent Events responses.
@@ -505,8 +597,9 @@ func (c *ChatCompletionClient) buildCompleteResponse(content string, reasoning s
 		},
 	}
 
-	promptTokens := c.estimateTokens(request.Messages)
-	completionTokens := c.estimateTokens([]openai.ChatCompletionMessage{message})
+	counter := tokenizer.ForModel("", model)
+	promptTokens := counter.CountMessages(request.Messages)
+	completionTokens := tokenizer.CountCompletion(counter, message)
 	totalTokens := promptTokens + completionTokens
 
 	return openai.ChatCompletionResponse{
@@ -523,35 +616,6 @@ func (c *ChatCompletionClient) buildCompleteResponse(content string, reasoning s
 	}
 }
 
-func (c *ChatCompletionClient) estimateTokens(messages []openai.ChatCompletionMessage) int {
-	var allText strings.Builder
-
-	for _, msg := range messages {
-		allText.WriteString(msg.Content)
-		allText.WriteString(" ")
-
-		if msg.FunctionCall != nil {
-			allText.WriteString(msg.FunctionCall.Name)
-			allText.WriteString(" ")
-			allText.WriteString(msg.FunctionCall.Arguments)
-			allText.WriteString(" ")
-		}
-
-		for _, toolCall := range msg.ToolCalls {
-			allText.WriteString(toolCall.ID)
-			allText.WriteString(" ")
-			allText.WriteString(toolCall.Function.Name)
-			allText.WriteString(" ")
-			allText.WriteString(toolCall.Function.Arguments)
-			allText.WriteString(" ")
-		}
-	}
-
-	normalized := strings.Join(strings.Fields(allText.String()), " ")
-	words := strings.Fields(normalized)
-	return len(words)
-}
-
 func (c *ChatCompletionClient) sendAsyncError(errChan chan<- error, err error) {
 	if err == nil {
 		return
@@ -0,0 +1,83 @@
+package chat
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"resty.dev/v3"
+)
+
+type EmbeddingClient struct {
+	client  *resty.Client
+	baseURL string
+	name    string
+}
+
+type EmbeddingRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+type EmbeddingResponse struct {
+	Object string          `json:"object"`
+	Model  string          `json:"model"`
+	Data   []EmbeddingData `json:"data"`
+}
+
+type EmbeddingData struct {
+	Object    string    `json:"object"`
+	Index     int       `json:"index"`
+	Embedding []float32 `json:"embedding"`
+}
+
+func NewEmbeddingClient(client *resty.Client, name, baseURL string) *EmbeddingClient {
+	return &EmbeddingClient{
+		client:  client,
+		baseURL: normalizeBaseURL(baseURL),
+		name:    name,
+	}
+}
+
+// CreateEmbeddings returns one vector per input, in input order
+func (c *EmbeddingClient) CreateEmbeddings(ctx context.Context, model string, inputs []string) ([][]float32, error) {
+	var respBody EmbeddingResponse
+	resp, err := c.client.R().
+		SetContext(ctx).
+		SetBody(EmbeddingRequest{Model: model, Input: inputs}).
+		SetResult(&respBody).
+		Post(c.baseURL + "/embeddings")
+	if err != nil {
+		return nil, err
+	}
+	if resp.IsError() {
+		return nil, c.errorFromResponse(resp, "embeddings request failed")
+	}
+
+	vectors := make([][]float32, len(inputs))
+	for _, data := range respBody.Data {
+		if data.Index < 0 || data.Index >= len(vectors) {
+			return nil, fmt.Errorf("%s: embedding index %d out of range", c.name, data.Index)
+		}
+		vectors[data.Index] = data.Embedding
+	}
+	for i, vector := range vectors {
+		if len(vector) == 0 {
+			return nil, fmt.Errorf("%s: missing embedding for input %d", c.name, i)
+		}
+	}
+	return vectors, nil
+}
+
+func (c *EmbeddingClient) errorFromResponse(resp *resty.Response, message string) error {
+	if resp == nil || resp.RawResponse == nil || resp.RawResponse.Body == nil {
+		return fmt.Errorf("%s: %s with status %d", c.name, message, statusCode(resp))
+	}
+	defer resp.RawResponse.Body.Close()
+	body, err := io.ReadAll(resp.RawResponse.Body)
+	if err != nil || strings.TrimSpace(string(body)) == "" {
+		return fmt.Errorf("%s: %s with status %d", c.name, message, statusCode(resp))
+	}
+	return fmt.Errorf("%s: %s with status %d: %s", c.name, message, statusCode(resp), strings.TrimSpace(string(body)))
+}
@@ -7,11 +7,14 @@ import (
 	"github.com/sirupsen/logrus"
 	"menlo.ai/indigo-api-gateway/app/utils/contextkeys"
 	"menlo.ai/indigo-api-gateway/app/utils/logger"
+	"menlo.ai/indigo-api-gateway/app/utils/redact"
+	"menlo.ai/indigo-api-gateway/app/utils/tracing"
 	"resty.dev/v3"
 )
 
 func NewClient(clientName string) *resty.Client {
 	client := resty.New()
+	client.SetTransport(tracing.Transport(client.Transport(), clientName))
 	client.AddRequestMiddleware(func(c *resty.Client, r *resty.Request) error {
 		start := time.Now()
 		ctx := context.WithValue(r.Context(), contextkeys.HttpClientStartsAt{}, start)
@@ -21,22 +24,27 @@ func NewClient(clientName string) *resty.Client {
 	})
 	client.AddResponseMiddleware(func(c *resty.Client, r *resty.Response) error {
 		logger := logger.GetLogger()
-		requestID := r.Request.Context().Value(contextkeys.RequestId{})
-		startTime, _ := r.Request.Context().Value(contextkeys.HttpClientStartsAt{}).(time.Time)
-		requestBody := r.Request.Context().Value(contextkeys.HttpClientRequestBody{})
+		policy := redact.PolicyFromEnv()
+		ctx := r.Request.Context()
+		requestID := ctx.Value(contextkeys.RequestId{})
+		startTime, _ := ctx.Value(contextkeys.HttpClientStartsAt{}).(time.Time)
 		latency := time.Since(startTime)
-		var responseBody any
-		if !r.Request.DoNotParseResponse {
-			responseBody = r.Result()
+		var requestBody, responseBody string
+		if omitted, _ := ctx.Value(contextkeys.OmitLogBodies{}).(bool); !omitted {
+			requestBody = policy.Value(ctx.Value(contextkeys.HttpClientRequestBody{}))
+			if !r.Request.DoNotParseResponse {
+				responseBody = policy.Value(r.Result())
+			}
 		}
 		logger.WithFields(logrus.Fields{
 			"request_id": requestID,
+			"trace_id":   tracing.TraceID(ctx),
 			"client":     clientName,
 			"status":     r.StatusCode(),
 			"method":     r.Request.RawRequest.Method,
 			"path":       r.Request.RawRequest.URL.Path,
-			"query":      r.Request.RawRequest.URL.RawQuery,
-			"headers":    r.Request.RawRequest.Header,
+			"query":      policy.Query(r.Request.RawRequest.URL.RawQuery),
+			"headers":    policy.Headers(r.Request.RawRequest.Header),
 			"req_body":   requestBody,
 			"resp_body":  responseBody,
 			"latency":    latency.String(),
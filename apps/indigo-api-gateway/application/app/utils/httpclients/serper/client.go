@@ -15,14 +15,30 @@ func Init() {
 	SerperRestyClient = httpclients.NewClient("SerperClient")
 }
 
+const SerperDefaultSearchBaseURL = "https://google.serper.dev"
+
 type SerperClient struct {
-	apiKey string
+	apiKey        string
+	searchBaseURL string
 }
 
 func NewSerperClient() *SerperClient {
 	return &SerperClient{
-		apiKey: environment_variables.EnvironmentVariables.SERPER_API_KEY,
+		apiKey:        environment_variables.Current().SERPER_API_KEY,
+		searchBaseURL: SerperDefaultSearchBaseURL,
+	}
+}
+
+// NewSerperClientWithConfig creates a client searching through baseURL with apiKey, empty values keep the defaults
+func NewSerperClientWithConfig(baseURL, apiKey string) *SerperClient {
+	client := NewSerperClient()
+	if baseURL != "" {
+		client.searchBaseURL = baseURL
+	}
+	if apiKey != "" {
+		client.apiKey = apiKey
 	}
+	return client
 }
 
 type TBSTimeRange string
@@ -66,7 +82,7 @@ func (c *SerperClient) Search(ctx context.Context, query SearchRequest) (*Search
 		SetHeader("Content-Type", "application/json").
 		SetBody(query).
 		SetResult(&result).
-		Post("https://google.serper.dev/search")
+		Post(c.searchBaseURL + "/search")
 
 	if err != nil {
 		return nil, err
@@ -86,6 +102,7 @@ type FetchWebpageRequest struct {
 
 type FetchWebpageResponse struct {
 	Text     string                 `json:"text"`
+	Markdown string                 `json:"markdown"`
 	Metadata map[string]interface{} `json:"metadata"`
 }
 
@@ -0,0 +1,85 @@
+package websearch
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+)
+
+const BraveDefaultBaseURL = "https://api.search.brave.com"
+
+type BraveClient struct {
+	baseURL string
+	apiKey  string
+}
+
+func NewBraveClient(baseURL, apiKey string) *BraveClient {
+	if baseURL == "" {
+		baseURL = BraveDefaultBaseURL
+	}
+	return &BraveClient{baseURL: baseURL, apiKey: apiKey}
+}
+
+type BraveSearchRequest struct {
+	Q          string
+	Country    string
+	SearchLang string
+	// Count is at most 20 and Offset, the page, at most 9
+	Count  int
+	Offset int
+	// Freshness is pd, pw, pm or py
+	Freshness string
+}
+
+type BraveResult struct {
+	Title       string `json:"title"`
+	URL         string `json:"url"`
+	Description string `json:"description"`
+	Age         string `json:"age"`
+	Profile     struct {
+		Name string `json:"name"`
+	} `json:"profile"`
+}
+
+type BraveSearchResponse struct {
+	Web struct {
+		Results []BraveResult `json:"results"`
+	} `json:"web"`
+	News struct {
+		Results []BraveResult `json:"results"`
+	} `json:"news"`
+}
+
+func (c *BraveClient) Search(ctx context.Context, query BraveSearchRequest) (*BraveSearchResponse, error) {
+	var result BraveSearchResponse
+	request := WebSearchRestyClient.R().
+		SetContext(ctx).
+		SetHeader("Accept", "application/json").
+		SetHeader("X-Subscription-Token", c.apiKey).
+		SetQueryParam("q", query.Q).
+		SetResult(&result)
+	if query.Country != "" {
+		request.SetQueryParam("country", query.Country)
+	}
+	if query.SearchLang != "" {
+		request.SetQueryParam("search_lang", query.SearchLang)
+	}
+	if query.Count > 0 {
+		request.SetQueryParam("count", strconv.Itoa(query.Count))
+	}
+	if query.Offset > 0 {
+		request.SetQueryParam("offset", strconv.Itoa(query.Offset))
+	}
+	if query.Freshness != "" {
+		request.SetQueryParam("freshness", query.Freshness)
+	}
+
+	resp, err := request.Get(c.baseURL + "/res/v1/web/search")
+	if err != nil {
+		return nil, err
+	}
+	if resp.IsError() {
+		return nil, fmt.Errorf("brave API error: %s", resp.Status())
+	}
+	return &result, nil
+}
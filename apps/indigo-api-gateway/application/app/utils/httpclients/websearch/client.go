@@ -0,0 +1,13 @@
+package websearch
+
+import (
+	"menlo.ai/indigo-api-gateway/app/utils/httpclients"
+	"resty.dev/v3"
+)
+
+// WebSearchRestyClient is shared by the SearXNG, Brave and Tavily clients
+var WebSearchRestyClient *resty.Client
+
+func Init() {
+	WebSearchRestyClient = httpclients.NewClient("WebSearchClient")
+}
@@ -0,0 +1,66 @@
+package websearch
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+)
+
+type SearXNGClient struct {
+	baseURL string
+}
+
+// NewSearXNGClient creates a client of the SearXNG instance at baseURL, its JSON output format must be enabled
+func NewSearXNGClient(baseURL string) *SearXNGClient {
+	return &SearXNGClient{baseURL: baseURL}
+}
+
+type SearXNGSearchRequest struct {
+	Q        string
+	Language string
+	PageNo   int
+	// TimeRange is day, week, month or year
+	TimeRange string
+}
+
+type SearXNGResult struct {
+	Title         string `json:"title"`
+	URL           string `json:"url"`
+	Content       string `json:"content"`
+	Engine        string `json:"engine"`
+	PublishedDate string `json:"publishedDate"`
+}
+
+type SearXNGSearchResponse struct {
+	Query   string          `json:"query"`
+	Results []SearXNGResult `json:"results"`
+	Answers []interface{}   `json:"answers"`
+}
+
+func (c *SearXNGClient) Search(ctx context.Context, query SearXNGSearchRequest) (*SearXNGSearchResponse, error) {
+	var result SearXNGSearchResponse
+	request := WebSearchRestyClient.R().
+		SetContext(ctx).
+		SetHeader("Accept", "application/json").
+		SetQueryParam("q", query.Q).
+		SetQueryParam("format", "json").
+		SetResult(&result)
+	if query.Language != "" {
+		request.SetQueryParam("language", query.Language)
+	}
+	if query.PageNo > 1 {
+		request.SetQueryParam("pageno", strconv.Itoa(query.PageNo))
+	}
+	if query.TimeRange != "" {
+		request.SetQueryParam("time_range", query.TimeRange)
+	}
+
+	resp, err := request.Get(c.baseURL + "/search")
+	if err != nil {
+		return nil, err
+	}
+	if resp.IsError() {
+		return nil, fmt.Errorf("searxng API error: %s", resp.Status())
+	}
+	return &result, nil
+}
@@ -0,0 +1,60 @@
+package websearch
+
+import (
+	"context"
+	"fmt"
+)
+
+const TavilyDefaultBaseURL = "https://api.tavily.com"
+
+type TavilyClient struct {
+	baseURL string
+	apiKey  string
+}
+
+func NewTavilyClient(baseURL, apiKey string) *TavilyClient {
+	if baseURL == "" {
+		baseURL = TavilyDefaultBaseURL
+	}
+	return &TavilyClient{baseURL: baseURL, apiKey: apiKey}
+}
+
+type TavilySearchRequest struct {
+	Query      string `json:"query"`
+	MaxResults int    `json:"max_results,omitempty"`
+	// TimeRange is day, week, month or year
+	TimeRange     string `json:"time_range,omitempty"`
+	IncludeAnswer bool   `json:"include_answer"`
+}
+
+type TavilyResult struct {
+	Title         string  `json:"title"`
+	URL           string  `json:"url"`
+	Content       string  `json:"content"`
+	Score         float64 `json:"score"`
+	PublishedDate string  `json:"published_date"`
+}
+
+type TavilySearchResponse struct {
+	Query   string         `json:"query"`
+	Answer  string         `json:"answer"`
+	Results []TavilyResult `json:"results"`
+}
+
+func (c *TavilyClient) Search(ctx context.Context, query TavilySearchRequest) (*TavilySearchResponse, error) {
+	var result TavilySearchResponse
+	resp, err := WebSearchRestyClient.R().
+		SetContext(ctx).
+		SetHeader("Authorization", "Bearer "+c.apiKey).
+		SetHeader("Content-Type", "application/json").
+		SetBody(query).
+		SetResult(&result).
+		Post(c.baseURL + "/search")
+	if err != nil {
+		return nil, err
+	}
+	if resp.IsError() {
+		return nil, fmt.Errorf("tavily API error: %s", resp.Status())
+	}
+	return &result, nil
+}
@@ -57,7 +57,7 @@ func ValidateIDFormat(id, expectedPrefix string) bool {
 }
 
 func HashKey(key string) string {
-	h := hmac.New(sha256.New, []byte(environment_variables.EnvironmentVariables.APIKEY_SECRET))
+	h := hmac.New(sha256.New, []byte(environment_variables.Current().APIKEY_SECRET))
 	h.Write([]byte(key))
 
 	return hex.EncodeToString(h.Sum(nil))
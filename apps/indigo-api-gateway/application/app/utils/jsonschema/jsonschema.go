@@ -0,0 +1,449 @@
+// Package jsonschema validates JSON documents against the JSON Schema keywords used by structured outputs:
+// type, enum, const, the object, array, string and number constraints, allOf/anyOf/oneOf/not and local $ref.
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ValidationError describes one mismatch between a document and its schema
+type ValidationError struct {
+	// Path is a JSON pointer to the offending value, "" for the document root
+	Path    string
+	Message string
+}
+
+func (e ValidationError) String() string {
+	if e.Path == "" {
+		return e.Message
+	}
+	return fmt.Sprintf("%s: %s", e.Path, e.Message)
+}
+
+// Schema is a compiled JSON Schema document
+type Schema struct {
+	root     any
+	patterns map[string]*regexp.Regexp
+}
+
+// Compile parses a JSON Schema document
+func Compile(raw []byte) (*Schema, error) {
+	root, err := decode(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	switch root.(type) {
+	case map[string]any, bool:
+	default:
+		return nil, fmt.Errorf("invalid schema: expected an object or a boolean")
+	}
+	schema := &Schema{root: root, patterns: make(map[string]*regexp.Regexp)}
+	if err := schema.compilePatterns(root); err != nil {
+		return nil, err
+	}
+	return schema, nil
+}
+
+// compilePatterns compiles every pattern up front so a bad expression is reported with the schema
+func (s *Schema) compilePatterns(node any) error {
+	switch value := node.(type) {
+	case map[string]any:
+		if pattern, ok := value["pattern"].(string); ok {
+			if _, seen := s.patterns[pattern]; !seen {
+				re, err := regexp.Compile(pattern)
+				if err != nil {
+					return fmt.Errorf("invalid schema: pattern %q: %w", pattern, err)
+				}
+				s.patterns[pattern] = re
+			}
+		}
+		for _, child := range value {
+			if err := s.compilePatterns(child); err != nil {
+				return err
+			}
+		}
+	case []any:
+		for _, child := range value {
+			if err := s.compilePatterns(child); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ValidateJSON parses the document and validates it
+func (s *Schema) ValidateJSON(data []byte) []ValidationError {
+	document, err := decode(data)
+	if err != nil {
+		return []ValidationError{{Message: fmt.Sprintf("invalid JSON: %s", err.Error())}}
+	}
+	return s.Validate(document)
+}
+
+// Validate validates a document decoded with json.Decoder.UseNumber
+func (s *Schema) Validate(document any) []ValidationError {
+	var errs []ValidationError
+	s.validate(s.root, document, "", &errs)
+	return errs
+}
+
+// decode parses JSON keeping numbers exact so integers can be told apart from floats
+func decode(data []byte) (any, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	if decoder.More() {
+		return nil, fmt.Errorf("unexpected data after the top-level value")
+	}
+	return value, nil
+}
+
+func (s *Schema) validate(node any, value any, path string, errs *[]ValidationError) {
+	schema, ok := node.(map[string]any)
+	if !ok {
+		if allowed, isBool := node.(bool); isBool && !allowed {
+			*errs = append(*errs, ValidationError{Path: path, Message: "no value is allowed here"})
+		}
+		return
+	}
+	fail := func(format string, args ...any) {
+		*errs = append(*errs, ValidationError{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if ref, ok := schema["$ref"].(string); ok {
+		target, err := s.resolve(ref)
+		if err != nil {
+			fail("%s", err.Error())
+			return
+		}
+		s.validate(target, value, path, errs)
+	}
+
+	if types, ok := typeList(schema["type"]); ok && !matchesAnyType(value, types) {
+		fail("expected %s, got %s", strings.Join(types, " or "), typeName(value))
+		return
+	}
+	if enum, ok := schema["enum"].([]any); ok {
+		found := false
+		for _, candidate := range enum {
+			if equal(candidate, value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			fail("value must be one of %s", compact(enum))
+		}
+	}
+	if constant, ok := schema["const"]; ok && !equal(constant, value) {
+		fail("value must be %s", compact(constant))
+	}
+
+	switch typed := value.(type) {
+	case map[string]any:
+		s.validateObject(schema, typed, path, errs)
+	case []any:
+		s.validateArray(schema, typed, path, errs)
+	case string:
+		length := utf8.RuneCountInString(typed)
+		if minimum, ok := intKeyword(schema, "minLength"); ok && length < minimum {
+			fail("string is shorter than %d characters", minimum)
+		}
+		if maximum, ok := intKeyword(schema, "maxLength"); ok && length > maximum {
+			fail("string is longer than %d characters", maximum)
+		}
+		if pattern, ok := schema["pattern"].(string); ok && !s.patterns[pattern].MatchString(typed) {
+			fail("string does not match pattern %q", pattern)
+		}
+	case json.Number:
+		s.validateNumber(schema, typed, fail)
+	}
+
+	if all, ok := schema["allOf"].([]any); ok {
+		for _, sub := range all {
+			s.validate(sub, value, path, errs)
+		}
+	}
+	if anyOf, ok := schema["anyOf"].([]any); ok {
+		if s.countMatches(anyOf, value, path) == 0 {
+			fail("value does not match any of the allowed schemas")
+		}
+	}
+	if oneOf, ok := schema["oneOf"].([]any); ok {
+		if matches := s.countMatches(oneOf, value, path); matches != 1 {
+			fail("value must match exactly one schema, matched %d", matches)
+		}
+	}
+	if not, ok := schema["not"]; ok && s.countMatches([]any{not}, value, path) == 1 {
+		fail("value matches a schema it must not match")
+	}
+}
+
+func (s *Schema) validateObject(schema map[string]any, object map[string]any, path string, errs *[]ValidationError) {
+	if required, ok := schema["required"].([]any); ok {
+		for _, name := range required {
+			if key, ok := name.(string); ok {
+				if _, present := object[key]; !present {
+					*errs = append(*errs, ValidationError{Path: path, Message: fmt.Sprintf("missing required property %q", key)})
+				}
+			}
+		}
+	}
+	if minimum, ok := intKeyword(schema, "minProperties"); ok && len(object) < minimum {
+		*errs = append(*errs, ValidationError{Path: path, Message: fmt.Sprintf("object has fewer than %d properties", minimum)})
+	}
+	if maximum, ok := intKeyword(schema, "maxProperties"); ok && len(object) > maximum {
+		*errs = append(*errs, ValidationError{Path: path, Message: fmt.Sprintf("object has more than %d properties", maximum)})
+	}
+
+	properties, _ := schema["properties"].(map[string]any)
+	additional, hasAdditional := schema["additionalProperties"]
+	// iterate in key order so errors are reported deterministically
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		childPath := path + "/" + escapePointer(key)
+		if property, ok := properties[key]; ok {
+			s.validate(property, object[key], childPath, errs)
+			continue
+		}
+		if !hasAdditional {
+			continue
+		}
+		if allowed, ok := additional.(bool); ok && !allowed {
+			*errs = append(*errs, ValidationError{Path: path, Message: fmt.Sprintf("unexpected property %q", key)})
+			continue
+		}
+		s.validate(additional, object[key], childPath, errs)
+	}
+}
+
+func (s *Schema) validateArray(schema map[string]any, array []any, path string, errs *[]ValidationError) {
+	if minimum, ok := intKeyword(schema, "minItems"); ok && len(array) < minimum {
+		*errs = append(*errs, ValidationError{Path: path, Message: fmt.Sprintf("array has fewer than %d items", minimum)})
+	}
+	if maximum, ok := intKeyword(schema, "maxItems"); ok && len(array) > maximum {
+		*errs = append(*errs, ValidationError{Path: path, Message: fmt.Sprintf("array has more than %d items", maximum)})
+	}
+	if unique, ok := schema["uniqueItems"].(bool); ok && unique {
+		for i := range array {
+			for j := i + 1; j < len(array); j++ {
+				if equal(array[i], array[j]) {
+					*errs = append(*errs, ValidationError{Path: path, Message: fmt.Sprintf("items %d and %d are equal", i, j)})
+				}
+			}
+		}
+	}
+
+	prefix, _ := schema["prefixItems"].([]any)
+	for i, item := range array {
+		itemPath := fmt.Sprintf("%s/%d", path, i)
+		if i < len(prefix) {
+			s.validate(prefix[i], item, itemPath, errs)
+		} else if items, ok := schema["items"]; ok {
+			s.validate(items, item, itemPath, errs)
+		}
+	}
+}
+
+func (s *Schema) validateNumber(schema map[string]any, number json.Number, fail func(format string, args ...any)) {
+	value, err := number.Float64()
+	if err != nil {
+		fail("invalid number %s", number.String())
+		return
+	}
+	if minimum, ok := floatKeyword(schema, "minimum"); ok && value < minimum {
+		fail("value must be >= %v", minimum)
+	}
+	if maximum, ok := floatKeyword(schema, "maximum"); ok && value > maximum {
+		fail("value must be <= %v", maximum)
+	}
+	if minimum, ok := floatKeyword(schema, "exclusiveMinimum"); ok && value <= minimum {
+		fail("value must be > %v", minimum)
+	}
+	if maximum, ok := floatKeyword(schema, "exclusiveMaximum"); ok && value >= maximum {
+		fail("value must be < %v", maximum)
+	}
+	if divisor, ok := floatKeyword(schema, "multipleOf"); ok && divisor > 0 {
+		quotient := value / divisor
+		if math.Abs(quotient-math.Round(quotient)) > 1e-9 {
+			fail("value must be a multiple of %v", divisor)
+		}
+	}
+}
+
+// countMatches validates the value against each schema and counts those it satisfies
+func (s *Schema) countMatches(schemas []any, value any, path string) int {
+	matches := 0
+	for _, sub := range schemas {
+		var subErrs []ValidationError
+		s.validate(sub, value, path, &subErrs)
+		if len(subErrs) == 0 {
+			matches++
+		}
+	}
+	return matches
+}
+
+// resolve follows a local reference such as #/$defs/address
+func (s *Schema) resolve(ref string) (any, error) {
+	if ref == "#" {
+		return s.root, nil
+	}
+	if !strings.HasPrefix(ref, "#/") {
+		return nil, fmt.Errorf("unsupported reference %q, only local references are resolved", ref)
+	}
+	node := s.root
+	for _, token := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+		switch current := node.(type) {
+		case map[string]any:
+			next, ok := current[token]
+			if !ok {
+				return nil, fmt.Errorf("unresolved reference %q", ref)
+			}
+			node = next
+		case []any:
+			index, err := strconv.Atoi(token)
+			if err != nil || index < 0 || index >= len(current) {
+				return nil, fmt.Errorf("unresolved reference %q", ref)
+			}
+			node = current[index]
+		default:
+			return nil, fmt.Errorf("unresolved reference %q", ref)
+		}
+	}
+	return node, nil
+}
+
+func typeList(value any) ([]string, bool) {
+	switch typed := value.(type) {
+	case string:
+		return []string{typed}, true
+	case []any:
+		types := make([]string, 0, len(typed))
+		for _, item := range typed {
+			if name, ok := item.(string); ok {
+				types = append(types, name)
+			}
+		}
+		return types, len(types) > 0
+	}
+	return nil, false
+}
+
+func matchesAnyType(value any, types []string) bool {
+	actual := typeName(value)
+	for _, expected := range types {
+		if expected == actual || (expected == "number" && actual == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+func typeName(value any) string {
+	switch typed := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	case json.Number:
+		if _, err := typed.Int64(); err == nil {
+			return "integer"
+		}
+		if f, err := typed.Float64(); err == nil && f == math.Trunc(f) && !math.IsInf(f, 0) {
+			return "integer"
+		}
+		return "number"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+func intKeyword(schema map[string]any, key string) (int, bool) {
+	value, ok := floatKeyword(schema, key)
+	return int(value), ok
+}
+
+func floatKeyword(schema map[string]any, key string) (float64, bool) {
+	switch typed := schema[key].(type) {
+	case json.Number:
+		value, err := typed.Float64()
+		return value, err == nil
+	case float64:
+		return typed, true
+	}
+	return 0, false
+}
+
+// equal compares two decoded JSON values, numbers by value
+func equal(a, b any) bool {
+	if numberA, ok := a.(json.Number); ok {
+		numberB, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		floatA, errA := numberA.Float64()
+		floatB, errB := numberB.Float64()
+		return errA == nil && errB == nil && floatA == floatB
+	}
+	switch typedA := a.(type) {
+	case []any:
+		typedB, ok := b.([]any)
+		if !ok || len(typedA) != len(typedB) {
+			return false
+		}
+		for i := range typedA {
+			if !equal(typedA[i], typedB[i]) {
+				return false
+			}
+		}
+		return true
+	case map[string]any:
+		typedB, ok := b.(map[string]any)
+		if !ok || len(typedA) != len(typedB) {
+			return false
+		}
+		for key, valueA := range typedA {
+			valueB, ok := typedB[key]
+			if !ok || !equal(valueA, valueB) {
+				return false
+			}
+		}
+		return true
+	}
+	return a == b
+}
+
+func compact(value any) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(data)
+}
+
+func escapePointer(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+}
@@ -0,0 +1,111 @@
+package jsonschema
+
+import (
+	"strings"
+	"testing"
+)
+
+const personSchema = `{
+	"type": "object",
+	"properties": {
+		"name": {"type": "string", "minLength": 1},
+		"age": {"type": "integer", "minimum": 0},
+		"email": {"type": ["string", "null"], "pattern": "^[^@]+@[^@]+$"},
+		"tags": {"type": "array", "items": {"$ref": "#/$defs/tag"}, "uniqueItems": true},
+		"role": {"enum": ["admin", "member"]}
+	},
+	"required": ["name", "age"],
+	"additionalProperties": false,
+	"$defs": {
+		"tag": {"type": "string", "maxLength": 5}
+	}
+}`
+
+func TestValidate(t *testing.T) {
+	schema, err := Compile([]byte(personSchema))
+	if err != nil {
+		t.Fatalf("compile: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		document string
+		errors   []string
+	}{
+		{
+			name:     "valid",
+			document: `{"name": "Ada", "age": 36, "email": null, "tags": ["math"], "role": "admin"}`,
+		},
+		{
+			name:     "integer written as float",
+			document: `{"name": "Ada", "age": 36.0}`,
+		},
+		{
+			name:     "missing required and wrong type",
+			document: `{"age": 1.5}`,
+			errors:   []string{`missing required property "name"`, "/age: expected integer, got number"},
+		},
+		{
+			name:     "unexpected property",
+			document: `{"name": "Ada", "age": 1, "extra": true}`,
+			errors:   []string{`unexpected property "extra"`},
+		},
+		{
+			name:     "nested constraints",
+			document: `{"name": "", "age": -1, "email": "nope", "tags": ["toolong", "a", "a"], "role": "owner"}`,
+			errors: []string{
+				"/age: value must be >= 0",
+				`/email: string does not match pattern "^[^@]+@[^@]+$"`,
+				"/name: string is shorter than 1 characters",
+				`/role: value must be one of ["admin","member"]`,
+				"/tags: items 1 and 2 are equal",
+				"/tags/0: string is longer than 5 characters",
+			},
+		},
+		{
+			name:     "invalid json",
+			document: `{"name": "Ada",`,
+			errors:   []string{"invalid JSON: unexpected EOF"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := schema.ValidateJSON([]byte(tt.document))
+			got := make([]string, 0, len(errs))
+			for _, e := range errs {
+				got = append(got, e.String())
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.errors, "\n") {
+				t.Fatalf("errors mismatch\ngot:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(tt.errors, "\n"))
+			}
+		})
+	}
+}
+
+func TestCompositions(t *testing.T) {
+	schema, err := Compile([]byte(`{
+		"oneOf": [
+			{"type": "object", "properties": {"kind": {"const": "circle"}, "radius": {"type": "number", "exclusiveMinimum": 0}}, "required": ["kind", "radius"]},
+			{"type": "object", "properties": {"kind": {"const": "square"}, "side": {"type": "number", "multipleOf": 0.5}}, "required": ["kind", "side"]}
+		]
+	}`))
+	if err != nil {
+		t.Fatalf("compile: %v", err)
+	}
+
+	if errs := schema.ValidateJSON([]byte(`{"kind": "square", "side": 2.5}`)); len(errs) != 0 {
+		t.Fatalf("expected valid document, got %v", errs)
+	}
+	if errs := schema.ValidateJSON([]byte(`{"kind": "circle", "radius": 0}`)); len(errs) != 1 {
+		t.Fatalf("expected one error, got %v", errs)
+	}
+}
+
+func TestCompileRejectsInvalidSchemas(t *testing.T) {
+	for _, raw := range []string{`[]`, `{"pattern": "("}`, `not json`} {
+		if _, err := Compile([]byte(raw)); err == nil {
+			t.Errorf("expected %s to be rejected", raw)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package metrics
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "indigo"
+
+// Outcomes of upstream requests and cron jobs
+const (
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+	OutcomeSkipped = "skipped"
+)
+
+var (
+	httpRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "Latency of the HTTP requests served by the gateway, by route template and status.",
+		Buckets:   []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120},
+	}, []string{"method", "route", "status"})
+
+	upstreamRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "upstream",
+		Name:      "request_duration_seconds",
+		Help:      "Latency of the chat completions sent to inference providers, until the last byte of the response.",
+		Buckets:   []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 20, 30, 60, 120},
+	}, []string{"provider", "model", "stream", "outcome"})
+
+	upstreamTimeToFirstToken = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "upstream",
+		Name:      "time_to_first_token_seconds",
+		Help:      "Time until the first chunk of a streamed chat completion arrives from the provider.",
+		Buckets:   []float64{0.05, 0.1, 0.25, 0.5, 1, 2, 4, 8, 15, 30},
+	}, []string{"provider", "model"})
+
+	upstreamTokens = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "upstream",
+		Name:      "tokens_total",
+		Help:      "Tokens of the chat completions, by provider, model and type (prompt or completion).",
+	}, []string{"provider", "model", "type"})
+
+	activeStreams = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "active_sse_streams",
+		Help:      "Server-sent event streams currently open towards clients.",
+	})
+
+	redisErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "redis",
+		Name:      "errors_total",
+		Help:      "Failed Redis commands, by command name. Cache misses are not errors.",
+	}, []string{"command"})
+
+	cronJobRuns = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "cron",
+		Name:      "job_runs_total",
+		Help:      "Cron job runs by outcome; skipped runs lost the lock to another replica.",
+	}, []string{"job", "outcome"})
+
+	cronJobDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "cron",
+		Name:      "job_duration_seconds",
+		Help:      "Duration of the cron job runs that acquired their lock.",
+		Buckets:   []float64{0.1, 0.5, 1, 5, 15, 30, 60, 300, 900, 1800},
+	}, []string{"job"})
+)
+
+// Handler serves the metrics in the Prometheus exposition format
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// ObserveHTTPRequest records a served request; route is the route template so paths with IDs share a series
+func ObserveHTTPRequest(method, route string, status int, duration time.Duration) {
+	if route == "" {
+		route = "unmatched"
+	}
+	httpRequestDuration.WithLabelValues(method, route, strconv.Itoa(status)).Observe(duration.Seconds())
+}
+
+// ObserveUpstreamRequest records a chat completion sent to a provider
+func ObserveUpstreamRequest(provider, model string, stream bool, err error, duration time.Duration) {
+	outcome := OutcomeSuccess
+	if err != nil {
+		outcome = OutcomeFailure
+	}
+	upstreamRequestDuration.WithLabelValues(provider, model, strconv.FormatBool(stream), outcome).Observe(duration.Seconds())
+}
+
+func ObserveTimeToFirstToken(provider, model string, duration time.Duration) {
+	upstreamTimeToFirstToken.WithLabelValues(provider, model).Observe(duration.Seconds())
+}
+
+func AddTokens(provider, model string, promptTokens, completionTokens int) {
+	if promptTokens > 0 {
+		upstreamTokens.WithLabelValues(provider, model, "prompt").Add(float64(promptTokens))
+	}
+	if completionTokens > 0 {
+		upstreamTokens.WithLabelValues(provider, model, "completion").Add(float64(completionTokens))
+	}
+}
+
+// StreamStarted counts an open SSE stream, the returned function closes it
+func StreamStarted() func() {
+	activeStreams.Inc()
+	return activeStreams.Dec
+}
+
+func IncRedisError(command string) {
+	redisErrors.WithLabelValues(command).Inc()
+}
+
+// ObserveCronJob records the outcome of a cron job run, the duration of skipped runs is not recorded
+func ObserveCronJob(job, outcome string, duration time.Duration) {
+	cronJobRuns.WithLabelValues(job, outcome).Inc()
+	if outcome != OutcomeSkipped {
+		cronJobDuration.WithLabelValues(job).Observe(duration.Seconds())
+	}
+}
+
+// RegisterDBStats exposes the connection pool statistics of a database
+func RegisterDBStats(db *sql.DB, name string) error {
+	err := prometheus.Register(collectors.NewDBStatsCollector(db, name))
+	var registered prometheus.AlreadyRegisteredError
+	if errors.As(err, &registered) {
+		return nil
+	}
+	return err
+}
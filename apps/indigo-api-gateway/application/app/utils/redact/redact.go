@@ -0,0 +1,198 @@
+package redact
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+
+	"menlo.ai/indigo-api-gateway/config/environment_variables"
+)
+
+const (
+	// Placeholder replaces the redacted values
+	Placeholder = "[REDACTED]"
+	// DefaultMaxBodyBytes is the size of the logged bodies unless LOG_MAX_BODY_BYTES is set
+	DefaultMaxBodyBytes = 4096
+	// maxParsedBodyBytes bounds the bodies parsed for redaction, larger ones are not logged
+	maxParsedBodyBytes = 1 << 20
+)
+
+// Headers and JSON fields that are always redacted, matched case-insensitively
+var (
+	defaultHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key", "Api-Key"}
+	defaultFields  = []string{
+		"password", "secret", "token", "access_token", "refresh_token", "id_token", "client_secret",
+		"api_key", "apikey", "key", "private_key", "authorization", "cookie",
+	}
+)
+
+// Policy decides what of a request or response reaches the logs
+type Policy struct {
+	headers map[string]struct{}
+	fields  map[string]struct{}
+	// MaxBodyBytes truncates the logged bodies, negative drops them
+	MaxBodyBytes int
+}
+
+// NewPolicy redacts the default headers and fields and the extra ones
+func NewPolicy(extraHeaders, extraFields []string, maxBodyBytes int) *Policy {
+	policy := &Policy{
+		headers:      make(map[string]struct{}),
+		fields:       make(map[string]struct{}),
+		MaxBodyBytes: maxBodyBytes,
+	}
+	if policy.MaxBodyBytes == 0 {
+		policy.MaxBodyBytes = DefaultMaxBodyBytes
+	}
+	for _, name := range append(append([]string{}, defaultHeaders...), extraHeaders...) {
+		if name = strings.TrimSpace(name); name != "" {
+			policy.headers[strings.ToLower(name)] = struct{}{}
+		}
+	}
+	for _, name := range append(append([]string{}, defaultFields...), extraFields...) {
+		if name = strings.TrimSpace(name); name != "" {
+			policy.fields[strings.ToLower(name)] = struct{}{}
+		}
+	}
+	return policy
+}
+
+// PolicyFromEnv builds the policy of LOG_REDACT_HEADERS, LOG_REDACT_FIELDS and LOG_MAX_BODY_BYTES
+func PolicyFromEnv() *Policy {
+	env := environment_variables.Current()
+	return NewPolicy(env.LOG_REDACT_HEADERS, env.LOG_REDACT_FIELDS, env.LOG_MAX_BODY_BYTES)
+}
+
+// Headers returns a copy of the headers with the values of the sensitive ones redacted
+func (p *Policy) Headers(headers http.Header) http.Header {
+	redacted := make(http.Header, len(headers))
+	for name, values := range headers {
+		if _, ok := p.headers[strings.ToLower(name)]; ok {
+			redacted[name] = []string{Placeholder}
+			continue
+		}
+		redacted[name] = values
+	}
+	return redacted
+}
+
+// Query redacts the values of the sensitive query parameters
+func (p *Policy) Query(rawQuery string) string {
+	if rawQuery == "" {
+		return ""
+	}
+	values, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return Placeholder
+	}
+	if !p.redactValues(values) {
+		return rawQuery
+	}
+	return values.Encode()
+}
+
+// Body returns the loggable form of a body: JSON and form bodies with their sensitive fields redacted,
+// truncated to MaxBodyBytes, and a size placeholder for other content types
+func (p *Policy) Body(body []byte, contentType string) string {
+	if len(body) == 0 || p.MaxBodyBytes < 0 {
+		return Omitted(len(body))
+	}
+	if len(body) > maxParsedBodyBytes {
+		return Omitted(len(body))
+	}
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case mediaType == "application/x-www-form-urlencoded":
+		values, err := url.ParseQuery(string(body))
+		if err != nil {
+			return Omitted(len(body))
+		}
+		p.redactValues(values)
+		return p.truncate(values.Encode())
+	case mediaType == "" || mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		var value any
+		decoder := json.NewDecoder(bytes.NewReader(body))
+		decoder.UseNumber()
+		if err := decoder.Decode(&value); err != nil {
+			return Omitted(len(body))
+		}
+		encoded, err := json.Marshal(p.redactJSON(value))
+		if err != nil {
+			return Omitted(len(body))
+		}
+		return p.truncate(string(encoded))
+	default:
+		return Omitted(len(body))
+	}
+}
+
+// Value logs a value as its redacted JSON encoding
+func (p *Policy) Value(value any) string {
+	if value == nil {
+		return ""
+	}
+	switch v := value.(type) {
+	case []byte:
+		return p.Body(v, "")
+	case string:
+		return p.Body([]byte(v), "")
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return Placeholder
+	}
+	return p.Body(encoded, "application/json")
+}
+
+// Omitted stands in for a body that is not logged
+func Omitted(size int) string {
+	if size == 0 {
+		return ""
+	}
+	return fmt.Sprintf("[omitted %d bytes]", size)
+}
+
+func (p *Policy) redactJSON(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, field := range v {
+			if _, ok := p.fields[strings.ToLower(key)]; ok && field != nil {
+				v[key] = Placeholder
+				continue
+			}
+			v[key] = p.redactJSON(field)
+		}
+	case []any:
+		for i, item := range v {
+			v[i] = p.redactJSON(item)
+		}
+	}
+	return value
+}
+
+func (p *Policy) redactValues(values url.Values) bool {
+	redacted := false
+	for key := range values {
+		if _, ok := p.fields[strings.ToLower(key)]; ok {
+			values[key] = []string{Placeholder}
+			redacted = true
+		}
+	}
+	return redacted
+}
+
+func (p *Policy) truncate(text string) string {
+	if len(text) <= p.MaxBodyBytes {
+		return text
+	}
+	cut := p.MaxBodyBytes
+	for cut > 0 && !utf8.RuneStart(text[cut]) {
+		cut--
+	}
+	return fmt.Sprintf("%s...[truncated %d bytes]", text[:cut], len(text)-cut)
+}
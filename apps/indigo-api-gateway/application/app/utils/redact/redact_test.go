@@ -0,0 +1,72 @@
+package redact
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestHeaders(t *testing.T) {
+	policy := NewPolicy([]string{"X-Internal-Token"}, nil, 0)
+	headers := http.Header{
+		"Authorization":    {"Bearer sk-secret"},
+		"Cookie":           {"session=1"},
+		"X-Internal-Token": {"t"},
+		"Content-Type":     {"application/json"},
+	}
+	redacted := policy.Headers(headers)
+	for _, name := range []string{"Authorization", "Cookie", "X-Internal-Token"} {
+		if redacted.Get(name) != Placeholder {
+			t.Errorf("%s = %q, want it redacted", name, redacted.Get(name))
+		}
+	}
+	if redacted.Get("Content-Type") != "application/json" || headers.Get("Authorization") != "Bearer sk-secret" {
+		t.Error("redaction changed a header it should keep or the original headers")
+	}
+}
+
+func TestBody(t *testing.T) {
+	policy := NewPolicy(nil, []string{"ssn"}, 0)
+	body := policy.Body([]byte(`{"user":{"password":"p","SSN":"123","name":"a"},"keys":[{"api_key":"k"}],"usage":{"total_tokens":3},"n":12345678901234567890}`), "application/json; charset=utf-8")
+	for _, secret := range []string{`"p"`, `"123"`, `"k"`} {
+		if strings.Contains(body, secret) {
+			t.Errorf("%s was logged: %s", secret, body)
+		}
+	}
+	for _, kept := range []string{`"name":"a"`, `"total_tokens":3`, `12345678901234567890`} {
+		if !strings.Contains(body, kept) {
+			t.Errorf("%s was not kept: %s", kept, body)
+		}
+	}
+
+	if got := policy.Body([]byte("grant_type=password&client_secret=s"), "application/x-www-form-urlencoded"); strings.Contains(got, "=s") {
+		t.Errorf("form secret was logged: %s", got)
+	}
+	if got := policy.Body([]byte("binary"), "multipart/form-data; boundary=x"); got != "[omitted 6 bytes]" {
+		t.Errorf("multipart body = %q", got)
+	}
+	if got := policy.Body([]byte("{not json"), "application/json"); got != "[omitted 9 bytes]" {
+		t.Errorf("invalid JSON body = %q", got)
+	}
+}
+
+func TestBodyLimits(t *testing.T) {
+	long := `{"text":"` + strings.Repeat("é", 50) + `"}`
+	got := NewPolicy(nil, nil, 20).Body([]byte(long), "application/json")
+	if !strings.HasPrefix(got, `{"text":"éééé`) || !strings.Contains(got, "...[truncated") {
+		t.Errorf("truncated body = %q", got)
+	}
+	if got := NewPolicy(nil, nil, -1).Body([]byte(`{"a":1}`), "application/json"); got != "[omitted 7 bytes]" {
+		t.Errorf("body logged with a negative limit: %q", got)
+	}
+}
+
+func TestQuery(t *testing.T) {
+	policy := NewPolicy(nil, nil, 0)
+	if got := policy.Query("q=go&api_key=secret"); strings.Contains(got, "secret") || !strings.Contains(got, "q=go") {
+		t.Errorf("query = %q", got)
+	}
+	if got := policy.Query("limit=10&order=asc"); got != "limit=10&order=asc" {
+		t.Errorf("query without secrets was rewritten: %q", got)
+	}
+}
@@ -0,0 +1,83 @@
+package tokenizer
+
+import "unicode/utf8"
+
+// SplitText cuts text into chunks of at most maxTokens cl100k_base tokens, consecutive chunks share overlapTokens tokens.
+// A token can hold part of a multi-byte character, chunk edges move back to the previous token boundary between
+// characters so characters are never split nor lost: a chunk starting inside a character also holds its first tokens.
+func SplitText(text string, maxTokens int, overlapTokens int) []string {
+	if text == "" || maxTokens <= 0 {
+		return nil
+	}
+	if overlapTokens < 0 || overlapTokens >= maxTokens {
+		overlapTokens = 0
+	}
+
+	encoding, err := loadEncoding(EncodingCL100K)
+	if err != nil {
+		return splitRunes(text, maxTokens*4, overlapTokens*4)
+	}
+
+	tokens := encoding.EncodeOrdinary(text)
+	// offsets[i] is the byte offset of token i, offsets[len(tokens)] the end of the text
+	offsets := make([]int, len(tokens)+1)
+	for i, token := range tokens {
+		offsets[i+1] = offsets[i] + len(encoding.Decode([]int{token}))
+	}
+	if offsets[len(tokens)] != len(text) {
+		return splitRunes(text, maxTokens*4, overlapTokens*4)
+	}
+	between := func(i int) bool {
+		return offsets[i] == len(text) || utf8.RuneStart(text[offsets[i]])
+	}
+
+	step := maxTokens - overlapTokens
+	var chunks []string
+	lastTo := 0
+	for start := 0; start < len(tokens); start += step {
+		end := start + maxTokens
+		if end > len(tokens) {
+			end = len(tokens)
+		}
+		from := start
+		for !between(from) {
+			from--
+		}
+		to := end
+		for to > from && !between(to) {
+			to--
+		}
+		if to == from {
+			// a single character spans the whole chunk, keep it whole
+			for !between(to) {
+				to++
+			}
+		}
+		if to > lastTo {
+			chunks = append(chunks, text[offsets[from]:offsets[to]])
+			lastTo = to
+		}
+		if end == len(tokens) {
+			break
+		}
+	}
+	return chunks
+}
+
+// splitRunes is the heuristic fallback, sizes are expressed in characters
+func splitRunes(text string, maxRunes int, overlapRunes int) []string {
+	runes := []rune(text)
+	step := maxRunes - overlapRunes
+	var chunks []string
+	for start := 0; start < len(runes); start += step {
+		end := start + maxRunes
+		if end > len(runes) {
+			end = len(runes)
+		}
+		chunks = append(chunks, string(runes[start:end]))
+		if end == len(runes) {
+			break
+		}
+	}
+	return chunks
+}
@@ -0,0 +1,101 @@
+package tokenizer
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSplitTextChunkBoundaries(t *testing.T) {
+	encoding, err := loadEncoding(EncodingCL100K)
+	if err != nil {
+		t.Fatalf("failed to load encoding: %v", err)
+	}
+	text := strings.Repeat("The quick brown fox jumps over the lazy dog. ", 20)
+	tokens := encoding.EncodeOrdinary(text)
+
+	chunks := SplitText(text, 30, 10)
+	if len(chunks) < 2 {
+		t.Fatalf("expected several chunks, got %d", len(chunks))
+	}
+	// chunks start every maxTokens-overlapTokens tokens and the last one ends with the text
+	expected := (len(tokens)-30+19)/20 + 1
+	if len(chunks) != expected {
+		t.Fatalf("expected %d chunks for %d tokens, got %d", expected, len(tokens), len(chunks))
+	}
+	for i, chunk := range chunks {
+		if count := len(encoding.EncodeOrdinary(chunk)); count > 30 {
+			t.Fatalf("chunk %d has %d tokens", i, count)
+		}
+	}
+	if !strings.HasSuffix(text, chunks[len(chunks)-1]) {
+		t.Fatalf("expected the last chunk to end the text, got %q", chunks[len(chunks)-1])
+	}
+}
+
+func TestSplitTextOverlap(t *testing.T) {
+	encoding, err := loadEncoding(EncodingCL100K)
+	if err != nil {
+		t.Fatalf("failed to load encoding: %v", err)
+	}
+	text := strings.Repeat("alpha beta gamma delta epsilon zeta eta theta ", 10)
+	tokens := encoding.EncodeOrdinary(text)
+
+	chunks := SplitText(text, 12, 4)
+	for i := 0; i+1 < len(chunks); i++ {
+		// chunk i+1 starts with the last overlapTokens tokens of chunk i
+		shared := encoding.Decode(tokens[(i+1)*8 : (i+1)*8+4])
+		if !strings.HasSuffix(chunks[i], shared) || !strings.HasPrefix(chunks[i+1], shared) {
+			t.Fatalf("chunks %d and %d do not share %q: %q, %q", i, i+1, shared, chunks[i], chunks[i+1])
+		}
+	}
+
+	// an overlap as large as the chunk falls back to no overlap
+	joined := strings.Join(SplitText(text, 12, 12), "")
+	if joined != text {
+		t.Fatalf("expected chunks without overlap to rebuild the text, got %q", joined)
+	}
+}
+
+func TestSplitTextEdgeCases(t *testing.T) {
+	if chunks := SplitText("", 10, 2); chunks != nil {
+		t.Fatalf("expected no chunks for empty text, got %v", chunks)
+	}
+	if chunks := SplitText("hello", 0, 0); chunks != nil {
+		t.Fatalf("expected no chunks without a size, got %v", chunks)
+	}
+	if chunks := SplitText("hello world", 100, 10); len(chunks) != 1 || chunks[0] != "hello world" {
+		t.Fatalf("expected a single chunk for short text, got %v", chunks)
+	}
+}
+
+func TestSplitRunesKeepsCharacters(t *testing.T) {
+	text := "日本語のテキストを分割する"
+	chunks := splitRunes(text, 4, 1)
+	for i, chunk := range chunks {
+		if !utf8.ValidString(chunk) || utf8.RuneCountInString(chunk) > 4 {
+			t.Fatalf("chunk %d is %q", i, chunk)
+		}
+	}
+	if chunks[0] != "日本語の" || chunks[1] != "のテキス" {
+		t.Fatalf("expected chunks to overlap by one character, got %v", chunks)
+	}
+}
+
+func TestSplitTextKeepsMultiByteCharacters(t *testing.T) {
+	text := strings.Repeat("日本語のテキストを分割する。😀 ", 10)
+	for _, chunk := range SplitText(text, 5, 2) {
+		if !utf8.ValidString(chunk) {
+			t.Fatalf("chunk %q splits a character", chunk)
+		}
+	}
+}
+
+func TestSplitTextWithoutOverlapKeepsEveryCharacter(t *testing.T) {
+	text := strings.Repeat("日本語のテキストを分割する。😀 ", 10)
+	for _, size := range []int{1, 2, 3, 5, 7} {
+		if joined := strings.Join(SplitText(text, size, 0), ""); joined != text {
+			t.Fatalf("size %d: expected the chunks to rebuild the text, got %q", size, joined)
+		}
+	}
+}
@@ -0,0 +1,242 @@
+package tokenizer
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pkoukk/tiktoken-go"
+	tiktoken_loader "github.com/pkoukk/tiktoken-go-loader"
+	openai "github.com/sashabaranov/go-openai"
+)
+
+const (
+	EncodingO200K  = "o200k_base"
+	EncodingCL100K = "cl100k_base"
+
+	// Per-message framing overhead used by OpenAI chat models
+	tokensPerMessage = 3
+	tokensPerName    = 1
+	// Every reply is primed with <|start|>assistant<|message|>
+	TokensPerReply = 3
+	// Flat cost for an image part; matches a low-detail image on OpenAI models
+	tokensPerImage = 85
+)
+
+func init() {
+	// Use the embedded BPE ranks so counting never reaches out to the network
+	tiktoken.SetBpeLoader(tiktoken_loader.NewOfflineLoader())
+}
+
+// Counter counts tokens for a specific tokenizer family
+type Counter interface {
+	// Name returns the encoding or heuristic name backing the counter
+	Name() string
+	// CountText returns the number of tokens in a plain text string
+	CountText(text string) int
+	// CountMessages returns the prompt size of a chat message list including framing overhead
+	CountMessages(messages []openai.ChatCompletionMessage) int
+}
+
+// tokenizerEncodings maps ModelCatalog.Architecture.Tokenizer values to the closest BPE encoding.
+// Families without a public BPE are approximated with cl100k_base, which tracks them within a few percent.
+var tokenizerEncodings = map[string]string{
+	"gpt":      EncodingO200K,
+	"o200k":    EncodingO200K,
+	"cl100k":   EncodingCL100K,
+	"claude":   EncodingCL100K,
+	"gemini":   EncodingCL100K,
+	"llama2":   EncodingCL100K,
+	"llama3":   EncodingCL100K,
+	"llama4":   EncodingCL100K,
+	"mistral":  EncodingCL100K,
+	"qwen":     EncodingCL100K,
+	"qwen3":    EncodingCL100K,
+	"deepseek": EncodingCL100K,
+	"grok":     EncodingCL100K,
+	"cohere":   EncodingCL100K,
+	"nova":     EncodingCL100K,
+	"router":   EncodingCL100K,
+	"other":    EncodingCL100K,
+}
+
+var (
+	encodingCache = make(map[string]*tiktoken.Tiktoken)
+	encodingMutex sync.Mutex
+)
+
+// ForModel returns a counter for the given catalog tokenizer name and model key.
+// GPT models are resolved by model name first so older models keep their historical encoding.
+func ForModel(tokenizerName string, modelKey string) Counter {
+	name := strings.ToLower(strings.TrimSpace(tokenizerName))
+	encodingName, ok := tokenizerEncodings[name]
+	if !ok {
+		encodingName = EncodingCL100K
+	}
+	if name == "gpt" || name == "" {
+		if modelEncoding, found := encodingForModel(stripVendor(modelKey)); found {
+			encodingName = modelEncoding
+		}
+	}
+
+	encoding, err := loadEncoding(encodingName)
+	if err != nil {
+		return HeuristicCounter{}
+	}
+	return &bpeCounter{name: encodingName, encoding: encoding}
+}
+
+// Default returns the counter used when nothing is known about the model
+func Default() Counter {
+	return ForModel("", "")
+}
+
+func loadEncoding(encodingName string) (*tiktoken.Tiktoken, error) {
+	encodingMutex.Lock()
+	defer encodingMutex.Unlock()
+	if encoding, ok := encodingCache[encodingName]; ok {
+		return encoding, nil
+	}
+	encoding, err := tiktoken.GetEncoding(encodingName)
+	if err != nil {
+		return nil, err
+	}
+	encodingCache[encodingName] = encoding
+	return encoding, nil
+}
+
+func encodingForModel(modelKey string) (string, bool) {
+	if modelKey == "" {
+		return "", false
+	}
+	if encodingName, ok := tiktoken.MODEL_TO_ENCODING[modelKey]; ok {
+		return encodingName, true
+	}
+	for _, prefix := range modelPrefixes() {
+		if strings.HasPrefix(modelKey, prefix) {
+			return tiktoken.MODEL_PREFIX_TO_ENCODING[prefix], true
+		}
+	}
+	return "", false
+}
+
+// modelPrefixes are the model name prefixes of tiktoken, longest first so the most specific one matches
+var modelPrefixes = sync.OnceValue(func() []string {
+	return sortedPrefixes(tiktoken.MODEL_PREFIX_TO_ENCODING)
+})
+
+func sortedPrefixes(prefixToEncoding map[string]string) []string {
+	prefixes := make([]string, 0, len(prefixToEncoding))
+	for prefix := range prefixToEncoding {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Slice(prefixes, func(i, j int) bool {
+		if len(prefixes[i]) != len(prefixes[j]) {
+			return len(prefixes[i]) > len(prefixes[j])
+		}
+		return prefixes[i] < prefixes[j]
+	})
+	return prefixes
+}
+
+// stripVendor removes an OpenRouter style vendor prefix such as "openai/gpt-4o"
+func stripVendor(modelKey string) string {
+	if idx := strings.LastIndex(modelKey, "/"); idx >= 0 {
+		return modelKey[idx+1:]
+	}
+	return modelKey
+}
+
+type bpeCounter struct {
+	name     string
+	encoding *tiktoken.Tiktoken
+}
+
+func (c *bpeCounter) Name() string {
+	return c.name
+}
+
+func (c *bpeCounter) CountText(text string) int {
+	if text == "" {
+		return 0
+	}
+	return len(c.encoding.EncodeOrdinary(text))
+}
+
+func (c *bpeCounter) CountMessages(messages []openai.ChatCompletionMessage) int {
+	return countMessages(c, messages)
+}
+
+// HeuristicCounter approximates tokens as four characters each; used when no encoding can be loaded
+type HeuristicCounter struct{}
+
+func (HeuristicCounter) Name() string {
+	return "heuristic"
+}
+
+func (HeuristicCounter) CountText(text string) int {
+	runes := len([]rune(text))
+	return (runes + 3) / 4
+}
+
+func (c HeuristicCounter) CountMessages(messages []openai.ChatCompletionMessage) int {
+	return countMessages(c, messages)
+}
+
+// CountCompletion returns the tokens generated for an assistant message, without prompt framing
+func CountCompletion(counter Counter, message openai.ChatCompletionMessage) int {
+	tokens := counter.CountText(message.Content)
+	tokens += counter.CountText(message.ReasoningContent)
+	if message.FunctionCall != nil {
+		tokens += counter.CountText(message.FunctionCall.Name)
+		tokens += counter.CountText(message.FunctionCall.Arguments)
+	}
+	for _, toolCall := range message.ToolCalls {
+		tokens += counter.CountText(toolCall.Function.Name)
+		tokens += counter.CountText(toolCall.Function.Arguments)
+	}
+	return tokens
+}
+
+// CountMessage returns the token size of a single message including its framing overhead
+func CountMessage(counter Counter, message openai.ChatCompletionMessage) int {
+	tokens := tokensPerMessage
+	tokens += counter.CountText(message.Role)
+	tokens += counter.CountText(message.Content)
+	tokens += counter.CountText(message.ReasoningContent)
+	for _, part := range message.MultiContent {
+		switch part.Type {
+		case openai.ChatMessagePartTypeText:
+			tokens += counter.CountText(part.Text)
+		case openai.ChatMessagePartTypeImageURL:
+			tokens += tokensPerImage
+		}
+	}
+	if message.Name != "" {
+		tokens += counter.CountText(message.Name) + tokensPerName
+	}
+	if message.FunctionCall != nil {
+		tokens += counter.CountText(message.FunctionCall.Name)
+		tokens += counter.CountText(message.FunctionCall.Arguments)
+	}
+	for _, toolCall := range message.ToolCalls {
+		tokens += counter.CountText(toolCall.ID)
+		tokens += counter.CountText(toolCall.Function.Name)
+		tokens += counter.CountText(toolCall.Function.Arguments)
+	}
+	if message.ToolCallID != "" {
+		tokens += counter.CountText(message.ToolCallID)
+	}
+	return tokens
+}
+
+func countMessages(counter Counter, messages []openai.ChatCompletionMessage) int {
+	if len(messages) == 0 {
+		return 0
+	}
+	total := TokensPerReply
+	for _, message := range messages {
+		total += CountMessage(counter, message)
+	}
+	return total
+}
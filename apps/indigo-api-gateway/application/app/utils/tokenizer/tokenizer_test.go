@@ -0,0 +1,87 @@
+package tokenizer
+
+import (
+	"testing"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+func TestForModelResolvesEncodings(t *testing.T) {
+	cases := []struct {
+		tokenizer string
+		model     string
+		expected  string
+	}{
+		{tokenizer: "gpt", model: "openai/gpt-4o", expected: EncodingO200K},
+		{tokenizer: "gpt", model: "gpt-4", expected: EncodingCL100K},
+		{tokenizer: "gpt", model: "openai/gpt-4o-2024-11-20", expected: EncodingO200K},
+		{tokenizer: "gpt", model: "gpt-4-0613", expected: EncodingCL100K},
+		{tokenizer: "Llama3", model: "meta-llama/llama-3-8b", expected: EncodingCL100K},
+		{tokenizer: "unknown-family", model: "", expected: EncodingCL100K},
+	}
+	for _, c := range cases {
+		if name := ForModel(c.tokenizer, c.model).Name(); name != c.expected {
+			t.Fatalf("ForModel(%q, %q) = %s, expected %s", c.tokenizer, c.model, name, c.expected)
+		}
+	}
+}
+
+func TestSortedPrefixesMatchLongestFirst(t *testing.T) {
+	prefixes := sortedPrefixes(map[string]string{"gpt-4": EncodingCL100K, "gpt-4o-mini-": EncodingCL100K, "gpt-4o-": EncodingO200K, "o1-": EncodingO200K})
+	expected := []string{"gpt-4o-mini-", "gpt-4o-", "gpt-4", "o1-"}
+	for i, prefix := range expected {
+		if prefixes[i] != prefix {
+			t.Fatalf("expected %v, got %v", expected, prefixes)
+		}
+	}
+}
+
+func TestCountText(t *testing.T) {
+	counter := ForModel("cl100k", "")
+	if got := counter.CountText("hello world"); got != 2 {
+		t.Fatalf("expected 2 tokens, got %d", got)
+	}
+	if got := counter.CountText(""); got != 0 {
+		t.Fatalf("expected no tokens for empty text, got %d", got)
+	}
+	if got := (HeuristicCounter{}).CountText("héllo wörld!"); got != 3 {
+		t.Fatalf("expected the heuristic to count 12 runes as 3 tokens, got %d", got)
+	}
+}
+
+func TestCountMessagesAddsFraming(t *testing.T) {
+	counter := HeuristicCounter{}
+	if got := counter.CountMessages(nil); got != 0 {
+		t.Fatalf("expected an empty prompt to count 0, got %d", got)
+	}
+	message := openai.ChatCompletionMessage{Role: "user", Content: "abcdefgh"}
+	// framing 3 + role 1 + content 2
+	if got := CountMessage(counter, message); got != 6 {
+		t.Fatalf("expected 6 tokens for the message, got %d", got)
+	}
+	if got := counter.CountMessages([]openai.ChatCompletionMessage{message, message}); got != 2*6+TokensPerReply {
+		t.Fatalf("expected two messages and the reply priming, got %d", got)
+	}
+
+	named := message
+	named.Name = "abcd"
+	if got := CountMessage(counter, named); got != 6+1+tokensPerName {
+		t.Fatalf("expected the name and its overhead to be counted, got %d", got)
+	}
+	image := openai.ChatCompletionMessage{Role: "user", MultiContent: []openai.ChatMessagePart{
+		{Type: openai.ChatMessagePartTypeText, Text: "abcd"},
+		{Type: openai.ChatMessagePartTypeImageURL, ImageURL: &openai.ChatMessageImageURL{URL: "https://example.com/a.png"}},
+	}}
+	if got := CountMessage(counter, image); got != 3+1+1+tokensPerImage {
+		t.Fatalf("expected the text part and a flat image cost, got %d", got)
+	}
+	toolCall := openai.ChatCompletionMessage{Role: "assistant", ToolCalls: []openai.ToolCall{
+		{ID: "abcd", Function: openai.FunctionCall{Name: "abcd", Arguments: "abcdefgh"}},
+	}}
+	if got := CountMessage(counter, toolCall); got != 3+3+1+1+2 {
+		t.Fatalf("expected the tool call id, name and arguments to be counted, got %d", got)
+	}
+	if got := CountCompletion(counter, toolCall); got != 1+2 {
+		t.Fatalf("expected the completion to count the tool name and arguments only, got %d", got)
+	}
+}
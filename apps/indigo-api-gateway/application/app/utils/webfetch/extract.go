@@ -0,0 +1,279 @@
+package webfetch
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+const (
+	// minCandidateText is the text length below which a paragraph does not score
+	minCandidateText = 25
+	// minMainText is the text length an article or main element needs to be taken as is
+	minMainText = 250
+)
+
+var (
+	// droppedElements never hold the main content
+	droppedElements = map[atom.Atom]bool{
+		atom.Script: true, atom.Style: true, atom.Noscript: true, atom.Template: true,
+		atom.Svg: true, atom.Iframe: true, atom.Object: true, atom.Embed: true, atom.Canvas: true,
+		atom.Form: true, atom.Button: true, atom.Input: true, atom.Select: true, atom.Textarea: true,
+		atom.Nav: true, atom.Footer: true, atom.Aside: true, atom.Dialog: true, atom.Menu: true,
+	}
+	unlikelyCandidate = regexp.MustCompile(`(?i)banner|breadcrumb|combx|comment|community|cookie|disqus|footer|header|menu|modal|nav|popup|promo|related|remark|replies|rss|share|shoutbox|sidebar|skip|social|sponsor|subscribe|newsletter|advert|\bads?\b`)
+	likelyCandidate   = regexp.MustCompile(`(?i)article|body|column|content|main|post|entry|story|text`)
+	scoredElements    = map[atom.Atom]bool{atom.P: true, atom.Pre: true, atom.Td: true, atom.Blockquote: true, atom.Li: true}
+)
+
+type htmlDocument struct {
+	title       string
+	description string
+	text        string
+	markdown    string
+}
+
+// extractHTML finds the main content of a page readability-style and renders it as text and Markdown
+func extractHTML(reader io.Reader, base *url.URL) (*htmlDocument, error) {
+	root, err := html.Parse(reader)
+	if err != nil {
+		return nil, fmt.Errorf("webfetch: failed to parse HTML: %w", err)
+	}
+
+	doc := &htmlDocument{}
+	readMetadata(root, doc)
+	pruneNodes(root)
+
+	content := mainContent(root)
+	if content == nil {
+		return doc, nil
+	}
+	doc.markdown = render(content, base, true)
+	doc.text = render(content, base, false)
+	return doc, nil
+}
+
+func readMetadata(root *html.Node, doc *htmlDocument) {
+	var ogTitle, ogDescription string
+	walk(root, func(n *html.Node) bool {
+		switch n.DataAtom {
+		case atom.Title:
+			if doc.title == "" {
+				doc.title = collapseSpace(textContent(n))
+			}
+		case atom.Meta:
+			name := strings.ToLower(attr(n, "name"))
+			if name == "" {
+				name = strings.ToLower(attr(n, "property"))
+			}
+			value := collapseSpace(attr(n, "content"))
+			switch name {
+			case "description":
+				doc.description = value
+			case "og:title":
+				ogTitle = value
+			case "og:description":
+				ogDescription = value
+			}
+		case atom.Body:
+			return false
+		}
+		return true
+	})
+	if doc.title == "" {
+		doc.title = ogTitle
+	}
+	if doc.description == "" {
+		doc.description = ogDescription
+	}
+}
+
+// pruneNodes drops comments, boilerplate elements and the blocks whose class or id marks them as page chrome
+func pruneNodes(n *html.Node) {
+	for child := n.FirstChild; child != nil; {
+		next := child.NextSibling
+		if shouldPrune(child) {
+			n.RemoveChild(child)
+		} else {
+			pruneNodes(child)
+		}
+		child = next
+	}
+}
+
+func shouldPrune(n *html.Node) bool {
+	switch n.Type {
+	case html.CommentNode, html.DoctypeNode:
+		return true
+	case html.ElementNode:
+	default:
+		return false
+	}
+	if droppedElements[n.DataAtom] || hasAttr(n, "hidden") || attr(n, "aria-hidden") == "true" {
+		return true
+	}
+	switch n.DataAtom {
+	case atom.Html, atom.Body, atom.Main, atom.Article, atom.A, atom.Table, atom.Tbody, atom.Tr, atom.Td, atom.Th, atom.Pre, atom.Code:
+		return false
+	case atom.Header:
+		// Headers inside the article hold its title
+		return !hasAncestor(n, atom.Article, atom.Main)
+	}
+	if role := attr(n, "role"); role == "navigation" || role == "banner" || role == "contentinfo" || role == "complementary" {
+		return true
+	}
+	signature := attr(n, "class") + " " + attr(n, "id")
+	return unlikelyCandidate.MatchString(signature) && !likelyCandidate.MatchString(signature)
+}
+
+// mainContent prefers the largest article or main element and falls back to scoring the paragraphs
+func mainContent(root *html.Node) *html.Node {
+	var body, best *html.Node
+	bestLength := 0
+	walk(root, func(n *html.Node) bool {
+		switch {
+		case n.DataAtom == atom.Body:
+			body = n
+		case n.DataAtom == atom.Article || n.DataAtom == atom.Main || attr(n, "role") == "main":
+			if length := textLength(n); length > bestLength {
+				best, bestLength = n, length
+			}
+		}
+		return true
+	})
+	if best != nil && bestLength >= minMainText {
+		return best
+	}
+
+	if candidate := scoreCandidates(root); candidate != nil {
+		return candidate
+	}
+	if body != nil {
+		return body
+	}
+	return root
+}
+
+// scoreCandidates gives each paragraph a score by length and commas, credits its parent and half to its
+// grandparent, and picks the ancestor with the best score once link-heavy blocks are discounted
+func scoreCandidates(root *html.Node) *html.Node {
+	scores := map[*html.Node]float64{}
+	walk(root, func(n *html.Node) bool {
+		if !scoredElements[n.DataAtom] {
+			return true
+		}
+		text := collapseSpace(textContent(n))
+		length := utf8.RuneCountInString(text)
+		if length < minCandidateText {
+			return false
+		}
+		score := 1 + float64(strings.Count(text, ",")) + min(float64(length)/100, 3)
+		if parent := n.Parent; parent != nil {
+			scores[parent] += score
+			if grandparent := parent.Parent; grandparent != nil {
+				scores[grandparent] += score / 2
+			}
+		}
+		return false
+	})
+
+	var best *html.Node
+	bestScore := 0.0
+	for node, score := range scores {
+		score *= 1 - linkDensity(node)
+		if signature := attr(node, "class") + " " + attr(node, "id"); likelyCandidate.MatchString(signature) {
+			score *= 1.25
+		}
+		if score > bestScore {
+			best, bestScore = node, score
+		}
+	}
+	return best
+}
+
+func linkDensity(n *html.Node) float64 {
+	total := textLength(n)
+	if total == 0 {
+		return 0
+	}
+	links := 0
+	walk(n, func(child *html.Node) bool {
+		if child.DataAtom == atom.A {
+			links += textLength(child)
+			return false
+		}
+		return true
+	})
+	return float64(links) / float64(total)
+}
+
+// walk visits the nodes depth first, visit returns false to skip the children of a node
+func walk(n *html.Node, visit func(*html.Node) bool) {
+	if n.Type == html.ElementNode || n.Type == html.DocumentNode {
+		if n.Type == html.ElementNode && !visit(n) {
+			return
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child, visit)
+		}
+	}
+}
+
+func textContent(n *html.Node) string {
+	var builder strings.Builder
+	var collect func(*html.Node)
+	collect = func(node *html.Node) {
+		if node.Type == html.TextNode {
+			builder.WriteString(node.Data)
+			return
+		}
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			collect(child)
+		}
+	}
+	collect(n)
+	return builder.String()
+}
+
+func textLength(n *html.Node) int {
+	return utf8.RuneCountInString(collapseSpace(textContent(n)))
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func hasAttr(n *html.Node, key string) bool {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+func hasAncestor(n *html.Node, atoms ...atom.Atom) bool {
+	for parent := n.Parent; parent != nil; parent = parent.Parent {
+		for _, a := range atoms {
+			if parent.DataAtom == a {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func collapseSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
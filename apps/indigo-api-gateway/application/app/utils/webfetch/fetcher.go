@@ -0,0 +1,239 @@
+package webfetch
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html/charset"
+)
+
+const (
+	DefaultTimeout      = 15 * time.Second
+	DefaultMaxBytes     = 5 << 20
+	DefaultMaxRedirects = 5
+	// UserAgentToken is the product token matched against the user-agent lines of robots.txt
+	UserAgentToken   = "IndigoFetcher"
+	DefaultUserAgent = "Mozilla/5.0 (compatible; " + UserAgentToken + "/1.0)"
+)
+
+var (
+	ErrBlockedAddress         = errors.New("webfetch: address is not publicly routable")
+	ErrDisallowedByRobots     = errors.New("webfetch: disallowed by robots.txt")
+	ErrTooManyRedirects       = errors.New("webfetch: too many redirects")
+	ErrUnsupportedScheme      = errors.New("webfetch: only http and https URLs are supported")
+	ErrUnsupportedContentType = errors.New("webfetch: unsupported content type")
+)
+
+// Config bounds what a Fetcher reads
+type Config struct {
+	Timeout      time.Duration
+	MaxBytes     int64
+	MaxRedirects int
+	UserAgent    string
+	// IgnoreRobots skips the robots.txt check
+	IgnoreRobots bool
+	// AllowPrivateNetworks disables the SSRF guard, only for tests and trusted deployments
+	AllowPrivateNetworks bool
+}
+
+// Page is a fetched document reduced to its main content
+type Page struct {
+	// URL is the final URL after redirects
+	URL         string
+	StatusCode  int
+	ContentType string
+	Title       string
+	Description string
+	Text        string
+	Markdown    string
+	// Truncated is set when the body was cut at MaxBytes
+	Truncated bool
+}
+
+// Fetcher downloads webpages and PDFs without reaching private networks
+type Fetcher struct {
+	config Config
+	client *http.Client
+	robots *robotsCache
+}
+
+func NewFetcher(config Config) *Fetcher {
+	if config.Timeout <= 0 {
+		config.Timeout = DefaultTimeout
+	}
+	if config.MaxBytes <= 0 {
+		config.MaxBytes = DefaultMaxBytes
+	}
+	if config.MaxRedirects <= 0 {
+		config.MaxRedirects = DefaultMaxRedirects
+	}
+	if config.UserAgent == "" {
+		config.UserAgent = DefaultUserAgent
+	}
+
+	dialer := &net.Dialer{Timeout: config.Timeout, KeepAlive: 30 * time.Second}
+	if !config.AllowPrivateNetworks {
+		// The guard runs on the resolved address, so DNS rebinding cannot bypass it
+		dialer.Control = GuardDial
+	}
+	transport := &http.Transport{
+		// Proxies from the environment would hide the destination from the guard
+		Proxy:                 nil,
+		DialContext:           dialer.DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          50,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ResponseHeaderTimeout: config.Timeout,
+	}
+
+	f := &Fetcher{config: config, robots: newRobotsCache()}
+	f.client = &http.Client{
+		Transport: transport,
+		Timeout:   config.Timeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) > config.MaxRedirects {
+				return ErrTooManyRedirects
+			}
+			return f.checkURL(req.Context(), req.URL)
+		},
+	}
+	return f
+}
+
+// Fetch downloads rawURL and extracts its main content as text and Markdown
+func (f *Fetcher) Fetch(ctx context.Context, rawURL string) (*Page, error) {
+	target, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return nil, fmt.Errorf("webfetch: invalid URL: %w", err)
+	}
+	if err := f.checkURL(ctx, target); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", f.config.UserAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/pdf;q=0.9,text/plain;q=0.8,*/*;q=0.5")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, unwrapURLError(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusBadRequest {
+		return nil, fmt.Errorf("webfetch: %s returned %s", resp.Request.URL, resp.Status)
+	}
+
+	body, truncated, err := readLimited(resp.Body, f.config.MaxBytes)
+	if err != nil {
+		return nil, err
+	}
+	page := &Page{
+		URL:        resp.Request.URL.String(),
+		StatusCode: resp.StatusCode,
+		Truncated:  truncated,
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if mediaType == "" || mediaType == "application/octet-stream" {
+		contentType = http.DetectContentType(body)
+		mediaType, _, _ = mime.ParseMediaType(contentType)
+	}
+	page.ContentType = mediaType
+
+	switch {
+	case mediaType == "text/html" || mediaType == "application/xhtml+xml":
+		reader, err := charset.NewReader(bytes.NewReader(body), contentType)
+		if err != nil {
+			reader = bytes.NewReader(body)
+		}
+		doc, err := extractHTML(reader, resp.Request.URL)
+		if err != nil {
+			return nil, err
+		}
+		page.Title = doc.title
+		page.Description = doc.description
+		page.Text = doc.text
+		page.Markdown = doc.markdown
+	case mediaType == "application/pdf":
+		doc, err := extractPDF(body)
+		if err != nil {
+			return nil, err
+		}
+		page.Title = doc.title
+		page.Text = doc.text
+		page.Markdown = doc.text
+	case strings.HasPrefix(mediaType, "text/") || mediaType == "application/json" || strings.HasSuffix(mediaType, "+json") ||
+		mediaType == "application/xml" || strings.HasSuffix(mediaType, "+xml"):
+		reader, err := charset.NewReader(bytes.NewReader(body), contentType)
+		if err != nil {
+			reader = bytes.NewReader(body)
+		}
+		text, err := io.ReadAll(reader)
+		if err != nil {
+			return nil, err
+		}
+		page.Text = strings.TrimSpace(string(text))
+		page.Markdown = page.Text
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedContentType, mediaType)
+	}
+	return page, nil
+}
+
+// checkURL rejects URLs outside http(s), hosts that are not publicly routable and paths robots.txt disallows
+func (f *Fetcher) checkURL(ctx context.Context, target *url.URL) error {
+	if target.Scheme != "http" && target.Scheme != "https" {
+		return ErrUnsupportedScheme
+	}
+	host := target.Hostname()
+	if host == "" {
+		return fmt.Errorf("webfetch: URL has no host")
+	}
+	if !f.config.AllowPrivateNetworks && IsBlockedHost(host) {
+		return ErrBlockedAddress
+	}
+	if f.config.IgnoreRobots {
+		return nil
+	}
+	rules := f.robots.get(ctx, f, target)
+	if !rules.allowed(target) {
+		return ErrDisallowedByRobots
+	}
+	return nil
+}
+
+// readLimited reads at most limit bytes and reports whether the body was longer
+func readLimited(body io.Reader, limit int64) ([]byte, bool, error) {
+	data, err := io.ReadAll(io.LimitReader(body, limit+1))
+	if err != nil {
+		return nil, false, fmt.Errorf("webfetch: failed to read body: %w", err)
+	}
+	if int64(len(data)) > limit {
+		return data[:limit], true, nil
+	}
+	return data, false, nil
+}
+
+// unwrapURLError surfaces the sentinel errors raised inside the client
+func unwrapURLError(err error) error {
+	for _, sentinel := range []error{ErrBlockedAddress, ErrDisallowedByRobots, ErrTooManyRedirects, ErrUnsupportedScheme} {
+		if errors.Is(err, sentinel) {
+			return sentinel
+		}
+	}
+	return fmt.Errorf("webfetch: %w", err)
+}
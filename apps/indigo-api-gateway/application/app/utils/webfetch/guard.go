@@ -0,0 +1,65 @@
+package webfetch
+
+import (
+	"net"
+	"net/netip"
+	"strings"
+	"syscall"
+)
+
+// blockedPrefixes are the special-purpose ranges a fetch must never reach, on top of the
+// loopback, private, link-local and multicast ranges the net/netip helpers cover
+var blockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("192.0.2.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("198.51.100.0/24"),
+	netip.MustParsePrefix("203.0.113.0/24"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+	netip.MustParsePrefix("100::/64"),
+	netip.MustParsePrefix("2001:db8::/32"),
+}
+
+// IsBlockedIP reports whether ip is loopback, private, link-local or otherwise not publicly routable
+func IsBlockedIP(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	if !ip.IsValid() || ip.IsUnspecified() || ip.IsLoopback() || ip.IsPrivate() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return true
+	}
+	for _, prefix := range blockedPrefixes {
+		if prefix.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsBlockedHost rejects literal private addresses and local names before any DNS lookup,
+// the dial guard covers names that resolve to them
+func IsBlockedHost(host string) bool {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") || strings.HasSuffix(host, ".internal") {
+		return true
+	}
+	if ip, err := netip.ParseAddr(host); err == nil {
+		return IsBlockedIP(ip)
+	}
+	return false
+}
+
+// GuardDial runs on every connection after DNS resolution and refuses blocked addresses
+func GuardDial(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return ErrBlockedAddress
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil || IsBlockedIP(ip) {
+		return ErrBlockedAddress
+	}
+	return nil
+}
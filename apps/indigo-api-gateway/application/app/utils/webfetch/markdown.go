@@ -0,0 +1,296 @@
+package webfetch
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// lineBreak marks a br in inline content, the newlines of text nodes are plain whitespace
+const lineBreak = "\x00"
+
+var blockElements = map[atom.Atom]bool{
+	atom.Html: true, atom.Body: true, atom.Main: true, atom.Article: true, atom.Section: true, atom.Div: true,
+	atom.Header: true, atom.Footer: true, atom.Aside: true, atom.Nav: true, atom.P: true, atom.Address: true,
+	atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true,
+	atom.Ul: true, atom.Ol: true, atom.Li: true, atom.Dl: true, atom.Dt: true, atom.Dd: true,
+	atom.Pre: true, atom.Blockquote: true, atom.Table: true, atom.Hr: true, atom.Figure: true,
+	atom.Figcaption: true, atom.Details: true, atom.Summary: true,
+}
+
+// renderer turns a content tree into Markdown, or into plain text with the same block layout
+type renderer struct {
+	base     *url.URL
+	markdown bool
+}
+
+func render(n *html.Node, base *url.URL, markdown bool) string {
+	r := &renderer{base: base, markdown: markdown}
+	return strings.TrimSpace(r.blocks(n))
+}
+
+// blocks renders the children of n, wrapping runs of inline content into paragraphs
+func (r *renderer) blocks(n *html.Node) string {
+	var parts []string
+	var inline strings.Builder
+	flush := func() {
+		if text := strings.TrimSpace(collapseInline(inline.String())); text != "" {
+			parts = append(parts, text)
+		}
+		inline.Reset()
+	}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == html.ElementNode && blockElements[child.DataAtom] {
+			flush()
+			if block := r.block(child); block != "" {
+				parts = append(parts, block)
+			}
+			continue
+		}
+		inline.WriteString(r.inline(child))
+	}
+	flush()
+	return strings.Join(parts, "\n\n")
+}
+
+func (r *renderer) block(n *html.Node) string {
+	switch n.DataAtom {
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		text := strings.TrimSpace(collapseInline(r.inlineChildren(n)))
+		if text == "" || !r.markdown {
+			return text
+		}
+		level := int(n.Data[1] - '0')
+		return strings.Repeat("#", level) + " " + text
+	case atom.Ul, atom.Ol:
+		return r.list(n, 0)
+	case atom.Pre:
+		code := strings.Trim(textContent(n), "\n")
+		if !r.markdown {
+			return code
+		}
+		language := ""
+		if child := n.FirstChild; child != nil && child.DataAtom == atom.Code {
+			for _, class := range strings.Fields(attr(child, "class")) {
+				if strings.HasPrefix(class, "language-") {
+					language = strings.TrimPrefix(class, "language-")
+				}
+			}
+		}
+		fence := "```"
+		for strings.Contains(code, fence) {
+			fence += "`"
+		}
+		return fence + language + "\n" + code + "\n" + fence
+	case atom.Blockquote:
+		content := r.blocks(n)
+		if content == "" || !r.markdown {
+			return content
+		}
+		lines := strings.Split(content, "\n")
+		for i, line := range lines {
+			lines[i] = strings.TrimRight("> "+line, " ")
+		}
+		return strings.Join(lines, "\n")
+	case atom.Table:
+		return r.table(n)
+	case atom.Hr:
+		if r.markdown {
+			return "---"
+		}
+		return ""
+	case atom.Dt:
+		text := strings.TrimSpace(collapseInline(r.inlineChildren(n)))
+		if text != "" && r.markdown {
+			return "**" + text + "**"
+		}
+		return text
+	}
+	return r.blocks(n)
+}
+
+// list renders ul and ol items, nested lists are indented under their item
+func (r *renderer) list(n *html.Node, depth int) string {
+	var lines []string
+	index := 1
+	if start, err := strconv.Atoi(attr(n, "start")); err == nil {
+		index = start
+	}
+	indent := strings.Repeat("  ", depth)
+	for item := n.FirstChild; item != nil; item = item.NextSibling {
+		if item.Type != html.ElementNode || item.DataAtom != atom.Li {
+			continue
+		}
+		marker := "- "
+		if n.DataAtom == atom.Ol {
+			marker = strconv.Itoa(index) + ". "
+			index++
+		}
+
+		var text strings.Builder
+		var nested []string
+		for child := item.FirstChild; child != nil; child = child.NextSibling {
+			switch {
+			case child.Type == html.ElementNode && (child.DataAtom == atom.Ul || child.DataAtom == atom.Ol):
+				if sublist := r.list(child, depth+1); sublist != "" {
+					nested = append(nested, sublist)
+				}
+			case child.Type == html.ElementNode && blockElements[child.DataAtom]:
+				text.WriteString(" " + strings.ReplaceAll(r.block(child), "\n", " ") + " ")
+			default:
+				text.WriteString(r.inline(child))
+			}
+		}
+		line := strings.TrimSpace(collapseInline(text.String()))
+		if line == "" && len(nested) == 0 {
+			continue
+		}
+		lines = append(lines, indent+marker+line)
+		lines = append(lines, nested...)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// table renders a Markdown table with the first row as the header, or tab separated cells as text
+func (r *renderer) table(n *html.Node) string {
+	var rows [][]string
+	walk(n, func(child *html.Node) bool {
+		if child.DataAtom == atom.Table && child != n {
+			return false
+		}
+		if child.DataAtom != atom.Tr {
+			return true
+		}
+		var cells []string
+		for cell := child.FirstChild; cell != nil; cell = cell.NextSibling {
+			if cell.DataAtom == atom.Td || cell.DataAtom == atom.Th {
+				text := strings.TrimSpace(collapseInline(r.inlineChildren(cell)))
+				cells = append(cells, strings.ReplaceAll(text, "|", "\\|"))
+			}
+		}
+		if len(cells) > 0 {
+			rows = append(rows, cells)
+		}
+		return false
+	})
+	if len(rows) == 0 {
+		return ""
+	}
+	if !r.markdown {
+		lines := make([]string, len(rows))
+		for i, row := range rows {
+			lines[i] = strings.Join(row, "\t")
+		}
+		return strings.Join(lines, "\n")
+	}
+
+	columns := 0
+	for _, row := range rows {
+		columns = max(columns, len(row))
+	}
+	lines := make([]string, 0, len(rows)+1)
+	for i, row := range rows {
+		for len(row) < columns {
+			row = append(row, "")
+		}
+		lines = append(lines, "| "+strings.Join(row, " | ")+" |")
+		if i == 0 {
+			lines = append(lines, "|"+strings.Repeat(" --- |", columns))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (r *renderer) inlineChildren(n *html.Node) string {
+	var builder strings.Builder
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		builder.WriteString(r.inline(child))
+	}
+	return builder.String()
+}
+
+func (r *renderer) inline(n *html.Node) string {
+	switch n.Type {
+	case html.TextNode:
+		return n.Data
+	case html.ElementNode:
+	default:
+		return ""
+	}
+
+	switch n.DataAtom {
+	case atom.Br:
+		return lineBreak
+	case atom.Img:
+		alt := collapseSpace(attr(n, "alt"))
+		if !r.markdown {
+			return alt
+		}
+		src := r.resolve(attr(n, "src"))
+		if src == "" {
+			return alt
+		}
+		return "![" + alt + "](" + src + ")"
+	}
+
+	content := r.inlineChildren(n)
+	if !r.markdown || strings.TrimSpace(content) == "" {
+		return content
+	}
+	switch n.DataAtom {
+	case atom.A:
+		href := r.resolve(attr(n, "href"))
+		if href == "" {
+			return content
+		}
+		return "[" + strings.TrimSpace(collapseSpace(content)) + "](" + href + ")"
+	case atom.Strong, atom.B:
+		return wrapInline(content, "**")
+	case atom.Em, atom.I:
+		return wrapInline(content, "*")
+	case atom.Del, atom.S, atom.Strike:
+		return wrapInline(content, "~~")
+	case atom.Code, atom.Kbd, atom.Samp:
+		return wrapInline(content, "`")
+	}
+	return content
+}
+
+// resolve makes a link absolute, dropping fragment-only and script links
+func (r *renderer) resolve(href string) string {
+	href = strings.TrimSpace(href)
+	if href == "" || strings.HasPrefix(href, "#") {
+		return ""
+	}
+	target, err := url.Parse(href)
+	if err != nil {
+		return ""
+	}
+	if r.base != nil {
+		target = r.base.ResolveReference(target)
+	}
+	if target.Scheme != "http" && target.Scheme != "https" && target.Scheme != "mailto" {
+		return ""
+	}
+	return target.String()
+}
+
+// wrapInline puts the markers inside the surrounding whitespace so the emphasis stays valid Markdown
+func wrapInline(content, marker string) string {
+	trimmed := strings.TrimSpace(content)
+	leading := content[:strings.Index(content, trimmed)]
+	trailing := content[len(leading)+len(trimmed):]
+	return leading + marker + collapseSpace(trimmed) + marker + trailing
+}
+
+// collapseInline collapses whitespace like a browser while keeping the line breaks of br
+func collapseInline(s string) string {
+	lines := strings.Split(s, lineBreak)
+	for i, line := range lines {
+		lines[i] = collapseSpace(line)
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,366 @@
+package webfetch
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+const (
+	// maxPDFStreamBytes caps the inflated size of a single stream
+	maxPDFStreamBytes = 16 << 20
+	// pdfWordGap is the TJ kerning, in thousandths of an em, read as a space between words
+	pdfWordGap = -200
+)
+
+var (
+	ErrPDFNoText = errors.New("webfetch: no extractable text in PDF")
+
+	pdfStreamStart = regexp.MustCompile(`stream\r?\n`)
+	pdfTitle       = regexp.MustCompile(`/Title\s*(\((?:\\.|[^\\)])*\)|<[0-9A-Fa-f\s]*>)`)
+)
+
+type pdfDocument struct {
+	title string
+	text  string
+}
+
+// extractPDF reads the text shown by the content streams of a PDF. Fonts with custom encodings
+// and no standard mapping come out unreadable and are skipped, scanned PDFs have no text at all
+func extractPDF(data []byte) (*pdfDocument, error) {
+	if !bytes.HasPrefix(bytes.TrimLeft(data, "\x00\t\r\n "), []byte("%PDF-")) {
+		return nil, errors.New("webfetch: not a PDF document")
+	}
+
+	var out strings.Builder
+	offset := 0
+	for {
+		location := pdfStreamStart.FindIndex(data[offset:])
+		if location == nil {
+			break
+		}
+		start := offset + location[1]
+		end := bytes.Index(data[start:], []byte("endstream"))
+		if end < 0 {
+			break
+		}
+		dictionary := data[max(offset, offset+location[0]-1024) : offset+location[0]]
+		if index := bytes.LastIndex(dictionary, []byte("obj")); index >= 0 {
+			dictionary = dictionary[index:]
+		}
+		stream := data[start : start+end]
+		offset = start + end + len("endstream")
+
+		content, ok := decodePDFStream(dictionary, stream)
+		if !ok || !bytes.Contains(content, []byte("BT")) {
+			continue
+		}
+		pdfContentText(content, &out)
+	}
+
+	doc := &pdfDocument{text: cleanPDFText(out.String())}
+	if match := pdfTitle.FindSubmatch(data); match != nil {
+		doc.title = strings.TrimSpace(decodePDFString(match[1]))
+	}
+	if doc.text == "" {
+		return nil, ErrPDFNoText
+	}
+	return doc, nil
+}
+
+// ExtractPDFText returns the text of a PDF document, ErrPDFNoText is returned when it has none
+func ExtractPDFText(data []byte) (string, error) {
+	doc, err := extractPDF(data)
+	if err != nil {
+		return "", err
+	}
+	return doc.text, nil
+}
+
+// decodePDFStream inflates FlateDecode streams and passes unfiltered ones through, other
+// filters hold images or fonts and are skipped
+func decodePDFStream(dictionary, stream []byte) ([]byte, bool) {
+	for _, skipped := range []string{"/Image", "/FontFile", "/Length1", "/XRef", "/ObjStm", "/Metadata"} {
+		if bytes.Contains(dictionary, []byte(skipped)) {
+			return nil, false
+		}
+	}
+	if !bytes.Contains(dictionary, []byte("/Filter")) {
+		return stream, true
+	}
+	if !bytes.Contains(dictionary, []byte("/FlateDecode")) {
+		return nil, false
+	}
+	for _, other := range []string{"/DCTDecode", "/JPXDecode", "/LZWDecode", "/ASCII85Decode", "/ASCIIHexDecode", "/CCITTFaxDecode", "/JBIG2Decode", "/RunLengthDecode"} {
+		if bytes.Contains(dictionary, []byte(other)) {
+			return nil, false
+		}
+	}
+
+	reader, err := zlib.NewReader(bytes.NewReader(stream))
+	if err != nil {
+		return nil, false
+	}
+	defer reader.Close()
+	content, err := io.ReadAll(io.LimitReader(reader, maxPDFStreamBytes))
+	// Streams cut short by a wrong length still hold their text
+	if err != nil && len(content) == 0 {
+		return nil, false
+	}
+	return content, true
+}
+
+// pdfContentText writes the strings of the Tj, TJ, ' and " operators, with line breaks for the
+// operators that move to a new line
+func pdfContentText(content []byte, out *strings.Builder) {
+	var (
+		numbers []float64
+		strs    []string
+		array   []string
+		inArray bool
+		lineY   float64
+	)
+	newline := func() {
+		text := out.String()
+		if text != "" && !strings.HasSuffix(text, "\n") {
+			out.WriteString("\n")
+		}
+	}
+	space := func() {
+		text := out.String()
+		if text != "" && !strings.HasSuffix(text, " ") && !strings.HasSuffix(text, "\n") {
+			out.WriteString(" ")
+		}
+	}
+
+	for i := 0; i < len(content); {
+		c := content[i]
+		switch {
+		case isPDFSpace(c):
+			i++
+		case c == '%':
+			for i < len(content) && content[i] != '\n' && content[i] != '\r' {
+				i++
+			}
+		case c == '(':
+			value, next := readPDFLiteral(content, i)
+			i = next
+			if inArray {
+				array = append(array, value)
+			} else {
+				strs = append(strs, value)
+			}
+		case c == '<' && i+1 < len(content) && content[i+1] == '<':
+			i += 2
+		case c == '>' && i+1 < len(content) && content[i+1] == '>':
+			i += 2
+		case c == '<':
+			end := bytes.IndexByte(content[i:], '>')
+			if end < 0 {
+				return
+			}
+			value := decodePDFString(content[i : i+end+1])
+			i += end + 1
+			if inArray {
+				array = append(array, value)
+			} else {
+				strs = append(strs, value)
+			}
+		case c == '[':
+			inArray, array = true, nil
+			i++
+		case c == ']':
+			inArray = false
+			i++
+		case c == '/':
+			i++
+			for i < len(content) && !isPDFSpace(content[i]) && !isPDFDelimiter(content[i]) {
+				i++
+			}
+		case c == '-' || c == '+' || c == '.' || (c >= '0' && c <= '9'):
+			start := i
+			i++
+			for i < len(content) && (content[i] == '.' || (content[i] >= '0' && content[i] <= '9')) {
+				i++
+			}
+			number, err := strconv.ParseFloat(string(content[start:i]), 64)
+			if err != nil {
+				continue
+			}
+			if inArray {
+				if number <= pdfWordGap {
+					array = append(array, " ")
+				}
+			} else {
+				numbers = append(numbers, number)
+			}
+		case isPDFDelimiter(c):
+			i++
+		default:
+			start := i
+			for i < len(content) && !isPDFSpace(content[i]) && !isPDFDelimiter(content[i]) {
+				i++
+			}
+			switch string(content[start:i]) {
+			case "Tj":
+				out.WriteString(strings.Join(strs, ""))
+			case "'", "\"":
+				newline()
+				out.WriteString(strings.Join(strs, ""))
+			case "TJ":
+				out.WriteString(strings.Join(array, ""))
+			case "T*", "ET":
+				newline()
+			case "Td", "TD":
+				if len(numbers) >= 2 && numbers[len(numbers)-1] != 0 {
+					newline()
+				} else {
+					space()
+				}
+			case "Tm":
+				// Producers that place every word with Tm keep the same baseline within a line
+				if len(numbers) >= 6 && numbers[len(numbers)-1] == lineY {
+					space()
+				} else {
+					newline()
+				}
+				if len(numbers) >= 6 {
+					lineY = numbers[len(numbers)-1]
+				}
+			case "ID":
+				// Inline image data runs up to EI
+				end := bytes.Index(content[i:], []byte("EI"))
+				if end < 0 {
+					return
+				}
+				i += end + 2
+			}
+			numbers, strs, array = numbers[:0], strs[:0], nil
+		}
+	}
+}
+
+// readPDFLiteral reads a (string) with nested parentheses and escapes, starting at the opening parenthesis
+func readPDFLiteral(content []byte, i int) (string, int) {
+	var value []byte
+	depth := 0
+	for i < len(content) {
+		c := content[i]
+		switch {
+		case c == '\\' && i+1 < len(content):
+			i++
+			switch escaped := content[i]; escaped {
+			case 'n':
+				value = append(value, '\n')
+			case 'r':
+				value = append(value, '\r')
+			case 't':
+				value = append(value, '\t')
+			case 'b', 'f':
+			case '\r', '\n':
+				// Line continuation
+				if escaped == '\r' && i+1 < len(content) && content[i+1] == '\n' {
+					i++
+				}
+			default:
+				if escaped >= '0' && escaped <= '7' {
+					octal := 0
+					for n := 0; n < 3 && i < len(content) && content[i] >= '0' && content[i] <= '7'; n++ {
+						octal = octal*8 + int(content[i]-'0')
+						i++
+					}
+					value = append(value, byte(octal))
+					continue
+				}
+				value = append(value, escaped)
+			}
+			i++
+			continue
+		case c == '(':
+			depth++
+			if depth > 1 {
+				value = append(value, c)
+			}
+		case c == ')':
+			depth--
+			if depth == 0 {
+				return decodePDFText(value), i + 1
+			}
+			value = append(value, c)
+		default:
+			value = append(value, c)
+		}
+		i++
+	}
+	return decodePDFText(value), i
+}
+
+// decodePDFString decodes a (literal) or <hex> string
+func decodePDFString(raw []byte) string {
+	if len(raw) > 0 && raw[0] == '(' {
+		value, _ := readPDFLiteral(raw, 0)
+		return value
+	}
+	hex := make([]byte, 0, len(raw))
+	for _, c := range raw {
+		if (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F') {
+			hex = append(hex, c)
+		}
+	}
+	if len(hex)%2 == 1 {
+		hex = append(hex, '0')
+	}
+	value := make([]byte, len(hex)/2)
+	for i := range value {
+		b, _ := strconv.ParseUint(string(hex[2*i:2*i+2]), 16, 8)
+		value[i] = byte(b)
+	}
+	return decodePDFText(value)
+}
+
+// decodePDFText reads UTF-16 strings with a byte order mark and Latin-1 otherwise, glyph ids
+// of embedded fonts decode to control characters and are dropped
+func decodePDFText(value []byte) string {
+	if len(value) >= 2 && value[0] == 0xFE && value[1] == 0xFF {
+		units := make([]uint16, 0, len(value)/2)
+		for i := 2; i+1 < len(value); i += 2 {
+			units = append(units, uint16(value[i])<<8|uint16(value[i+1]))
+		}
+		return string(utf16.Decode(units))
+	}
+	var builder strings.Builder
+	for _, b := range value {
+		switch {
+		case b == '\n' || b == '\r' || b == '\t':
+			builder.WriteByte(' ')
+		case b < 0x20 || b == 0x7F:
+		default:
+			builder.WriteRune(rune(b))
+		}
+	}
+	return builder.String()
+}
+
+func cleanPDFText(text string) string {
+	lines := strings.Split(text, "\n")
+	kept := make([]string, 0, len(lines))
+	for _, line := range lines {
+		if line = collapseSpace(line); line != "" {
+			kept = append(kept, line)
+		}
+	}
+	return strings.Join(kept, "\n")
+}
+
+func isPDFSpace(c byte) bool {
+	return c == ' ' || c == '\n' || c == '\r' || c == '\t' || c == '\f' || c == 0
+}
+
+func isPDFDelimiter(c byte) bool {
+	return c == '(' || c == ')' || c == '<' || c == '>' || c == '[' || c == ']' || c == '{' || c == '}' || c == '/' || c == '%'
+}
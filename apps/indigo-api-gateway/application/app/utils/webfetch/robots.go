@@ -0,0 +1,235 @@
+package webfetch
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	robotsCacheTTL  = time.Hour
+	robotsMaxBytes  = 512 << 10
+	robotsMaxOrigin = 1000
+)
+
+// robotsRule is an allow or disallow line of robots.txt
+type robotsRule struct {
+	pattern string
+	allow   bool
+}
+
+// robotsRules are the rules of the group that applies to UserAgentToken, following RFC 9309
+type robotsRules struct {
+	rules      []robotsRule
+	disallowed bool
+}
+
+var allowAll = &robotsRules{}
+
+// allowed applies the longest matching rule, allow wins ties
+func (r *robotsRules) allowed(target *url.URL) bool {
+	if r.disallowed {
+		return false
+	}
+	path := target.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if target.RawQuery != "" {
+		path += "?" + target.RawQuery
+	}
+	if path == "/robots.txt" {
+		return true
+	}
+
+	matched, allow := -1, true
+	for _, rule := range r.rules {
+		if !robotsMatch(rule.pattern, path) {
+			continue
+		}
+		if length := len(rule.pattern); length > matched || (length == matched && rule.allow) {
+			matched, allow = length, rule.allow
+		}
+	}
+	return allow
+}
+
+// robotsMatch matches a path against a pattern where * is any sequence and a trailing $ anchors the end
+func robotsMatch(pattern, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	if anchored {
+		pattern = strings.TrimSuffix(pattern, "$")
+	}
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+	rest := path[len(parts[0]):]
+	for i, part := range parts[1:] {
+		if i == len(parts)-2 && anchored {
+			return strings.HasSuffix(rest, part)
+		}
+		index := strings.Index(rest, part)
+		if index < 0 {
+			return false
+		}
+		rest = rest[index+len(part):]
+	}
+	return !anchored || rest == ""
+}
+
+// parseRobots keeps the group naming UserAgentToken, or the * group when there is none
+func parseRobots(data []byte) *robotsRules {
+	token := strings.ToLower(UserAgentToken)
+	var (
+		specific, wildcard []robotsRule
+		foundSpecific      bool
+		foundWildcard      bool
+		agents             []string
+		inRules            bool
+	)
+	addRule := func(rule robotsRule) {
+		for _, agent := range agents {
+			switch {
+			case agent == "*":
+				wildcard = append(wildcard, rule)
+			case strings.Contains(token, agent):
+				specific = append(specific, rule)
+			}
+		}
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if index := strings.IndexByte(line, '#'); index >= 0 {
+			line = line[:index]
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			if inRules {
+				agents, inRules = nil, false
+			}
+			agent := strings.ToLower(value)
+			agents = append(agents, agent)
+			if agent == "*" {
+				foundWildcard = true
+			} else if strings.Contains(token, agent) {
+				foundSpecific = true
+			}
+		case "allow", "disallow":
+			inRules = true
+			// An empty disallow allows everything and adds no rule
+			if value == "" {
+				continue
+			}
+			addRule(robotsRule{pattern: value, allow: key == "allow"})
+		}
+	}
+
+	if foundSpecific {
+		return &robotsRules{rules: specific}
+	}
+	if foundWildcard {
+		return &robotsRules{rules: wildcard}
+	}
+	return allowAll
+}
+
+type robotsEntry struct {
+	rules   *robotsRules
+	expires time.Time
+}
+
+// robotsCache keeps the robots.txt rules of each origin in memory for robotsCacheTTL
+type robotsCache struct {
+	mu      sync.Mutex
+	entries map[string]robotsEntry
+}
+
+func newRobotsCache() *robotsCache {
+	return &robotsCache{entries: map[string]robotsEntry{}}
+}
+
+func (c *robotsCache) get(ctx context.Context, f *Fetcher, target *url.URL) *robotsRules {
+	origin := target.Scheme + "://" + target.Host
+	now := time.Now()
+
+	c.mu.Lock()
+	entry, ok := c.entries[origin]
+	c.mu.Unlock()
+	if ok && now.Before(entry.expires) {
+		return entry.rules
+	}
+
+	rules, cacheable := f.fetchRobots(ctx, origin)
+	if !cacheable {
+		return rules
+	}
+	c.mu.Lock()
+	if len(c.entries) >= robotsMaxOrigin {
+		for key, cached := range c.entries {
+			if now.After(cached.expires) {
+				delete(c.entries, key)
+			}
+		}
+		if len(c.entries) >= robotsMaxOrigin {
+			c.entries = map[string]robotsEntry{}
+		}
+	}
+	c.entries[origin] = robotsEntry{rules: rules, expires: now.Add(robotsCacheTTL)}
+	c.mu.Unlock()
+	return rules
+}
+
+// fetchRobots reads robots.txt of an origin. A missing file allows everything and a server
+// error disallows everything, as RFC 9309 asks. Network errors are not cached, the fetch of
+// the page itself reports them
+func (f *Fetcher) fetchRobots(ctx context.Context, origin string) (*robotsRules, bool) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, origin+"/robots.txt", nil)
+	if err != nil {
+		return allowAll, false
+	}
+	req.Header.Set("User-Agent", f.config.UserAgent)
+
+	// Redirects of robots.txt go through the same guard without a robots check of their own
+	client := *f.client
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if len(via) > f.config.MaxRedirects {
+			return ErrTooManyRedirects
+		}
+		if !f.config.AllowPrivateNetworks && IsBlockedHost(req.URL.Hostname()) {
+			return ErrBlockedAddress
+		}
+		return nil
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return allowAll, false
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= http.StatusInternalServerError:
+		return &robotsRules{disallowed: true}, true
+	case resp.StatusCode >= http.StatusBadRequest:
+		return allowAll, true
+	}
+	data, _, err := readLimited(resp.Body, robotsMaxBytes)
+	if err != nil {
+		return allowAll, false
+	}
+	return parseRobots(data), true
+}
@@ -0,0 +1,200 @@
+package webfetch
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestIsBlockedIP(t *testing.T) {
+	cases := map[string]bool{
+		"127.0.0.1":       true,
+		"10.1.2.3":        true,
+		"172.16.0.1":      true,
+		"192.168.1.1":     true,
+		"169.254.169.254": true,
+		"100.64.0.1":      true,
+		"0.0.0.0":         true,
+		"::1":             true,
+		"fe80::1":         true,
+		"fd00::1":         true,
+		"::ffff:10.0.0.1": true,
+		"8.8.8.8":         false,
+		"2606:4700::1111": false,
+	}
+	for address, blocked := range cases {
+		if got := IsBlockedIP(netip.MustParseAddr(address)); got != blocked {
+			t.Errorf("IsBlockedIP(%s) = %v, want %v", address, got, blocked)
+		}
+	}
+}
+
+func TestFetchRejectsPrivateAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("the guard let a request to %s through", r.URL)
+	}))
+	defer server.Close()
+
+	fetcher := NewFetcher(Config{IgnoreRobots: true})
+	for _, target := range []string{server.URL, "http://localhost/", "file:///etc/passwd"} {
+		if _, err := fetcher.Fetch(context.Background(), target); !errors.Is(err, ErrBlockedAddress) && !errors.Is(err, ErrUnsupportedScheme) {
+			t.Errorf("Fetch(%s) error = %v, want a blocked address", target, err)
+		}
+	}
+}
+
+func TestFetchExtractsMainContent(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/robots.txt", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "User-agent: *\nDisallow: /private\n\nUser-agent: OtherBot\nDisallow: /\n")
+	})
+	mux.HandleFunc("/moved", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/article", http.StatusFound)
+	})
+	mux.HandleFunc("/article", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, `<html><head><title>Gateway notes</title><meta name="description" content="How the gateway works"></head>
+<body>
+<nav><a href="/">Home</a> <a href="/about">About</a></nav>
+<div class="sidebar"><p>Subscribe to our newsletter, it is great, really, truly.</p></div>
+<article>
+<h1>Gateway notes</h1>
+<p>The gateway routes requests to <a href="/providers">model providers</a>, keeps <strong>usage</strong> per project, and enforces the limits of each organization.</p>
+<ul><li>Chat completions</li><li>Responses<ul><li>Background mode</li></ul></li></ul>
+<pre><code class="language-go">fmt.Println("hi")</code></pre>
+<table><tr><th>Name</th><th>Value</th></tr><tr><td>timeout</td><td>15s</td></tr></table>
+<p>Tools run inside the gateway, so a model can search the web, read pages and call the MCP servers of the organization.</p>
+</article>
+<footer>Copyright</footer>
+<script>alert("x")</script>
+</body></html>`)
+	})
+	mux.HandleFunc("/private/page", func(w http.ResponseWriter, r *http.Request) {
+		t.Error("robots.txt was not respected")
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	fetcher := NewFetcher(Config{AllowPrivateNetworks: true})
+	page, err := fetcher.Fetch(context.Background(), server.URL+"/moved")
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if page.URL != server.URL+"/article" || page.Title != "Gateway notes" || page.Description != "How the gateway works" {
+		t.Fatalf("unexpected page metadata: %+v", page)
+	}
+	for _, want := range []string{
+		"# Gateway notes",
+		"[model providers](" + server.URL + "/providers)",
+		"**usage**",
+		"- Responses\n  - Background mode",
+		"```go\nfmt.Println(\"hi\")\n```",
+		"| Name | Value |\n| --- | --- |\n| timeout | 15s |",
+	} {
+		if !strings.Contains(page.Markdown, want) {
+			t.Errorf("markdown misses %q:\n%s", want, page.Markdown)
+		}
+	}
+	for _, unwanted := range []string{"Home", "newsletter", "Copyright", "alert"} {
+		if strings.Contains(page.Markdown, unwanted) || strings.Contains(page.Text, unwanted) {
+			t.Errorf("boilerplate %q was kept:\n%s", unwanted, page.Markdown)
+		}
+	}
+	if strings.Contains(page.Text, "**") || !strings.Contains(page.Text, "model providers, keeps usage") {
+		t.Errorf("unexpected text:\n%s", page.Text)
+	}
+
+	if _, err := fetcher.Fetch(context.Background(), server.URL+"/private/page"); !errors.Is(err, ErrDisallowedByRobots) {
+		t.Errorf("Fetch of a disallowed path error = %v", err)
+	}
+}
+
+func TestFetchLimits(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/loop", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/loop", http.StatusFound)
+	})
+	mux.HandleFunc("/large", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		fmt.Fprint(w, strings.Repeat("a", 2048))
+	})
+	mux.HandleFunc("/binary", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte{0x89, 'P', 'N', 'G'})
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	fetcher := NewFetcher(Config{AllowPrivateNetworks: true, IgnoreRobots: true, MaxBytes: 1024, MaxRedirects: 3})
+	if _, err := fetcher.Fetch(context.Background(), server.URL+"/loop"); !errors.Is(err, ErrTooManyRedirects) {
+		t.Errorf("redirect loop error = %v", err)
+	}
+	page, err := fetcher.Fetch(context.Background(), server.URL+"/large")
+	if err != nil || !page.Truncated || len(page.Text) != 1024 {
+		t.Errorf("large body: err = %v, page = %+v", err, page)
+	}
+	if _, err := fetcher.Fetch(context.Background(), server.URL+"/binary"); !errors.Is(err, ErrUnsupportedContentType) {
+		t.Errorf("binary body error = %v", err)
+	}
+}
+
+func TestRobotsRules(t *testing.T) {
+	rules := parseRobots([]byte(`
+User-agent: *
+Disallow: /
+
+User-agent: IndigoFetcher
+Disallow: /private
+Allow: /private/public
+Disallow: /*.pdf$
+`))
+	cases := map[string]bool{
+		"/":                    true,
+		"/private":             false,
+		"/private/x":           false,
+		"/private/public/page": true,
+		"/docs/file.pdf":       false,
+		"/docs/file.pdf?x=1":   true,
+		"/robots.txt":          true,
+	}
+	for path, allowed := range cases {
+		target, _ := url.Parse("https://example.com" + path)
+		if got := rules.allowed(target); got != allowed {
+			t.Errorf("allowed(%s) = %v, want %v", path, got, allowed)
+		}
+	}
+
+	wildcard := parseRobots([]byte("User-agent: *\nDisallow: /\n"))
+	if target, _ := url.Parse("https://example.com/page"); wildcard.allowed(target) {
+		t.Error("the * group was not applied")
+	}
+}
+
+func TestExtractPDF(t *testing.T) {
+	var content bytes.Buffer
+	writer := zlib.NewWriter(&content)
+	writer.Write([]byte("BT /F1 12 Tf 72 720 Td (Hello, PDF) Tj 0 -14 Td [(Second) -250 (line)] TJ ET"))
+	writer.Close()
+
+	var pdf bytes.Buffer
+	pdf.WriteString("%PDF-1.4\n1 0 obj\n<< /Title (Quarterly \\(Q3\\) report) >>\nendobj\n")
+	fmt.Fprintf(&pdf, "4 0 obj\n<< /Length %d /Filter /FlateDecode >>\nstream\n", content.Len())
+	pdf.Write(content.Bytes())
+	pdf.WriteString("\nendstream\nendobj\n%%EOF\n")
+
+	doc, err := extractPDF(pdf.Bytes())
+	if err != nil {
+		t.Fatalf("extractPDF: %v", err)
+	}
+	if doc.text != "Hello, PDF\nSecond line" || doc.title != "Quarterly (Q3) report" {
+		t.Errorf("unexpected document: %+v", doc)
+	}
+}
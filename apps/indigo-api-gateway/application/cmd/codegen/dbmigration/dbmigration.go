@@ -79,7 +79,9 @@ func createTmpFolder() error {
 }
 
 func main() {
-	environment_variables.EnvironmentVariables.LoadFromEnv()
+	if err := environment_variables.Load(); err != nil {
+		log.Println(err)
+	}
 	if err := createTmpFolder(); err != nil {
 		panic(err)
 	}
@@ -16,8 +16,10 @@ import (
 var GormGenerator *gen.Generator
 
 func init() {
-	environment_variables.EnvironmentVariables.LoadFromEnv()
-	db, err := gorm.Open(postgres.Open(environment_variables.EnvironmentVariables.DB_POSTGRESQL_WRITE_DSN))
+	if err := environment_variables.Load(); err != nil {
+		log.Println(err)
+	}
+	db, err := gorm.Open(postgres.Open(environment_variables.Current().DB_POSTGRESQL_WRITE_DSN))
 	if err != nil {
 		panic(err)
 	}
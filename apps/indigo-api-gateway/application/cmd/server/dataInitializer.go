@@ -30,7 +30,7 @@ func (d *DataInitializer) Install(ctx context.Context) error {
 		return err
 	}
 
-	if environment_variables.EnvironmentVariables.JAN_INFERENCE_SETUP {
+	if environment_variables.Current().JAN_INFERENCE_SETUP {
 		err = d.setupJanProvider(ctx)
 		if err != nil {
 			return fmt.Errorf("failed to setup Jan provider: %v", err)
@@ -53,14 +53,15 @@ func (d *DataInitializer) applyStoredSettings(ctx context.Context) error {
 		return err
 	}
 	if smtp != nil && smtp.Host != "" {
-		env := &environment_variables.EnvironmentVariables
-		env.SMTP_HOST = smtp.Host
-		env.SMTP_PORT = smtp.Port
-		env.SMTP_USERNAME = smtp.Username
-		if smtp.Password != "" {
-			env.SMTP_PASSWORD = smtp.Password
-		}
-		env.SMTP_SENDER_EMAIL = smtp.FromEmail
+		environment_variables.Override(environment_variables.OverrideSMTP, func(env *environment_variables.EnvironmentVariable) {
+			env.SMTP_HOST = smtp.Host
+			env.SMTP_PORT = smtp.Port
+			env.SMTP_USERNAME = smtp.Username
+			if smtp.Password != "" {
+				env.SMTP_PASSWORD = smtp.Password
+			}
+			env.SMTP_SENDER_EMAIL = smtp.FromEmail
+		})
 	}
 	return nil
 }
@@ -91,7 +92,7 @@ func (d *DataInitializer) setupJanProvider(ctx context.Context) error {
 		OrganizationID: organization.DEFAULT_ORGANIZATION.ID,
 		Name:           "Jan Shared Key",
 		Vendor:         string(model.ProviderJan),
-		BaseURL:        environment_variables.EnvironmentVariables.JAN_INFERENCE_MODEL_URL,
+		BaseURL:        environment_variables.Current().JAN_INFERENCE_MODEL_URL,
 		APIKey:         "none",
 		Metadata: map[string]string{
 			"description": "Default organization access to Jan Provider",
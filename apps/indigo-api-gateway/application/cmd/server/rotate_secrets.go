@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+	"menlo.ai/indigo-api-gateway/app/utils/logger"
+)
+
+// rotateSecretsCommand re-encrypts the stored secrets with the primary master key: indigo-server rotate-secrets
+const rotateSecretsCommand = "rotate-secrets"
+
+func rotateSecrets(ctx context.Context) error {
+	rotation, err := CreateSecretRotation()
+	if err != nil {
+		return err
+	}
+	reports, rotateErr := rotation.Rotate(ctx)
+	failed := 0
+	for _, report := range reports {
+		failed += report.Failed
+		logger.GetLogger().WithFields(logrus.Fields{
+			"kind":    report.Kind,
+			"scanned": report.Scanned,
+			"rotated": report.Rotated,
+			"current": report.Current,
+			"changed": report.Changed,
+			"failed":  report.Failed,
+		}).Info("secrets rotated")
+	}
+	if rotateErr != nil {
+		return rotateErr.GetError()
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d secrets could not be re-encrypted, keep their master keys until they are fixed", failed)
+	}
+	return nil
+}
@@ -4,39 +4,120 @@ import (
 	"context"
 	nethttp "net/http"
 	_ "net/http/pprof"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	_ "github.com/grafana/pyroscope-go/godeltaprof/http/pprof"
 
 	"github.com/mileusna/crontab"
 	"menlo.ai/indigo-api-gateway/app/domain/cron"
+	"menlo.ai/indigo-api-gateway/app/domain/response"
+	"menlo.ai/indigo-api-gateway/app/domain/vectorstore"
+	"menlo.ai/indigo-api-gateway/app/domain/webhook"
 	"menlo.ai/indigo-api-gateway/app/infrastructure/database"
 	apphttp "menlo.ai/indigo-api-gateway/app/interfaces/http"
 	"menlo.ai/indigo-api-gateway/app/utils/httpclients/serper"
+	"menlo.ai/indigo-api-gateway/app/utils/httpclients/websearch"
 	"menlo.ai/indigo-api-gateway/app/utils/logger"
+	"menlo.ai/indigo-api-gateway/app/utils/metrics"
+	"menlo.ai/indigo-api-gateway/app/utils/tracing"
 	"menlo.ai/indigo-api-gateway/config/environment_variables"
 )
 
 type Application struct {
-	HttpServer  *apphttp.HttpServer
-	CronService *cron.CronService
+	HttpServer         *apphttp.HttpServer
+	CronService        *cron.CronService
+	ResponseService    *response.ResponseService
+	ResponseWorker     *response.ResponseWorker
+	WebhookDispatcher  *webhook.WebhookDispatcher
+	VectorStoreIndexer *vectorstore.VectorStoreIndexer
 }
 
-func (application *Application) Start() {
+const (
+	defaultReadinessDelay = 5 * time.Second
+	defaultDrainTimeout   = 25 * time.Second
+)
+
+// Start runs the server until ctx is cancelled, then drains it: the readiness probe fails first, the open
+// requests and streams and the running background responses get until the drain timeout to finish
+func (application *Application) Start(ctx context.Context) error {
+	log := logger.GetLogger()
+
+	// Fail the responses interrupted by the previous shutdown of a replica
+	if failed, err := application.ResponseService.FailOrphanedResponses(ctx); err != nil {
+		log.Errorf("failed to sweep orphaned responses: %s - %s", err.GetCode(), err.Error())
+	} else if failed > 0 {
+		log.Warnf("marked %d orphaned responses as failed", failed)
+	}
+
+	background, stopBackground := context.WithCancel(context.Background())
+	defer stopBackground()
+
 	// Start cron service
 	cronTab := crontab.New()
-	background := context.Background()
 	application.CronService.Start(background, cronTab)
 
+	// Start background response workers
+	application.ResponseWorker.Start(background)
+
+	// Start webhook delivery dispatcher
+	application.WebhookDispatcher.Start(background)
+
+	// Start indexing the files attached to vector stores
+	application.VectorStoreIndexer.Start(background)
+
+	// Reload the configuration on SIGHUP and configuration file changes
+	environment_variables.Watch(background)
+
 	// Start HTTP server
-	if err := application.HttpServer.Run(); err != nil {
-		panic(err)
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- application.HttpServer.Run()
+	}()
+	select {
+	case err := <-serveErr:
+		cronTab.Shutdown()
+		return err
+	case <-ctx.Done():
+	}
+
+	env := environment_variables.Current()
+	readinessDelay := defaultReadinessDelay
+	if env.SHUTDOWN_READINESS_DELAY_SECONDS > 0 {
+		readinessDelay = time.Duration(env.SHUTDOWN_READINESS_DELAY_SECONDS) * time.Second
+	}
+	drainTimeout := defaultDrainTimeout
+	if env.SHUTDOWN_DRAIN_TIMEOUT_SECONDS > 0 {
+		drainTimeout = time.Duration(env.SHUTDOWN_DRAIN_TIMEOUT_SECONDS) * time.Second
 	}
+	log.Infof("shutting down, draining for up to %s", readinessDelay+drainTimeout)
+
+	// Stop scheduling work, the running background responses keep going until the deadline
+	cronTab.Shutdown()
+	stopBackground()
+
+	drainCtx, cancel := context.WithTimeout(context.Background(), readinessDelay+drainTimeout)
+	defer cancel()
+	if err := application.HttpServer.Shutdown(drainCtx, readinessDelay); err != nil {
+		log.Warnf("requests still open at the drain deadline were closed: %v", err)
+	}
+	application.ResponseWorker.Shutdown(drainCtx)
+	if err := <-serveErr; err != nil {
+		return err
+	}
+	log.Info("shutdown complete")
+	return nil
 }
 
 func init() {
 	logger.GetLogger()
-	environment_variables.EnvironmentVariables.LoadFromEnv()
+	if err := environment_variables.Load(); err != nil {
+		logger.GetLogger().Fatal(err)
+	}
 	serper.Init()
+	websearch.Init()
 }
 
 // @title Indigo Server
@@ -50,8 +131,29 @@ func init() {
 // @description Type "Bearer" followed by a space and JWT token.
 func main() {
 	background := context.Background()
+	ctx, stop := signal.NotifyContext(background, syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	if len(os.Args) > 1 && os.Args[1] == rotateSecretsCommand {
+		if err := rotateSecrets(ctx); err != nil {
+			logger.GetLogger().Fatal(err)
+		}
+		return
+	}
+
+	shutdownTracing, err := tracing.Init(background)
+	if err != nil {
+		panic(err)
+	}
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			logger.GetLogger().Errorf("failed to flush traces: %v", err)
+		}
+	}()
 
 	// Expose pprof endpoints for profiling (for Grafana Alloy/Pyroscope Go pull mode)
+	// and the Prometheus metrics on the internal port, away from the public API
+	nethttp.Handle("/metrics", metrics.Handler())
 	go func() {
 		// Default pprof mux is registered on DefaultServeMux by importing net/http/pprof
 		// Listen on localhost:6060 (or change port as needed)
@@ -76,5 +178,7 @@ func main() {
 	if err != nil {
 		panic(err)
 	}
-	application.Start()
+	if err := application.Start(ctx); err != nil {
+		panic(err)
+	}
 }
@@ -6,6 +6,7 @@ import (
 	"github.com/google/wire"
 	"gorm.io/gorm"
 	"menlo.ai/indigo-api-gateway/app/domain"
+	"menlo.ai/indigo-api-gateway/app/domain/secrets"
 	"menlo.ai/indigo-api-gateway/app/infrastructure"
 	"menlo.ai/indigo-api-gateway/app/infrastructure/database"
 	"menlo.ai/indigo-api-gateway/app/infrastructure/database/repository"
@@ -40,3 +41,12 @@ func CreateDataInitializer() (*DataInitializer, error) {
 	)
 	return nil, nil
 }
+
+func CreateSecretRotation() (*secrets.RotationService, error) {
+	wire.Build(
+		database.NewDB,
+		repository.RepositoryProvider,
+		secrets.NewRotationService,
+	)
+	return nil, nil
+}
@@ -10,32 +10,51 @@ import (
 	"gorm.io/gorm"
 	"menlo.ai/indigo-api-gateway/app/domain/apikey"
 	"menlo.ai/indigo-api-gateway/app/domain/auth"
+	"menlo.ai/indigo-api-gateway/app/domain/completioncache"
+	"menlo.ai/indigo-api-gateway/app/domain/contextwindow"
 	"menlo.ai/indigo-api-gateway/app/domain/conversation"
+	"menlo.ai/indigo-api-gateway/app/domain/conversationtitle"
 	"menlo.ai/indigo-api-gateway/app/domain/cron"
+	"menlo.ai/indigo-api-gateway/app/domain/file"
 	"menlo.ai/indigo-api-gateway/app/domain/invite"
+	"menlo.ai/indigo-api-gateway/app/domain/mcp/federatedmcp"
+	"menlo.ai/indigo-api-gateway/app/domain/mcp/mcpactivity"
 	"menlo.ai/indigo-api-gateway/app/domain/mcp/serpermcp"
 	"menlo.ai/indigo-api-gateway/app/domain/model"
 	"menlo.ai/indigo-api-gateway/app/domain/organization"
 	"menlo.ai/indigo-api-gateway/app/domain/project"
 	"menlo.ai/indigo-api-gateway/app/domain/response"
+	"menlo.ai/indigo-api-gateway/app/domain/retention"
+	"menlo.ai/indigo-api-gateway/app/domain/secrets"
 	"menlo.ai/indigo-api-gateway/app/domain/settings"
 	"menlo.ai/indigo-api-gateway/app/domain/user"
+	"menlo.ai/indigo-api-gateway/app/domain/vectorstore"
+	"menlo.ai/indigo-api-gateway/app/domain/webhook"
 	"menlo.ai/indigo-api-gateway/app/domain/workspace"
 	"menlo.ai/indigo-api-gateway/app/infrastructure/cache"
 	"menlo.ai/indigo-api-gateway/app/infrastructure/database"
 	"menlo.ai/indigo-api-gateway/app/infrastructure/database/repository/apikeyrepo"
+	"menlo.ai/indigo-api-gateway/app/infrastructure/database/repository/completioncacherepo"
 	"menlo.ai/indigo-api-gateway/app/infrastructure/database/repository/conversationrepo"
+	"menlo.ai/indigo-api-gateway/app/infrastructure/database/repository/cronrepo"
+	"menlo.ai/indigo-api-gateway/app/infrastructure/database/repository/filerepo"
 	"menlo.ai/indigo-api-gateway/app/infrastructure/database/repository/inviterepo"
 	"menlo.ai/indigo-api-gateway/app/infrastructure/database/repository/itemrepo"
+	"menlo.ai/indigo-api-gateway/app/infrastructure/database/repository/mcprepo"
 	"menlo.ai/indigo-api-gateway/app/infrastructure/database/repository/modelrepo"
 	"menlo.ai/indigo-api-gateway/app/infrastructure/database/repository/organizationrepo"
 	"menlo.ai/indigo-api-gateway/app/infrastructure/database/repository/projectrepo"
 	"menlo.ai/indigo-api-gateway/app/infrastructure/database/repository/responserepo"
+	"menlo.ai/indigo-api-gateway/app/infrastructure/database/repository/retentionrepo"
+	"menlo.ai/indigo-api-gateway/app/infrastructure/database/repository/secretrepo"
 	"menlo.ai/indigo-api-gateway/app/infrastructure/database/repository/settingsrepo"
 	"menlo.ai/indigo-api-gateway/app/infrastructure/database/repository/transaction"
 	"menlo.ai/indigo-api-gateway/app/infrastructure/database/repository/userrepo"
+	"menlo.ai/indigo-api-gateway/app/infrastructure/database/repository/vectorstorerepo"
+	"menlo.ai/indigo-api-gateway/app/infrastructure/database/repository/webhookrepo"
 	"menlo.ai/indigo-api-gateway/app/infrastructure/database/repository/workspacerepo"
 	"menlo.ai/indigo-api-gateway/app/infrastructure/inference"
+	"menlo.ai/indigo-api-gateway/app/infrastructure/storage"
 	"menlo.ai/indigo-api-gateway/app/interfaces/http"
 	"menlo.ai/indigo-api-gateway/app/interfaces/http/routes/v1"
 	auth2 "menlo.ai/indigo-api-gateway/app/interfaces/http/routes/v1/auth"
@@ -43,6 +62,7 @@ import (
 	"menlo.ai/indigo-api-gateway/app/interfaces/http/routes/v1/chat"
 	"menlo.ai/indigo-api-gateway/app/interfaces/http/routes/v1/conv"
 	"menlo.ai/indigo-api-gateway/app/interfaces/http/routes/v1/conversations"
+	"menlo.ai/indigo-api-gateway/app/interfaces/http/routes/v1/files"
 	"menlo.ai/indigo-api-gateway/app/interfaces/http/routes/v1/mcp"
 	"menlo.ai/indigo-api-gateway/app/interfaces/http/routes/v1/mcp/mcp_impl"
 	"menlo.ai/indigo-api-gateway/app/interfaces/http/routes/v1/model"
@@ -50,7 +70,9 @@ import (
 	"menlo.ai/indigo-api-gateway/app/interfaces/http/routes/v1/organization/invites"
 	"menlo.ai/indigo-api-gateway/app/interfaces/http/routes/v1/organization/projects"
 	"menlo.ai/indigo-api-gateway/app/interfaces/http/routes/v1/organization/projects/api_keys"
+	"menlo.ai/indigo-api-gateway/app/interfaces/http/routes/v1/organization/projects/webhooks"
 	"menlo.ai/indigo-api-gateway/app/interfaces/http/routes/v1/responses"
+	"menlo.ai/indigo-api-gateway/app/interfaces/http/routes/v1/vectorstores"
 )
 
 import (
@@ -87,47 +109,86 @@ func CreateApplication() (*Application, error) {
 	modelCatalogService := model.NewModelCatalogService(modelCatalogRepository)
 	providerRegistryService := model.NewProviderRegistryService(providerRepository, providerModelService, modelCatalogService)
 	inferenceProvider := inference.NewInferenceProvider()
-	projectsRoute := projects.NewProjectsRoute(projectService, apiKeyService, authService, projectApiKeyRoute, providerRegistryService, inferenceProvider)
+	completionCacheSettingsRepository := completioncacherepo.NewCompletionCacheSettingsRepository(transactionDatabase)
+	completionCacheService := completioncache.NewCompletionCacheService(completionCacheSettingsRepository, redisCacheService, providerRegistryService, inferenceProvider)
+	endpointRepository := webhookrepo.NewWebhookEndpointRepository(transactionDatabase)
+	deliveryRepository := webhookrepo.NewWebhookDeliveryRepository(transactionDatabase)
+	webhookService := webhook.NewWebhookService(endpointRepository, deliveryRepository)
+	projectWebhookRoute := webhooks.NewProjectWebhookRoute(authService, webhookService)
+	projectsRoute := projects.NewProjectsRoute(projectService, apiKeyService, authService, projectApiKeyRoute, projectWebhookRoute, providerRegistryService, inferenceProvider, completionCacheService)
 	invitesRoute := invites.NewInvitesRoute(inviteService, projectService, organizationService, authService)
 	settingRepository := settingsrepo.NewSettingRepository(transactionDatabase)
 	auditRepository := settingsrepo.NewAuditRepository(transactionDatabase)
 	settingsService := settings.NewService(settingRepository)
 	auditService := settings.NewAuditService(auditRepository)
 	modelProviderRoute := organization2.NewModelProviderRoute(authService, providerRegistryService, inferenceProvider, projectService, auditService)
-	organizationRoute := organization2.NewOrganizationRoute(adminApiKeyAPI, projectsRoute, invitesRoute, modelProviderRoute, authService, organizationService, projectService, inviteService, providerRegistryService, userService, settingsService, auditService)
-	completionAPI := chat.NewCompletionAPI(inferenceProvider, providerRegistryService)
+	mcpServerRepository := mcprepo.NewMCPServerRepository(transactionDatabase)
+	mcpToolAllowlistRepository := mcprepo.NewMCPToolAllowlistRepository(transactionDatabase)
+	federatedMCPService := federatedmcp.NewFederatedMCPService(mcpServerRepository, mcpToolAllowlistRepository)
+	mcpActivityRepository := mcprepo.NewMCPActivityRepository(transactionDatabase)
+	activityService := mcpactivity.NewActivityService(mcpActivityRepository)
+	retentionRepository := retentionrepo.NewRetentionRepository(transactionDatabase)
+	retentionService := retention.NewRetentionService(retentionRepository, organizationService, settingsService)
+	cronJobRunRepository := cronrepo.NewCronJobRunRepository(transactionDatabase)
+	cronService := cron.NewCronService(cronJobRunRepository, settingsService, retentionService, activityService, redisCacheService)
+	cronJobRoute := organization2.NewCronJobRoute(authService, cronService, auditService)
+	mcpServerRoute := organization2.NewMCPServerRoute(authService, federatedMCPService, projectService, auditService)
+	organizationRoute := organization2.NewOrganizationRoute(adminApiKeyAPI, projectsRoute, invitesRoute, modelProviderRoute, mcpServerRoute, cronJobRoute, authService, organizationService, projectService, inviteService, providerRegistryService, userService, settingsService, auditService)
+	serperService := serpermcp.NewSerperService(settingsService, redisCacheService)
+	serperMCP := mcpimpl.NewSerperMCP(serperService)
+	federatedMCP := mcpimpl.NewFederatedMCP(serperMCP, federatedMCPService)
+	completionAPI := chat.NewCompletionAPI(inferenceProvider, providerRegistryService, authService, federatedMCP, completionCacheService)
 	chatRoute := chat.NewChatRoute(completionAPI)
 	conversationRepository := conversationrepo.NewConversationGormRepository(transactionDatabase)
 	itemRepository := itemrepo.NewItemGormRepository(transactionDatabase)
-	conversationService := conversation.NewService(conversationRepository, itemRepository)
+	workspaceRepository := workspacerepo.NewWorkspaceGormRepository(transactionDatabase)
+	workspaceService := workspace.NewWorkspaceService(workspaceRepository, conversationRepository, organizationService)
+	conversationService := conversation.NewService(conversationRepository, itemRepository, workspaceService)
 	completionNonStreamHandler := conv.NewCompletionNonStreamHandler(inferenceProvider, conversationService)
 	completionStreamHandler := conv.NewCompletionStreamHandler(inferenceProvider, conversationService)
-	convCompletionAPI := conv.NewConvCompletionAPI(completionNonStreamHandler, completionStreamHandler, conversationService, authService, projectService, providerRegistryService, providerModelService, inferenceProvider)
-	serperService := serpermcp.NewSerperService()
-	serperMCP := mcpimpl.NewSerperMCP(serperService)
+	contextWindowService := contextwindow.NewContextWindowService(providerModelService, modelCatalogService, providerRegistryService, inferenceProvider, conversationService, workspaceService)
+	titleService := conversationtitle.NewTitleService(settingsService, providerRegistryService, inferenceProvider, conversationService)
+	convCompletionAPI := conv.NewConvCompletionAPI(completionNonStreamHandler, completionStreamHandler, conversationService, authService, projectService, providerRegistryService, providerModelService, inferenceProvider, contextWindowService, titleService)
 	convMCPAPI := conv.NewConvMCPAPI(authService, serperMCP)
 	convChatRoute := conv.NewConvChatRoute(authService, convCompletionAPI, convMCPAPI)
-	workspaceRepository := workspacerepo.NewWorkspaceGormRepository(transactionDatabase)
-	workspaceService := workspace.NewWorkspaceService(workspaceRepository, conversationRepository)
-	workspaceRoute := conv.NewWorkspaceRoute(authService, workspaceService)
+	workspaceRoute := conv.NewWorkspaceRoute(authService, workspaceService, userService)
 	conversationAPI := conversations.NewConversationAPI(conversationService, authService, workspaceService)
 	modelAPI := modelroute.NewModelAPI(inferenceProvider, authService, projectService, providerRegistryService, providerModelService)
 	providersAPI := modelroute.NewProvidersAPI(authService, projectService, providerRegistryService)
-	mcpapi := mcp.NewMCPAPI(serperMCP, authService)
+	mcpapi := mcp.NewMCPAPI(federatedMCP, authService, activityService, projectService)
 	googleAuthAPI := google.NewGoogleAuthAPI(userService, authService)
 	authRoute := auth2.NewAuthRoute(googleAuthAPI, userService, authService)
 	responseRepository := responserepo.NewResponseGormRepository(transactionDatabase)
-	responseService := response.NewResponseService(responseRepository, itemRepository, conversationService)
-	responseModelService := response.NewResponseModelService(userService, authService, apiKeyService, conversationService, responseService, inferenceProvider, providerRegistryService)
+	responseService := response.NewResponseService(responseRepository, itemRepository, conversationService, webhookService)
+	responseJobRepository := responserepo.NewResponseJobRepository(transactionDatabase)
+	cancellationRegistry := response.NewCancellationRegistry()
+	fileRepository := filerepo.NewFileRepository(transactionDatabase)
+	blobStorage := storage.NewBlobStorage()
+	fileService := file.NewFileService(fileRepository, blobStorage)
+	vectorStoreRepository := vectorstorerepo.NewVectorStoreRepository(transactionDatabase)
+	vectorStoreFileRepository := vectorstorerepo.NewVectorStoreFileRepository(transactionDatabase)
+	chunkRepository := vectorstorerepo.NewChunkRepository(transactionDatabase)
+	vectorStoreService := vectorstore.NewVectorStoreService(vectorStoreRepository, vectorStoreFileRepository, chunkRepository, fileService, providerRegistryService, inferenceProvider)
+	toolExecutor := response.NewToolExecutor(serperService, vectorStoreService)
+	streamBuffer := response.NewStreamBuffer(redisCacheService)
+	responseModelService := response.NewResponseModelService(userService, authService, apiKeyService, conversationService, responseService, inferenceProvider, providerRegistryService, contextWindowService, responseJobRepository, cancellationRegistry, toolExecutor, fileService, vectorStoreService, streamBuffer)
 	streamModelService := response.NewStreamModelService(responseModelService)
 	nonStreamModelService := response.NewNonStreamModelService(responseModelService)
 	responseRoute := responses.NewResponseRoute(responseModelService, authService, responseService, streamModelService, nonStreamModelService)
-	v1Route := v1.NewV1Route(organizationRoute, chatRoute, convChatRoute, workspaceRoute, conversationAPI, modelAPI, providersAPI, mcpapi, authRoute, responseRoute)
+	filesRoute := files.NewFilesRoute(authService, fileService, vectorStoreService)
+	vectorStoresRoute := vectorstores.NewVectorStoresRoute(authService, vectorStoreService)
+	v1Route := v1.NewV1Route(organizationRoute, chatRoute, convChatRoute, workspaceRoute, conversationAPI, modelAPI, providersAPI, mcpapi, authRoute, responseRoute, filesRoute, vectorStoresRoute)
 	httpServer := http.NewHttpServer(v1Route)
-	cronService := cron.NewCronService()
+	responseWorker := response.NewResponseWorker(responseJobRepository, responseService, conversationService, providerRegistryService, nonStreamModelService)
+	webhookDispatcher := webhook.NewWebhookDispatcher(webhookService)
+	vectorStoreIndexer := vectorstore.NewVectorStoreIndexer(vectorStoreService)
 	application := &Application{
-		HttpServer:  httpServer,
-		CronService: cronService,
+		HttpServer:         httpServer,
+		CronService:        cronService,
+		ResponseService:    responseService,
+		ResponseWorker:     responseWorker,
+		WebhookDispatcher:  webhookDispatcher,
+		VectorStoreIndexer: vectorStoreIndexer,
 	}
 	return application, nil
 }
@@ -166,6 +227,17 @@ func CreateDataInitializer() (*DataInitializer, error) {
 	return dataInitializer, nil
 }
 
+func CreateSecretRotation() (*secrets.RotationService, error) {
+	db, err := database.NewDB()
+	if err != nil {
+		return nil, err
+	}
+	transactionDatabase := transaction.NewDatabase(db)
+	encryptedSecretRepository := secretrepo.NewEncryptedSecretRepository(transactionDatabase)
+	rotationService := secrets.NewRotationService(encryptedSecretRepository)
+	return rotationService, nil
+}
+
 // wire.go:
 
 func ProvideDatabase() *gorm.DB {
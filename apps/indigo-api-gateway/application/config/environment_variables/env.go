@@ -1,28 +1,22 @@
 package environment_variables
 
-import (
-	"os"
-	"reflect"
-	"strconv"
-	"strings"
-	"time"
-
-	"menlo.ai/indigo-api-gateway/app/utils/logger"
-	"menlo.ai/indigo-api-gateway/config"
-)
+import "sync/atomic"
 
+// EnvironmentVariable is the configuration of the gateway, read from the environment and from the YAML file of
+// CONFIG_FILE, the environment winning over the file. Keys tagged required must be set; static keys are only
+// read at startup, a reload keeps their value and reports the change.
 type EnvironmentVariable struct {
-	JAN_INFERENCE_MODEL_URL     string
-	JAN_INFERENCE_SETUP         bool
-	SERPER_API_KEY              string
-	JWT_SECRET                  []byte
-	OAUTH2_GOOGLE_CLIENT_ID     string
-	OAUTH2_GOOGLE_CLIENT_SECRET string
-	OAUTH2_GOOGLE_REDIRECT_URL  string
-	DB_POSTGRESQL_WRITE_DSN     string
-	DB_POSTGRESQL_READ1_DSN     string
-	APIKEY_SECRET               string
-	MODEL_PROVIDER_SECRET       string
+	JAN_INFERENCE_MODEL_URL     string `config:"static"`
+	JAN_INFERENCE_SETUP         bool   `config:"static"`
+	SERPER_API_KEY              string `config:"static"`
+	JWT_SECRET                  []byte `config:"required,static"`
+	OAUTH2_GOOGLE_CLIENT_ID     string `config:"static"`
+	OAUTH2_GOOGLE_CLIENT_SECRET string `config:"static"`
+	OAUTH2_GOOGLE_REDIRECT_URL  string `config:"static"`
+	DB_POSTGRESQL_WRITE_DSN     string `config:"required,static"`
+	DB_POSTGRESQL_READ1_DSN     string `config:"static"`
+	APIKEY_SECRET               string `config:"required,static"`
+	MODEL_PROVIDER_SECRET       string `config:"static"`
 	ALLOWED_CORS_HOSTS          []string
 	SMTP_HOST                   string
 	SMTP_PORT                   int
@@ -33,55 +27,55 @@ type EnvironmentVariable struct {
 	ORGANIZATION_ADMIN_EMAILS   []string
 	LOCAL_ADMIN_PASSWORD        string
 	// Redis configuration
-	REDIS_URL      string
-	REDIS_PASSWORD string
-	REDIS_DB       int
+	REDIS_URL      string `config:"required,static"`
+	REDIS_PASSWORD string `config:"static"`
+	REDIS_DB       int    `config:"static"`
+	// Context window management
+	CONTEXT_SUMMARY_MODEL string
+	// Background responses
+	RESPONSE_WORKER_CONCURRENCY int `config:"static"`
+	WEBHOOK_MAX_ATTEMPTS        int
+	// File storage
+	FILE_STORAGE_DRIVER        string `config:"static"`
+	FILE_STORAGE_LOCAL_DIR     string `config:"static"`
+	FILE_STORAGE_S3_ENDPOINT   string `config:"static"`
+	FILE_STORAGE_S3_REGION     string `config:"static"`
+	FILE_STORAGE_S3_BUCKET     string `config:"static"`
+	FILE_STORAGE_S3_ACCESS_KEY string `config:"static"`
+	FILE_STORAGE_S3_SECRET_KEY string `config:"static"`
+	FILE_MAX_UPLOAD_BYTES      int
+
+	VECTOR_STORE_EMBEDDING_MODEL string
+	// Structured outputs
+	STRUCTURED_OUTPUT_REPAIR_ATTEMPTS int
+	// MCP
+	MCP_ACTIVITY_RETENTION_DAYS int
+	// Cron
+	CRON_RUN_RETENTION_DAYS int
+	// Webpage fetching
+	WEBPAGE_FETCHER           string `config:"static"`
+	WEBPAGE_CACHE_TTL_MINUTES int    `config:"static"`
+	// Request logging
+	LOG_REDACT_HEADERS   []string
+	LOG_REDACT_FIELDS    []string
+	LOG_MAX_BODY_BYTES   int
+	LOG_OMIT_BODY_ROUTES []string
+	LOG_SAMPLE_RATE      string
+	// Secret encryption, see crypto.DefaultKeyring
+	SECRET_KEYS_FILE                string   `config:"static"`
+	MODEL_PROVIDER_PREVIOUS_SECRETS []string `config:"static"`
+	// Graceful shutdown
+	SHUTDOWN_READINESS_DELAY_SECONDS int
+	SHUTDOWN_DRAIN_TIMEOUT_SECONDS   int
 }
 
-func (ev *EnvironmentVariable) LoadFromEnv() {
-	v := reflect.ValueOf(ev).Elem()
-	t := v.Type()
-	for i := 0; i < v.NumField(); i++ {
-		field := t.Field(i)
-		envKey := field.Name
-		envValue := os.Getenv(envKey)
-		if envValue == "" {
-			logger.GetLogger().Warnf("Missing SYSENV: %s", envKey)
-		}
-		if envValue != "" {
-			switch v.Field(i).Kind() {
-			case reflect.String:
-				v.Field(i).SetString(envValue)
-			case reflect.Int:
-				intV, err := strconv.Atoi(envValue)
-				if err != nil {
-					logger.GetLogger().Errorf("Invalid int value for %s: %s", envKey, envValue)
-				} else {
-					v.Field(i).SetInt(int64(intV))
-				}
-			case reflect.Bool:
-				boolVal, err := strconv.ParseBool(envValue)
-				if err != nil {
-					logger.GetLogger().Errorf("Invalid boolean value for %s: %s", envKey, envValue)
-				} else {
-					v.Field(i).SetBool(boolVal)
-				}
-			case reflect.Slice:
-				if v.Field(i).Type().Elem().Kind() == reflect.Uint8 {
-					v.Field(i).SetBytes([]byte(envValue))
-				} else if v.Field(i).Type().Elem().Kind() == reflect.String {
-					entries := strings.Split(envValue, ",")
-					v.Field(i).Set(reflect.ValueOf(entries))
-				} else {
-					logger.GetLogger().Errorf("Unsupported slice type for %s", field.Name)
-				}
-			default:
-				logger.GetLogger().Errorf("Unsupported field type: %s", field.Name)
-			}
-		}
-	}
-	config.EnvReloadedAt = time.Now()
+var current atomic.Pointer[EnvironmentVariable]
+
+func init() {
+	current.Store(&EnvironmentVariable{})
 }
 
-// Singleton
-var EnvironmentVariables = EnvironmentVariable{}
+// Current returns the configuration in use. It is shared and must not be modified, see Override.
+func Current() *EnvironmentVariable {
+	return current.Load()
+}
@@ -0,0 +1,226 @@
+package environment_variables
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// ConfigFileEnv names the environment variable with the path of the YAML configuration file
+	ConfigFileEnv = "CONFIG_FILE"
+	// OverrideSMTP is the override of the SMTP settings stored in the database
+	OverrideSMTP = "smtp"
+)
+
+var (
+	// mu serializes the writers of the configuration, readers go through Current
+	mu sync.Mutex
+	// loaded is the configuration read from the sources, before the overrides
+	loaded     = &EnvironmentVariable{}
+	overrides  = map[string]func(*EnvironmentVariable){}
+	reloadedAt atomic.Pointer[time.Time]
+)
+
+// ReloadResult records what a reload changed
+type ReloadResult struct {
+	// Changed lists the keys whose new value is in use
+	Changed []string
+	// Ignored lists the static keys whose value changed in the sources but not in the running server
+	Ignored []string
+}
+
+// Load reads the configuration and installs it, even when it is invalid so tools that need a few keys can run;
+// the returned error lists the invalid and missing required keys
+func Load() error {
+	mu.Lock()
+	defer mu.Unlock()
+	next, err := read()
+	loaded = next
+	install()
+	return err
+}
+
+// Reload reads the configuration again and swaps it in at once. An invalid configuration is rejected and the
+// running one kept; static keys keep their running value.
+func Reload() (*ReloadResult, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	next, err := read()
+	if err != nil {
+		return nil, err
+	}
+	result := &ReloadResult{}
+	previous := reflect.ValueOf(loaded).Elem()
+	value := reflect.ValueOf(next).Elem()
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		if reflect.DeepEqual(previous.Field(i).Interface(), value.Field(i).Interface()) {
+			continue
+		}
+		if hasOption(field, "static") {
+			value.Field(i).Set(previous.Field(i))
+			result.Ignored = append(result.Ignored, field.Name)
+			continue
+		}
+		result.Changed = append(result.Changed, field.Name)
+	}
+	loaded = next
+	install()
+	return result, nil
+}
+
+// Override changes the running configuration with a value that does not come from its sources, such as
+// settings stored in the database; the override named name replaces the previous one and outlives reloads
+func Override(name string, apply func(*EnvironmentVariable)) {
+	mu.Lock()
+	defer mu.Unlock()
+	overrides[name] = apply
+	install()
+}
+
+// ReloadedAt returns when the configuration was last installed
+func ReloadedAt() time.Time {
+	if at := reloadedAt.Load(); at != nil {
+		return *at
+	}
+	return time.Time{}
+}
+
+// install publishes a copy of the loaded configuration with the overrides applied
+func install() {
+	next := *loaded
+	names := make([]string, 0, len(overrides))
+	for name := range overrides {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		overrides[name](&next)
+	}
+	current.Store(&next)
+	now := time.Now()
+	reloadedAt.Store(&now)
+}
+
+// read parses the configuration file, then the environment, and validates the result
+func read() (*EnvironmentVariable, error) {
+	var errs []error
+	values := map[string]any{}
+	if path := strings.TrimSpace(os.Getenv(ConfigFileEnv)); path != "" {
+		fileValues, err := readFile(path)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		for key, value := range fileValues {
+			values[key] = value
+		}
+	}
+
+	next := &EnvironmentVariable{}
+	v := reflect.ValueOf(next).Elem()
+	t := v.Type()
+	for i := 0; i < v.NumField(); i++ {
+		field := t.Field(i)
+		raw, ok := values[field.Name]
+		delete(values, field.Name)
+		if envValue := os.Getenv(field.Name); envValue != "" {
+			raw, ok = envValue, true
+		}
+		if ok {
+			if err := setField(v.Field(i), raw); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", field.Name, err))
+				continue
+			}
+		}
+		if hasOption(field, "required") && v.Field(i).IsZero() {
+			errs = append(errs, fmt.Errorf("%s is required", field.Name))
+		}
+	}
+	for key := range values {
+		errs = append(errs, fmt.Errorf("%s: unknown key in %s", key, os.Getenv(ConfigFileEnv)))
+	}
+	if len(errs) > 0 {
+		return next, fmt.Errorf("invalid configuration: %w", errors.Join(errs...))
+	}
+	return next, nil
+}
+
+// readFile reads the top-level keys of the YAML file, keys are matched case-insensitively
+func readFile(path string) (map[string]any, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ConfigFileEnv, err)
+	}
+	var document map[string]any
+	if err := yaml.Unmarshal(content, &document); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	values := make(map[string]any, len(document))
+	for key, value := range document {
+		if value != nil {
+			values[strings.ToUpper(key)] = value
+		}
+	}
+	return values, nil
+}
+
+// setField assigns an environment string or a YAML value, lists being comma-separated in the environment
+func setField(field reflect.Value, raw any) error {
+	if items, ok := raw.([]any); ok {
+		if field.Kind() != reflect.Slice || field.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("a list is not valid for a %s", field.Type())
+		}
+		entries := make([]string, 0, len(items))
+		for _, item := range items {
+			entries = append(entries, fmt.Sprint(item))
+		}
+		field.Set(reflect.ValueOf(entries))
+		return nil
+	}
+	if _, ok := raw.(map[string]any); ok {
+		return fmt.Errorf("a map is not valid for a %s", field.Type())
+	}
+	value := fmt.Sprint(raw)
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Int:
+		intV, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid int value %q", value)
+		}
+		field.SetInt(int64(intV))
+	case reflect.Bool:
+		boolVal, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid boolean value %q", value)
+		}
+		field.SetBool(boolVal)
+	case reflect.Slice:
+		switch field.Type().Elem().Kind() {
+		case reflect.Uint8:
+			field.SetBytes([]byte(value))
+		case reflect.String:
+			field.Set(reflect.ValueOf(strings.Split(value, ",")))
+		default:
+			return fmt.Errorf("unsupported slice type %s", field.Type())
+		}
+	default:
+		return fmt.Errorf("unsupported field type %s", field.Type())
+	}
+	return nil
+}
+
+func hasOption(field reflect.StructField, option string) bool {
+	return slices.Contains(strings.Split(field.Tag.Get("config"), ","), option)
+}
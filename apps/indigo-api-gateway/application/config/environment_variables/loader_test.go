@@ -0,0 +1,100 @@
+package environment_variables
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func writeConfig(t *testing.T, path string, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+}
+
+func TestLoadReportsMissingAndInvalidKeys(t *testing.T) {
+	t.Setenv(ConfigFileEnv, "")
+	t.Setenv("DB_POSTGRESQL_WRITE_DSN", "")
+	t.Setenv("JWT_SECRET", "secret")
+	t.Setenv("APIKEY_SECRET", "secret")
+	t.Setenv("REDIS_URL", "redis://localhost:6379")
+	t.Setenv("SMTP_PORT", "abc")
+
+	err := Load()
+	if err == nil {
+		t.Fatal("expected a validation error")
+	}
+	for _, expected := range []string{"DB_POSTGRESQL_WRITE_DSN is required", `SMTP_PORT: invalid int value "abc"`} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Fatalf("expected %q in %q", expected, err.Error())
+		}
+	}
+}
+
+func TestReloadSwapsReloadableKeysOnly(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	writeConfig(t, path, `
+db_postgresql_write_dsn: postgres://first
+jwt_secret: secret
+apikey_secret: secret
+redis_url: redis://localhost:6379
+allowed_cors_hosts: [http://a.example]
+log_max_body_bytes: 100
+`)
+	t.Setenv(ConfigFileEnv, path)
+	t.Setenv("DB_POSTGRESQL_WRITE_DSN", "")
+	t.Setenv("JWT_SECRET", "")
+	t.Setenv("APIKEY_SECRET", "")
+	t.Setenv("REDIS_URL", "")
+	t.Setenv("ALLOWED_CORS_HOSTS", "")
+	t.Setenv("LOG_MAX_BODY_BYTES", "200")
+	t.Setenv("SMTP_PORT", "")
+	if err := Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	Override("test", func(env *EnvironmentVariable) {
+		env.SMTP_HOST = "smtp.example"
+	})
+	t.Cleanup(func() {
+		mu.Lock()
+		delete(overrides, "test")
+		mu.Unlock()
+	})
+	before := Current()
+	if before.LOG_MAX_BODY_BYTES != 200 {
+		t.Fatalf("expected the environment to win over the file, got %d", before.LOG_MAX_BODY_BYTES)
+	}
+
+	writeConfig(t, path, `
+db_postgresql_write_dsn: postgres://second
+jwt_secret: secret
+apikey_secret: secret
+redis_url: redis://localhost:6379
+allowed_cors_hosts: [http://a.example, http://b.example]
+`)
+	result, err := Reload()
+	if err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	if !slices.Equal(result.Changed, []string{"ALLOWED_CORS_HOSTS"}) || !slices.Equal(result.Ignored, []string{"DB_POSTGRESQL_WRITE_DSN"}) {
+		t.Fatalf("unexpected reload result %+v", result)
+	}
+	after := Current()
+	if after.DB_POSTGRESQL_WRITE_DSN != "postgres://first" || len(after.ALLOWED_CORS_HOSTS) != 2 || after.SMTP_HOST != "smtp.example" {
+		t.Fatalf("unexpected configuration after reload %+v", after)
+	}
+	if len(before.ALLOWED_CORS_HOSTS) != 1 {
+		t.Fatal("reload modified the previous configuration")
+	}
+
+	writeConfig(t, path, "jwt_secret: [a, b]\n")
+	if _, err := Reload(); err == nil {
+		t.Fatal("expected the invalid configuration to be rejected")
+	}
+	if Current() != after {
+		t.Fatal("rejected reload replaced the configuration")
+	}
+}
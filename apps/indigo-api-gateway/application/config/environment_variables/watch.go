@@ -0,0 +1,78 @@
+package environment_variables
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"menlo.ai/indigo-api-gateway/app/utils/logger"
+)
+
+// fileCheckInterval is how often the configuration file is compared with the loaded one; the content is
+// compared rather than watched so the symlink swaps of mounted ConfigMaps are seen
+const fileCheckInterval = 10 * time.Second
+
+// Watch reloads the configuration on SIGHUP and when the configuration file changes, until ctx is done
+func Watch(ctx context.Context) {
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	go func() {
+		defer signal.Stop(hangup)
+		path := strings.TrimSpace(os.Getenv(ConfigFileEnv))
+		content := readContent(path)
+		ticker := time.NewTicker(fileCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-hangup:
+				content = readContent(path)
+				reload("SIGHUP")
+			case <-ticker.C:
+				if path == "" {
+					continue
+				}
+				next := readContent(path)
+				if next == nil || bytes.Equal(next, content) {
+					continue
+				}
+				content = next
+				reload("file change")
+			}
+		}
+	}()
+}
+
+func reload(trigger string) {
+	log := logger.GetLogger()
+	result, err := Reload()
+	if err != nil {
+		log.Errorf("configuration reload on %s rejected, keeping the running configuration: %v", trigger, err)
+		return
+	}
+	if len(result.Ignored) > 0 {
+		log.Errorf("configuration reload on %s: %s only change on restart, keeping the running values",
+			trigger, strings.Join(result.Ignored, ", "))
+	}
+	if len(result.Changed) == 0 {
+		log.Infof("configuration reloaded on %s, no change", trigger)
+		return
+	}
+	log.WithField("changed_keys", result.Changed).Infof("configuration reloaded on %s", trigger)
+}
+
+func readContent(path string) []byte {
+	if path == "" {
+		return nil
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	return content
+}
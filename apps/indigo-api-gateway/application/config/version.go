@@ -1,12 +1,8 @@
 package config
 
-import (
-	"strings"
-	"time"
-)
+import "strings"
 
 var Version = "dev"
-var EnvReloadedAt = time.Now()
 
 func IsDev() bool {
 	return strings.HasPrefix(Version, "dev")